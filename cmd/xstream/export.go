@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio" // 带缓冲的I/O包，用于写入导出文件
+	"flag"  // 命令行参数解析包，用于解析子命令的参数
+	"io"    // I/O接口包，用于判断存储后端是否需要关闭
+	"log"   // 日志包，用于输出结果和错误
+	"os"    // 操作系统功能包，用于读写快照文件和退出
+
+	"github.com/MGter/xStreamTool_go/internal/config" // 配置管理：读取数据库连接配置
+)
+
+// runExport 执行 store export 子命令
+// 用法：xstream store export --type sqlite [--path data/xstreamtool.db] [--config other.json] [--out snapshot.json]
+// 把存储中的全部数据（包括ID、时间戳和附属数据）导出为快照JSON，不指定 --out 时写到标准输出
+func runExport(args []string) {
+	fs := flag.NewFlagSet("store export", flag.ExitOnError)
+	storeType := fs.String("type", "", "存储类型，默认使用配置文件中的类型，memory-json 等同于 file")
+	path := fs.String("path", "", "数据文件路径（file、sqlite）")
+	configPath := fs.String("config", "", "读取存储连接配置的配置文件，默认使用 config.json")
+	out := fs.String("out", "", "快照文件路径，默认写到标准输出")
+	fs.Parse(args)
+
+	base := config.LoadConfig()
+	if *storeType == "" {
+		*storeType = base.Database.Type
+	}
+	s := openMigrateStore("源", base, *storeType, *path, *configPath)
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if *out == "" {
+		if err := s.Export(os.Stdout); err != nil {
+			log.Fatalf("❌ 导出失败: %v", err)
+		}
+		return
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("❌ 创建快照文件失败: %v", err)
+	}
+	defer file.Close()
+	buffered := bufio.NewWriter(file)
+	if err := s.Export(buffered); err != nil {
+		log.Fatalf("❌ 导出失败: %v", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Fatalf("❌ 写入快照文件失败: %v", err)
+	}
+	log.Printf("💾 已把 %s 存储的数据导出到 %s", *storeType, *out)
+}
+
+// runImport 执行 store import 子命令
+// 用法：xstream store import --type sqlite [--path data/xstreamtool.db] [--config other.json] [--in snapshot.json] [--replace]
+// 用 store export 或备份得到的快照JSON替换存储中的全部数据，不指定 --in 时从标准输入读取；
+// 存储中已有待办事项时需要 --replace 才会覆盖
+func runImport(args []string) {
+	fs := flag.NewFlagSet("store import", flag.ExitOnError)
+	storeType := fs.String("type", "", "存储类型，默认使用配置文件中的类型，memory-json 等同于 file")
+	path := fs.String("path", "", "数据文件路径（file、sqlite）")
+	configPath := fs.String("config", "", "读取存储连接配置的配置文件，默认使用 config.json")
+	in := fs.String("in", "", "快照文件路径，默认从标准输入读取")
+	replace := fs.Bool("replace", false, "存储中已有数据时删除后再导入")
+	fs.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("❌ 读取快照文件失败: %v", err)
+		}
+		defer file.Close()
+		r = bufio.NewReader(file)
+	}
+
+	base := config.LoadConfig()
+	if *storeType == "" {
+		*storeType = base.Database.Type
+	}
+	s := openMigrateStore("目标", base, *storeType, *path, *configPath)
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	existing, err := s.GetAllTodos()
+	if err != nil {
+		log.Fatalf("❌ 读取存储失败: %v", err)
+	}
+	if len(existing) > 0 && !*replace {
+		log.Printf("❌ 存储中已有 %d 条待办事项，使用 --replace 删除后导入", len(existing))
+		if closer, ok := s.(io.Closer); ok {
+			closer.Close()
+		}
+		os.Exit(1)
+	}
+
+	if err := s.Import(r); err != nil {
+		log.Fatalf("❌ 导入失败: %v", err)
+	}
+	todos, err := s.GetAllTodos()
+	if err != nil {
+		log.Fatalf("❌ 读取导入后的数据失败: %v", err)
+	}
+	log.Printf("✅ 已导入 %d 条待办事项到 %s 存储", len(todos), *storeType)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json" // JSON编解码包，用于读取指定的配置文件和输出JSON格式的报告
+	"flag"          // 命令行参数解析包，用于解析子命令的参数
+	"io"            // I/O接口包，用于判断存储后端是否需要关闭
+	"log"           // 日志包，用于输出检查结果和错误
+	"os"            // 操作系统功能包，用于读取配置文件和设置退出码
+
+	"github.com/MGter/xStreamTool_go/internal/config" // 配置管理：读取数据库连接配置
+	"github.com/MGter/xStreamTool_go/internal/fsck"   // 数据一致性检查
+	"github.com/MGter/xStreamTool_go/internal/models" // 数据模型：检查报告格式定义
+	"github.com/MGter/xStreamTool_go/internal/store"  // 数据存储层：存储后端
+)
+
+// runFsck 执行 fsck 子命令
+// 用法：xstream fsck [--type sqlite] [--path data/xstreamtool.db] [--config other.json] [--repair] [--json]
+// 检查存储中数据之间的引用是否一致，--repair 时修复可以自动修复的问题。
+// 连接配置默认取自当前目录的 config.json 中的 database 部分；仍有未修复的问题时以退出码1退出，便于在脚本中使用
+func runFsck(args []string) {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	storeType := fs.String("type", "", "存储类型，默认使用配置文件中的类型")
+	path := fs.String("path", "", "数据文件路径（file、sqlite）")
+	configPath := fs.String("config", "", "读取存储连接配置的配置文件，默认使用 config.json")
+	repair := fs.Bool("repair", false, "修复可以自动修复的问题")
+	asJSON := fs.Bool("json", false, "以JSON格式输出报告")
+	fs.Parse(args)
+
+	cfg := config.LoadConfig()
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			log.Fatalf("❌ 读取配置文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			log.Fatalf("❌ 解析配置文件失败: %v", err)
+		}
+	}
+	dbConfig := cfg.Database
+	if *storeType != "" {
+		dbConfig.Type = *storeType
+	}
+	if alias, exists := storeAliases[dbConfig.Type]; exists {
+		dbConfig.Type = alias
+	}
+	if *path != "" {
+		dbConfig.Path = *path
+	}
+	if dbConfig.Path != "" && (dbConfig.Type == "file" || dbConfig.Type == "sqlite") {
+		// 数据文件不存在时会被创建，检查的结果没有意义
+		if _, err := os.Stat(dbConfig.Path); err != nil {
+			log.Fatalf("❌ 数据文件不可用: %v", err)
+		}
+	}
+
+	s, err := store.NewStore(&dbConfig)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var report *models.FsckReport
+	if *repair {
+		report, err = fsck.Repair(s)
+	} else {
+		report, err = fsck.Check(s)
+	}
+	if err != nil {
+		log.Fatalf("❌ 检查失败: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		log.Printf("🔍 检查了 %d 个待办事项、%d 条附属数据", report.Todos, report.Meta)
+		for _, issue := range report.Issues {
+			switch {
+			case issue.Repaired:
+				log.Printf("🔧 已修复 [%s] %s", issue.Kind, issue.Message)
+			case issue.Repairable:
+				log.Printf("⚠️ [%s] %s（可使用 --repair 修复）", issue.Kind, issue.Message)
+			default:
+				log.Printf("❌ [%s] %s（需要人工处理）", issue.Kind, issue.Message)
+			}
+		}
+	}
+
+	if report.Unresolved() > 0 {
+		if !*asJSON {
+			log.Printf("⚠️ 发现 %d 个问题，%d 个已修复", len(report.Issues), report.Repaired)
+		}
+		// 先关闭存储再退出，os.Exit 不会执行 defer
+		if closer, ok := s.(io.Closer); ok {
+			closer.Close()
+		}
+		os.Exit(1)
+	}
+	switch {
+	case *asJSON:
+	case len(report.Issues) == 0:
+		log.Printf("✅ 数据一致")
+	default:
+		log.Printf("✅ 已修复全部 %d 个问题", len(report.Issues))
+	}
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"   // Go标准库：提供上下文(context)功能，用于控制goroutine的生命周期、取消操作和超时控制
+	"expvar"    // Go标准库：监控指标包，指标通过 /debug/vars 以JSON格式导出
 	"flag"      // Go标准库：命令行参数解析包，用于解析程序启动时传入的命令行参数
 	"fmt"       // Go标准库：格式化I/O包，提供格式化输入输出功能，如Printf、Sprintf等
+	"io"        // Go标准库：I/O接口包，这里用于判断存储后端是否需要关闭（io.Closer）
 	"log"       // Go标准库：简单日志包，提供基本的日志记录功能
 	"net/http"  // Go标准库：HTTP客户端和服务器实现，提供HTTP协议相关功能
 	"os"        // Go标准库：操作系统功能包，提供与操作系统交互的功能，如文件操作、环境变量等
@@ -12,16 +14,37 @@ import (
 	"time"      // Go标准库：时间包，提供时间相关功能，如获取当前时间、时间格式化、定时器等
 
 	// 内部包导入（项目内部模块）
-	"github.com/MGter/xStreamTool_go/internal/api"    // API处理层：包含HTTP处理器和路由配置
-	"github.com/MGter/xStreamTool_go/internal/config" // 配置管理：负责应用配置的加载和保存
-	"github.com/MGter/xStreamTool_go/internal/store"  // 数据存储层：提供数据存储接口和内存存储实现
+	"github.com/MGter/xStreamTool_go/internal/api"       // API处理层：包含HTTP处理器和路由配置
+	"github.com/MGter/xStreamTool_go/internal/config"    // 配置管理：负责应用配置的加载和保存
+	"github.com/MGter/xStreamTool_go/internal/scheduler" // 定时任务：多实例安全的定时任务调度
+	"github.com/MGter/xStreamTool_go/internal/store"     // 数据存储层：提供数据存储接口和内存存储实现
 )
 
 func main() {
+	// 子命令分发：第一个参数是子命令名称时执行对应的子命令，而不是启动服务器
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "scrub": // 数据脱敏：xstream scrub --in backup.json --out scrubbed.json
+			runScrub(os.Args[2:])
+			return
+		case "store": // 存储管理：xstream store migrate --from file --to postgres、store export、store import
+			runStore(os.Args[2:])
+			return
+		case "fsck": // 数据一致性检查：xstream fsck [--repair]
+			runFsck(os.Args[2:])
+			return
+		case "seed": // 写入测试数据：xstream seed --file fixtures.yaml
+			runSeed(os.Args[2:])
+			return
+		}
+	}
+
 	// 解析命令行参数
 	port := flag.String("port", "8080", "服务器端口") // 定义port命令行参数，默认值"8080"，描述"服务器端口"
 	debug := flag.Bool("debug", false, "启用调试模式") // 定义debug命令行参数，默认值false，描述"启用调试模式"
-	flag.Parse()                                 // 解析命令行参数，将命令行参数值赋给对应的变量
+	noSeed := flag.Bool("no-seed", false, "首次运行时不写入示例数据")
+	seedFile := flag.String("seed-file", "", "首次运行时从JSON文件加载初始数据（备份快照或待办事项数组），代替内置的示例数据")
+	flag.Parse() // 解析命令行参数，将命令行参数值赋给对应的变量
 
 	fmt.Println("🚀 xStreamTool Go HTTP 服务器启动中...") // 打印启动信息
 
@@ -29,12 +52,79 @@ func main() {
 	cfg := config.LoadConfig() // 调用配置模块的LoadConfig函数加载配置文件
 	cfg.Server.Port = *port    // 用命令行参数覆盖配置中的端口设置（*是取指针值）
 	cfg.Server.Debug = *debug  // 用命令行参数覆盖配置中的调试模式设置
+	if *noSeed {
+		cfg.Database.NoSeed = true // 未指定时保留配置文件中的设置
+	}
+	if *seedFile != "" {
+		cfg.Database.SeedFile = *seedFile
+	}
 
 	// 初始化存储
-	todoStore := store.NewMemoryStore() // 创建内存存储实例，用于数据持久化
+	// 根据配置中的数据库类型选择存储后端，默认使用内存存储
+	todoStore, err := store.NewStore(&cfg.Database)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if closer, ok := todoStore.(io.Closer); ok {
+		defer closer.Close() // 程序退出时关闭数据库连接
+	}
+	log.Printf("💾 存储后端: %s", cfg.Database.Type)
+	for s := todoStore; ; {
+		// 逐层导出装饰器的统计
+		switch decorated := s.(type) {
+		case *store.CachedStore:
+			// 读缓存的命中、未命中和淘汰次数
+			expvar.Publish("store_cache", expvar.Func(func() interface{} { return decorated.Stats() }))
+		case *store.ReplicatedStore:
+			// 副本的同步状态和落后时间
+			expvar.Publish("store_replication", expvar.Func(func() interface{} { return decorated.Status() }))
+		case *store.DegradableStore:
+			// 是否处于降级模式、降级次数和只读副本的刷新时间
+			expvar.Publish("store_degraded", expvar.Func(func() interface{} { return decorated.Status() }))
+		case *store.InstrumentedStore:
+			// 存储后端每个方法的调用次数、耗时和错误率
+			expvar.Publish("store_metrics", expvar.Func(func() interface{} { return decorated.Stats() }))
+		}
+		wrapper, ok := s.(store.Wrapper)
+		if !ok {
+			break
+		}
+		s = wrapper.Unwrap()
+	}
+	if reporter, ok := store.Unwrap(todoStore).(store.UsageReporter); ok {
+		// 导出内存存储的用量和被拒绝、淘汰的次数，供监控系统采集和告警
+		expvar.Publish("memory_store", expvar.Func(func() interface{} { return reporter.Usage() }))
+	}
 
 	// 初始化 API 处理器
-	handler := api.NewHandler(todoStore) // 创建API处理器，传入存储实例作为依赖
+	handler := api.NewHandler(todoStore, cfg) // 创建API处理器，传入存储实例和配置作为依赖
+
+	// 启动定时任务调度
+	// 多个实例共用 SQL 或 Redis 存储时，通过存储中的分布式锁保证每个任务每个间隔只执行一次
+	sched := scheduler.New(store.NewLocker(todoStore), cfg.Scheduler.NodeID)
+	sched.Every("purge_share_links", time.Hour, handler.PurgeExpiredShareLinks) // 每小时清理过期的分享链接
+	sched.Every("purge_events", time.Hour, handler.PurgeExpiredEvents)          // 每小时清理超过保留时间的事件
+	sched.Every("notify_watchers", time.Minute, handler.NotifyWatchers)         // 每分钟给关注者发送截止提醒和完成通知
+	sched.Every("fire_reminders", time.Minute, handler.FireReminders)           // 每分钟发送到达提醒时间的提醒
+
+	// webhook：每分钟为刚过期的事项记录 todo.overdue 事件，定期把新事件投递给订阅并重试失败的投递
+	sched.Every("overdue_events", time.Minute, handler.PublishOverdueEvents)
+	sched.Every("deliver_webhooks", handler.WebhookInterval(), handler.DeliverWebhooks)
+	if backups := handler.Backups(); backups.Enabled() {
+		sched.Every("backup", backups.Interval(), backups.Run) // 定期把全部数据备份到带时间戳的文件
+	}
+	if archives := handler.Archives(); archives.Enabled() {
+		sched.Every("archive", archives.Interval(), handler.ArchiveCompletedTodos) // 定期把已完成的旧事项归档到冷存储
+	}
+	if cfg.Trash.Enabled {
+		sched.Every("purge_trash", handler.TrashInterval(), handler.PurgeExpiredTrash) // 定期永久删除回收站中超过保留期的事项
+	}
+	if cfg.Scheduler.Enabled {
+		handler.Health().Register("scheduler", sched.Check) // 获取任务锁失败时就绪检查报告异常
+		sched.Start()
+		defer sched.Stop() // 程序退出时停止调度并等待正在执行的任务结束
+		log.Printf("⏰ 定时任务实例: %s", sched.Owner())
+	}
 
 	// 设置路由
 	router := api.SetupRoutes(handler) // 设置所有HTTP路由，返回配置好的路由器
@@ -47,6 +137,7 @@ func main() {
 		WriteTimeout: 15 * time.Second,                    // 写入响应超时时间
 		IdleTimeout:  60 * time.Second,                    // 空闲连接超时时间
 	}
+	server.RegisterOnShutdown(handler.CloseStreams) // 关闭时结束事件流等长连接，不必等到超时
 
 	// 优雅关闭 - 创建信号通道用于接收系统信号
 	quit := make(chan os.Signal, 1)                      // 创建带缓冲区的信号通道，容量为1
@@ -72,6 +163,16 @@ func main() {
 	<-quit                      // 阻塞等待直到收到信号，从quit通道接收到信号
 	log.Println("🛑 正在关闭服务器...") // 打印正在关闭服务器的提示
 
+	// 先让就绪检查失败，等待负载均衡器（如 Kubernetes）摘除实例后再关闭连接；等待期间再次收到信号时立即关闭
+	handler.BeginShutdown()
+	if delay := handler.ShutdownDelay(); delay > 0 {
+		log.Printf("⏳ 就绪检查已返回 503，%v 后关闭连接", delay)
+		select {
+		case <-time.After(delay):
+		case <-quit:
+		}
+	}
+
 	// 设置关闭超时上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second) // 创建30秒超时的上下文
 	defer cancel()                                                           // 确保在函数返回时取消上下文，释放资源
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json" // JSON编解码包，用于读取指定的配置文件和创建空的数据文件
+	"errors"        // 错误处理包，用于判断文件是否存在
+	"flag"          // 命令行参数解析包，用于解析子命令的参数
+	"fmt"           // 格式化I/O包，用于输出用法说明
+	"io"            // I/O接口包，用于判断存储后端是否需要关闭
+	"log"           // 日志包，用于输出进度、结果和错误
+	"os"            // 操作系统功能包，用于读写文件和退出
+	"path/filepath" // 路径处理包，用于创建数据文件所在的目录
+	"time"          // 时间包，用于统计迁移耗时
+
+	"github.com/MGter/xStreamTool_go/internal/config" // 配置管理：读取数据库连接配置
+	"github.com/MGter/xStreamTool_go/internal/models" // 数据模型：快照格式定义
+	"github.com/MGter/xStreamTool_go/internal/store"  // 数据存储层：存储后端和迁移
+)
+
+// storeAliases 存储类型的别名
+var storeAliases = map[string]string{
+	"memory-json": "file", // 文件存储的数据保存在内存中，并以JSON文件持久化
+}
+
+// runStore 执行 store 子命令
+// 用法：xstream store migrate|export|import [参数]
+func runStore(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	switch args[0] {
+	case "migrate":
+		runMigrate(args[1:])
+	case "export":
+		runExport(args[1:])
+	case "import":
+		runImport(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, "用法: xstream store migrate --from 类型 --to 类型 [参数]")
+		fmt.Fprintln(os.Stderr, "      xstream store export [--type 类型] [--out 快照文件]")
+		fmt.Fprintln(os.Stderr, "      xstream store import [--type 类型] [--in 快照文件] [--replace]")
+		os.Exit(2)
+	}
+}
+
+// runMigrate 执行 store migrate 子命令
+// 用法：xstream store migrate --from file --to postgres [--from-path data/todos.json] [--to-config pg.json] [--batch 500]
+// 把一个存储后端中的待办事项和附属数据复制到另一个后端，输出进度，完成后比较两边的数量和校验和。
+// 两端的连接配置默认取自当前目录的 config.json 中的 database 部分，可以分别用 --from-config、--to-config 指定其它配置文件
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("store migrate", flag.ExitOnError)
+	from := fs.String("from", "", "源存储类型（必填），memory-json 等同于 file")
+	to := fs.String("to", "", "目标存储类型（必填）")
+	fromPath := fs.String("from-path", "", "源存储的数据文件路径（file、sqlite）")
+	toPath := fs.String("to-path", "", "目标存储的数据文件路径（file、sqlite）")
+	fromConfig := fs.String("from-config", "", "读取源存储连接配置的配置文件，默认使用 config.json")
+	toConfig := fs.String("to-config", "", "读取目标存储连接配置的配置文件，默认使用 config.json")
+	batch := fs.Int("batch", 500, "每批写入的待办事项数")
+	fs.Parse(args)
+
+	if *from == "" || *to == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	base := config.LoadConfig()
+	src := openMigrateStore("源", base, *from, *fromPath, *fromConfig)
+	if closer, ok := src.(io.Closer); ok {
+		defer closer.Close()
+	}
+	dst := openMigrateStore("目标", base, *to, *toPath, *toConfig)
+	if closer, ok := dst.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	log.Printf("🚚 开始迁移: %s → %s", *from, *to)
+	start := time.Now()
+	result, err := store.Migrate(src, dst, store.MigrateOptions{
+		BatchSize: *batch,
+		Progress: func(done, total int) {
+			log.Printf("📦 已写入 %d/%d 条待办事项（%d%%）", done, total, done*100/total)
+		},
+	})
+	if result != nil {
+		log.Printf("🔍 源存储: %d 条待办事项（校验和 %.12s），%d 条附属数据（校验和 %.12s）",
+			result.Source.Todos, result.Source.TodoChecksum, result.Source.Meta, result.Source.MetaChecksum)
+		log.Printf("🔍 目标存储: %d 条待办事项（校验和 %.12s），%d 条附属数据（校验和 %.12s）",
+			result.Target.Todos, result.Target.TodoChecksum, result.Target.Meta, result.Target.MetaChecksum)
+	}
+	if err != nil {
+		log.Fatalf("❌ 迁移失败: %v", err)
+	}
+
+	log.Printf("✅ 迁移完成，校验一致，耗时 %v", time.Since(start).Round(time.Millisecond))
+}
+
+// openMigrateStore 按存储类型打开迁移的一端
+// 连接配置取自 configPath 指定的配置文件（为空时使用 base），再用 storeType 和 path 覆盖类型和数据文件路径
+func openMigrateStore(side string, base *config.Config, storeType, path, configPath string) store.TodoStore {
+	cfg := *base
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			log.Fatalf("❌ 读取%s存储的配置文件失败: %v", side, err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			log.Fatalf("❌ 解析%s存储的配置文件失败: %v", side, err)
+		}
+	}
+
+	dbConfig := cfg.Database
+	if alias, exists := storeAliases[storeType]; exists {
+		storeType = alias
+	}
+	dbConfig.Type = storeType
+	if path != "" {
+		dbConfig.Path = path
+	}
+
+	switch {
+	case storeType == "memory":
+		// 内存存储只有示例数据，进程退出后数据丢失
+		log.Fatalf("❌ 内存存储不能用于迁移，请使用 file（memory-json）")
+	case (storeType == "file" || storeType == "sqlite") && side == "源":
+		// 数据文件不存在时会被创建，迁移的结果没有意义
+		if dbConfig.Path == "" {
+			dbConfig.Path = map[string]string{"file": store.DefaultFilePath, "sqlite": store.DefaultSQLitePath}[storeType]
+		}
+		if _, err := os.Stat(dbConfig.Path); err != nil {
+			log.Fatalf("❌ 源存储的数据文件不可用: %v", err)
+		}
+	case storeType == "file" && side == "目标":
+		// 数据文件不存在时文件存储会写入示例数据，先创建空的数据文件
+		if dbConfig.Path == "" {
+			dbConfig.Path = store.DefaultFilePath
+		}
+		if err := createEmptyDataFile(dbConfig.Path); err != nil {
+			log.Fatalf("❌ 创建目标数据文件失败: %v", err)
+		}
+	}
+
+	s, err := store.NewStore(&dbConfig)
+	if err != nil {
+		log.Fatalf("❌ 打开%s存储失败: %v", side, err)
+	}
+	return s
+}
+
+// createEmptyDataFile 数据文件不存在时创建没有任何数据的快照文件，已存在时不做任何修改
+func createEmptyDataFile(path string) error {
+	if _, err := os.Stat(path); err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(&models.Snapshot{
+		Version:    models.SnapshotVersion,
+		ExportedAt: time.Now(),
+		NextID:     1,
+		Todos:      []*models.Todo{},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json" // JSON编解码包，用于读取备份文件和写出脱敏后的文件
+	"flag"          // 命令行参数解析包，用于解析子命令的参数
+	"log"           // 日志包，用于输出执行结果和错误
+	"os"            // 操作系统功能包，用于读写文件
+	"time"          // 时间包，用于生成默认的随机种子
+
+	"github.com/MGter/xStreamTool_go/internal/models" // 数据模型：快照格式定义
+	"github.com/MGter/xStreamTool_go/internal/scrub"  // 数据脱敏：把敏感内容替换为假数据
+)
+
+// runScrub 执行 scrub 子命令
+// 用法：xstream scrub --in backup.json --out scrubbed.json [--seed 42]
+// 读取快照格式的备份文件，把标题、描述、分类和邮箱替换为假数据后写入新文件，
+// ID、日期、优先级和完成状态保持不变，便于在提交问题时分享可复现的数据集
+func runScrub(args []string) {
+	fs := flag.NewFlagSet("scrub", flag.ExitOnError)
+	in := fs.String("in", "", "输入的备份文件（必填）")
+	out := fs.String("out", "", "输出的脱敏文件（必填）")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "随机种子，相同的种子生成相同的假数据")
+	fs.Parse(args)
+
+	if *in == "" || *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// 读取并解析备份文件
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatalf("❌ 读取备份文件失败: %v", err)
+	}
+
+	var snapshot models.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.Fatalf("❌ 解析备份文件失败: %v", err)
+	}
+
+	// 脱敏
+	scrubbed, err := scrub.New(*seed).Snapshot(&snapshot)
+	if err != nil {
+		log.Fatalf("❌ 数据脱敏失败: %v", err)
+	}
+
+	// 写出结果
+	data, err = json.MarshalIndent(scrubbed, "", "  ")
+	if err != nil {
+		log.Fatalf("❌ 编码脱敏数据失败: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatalf("❌ 写入脱敏文件失败: %v", err)
+	}
+
+	log.Printf("✅ 已脱敏 %d 条待办事项，输出到 %s", len(scrubbed.Todos), *out)
+}
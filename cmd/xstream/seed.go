@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json" // JSON编解码包，用于读取指定的配置文件
+	"flag"          // 命令行参数解析包，用于解析子命令的参数
+	"io"            // I/O接口包，用于判断存储后端是否需要关闭
+	"log"           // 日志包，用于输出结果和错误
+	"os"            // 操作系统功能包，用于读取配置文件和退出
+	"time"          // 时间包，用于确定相对时间的参照时间
+
+	"github.com/MGter/xStreamTool_go/internal/config"   // 配置管理：读取数据库连接配置
+	"github.com/MGter/xStreamTool_go/internal/fixtures" // 测试数据：解析数据集并写入存储
+	"github.com/MGter/xStreamTool_go/internal/store"    // 数据存储层：存储后端
+)
+
+// runSeed 执行 seed 子命令
+// 用法：xstream seed --file fixtures.yaml [--type sqlite] [--path data/xstreamtool.db] [--config other.json] [--now 2024-06-01T09:00:00Z] [--replace]
+// 或：xstream seed --count 10000 [--users 20] [--rand-seed 1] [其它参数同上]
+// 把测试数据文件描述的数据集写入存储，演示、截图和集成测试使用同一份数据；
+// 指定 --count 时按真实的分布生成大量演示数据，用于评估分页、搜索、统计等功能在大数据量下的表现。
+// 连接配置默认取自当前目录的 config.json 中的 database 部分；存储中已有待办事项时需要 --replace 才会覆盖
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := fs.String("file", "", "测试数据文件，与 --count 二选一")
+	count := fs.Int("count", 0, "生成的演示待办事项数，与 --file 二选一")
+	users := fs.Int("users", 10, "生成演示数据时的用户数（关注者从中选择）")
+	randSeed := fs.Int64("rand-seed", 1, "生成演示数据的随机数种子，相同的种子和参照时间生成相同的数据")
+	storeType := fs.String("type", "", "存储类型，默认使用配置文件中的类型，memory-json 等同于 file")
+	path := fs.String("path", "", "数据文件路径（file、sqlite）")
+	configPath := fs.String("config", "", "读取存储连接配置的配置文件，默认使用 config.json")
+	now := fs.String("now", "", "相对时间的参照时间（RFC3339），默认使用数据文件中的 now 或当前时间")
+	replace := fs.Bool("replace", false, "存储中已有数据时删除后再写入")
+	fs.Parse(args)
+
+	if (*file == "") == (*count <= 0) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var dataset *fixtures.Fixtures
+	if *file != "" {
+		loaded, err := fixtures.Load(*file)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		dataset = loaded
+	} else {
+		opts := fixtures.GenerateOptions{Count: *count, Users: *users, Seed: *randSeed}
+		if *now != "" {
+			t, err := time.Parse(time.RFC3339, *now)
+			if err != nil {
+				log.Fatalf("❌ 无效的参照时间: %v", err)
+			}
+			opts.Now = t
+		}
+		generated, err := fixtures.Generate(opts)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		dataset = generated
+	}
+	if *now != "" {
+		dataset.Now = *now
+	}
+
+	cfg := config.LoadConfig()
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			log.Fatalf("❌ 读取配置文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			log.Fatalf("❌ 解析配置文件失败: %v", err)
+		}
+	}
+	if *storeType == "" {
+		*storeType = cfg.Database.Type
+	}
+	if *storeType == "memory" {
+		// 内存存储的数据在进程退出后丢失
+		log.Fatalf("❌ 内存存储不能写入测试数据，请使用 file（memory-json）")
+	}
+	s := openMigrateStore("目标", cfg, *storeType, *path, "")
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	existing, err := s.GetAllTodos()
+	if err != nil {
+		log.Fatalf("❌ 读取存储失败: %v", err)
+	}
+	if len(existing) > 0 && !*replace {
+		log.Printf("❌ 存储中已有 %d 条待办事项，使用 --replace 删除后写入测试数据", len(existing))
+		if closer, ok := s.(io.Closer); ok {
+			closer.Close()
+		}
+		os.Exit(1)
+	}
+
+	snapshot, err := fixtures.Seed(s, dataset, time.Now())
+	if err != nil {
+		log.Fatalf("❌ 写入测试数据失败: %v", err)
+	}
+	log.Printf("🌱 已写入 %d 条待办事项、%d 个项目默认设置、%d 个关注者（参照时间 %s）",
+		len(snapshot.Todos), len(snapshot.Meta[store.CategoryDefaultsNamespace]), len(snapshot.Meta[store.WatchersNamespace]),
+		snapshot.ExportedAt.Format(time.RFC3339))
+}
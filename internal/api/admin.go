@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/fsck"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/scrub"
+)
+
+// adminPathPrefix 管理接口的路径前缀，其下的接口（脱敏除外）都需要管理令牌
+const adminPathPrefix = "/admin"
+
+// requireAdmin 管理接口的访问控制中间件：请求需要带有 Authorization: Bearer <admin.token>
+// 未配置 admin.token 时接口不可用，返回 404；没有令牌返回 401，令牌错误返回 403
+func (h *Handler) requireAdmin(next http.Handler) http.Handler {
+	token := h.config.Admin.Token
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			sendError(w, "未启用该管理接口，需要在配置的 admin.token 中设置访问令牌", http.StatusNotFound)
+			return
+		}
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || provided == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			sendError(w, "需要管理令牌", http.StatusUnauthorized)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			sendError(w, "管理令牌无效", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ScrubSnapshot 对上传的快照进行脱敏
+// 请求体为快照格式的备份数据，返回替换了标题、描述、分类和邮箱的快照；
+// 可通过 ?seed= 指定随机种子，使结果可复现
+func (h *Handler) ScrubSnapshot(w http.ResponseWriter, r *http.Request) {
+	var snapshot models.Snapshot
+	if err := decodeJSON(r, &snapshot); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+
+	seed := time.Now().UnixNano()
+	if value := r.URL.Query().Get("seed"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			sendError(w, "无效的随机种子", http.StatusBadRequest)
+			return
+		}
+		seed = parsed
+	}
+
+	scrubbed, err := scrub.New(seed).Snapshot(&snapshot)
+	if err != nil {
+		sendError(w, "脱敏失败", http.StatusBadRequest)
+		return
+	}
+
+	sendJSON(w, scrubbed, http.StatusOK)
+}
+
+// CheckConsistency 检查数据之间的引用是否一致，只报告问题，不修改数据
+func (h *Handler) CheckConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := fsck.Check(h.store)
+	if err != nil {
+		sendError(w, "检查失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, report, http.StatusOK)
+}
+
+// RepairConsistency 检查并修复可以自动修复的问题，返回的报告中标记了已修复的问题
+func (h *Handler) RepairConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := fsck.Repair(h.storeFor(r))
+	if err != nil {
+		sendError(w, "修复失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, report, http.StatusOK)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// defaultUpcomingDays 近期视图默认包含的天数
+const defaultUpcomingDays = 7
+
+// maxUpcomingDays 近期视图最多包含的天数
+const maxUpcomingDays = 365
+
+// agendaEnvelope 今天、近期、过期视图的响应：v1 列表信封加上划分日期使用的时区和截止时间范围
+type agendaEnvelope struct {
+	listEnvelope
+	Timezone string     `json:"timezone"`       // 划分日期使用的时区
+	From     *time.Time `json:"from,omitempty"` // 截止时间范围的起点（包含），过期视图没有
+	To       *time.Time `json:"to"`             // 截止时间范围的终点（不包含），过期视图为当前时间
+}
+
+// TodayTodos 今天到期的事项：截止时间在用户时区的今天零点到明天零点之间
+// 时区见 userLocation；默认只返回未完成的事项、按截止时间升序排列，排序、分页、过滤参数与列表接口相同
+func (h *Handler) TodayTodos(w http.ResponseWriter, r *http.Request) {
+	view, loc, err := h.parseAgendaView(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from := startOfDay(time.Now(), loc)
+	to := from.AddDate(0, 0, 1)
+	view.Filter.DueAfter, view.Filter.DueBefore = &from, &to
+	h.sendAgenda(w, r, view, loc, &from, &to)
+}
+
+// UpcomingTodos 近期到期的事项：截止时间在用户时区明天零点之后的 ?days= 天内（默认7天，不包含今天）
+func (h *Handler) UpcomingTodos(w http.ResponseWriter, r *http.Request) {
+	days := defaultUpcomingDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxUpcomingDays {
+			sendError(w, "days 必须是1到365之间的整数", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	view, loc, err := h.parseAgendaView(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from := startOfDay(time.Now(), loc).AddDate(0, 0, 1)
+	to := from.AddDate(0, 0, days)
+	view.Filter.DueAfter, view.Filter.DueBefore = &from, &to
+	h.sendAgenda(w, r, view, loc, &from, &to)
+}
+
+// OverdueTodos 已过期的事项：未完成且截止时间早于当前时间，与 ?overdue=true 的规则一致
+func (h *Handler) OverdueTodos(w http.ResponseWriter, r *http.Request) {
+	view, loc, err := h.parseAgendaView(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	overdue := true
+	view.Filter.Overdue, view.Filter.Now = &overdue, now
+	h.sendAgenda(w, r, view, loc, nil, &now)
+}
+
+// parseAgendaView 解析视图的列表设置和用户时区
+// 与列表接口不同，没有指定 show_completed 或 completed 时只包含未完成的事项，没有指定 sort 时按截止时间排序（默认升序）；
+// 截止时间范围由视图决定，忽略 due_before、due_after 参数
+func (h *Handler) parseAgendaView(r *http.Request) (listView, *time.Location, error) {
+	view, err := h.parseListView(r)
+	if err != nil {
+		return view, nil, err
+	}
+	loc, err := h.userLocation(r)
+	if err != nil {
+		return view, nil, err
+	}
+
+	query := r.URL.Query()
+	if !query.Has("show_completed") && !query.Has("completed") {
+		view.ShowCompleted = false
+	}
+	if query.Get("sort") == "" {
+		view.Sort = []models.SortKey{{Field: models.SortByDueDate, Desc: query.Get("order") == "desc"}}
+	}
+	view.Filter.DueAfter, view.Filter.DueBefore = nil, nil
+	return view, loc, nil
+}
+
+// sendAgenda 按视图设置取出事项并发送，响应中附带时区和截止时间范围
+func (h *Handler) sendAgenda(w http.ResponseWriter, r *http.Request, view listView, loc *time.Location, from, to *time.Time) {
+	todos, err := h.listTodos(view)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	todos, total := view.apply(todos, h.agingPolicy())
+
+	setPaginationHeaders(w, r.URL, view, total)
+	sendJSONWithETag(w, r, agendaEnvelope{
+		listEnvelope: h.newListEnvelope(r, h.todoResponses(r, todos), view, total),
+		Timezone:     loc.String(),
+		From:         from,
+		To:           to,
+	})
+}
+
+// userLocation 返回请求者的时区：依次取 ?tz= 查询参数、X-Timezone 请求头（IANA 名称，如 Asia/Shanghai）、
+// 配置的 view.timezone，都没有时使用服务器时区
+func (h *Handler) userLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		name = r.Header.Get("X-Timezone")
+	}
+	if name == "" {
+		name = h.config.View.Timezone
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("无效的时区 " + strconv.Quote(name) + "，应为 IANA 时区名称，如 Asia/Shanghai")
+	}
+	return loc, nil
+}
+
+// startOfDay 返回t在时区loc中当天的零点
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,16 @@
+package api
+
+import "github.com/MGter/xStreamTool_go/internal/models"
+
+// agingPolicy 根据配置生成优先级老化策略
+func (h *Handler) agingPolicy() *models.AgingPolicy {
+	cfg := h.config.PriorityAging
+	return &models.AgingPolicy{
+		Enabled:         cfg.Enabled,
+		DueSoonDays:     cfg.DueSoonDays,
+		DueSoonBoost:    cfg.DueSoonBoost,
+		OverdueBoost:    cfg.OverdueBoost,
+		OverdueStepDays: cfg.OverdueStepDays,
+		MaxPriority:     cfg.MaxPriority,
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/archive"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// Archives 返回归档管理器，用于注册定期归档任务
+func (h *Handler) Archives() *archive.Manager {
+	return h.archives
+}
+
+// ArchiveCompletedTodos 定时任务：把完成后超过保留天数的事项归档到冷存储并从存储中移除
+func (h *Handler) ArchiveCompletedTodos(ctx context.Context) error {
+	_, err := h.archiveCompleted(ctx)
+	return err
+}
+
+// archiveCompleted 执行一次归档，与删除事项一样产生删除事件，并清理指向被移除事项的关联、分享链接和关注者
+func (h *Handler) archiveCompleted(ctx context.Context) (*models.ArchiveResult, error) {
+	result, err := h.archives.Archive(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if result.Archived == 0 {
+		return result, nil
+	}
+
+	for _, id := range result.IDs {
+		h.publish(models.EventTodoDeleted, id, nil)
+	}
+	h.removeLinksTo(result.IDs...)
+	h.removeShareLinks(result.IDs...)
+	h.removeWatchers(result.IDs...)
+	h.removeDependencies(result.IDs...)
+
+	log.Printf("🗄️ 已归档 %d 个已完成的待办事项到 %s（%d 字节）", result.Archived, result.Archive, result.Size)
+	return result, nil
+}
+
+// RunArchive 立即执行一次归档
+func (h *Handler) RunArchive(w http.ResponseWriter, r *http.Request) {
+	result, err := h.archiveCompleted(r.Context())
+	if err != nil {
+		logf(r, "❌ 归档失败: %v", err)
+		sendError(w, "归档失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, result, http.StatusOK)
+}
+
+// SearchArchive 搜索归档的事项，最近归档的在前
+// ?q= 不区分大小写地匹配标题、描述或分类，?category= 只返回该分类的事项；
+// 恢复到存储的事项仍然保留在归档文件中
+func (h *Handler) SearchArchive(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	results, err := h.archives.Search(r.Context(), query.Get("q"), query.Get("category"))
+	if err != nil {
+		logf(r, "❌ 搜索归档失败: %v", err)
+		sendError(w, "搜索归档失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, results, http.StatusOK)
+}
+
+// ListArchivedTodos 分页浏览归档的事项，最近归档的在前
+// 与 GET /api/archive 一样支持 ?q= 和 ?category=，?page= 和 ?per_page= 分页（默认每页数量与待办事项列表相同），
+// 响应为 {"data": [...], "meta": {...}} 信封格式
+func (h *Handler) ListArchivedTodos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	view := listView{Page: 1, PerPage: h.config.View.PageSize}
+	if err := view.parsePage(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.archives.Search(r.Context(), query.Get("q"), query.Get("category"))
+	if err != nil {
+		logf(r, "❌ 读取归档失败: %v", err)
+		sendError(w, "读取归档失败", http.StatusInternalServerError)
+		return
+	}
+	total := len(results)
+	if view.PerPage > 0 {
+		start := (view.Page - 1) * view.PerPage
+		if start > total {
+			start = total
+		}
+		end := start + view.PerPage
+		if end > total {
+			end = total
+		}
+		results = results[start:end]
+	}
+	setPaginationHeaders(w, r.URL, view, total)
+	sendJSONWithETag(w, r, h.newListEnvelope(r, results, view, total))
+}
+
+// GetArchivedTodo 获取最近一次归档的指定事项
+func (h *Handler) GetArchivedTodo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	archived, err := h.archives.Find(r.Context(), id)
+	if errors.Is(err, archive.ErrNotArchived) {
+		sendError(w, "归档中没有该待办事项", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logf(r, "❌ 读取归档失败: %v", err)
+		sendError(w, "读取归档失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, archived, http.StatusOK)
+}
+
+// RehydrateTodo 把最近一次归档的指定事项按原ID恢复到存储
+// 存储中已有该ID的事项时返回 409；归档时被清理的关联、分享链接和关注者不会恢复
+func (h *Handler) RehydrateTodo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	archived, err := h.archives.Find(r.Context(), id)
+	if errors.Is(err, archive.ErrNotArchived) {
+		sendError(w, "归档中没有该待办事项", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logf(r, "❌ 读取归档失败: %v", err)
+		sendError(w, "读取归档失败", http.StatusInternalServerError)
+		return
+	}
+
+	todo := archived.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		loader, ok := tx.(store.Loader)
+		if !ok {
+			return store.ErrLoadUnsupported
+		}
+		if _, err := tx.GetTodoByID(id); err == nil {
+			return store.ErrDuplicateID
+		} else if !errors.Is(err, store.ErrTodoNotFound) {
+			return err
+		}
+		return loader.LoadTodos([]*models.Todo{todo})
+	})
+	switch {
+	case errors.Is(err, store.ErrDuplicateID):
+		sendError(w, "存储中已有该ID的待办事项", http.StatusConflict)
+		return
+	case errors.Is(err, store.ErrLoadUnsupported):
+		sendError(w, "当前存储不支持恢复归档的事项", http.StatusNotImplemented)
+		return
+	case err != nil:
+		logf(r, "❌ 恢复归档的待办事项 %d 失败: %v", id, err)
+		sendError(w, "恢复失败", http.StatusInternalServerError)
+		return
+	}
+
+	restored, err := h.store.GetTodoByID(id)
+	if err != nil {
+		restored = todo
+	}
+	h.publish(models.EventTodoCreated, restored.ID, restored)
+	logf(r, "♻️ 已从 %s 恢复待办事项 %d", archived.Archive, id)
+	sendJSON(w, h.todoResponse(r, restored), http.StatusCreated)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// 审计日志分页参数
+const (
+	defaultAuditLimit = 100  // 默认每次返回的记录数
+	maxAuditLimit     = 1000 // 每次最多返回的记录数
+)
+
+// storeFor 返回以请求者身份修改数据的存储，启用审计日志时修改记入请求者名下
+// 操作者取自配置的请求头，请求中没有时记为 anonymous
+func (h *Handler) storeFor(r *http.Request) store.TodoStore {
+	audited, ok := h.store.(*store.AuditedStore)
+	if !ok {
+		return h.store
+	}
+
+	actor := models.AuditActorAnonymous
+	if header := h.config.Audit.ActorHeader; header != "" {
+		if value := strings.TrimSpace(r.Header.Get(header)); value != "" {
+			actor = value
+		}
+	}
+	return audited.As(actor, clientKey(r))
+}
+
+// ListAuditEntries 查询审计日志，最新的在前
+// 支持 ?todo_id=、?actor=、?action=（create、update、complete、delete）、?since= 和 ?until=（RFC 3339 时间）过滤；
+// ?limit= 为最多返回的数量，has_more 为 true 时把 ?before= 设为 next_before 继续读取更早的记录
+func (h *Handler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	if !h.config.Audit.Enabled {
+		sendError(w, "未启用审计日志", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := store.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Before: query.Get("before"),
+		Limit:  defaultAuditLimit,
+	}
+	if value := query.Get("todo_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			sendError(w, "无效的todo_id参数", http.StatusBadRequest)
+			return
+		}
+		filter.TodoID = id
+	}
+	if filter.Action != "" && !models.IsValidAuditAction(filter.Action) {
+		sendError(w, "无效的action参数，可选 create、update、complete、delete", http.StatusBadRequest)
+		return
+	}
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				sendError(w, "无效的"+name+"参数，应为 RFC 3339 格式的时间", http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxAuditLimit {
+			sendError(w, "limit参数必须在1到1000之间", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	// 多读取一条用于判断是否还有更早的记录
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := store.ListAuditEntries(h.store, filter)
+	if err != nil {
+		sendError(w, "获取审计日志失败", http.StatusInternalServerError)
+		return
+	}
+
+	hasMore := len(entries) > limit
+	response := map[string]interface{}{"has_more": hasMore}
+	if hasMore {
+		entries = entries[:limit]
+		response["next_before"] = entries[limit-1].ID
+	}
+	response["entries"] = entries
+	sendJSON(w, response, http.StatusOK)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/MGter/xStreamTool_go/internal/backup"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// Backups 返回备份管理器，用于注册定期备份任务
+func (h *Handler) Backups() *backup.Manager {
+	return h.backups
+}
+
+// ListBackups 列出备份目录中的备份文件，最新的在前
+func (h *Handler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.backups.List()
+	if err != nil {
+		sendError(w, "读取备份目录失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, backups, http.StatusOK)
+}
+
+// CreateBackup 立即备份一次
+func (h *Handler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	info, err := h.backups.Backup()
+	if err != nil {
+		logf(r, "❌ 备份失败: %v", err)
+		sendError(w, "备份失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, info, http.StatusCreated)
+}
+
+// RestoreBackup 用备份替换当前的全部待办事项和附属数据
+// 通过 ?file= 指定备份目录中的备份文件（json 或 sql 格式），或在请求体中直接提交快照格式的数据；
+// 启用了定期备份时，恢复前先自动备份一次当前数据。事件发件箱保持不变，恢复不会产生事件
+func (h *Handler) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	result := &models.RestoreResult{}
+	var snapshot *models.Snapshot
+	if name := r.URL.Query().Get("file"); name != "" {
+		loaded, err := h.backups.Load(name)
+		switch {
+		case errors.Is(err, backup.ErrInvalidName):
+			sendError(w, "无效的备份文件名", http.StatusBadRequest)
+			return
+		case errors.Is(err, os.ErrNotExist):
+			sendError(w, "备份文件不存在", http.StatusNotFound)
+			return
+		case err != nil:
+			logf(r, "❌ 读取备份文件 %s 失败: %v", name, err)
+			sendError(w, "读取备份文件失败", http.StatusUnprocessableEntity)
+			return
+		}
+		snapshot, result.Source = loaded, name
+	} else {
+		snapshot = &models.Snapshot{}
+		if err := decodeJSON(r, snapshot); err != nil {
+			sendDecodeError(w, "无效数据", err)
+			return
+		}
+		result.Source = "request"
+	}
+
+	if h.backups.Enabled() {
+		info, err := h.backups.Backup()
+		if err != nil {
+			logf(r, "❌ 恢复前备份当前数据失败: %v", err)
+			sendError(w, "恢复前备份当前数据失败", http.StatusInternalServerError)
+			return
+		}
+		result.SafetyBackup = info.Name
+	}
+
+	err := store.RestoreSnapshot(h.storeFor(r), snapshot)
+	switch {
+	case errors.Is(err, store.ErrInvalidID), errors.Is(err, store.ErrDuplicateID):
+		sendError(w, "备份中的待办事项ID无效或重复", http.StatusBadRequest)
+		return
+	case errors.Is(err, store.ErrLoadUnsupported):
+		sendError(w, "当前存储不支持恢复备份", http.StatusNotImplemented)
+		return
+	case err != nil:
+		logf(r, "❌ 恢复备份失败: %v", err)
+		sendError(w, "恢复失败", http.StatusInternalServerError)
+		return
+	}
+
+	result.Todos = len(snapshot.Todos)
+	for _, namespace := range store.MigrateNamespaces {
+		result.Meta += len(snapshot.Meta[namespace])
+	}
+	logf(r, "♻️ 已从 %s 恢复 %d 个待办事项、%d 条附属数据", result.Source, result.Todos, result.Meta)
+	sendJSON(w, result, http.StatusOK)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// errTrailingData 请求体在第一个 JSON 值之后还有其它内容
+var errTrailingData = errors.New("请求体只能包含一个JSON值")
+
+// bodyLimitMiddleware 限制 API 请求体的大小（server.max_body_bytes）
+// Content-Length 已超出时直接返回 413；否则用 http.MaxBytesReader 包装请求体，读取超出部分时解码失败并返回 413
+func (h *Handler) bodyLimitMiddleware(next http.Handler) http.Handler {
+	limit := h.config.Server.MaxBodyBytes
+	if limit <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			sendError(w, bodyTooLargeMessage(limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeJSON 严格地解码 JSON 请求体：不允许未知字段，第一个值之后不允许还有其它内容
+// 请求体为空时返回 io.EOF，可以为空的接口据此忽略
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err == nil {
+		return errTrailingData
+	} else if !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// sendDecodeError 返回解码请求体失败的错误：请求体过大时返回 413，其它情况返回 400，
+// message 为接口对请求体格式的说明，后面附上具体的原因（如未知字段、类型错误的字段）
+func sendDecodeError(w http.ResponseWriter, message string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		sendError(w, bodyTooLargeMessage(tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	detail := decodeErrorDetail(err)
+	if !strings.Contains(message, detail) {
+		message += "：" + detail
+	}
+	sendError(w, message, http.StatusBadRequest)
+}
+
+// decodeErrorDetail 把 encoding/json 的错误转换为便于调用方定位问题的说明
+func decodeErrorDetail(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "请求体为空"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "JSON不完整"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("JSON格式错误（第 %d 字节）", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return "请求体应为" + jsonTypeName(typeErr.Type)
+		}
+		return fmt.Sprintf("字段 %q 应为%s", typeErr.Field, jsonTypeName(typeErr.Type))
+	case errors.As(err, &timeErr):
+		return fmt.Sprintf("时间 %q 格式错误，应为 RFC 3339 格式，如 2006-01-02T15:04:05Z", timeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json 没有为未知字段定义错误类型
+		return "未知字段 " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	}
+	return err.Error()
+}
+
+// jsonTypeName 返回 Go 类型对应的 JSON 类型的名称
+func jsonTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "字符串"
+	case reflect.Bool:
+		return "布尔值"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "整数"
+	case reflect.Float32, reflect.Float64:
+		return "数字"
+	case reflect.Slice, reflect.Array:
+		return "数组"
+	case reflect.Struct, reflect.Map:
+		return "对象"
+	}
+	return t.String()
+}
+
+// bodyTooLargeMessage 请求体过大的错误信息
+func bodyTooLargeMessage(limit int64) string {
+	return fmt.Sprintf("请求体过大，不能超过 %d 字节", limit)
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// maxBulkItems 一次批量操作最多包含的事项数
+const maxBulkItems = 1000
+
+// bulkUpdateItem 批量更新请求中的一项：事项ID加上与单个更新相同的字段
+type bulkUpdateItem struct {
+	ID int `json:"id"`
+	models.TodoRequest
+}
+
+// BulkCreateTodos 批量创建待办事项
+// 请求体为待办事项数组，每一项的格式与单个创建相同；任一项无效时一个都不创建
+func (h *Handler) BulkCreateTodos(w http.ResponseWriter, r *http.Request) {
+	var reqs []*models.TodoRequest
+	if err := decodeJSON(r, &reqs); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为数组", err)
+		return
+	}
+	if err := checkBulkSize(len(reqs)); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 每一项都需要应用规则，只读取一次
+	rules, err := store.ListRules(h.store)
+	if err != nil {
+		sendError(w, "读取自动分类规则失败", http.StatusInternalServerError)
+		return
+	}
+
+	for i, req := range reqs {
+		if req == nil {
+			sendError(w, fmt.Sprintf("第 %d 项：无效数据", i+1), http.StatusBadRequest)
+			return
+		}
+		if err := validateTodoRequest(req); err != nil {
+			sendError(w, fmt.Sprintf("第 %d 项：%v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		models.ApplyRules(rules, req)
+		if err := h.applyCategoryDefaults(req); err != nil {
+			sendError(w, "读取分类默认设置失败", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	todos, err := h.storeFor(r).BulkCreate(reqs)
+	if errors.Is(err, store.ErrStoreFull) {
+		sendError(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	}
+	if err != nil {
+		sendError(w, "批量创建失败", http.StatusInternalServerError)
+		return
+	}
+	for _, todo := range todos {
+		h.publish(models.EventTodoCreated, todo.ID, todo)
+	}
+
+	sendJSON(w, h.todoResponses(r, todos), http.StatusCreated)
+}
+
+// BulkUpdateTodos 批量更新待办事项
+// 请求体为数组，每一项包含 id 以及与单个更新相同的字段；任一项无效或不存在时一个都不更新
+func (h *Handler) BulkUpdateTodos(w http.ResponseWriter, r *http.Request) {
+	var items []*bulkUpdateItem
+	if err := decodeJSON(r, &items); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为数组", err)
+		return
+	}
+	if err := checkBulkSize(len(items)); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updates := make([]store.TodoUpdate, len(items))
+	for i, item := range items {
+		if item == nil || item.ID <= 0 {
+			sendError(w, fmt.Sprintf("第 %d 项：无效ID", i+1), http.StatusBadRequest)
+			return
+		}
+		if err := validateTodoRequest(&item.TodoRequest); err != nil {
+			sendError(w, fmt.Sprintf("第 %d 项：%v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		updates[i] = store.TodoUpdate{ID: item.ID, Request: &item.TodoRequest}
+	}
+
+	todos, err := h.storeFor(r).BulkUpdate(updates)
+	if err != nil {
+		sendBulkError(w, err, "批量更新失败")
+		return
+	}
+	for _, todo := range todos {
+		h.publish(models.EventTodoUpdated, todo.ID, todo)
+	}
+
+	sendJSON(w, h.todoResponses(r, todos), http.StatusOK)
+}
+
+// BulkDeleteTodos 批量删除待办事项
+// 请求体为ID数组，如 [1, 2, 3]；任一事项不存在时一个都不删除
+func (h *Handler) BulkDeleteTodos(w http.ResponseWriter, r *http.Request) {
+	var ids []int
+	if err := decodeJSON(r, &ids); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为ID数组", err)
+		return
+	}
+	if err := checkBulkSize(len(ids)); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.deleteTodos(r, ids); err != nil {
+		sendBulkError(w, err, "批量删除失败")
+		return
+	}
+
+	sendJSON(w, map[string]interface{}{"message": "删除成功", "deleted": len(ids)}, http.StatusOK)
+}
+
+// DeleteTodosByFilter 删除符合过滤条件的全部待办事项
+// 过滤参数与列表接口相同（如 ?completed=true&category=工作），至少需要一个，避免误删全部事项；
+// ?dry_run=true 时不删除，只返回将被删除的事项
+func (h *Handler) DeleteTodosByFilter(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var view listView
+	if err := view.parseFilter(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if view.Filter.IsZero() {
+		sendError(w, "至少需要一个过滤参数，如 ?completed=true", http.StatusBadRequest)
+		return
+	}
+
+	dryRun := false
+	if value := query.Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			sendError(w, "dry_run 必须是 true 或 false", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	todos, err := h.storeFor(r).ListTodos(store.ListOptions{SortField: models.SortByID, Filter: view.Filter})
+	if err != nil {
+		sendError(w, "读取待办事项失败", http.StatusInternalServerError)
+		return
+	}
+	if dryRun {
+		sendJSON(w, map[string]interface{}{
+			"dry_run": true,
+			"deleted": len(todos),
+			"todos":   h.todoResponses(r, todos),
+		}, http.StatusOK)
+		return
+	}
+
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	if len(ids) > 0 {
+		if err := h.deleteTodos(r, ids); err != nil {
+			sendBulkError(w, err, "批量删除失败")
+			return
+		}
+	}
+
+	sendJSON(w, map[string]interface{}{"message": "删除成功", "deleted": len(ids), "ids": ids}, http.StatusOK)
+}
+
+// deleteTodos 删除一批待办事项（启用回收站时移入回收站），任一事项不存在时一个都不删除
+// 删除后写入事件，并与单个删除一样清理指向这些事项的关联、它们的分享链接和关注者
+func (h *Handler) deleteTodos(r *http.Request, ids []int) error {
+	var err error
+	if h.config.Trash.Enabled {
+		err = h.moveToTrash(r, ids...)
+	} else {
+		err = h.storeFor(r).BulkDelete(ids)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		h.publish(models.EventTodoDeleted, id, nil)
+	}
+
+	h.removeLinksTo(ids...)
+	h.removeShareLinks(ids...)
+	h.removeWatchers(ids...)
+	h.removeDependencies(ids...)
+	return nil
+}
+
+// bulkCompleteRequest 批量标记完成请求
+type bulkCompleteRequest struct {
+	IDs []int `json:"ids"` // 要标记完成的事项ID
+}
+
+// bulkCompleteResult 批量标记完成中单个事项的结果
+type bulkCompleteResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`          // completed、already_completed、not_found 或 failed
+	Error  string `json:"error,omitempty"` // 失败原因
+}
+
+// 批量标记完成中单个事项的结果状态
+const (
+	bulkCompleted        = "completed"
+	bulkAlreadyCompleted = "already_completed"
+	bulkNotFound         = "not_found"
+	bulkFailed           = "failed"
+)
+
+// BulkCompleteTodos 批量标记待办事项为已完成
+// 请求体为 {"ids": [1, 2, 3]}，或者不带请求体、通过与列表接口相同的过滤参数（如 ?category=工作&overdue=true）选择未完成的事项，两者不能同时使用。
+// 每个事项在各自的事务中标记完成，一项失败不影响其它项；响应中逐项返回结果，已经完成的事项不会再次修改
+func (h *Handler) BulkCompleteTodos(w http.ResponseWriter, r *http.Request) {
+	var req bulkCompleteRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+			sendDecodeError(w, "无效数据，请求体应为 {\"ids\": [...]}", err)
+			return
+		}
+	}
+
+	var view listView
+	if err := view.parseFilter(r.URL.Query()); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := view.Filter
+
+	ids := req.IDs
+	switch {
+	case len(ids) > 0 && !filter.IsZero():
+		sendError(w, "ids 和过滤参数不能同时使用", http.StatusBadRequest)
+		return
+	case len(ids) == 0 && filter.IsZero():
+		sendError(w, "需要提供 ids 或至少一个过滤参数", http.StatusBadRequest)
+		return
+	case len(ids) == 0:
+		completed := false
+		filter.Completed = &completed
+		todos, err := h.storeFor(r).ListTodos(store.ListOptions{SortField: models.SortByID, Filter: filter})
+		if err != nil {
+			sendError(w, "读取待办事项失败", http.StatusInternalServerError)
+			return
+		}
+		if len(todos) > maxBulkItems {
+			sendError(w, fmt.Sprintf("匹配的事项有 %d 项，一次最多处理 %d 项，请缩小过滤范围", len(todos), maxBulkItems), http.StatusBadRequest)
+			return
+		}
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+	default:
+		if err := checkBulkSize(len(ids)); err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		seen := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			if seen[id] {
+				sendError(w, fmt.Sprintf("ID %d 重复", id), http.StatusBadRequest)
+				return
+			}
+			seen[id] = true
+		}
+	}
+
+	results := make([]bulkCompleteResult, len(ids))
+	completed, failed := 0, 0
+	for i, id := range ids {
+		results[i] = h.completeOne(r, id)
+		switch results[i].Status {
+		case bulkCompleted:
+			completed++
+		case bulkNotFound, bulkFailed:
+			failed++
+		}
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"results":   results,
+		"completed": completed,
+		"failed":    failed,
+	}, http.StatusOK)
+}
+
+// completeOne 在事务中把单个事项标记为已完成，已经完成的事项不修改
+func (h *Handler) completeOne(r *http.Request, id int) bulkCompleteResult {
+	result := bulkCompleteResult{ID: id, Status: bulkAlreadyCompleted}
+	var updated *models.Todo
+	err := h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil || todo.Completed {
+			return err
+		}
+
+		req := todo.Request()
+		req.Completed = true
+		updated, err = tx.UpdateTodo(id, req)
+		return err
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		result.Status, result.Error = bulkNotFound, "未找到"
+	case err != nil:
+		result.Status, result.Error = bulkFailed, err.Error()
+	case updated != nil:
+		result.Status = bulkCompleted
+		h.publish(models.EventTodoCompleted, updated.ID, updated)
+	}
+	return result
+}
+
+// checkBulkSize 检查批量操作的事项数
+func checkBulkSize(n int) error {
+	if n == 0 {
+		return errors.New("至少需要一项")
+	}
+	if n > maxBulkItems {
+		return fmt.Errorf("一次最多处理 %d 项", maxBulkItems)
+	}
+	return nil
+}
+
+// sendBulkError 按存储返回的错误发送批量更新、删除的错误响应
+func sendBulkError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, store.ErrDuplicateID):
+		sendError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, store.ErrVersionConflict):
+		sendError(w, err.Error(), http.StatusConflict)
+	default:
+		sendError(w, message, http.StatusInternalServerError)
+	}
+}
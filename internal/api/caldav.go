@@ -0,0 +1,697 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/ical"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// CalDAV 路径：根路径同时作为用户主体（principal）和日历主目录，tasks 为唯一的任务集合
+const (
+	caldavRoot       = "/caldav/"
+	caldavCollection = "/caldav/tasks/"
+)
+
+// caldavMaxBody CalDAV 请求体的最大长度
+const caldavMaxBody = 1 << 20
+
+// XML 命名空间
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+// caldavResource 集合中的一个任务资源
+type caldavResource struct {
+	Name string       // 资源名称，例如 "12.ics"
+	UID  string       // VTODO UID
+	Todo *models.Todo // 对应的待办事项
+}
+
+// href 资源的完整路径
+func (r *caldavResource) href() string {
+	return caldavCollection + url.PathEscape(r.Name)
+}
+
+// etag 资源的实体标签，事项每次修改都会变化
+func (r *caldavResource) etag() string {
+	return fmt.Sprintf(`"%d-%d"`, r.Todo.ID, r.Todo.UpdatedAt.UnixNano())
+}
+
+// data 资源的 iCalendar 数据
+func (r *caldavResource) data() []byte {
+	return ical.Encode([]ical.Entry{{Todo: r.Todo, UID: r.UID}})
+}
+
+// CalDAV CalDAV 任务同步入口
+// 提供一个 VTODO 任务集合（/caldav/tasks/），Apple 提醒事项、Thunderbird 等客户端可以双向同步；
+// 本服务没有用户体系，所有客户端共享同一个集合
+func (h *Handler) CalDAV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+
+	path := r.URL.Path
+	switch {
+	case path == strings.TrimSuffix(caldavRoot, "/") || path == strings.TrimSuffix(caldavCollection, "/"):
+		// 统一使用以 / 结尾的集合路径
+		http.Redirect(w, r, path+"/", http.StatusMovedPermanently)
+	case path == caldavRoot:
+		h.caldavRoot(w, r)
+	case path == caldavCollection:
+		h.caldavCollection(w, r)
+	case strings.HasPrefix(path, caldavCollection) && !strings.Contains(path[len(caldavCollection):], "/"):
+		h.caldavItem(w, r, path[len(caldavCollection):])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// CalDAVWellKnown 处理 /.well-known/caldav 服务发现，重定向到 CalDAV 根路径
+func (h *Handler) CalDAVWellKnown(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, caldavRoot, http.StatusMovedPermanently)
+}
+
+// caldavRoot 处理根路径（用户主体和日历主目录）
+func (h *Handler) caldavRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		caldavOptions(w, "OPTIONS, PROPFIND")
+	case "PROPFIND":
+		props, err := parsePropRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		responses := []string{caldavPropResponse(caldavRoot, props, h.rootProps())}
+		if r.Header.Get("Depth") != "0" {
+			collection, err := h.collectionProps()
+			if err != nil {
+				http.Error(w, "获取任务失败", http.StatusInternalServerError)
+				return
+			}
+			responses = append(responses, caldavPropResponse(caldavCollection, props, collection))
+		}
+		writeMultistatus(w, responses)
+	default:
+		caldavMethodNotAllowed(w, "OPTIONS, PROPFIND")
+	}
+}
+
+// caldavCollection 处理任务集合
+func (h *Handler) caldavCollection(w http.ResponseWriter, r *http.Request) {
+	const allow = "OPTIONS, PROPFIND, REPORT"
+
+	switch r.Method {
+	case http.MethodOptions:
+		caldavOptions(w, allow)
+	case "PROPFIND":
+		props, err := parsePropRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		collection, err := h.collectionProps()
+		if err != nil {
+			http.Error(w, "获取任务失败", http.StatusInternalServerError)
+			return
+		}
+		responses := []string{caldavPropResponse(caldavCollection, props, collection)}
+
+		if r.Header.Get("Depth") != "0" {
+			resources, err := h.caldavResources()
+			if err != nil {
+				http.Error(w, "获取任务失败", http.StatusInternalServerError)
+				return
+			}
+			for _, resource := range resources {
+				responses = append(responses, caldavPropResponse(resource.href(), props, itemProps(resource)))
+			}
+		}
+		writeMultistatus(w, responses)
+	case "REPORT":
+		h.caldavReport(w, r)
+	default:
+		caldavMethodNotAllowed(w, allow)
+	}
+}
+
+// caldavReport 处理 calendar-query 和 calendar-multiget 报告
+func (h *Handler) caldavReport(w http.ResponseWriter, r *http.Request) {
+	report, err := parseReport(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resources []*caldavResource
+	switch report.Kind {
+	case "calendar-query":
+		// 集合中只有 VTODO，只查询其它组件（如 VEVENT）时返回空结果
+		if report.wantsTodos() {
+			if resources, err = h.caldavResources(); err != nil {
+				http.Error(w, "获取任务失败", http.StatusInternalServerError)
+				return
+			}
+		}
+	case "calendar-multiget":
+		var responses []string
+		for _, href := range report.Hrefs {
+			name, err := url.PathUnescape(strings.TrimPrefix(href, caldavCollection))
+			if err != nil || !strings.HasPrefix(href, caldavCollection) {
+				responses = append(responses, caldavStatusResponse(href, http.StatusNotFound))
+				continue
+			}
+			resource, err := h.caldavLookup(name)
+			if err != nil {
+				responses = append(responses, caldavStatusResponse(href, http.StatusNotFound))
+				continue
+			}
+			responses = append(responses, caldavPropResponse(resource.href(), report.Props, itemProps(resource)))
+		}
+		writeMultistatus(w, responses)
+		return
+	default:
+		http.Error(w, "不支持的REPORT: "+report.Kind, http.StatusForbidden)
+		return
+	}
+
+	responses := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		responses = append(responses, caldavPropResponse(resource.href(), report.Props, itemProps(resource)))
+	}
+	writeMultistatus(w, responses)
+}
+
+// caldavItem 处理单个任务资源
+func (h *Handler) caldavItem(w http.ResponseWriter, r *http.Request, escapedName string) {
+	const allow = "OPTIONS, PROPFIND, GET, HEAD, PUT, DELETE"
+
+	name, err := url.PathUnescape(escapedName)
+	if err != nil || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		caldavOptions(w, allow)
+	case http.MethodGet, http.MethodHead:
+		resource, err := h.caldavLookup(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		data := resource.data()
+		w.Header().Set("Content-Type", ical.ContentType)
+		w.Header().Set("ETag", resource.etag())
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case "PROPFIND":
+		props, err := parsePropRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resource, err := h.caldavLookup(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeMultistatus(w, []string{caldavPropResponse(resource.href(), props, itemProps(resource))})
+	case http.MethodPut:
+		h.caldavPut(w, r, name)
+	case http.MethodDelete:
+		resource, err := h.caldavLookup(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != resource.etag() {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if h.config.Trash.Enabled {
+			err = h.moveToTrash(r, resource.Todo.ID)
+		} else {
+			err = h.storeFor(r).DeleteTodo(resource.Todo.ID)
+		}
+		if err != nil {
+			http.Error(w, "删除失败", http.StatusInternalServerError)
+			return
+		}
+		h.publish(models.EventTodoDeleted, resource.Todo.ID, nil)
+		h.removeLinksTo(resource.Todo.ID)
+		h.removeShareLinks(resource.Todo.ID)
+		h.removeWatchers(resource.Todo.ID)
+		h.removeDependencies(resource.Todo.ID)
+		if err := store.DeleteCalDAVResource(h.store, name); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			logf(r, "删除CalDAV资源 %s 的对应关系失败: %v", name, err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		caldavMethodNotAllowed(w, allow)
+	}
+}
+
+// caldavPut 创建或更新任务资源
+// 支持 If-Match 和 If-None-Match: * 条件请求，避免覆盖其它客户端的修改
+func (h *Handler) caldavPut(w http.ResponseWriter, r *http.Request, name string) {
+	if !strings.HasSuffix(name, ".ics") {
+		http.Error(w, "资源名称必须以 .ics 结尾", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, caldavMaxBody))
+	if err != nil {
+		http.Error(w, "读取请求失败", http.StatusRequestEntityTooLarge)
+		return
+	}
+	parsed, err := ical.Decode(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	req := parsed.Request
+	if req.Location != nil {
+		if err := req.Location.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	existing, err := h.caldavLookup(name)
+	if err != nil && !errors.Is(err, store.ErrTodoNotFound) {
+		http.Error(w, "获取任务失败", http.StatusInternalServerError)
+		return
+	}
+
+	// 条件请求检查
+	if existing != nil && r.Header.Get("If-None-Match") == "*" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" {
+		if existing == nil || (match != "*" && match != existing.etag()) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+	}
+
+	if existing == nil {
+		todo, err := h.storeFor(r).CreateTodo(req)
+		if errors.Is(err, store.ErrStoreFull) {
+			http.Error(w, err.Error(), http.StatusInsufficientStorage)
+			return
+		}
+		if err != nil {
+			http.Error(w, "创建失败", http.StatusInternalServerError)
+			return
+		}
+		h.publish(models.EventTodoCreated, todo.ID, todo)
+		resource := &caldavResource{Name: name, UID: parsed.UID, Todo: todo}
+		if resource.UID == "" {
+			resource.UID = defaultCalDAVUID(todo.ID)
+		}
+		if err := store.SaveCalDAVResource(h.store, &models.CalDAVResource{Name: name, TodoID: todo.ID, UID: resource.UID}); err != nil {
+			http.Error(w, "保存失败", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("ETag", resource.etag())
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	// 更新时只修改 iCalendar 中包含的字段，关联链接等其它数据保持不变
+	todo := existing.Todo
+	todo.Title = req.Title
+	todo.Description = req.Description
+	todo.SetCompleted(req.Completed, time.Now())
+	todo.Priority = req.Priority
+	todo.Category = req.Category
+	todo.DueDate = req.DueDate
+	todo.Location = req.Location
+	saved, err := h.storeFor(r).SaveTodo(todo)
+	if err != nil {
+		http.Error(w, "更新失败", http.StatusInternalServerError)
+		return
+	}
+	h.publish(models.EventTodoUpdated, saved.ID, saved)
+
+	// 客户端修改了UID时更新对应关系
+	if parsed.UID != "" && parsed.UID != existing.UID {
+		if err := store.SaveCalDAVResource(h.store, &models.CalDAVResource{Name: name, TodoID: saved.ID, UID: parsed.UID}); err != nil {
+			logf(r, "保存CalDAV资源 %s 的对应关系失败: %v", name, err)
+		}
+	}
+
+	w.Header().Set("ETag", (&caldavResource{Todo: saved}).etag())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// caldavResources 列出集合中的所有资源
+// 有对应关系的事项使用客户端的资源名称和UID，其它事项使用 {id}.ics；指向已删除事项的对应关系会被清理
+func (h *Handler) caldavResources() ([]*caldavResource, error) {
+	mappings, err := store.ListCalDAVResources(h.store)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := h.store.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*caldavResource, 0, len(todos))
+	existing := make(map[int]bool, len(todos))
+	for _, todo := range todos {
+		existing[todo.ID] = true
+		resources = append(resources, newCalDAVResource(todo, mappings[todo.ID]))
+	}
+
+	for id, mapping := range mappings {
+		if !existing[id] {
+			if err := store.DeleteCalDAVResource(h.store, mapping.Name); err != nil {
+				log.Printf("清理CalDAV资源 %s 的对应关系失败: %v", mapping.Name, err)
+			}
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Todo.ID < resources[j].Todo.ID
+	})
+	return resources, nil
+}
+
+// caldavLookup 根据资源名称查找资源，不存在时返回 store.ErrTodoNotFound
+func (h *Handler) caldavLookup(name string) (*caldavResource, error) {
+	mapping, err := store.GetCalDAVResource(h.store, name)
+	switch {
+	case err == nil:
+		todo, err := h.store.GetTodoByID(mapping.TodoID)
+		if err != nil {
+			return nil, store.ErrTodoNotFound
+		}
+		return newCalDAVResource(todo, mapping), nil
+	case !errors.Is(err, store.ErrMetaNotFound):
+		return nil, err
+	}
+
+	// 没有对应关系时按 {id}.ics 查找服务端创建的事项
+	id, err := strconv.Atoi(strings.TrimSuffix(name, ".ics"))
+	if err != nil || !strings.HasSuffix(name, ".ics") {
+		return nil, store.ErrTodoNotFound
+	}
+	todo, err := h.store.GetTodoByID(id)
+	if err != nil {
+		return nil, store.ErrTodoNotFound
+	}
+	return newCalDAVResource(todo, nil), nil
+}
+
+// newCalDAVResource 根据事项和对应关系（可以为nil）生成资源
+func newCalDAVResource(todo *models.Todo, mapping *models.CalDAVResource) *caldavResource {
+	if mapping != nil {
+		return &caldavResource{Name: mapping.Name, UID: mapping.UID, Todo: todo}
+	}
+	return &caldavResource{Name: strconv.Itoa(todo.ID) + ".ics", UID: defaultCalDAVUID(todo.ID), Todo: todo}
+}
+
+// defaultCalDAVUID 服务端创建的事项使用的UID
+func defaultCalDAVUID(id int) string {
+	return fmt.Sprintf("xstreamtool-todo-%d", id)
+}
+
+// rootProps 根路径的属性，值为XML片段
+func (h *Handler) rootProps() map[string]string {
+	href := "<D:href>" + caldavRoot + "</D:href>"
+	return map[string]string{
+		nsDAV + " resourcetype":               "<D:collection/><D:principal/>",
+		nsDAV + " displayname":                "xStreamTool",
+		nsDAV + " current-user-principal":     href,
+		nsDAV + " principal-URL":              href,
+		nsDAV + " owner":                      href,
+		nsCalDAV + " calendar-home-set":       href,
+		nsDAV + " current-user-privilege-set": caldavPrivileges,
+	}
+}
+
+// collectionProps 任务集合的属性，值为XML片段
+// getctag 由所有资源的名称和 ETag 计算，任意任务变化时都会改变，客户端据此判断是否需要同步
+func (h *Handler) collectionProps() (map[string]string, error) {
+	resources, err := h.caldavResources()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := fnv.New64a()
+	for _, resource := range resources {
+		io.WriteString(hash, resource.Name+resource.etag()+"\n")
+	}
+	ctag := fmt.Sprintf(`"%x-%d"`, hash.Sum64(), len(resources))
+
+	href := "<D:href>" + caldavRoot + "</D:href>"
+	return map[string]string{
+		nsDAV + " resourcetype":                        "<D:collection/><C:calendar/>",
+		nsDAV + " displayname":                         "xStreamTool 待办事项",
+		nsDAV + " current-user-principal":              href,
+		nsDAV + " owner":                               href,
+		nsDAV + " current-user-privilege-set":          caldavPrivileges,
+		nsDAV + " getetag":                             xmlEscape(ctag),
+		nsCS + " getctag":                              xmlEscape(ctag),
+		nsCalDAV + " supported-calendar-component-set": `<C:comp name="VTODO"/>`,
+		nsCalDAV + " calendar-description":             "xStreamTool 待办事项同步",
+	}, nil
+}
+
+// itemProps 任务资源的属性，值为XML片段
+func itemProps(resource *caldavResource) map[string]string {
+	data := resource.data()
+	return map[string]string{
+		nsDAV + " resourcetype":     "",
+		nsDAV + " getetag":          xmlEscape(resource.etag()),
+		nsDAV + " getcontenttype":   ical.ContentType,
+		nsDAV + " getcontentlength": strconv.Itoa(len(data)),
+		nsDAV + " getlastmodified":  resource.Todo.UpdatedAt.UTC().Format(http.TimeFormat),
+		nsCalDAV + " calendar-data": xmlEscape(string(data)),
+	}
+}
+
+// caldavPrivileges 当前用户在集合上的权限：本服务没有权限控制，客户端拥有全部权限
+const caldavPrivileges = "<D:privilege><D:all/></D:privilege>" +
+	"<D:privilege><D:read/></D:privilege>" +
+	"<D:privilege><D:write/></D:privilege>" +
+	"<D:privilege><D:write-content/></D:privilege>" +
+	"<D:privilege><D:bind/></D:privilege>" +
+	"<D:privilege><D:unbind/></D:privilege>"
+
+// propRequest 客户端请求的属性列表
+type propRequest struct {
+	All   bool       // allprop 或请求体为空时返回所有属性
+	Names []xml.Name // 请求的属性名称
+}
+
+// caldavReportRequest REPORT 请求
+type caldavReportRequest struct {
+	Kind        string      // 报告类型，例如 calendar-query
+	Props       propRequest // 请求的属性
+	Hrefs       []string    // calendar-multiget 请求的资源路径
+	CompFilters []string    // calendar-query 过滤条件中的组件名称
+}
+
+// wantsTodos 判断 calendar-query 是否需要返回 VTODO
+// 没有组件过滤条件、过滤 VTODO 或只过滤 VCALENDAR 时都返回所有任务
+func (r *caldavReportRequest) wantsTodos() bool {
+	for _, name := range r.CompFilters {
+		if !strings.EqualFold(name, "VCALENDAR") && !strings.EqualFold(name, "VTODO") {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePropRequest 解析 PROPFIND 请求体
+func parsePropRequest(r *http.Request) (propRequest, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, caldavMaxBody))
+	if err != nil {
+		return propRequest{}, errors.New("读取请求失败")
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return propRequest{All: true}, nil
+	}
+
+	report, err := parseDAVBody(data)
+	if err != nil {
+		return propRequest{}, err
+	}
+	return report.Props, nil
+}
+
+// parseReport 解析 REPORT 请求体
+func parseReport(r *http.Request) (*caldavReportRequest, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, caldavMaxBody))
+	if err != nil {
+		return nil, errors.New("读取请求失败")
+	}
+	return parseDAVBody(data)
+}
+
+// parseDAVBody 解析 PROPFIND/REPORT 的XML请求体
+// 只提取需要的信息：根元素名称、prop 下的属性名称、href 和 comp-filter 的组件名称
+func parseDAVBody(data []byte) (*caldavReportRequest, error) {
+	decoder := xml.NewDecoder(strings.NewReader(string(data)))
+	result := &caldavReportRequest{}
+
+	var stack []xml.Name
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("无效的XML请求体")
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				result.Kind = element.Name.Local
+			}
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].Local
+			}
+			switch {
+			case parent == "prop" && len(stack) == 2:
+				result.Props.Names = append(result.Props.Names, element.Name)
+			case element.Name.Local == "allprop" && len(stack) == 1:
+				result.Props.All = true
+			case element.Name.Local == "comp-filter":
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "name" {
+						result.CompFilters = append(result.CompFilters, attr.Value)
+					}
+				}
+			}
+			stack = append(stack, element.Name)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 && stack[len(stack)-1].Local == "href" {
+				if href := strings.TrimSpace(string(element)); href != "" {
+					result.Hrefs = append(result.Hrefs, href)
+				}
+			}
+		}
+	}
+
+	if result.Kind == "" {
+		return nil, errors.New("无效的XML请求体")
+	}
+	if !result.Props.All && len(result.Props.Names) == 0 && result.Kind == "propfind" {
+		result.Props.All = true
+	}
+	return result, nil
+}
+
+// caldavPropResponse 生成单个资源的 response 元素
+// 请求的属性中存在的放在 200 propstat 中，不存在的放在 404 propstat 中
+func caldavPropResponse(href string, req propRequest, props map[string]string) string {
+	var found, missing strings.Builder
+
+	if req.All {
+		keys := make([]string, 0, len(props))
+		for key := range props {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			space, local, _ := strings.Cut(key, " ")
+			found.WriteString(propElement(space, local, props[key]))
+		}
+	} else {
+		for _, name := range req.Names {
+			value, exists := props[name.Space+" "+name.Local]
+			if exists {
+				found.WriteString(propElement(name.Space, name.Local, value))
+			} else {
+				missing.WriteString(propElement(name.Space, name.Local, ""))
+			}
+		}
+	}
+
+	var response strings.Builder
+	response.WriteString("<D:response><D:href>" + xmlEscape(href) + "</D:href>")
+	if found.Len() > 0 {
+		response.WriteString("<D:propstat><D:prop>" + found.String() + "</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat>")
+	}
+	if missing.Len() > 0 {
+		response.WriteString("<D:propstat><D:prop>" + missing.String() + "</D:prop><D:status>HTTP/1.1 404 Not Found</D:status></D:propstat>")
+	}
+	response.WriteString("</D:response>")
+	return response.String()
+}
+
+// caldavStatusResponse 生成只有状态的 response 元素（用于不存在的资源）
+func caldavStatusResponse(href string, status int) string {
+	return fmt.Sprintf("<D:response><D:href>%s</D:href><D:status>HTTP/1.1 %d %s</D:status></D:response>",
+		xmlEscape(href), status, http.StatusText(status))
+}
+
+// propElement 生成属性元素，已知命名空间使用固定前缀，其它命名空间内联声明
+func propElement(space, local, value string) string {
+	prefix := map[string]string{nsDAV: "D", nsCalDAV: "C", nsCS: "CS"}[space]
+	if prefix == "" {
+		return fmt.Sprintf(`<X:%s xmlns:X="%s">%s</X:%s>`, local, xmlEscape(space), value, local)
+	}
+	if value == "" {
+		return "<" + prefix + ":" + local + "/>"
+	}
+	return "<" + prefix + ":" + local + ">" + value + "</" + prefix + ":" + local + ">"
+}
+
+// writeMultistatus 输出 207 Multi-Status 响应
+func writeMultistatus(w http.ResponseWriter, responses []string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	fmt.Fprintf(w, `<D:multistatus xmlns:D="%s" xmlns:C="%s" xmlns:CS="%s">`, nsDAV, nsCalDAV, nsCS)
+	for _, response := range responses {
+		io.WriteString(w, response)
+	}
+	io.WriteString(w, "</D:multistatus>")
+}
+
+// caldavOptions 响应 OPTIONS 请求
+func caldavOptions(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	w.WriteHeader(http.StatusOK)
+}
+
+// caldavMethodNotAllowed 响应不支持的方法
+func caldavMethodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+}
+
+// xmlEscape 转义XML文本
+func xmlEscape(text string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
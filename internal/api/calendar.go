@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// CalendarMonth 日历月视图：按截止日期把指定月份的事项分到每一天，供日历组件使用
+// 日期按用户时区划分（见 userLocation）；默认包含已完成的事项，?show_completed=false 时只返回未完成的事项，
+// 已归档的事项只在 ?include_archived=true 时返回；支持列表接口的 category、priority 等过滤参数，截止日期范围由月份决定
+func (h *Handler) CalendarMonth(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	year, err := strconv.Atoi(vars["year"])
+	if err != nil || year < 1 || year > 9999 {
+		sendError(w, "无效的年份", http.StatusBadRequest)
+		return
+	}
+	month, err := strconv.Atoi(vars["month"])
+	if err != nil || month < 1 || month > 12 {
+		sendError(w, "月份必须是1到12之间的整数", http.StatusBadRequest)
+		return
+	}
+
+	loc, err := h.userLocation(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	view := listView{ShowCompleted: true, Sort: []models.SortKey{{Field: models.SortByDueDate}}}
+	query := r.URL.Query()
+	if value := query.Get("show_completed"); value != "" {
+		showCompleted, err := strconv.ParseBool(value)
+		if err != nil {
+			sendError(w, "show_completed 必须是 true 或 false", http.StatusBadRequest)
+			return
+		}
+		view.ShowCompleted = showCompleted
+	}
+	if err := view.parseArchived(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := view.parseFilter(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 1, 0)
+	view.Filter.DueAfter, view.Filter.DueBefore = &from, &to
+
+	todos, err := h.store.ListTodos(view.listOptions())
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	todos, total := view.apply(todos, nil)
+
+	calendar := models.CalendarMonth{Year: year, Month: month, Timezone: loc.String(), Total: total}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		calendar.Days = append(calendar.Days, models.CalendarDay{
+			Date:    day.Format("2006-01-02"),
+			Weekday: int(day.Weekday()),
+			Todos:   []models.TodoResponse{},
+		})
+	}
+	responses := h.todoResponses(r, todos)
+	for i, todo := range todos {
+		day := &calendar.Days[todo.DueDate.In(loc).Day()-1]
+		day.Todos = append(day.Todos, responses[i])
+		if todo.Completed {
+			day.Completed++
+		}
+	}
+
+	sendJSONWithETag(w, r, calendar)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// ListCategoryDefaults 获取所有分类的默认设置
+func (h *Handler) ListCategoryDefaults(w http.ResponseWriter, r *http.Request) {
+	defaults, err := store.ListCategoryDefaults(h.store)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, defaults, http.StatusOK)
+}
+
+// GetCategoryDefaults 获取单个分类的默认设置
+func (h *Handler) GetCategoryDefaults(w http.ResponseWriter, r *http.Request) {
+	category := mux.Vars(r)["name"]
+
+	defaults, err := store.GetCategoryDefaults(h.store, category)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, defaults, http.StatusOK)
+}
+
+// UpdateCategoryDefaults 设置分类的默认设置
+func (h *Handler) UpdateCategoryDefaults(w http.ResponseWriter, r *http.Request) {
+	var defaults models.CategoryDefaults
+	if err := decodeJSON(r, &defaults); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+
+	if !defaults.Priority.IsValid() {
+		sendError(w, models.ErrInvalidPriority.Error(), http.StatusBadRequest)
+		return
+	}
+	if defaults.RemindOffset < 0 {
+		sendError(w, models.ErrInvalidRemindOffset.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 分类名称以路径为准
+	defaults.Category = mux.Vars(r)["name"]
+
+	if err := store.SaveCategoryDefaults(h.store, &defaults); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, defaults, http.StatusOK)
+}
+
+// DeleteCategoryDefaults 删除分类的默认设置
+func (h *Handler) DeleteCategoryDefaults(w http.ResponseWriter, r *http.Request) {
+	category := mux.Vars(r)["name"]
+
+	if err := store.DeleteCategoryDefaults(h.store, category); err != nil {
+		sendError(w, "删除失败", http.StatusNotFound)
+		return
+	}
+
+	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
+}
+
+// applyCategoryDefaults 将分类默认设置应用到创建请求
+// 分类未配置默认设置时不做任何修改
+func (h *Handler) applyCategoryDefaults(req *models.TodoRequest) error {
+	if req.Category == "" {
+		return nil
+	}
+
+	defaults, err := store.GetCategoryDefaults(h.store, req.Category)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	defaults.Apply(req)
+	return nil
+}
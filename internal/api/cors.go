@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsExposedHeaders 允许跨域请求的脚本读取的响应头（简单响应头之外的）
+var corsExposedHeaders = []string{
+	"ETag", "Last-Modified", "Link", "X-Total-Count", "Retry-After", "Warning", "X-Cache", DegradedHeader, RequestIDHeader,
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}
+
+// allowedOrigin 返回 Access-Control-Allow-Origin 的值，来源不在 server.allowed_origins 中时返回空字符串
+// 配置了 "*" 时返回 "*"，否则原样返回请求的来源
+func (h *Handler) allowedOrigin(origin string) string {
+	h.configMu.RLock()
+	origins := h.config.Server.AllowedOrigins
+	h.configMu.RUnlock()
+	for _, allowed := range origins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsMiddleware 跨域中间件
+// 请求带有 Origin 且来源在 server.allowed_origins 中时设置 Access-Control-Allow-Origin 等响应头，
+// 不在其中的来源不设置，由浏览器拒绝跨域读取；不带 Origin 的请求（同源请求、curl 等）不受影响
+func (h *Handler) corsMiddleware(next http.Handler) http.Handler {
+	exposed := strings.Join(corsExposedHeaders, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 响应内容随 Origin 变化，缓存需要区分
+		w.Header().Add("Vary", "Origin")
+		if allowed := h.allowedOrigin(origin); allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			w.Header().Set("Access-Control-Expose-Headers", exposed)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Preflight 处理 OPTIONS 请求，包括浏览器跨域前的预检请求
+// Access-Control-Allow-Origin 由 corsMiddleware 设置，这里返回允许的方法、请求头和预检结果的缓存时间
+func (h *Handler) Preflight(w http.ResponseWriter, r *http.Request) {
+	server := h.config.Server
+	w.Header().Set("Allow", strings.Join(append([]string{"OPTIONS"}, server.AllowedMethods...), ", "))
+
+	if r.Header.Get("Access-Control-Request-Method") != "" && w.Header().Get("Access-Control-Allow-Origin") != "" {
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(server.AllowedMethods, ", "))
+
+		headers := strings.Join(server.AllowedHeaders, ", ")
+		for _, header := range server.AllowedHeaders {
+			if header == "*" {
+				// 允许任意请求头时原样返回预检请求中列出的请求头
+				headers = r.Header.Get("Access-Control-Request-Headers")
+				break
+			}
+		}
+		if headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		if server.CORSMaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(server.CORSMaxAge))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
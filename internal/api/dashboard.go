@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// 仪表盘参数
+const (
+	dashboardTopN         = 5                // 即将到期、已过期列表最多返回的数量
+	dashboardRecentLimit  = 10               // 最近活动最多返回的数量
+	dashboardUpcomingDays = 7                // 多少天内到期算作即将到期
+	dashboardCacheTTL     = 30 * time.Second // 没有新事件时缓存的最长有效期，过期状态会随时间变化
+)
+
+// dashboardCache 仪表盘数据缓存
+// 缓存像集成方一样从事件发件箱读取新事件：读到新事件（包括其它实例产生的）即失效，
+// 读到的事件同时用于生成最近活动
+type dashboardCache struct {
+	mu      sync.Mutex
+	lastSeq int64                    // 已读取的最后一个事件序号
+	recent  []models.Activity        // 最近活动，最新的在前
+	widgets *models.DashboardWidgets // 缓存的数据，为nil表示需要重新生成
+}
+
+// GetDashboardWidgets 获取仪表盘所需的全部数据块（计数器、即将到期、已过期、最近活动）
+// 数据会缓存，有新事件或超过缓存有效期后重新生成；ETag 为内容的哈希，数据没有变化时按 If-None-Match 返回 304
+func (h *Handler) GetDashboardWidgets(w http.ResponseWriter, r *http.Request) {
+	c := h.dashboard
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fresh, err := c.catchUp(h)
+	if err != nil {
+		sendError(w, "获取事件失败", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	if !fresh || c.widgets == nil || now.Sub(c.widgets.GeneratedAt) > dashboardCacheTTL {
+		todos, err := h.store.GetAllTodos()
+		if err != nil {
+			sendError(w, "获取失败", http.StatusInternalServerError)
+			return
+		}
+		c.widgets = buildDashboard(todos, now)
+		c.widgets.RecentActivity = append([]models.Activity{}, c.recent...)
+		c.widgets.LastSeq = c.lastSeq
+		w.Header().Set("X-Cache", "MISS")
+	} else {
+		w.Header().Set("X-Cache", "HIT")
+	}
+
+	// 缓存的数据使用默认语言区域，按请求者的语言区域复制一份
+	widgets := *c.widgets
+	locale := h.locale(r)
+	widgets.Upcoming = localizeResponses(c.widgets.Upcoming, locale)
+	widgets.Overdue = localizeResponses(c.widgets.Overdue, locale)
+	sendJSONWithETag(w, r, &widgets)
+}
+
+// catchUp 读取上次之后的新事件并更新最近活动，没有新事件时返回 true
+func (c *dashboardCache) catchUp(h *Handler) (bool, error) {
+	fresh := true
+	for {
+		events, err := h.events.ListEvents(c.lastSeq, maxEventLimit)
+		if err != nil {
+			return false, err
+		}
+		for _, event := range events {
+			c.recent = append([]models.Activity{newActivity(event)}, c.recent...)
+			c.lastSeq = event.Seq
+			fresh = false
+		}
+		if len(c.recent) > dashboardRecentLimit {
+			c.recent = c.recent[:dashboardRecentLimit]
+		}
+		if len(events) < maxEventLimit {
+			return fresh, nil
+		}
+	}
+}
+
+// newActivity 由事件生成最近活动，标题取自事件中的待办事项
+func newActivity(event *models.Event) models.Activity {
+	activity := models.Activity{
+		Seq:       event.Seq,
+		Type:      event.Type,
+		TodoID:    event.TodoID,
+		CreatedAt: event.CreatedAt,
+	}
+	if len(event.Data) > 0 {
+		var todo models.Todo
+		if err := json.Unmarshal(event.Data, &todo); err == nil {
+			activity.Title = todo.Title
+		}
+	}
+	return activity
+}
+
+// buildDashboard 统计计数器并选出即将到期、已过期的待办事项
+func buildDashboard(todos []*models.Todo, now time.Time) *models.DashboardWidgets {
+	year, month, day := now.Date()
+	endOfToday := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+	upcomingEnd := now.AddDate(0, 0, dashboardUpcomingDays)
+
+	widgets := &models.DashboardWidgets{
+		Counters:    models.DashboardCounters{ByCategory: map[string]int{}},
+		GeneratedAt: now,
+	}
+	var upcoming, overdue []*models.Todo
+	for _, todo := range todos {
+		counters := &widgets.Counters
+		counters.Total++
+		if todo.Completed {
+			counters.Completed++
+			continue
+		}
+		counters.Active++
+		counters.ByCategory[todo.Category]++
+
+		if !todo.HasDueDate() {
+			continue
+		}
+		if todo.DueDate.Before(now) {
+			counters.Overdue++
+			overdue = append(overdue, todo)
+			continue
+		}
+		if todo.DueDate.Before(endOfToday) {
+			counters.DueToday++
+		}
+		if todo.DueDate.Before(upcomingEnd) {
+			counters.Upcoming++
+			upcoming = append(upcoming, todo)
+		}
+	}
+
+	widgets.Upcoming = topByDueDate(upcoming)
+	widgets.Overdue = topByDueDate(overdue)
+	return widgets
+}
+
+// localizeResponses 返回按语言区域设置了 display_status 的副本
+func localizeResponses(responses []models.TodoResponse, locale string) []models.TodoResponse {
+	localized := make([]models.TodoResponse, len(responses))
+	copy(localized, responses)
+	for i := range localized {
+		localized[i].Localize(locale)
+	}
+	return localized
+}
+
+// topByDueDate 按截止时间升序（相同时优先级高的在前）取前 dashboardTopN 个
+func topByDueDate(todos []*models.Todo) []models.TodoResponse {
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DueAt().Equal(todos[j].DueAt()) {
+			return todos[i].DueAt().Before(todos[j].DueAt())
+		}
+		return todos[i].Priority > todos[j].Priority
+	})
+	if len(todos) > dashboardTopN {
+		todos = todos[:dashboardTopN]
+	}
+
+	responses := make([]models.TodoResponse, len(todos))
+	for i, todo := range todos {
+		responses[i] = todo.ToResponse()
+	}
+	return responses
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// DegradedHeader 降级模式下读请求的响应头，值为 true 表示数据来自只读副本，可能不是最新的
+const DegradedHeader = "X-Store-Degraded"
+
+// recoveredEvent store.recovered 事件的数据
+type recoveredEvent struct {
+	DegradedAt      time.Time `json:"degraded_at"`          // 进入降级模式的时间
+	RecoveredAt     time.Time `json:"recovered_at"`         // 恢复的时间
+	DowntimeSeconds float64   `json:"downtime_seconds"`     // 不可用的时长（秒）
+	LastError       string    `json:"last_error,omitempty"` // 降级期间最后一次检查失败的原因
+}
+
+// watchRecovery 启用了降级模式时，在存储后端恢复后写入 store.recovered 事件，集成方据此重试降级期间被拒绝的写请求
+func (h *Handler) watchRecovery() {
+	degradable := store.FindDegradable(h.store)
+	if degradable == nil {
+		return
+	}
+	degradable.OnRecover(func(recovery store.Recovery) {
+		data, err := json.Marshal(recoveredEvent{
+			DegradedAt:      recovery.DegradedAt,
+			RecoveredAt:     recovery.RecoveredAt,
+			DowntimeSeconds: math.Round(recovery.RecoveredAt.Sub(recovery.DegradedAt).Seconds()),
+			LastError:       recovery.LastError,
+		})
+		if err != nil {
+			log.Printf("编码事件 %s 失败: %v", models.EventStoreRecovered, err)
+			return
+		}
+		event := &models.Event{Type: models.EventStoreRecovered, Data: data, CreatedAt: recovery.RecoveredAt}
+		if err := h.events.AppendEvent(event); err != nil {
+			log.Printf("写入事件 %s 失败: %v", models.EventStoreRecovered, err)
+		}
+	})
+}
+
+// degradedMiddleware 降级模式中间件
+// 存储后端不可用时，读请求照常处理（数据来自只读副本），响应带有 X-Store-Degraded 和 Warning 头；
+// 写请求不再访问存储，直接返回 503，并通过 Retry-After 告知客户端下一次检查后端的时间
+func (h *Handler) degradedMiddleware(next http.Handler) http.Handler {
+	degradable := store.FindDegradable(h.store)
+	if degradable == nil {
+		return next
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(degradable.RetryAfter().Seconds())))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !degradable.Degraded() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			w.Header().Set(DegradedHeader, "true")
+			w.Header().Set("Warning", `110 - "Response is Stale"`)
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set(DegradedHeader, "true")
+			w.Header().Set("Retry-After", retryAfter)
+			sendError(w, "存储暂时不可用，请稍后重试", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// GetDegradedStatus 管理接口：查询降级模式的状态
+// 存储后端可用时返回 200，处于降级模式时返回 503，便于监控系统告警；未启用降级模式时返回 404
+func (h *Handler) GetDegradedStatus(w http.ResponseWriter, r *http.Request) {
+	degradable := store.FindDegradable(h.store)
+	if degradable == nil {
+		sendError(w, "未启用降级模式", http.StatusNotFound)
+		return
+	}
+
+	status := degradable.Status()
+	code := http.StatusOK
+	if status.Degraded {
+		code = http.StatusServiceUnavailable
+	}
+	sendJSON(w, status, code)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// 修改阻塞依赖时在事务中检查出的错误
+var (
+	errDependencyTargetNotFound = errors.New("目标待办事项不存在")
+	errDependencyExists         = errors.New("依赖已存在")
+	errDependencyCycle          = errors.New("添加后依赖会形成环，事项将互相等待")
+	errDependencyNotFound       = errors.New("依赖不存在")
+)
+
+// dependencyRequest 添加阻塞依赖请求
+type dependencyRequest struct {
+	TargetID int `json:"target_id"` // 需要先完成的待办事项ID
+}
+
+// dependenciesResponse 待办事项的阻塞依赖
+type dependenciesResponse struct {
+	BlockedBy []models.DependencyTarget `json:"blocked_by"` // 需要先完成的事项，按添加顺序排列
+	Blocked   bool                      `json:"blocked"`    // 是否还有未完成的依赖
+}
+
+// GetTodoDependencies 获取待办事项等待的事项及其完成状态
+func (h *Handler) GetTodoDependencies(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	s := h.storeFor(r)
+	if _, err := s.GetTodoByID(id); err != nil {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	deps, err := store.GetDependencies(s, id)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.dependenciesResponse(s, deps)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, response, http.StatusOK)
+}
+
+// CreateTodoDependency 添加阻塞依赖：待办事项需要等待目标事项完成
+// 不能依赖自身或不存在的事项，也不能形成环
+func (h *Handler) CreateTodoDependency(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dependencyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if req.TargetID == id {
+		sendError(w, "不能依赖自身", http.StatusBadRequest)
+		return
+	}
+
+	var deps *models.Dependencies
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		if _, err := tx.GetTodoByID(id); err != nil {
+			return err
+		}
+		if _, err := tx.GetTodoByID(req.TargetID); errors.Is(err, store.ErrTodoNotFound) {
+			return errDependencyTargetNotFound
+		} else if err != nil {
+			return err
+		}
+
+		all, err := store.ListDependencies(tx)
+		if err != nil {
+			return err
+		}
+		deps = all[id]
+		if deps == nil {
+			deps = &models.Dependencies{TodoID: id}
+		}
+		if deps.IsBlockedBy(req.TargetID) {
+			return errDependencyExists
+		}
+		if dependsOn(all, req.TargetID, id) {
+			return errDependencyCycle
+		}
+
+		deps.BlockedBy = append(deps.BlockedBy, req.TargetID)
+		return store.SaveDependencies(tx, deps)
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errDependencyTargetNotFound):
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errDependencyExists), errors.Is(err, errDependencyCycle):
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.dependenciesResponse(h.storeFor(r), deps)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, response, http.StatusCreated)
+}
+
+// DeleteTodoDependency 删除待办事项对目标事项的阻塞依赖
+func (h *Handler) DeleteTodoDependency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+	targetID, err := strconv.Atoi(vars["target"])
+	if err != nil {
+		sendError(w, "无效的目标ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		deps, err := store.GetDependencies(tx, id)
+		if err != nil {
+			return err
+		}
+		if !deps.Remove(targetID) {
+			return errDependencyNotFound
+		}
+		return store.SaveDependencies(tx, deps)
+	})
+	switch {
+	case errors.Is(err, errDependencyNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		sendError(w, "删除失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
+}
+
+// dependenciesResponse 读取依赖的目标事项，生成响应；目标事项已被删除时不包含在响应中
+func (h *Handler) dependenciesResponse(s store.TodoStore, deps *models.Dependencies) (*dependenciesResponse, error) {
+	response := &dependenciesResponse{BlockedBy: make([]models.DependencyTarget, 0, len(deps.BlockedBy))}
+	for _, targetID := range deps.BlockedBy {
+		target, err := s.GetTodoByID(targetID)
+		if errors.Is(err, store.ErrTodoNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		response.BlockedBy = append(response.BlockedBy, models.DependencyTarget{ID: target.ID, Title: target.Title, Completed: target.Completed})
+		if !target.Completed {
+			response.Blocked = true
+		}
+	}
+	return response, nil
+}
+
+// dependsOn 判断 from 是否直接或间接地等待 to
+func dependsOn(all map[int]*models.Dependencies, from, to int) bool {
+	visited := make(map[int]bool)
+	pending := []int{from}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if id == to {
+			return true
+		}
+		if visited[id] || all[id] == nil {
+			continue
+		}
+		visited[id] = true
+		pending = append(pending, all[id].BlockedBy...)
+	}
+	return false
+}
+
+// removeDependencies 删除已删除事项的依赖记录，以及其它事项对它们的依赖
+func (h *Handler) removeDependencies(ids ...int) {
+	all, err := store.ListDependencies(h.store)
+	if err != nil {
+		log.Printf("清理阻塞依赖失败: %v", err)
+		return
+	}
+
+	deleted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	for todoID, deps := range all {
+		changed := false
+		if deleted[todoID] {
+			deps.BlockedBy, changed = nil, true
+		}
+		for _, id := range ids {
+			if deps.Remove(id) {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := store.SaveDependencies(h.store, deps); err != nil {
+			log.Printf("清理待办事项 %d 的阻塞依赖失败: %v", todoID, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// etagMatches 判断 If-None-Match 请求头是否包含指定的 ETag
+// 请求头可以是逗号分隔的多个 ETag 或 *，按弱比较忽略 W/ 前缀
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified 客户端缓存的版本与 ETag 一致时返回 304 并返回 true，调用方不再发送响应体
+// 调用前应已设置 ETag 及需要保留的其它响应头
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// sendJSONWithETag 以响应内容的哈希作为 ETag 发送 200 响应，与 If-None-Match 一致时返回 304 不带响应体
+// 用于列表等没有版本号的响应，轮询的客户端在数据没有变化时不必重复下载
+func sendJSONWithETag(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		logf(r, "JSON编码错误: %v", err)
+		sendError(w, "编码失败", http.StatusInternalServerError)
+		return
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if notModified(w, r, etag) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// errPreconditionFailed 写请求的 If-Match 或 If-Unmodified-Since 条件不满足
+var errPreconditionFailed = errors.New("事项已被修改，与 If-Match 或 If-Unmodified-Since 指定的版本不一致，请重新获取后再修改")
+
+// checkPreconditions 检查写请求的条件请求头，todo 为修改前的事项
+// If-Match 为 * 或包含当前版本的 ETag（"3"、W/"3" 或 3）时满足；没有 If-Match 时检查 If-Unmodified-Since，
+// 事项在该时间之后（精确到秒）修改过时不满足。无法解析的 If-Unmodified-Since 按规范忽略
+func checkPreconditions(r *http.Request, todo *models.Todo) error {
+	if header := r.Header.Get("If-Match"); header != "" {
+		version := strconv.Itoa(todo.Version)
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.Trim(strings.TrimPrefix(strings.TrimSpace(candidate), "W/"), `"`)
+			if candidate == "*" || candidate == version {
+				return nil
+			}
+		}
+		return errPreconditionFailed
+	}
+
+	if header := r.Header.Get("If-Unmodified-Since"); header != "" {
+		since, err := http.ParseTime(header)
+		if err == nil && todo.UpdatedAt.Truncate(time.Second).After(since) {
+			return errPreconditionFailed
+		}
+	}
+	return nil
+}
+
+// sendPreconditionFailed 返回 412，ETag 为事项的当前版本，客户端可以据此重新获取
+func sendPreconditionFailed(w http.ResponseWriter, current *models.Todo) {
+	if current != nil {
+		setVersionETag(w, current)
+	}
+	sendError(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// 事件列表分页参数
+const (
+	defaultEventLimit = 100  // 默认每次返回的事件数
+	maxEventLimit     = 1000 // 每次最多返回的事件数
+)
+
+// eventRetention 事件在发件箱中的保留时间，集成方停机超过该时间后需要全量同步
+const eventRetention = 30 * 24 * time.Hour
+
+// eventOffsetRequest 确认事件位置请求
+type eventOffsetRequest struct {
+	Seq int64 `json:"seq"` // 已处理的最后一个事件序号
+}
+
+// publish 把事件写入发件箱
+// 事件在数据修改成功之后写入，写入失败时只记录日志，不影响请求结果；
+// 启用了变更日志时存储已经在修改的事务中记录了事件，不再重复写入
+func (h *Handler) publish(eventType string, todoID int, todo *models.Todo) {
+	if h.journaled {
+		return
+	}
+	event := &models.Event{Type: eventType, TodoID: todoID, CreatedAt: time.Now()}
+	if todo != nil {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			log.Printf("编码事件 %s 失败: %v", eventType, err)
+			return
+		}
+		event.Data = data
+	}
+
+	if err := h.events.AppendEvent(event); err != nil {
+		log.Printf("写入事件 %s（待办事项 %d）失败: %v", eventType, todoID, err)
+	}
+}
+
+// ListEvents 按序号读取事件，用于集成方补发和重放
+// ?after= 为上次处理的最后一个序号（默认0，从头开始），?limit= 为最多返回的数量
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var after int64
+	if value := query.Get("after"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			sendError(w, "无效的after参数", http.StatusBadRequest)
+			return
+		}
+		after = parsed
+	}
+
+	limit := defaultEventLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxEventLimit {
+			sendError(w, "limit参数必须在1到1000之间", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.events.ListEvents(after, limit)
+	if err != nil {
+		sendError(w, "获取事件失败", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []*models.Event{}
+	}
+
+	// last_seq 为下一次请求应使用的 after 值
+	lastSeq := after
+	if len(events) > 0 {
+		lastSeq = events[len(events)-1].Seq
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"events":   events,
+		"last_seq": lastSeq,
+		"has_more": len(events) == limit,
+	}, http.StatusOK)
+}
+
+// GetEventOffset 获取集成方已确认的事件位置，没有确认过时序号为0
+func (h *Handler) GetEventOffset(w http.ResponseWriter, r *http.Request) {
+	consumer := mux.Vars(r)["consumer"]
+
+	offset, err := store.GetEventOffset(h.store, consumer)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		offset = &models.EventOffset{Consumer: consumer}
+	} else if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, offset, http.StatusOK)
+}
+
+// UpdateEventOffset 确认集成方已处理到的事件位置
+// 允许设置比当前更小的序号，用于重放之前的事件
+func (h *Handler) UpdateEventOffset(w http.ResponseWriter, r *http.Request) {
+	var req eventOffsetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if req.Seq < 0 {
+		sendError(w, "序号不能为负数", http.StatusBadRequest)
+		return
+	}
+
+	offset := &models.EventOffset{Consumer: mux.Vars(r)["consumer"], Seq: req.Seq, UpdatedAt: time.Now()}
+	if err := store.SaveEventOffset(h.store, offset); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, offset, http.StatusOK)
+}
+
+// PublishOverdueEvents 定时任务：为超过截止时间仍未完成的事项记录 todo.overdue 事件
+// 已记录的事项和截止时间保存在存储中，每个截止时间只记录一次；事项完成、删除或截止时间修改后清除记录，再次过期时重新记录
+func (h *Handler) PublishOverdueEvents(ctx context.Context) error {
+	todos, err := h.store.GetAllTodos()
+	if err != nil {
+		return err
+	}
+	recorded, err := h.store.ListMeta(store.OverdueEventsNamespace)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	overdue := make(map[string]bool)
+	published := 0
+	for _, todo := range todos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if todo.Completed || !todo.HasDueDate() || todo.DueDate.After(now) {
+			continue
+		}
+
+		key := strconv.Itoa(todo.ID)
+		overdue[key] = true
+		var due time.Time
+		if data, ok := recorded[key]; ok && json.Unmarshal(data, &due) == nil && due.Equal(*todo.DueDate) {
+			continue
+		}
+
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return err
+		}
+		event := &models.Event{Type: models.EventTodoOverdue, TodoID: todo.ID, Data: data, CreatedAt: now}
+		if err := h.events.AppendEvent(event); err != nil {
+			return err
+		}
+		marker, err := json.Marshal(todo.DueDate)
+		if err != nil {
+			return err
+		}
+		if err := h.store.PutMeta(store.OverdueEventsNamespace, key, marker); err != nil {
+			return err
+		}
+		published++
+	}
+
+	for key := range recorded {
+		if overdue[key] {
+			continue
+		}
+		if err := h.store.DeleteMeta(store.OverdueEventsNamespace, key); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			return err
+		}
+	}
+
+	if published > 0 {
+		log.Printf("⏰ %d 个事项已过期", published)
+	}
+	return nil
+}
+
+// PurgeExpiredEvents 定时任务：删除超过保留时间的事件
+func (h *Handler) PurgeExpiredEvents(ctx context.Context) error {
+	purged, err := h.events.PurgeEvents(time.Now().Add(-eventRetention))
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("清理了 %d 个过期的事件", purged)
+	}
+	return nil
+}
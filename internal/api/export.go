@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/export"
+)
+
+// ExportTodos 导出待办事项
+// ?format= 可选 json（默认）、csv、xlsx；排序、是否包含已完成事项和地点过滤与列表接口相同，导出时不分页
+func (h *Handler) ExportTodos(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatJSON
+	}
+	if !export.IsValidFormat(format) {
+		sendError(w, "导出格式必须是 json、csv 或 xlsx", http.StatusBadRequest)
+		return
+	}
+
+	view, err := h.parseListView(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	view.PerPage = 0
+
+	todos, err := h.listTodos(view)
+	if err != nil {
+		sendError(w, "获取待办事项失败", http.StatusInternalServerError)
+		return
+	}
+	todos, _ = view.apply(todos, h.agingPolicy())
+
+	// 先写入缓冲区，生成失败时仍然可以返回错误响应
+	var buf bytes.Buffer
+	var contentType string
+	switch format {
+	case export.FormatCSV:
+		contentType = export.ContentTypeCSV
+		err = export.WriteCSV(&buf, todos)
+	case export.FormatXLSX:
+		contentType = export.ContentTypeXLSX
+		err = export.WriteXLSX(&buf, todos)
+	default:
+		contentType = "application/json"
+		err = json.NewEncoder(&buf).Encode(todos)
+	}
+	if err != nil {
+		logf(r, "导出待办事项（%s）失败: %v", format, err)
+		sendError(w, "导出失败", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("todos-%s.%s", time.Now().Format("20060102"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
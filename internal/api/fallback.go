@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// allowCandidates 计算 Allow 响应头时尝试的请求方法
+var allowCandidates = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// unmatched 为没有匹配到路由的请求补上全局中间件
+// mux 只在匹配到路由时执行中间件，404 和 405 响应同样需要请求ID、访问日志和跨域响应头
+func (h *Handler) unmatched(handler http.Handler) http.Handler {
+	return requestIDMiddleware(loggingMiddleware(h.corsMiddleware(handler)))
+}
+
+// NotFound 地址不存在时返回与其它接口相同格式的 JSON 错误，代替 mux 默认的纯文本响应
+// mux 在子路由（/api、/api/v1）中方法不匹配时也按 404 处理，因此这里先检查地址是否支持其它方法，支持时返回 405
+func (h *Handler) NotFound(router *mux.Router) http.HandlerFunc {
+	methodNotAllowed := h.MethodNotAllowed(router)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(allowedMethods(router, r)) > 0 {
+			methodNotAllowed(w, r)
+			return
+		}
+		sendError(w, "未找到 "+r.URL.Path, http.StatusNotFound)
+	}
+}
+
+// MethodNotAllowed 地址存在但不支持请求方法时返回 405，Allow 响应头中列出该地址支持的方法
+func (h *Handler) MethodNotAllowed(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := append(allowedMethods(router, r), http.MethodOptions)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		sendError(w, "不支持 "+r.Method+" 请求，允许的方法: "+strings.Join(allowed, ", "), http.StatusMethodNotAllowed)
+	}
+}
+
+// allowedMethods 逐个尝试候选方法，返回请求地址可以匹配到路由的方法
+// 任意地址都可以匹配的 OPTIONS 不计算在内
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range allowCandidates {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
@@ -2,26 +2,95 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
-	"html/template"
+	"errors"
+	"expvar"
 	"log"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/MGter/xStreamTool_go/internal/archive"
+	"github.com/MGter/xStreamTool_go/internal/backup"
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/health"
 	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/notify"
 	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/MGter/xStreamTool_go/internal/web"
 	"github.com/gorilla/mux"
 )
 
 // Handler HTTP 处理器
 type Handler struct {
-	store store.TodoStore
+	store   store.TodoStore
+	config  *config.Config
+	limiter *rateLimiter
+	health  *health.Registry // 依赖组件健康检查注册表
+	events  store.EventLog   // 事件发件箱
+	mirror  *mirror          // 流量镜像，未启用时为nil
+	web     *web.Renderer    // 网页渲染器
+
+	dashboard *dashboardCache  // 仪表盘数据缓存
+	mailer    notify.Mailer    // 关注者邮件通知
+	webhooks  *http.Client     // 发送 webhook 的客户端
+	backups   *backup.Manager  // 备份管理器
+	archives  *archive.Manager // 冷存储归档管理器
+	journaled bool             // 存储是否启用了变更日志，启用时事件由存储在修改的事务中记录
+
+	routeLimiters map[string]*rateLimiter // 单独限流的接口，键为 "METHOD /path" 或 "/path"
+	configMu      sync.RWMutex            // 保护运行时可以修改的配置项（日志级别、限流、CORS 允许的来源）
+
+	hub       *wsHub        // WebSocket 连接管理
+	closing   chan struct{} // 服务器关闭时关闭，通知事件流等长连接结束
+	closeOnce sync.Once
+
+	shuttingDown atomic.Bool // 收到停止信号后为 true，就绪检查返回 503
 }
 
 // NewHandler 创建新的处理器
-func NewHandler(store store.TodoStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(todoStore store.TodoStore, cfg *config.Config) *Handler {
+	// 启用审计日志时，包括定时任务在内的所有修改都经过审计装饰器
+	if cfg.Audit.Enabled {
+		todoStore = store.NewAuditedStore(todoStore)
+	}
+
+	h := &Handler{
+		store:   todoStore,
+		config:  cfg,
+		limiter: newRateLimiter(cfg.Server.RateLimit),
+		health:  health.NewRegistry(0),
+		web:     web.NewRenderer(cfg.UI),
+		events:  store.NewEventLog(todoStore),
+		mirror:  newMirror(cfg.Mirror),
+
+		dashboard: &dashboardCache{},
+		mailer:    notify.NewMailer(cfg.Notifications),
+		webhooks:  newWebhookClient(cfg.Webhooks),
+		backups:   backup.New(todoStore, cfg.Backup),
+		archives:  archive.New(todoStore, cfg.Archive),
+		journaled: store.FindJournaled(todoStore) != nil,
+
+		routeLimiters: newRouteLimiters(cfg.Server.RouteRateLimits),
+		closing:       make(chan struct{}),
+	}
+	h.hub = newWSHub(h)
+	if err := setLogLevel(cfg.Logging.Level); err != nil {
+		log.Printf("⚠️ %v，使用 info", err)
+		setLogLevel("info")
+	}
+	h.health.RegisterDetailed("store", h.checkStore)
+	h.health.RegisterDetailed("disk", health.DiskCheck(filepath.Dir(cfg.Logging.File), cfg.Health.MinDiskFreeMB, cfg.Health.CriticalDiskFreeMB))
+	h.health.RegisterDetailed("memory", health.MemoryCheck(cfg.Health.MaxHeapMB))
+	h.health.Register("shutdown", h.checkShutdown)
+	if _, ok := store.Unwrap(todoStore).(store.SchemaChecker); ok {
+		h.health.Register("migrations", h.checkSchema)
+	}
+	h.watchRecovery()
+	return h
 }
 
 // SetupRoutes 设置路由
@@ -29,230 +98,254 @@ func SetupRoutes(h *Handler) *mux.Router {
 	router := mux.NewRouter()
 
 	// 全局中间件
+	router.Use(requestIDMiddleware) // 最先执行，之后的日志和错误响应都带有请求ID
 	router.Use(loggingMiddleware)
+	router.Use(h.degradedMiddleware) // 存储降级时拒绝写请求，包括网页表单和 CalDAV
+	router.Use(h.corsMiddleware)     // 按 server.allowed_origins 设置跨域响应头
 
 	// Web 页面路由
 	router.HandleFunc("/", h.HomePage).Methods("GET")
 	router.HandleFunc("/todos", h.TodosPage).Methods("GET")
 	router.HandleFunc("/api/docs", h.APIDocsPage).Methods("GET")
+	router.HandleFunc("/api/openapi.json", h.OpenAPISpec(router)).Methods("GET")
+	router.HandleFunc("/api/docs/postman.json", h.PostmanCollection(router)).Methods("GET")
+	router.HandleFunc("/share/{token}", h.SharedTodoPage).Methods("GET")        // 公开分享页面，无需认证
+	router.HandleFunc("/unsubscribe/{token}", h.UnsubscribePage).Methods("GET") // 关注者退订页面，无需认证
+	router.HandleFunc("/unsubscribe/{token}", h.Unsubscribe).Methods("POST")
+	router.HandleFunc("/ws", h.WebSocket(router)).Methods("GET")  // WebSocket 实时同步，修改命令按 /api/v1 的接口执行
+	router.HandleFunc("/healthz", h.Liveness).Methods("GET")      // 存活检查，不受限流影响
+	router.HandleFunc("/readyz", h.Readiness).Methods("GET")      // 就绪检查，不受限流影响
+	router.Handle("/debug/vars", expvar.Handler()).Methods("GET") // 监控指标（expvar），不受限流影响
+
+	// CalDAV 任务同步（使用 PROPFIND、REPORT 等 WebDAV 方法，因此不限定请求方法）
+	router.HandleFunc("/.well-known/caldav", h.CalDAVWellKnown)
+	router.PathPrefix("/caldav").HandlerFunc(h.CalDAV)
+
+	// 其它地址的 OPTIONS 请求（跨域预检）统一处理，不经过限流；CalDAV 的 OPTIONS 由上面的路由自行处理。
+	// 不使用 Methods("OPTIONS")：只限定方法的路由会让其它方法访问不存在的地址时返回 405 而不是 404
+	router.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+		return r.Method == http.MethodOptions
+	}).HandlerFunc(h.Preflight)
 
 	// API 路由
 	api := router.PathPrefix("/api").Subrouter()
-	api.HandleFunc("/todos", h.GetTodos).Methods("GET")
+	api.Use(h.rateLimitMiddleware)
+	api.Use(h.bodyLimitMiddleware)
+	api.Use(yamlMiddleware)     // 请求体和响应支持 application/yaml
+	api.Use(h.mirrorMiddleware) // 在限流之后，只镜像实际处理的请求
+
+	// 当前版本的接口挂载在 /api/v1 下，/api 作为它的别名保留给现有客户端；
+	// 以后有不兼容的改动（如新的分页信封格式）时在 /api/v2 下发布，不影响这两个前缀。
+	// v1 子路由需在 /api 的路由之前注册，否则 /api/v1/... 会被 /api 下的路由抢先匹配
+	v1 := api.PathPrefix("/v1").Subrouter()
+	h.registerAPIRoutes(v1, h.ListTodosV1)
+	h.registerAPIRoutes(api, h.GetTodos)
+
+	// JSON-RPC 的方法按 /api/v1 的接口执行，需要整个路由
+	rpc := h.RPC(router)
+	v1.HandleFunc("/rpc", rpc).Methods("POST")
+	api.HandleFunc("/rpc", rpc).Methods("POST")
+
+	// 不存在的地址和不支持的方法返回 JSON 错误
+	router.NotFoundHandler = h.unmatched(h.NotFound(router))
+	router.MethodNotAllowedHandler = h.unmatched(h.MethodNotAllowed(router))
+
+	return router
+}
+
+// registerAPIRoutes 在指定前缀下注册 API 路由，/api 和 /api/v1 共用同一套路由
+// listTodos 为 GET /todos 的处理函数：/api/v1 返回 {"data": [...], "meta": {...}} 信封格式，
+// /api 为兼容现有客户端仍返回数组
+func (h *Handler) registerAPIRoutes(api *mux.Router, listTodos http.HandlerFunc) {
+	api.HandleFunc("/todos", listTodos).Methods("GET")
 	api.HandleFunc("/todos", h.CreateTodo).Methods("POST")
+	api.HandleFunc("/todos", h.DeleteTodosByFilter).Methods("DELETE")
+	api.HandleFunc("/todos/bulk", h.BulkCreateTodos).Methods("POST") // 批量路由需在 /todos/{id} 之前注册
+	api.HandleFunc("/todos/bulk", h.BulkUpdateTodos).Methods("PUT")
+	api.HandleFunc("/todos/bulk", h.BulkDeleteTodos).Methods("DELETE")
+	api.HandleFunc("/todos/complete", h.BulkCompleteTodos).Methods("PATCH")
+	api.HandleFunc("/todos/reorder", h.ReorderTodos).Methods("PATCH")
+	api.HandleFunc("/todos/export", h.ExportTodos).Methods("GET")
+	api.HandleFunc("/todos/stream", h.StreamTodos).Methods("GET")
+	api.HandleFunc("/todos/import", h.ImportTodos).Methods("POST")
+	api.HandleFunc("/todos/calendar.ics", h.CalendarFeed).Methods("GET")
+	api.HandleFunc("/todos/search", h.SearchTodos).Methods("GET")
+	api.HandleFunc("/todos/next", h.GetNextTodo).Methods("GET")
+	api.HandleFunc("/todos/today", h.TodayTodos).Methods("GET")
+	api.HandleFunc("/todos/upcoming", h.UpcomingTodos).Methods("GET")
+	api.HandleFunc("/todos/overdue", h.OverdueTodos).Methods("GET")
+	api.HandleFunc("/todos/archived", h.ListArchivedTodos).Methods("GET")
+	api.HandleFunc("/todos/next/skip", h.SkipNextTodo).Methods("POST")
 	api.HandleFunc("/todos/{id}", h.GetTodo).Methods("GET")
 	api.HandleFunc("/todos/{id}", h.UpdateTodo).Methods("PUT")
+	api.HandleFunc("/todos/{id}", h.PatchTodo).Methods("PATCH")
 	api.HandleFunc("/todos/{id}", h.DeleteTodo).Methods("DELETE")
 	api.HandleFunc("/todos/{id}/complete", h.CompleteTodo).Methods("PATCH")
+	api.HandleFunc("/todos/{id}/uncomplete", h.UncompleteTodo).Methods("PATCH")
+	api.HandleFunc("/todos/{id}/archive", h.ArchiveTodo).Methods("POST")
+	api.HandleFunc("/todos/{id}/unarchive", h.UnarchiveTodo).Methods("POST")
+	api.HandleFunc("/todos/{id}/history", h.GetTodoHistory).Methods("GET")
+	api.HandleFunc("/todos/{id}/revert/{revision}", h.RevertTodo).Methods("POST")
+	api.HandleFunc("/todos/{id}/links", h.GetTodoLinks).Methods("GET")
+	api.HandleFunc("/todos/{id}/links", h.CreateTodoLink).Methods("POST")
+	api.HandleFunc("/todos/{id}/links/{target}", h.DeleteTodoLink).Methods("DELETE")
+	api.HandleFunc("/todos/{id}/dependencies", h.GetTodoDependencies).Methods("GET")
+	api.HandleFunc("/todos/{id}/dependencies", h.CreateTodoDependency).Methods("POST")
+	api.HandleFunc("/todos/{id}/dependencies/{target}", h.DeleteTodoDependency).Methods("DELETE")
+	api.HandleFunc("/todos/{id}/subtasks", h.GetSubtasks).Methods("GET")
+	api.HandleFunc("/todos/{id}/subtasks", h.CreateSubtask).Methods("POST")
+	api.HandleFunc("/todos/{id}/subtasks/{subtask}", h.UpdateSubtask).Methods("PATCH")
+	api.HandleFunc("/todos/{id}/subtasks/{subtask}", h.DeleteSubtask).Methods("DELETE")
+	api.HandleFunc("/events", h.EventStream).Methods("GET")
 	api.HandleFunc("/health", h.HealthCheck).Methods("GET")
-
-	return router
+	api.HandleFunc("/ratelimit", h.GetRateLimit).Methods("GET")
+	api.HandleFunc("/dashboard/widgets", h.GetDashboardWidgets).Methods("GET")
+	api.HandleFunc("/calendar/{year}/{month}", h.CalendarMonth).Methods("GET")
+
+	// 标签
+	api.HandleFunc("/tags", h.ListTags).Methods("GET")
+	api.HandleFunc("/tags/merge", h.MergeTags).Methods("POST")
+	api.HandleFunc("/tags/{tag}/rename", h.RenameTag).Methods("POST")
+
+	// 提醒
+	api.HandleFunc("/reminders", h.ListReminders).Methods("GET")
+	api.HandleFunc("/reminders/{id}/snooze", h.SnoozeReminder).Methods("POST")
+
+	// 分类默认设置
+	api.HandleFunc("/categories/defaults", h.ListCategoryDefaults).Methods("GET")
+	api.HandleFunc("/categories/{name}/defaults", h.GetCategoryDefaults).Methods("GET")
+	api.HandleFunc("/categories/{name}/defaults", h.UpdateCategoryDefaults).Methods("PUT")
+	api.HandleFunc("/categories/{name}/defaults", h.DeleteCategoryDefaults).Methods("DELETE")
+
+	// 公开分享链接管理
+	api.HandleFunc("/todos/{id}/shares", h.GetTodoShareLinks).Methods("GET")
+	api.HandleFunc("/todos/{id}/shares", h.CreateShareLink).Methods("POST")
+	api.HandleFunc("/shares", h.ListShareLinks).Methods("GET")
+	api.HandleFunc("/shares/{token}", h.RevokeShareLink).Methods("DELETE")
+
+	// 关注者（外部协作者）邮件通知
+	api.HandleFunc("/todos/{id}/watchers", h.GetTodoWatchers).Methods("GET")
+	api.HandleFunc("/todos/{id}/watchers", h.CreateWatcher).Methods("POST")
+	api.HandleFunc("/todos/{id}/watchers/{token}", h.DeleteWatcher).Methods("DELETE")
+
+	// webhook 订阅
+	api.HandleFunc("/webhooks", h.ListWebhooks).Methods("GET")
+	api.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", h.GetWebhook).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", h.UpdateWebhook).Methods("PUT")
+	api.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", h.ListWebhookDeliveries).Methods("GET")
+	api.HandleFunc("/webhooks/{id}/deliveries/{delivery}/redeliver", h.RedeliverWebhook).Methods("POST")
+
+	// 自动分类规则
+	api.HandleFunc("/rules", h.ListRules).Methods("GET")
+	api.HandleFunc("/rules", h.CreateRule).Methods("POST")
+	api.HandleFunc("/rules/preview", h.PreviewRules).Methods("GET", "POST")
+	api.HandleFunc("/rules/{id}", h.GetRule).Methods("GET")
+	api.HandleFunc("/rules/{id}", h.UpdateRule).Methods("PUT")
+	api.HandleFunc("/rules/{id}", h.DeleteRule).Methods("DELETE")
+
+	// 通知模板
+	api.HandleFunc("/templates", h.ListTemplates).Methods("GET")
+	api.HandleFunc("/templates/{name}", h.GetTemplate).Methods("GET")
+	api.HandleFunc("/templates/{name}", h.UpdateTemplate).Methods("PUT")
+	api.HandleFunc("/templates/{name}", h.DeleteTemplate).Methods("DELETE")
+	api.HandleFunc("/templates/{name}/preview", h.PreviewTemplate).Methods("POST")
+
+	// 冷存储归档
+	api.HandleFunc("/archive", h.SearchArchive).Methods("GET")
+	api.HandleFunc("/archive/{id}", h.GetArchivedTodo).Methods("GET")
+	api.HandleFunc("/archive/{id}/rehydrate", h.RehydrateTodo).Methods("POST")
+
+	// 回收站
+	api.HandleFunc("/trash", h.ListTrash).Methods("GET")
+	api.HandleFunc("/trash/{id}/restore", h.RestoreTrashedTodo).Methods("POST")
+
+	// 审计日志
+	api.HandleFunc("/audit", h.ListAuditEntries).Methods("GET")
+
+	// 管理接口
+	// 脱敏只处理请求体中的快照，不读写存储，不需要管理令牌；需在 /admin 子路由之前注册
+	api.HandleFunc("/admin/scrub", h.ScrubSnapshot).Methods("POST")
+
+	// 其它管理接口可以查看内部数据和事件、修改配置、恢复备份、永久删除等，都需要管理令牌（admin.token），未配置时不可用
+	admin := api.PathPrefix(adminPathPrefix).Subrouter()
+	admin.Use(h.requireAdmin)
+	admin.HandleFunc("/events", h.ListEvents).Methods("GET")
+	admin.HandleFunc("/config", h.GetConfig).Methods("GET")
+	admin.HandleFunc("/config", h.UpdateConfig).Methods("PUT")
+	admin.HandleFunc("/mirror", h.GetMirrorStats).Methods("GET")
+	admin.HandleFunc("/replication", h.GetReplicationStatus).Methods("GET")
+	admin.HandleFunc("/replication/resync", h.ResyncReplicas).Methods("POST")
+	admin.HandleFunc("/degraded", h.GetDegradedStatus).Methods("GET")
+	admin.HandleFunc("/backups", h.ListBackups).Methods("GET")
+	admin.HandleFunc("/backups", h.CreateBackup).Methods("POST")
+	admin.HandleFunc("/restore", h.RestoreBackup).Methods("POST")
+	admin.HandleFunc("/archive", h.RunArchive).Methods("POST")
+	admin.HandleFunc("/trash/purge", h.PurgeTrash).Methods("POST")
+	admin.HandleFunc("/trash/{id}/extend", h.ExtendTrashedTodo).Methods("POST")
+	admin.HandleFunc("/trash/{id}", h.PurgeTrashedTodo).Methods("DELETE")
+	admin.HandleFunc("/fsck", h.CheckConsistency).Methods("GET")
+	admin.HandleFunc("/fsck", h.RepairConsistency).Methods("POST")
+	admin.HandleFunc("/events/offsets/{consumer}", h.GetEventOffset).Methods("GET")
+	admin.HandleFunc("/events/offsets/{consumer}", h.UpdateEventOffset).Methods("PUT")
 }
 
 // HomePage 首页
 func (h *Handler) HomePage(w http.ResponseWriter, r *http.Request) {
-	html := `
-	<!DOCTYPE html>
-	<html>
-	<head>
-		<title>xStreamTool Go</title>
-		<style>
-			body { font-family: Arial, sans-serif; max-width: 800px; margin: 0 auto; padding: 20px; }
-			h1 { color: #333; }
-			.card { background: #f9f9f9; padding: 20px; margin: 20px 0; border-radius: 8px; }
-			.btn { display: inline-block; padding: 10px 20px; background: #007bff; color: white; text-decoration: none; border-radius: 5px; }
-		</style>
-	</head>
-	<body>
-		<h1>🚀 xStreamTool Go HTTP 服务器</h1>
-		<div class="card">
-			<h2>欢迎使用</h2>
-			<p>这是一个简单的 Go HTTP 服务器示例</p>
-			<a href="/todos" class="btn">查看待办事项</a>
-			<a href="/api/docs" class="btn">API 文档</a>
-		</div>
-		<div class="card">
-			<h3>📋 API 端点</h3>
-			<ul>
-				<li><code>GET /api/todos</code> - 获取所有待办事项</li>
-				<li><code>GET /api/todos/{id}</code> - 获取单个待办事项</li>
-				<li><code>POST /api/todos</code> - 创建新待办事项</li>
-				<li><code>PUT /api/todos/{id}</code> - 更新待办事项</li>
-				<li><code>DELETE /api/todos/{id}</code> - 删除待办事项</li>
-			</ul>
-		</div>
-	</body>
-	</html>
-	`
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, html)
+	h.renderPage(w, "home", nil)
 }
 
 // TodosPage 待办事项页面
 func (h *Handler) TodosPage(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.store.GetAllTodos()
+	view, err := h.parseListView(r)
 	if err != nil {
-		sendError(w, "获取待办事项失败", http.StatusInternalServerError)
+		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	tmplStr := `
-	<!DOCTYPE html>
-	<html>
-	<head>
-		<title>待办事项</title>
-		<style>
-			body { font-family: Arial, sans-serif; max-width: 800px; margin: 0 auto; padding: 20px; }
-			.todo-item { background: #f5f5f5; padding: 15px; margin: 10px 0; border-radius: 5px; }
-			.completed { background: #e8f5e8; }
-			.btn { padding: 5px 10px; margin-right: 5px; border: none; border-radius: 3px; cursor: pointer; }
-			.btn-primary { background: #007bff; color: white; }
-			.btn-success { background: #28a745; color: white; }
-			.btn-danger { background: #dc3545; color: white; }
-		</style>
-	</head>
-	<body>
-		<h1>📋 待办事项列表</h1>
-		<div id="todoList">
-			{{range .}}
-			<div class="todo-item {{if .Completed}}completed{{end}}">
-				<h3>{{.Title}} {{if .Completed}}✅{{end}}</h3>
-				<p>ID: {{.ID}} | 创建时间: {{.CreatedAt.Format "2006-01-02 15:04"}}</p>
-				<p>优先级: {{.Priority}} | 分类: {{.Category}}</p>
-				<button class="btn btn-success" onclick="completeTodo({{.ID}})">标记完成</button>
-				<button class="btn btn-danger" onclick="deleteTodo({{.ID}})">删除</button>
-			</div>
-			{{else}}
-			<p>暂无待办事项</p>
-			{{end}}
-		</div>
-		
-		<div style="margin-top: 30px; background: #f8f9fa; padding: 20px; border-radius: 8px;">
-			<h3>添加新待办事项</h3>
-			<input type="text" id="title" placeholder="标题" style="width: 100%; padding: 10px; margin: 10px 0;">
-			<textarea id="description" placeholder="描述" style="width: 100%; padding: 10px; margin: 10px 0;" rows="3"></textarea>
-			<button class="btn btn-primary" onclick="createTodo()">添加</button>
-		</div>
-
-		<script>
-			async function createTodo() {
-				const title = document.getElementById('title').value;
-				if (!title) {
-					alert('请输入标题');
-					return;
-				}
-				
-				const response = await fetch('/api/todos', {
-					method: 'POST',
-					headers: { 'Content-Type': 'application/json' },
-					body: JSON.stringify({ title: title, description: document.getElementById('description').value })
-				});
-				
-				if (response.ok) {
-					alert('创建成功！');
-					location.reload();
-				}
-			}
-			
-			async function completeTodo(id) {
-				const response = await fetch('/api/todos/' + id + '/complete', { method: 'PATCH' });
-				if (response.ok) {
-					alert('标记完成！');
-					location.reload();
-				}
-			}
-			
-			async function deleteTodo(id) {
-				if (!confirm('确定删除吗？')) return;
-				const response = await fetch('/api/todos/' + id, { method: 'DELETE' });
-				if (response.ok) {
-					alert('删除成功！');
-					location.reload();
-				}
-			}
-		</script>
-	</body>
-	</html>
-	`
-
-	tmpl, err := template.New("todos").Parse(tmplStr)
+	todos, err := h.listTodos(view)
 	if err != nil {
-		sendError(w, "模板错误", http.StatusInternalServerError)
+		sendError(w, "获取待办事项失败", http.StatusInternalServerError)
 		return
 	}
+	todos, _ = view.apply(todos, h.agingPolicy())
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	tmpl.Execute(w, todos)
+	h.renderPage(w, "todos", todos)
 }
 
-// APIDocsPage API 文档页面
-func (h *Handler) APIDocsPage(w http.ResponseWriter, r *http.Request) {
-	html := `
-	<!DOCTYPE html>
-	<html>
-	<head>
-		<title>API 文档</title>
-		<style>
-			body { font-family: Arial, sans-serif; max-width: 800px; margin: 0 auto; padding: 20px; }
-			.endpoint { background: #f8f9fa; padding: 15px; margin: 15px 0; border-radius: 5px; }
-			.method { display: inline-block; padding: 5px 10px; background: #6c757d; color: white; border-radius: 3px; }
-			.path { font-family: monospace; background: #e9ecef; padding: 5px; border-radius: 3px; }
-		</style>
-	</head>
-	<body>
-		<h1>📚 API 文档</h1>
-		<div class="endpoint">
-			<span class="method">GET</span> <span class="path">/api/todos</span>
-			<p>获取所有待办事项</p>
-		</div>
-		<div class="endpoint">
-			<span class="method">POST</span> <span class="path">/api/todos</span>
-			<p>创建待办事项</p>
-			<pre>{
-  "title": "任务标题",
-  "description": "任务描述"
-}</pre>
-		</div>
-		<div class="endpoint">
-			<span class="method">GET</span> <span class="path">/api/todos/{id}</span>
-			<p>获取单个待办事项</p>
-		</div>
-		<div class="endpoint">
-			<span class="method">PUT</span> <span class="path">/api/todos/{id}</span>
-			<p>更新待办事项</p>
-		</div>
-		<div class="endpoint">
-			<span class="method">DELETE</span> <span class="path">/api/todos/{id}</span>
-			<p>删除待办事项</p>
-		</div>
-		<div class="endpoint">
-			<span class="method">PATCH</span> <span class="path">/api/todos/{id}/complete</span>
-			<p>标记待办事项为完成</p>
-		</div>
-	</body>
-	</html>
-	`
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, html)
+// renderPage 渲染网页，渲染失败时返回错误信息
+func (h *Handler) renderPage(w http.ResponseWriter, page string, data interface{}) {
+	if err := h.web.Render(w, page, data); err != nil {
+		log.Printf("渲染页面 %s 失败: %v", page, err)
+		sendError(w, "模板错误", http.StatusInternalServerError)
+	}
 }
 
 // GetTodos 获取所有待办事项
+// 排序、分页以及是否包含已完成事项由配置的默认值决定，可通过查询参数覆盖；
+// ?sort= 和 ?order= 指定的排序由存储完成（按有效优先级排序除外）；
+// 响应的 ETag 为内容的哈希，与 If-None-Match 一致时返回 304
 func (h *Handler) GetTodos(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.store.GetAllTodos()
+	view, err := h.parseListView(r)
 	if err != nil {
-		sendError(w, "获取失败", http.StatusInternalServerError)
+		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	responses := make([]models.TodoResponse, len(todos))
-	for i, todo := range todos {
-		responses[i] = todo.ToResponse()
+	todos, err := h.listTodos(view)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
 	}
+	todos, total := view.apply(todos, h.agingPolicy())
 
-	sendJSON(w, responses, http.StatusOK)
+	setPaginationHeaders(w, r.URL, view, total)
+	sendJSONWithETag(w, r, h.todoResponses(r, todos))
 }
 
 // GetTodo 获取单个待办事项
+// ETag 为版本号，与 If-None-Match 一致时返回 304
 func (h *Handler) GetTodo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -267,32 +360,78 @@ func (h *Handler) GetTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sendJSON(w, todo.ToResponse(), http.StatusOK)
+	setVersionETag(w, todo)
+	if notModified(w, r, w.Header().Get("ETag")) {
+		return
+	}
+	sendJSON(w, h.todoResponse(r, todo), http.StatusOK)
+}
+
+// setVersionETag 以待办事项的版本号作为 ETag，修改时间作为 Last-Modified，
+// 客户端修改时可以通过 If-Match 或 If-Unmodified-Since 请求头带回
+func setVersionETag(w http.ResponseWriter, todo *models.Todo) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(todo.Version)))
+	w.Header().Set("Last-Modified", todo.UpdatedAt.UTC().Format(http.TimeFormat))
+}
+
+// validateTodoRequest 检查创建和更新请求：标题必填，优先级在0到5之间，地点的经纬度必须有效；标签规范化为小写并去掉重复项
+func validateTodoRequest(req *models.TodoRequest) error {
+	if req.Title == "" {
+		return errors.New("标题必填")
+	}
+	if !req.Priority.IsValid() {
+		return models.ErrInvalidPriority
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+	req.Tags = tags
+	if req.Location != nil {
+		return req.Location.Validate()
+	}
+	return nil
 }
 
 // CreateTodo 创建待办事项
 func (h *Handler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	var req models.TodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "无效数据", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
 		return
 	}
 
-	if req.Title == "" {
-		sendError(w, "标题必填", http.StatusBadRequest)
+	if err := validateTodoRequest(&req); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 未显式指定的分类和优先级先按自动分类规则填充，其余字段再使用分类默认设置
+	if err := h.applyRules(&req); err != nil {
+		sendError(w, "读取自动分类规则失败", http.StatusInternalServerError)
+		return
+	}
+	if err := h.applyCategoryDefaults(&req); err != nil {
+		sendError(w, "读取分类默认设置失败", http.StatusInternalServerError)
 		return
 	}
 
-	todo, err := h.store.CreateTodo(&req)
+	todo, err := h.storeFor(r).CreateTodo(&req)
+	if errors.Is(err, store.ErrStoreFull) {
+		sendError(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	}
 	if err != nil {
 		sendError(w, "创建失败", http.StatusInternalServerError)
 		return
 	}
+	h.publish(models.EventTodoCreated, todo.ID, todo)
 
-	sendJSON(w, todo.ToResponse(), http.StatusCreated)
+	sendJSON(w, h.todoResponse(r, todo), http.StatusCreated)
 }
 
 // UpdateTodo 更新待办事项
+// If-Match（GET 返回的 ETag）或 If-Unmodified-Since 与当前事项不一致时返回 412，请求体中的 version 不一致时返回 409
 func (h *Handler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -302,26 +441,160 @@ func (h *Handler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.TodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "无效数据", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
 		return
 	}
 
-	if req.Title == "" {
-		sendError(w, "标题必填", http.StatusBadRequest)
+	if err := validateTodoRequest(&req); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	todo, err := h.store.UpdateTodo(id, &req)
-	if err != nil {
+	// 条件请求头与读取、更新在同一个事务中检查，不满足时返回 412；请求体中的 version 不一致时返回 409
+	var current, todo *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		if current, err = tx.GetTodoByID(id); err != nil {
+			return err
+		}
+		if err := checkPreconditions(r, current); err != nil {
+			return err
+		}
+		todo, err = tx.UpdateTodo(id, &req)
+		return err
+	})
+	switch {
+	case errors.Is(err, errPreconditionFailed):
+		sendPreconditionFailed(w, current)
+		return
+	case errors.Is(err, store.ErrVersionConflict):
+		sendError(w, err.Error()+"，请重新获取后再修改", http.StatusConflict)
+		return
+	case err != nil:
 		sendError(w, "更新失败", http.StatusNotFound)
 		return
 	}
+	h.publish(models.EventTodoUpdated, todo.ID, todo)
 
-	sendJSON(w, todo.ToResponse(), http.StatusOK)
+	setVersionETag(w, todo)
+	sendJSON(w, h.todoResponse(r, todo), http.StatusOK)
+}
+
+// PatchTodo 部分更新待办事项，只修改请求体中提供的字段，其它字段保持不变
+// due_date、location 为 null 时清空；读取和更新在同一个事务中，不会覆盖两步之间其它请求的修改。
+// 与 PUT 一样支持 If-Match、If-Unmodified-Since 请求头（不满足时返回 412）或请求体中的 version 做乐观并发控制
+// Content-Type 为 application/json-patch+json 时请求体为 JSON Patch 操作列表，test 操作不满足时返回 409；
+// 为 application/merge-patch+json 时请求体为 JSON Merge Patch，任何字段为 null 时都恢复为空值
+func (h *Handler) PatchTodo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	// 按 Content-Type 选择补丁格式：JSON Patch（RFC 6902）逐个操作修改，JSON Merge Patch（RFC 7386）按字段合并且 null 表示清空，
+	// 其它情况（application/json）使用 TodoPatch，只修改提供的字段
+	var apply func(req *models.TodoRequest) error
+	switch mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType {
+	case models.JSONPatchContentType:
+		var ops models.JSONPatch
+		if err := decodeJSON(r, &ops); err != nil {
+			sendDecodeError(w, "无效的JSON Patch", err)
+			return
+		}
+		if err := ops.Validate(); err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		apply = ops.ApplyTo
+	case models.MergePatchContentType:
+		var merge models.MergePatch
+		if err := decodeJSON(r, &merge); err != nil {
+			sendDecodeError(w, "无效的JSON Merge Patch", err)
+			return
+		}
+		if len(merge) == 0 {
+			sendError(w, "没有要修改的字段", http.StatusBadRequest)
+			return
+		}
+		apply = merge.ApplyTo
+	default:
+		var patch models.TodoPatch
+		if err := decodeJSON(r, &patch); err != nil {
+			sendDecodeError(w, "无效数据", err)
+			return
+		}
+		if patch.IsEmpty() {
+			sendError(w, "没有要修改的字段", http.StatusBadRequest)
+			return
+		}
+		apply = func(req *models.TodoRequest) error {
+			patch.Apply(req)
+			return nil
+		}
+	}
+	h.patchTodo(w, r, id, apply)
+}
+
+// patchTodo 在同一个事务中读取事项、检查条件请求头、用 apply 修改完整的更新请求并保存
+// 从未完成变为完成时发布 todo.completed 事件，其它情况发布 todo.updated 事件
+func (h *Handler) patchTodo(w http.ResponseWriter, r *http.Request, id int, apply func(req *models.TodoRequest) error) {
+	var before, updated *models.Todo
+	var invalid error
+	err := h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		before = todo
+		if err := checkPreconditions(r, todo); err != nil {
+			return err
+		}
+
+		req := todo.Request()
+		if invalid = apply(req); invalid != nil {
+			return invalid
+		}
+		if invalid = validateTodoRequest(req); invalid != nil {
+			return invalid
+		}
+		updated, err = tx.UpdateTodo(id, req)
+		return err
+	})
+	switch {
+	case errors.Is(invalid, models.ErrJSONPatchTestFailed):
+		sendError(w, invalid.Error(), http.StatusConflict)
+		return
+	case invalid != nil:
+		sendError(w, invalid.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errPreconditionFailed):
+		sendPreconditionFailed(w, before)
+		return
+	case errors.Is(err, store.ErrVersionConflict):
+		sendError(w, err.Error()+"，请重新获取后再修改", http.StatusConflict)
+		return
+	case err != nil:
+		sendError(w, "更新失败", http.StatusInternalServerError)
+		return
+	}
+
+	eventType := models.EventTodoUpdated
+	if !before.Completed && updated.Completed {
+		eventType = models.EventTodoCompleted
+	}
+	h.publish(eventType, updated.ID, updated)
+
+	setVersionETag(w, updated)
+	sendJSON(w, h.todoResponse(r, updated), http.StatusOK)
 }
 
 // DeleteTodo 删除待办事项
+// If-Match 或 If-Unmodified-Since 与当前事项不一致时返回 412，不删除
 func (h *Handler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -330,16 +603,54 @@ func (h *Handler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.DeleteTodo(id); err != nil {
+	// 启用回收站时移入回收站，保留期内可以恢复；条件请求头在同一个事务中检查
+	var current *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		if current, err = tx.GetTodoByID(id); err != nil {
+			return err
+		}
+		if err := checkPreconditions(r, current); err != nil {
+			return err
+		}
+		if h.config.Trash.Enabled {
+			return store.MoveToTrash(tx, []int{id}, time.Now(), h.trashRetention())
+		}
+		return tx.DeleteTodo(id)
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		sendPreconditionFailed(w, current)
+		return
+	}
+	if err != nil {
 		sendError(w, "删除失败", http.StatusNotFound)
 		return
 	}
 
+	h.publish(models.EventTodoDeleted, id, nil)
+
+	// 清理其它事项中指向该事项的关联，以及该事项的分享链接
+	h.removeLinksTo(id)
+	h.removeShareLinks(id)
+	h.removeWatchers(id)
+	h.removeDependencies(id)
+
 	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
 }
 
-// CompleteTodo 标记完成
+// CompleteTodo 标记完成，记录完成时间（已完成的事项保留原来的完成时间）
+// 与 PUT 一样支持 If-Match、If-Unmodified-Since 条件请求头，不满足时返回 412
 func (h *Handler) CompleteTodo(w http.ResponseWriter, r *http.Request) {
+	h.setCompleted(w, r, true)
+}
+
+// UncompleteTodo 重新打开已完成的事项，清空完成时间，条件请求头与 CompleteTodo 相同
+func (h *Handler) UncompleteTodo(w http.ResponseWriter, r *http.Request) {
+	h.setCompleted(w, r, false)
+}
+
+// setCompleted 修改事项的完成状态，相当于 JSON Merge Patch {"completed": completed}
+// 从未完成变为完成时发布 todo.completed 事件，其它情况发布 todo.updated 事件
+func (h *Handler) setCompleted(w http.ResponseWriter, r *http.Request, completed bool) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -347,39 +658,80 @@ func (h *Handler) CompleteTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.store.GetTodoByID(id)
+	merge := models.MergePatch{"completed": json.RawMessage(strconv.FormatBool(completed))}
+	h.patchTodo(w, r, id, merge.ApplyTo)
+}
+
+// ArchiveTodo 归档事项：归档的事项保留在存储中，但默认不出现在列表、搜索和推荐中，?include_archived=true 时才返回
+// 条件请求头与 CompleteTodo 相同
+func (h *Handler) ArchiveTodo(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// UnarchiveTodo 取消归档，事项重新出现在默认列表中
+func (h *Handler) UnarchiveTodo(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+// setArchived 修改事项的归档状态，发布 todo.updated 事件
+func (h *Handler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		sendError(w, "未找到", http.StatusNotFound)
+		sendError(w, "无效ID", http.StatusBadRequest)
 		return
 	}
 
-	req := &models.TodoRequest{
-		Title:       todo.Title,
-		Description: todo.Description,
-		Completed:   true,
-		Priority:    todo.Priority,
-		Category:    todo.Category,
-		DueDate:     todo.DueDate,
+	// 归档标记不在更新请求中，修改后保存完整的事项
+	var current, saved *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		current = todo
+		if err := checkPreconditions(r, todo); err != nil {
+			return err
+		}
+
+		todo.Archived = archived
+		saved, err = tx.SaveTodo(todo)
+		return err
+	})
+	if errors.Is(err, store.ErrTodoNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errPreconditionFailed) {
+		sendPreconditionFailed(w, current)
+		return
 	}
-
-	updatedTodo, err := h.store.UpdateTodo(id, req)
 	if err != nil {
 		sendError(w, "更新失败", http.StatusInternalServerError)
 		return
 	}
+	h.publish(models.EventTodoUpdated, saved.ID, saved)
 
-	sendJSON(w, updatedTodo.ToResponse(), http.StatusOK)
+	setVersionETag(w, saved)
+	sendJSON(w, h.todoResponse(r, saved), http.StatusOK)
 }
 
 // HealthCheck 健康检查
+// 检查所有已注册的组件（存储、日志目录磁盘空间、内存等），status 为汇总状态：healthy、degraded 或 unhealthy，
+// 有组件异常（unhealthy）时返回 503，降级时仍返回 200
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Check(r.Context())
 	response := map[string]interface{}{
-		"status":  "healthy",
-		"time":    time.Now().Unix(),
-		"service": "xstreamtool-go",
-		"version": "1.0.0",
+		"status":     report.Health,
+		"time":       time.Now().Unix(),
+		"service":    "xstreamtool-go",
+		"version":    "1.0.0",
+		"components": report.Components,
+	}
+	status := http.StatusOK
+	if report.Health == health.HealthUnhealthy {
+		status = http.StatusServiceUnavailable
 	}
-	sendJSON(w, response, http.StatusOK)
+	sendJSON(w, response, status)
 }
 
 // 辅助函数
@@ -391,8 +743,13 @@ func sendJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 	}
 }
 
+// sendError 发送错误响应，响应头中有请求ID时一并返回，便于按ID查找日志
 func sendError(w http.ResponseWriter, message string, statusCode int) {
-	sendJSON(w, map[string]string{"error": message}, statusCode)
+	payload := map[string]string{"error": message}
+	if id := w.Header().Get(RequestIDHeader); id != "" {
+		payload["request_id"] = id
+	}
+	sendJSON(w, payload, statusCode)
 }
 
 // 中间件
@@ -400,6 +757,8 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("[%s] %s %s %v", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start))
+		if logEnabled("info") {
+			logf(r, "[%s] %s %s %v", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start))
+		}
 	})
 }
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// errRevisionNotFound 修改历史中没有指定的修订版本
+var errRevisionNotFound = errors.New("修改历史中没有该修订版本")
+
+// todoHistory 返回事项的修改历史，按修订号从旧到新排列；修改历史来自审计日志
+func (h *Handler) todoHistory(id int) ([]*models.AuditEntry, error) {
+	entries, err := store.ListAuditEntries(h.store, store.AuditFilter{TodoID: id})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// GetTodoHistory 获取事项的修改历史：每个修订版本的操作者、时间和变化的字段，按修订号从旧到新排列
+// 修改历史来自审计日志，需在配置的 audit 部分启用；已删除事项的历史仍然可以查看
+func (h *Handler) GetTodoHistory(w http.ResponseWriter, r *http.Request) {
+	if !h.config.Audit.Enabled {
+		sendError(w, "未启用审计日志，没有修改历史", http.StatusNotFound)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.todoHistory(id)
+	if err != nil {
+		sendError(w, "获取修改历史失败", http.StatusInternalServerError)
+		return
+	}
+	if len(entries) == 0 {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+
+	revisions := make([]models.TodoRevision, len(entries))
+	for i, entry := range entries {
+		revisions[i] = models.NewTodoRevision(entry)
+	}
+	sendJSON(w, map[string]interface{}{
+		"todo_id":   id,
+		"revisions": revisions,
+	}, http.StatusOK)
+}
+
+// RevertTodo 把事项恢复为指定修订版本的内容，恢复本身作为一次新的修改记入历史
+// 恢复的字段见 models.Todo.RevertTo；内容与当前相同时不修改。条件请求头与 PUT 相同，已删除的事项需先从回收站恢复
+func (h *Handler) RevertTodo(w http.ResponseWriter, r *http.Request) {
+	if !h.config.Audit.Enabled {
+		sendError(w, "未启用审计日志，没有修改历史", http.StatusNotFound)
+		return
+	}
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil || revision < 1 {
+		sendError(w, "无效的修订号", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.todoHistory(id)
+	if err != nil {
+		sendError(w, "获取修改历史失败", http.StatusInternalServerError)
+		return
+	}
+	var target *models.Todo
+	for _, entry := range entries {
+		if entry.After != nil && entry.Revision() == revision {
+			target = entry.After
+		}
+	}
+
+	var current, saved *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		current = todo
+		if err := checkPreconditions(r, todo); err != nil {
+			return err
+		}
+		if target == nil {
+			return errRevisionNotFound
+		}
+
+		reverted := todo.Clone()
+		reverted.RevertTo(target)
+		if reflect.DeepEqual(reverted, todo) {
+			saved = todo
+			return nil
+		}
+		saved, err = tx.SaveTodo(reverted)
+		return err
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errPreconditionFailed):
+		sendPreconditionFailed(w, current)
+		return
+	case errors.Is(err, errRevisionNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		sendError(w, "恢复失败", http.StatusInternalServerError)
+		return
+	}
+
+	if saved != current {
+		if !current.Completed && saved.Completed {
+			h.publish(models.EventTodoCompleted, saved.ID, saved)
+		} else {
+			h.publish(models.EventTodoUpdated, saved.ID, saved)
+		}
+	}
+
+	setVersionETag(w, saved)
+	sendJSON(w, h.todoResponse(r, saved), http.StatusOK)
+}
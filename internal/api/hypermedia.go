@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/i18n"
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// halMediaType 请求超媒体链接时使用的媒体类型
+const halMediaType = "application/hal+json"
+
+// wantsHypermedia 判断是否需要在响应中返回超媒体链接
+// 配置中启用了 hypermedia，或者客户端通过 Accept 头请求 application/hal+json 时返回
+func (h *Handler) wantsHypermedia(r *http.Request) bool {
+	return h.config.Server.Hypermedia || strings.Contains(r.Header.Get("Accept"), halMediaType)
+}
+
+// todoResponse 将单个待办事项转换为响应格式，附加反向链接和有效优先级，并按需附加超媒体链接
+// 反向链接只查询指向该事项的事项，不遍历全部事项
+func (h *Handler) todoResponse(r *http.Request, todo *models.Todo) models.TodoResponse {
+	return h.buildResponse(r, todo, backlinksTo(h.storeFor(r), todo.ID))
+}
+
+// todoResponses 将待办事项列表转换为响应格式，反向链接索引只构建一次
+func (h *Handler) todoResponses(r *http.Request, todos []*models.Todo) []models.TodoResponse {
+	backlinks := backlinkIndex(h.storeFor(r))
+	responses := make([]models.TodoResponse, len(todos))
+	for i, todo := range todos {
+		responses[i] = h.buildResponse(r, todo, backlinks[todo.ID])
+	}
+	return responses
+}
+
+// buildResponse 构建单个待办事项的响应，backlinks 为指向该事项的反向链接
+func (h *Handler) buildResponse(r *http.Request, todo *models.Todo, backlinks []models.Backlink) models.TodoResponse {
+	response := todo.ToResponse()
+	response.Localize(h.locale(r))
+	response.Backlinks = backlinks
+	if policy := h.agingPolicy(); policy.Enabled {
+		response.EffectivePriority = policy.EffectivePriority(todo, time.Now())
+	}
+	if h.wantsHypermedia(r) {
+		response.Hypermedia = hypermediaLinks(apiPrefix(r), todo)
+	}
+	return response
+}
+
+// locale 返回请求者的语言区域：?lang= 优先，其次是 Accept-Language，都没有时使用界面配置的语言区域
+func (h *Handler) locale(r *http.Request) string {
+	return i18n.FromRequest(r, h.config.UI.Locale)
+}
+
+// apiPrefix 返回请求使用的接口前缀，链接与请求保持在同一个前缀下：/api 的请求为 /api，其它（/api/v1、内部请求）为 /api/v1
+func apiPrefix(r *http.Request) string {
+	if r.URL.Path == "/api" || (strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, apiBasePath+"/")) {
+		return "/api"
+	}
+	return apiBasePath
+}
+
+// hypermediaLinks 生成单个待办事项的超媒体链接，prefix 为接口前缀
+func hypermediaLinks(prefix string, todo *models.Todo) map[string]models.Link {
+	self := fmt.Sprintf("%s/todos/%d", prefix, todo.ID)
+	links := map[string]models.Link{
+		"self":       {Href: self},
+		"collection": {Href: prefix + "/todos"},
+		"links":      {Href: self + "/links"},
+		"subtasks":   {Href: self + "/subtasks"},
+		"update":     {Href: self, Method: http.MethodPatch},
+		"delete":     {Href: self, Method: http.MethodDelete},
+	}
+
+	// 未完成的事项提供标记完成的链接，已完成的提供重新打开的链接
+	if !todo.Completed {
+		links["complete"] = models.Link{Href: self + "/complete", Method: http.MethodPatch}
+	} else {
+		links["uncomplete"] = models.Link{Href: self + "/uncomplete", Method: http.MethodPatch}
+	}
+	if !todo.Archived {
+		links["archive"] = models.Link{Href: self + "/archive", Method: http.MethodPost}
+	} else {
+		links["unarchive"] = models.Link{Href: self + "/unarchive", Method: http.MethodPost}
+	}
+	return links
+}
+
+// listLinks 生成列表的超媒体链接：self 为当前页，分页时还有 first、last 以及存在时的 prev、next，与 Link 响应头一致
+// 不需要超媒体链接时返回nil
+func (h *Handler) listLinks(r *http.Request, view listView, total int) map[string]models.Link {
+	if !h.wantsHypermedia(r) {
+		return nil
+	}
+
+	self := r.URL.Path
+	if r.URL.RawQuery != "" {
+		self += "?" + r.URL.RawQuery
+	}
+	links := map[string]models.Link{"self": {Href: self}}
+	if view.PerPage <= 0 {
+		return links
+	}
+
+	meta := newListMeta(r.URL, view, total)
+	links["first"] = models.Link{Href: *pageURL(r.URL, 1)}
+	links["last"] = models.Link{Href: *pageURL(r.URL, meta.TotalPages)}
+	if meta.Prev != nil {
+		links["prev"] = models.Link{Href: *meta.Prev}
+	}
+	if meta.Next != nil {
+		links["next"] = models.Link{Href: *meta.Next}
+	}
+	return links
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/ical"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// calendarFeedRefresh 建议日历应用刷新订阅的间隔
+const calendarFeedRefresh = time.Hour
+
+// CalendarFeed 以 iCalendar 格式导出有截止时间的未完成事项，供 Google 日历、Outlook、Apple 日历等按地址订阅
+// 默认每个事项同时生成 VEVENT（在截止时间的事件）和 VTODO，?component=vevent 或 vtodo 只生成其中一种；
+// 支持与列表接口相同的过滤参数（如 ?category=工作），已完成和已归档的事项总是不导出
+func (h *Handler) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := ical.FeedOptions{Name: h.config.UI.BrandName, Refresh: calendarFeedRefresh}
+	switch strings.ToLower(query.Get("component")) {
+	case "":
+		opts.Events, opts.Todos = true, true
+	case "vevent":
+		opts.Events = true
+	case "vtodo":
+		opts.Todos = true
+	default:
+		sendError(w, "component 必须是 vevent 或 vtodo", http.StatusBadRequest)
+		return
+	}
+
+	var view listView
+	if err := view.parseFilter(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	completed, archived := false, false
+	view.Filter.Completed = &completed
+	view.Filter.Archived = &archived
+
+	todos, err := h.store.ListTodos(store.ListOptions{SortField: models.SortByDueDate, Filter: view.Filter})
+	if err != nil {
+		sendError(w, "获取待办事项失败", http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]ical.Entry, 0, len(todos))
+	for _, todo := range todos {
+		if todo.HasDueDate() {
+			entries = append(entries, ical.Entry{Todo: todo, UID: defaultCalDAVUID(todo.ID)})
+		}
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `inline; filename="todos.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(ical.EncodeFeed(entries, opts))
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/export"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// 导入时ID已存在的事项的处理方式
+const (
+	importSkip      = "skip"      // 保留现有事项，跳过导入的事项
+	importOverwrite = "overwrite" // 用导入的内容覆盖现有事项
+	importNewID     = "new-id"    // 以新的ID创建导入的事项
+)
+
+// 导入结果中单个事项的状态
+const (
+	importCreated     = "created"     // 按原ID（或没有ID时按新ID）创建
+	importOverwritten = "overwritten" // 覆盖了ID相同的现有事项
+	importSkipped     = "skipped"     // ID已存在，已跳过
+	importRenumbered  = "renumbered"  // ID已存在，以新的ID创建
+)
+
+// importResult 导入结果中的单个事项
+type importResult struct {
+	Index      int    `json:"index"`                 // 在导入数据中的位置，从0开始
+	ID         int    `json:"id"`                    // 导入后的ID
+	OriginalID int    `json:"original_id,omitempty"` // 导入数据中的ID，与导入后的ID不同时返回
+	Status     string `json:"status"`                // created、overwritten、skipped 或 renumbered
+}
+
+// ImportTodos 导入待办事项
+// 请求体为 GET /api/todos/export?format=json 导出的待办事项数组，保留ID、完成状态、创建时间和关联；
+// ?strategy= 指定ID已存在的事项如何处理：skip（默认，跳过）、overwrite（覆盖）或 new-id（以新ID创建）。
+// 全部事项在一个事务中导入，任一项无效时一个都不导入
+func (h *Handler) ImportTodos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if format := query.Get("format"); format != "" && format != export.FormatJSON {
+		sendError(w, "导入格式目前只支持 json", http.StatusBadRequest)
+		return
+	}
+	strategy := query.Get("strategy")
+	switch strategy {
+	case "":
+		strategy = importSkip
+	case importSkip, importOverwrite, importNewID:
+	default:
+		sendError(w, "strategy 必须是 skip、overwrite 或 new-id", http.StatusBadRequest)
+		return
+	}
+
+	var todos []*models.Todo
+	if err := decodeJSON(r, &todos); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为导出的待办事项数组", err)
+		return
+	}
+	if err := checkBulkSize(len(todos)); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	seen := make(map[int]bool, len(todos))
+	for i, todo := range todos {
+		if todo == nil {
+			sendError(w, fmt.Sprintf("第 %d 项：无效数据", i+1), http.StatusBadRequest)
+			return
+		}
+		if err := validateTodoRequest(todo.Request()); err != nil {
+			sendError(w, fmt.Sprintf("第 %d 项：%v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		if todo.ID > 0 {
+			if seen[todo.ID] {
+				sendError(w, fmt.Sprintf("第 %d 项：ID %d 重复", i+1, todo.ID), http.StatusBadRequest)
+				return
+			}
+			seen[todo.ID] = true
+		}
+	}
+
+	var results []importResult
+	var created, updated []*models.Todo
+	err := h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		results, created, updated = make([]importResult, len(todos)), nil, nil
+		for i, todo := range todos {
+			result, saved, err := importTodo(tx, todo, strategy)
+			if err != nil {
+				return fmt.Errorf("第 %d 项：%w", i+1, err)
+			}
+			result.Index = i
+			results[i] = result
+			switch result.Status {
+			case importOverwritten:
+				updated = append(updated, saved)
+			case importCreated, importRenumbered:
+				created = append(created, saved)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, store.ErrStoreFull) {
+		sendError(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	}
+	if err != nil {
+		sendError(w, "导入失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, todo := range created {
+		h.publish(models.EventTodoCreated, todo.ID, todo)
+	}
+	for _, todo := range updated {
+		h.publish(models.EventTodoUpdated, todo.ID, todo)
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"created": len(created),
+		"updated": len(updated),
+		"skipped": len(todos) - len(created) - len(updated),
+		"results": results,
+	}, http.StatusOK)
+}
+
+// importTodo 在事务中导入单个事项，返回结果和写入后的事项（跳过时为nil）
+// 没有ID的事项以新ID创建；ID不存在时按原ID写入，存储不支持按原ID写入时以新ID创建
+func importTodo(tx store.TodoStore, todo *models.Todo, strategy string) (importResult, *models.Todo, error) {
+	result := importResult{ID: todo.ID}
+	if todo.ID <= 0 {
+		saved, err := createImported(tx, todo)
+		if err != nil {
+			return result, nil, err
+		}
+		result.ID, result.Status = saved.ID, importCreated
+		return result, saved, nil
+	}
+
+	_, err := tx.GetTodoByID(todo.ID)
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		loader, ok := tx.(store.Loader)
+		if !ok {
+			saved, err := createImported(tx, todo)
+			if err != nil {
+				return result, nil, err
+			}
+			result.ID, result.OriginalID, result.Status = saved.ID, todo.ID, importCreated
+			return result, saved, nil
+		}
+		loaded := importedTodo(todo)
+		if err := loader.LoadTodos([]*models.Todo{loaded}); err != nil {
+			return result, nil, err
+		}
+		result.Status = importCreated
+		return result, loaded, nil
+	case err != nil:
+		return result, nil, err
+	}
+
+	switch strategy {
+	case importOverwrite:
+		saved, err := tx.SaveTodo(todo)
+		if err != nil {
+			return result, nil, err
+		}
+		result.Status = importOverwritten
+		return result, saved, nil
+	case importNewID:
+		saved, err := createImported(tx, todo)
+		if err != nil {
+			return result, nil, err
+		}
+		result.ID, result.OriginalID, result.Status = saved.ID, todo.ID, importRenumbered
+		return result, saved, nil
+	default:
+		result.Status = importSkipped
+		return result, nil, nil
+	}
+}
+
+// createImported 以新ID创建导入的事项，保留完成状态等内容；关联指向导入数据中的ID，不再保留
+func createImported(tx store.TodoStore, todo *models.Todo) (*models.Todo, error) {
+	return tx.CreateTodo(todo.Request())
+}
+
+// importedTodo 返回按原ID写入的事项，补全导入数据中缺少的版本号和时间
+func importedTodo(todo *models.Todo) *models.Todo {
+	loaded := todo.Clone()
+	if loaded.Version <= 0 {
+		loaded.Version = 1
+	}
+	if loaded.CreatedAt.IsZero() {
+		loaded.CreatedAt = time.Now()
+	}
+	if loaded.UpdatedAt.IsZero() {
+		loaded.UpdatedAt = loaded.CreatedAt
+	}
+	return loaded
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// 修改关联链接时在事务中检查出的错误
+var (
+	errLinkTargetNotFound = errors.New("目标待办事项不存在")
+	errLinkExists         = errors.New("关联已存在")
+	errLinkNotFound       = errors.New("关联不存在")
+)
+
+// linkRequest 创建关联链接请求
+type linkRequest struct {
+	Type     string `json:"type"`      // 关联类型：relates_to 或 duplicates
+	TargetID int    `json:"target_id"` // 目标待办事项ID
+}
+
+// GetTodoLinks 获取待办事项的关联链接和反向链接
+func (h *Handler) GetTodoLinks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	s := h.storeFor(r)
+	todo, err := s.GetTodoByID(id)
+	if err != nil {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+
+	links := todo.Links
+	if links == nil {
+		links = []models.TodoLink{}
+	}
+	backlinks := backlinksTo(s, id)
+	if backlinks == nil {
+		backlinks = []models.Backlink{}
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"links":     links,
+		"backlinks": backlinks,
+	}, http.StatusOK)
+}
+
+// CreateTodoLink 为待办事项添加关联链接
+func (h *Handler) CreateTodoLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	var req linkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = models.LinkRelatesTo
+	}
+	if !models.IsValidLinkType(req.Type) {
+		sendError(w, "关联类型必须是 relates_to 或 duplicates，阻塞依赖请使用 /dependencies", http.StatusBadRequest)
+		return
+	}
+	if req.TargetID == id {
+		sendError(w, "不能关联自身", http.StatusBadRequest)
+		return
+	}
+
+	var saved *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.GetTodoByID(req.TargetID); errors.Is(err, store.ErrTodoNotFound) {
+			return errLinkTargetNotFound
+		} else if err != nil {
+			return err
+		}
+		if todo.HasLink(req.Type, req.TargetID) {
+			return errLinkExists
+		}
+
+		todo.Links = append(todo.Links, models.TodoLink{Type: req.Type, TargetID: req.TargetID})
+		saved, err = tx.SaveTodo(todo)
+		return err
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errLinkTargetNotFound):
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errLinkExists):
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+	h.publish(models.EventTodoUpdated, saved.ID, saved)
+
+	sendJSON(w, h.todoResponse(r, saved), http.StatusCreated)
+}
+
+// DeleteTodoLink 删除待办事项指向目标事项的关联链接
+// 可通过 ?type= 只删除指定类型的关联，否则删除指向目标的所有关联
+func (h *Handler) DeleteTodoLink(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+	targetID, err := strconv.Atoi(vars["target"])
+	if err != nil {
+		sendError(w, "无效的目标ID", http.StatusBadRequest)
+		return
+	}
+
+	var saved *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		if !todo.RemoveLinks(r.URL.Query().Get("type"), targetID) {
+			return errLinkNotFound
+		}
+		saved, err = tx.SaveTodo(todo)
+		return err
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errLinkNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+	h.publish(models.EventTodoUpdated, saved.ID, saved)
+
+	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
+}
+
+// backlinksTo 查询指向待办事项的反向链接，按发起方ID排序，只读取有关联链接指向它的事项
+// 读取失败时记录日志并返回nil，响应中不包含反向链接但不影响主流程
+func backlinksTo(s store.TodoStore, id int) []models.Backlink {
+	sources, err := s.ListTodos(store.ListOptions{SortField: models.SortByID, Filter: store.TodoFilter{LinkedTo: id}})
+	if err != nil {
+		log.Printf("查询反向链接失败: %v", err)
+		return nil
+	}
+
+	var backlinks []models.Backlink
+	for _, source := range sources {
+		for _, link := range source.Links {
+			if link.TargetID == id {
+				backlinks = append(backlinks, models.Backlink{Type: link.Type, SourceID: source.ID})
+			}
+		}
+	}
+	return backlinks
+}
+
+// backlinkIndex 构建全部事项的反向链接索引，key为被指向的待办事项ID，用于一次为多个事项生成响应
+// 读取失败时记录日志并返回空索引，响应中不包含反向链接但不影响主流程
+func backlinkIndex(s store.TodoStore) map[int][]models.Backlink {
+	todos, err := s.GetAllTodos()
+	if err != nil {
+		log.Printf("构建反向链接失败: %v", err)
+		return nil
+	}
+
+	index := make(map[int][]models.Backlink)
+	for _, todo := range todos {
+		for _, link := range todo.Links {
+			index[link.TargetID] = append(index[link.TargetID], models.Backlink{Type: link.Type, SourceID: todo.ID})
+		}
+	}
+
+	// 按发起方ID排序，保证输出稳定
+	for _, backlinks := range index {
+		sort.Slice(backlinks, func(i, j int) bool {
+			return backlinks[i].SourceID < backlinks[j].SourceID
+		})
+	}
+	return index
+}
+
+// removeLinksTo 删除其它待办事项中指向已删除事项的关联，避免留下悬空链接
+// 批量删除时一次传入所有ID，只需遍历一次待办事项
+func (h *Handler) removeLinksTo(ids ...int) {
+	todos, err := h.store.GetAllTodos()
+	if err != nil {
+		log.Printf("清理关联链接失败: %v", err)
+		return
+	}
+
+	for _, todo := range todos {
+		changed := false
+		for _, id := range ids {
+			if todo.RemoveLinks("", id) {
+				changed = true
+			}
+		}
+		if changed {
+			saved, err := h.store.SaveTodo(todo)
+			if err != nil {
+				log.Printf("清理待办事项 %d 的关联链接失败: %v", todo.ID, err)
+				continue
+			}
+			h.publish(models.EventTodoUpdated, saved.ID, saved)
+		}
+	}
+}
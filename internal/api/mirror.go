@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+)
+
+// mirrorMaxBody 请求体超过该大小的请求不镜像，避免为镜像缓存大量数据
+const mirrorMaxBody = 1 << 20
+
+// mirrorHeader 镜像请求携带的请求头，目标服务可据此识别镜像流量；带有该请求头的请求不会被再次镜像
+const mirrorHeader = "X-Mirrored-Request"
+
+// hopHeaders 逐跳请求头，只对单个连接有效，不应转发
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// mirror 流量镜像器
+// 把一部分API请求在原请求处理完成后异步复制发送到另一个服务，比较两边的状态码，响应本身被丢弃
+type mirror struct {
+	target   string        // 目标服务的基础地址
+	percent  float64       // 镜像比例（0-100）
+	client   *http.Client  // 发送镜像请求的客户端
+	inflight chan struct{} // 限制同时进行的镜像请求数
+
+	mirrored   atomic.Int64 // 已发送的镜像请求数
+	failed     atomic.Int64 // 发送失败的镜像请求数
+	mismatched atomic.Int64 // 状态码与原请求不一致的镜像请求数
+	dropped    atomic.Int64 // 因并发已满或请求体过大而放弃镜像的请求数
+}
+
+// newMirror 根据配置创建流量镜像器，未启用或未配置目标地址时返回nil
+func newMirror(cfg config.MirrorConfig) *mirror {
+	if !cfg.Enabled || cfg.TargetURL == "" || cfg.Percent <= 0 {
+		return nil
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	maxInFlight := cfg.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = 50
+	}
+
+	return &mirror{
+		target:   strings.TrimSuffix(cfg.TargetURL, "/"),
+		percent:  cfg.Percent,
+		client:   &http.Client{Timeout: timeout},
+		inflight: make(chan struct{}, maxInFlight),
+	}
+}
+
+// mirrorMiddleware 流量镜像中间件
+// 原请求先正常处理，之后按比例在后台发送镜像请求，不影响原请求的响应和耗时
+func (h *Handler) mirrorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := h.mirror
+		// 事件流是长连接，不镜像
+		if m == nil || r.Header.Get(mirrorHeader) != "" || r.Header.Get("Accept") == eventStreamMediaType || rand.Float64()*100 >= m.percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 读取请求体以便镜像时重放，过大的请求体不镜像，原请求照常读取剩余部分
+		var body []byte
+		if r.Body != nil {
+			buf, err := io.ReadAll(io.LimitReader(r.Body, mirrorMaxBody+1))
+			if err != nil {
+				sendDecodeError(w, "读取请求失败", err)
+				return
+			}
+			if len(buf) > mirrorMaxBody {
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+				m.dropped.Add(1)
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(buf))
+			body = buf
+		}
+
+		shadow := r.Clone(context.Background())
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		select {
+		case m.inflight <- struct{}{}:
+			go func() {
+				defer func() { <-m.inflight }()
+				m.send(shadow, body, recorder.status)
+			}()
+		default:
+			m.dropped.Add(1)
+		}
+	})
+}
+
+// send 发送镜像请求并与原请求的状态码比较
+func (m *mirror) send(r *http.Request, body []byte, primaryStatus int) {
+	req, err := http.NewRequest(r.Method, m.target+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		m.failed.Add(1)
+		return
+	}
+	req.Header = r.Header.Clone()
+	for _, header := range hopHeaders {
+		req.Header.Del(header)
+	}
+	req.Header.Set(mirrorHeader, "1")
+	if host := clientKey(r); host != "" {
+		req.Header.Set("X-Forwarded-For", host)
+	}
+
+	m.mirrored.Add(1)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		m.failed.Add(1)
+		logf(r, "镜像请求 %s %s 失败: %v", r.Method, r.URL.Path, err)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != primaryStatus {
+		m.mismatched.Add(1)
+		logf(r, "镜像请求 %s %s 状态码不一致: 原请求 %d，镜像 %d", r.Method, r.URL.Path, primaryStatus, resp.StatusCode)
+	}
+}
+
+// GetMirrorStats 查询流量镜像的统计数据
+func (h *Handler) GetMirrorStats(w http.ResponseWriter, r *http.Request) {
+	m := h.mirror
+	if m == nil {
+		sendJSON(w, map[string]interface{}{"enabled": false}, http.StatusOK)
+		return
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"enabled":    true,
+		"target_url": m.target,
+		"percent":    m.percent,
+		"mirrored":   m.mirrored.Load(),
+		"failed":     m.failed.Load(),
+		"mismatched": m.mismatched.Load(),
+		"dropped":    m.dropped.Load(),
+	}, http.StatusOK)
+}
+
+// statusRecorder 记录响应状态码的 ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader 记录状态码
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap 返回原始的 ResponseWriter，流式响应通过 http.ResponseController 逐行发送
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// readCloser 组合读取器和原请求体的 Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
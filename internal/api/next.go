@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// nextSkipRequest 跳过推荐事项的请求，请求体可以为空
+type nextSkipRequest struct {
+	ID      int `json:"id"`      // 要跳过的待办事项ID，为0时跳过当前推荐的事项
+	Minutes int `json:"minutes"` // 推迟的分钟数，为0时使用配置的默认值
+}
+
+// GetNextTodo 返回下一步最应该处理的待办事项
+// 按配置的评分规则为未完成的事项评分，返回得分最高的一个；可通过 ?category= 只在指定分类中推荐。
+// 没有可推荐的事项时返回 204
+func (h *Handler) GetNextTodo(w http.ResponseWriter, r *http.Request) {
+	next, err := h.nextAction(r, time.Now())
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	if next == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	sendJSON(w, next, http.StatusOK)
+}
+
+// SkipNextTodo 暂时跳过推荐的事项，在推迟时间内不再推荐它，返回跳过之后的推荐事项
+// 请求体中不指定 id 时跳过当前推荐的事项（与 GET 使用相同的 ?category=）
+func (h *Handler) SkipNextTodo(w http.ResponseWriter, r *http.Request) {
+	var req nextSkipRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if req.Minutes < 0 {
+		sendError(w, "推迟时间不能为负数", http.StatusBadRequest)
+		return
+	}
+	if req.Minutes == 0 {
+		req.Minutes = h.config.NextAction.SkipMinutes
+	}
+
+	now := time.Now()
+	if req.ID == 0 {
+		current, err := h.nextAction(r, now)
+		if err != nil {
+			sendError(w, "获取失败", http.StatusInternalServerError)
+			return
+		}
+		if current == nil {
+			sendError(w, "没有可跳过的待办事项", http.StatusNotFound)
+			return
+		}
+		req.ID = current.Todo.ID
+	} else {
+		todo, err := h.store.GetTodoByID(req.ID)
+		if errors.Is(err, store.ErrTodoNotFound) {
+			sendError(w, "未找到", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			sendError(w, "获取失败", http.StatusInternalServerError)
+			return
+		}
+		if todo.Completed {
+			sendError(w, "已完成的事项不会被推荐", http.StatusBadRequest)
+			return
+		}
+	}
+
+	skip := models.NextSkip{
+		TodoID:    req.ID,
+		Until:     now.Add(time.Duration(req.Minutes) * time.Minute),
+		CreatedAt: now,
+	}
+	if err := store.SaveNextSkip(h.store, &skip); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+	h.purgeNextSkips(now)
+
+	next, err := h.nextAction(r, now)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, models.NextSkipResult{Skipped: skip, Next: next}, http.StatusOK)
+}
+
+// nextAction 为未完成且未归档的事项评分，返回得分最高的一个（得分相同时ID小的优先），没有可推荐的事项时返回nil
+// 请求中的 ?category= 指定只在该分类中推荐
+func (h *Handler) nextAction(r *http.Request, now time.Time) (*models.NextAction, error) {
+	category := r.URL.Query().Get("category")
+	todos, err := h.store.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	skips, err := store.ListNextSkips(h.store)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := store.ListDependencies(h.store)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	policy := h.agingPolicy()
+	var best *models.Todo
+	var result models.NextAction
+	for _, todo := range todos {
+		if todo.Completed || todo.Archived || (category != "" && todo.Category != category) {
+			continue
+		}
+		if skip, exists := skips[todo.ID]; exists && now.Before(skip.Until) {
+			result.Skipped++
+			continue
+		}
+		result.Candidates++
+
+		detail := h.scoreTodo(todo, byID, deps[todo.ID], policy, now)
+		score := round2(detail.Priority + detail.Due + detail.Age + detail.Blocked)
+		if best == nil || score > result.Score || (score == result.Score && todo.ID < best.ID) {
+			best, result.Score, result.Breakdown = todo, score, detail
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	result.Todo = h.todoResponses(r, []*models.Todo{best})[0]
+	return &result, nil
+}
+
+// scoreTodo 按配置的评分规则计算待办事项的各项得分，deps 为事项的阻塞依赖（没有时为nil）
+func (h *Handler) scoreTodo(todo *models.Todo, byID map[int]*models.Todo, deps *models.Dependencies, policy *models.AgingPolicy, now time.Time) models.ScoreDetail {
+	cfg := h.config.NextAction
+	detail := models.ScoreDetail{
+		Priority: float64(policy.EffectivePriority(todo, now)) * cfg.PriorityWeight,
+	}
+
+	if todo.HasDueDate() {
+		window := time.Duration(cfg.DueWindowDays) * 24 * time.Hour
+		remaining := todo.DueDate.Sub(now)
+		switch {
+		case remaining <= 0:
+			detail.Due = cfg.DueWeight
+		case remaining < window:
+			detail.Due = cfg.DueWeight * (1 - float64(remaining)/float64(window))
+		}
+	}
+
+	ageDays := math.Max(now.Sub(todo.CreatedAt).Hours()/24, 0)
+	detail.Age = math.Min(ageDays, float64(cfg.MaxAgeDays)) * cfg.AgeWeight
+
+	if deps != nil {
+		for _, targetID := range deps.BlockedBy {
+			if target, exists := byID[targetID]; exists && !target.Completed {
+				detail.Blocked = -cfg.BlockedPenalty
+				break
+			}
+		}
+	}
+
+	detail.Priority, detail.Due, detail.Age = round2(detail.Priority), round2(detail.Due), round2(detail.Age)
+	return detail
+}
+
+// round2 保留两位小数
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// purgeNextSkips 删除已过期的跳过记录
+func (h *Handler) purgeNextSkips(now time.Time) {
+	skips, err := store.ListNextSkips(h.store)
+	if err != nil {
+		log.Printf("⚠️ 读取跳过记录失败: %v", err)
+		return
+	}
+	for id, skip := range skips {
+		if !now.Before(skip.Until) {
+			if err := store.DeleteNextSkip(h.store, id); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+				log.Printf("⚠️ 删除过期的跳过记录失败: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// openAPIVersion 生成的文档遵循的 OpenAPI 版本
+const openAPIVersion = "3.0.3"
+
+// apiBasePath 文档描述的接口前缀，/api 是它的别名
+const apiBasePath = "/api/v1"
+
+// openAPIDescription 文档的总体说明，路由表之外的地址（CalDAV、就绪检查、监控指标）也在这里说明
+const openAPIDescription = "当前版本的全部接口挂载在 /api/v1 下，/api 是它的别名（`GET /api/todos` 为兼容仍返回数组）；以后不兼容的改动会在 /api/v2 下发布。\n\n" +
+	"请求体必须是严格的 JSON：未知字段、类型错误或多余的内容返回 400，超过配置中的 `server.max_body_bytes` 返回 413。" +
+	"错误响应的格式为 `{\"error\": \"错误信息\", \"request_id\": \"请求ID\"}`。每个响应都带有 `X-Request-ID` 头，请求中携带该头时沿用客户端的ID，反馈问题时提供它即可查找对应的日志。请求头携带 `Accept: application/hal+json`（或在配置中启用 hypermedia）时，待办事项响应会包含 `_links` 超媒体链接（self、collection、update、delete，未完成时还有 complete），/api/v1 的列表响应也会包含当前页和前后页的 `_links`。" +
+	"请求体和响应都支持 YAML：请求头 `Content-Type: application/yaml` 发送 YAML 请求体，`Accept: application/yaml` 得到 YAML 响应。" +
+	"跨域请求按配置中的 `server.allowed_origins` 设置 `Access-Control-*` 响应头，预检请求返回 204。\n\n" +
+	"其它地址：`/caldav/` 为 CalDAV 任务集合（VTODO），可在 Apple 提醒事项、Thunderbird 等客户端中添加账户双向同步；" +
+	"`/ws` 为 WebSocket 实时同步，推送数据变化的事件，并可以发送 create、update、patch、complete、delete 命令修改待办事项；" +
+	"`GET /healthz` 为存活检查，进程能处理请求时总是返回 200；`GET /readyz` 为就绪检查，任一组件异常（存储不可用、数据库迁移未完成、正在关闭）时返回 503；`GET /debug/vars` 为 expvar 格式的监控指标。"
+
+// openAPIDocument OpenAPI 3 文档
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers"`
+	Tags       []openAPITag                            `json:"tags"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+// openAPIInfo 文档信息
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// openAPIServer 接口地址
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+// openAPITag 接口分组，按路径的第一段划分
+type openAPITag struct {
+	Name string `json:"name"`
+}
+
+// openAPIOperation 单个接口（路径 + 方法）
+type openAPIOperation struct {
+	Tags        []string                   `json:"tags"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// openAPIParameter 路径参数、查询参数或请求头
+type openAPIParameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Required    bool        `json:"required,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Schema      *jsonSchema `json:"schema"`
+}
+
+// openAPIRequestBody 请求体
+type openAPIRequestBody struct {
+	Required bool                    `json:"required"`
+	Content  map[string]openAPIMedia `json:"content"`
+}
+
+// openAPIResponse 响应，Ref 非空时引用 components/responses 中的响应
+type openAPIResponse struct {
+	Ref         string                  `json:"$ref,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Content     map[string]openAPIMedia `json:"content,omitempty"`
+}
+
+// openAPIMedia 某种媒体类型的内容
+type openAPIMedia struct {
+	Schema  *jsonSchema `json:"schema"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+// openAPIComponents 可复用的组件
+type openAPIComponents struct {
+	Schemas         map[string]*jsonSchema           `json:"schemas"`
+	Responses       map[string]openAPIResponse       `json:"responses"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+// openAPISecurityScheme 认证方式
+type openAPISecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+// adminTokenNote 需要管理令牌的接口在说明中附加的内容
+const adminTokenNote = "需要 Authorization: Bearer <admin.token>，未配置 admin.token 时返回 404"
+
+// pathParamDescriptions 路径参数的说明，id、target 为整数，其它为字符串
+var pathParamDescriptions = map[string]string{
+	"id":       "ID",
+	"target":   "目标事项ID",
+	"token":    "令牌",
+	"name":     "名称",
+	"consumer": "集成方名称",
+}
+
+// OpenAPISpec 根据路由表生成 OpenAPI 3 文档
+// 遍历 /api/v1 下的全部路由生成接口，请求体和响应体的结构由 routeDocs 中的模型类型反射得到
+func (h *Handler) OpenAPISpec(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := buildOpenAPI(router)
+		if err != nil {
+			sendError(w, "生成文档失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, doc, http.StatusOK)
+	}
+}
+
+// buildOpenAPI 遍历路由表生成 OpenAPI 文档
+func buildOpenAPI(router *mux.Router) (*openAPIDocument, error) {
+	schemas := newSchemaRegistry()
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: "xStreamTool Go API", Description: openAPIDescription, Version: "1.0.0"},
+		Servers: []openAPIServer{{URL: apiBasePath}},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+
+	tags := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, apiBasePath+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// 没有限定方法的路由（如子路由前缀）不生成接口
+			return nil
+		}
+
+		path, params := openAPIPath(strings.TrimPrefix(template, apiBasePath))
+		tag := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+		tags[tag] = true
+
+		operationID := handlerName(route.GetHandler())
+		admin := underAdminPrefix(ancestors)
+		for _, method := range methods {
+			op := newOperation(schemas, method, path, params)
+			if admin {
+				requireAdminToken(op)
+			}
+			op.Tags = []string{tag}
+			op.OperationID = operationID
+			if len(methods) > 1 {
+				op.OperationID += strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+			}
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(map[string]*openAPIOperation)
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, openAPITag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	doc.Components = openAPIComponents{
+		Schemas: schemas.schemas,
+		Responses: map[string]openAPIResponse{
+			"Error": {
+				Description: "错误",
+				Content: map[string]openAPIMedia{"application/json": {Schema: &jsonSchema{
+					Type: "object",
+					Properties: map[string]*jsonSchema{
+						"error":      {Type: "string", Description: "错误信息"},
+						"request_id": {Type: "string", Description: "请求ID，与响应头 X-Request-ID 相同"},
+					},
+				}}},
+			},
+		},
+		SecuritySchemes: map[string]openAPISecurityScheme{
+			"adminToken": {Type: "http", Scheme: "bearer", Description: "配置中的 admin.token"},
+		},
+	}
+	return doc, nil
+}
+
+// underAdminPrefix 路由是否注册在需要管理令牌的 /admin 子路由下
+func underAdminPrefix(ancestors []*mux.Route) bool {
+	for _, ancestor := range ancestors {
+		if template, err := ancestor.GetPathTemplate(); err == nil && strings.HasSuffix(template, adminPathPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdminToken 为需要管理令牌的接口补充说明、认证方式和 401、403、404 响应
+func requireAdminToken(op *openAPIOperation) {
+	if op.Description != "" {
+		op.Description += "。"
+	}
+	op.Description += adminTokenNote
+	op.Security = []map[string][]string{{"adminToken": {}}}
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		op.Responses[strconv.Itoa(status)] = openAPIResponse{Ref: "#/components/responses/Error"}
+	}
+}
+
+// newOperation 根据 routeDocs 中的说明生成单个接口
+func newOperation(schemas *schemaRegistry, method, path string, params []openAPIParameter) *openAPIOperation {
+	doc := routeDocs[method+" "+path]
+	op := &openAPIOperation{
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		Parameters:  append([]openAPIParameter(nil), params...),
+		Responses:   map[string]openAPIResponse{"default": {Ref: "#/components/responses/Error"}},
+	}
+	for _, param := range doc.Query {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Schema:      &jsonSchema{Type: param.Type},
+		})
+	}
+
+	if doc.Body != nil {
+		media := openAPIMedia{Schema: schemas.schemaOf(doc.Body)}
+		media.Example = postmanExampleBodies[method+" /api"+path]
+		op.RequestBody = &openAPIRequestBody{Required: true, Content: map[string]openAPIMedia{
+			"application/json": media,
+			yamlMediaType:      {Schema: media.Schema},
+		}}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := openAPIResponse{Description: http.StatusText(status)}
+	switch {
+	case doc.ContentType != "":
+		response.Content = map[string]openAPIMedia{doc.ContentType: {Schema: &jsonSchema{Type: "string"}}}
+	case doc.Response != nil:
+		schema := schemas.schemaOf(doc.Response)
+		response.Content = map[string]openAPIMedia{"application/json": {Schema: schema}, yamlMediaType: {Schema: schema}}
+	}
+	op.Responses[strconv.Itoa(status)] = response
+
+	if doc.Conditional {
+		op.Parameters = append(op.Parameters,
+			openAPIParameter{Name: "If-Match", In: "header", Description: "读取时的 ETag，与当前版本不一致时返回 412", Schema: &jsonSchema{Type: "string"}},
+			openAPIParameter{Name: "If-Unmodified-Since", In: "header", Description: "读取时的 Last-Modified，事项在此之后修改过时返回 412", Schema: &jsonSchema{Type: "string"}},
+		)
+		op.Responses[strconv.Itoa(http.StatusPreconditionFailed)] = openAPIResponse{Ref: "#/components/responses/Error"}
+	}
+
+	if doc.Cached {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:        "If-None-Match",
+			In:          "header",
+			Description: "上次响应的 ETag，数据没有变化时返回 304",
+			Schema:      &jsonSchema{Type: "string"},
+		})
+		op.Responses[strconv.Itoa(http.StatusNotModified)] = openAPIResponse{Description: http.StatusText(http.StatusNotModified)}
+	}
+	return op
+}
+
+// openAPIPath 把 mux 的路径模板转换为 OpenAPI 格式（去掉 {id:[0-9]+} 中的正则），并返回路径参数
+func openAPIPath(template string) (string, []openAPIParameter) {
+	segments := strings.Split(template, "/")
+	var params []openAPIParameter
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.SplitN(strings.Trim(segment, "{}"), ":", 2)[0]
+		segments[i] = "{" + name + "}"
+
+		schema := &jsonSchema{Type: "string"}
+		if name == "id" || name == "target" {
+			schema.Type = "integer"
+		}
+		params = append(params, openAPIParameter{
+			Name:        name,
+			In:          "path",
+			Required:    true,
+			Description: pathParamDescriptions[name],
+			Schema:      schema,
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// handlerName 返回处理函数的方法名作为 operationId，如 (*Handler).GetTodo-fm 返回 GetTodo
+func handlerName(handler http.Handler) string {
+	fn, ok := handler.(http.HandlerFunc)
+	if !ok {
+		return ""
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	return strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+}
+
+// docsPage API 文档页面的数据
+type docsPage struct {
+	SwaggerUIURL string // Swagger UI 静态资源的地址
+	SpecURL      string // OpenAPI 文档的地址
+}
+
+// APIDocsPage API 文档页面，用 Swagger UI 展示 /api/openapi.json，可以直接在浏览器中试用接口
+func (h *Handler) APIDocsPage(w http.ResponseWriter, r *http.Request) {
+	h.renderPage(w, "docs", docsPage{
+		SwaggerUIURL: strings.TrimSuffix(h.config.UI.SwaggerUIURL, "/"),
+		SpecURL:      "/api/openapi.json",
+	})
+}
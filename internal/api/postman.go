@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// postmanSchema Postman Collection v2.1 格式的 schema 地址
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanCollection Postman 集合（同样可以导入 Insomnia）
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Variable []postmanVariable `json:"variable"`
+	Item     []postmanFolder   `json:"item"`
+}
+
+// postmanInfo 集合信息
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// postmanVariable 集合或路径变量
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// postmanFolder 按资源分组的文件夹
+type postmanFolder struct {
+	Name string        `json:"name"`
+	Item []postmanItem `json:"item"`
+}
+
+// postmanItem 单个请求
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+// postmanRequest 请求定义
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanHeader `json:"header"`
+	URL    postmanURL      `json:"url"`
+	Body   *postmanBody    `json:"body,omitempty"`
+}
+
+// postmanHeader 请求头
+type postmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// postmanURL 请求地址
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+// postmanBody 请求体
+type postmanBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+// postmanExampleBodies 需要请求体的接口的示例数据，key为"方法 路径模板"
+var postmanExampleBodies = map[string]interface{}{
+	"POST /api/todos":                          map[string]interface{}{"title": "任务标题", "description": "任务描述", "priority": 3, "category": "工作"},
+	"PUT /api/todos/{id}":                      map[string]interface{}{"title": "任务标题", "description": "任务描述", "completed": false, "priority": 3, "category": "工作", "version": 1},
+	"POST /api/todos/bulk":                     []interface{}{map[string]interface{}{"title": "任务一", "priority": 3}, map[string]interface{}{"title": "任务二", "priority": 2}},
+	"PUT /api/todos/bulk":                      []interface{}{map[string]interface{}{"id": 1, "title": "任务一", "completed": true, "priority": 3}},
+	"DELETE /api/todos/bulk":                   []int{1, 2},
+	"POST /api/todos/next/skip":                map[string]interface{}{"id": 1, "minutes": 60},
+	"POST /api/todos/{id}/links":               map[string]interface{}{"type": "relates_to", "target_id": 2},
+	"POST /api/todos/{id}/dependencies":        map[string]interface{}{"target_id": 2},
+	"POST /api/todos/{id}/shares":              map[string]interface{}{"expires_in": 86400},
+	"POST /api/todos/{id}/watchers":            map[string]interface{}{"email": "partner@example.com"},
+	"PUT /api/categories/{name}/defaults":      map[string]interface{}{"priority": 4, "description": "默认描述", "remind_offset": 60},
+	"POST /api/rules":                          map[string]interface{}{"name": "发票", "keywords": []string{"invoice", "发票"}, "category": "财务", "priority": 4},
+	"PUT /api/rules/{id}":                      map[string]interface{}{"name": "发票", "keywords": []string{"invoice", "发票"}, "category": "财务", "priority": 4},
+	"POST /api/rules/preview":                  []interface{}{map[string]interface{}{"name": "发票", "keywords": []string{"invoice", "发票"}, "category": "财务", "priority": 4}},
+	"PUT /api/templates/{name}":                map[string]interface{}{"subject": "提醒：{{.Todo.Title}}", "body": "{{.Todo.Title}} 将于 {{date .Todo.DueDate}} 到期"},
+	"POST /api/templates/{name}/preview":       map[string]interface{}{"subject": "提醒：{{.Todo.Title}}", "body": "{{.Todo.Title}} 将于 {{date .Todo.DueDate}} 到期"},
+	"POST /api/admin/scrub":                    map[string]interface{}{"version": 1, "next_id": 1, "todos": []interface{}{}},
+	"POST /api/admin/restore":                  map[string]interface{}{"version": 1, "next_id": 1, "todos": []interface{}{}},
+	"PUT /api/admin/events/offsets/{consumer}": map[string]interface{}{"seq": 42},
+}
+
+// PostmanCollection 根据路由表生成 Postman 集合
+// 返回的处理函数会遍历路由器中所有 /api 下的路由，按资源分组生成请求，
+// 集合变量 baseUrl 默认指向当前访问的服务器地址
+func (h *Handler) PostmanCollection(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+
+		folders := make(map[string]*postmanFolder)
+		err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil || !strings.HasPrefix(path, "/api/") {
+				return nil
+			}
+			// /api/v1 下的路由与 /api 相同，不重复生成
+			if strings.HasPrefix(path, "/api/v1/") {
+				return nil
+			}
+			methods, err := route.GetMethods()
+			if err != nil {
+				// 没有限定方法的路由（如子路由前缀）不生成请求
+				return nil
+			}
+
+			// 按 /api 之后的第一段路径分组，例如 todos、categories；文档页面和 OpenAPI 文档本身不生成请求
+			group := strings.SplitN(strings.TrimPrefix(path, "/api/"), "/", 2)[0]
+			if group == "docs" || group == "openapi.json" {
+				return nil
+			}
+			folder, exists := folders[group]
+			if !exists {
+				folder = &postmanFolder{Name: group}
+				folders[group] = folder
+			}
+
+			for _, method := range methods {
+				folder.Item = append(folder.Item, postmanRequestItem(method, path))
+			}
+			return nil
+		})
+		if err != nil {
+			sendError(w, "生成集合失败", http.StatusInternalServerError)
+			return
+		}
+
+		collection := postmanCollection{
+			Info:     postmanInfo{Name: "xStreamTool Go API", Schema: postmanSchema},
+			Variable: []postmanVariable{{Key: "baseUrl", Value: scheme + "://" + r.Host}},
+			Item:     make([]postmanFolder, 0, len(folders)),
+		}
+		for _, folder := range folders {
+			collection.Item = append(collection.Item, *folder)
+		}
+		sort.Slice(collection.Item, func(i, j int) bool {
+			return collection.Item[i].Name < collection.Item[j].Name
+		})
+
+		w.Header().Set("Content-Disposition", `attachment; filename="postman.json"`)
+		sendJSON(w, collection, http.StatusOK)
+	}
+}
+
+// postmanRequestItem 将一条路由转换为 Postman 请求
+// 路径变量 {id} 转换为 Postman 的 :id 形式，并提供默认值
+func postmanRequestItem(method, path string) postmanItem {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	var variables []postmanVariable
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			// 去掉花括号以及可能存在的正则约束，例如 {id:[0-9]+}
+			name := strings.SplitN(strings.Trim(segment, "{}"), ":", 2)[0]
+			segments[i] = ":" + name
+			variables = append(variables, postmanVariable{Key: name, Value: "1"})
+		}
+	}
+
+	request := postmanRequest{
+		Method: method,
+		Header: []postmanHeader{{Key: "Accept", Value: "application/json"}},
+		URL: postmanURL{
+			Raw:      "{{baseUrl}}/" + strings.Join(segments, "/"),
+			Host:     []string{"{{baseUrl}}"},
+			Path:     segments,
+			Variable: variables,
+		},
+	}
+
+	if example, exists := postmanExampleBodies[method+" "+path]; exists {
+		raw, _ := json.MarshalIndent(example, "", "  ")
+		request.Header = append(request.Header, postmanHeader{Key: "Content-Type", Value: "application/json"})
+		request.Body = &postmanBody{Mode: "raw", Raw: string(raw)}
+	}
+
+	return postmanItem{Name: method + " " + path, Request: request}
+}
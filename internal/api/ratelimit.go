@@ -0,0 +1,269 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// rateLimiter 按客户端IP限流的令牌桶限流器
+// 每个客户端拥有一个容量为limit的令牌桶，令牌以每秒limit个的速度补充
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int                     // 每秒允许的请求数，同时也是令牌桶容量
+	buckets   map[string]*tokenBucket // 每个客户端的令牌桶
+	lastSweep time.Time               // 上次清理空闲令牌桶的时间
+}
+
+// tokenBucket 单个客户端的令牌桶
+type tokenBucket struct {
+	tokens float64   // 当前剩余令牌数
+	last   time.Time // 上次补充令牌的时间
+}
+
+// quota 客户端当前的配额状态
+type quota struct {
+	Limit     int       // 配额上限
+	Remaining int       // 剩余可用请求数
+	Reset     time.Time // 令牌桶重新装满的时间
+}
+
+// newRateLimiter 创建限流器，limit小于等于0表示不限流
+func newRateLimiter(limit int) *rateLimiter {
+	return &rateLimiter{
+		limit:     limit,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// enabled 是否启用了限流
+func (l *rateLimiter) enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit > 0
+}
+
+// setLimit 运行时修改每秒允许的请求数，已有的令牌桶全部清空，按新的上限重新装满
+func (l *rateLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.buckets = make(map[string]*tokenBucket)
+}
+
+// allow 尝试为客户端消耗一个令牌，返回是否允许请求以及消耗后的配额
+func (l *rateLimiter) allow(key string) (bool, quota) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 检查 enabled 之后限流可能已在运行时关闭
+	if l.limit <= 0 {
+		return true, quota{}
+	}
+	now := time.Now()
+	bucket := l.refill(key, now)
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	return allowed, l.quotaOf(bucket, now)
+}
+
+// peek 查看客户端当前的配额，不消耗令牌
+func (l *rateLimiter) peek(key string) quota {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return quota{}
+	}
+	now := time.Now()
+	return l.quotaOf(l.refill(key, now), now)
+}
+
+// refill 按流逝的时间为客户端补充令牌，调用方需持有锁
+func (l *rateLimiter) refill(key string, now time.Time) *tokenBucket {
+	l.sweep(now)
+
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(l.limit), last: now}
+		l.buckets[key] = bucket
+		return bucket
+	}
+
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(float64(l.limit), bucket.tokens+elapsed*float64(l.limit))
+	bucket.last = now
+	return bucket
+}
+
+// sweep 每分钟清理一次已经装满的令牌桶，避免客户端过多时占用内存
+// 装满的令牌桶与新建的令牌桶等价，删除不影响限流结果
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last).Seconds()*float64(l.limit)+bucket.tokens >= float64(l.limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// quotaOf 根据令牌桶计算配额，调用方需持有锁
+func (l *rateLimiter) quotaOf(bucket *tokenBucket, now time.Time) quota {
+	missing := float64(l.limit) - bucket.tokens
+	return quota{
+		Limit:     l.limit,
+		Remaining: int(bucket.tokens),
+		Reset:     now.Add(time.Duration(missing / float64(l.limit) * float64(time.Second))),
+	}
+}
+
+// setRateLimitHeaders 在响应中写入配额相关的头部
+func setRateLimitHeaders(w http.ResponseWriter, q quota) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(q.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(q.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(q.Reset.Unix(), 10))
+}
+
+// clientKey 获取用于限流的客户端标识（IP地址）
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// newRouteLimiters 为 server.route_rate_limits 中的每个接口创建独立的限流器
+// 键统一为 "METHOD /path" 或 "/path"，方法转为大写
+func newRouteLimiters(limits map[string]int) map[string]*rateLimiter {
+	limiters := make(map[string]*rateLimiter, len(limits))
+	for route, limit := range limits {
+		key := strings.TrimSpace(route)
+		if method, path, found := strings.Cut(key, " "); found {
+			key = strings.ToUpper(method) + " " + strings.TrimSpace(path)
+		}
+		limiters[key] = newRateLimiter(limit)
+	}
+	return limiters
+}
+
+// limiterFor 返回请求使用的限流器：匹配的路由在 server.route_rate_limits 中单独配置时使用该接口的限流器，
+// 先按 "METHOD /path" 再按 "/path" 查找，否则使用全局限流器。/api 和 /api/v1 下的同一接口共用配额
+func (h *Handler) limiterFor(r *http.Request) *rateLimiter {
+	if len(h.routeLimiters) == 0 {
+		return h.limiter
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return h.limiter
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return h.limiter
+	}
+	if trimmed := strings.TrimPrefix(template, apiBasePath); trimmed != template {
+		template = trimmed
+	} else {
+		template = strings.TrimPrefix(template, "/api")
+	}
+
+	path, _ := openAPIPath(template)
+	return h.limiterForRoute(r.Method, path)
+}
+
+// limiterForRoute 返回接口使用的限流器，path 为不含 /api 或 /api/v1 前缀的路由模板，如 /todos/{id}
+func (h *Handler) limiterForRoute(method, path string) *rateLimiter {
+	if limiter, ok := h.routeLimiters[strings.ToUpper(method)+" "+path]; ok {
+		return limiter
+	}
+	if limiter, ok := h.routeLimiters[path]; ok {
+		return limiter
+	}
+	return h.limiter
+}
+
+// rateLimitMiddleware 限流中间件
+// 超出配额的请求返回 429，并通过 Retry-After 告知客户端何时可以重试
+func (h *Handler) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := h.limiterFor(r)
+		// 查询配额的接口本身不消耗配额
+		if !limiter.enabled() || r.URL.Path == "/api/ratelimit" || r.URL.Path == "/api/v1/ratelimit" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, q := limiter.allow(clientKey(r))
+		if q.Limit > 0 {
+			setRateLimitHeaders(w, q)
+		}
+		if !allowed {
+			// 距离下一个令牌可用的时间，向上取整到秒
+			retryAfter := int(math.Ceil(1 / float64(q.Limit)))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			sendError(w, "请求过于频繁", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetRateLimit 查询调用方当前的限流配额
+// 返回内容与限流响应头一致，便于脚本和SDK自行控制请求速度。
+// ?route=POST /todos/import 查询该接口实际使用的配额（与限流中间件的查找规则一致，没有单独配置时为全局配额），
+// 不指定时为全局配额；routes 为 server.route_rate_limits 中每个单独限流的接口的配额
+func (h *Handler) GetRateLimit(w http.ResponseWriter, r *http.Request) {
+	limiter := h.limiter
+	if route := strings.TrimSpace(r.URL.Query().Get("route")); route != "" {
+		method, path, found := strings.Cut(route, " ")
+		if !found {
+			sendError(w, "route 的格式应为 \"METHOD /path\"，如 \"POST /todos/import\"", http.StatusBadRequest)
+			return
+		}
+		limiter = h.limiterForRoute(method, strings.TrimSpace(path))
+	}
+
+	key := clientKey(r)
+	response := quotaResponse(limiter, key)
+	if limiter.enabled() {
+		setRateLimitHeaders(w, limiter.peek(key))
+	}
+	if len(h.routeLimiters) > 0 {
+		routes := make(map[string]map[string]interface{}, len(h.routeLimiters))
+		for route, routeLimiter := range h.routeLimiters {
+			routes[route] = quotaResponse(routeLimiter, key)
+		}
+		response["routes"] = routes
+	}
+	sendJSON(w, response, http.StatusOK)
+}
+
+// quotaResponse 生成客户端在限流器上的配额，不消耗配额；未启用限流时只返回 enabled
+func quotaResponse(limiter *rateLimiter, key string) map[string]interface{} {
+	if !limiter.enabled() {
+		return map[string]interface{}{"enabled": false}
+	}
+	q := limiter.peek(key)
+	return map[string]interface{}{
+		"enabled":   true,
+		"limit":     q.Limit,
+		"remaining": q.Remaining,
+		"reset":     q.Reset.Unix(),
+		"reset_at":  q.Reset,
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/health"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// Health 返回健康检查注册表，供调度器、任务队列等子系统注册自己的检查函数
+func (h *Handler) Health() *health.Registry {
+	return h.health
+}
+
+// Liveness 存活检查
+// 只要进程能处理请求就返回 200，不检查依赖组件，避免存储故障时 Kubernetes 反复重启实例
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, map[string]interface{}{"status": "alive", "time": time.Now().Unix()}, http.StatusOK)
+}
+
+// BeginShutdown 标记服务器正在关闭：之后的就绪检查返回 503，已有的连接和请求不受影响
+// 收到停止信号时先调用，等待 server.shutdown_delay 秒后再关闭 HTTP 服务器
+func (h *Handler) BeginShutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// ShutdownDelay 收到停止信号后等待负载均衡器摘除实例的时间
+func (h *Handler) ShutdownDelay() time.Duration {
+	return time.Duration(h.config.Server.ShutdownDelay) * time.Second
+}
+
+// Readiness 就绪检查
+// 汇总所有已注册组件的状态（存储连接、数据库迁移、是否正在关闭等），全部正常时返回 200，否则返回 503，便于负载均衡器摘除异常实例
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	sendJSON(w, report, status)
+}
+
+// checkShutdown 服务器正在关闭时报告异常
+func (h *Handler) checkShutdown(ctx context.Context) error {
+	if h.shuttingDown.Load() {
+		return errors.New("服务器正在关闭")
+	}
+	return nil
+}
+
+// checkSchema 数据库迁移检查，数据库结构版本低于当前程序需要的版本时报告异常
+func (h *Handler) checkSchema(ctx context.Context) error {
+	return store.Unwrap(h.store).(store.SchemaChecker).CheckSchema(ctx)
+}
+
+// checkStore 存储健康检查
+// 支持 Ping 的存储检查后端连接，其它存储执行一次统计查询；耗时超过 health.slow_store_ms 时降级
+func (h *Handler) checkStore(ctx context.Context) (map[string]interface{}, error) {
+	start := time.Now()
+	var err error
+	if pinger, ok := h.store.(store.Pinger); ok {
+		err = pinger.Ping(ctx)
+	} else {
+		_, err = h.store.GetStats()
+	}
+	latency := time.Since(start)
+	details := map[string]interface{}{"type": h.config.Database.Type}
+	if err != nil {
+		return details, err
+	}
+	if slow := time.Duration(h.config.Health.SlowStoreMs) * time.Millisecond; slow > 0 && latency > slow {
+		return details, health.Degraded(fmt.Errorf("存储响应耗时 %v，超过 %v", latency.Round(time.Millisecond), slow))
+	}
+	return details, nil
+}
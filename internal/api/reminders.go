@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/notify"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// defaultSnoozeMinutes 稍后提醒默认推迟的分钟数
+const defaultSnoozeMinutes = 10
+
+// maxSnooze 稍后提醒最多推迟的时长
+const maxSnooze = 30 * 24 * time.Hour
+
+// 稍后提醒时在事务中检查出的错误
+var (
+	errNoReminder        = errors.New("该事项没有设置提醒")
+	errReminderCompleted = errors.New("事项已完成，不能稍后提醒")
+)
+
+// snoozeRequest 稍后提醒请求，minutes 和 until 只能指定一个，都不指定时推迟10分钟
+type snoozeRequest struct {
+	Minutes int        `json:"minutes,omitempty"` // 从现在起推迟的分钟数
+	Until   *time.Time `json:"until,omitempty"`   // 推迟到的时间，必须晚于当前时间
+}
+
+// remindAt 计算推迟后的提醒时间
+func (req *snoozeRequest) remindAt(now time.Time) (time.Time, error) {
+	switch {
+	case req.Minutes != 0 && req.Until != nil:
+		return time.Time{}, errors.New("minutes 和 until 不能同时使用")
+	case req.Until != nil:
+		if !req.Until.After(now) {
+			return time.Time{}, errors.New("until 必须晚于当前时间")
+		}
+		if req.Until.Sub(now) > maxSnooze {
+			return time.Time{}, errors.New("最多推迟30天")
+		}
+		return *req.Until, nil
+	case req.Minutes < 0 || time.Duration(req.Minutes)*time.Minute > maxSnooze:
+		return time.Time{}, errors.New("minutes 必须是1到43200之间的整数")
+	case req.Minutes == 0:
+		return now.Add(defaultSnoozeMinutes * time.Minute), nil
+	default:
+		return now.Add(time.Duration(req.Minutes) * time.Minute), nil
+	}
+}
+
+// ListReminders 列出待发送的提醒：设置了提醒时间、未完成、未归档且还没有发送的事项，按提醒时间排列
+// ?include_fired=true 时也包含已经发送过的提醒
+func (h *Handler) ListReminders(w http.ResponseWriter, r *http.Request) {
+	includeFired := false
+	if value := r.URL.Query().Get("include_fired"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			sendError(w, "include_fired 必须是 true 或 false", http.StatusBadRequest)
+			return
+		}
+		includeFired = parsed
+	}
+
+	todos, err := h.storeFor(r).GetAllTodos()
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	fired, err := h.firedReminders()
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	reminders := []*models.Reminder{}
+	for _, todo := range todos {
+		if todo.RemindAt == nil || todo.Completed || todo.Archived {
+			continue
+		}
+		reminder := models.NewReminder(todo, reminderFired(fired, todo))
+		if reminder.Fired && !includeFired {
+			continue
+		}
+		reminders = append(reminders, reminder)
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].RemindAt.Equal(reminders[j].RemindAt) {
+			return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+		}
+		return reminders[i].TodoID < reminders[j].TodoID
+	})
+	sendJSON(w, reminders, http.StatusOK)
+}
+
+// SnoozeReminder 稍后提醒：把事项的提醒时间推迟到指定时间，已经发送过的提醒到时会再次发送
+// 请求体为 {"minutes": 30} 或 {"until": "2024-01-01T09:00:00+08:00"}，可以为空（推迟10分钟）
+func (h *Handler) SnoozeReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	var req snoozeRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			sendDecodeError(w, "无效数据，请求体应为 {\"minutes\": 30} 或 {\"until\": \"...\"}", err)
+			return
+		}
+	}
+	remindAt, err := req.remindAt(time.Now())
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var current, saved *models.Todo
+	err = h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		current = todo
+		if err := checkPreconditions(r, todo); err != nil {
+			return err
+		}
+		switch {
+		case todo.RemindAt == nil:
+			return errNoReminder
+		case todo.Completed:
+			return errReminderCompleted
+		}
+
+		todo.RemindAt = &remindAt
+		saved, err = tx.SaveTodo(todo)
+		return err
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errNoReminder):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errReminderCompleted):
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errPreconditionFailed):
+		sendPreconditionFailed(w, current)
+		return
+	case err != nil:
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+	h.publish(models.EventTodoUpdated, saved.ID, saved)
+
+	setVersionETag(w, saved)
+	sendJSON(w, models.NewReminder(saved, false), http.StatusOK)
+}
+
+// FireReminders 定时任务：为到达提醒时间的未完成事项记录 todo.reminder 事件（通过 webhook 和 /api/events/stream 推送），
+// 并给事项的关注者发送提醒邮件（超出频率限制的邮件不再补发）
+// 已发送的事项和提醒时间保存在存储中，每个提醒时间只发送一次；提醒时间修改或清除、事项删除后清除记录
+func (h *Handler) FireReminders(ctx context.Context) error {
+	todos, err := h.store.GetAllTodos()
+	if err != nil {
+		return err
+	}
+	fired, err := h.firedReminders()
+	if err != nil {
+		return err
+	}
+	watchers, err := store.ListWatchers(h.store)
+	if err != nil {
+		return err
+	}
+	watchersByTodo := make(map[int][]*models.Watcher)
+	for _, watcher := range watchers {
+		watchersByTodo[watcher.TodoID] = append(watchersByTodo[watcher.TodoID], watcher)
+	}
+
+	now := time.Now()
+	keep := make(map[string]bool)
+	published, sent := 0, 0
+	for _, todo := range todos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if todo.RemindAt == nil {
+			continue
+		}
+		key := strconv.Itoa(todo.ID)
+		if reminderFired(fired, todo) {
+			keep[key] = true
+			continue
+		}
+		if todo.Completed || todo.Archived || todo.RemindAt.After(now) {
+			continue
+		}
+
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return err
+		}
+		event := &models.Event{Type: models.EventTodoReminder, TodoID: todo.ID, Data: data, CreatedAt: now}
+		if err := h.events.AppendEvent(event); err != nil {
+			return err
+		}
+		for _, watcher := range watchersByTodo[todo.ID] {
+			ok, err := h.sendReminderEmail(watcher, todo, now)
+			if err != nil {
+				return err
+			}
+			if ok {
+				sent++
+			}
+		}
+
+		marker, err := json.Marshal(todo.RemindAt)
+		if err != nil {
+			return err
+		}
+		if err := h.store.PutMeta(store.FiredRemindersNamespace, key, marker); err != nil {
+			return err
+		}
+		keep[key] = true
+		published++
+	}
+
+	for id := range fired {
+		key := strconv.Itoa(id)
+		if keep[key] {
+			continue
+		}
+		if err := h.store.DeleteMeta(store.FiredRemindersNamespace, key); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			return err
+		}
+	}
+
+	if published > 0 {
+		log.Printf("🔔 发送了 %d 个提醒，%d 封提醒邮件", published, sent)
+	}
+	return nil
+}
+
+// sendReminderEmail 给关注者发送提醒邮件，超出频率限制或发送失败时记录日志并返回 false
+func (h *Handler) sendReminderEmail(watcher *models.Watcher, todo *models.Todo, now time.Time) (bool, error) {
+	allowed, err := h.takeNotificationQuota(watcher.Email, now)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		log.Printf("⚠️ %s 超出邮件频率限制，不发送待办事项 %d 的提醒", watcher.Email, todo.ID)
+		return false, nil
+	}
+	if err := h.mailer.Send(h.watcherMessage(notify.TemplateEmailRemindAt, watcher, todo)); err != nil {
+		log.Printf("⚠️ 发送提醒给 %s 失败: %v", watcher.Email, err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// firedReminders 读取已发送提醒的记录，key为事项ID，值为发送时的提醒时间
+func (h *Handler) firedReminders() (map[int]time.Time, error) {
+	items, err := h.store.ListMeta(store.FiredRemindersNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	fired := make(map[int]time.Time, len(items))
+	for key, data := range items {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		var remindAt time.Time
+		if json.Unmarshal(data, &remindAt) == nil {
+			fired[id] = remindAt
+		}
+	}
+	return fired, nil
+}
+
+// reminderFired 事项当前的提醒时间是否已经发送过
+func reminderFired(fired map[int]time.Time, todo *models.Todo) bool {
+	remindAt, ok := fired[todo.ID]
+	return ok && todo.RemindAt != nil && remindAt.Equal(*todo.RemindAt)
+}
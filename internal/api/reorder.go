@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// reorderRequest 调整手动排序的请求，ids 和 moves 只能使用一种
+type reorderRequest struct {
+	IDs   []int         `json:"ids,omitempty"`   // 按新顺序排列的事项ID，这些事项在它们原来占据的位置上按该顺序重新排列，其它事项不动
+	Moves []reorderMove `json:"moves,omitempty"` // 依次执行的移动操作，适合拖放一个或几个事项
+}
+
+// reorderMove 把事项移动到另一个事项之前或之后，before 和 after 只能指定一个
+type reorderMove struct {
+	ID     int `json:"id"`               // 要移动的事项ID
+	Before int `json:"before,omitempty"` // 移动到该事项之前
+	After  int `json:"after,omitempty"`  // 移动到该事项之后
+}
+
+// todoPosition 调整后事项的位置
+type todoPosition struct {
+	ID       int `json:"id"`
+	Position int `json:"position"`
+}
+
+// validate 检查请求：ids 和 moves 只能提供一种，ID 不能重复，移动操作需要指定另一个事项
+func (req *reorderRequest) validate() error {
+	switch {
+	case len(req.IDs) > 0 && len(req.Moves) > 0:
+		return errors.New("ids 和 moves 不能同时使用")
+	case len(req.IDs) > 0:
+		if err := checkBulkSize(len(req.IDs)); err != nil {
+			return err
+		}
+		seen := make(map[int]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if seen[id] {
+				return fmt.Errorf("ID %d 重复", id)
+			}
+			seen[id] = true
+		}
+	case len(req.Moves) > 0:
+		if err := checkBulkSize(len(req.Moves)); err != nil {
+			return err
+		}
+		for i, move := range req.Moves {
+			if (move.Before == 0) == (move.After == 0) {
+				return fmt.Errorf("第 %d 个移动操作：before 和 after 必须且只能指定一个", i+1)
+			}
+			if move.ID == move.Before || move.ID == move.After {
+				return fmt.Errorf("第 %d 个移动操作：不能相对于自身移动", i+1)
+			}
+		}
+	default:
+		return errors.New("需要提供 ids 或 moves")
+	}
+	return nil
+}
+
+// apply 对按当前位置排列的全部事项执行调整，返回新的顺序；引用的事项不存在时返回 ErrTodoNotFound
+func (req *reorderRequest) apply(todos []*models.Todo) ([]*models.Todo, error) {
+	order := slices.Clone(todos)
+	indexOf := func(id int) (int, error) {
+		i := slices.IndexFunc(order, func(todo *models.Todo) bool { return todo.ID == id })
+		if i < 0 {
+			return 0, fmt.Errorf("%w：ID %d", store.ErrTodoNotFound, id)
+		}
+		return i, nil
+	}
+
+	if len(req.IDs) > 0 {
+		// 列出的事项依次填回它们原来占据的位置
+		slots := make([]int, len(req.IDs))
+		moved := make([]*models.Todo, len(req.IDs))
+		for i, id := range req.IDs {
+			index, err := indexOf(id)
+			if err != nil {
+				return nil, err
+			}
+			slots[i], moved[i] = index, order[index]
+		}
+		sort.Ints(slots)
+		for i, todo := range moved {
+			order[slots[i]] = todo
+		}
+		return order, nil
+	}
+
+	for _, move := range req.Moves {
+		from, err := indexOf(move.ID)
+		if err != nil {
+			return nil, err
+		}
+		todo := order[from]
+		order = slices.Delete(order, from, from+1)
+
+		to, err := indexOf(move.Before + move.After)
+		if err != nil {
+			return nil, err
+		}
+		if move.After != 0 {
+			to++
+		}
+		order = slices.Insert(order, to, todo)
+	}
+	return order, nil
+}
+
+// ReorderTodos 调整事项的手动排序，列表按 ?sort=position&order=asc 排序时使用该顺序
+// 请求体为 {"ids": [3, 1, 2]}（只调整列出的事项之间的顺序，适合对筛选后的列表整体排序），
+// 或 {"moves": [{"id": 3, "before": 1}]}（把事项移动到另一个事项之前或之后，适合拖放）。
+// 全部事项按当前位置（相同时按ID）排成一列，调整后从1开始重新编号，只保存位置变化的事项并发布 todo.updated 事件；
+// 响应为调整后全部事项的ID和位置
+func (h *Handler) ReorderTodos(w http.ResponseWriter, r *http.Request) {
+	var req reorderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为 {\"ids\": [...]} 或 {\"moves\": [...]}", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var saved []*models.Todo
+	var positions []todoPosition
+	err := h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		saved, positions = nil, nil
+		todos, err := tx.ListTodos(store.ListOptions{Sort: []models.SortKey{{Field: models.SortByPosition}, {Field: models.SortByID}}})
+		if err != nil {
+			return err
+		}
+		order, err := req.apply(todos)
+		if err != nil {
+			return err
+		}
+
+		positions = make([]todoPosition, len(order))
+		for i, todo := range order {
+			positions[i] = todoPosition{ID: todo.ID, Position: i + 1}
+			if todo.Position == i+1 {
+				continue
+			}
+			todo.Position = i + 1
+			updated, err := tx.SaveTodo(todo)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, updated)
+		}
+		return nil
+	})
+	if errors.Is(err, store.ErrTodoNotFound) {
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "调整排序失败", http.StatusInternalServerError)
+		return
+	}
+
+	for _, todo := range saved {
+		h.publish(models.EventTodoUpdated, todo.ID, todo)
+	}
+	sendJSON(w, map[string]interface{}{
+		"todos":   positions,
+		"updated": len(saved),
+	}, http.StatusOK)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// replicated 返回存储的复制装饰器，没有配置副本时发送404并返回nil
+func (h *Handler) replicated(w http.ResponseWriter) *store.ReplicatedStore {
+	replicated := store.FindReplicated(h.store)
+	if replicated == nil {
+		sendError(w, "未配置副本", http.StatusNotFound)
+	}
+	return replicated
+}
+
+// GetReplicationStatus 管理接口：查询各副本的同步状态
+// 全部副本的落后时间不超过 replica_max_lag 且最近一次同步没有失败时返回 200，否则返回 503，便于监控系统告警
+func (h *Handler) GetReplicationStatus(w http.ResponseWriter, r *http.Request) {
+	replicated := h.replicated(w)
+	if replicated == nil {
+		return
+	}
+
+	status := replicated.Status()
+	code := http.StatusOK
+	if !status.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	sendJSON(w, status, code)
+}
+
+// ResyncReplicas 管理接口：把主存储的全部数据重新同步到每个副本，在后台执行，进度通过复制状态查看
+func (h *Handler) ResyncReplicas(w http.ResponseWriter, r *http.Request) {
+	replicated := h.replicated(w)
+	if replicated == nil {
+		return
+	}
+
+	replicated.Resync()
+	sendJSON(w, map[string]string{"message": "已开始全量同步"}, http.StatusAccepted)
+}
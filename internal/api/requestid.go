@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 接受的客户端请求ID的最大长度，超过时重新生成
+const maxRequestIDLength = 128
+
+// contextKey 请求上下文中的键
+type contextKey int
+
+// requestIDKey 上下文中请求ID的键
+const requestIDKey contextKey = iota
+
+// requestIDMiddleware 请求ID中间件
+// 沿用客户端或网关传入的 X-Request-ID（只允许可见的 ASCII 字符），没有时生成新的ID；
+// ID 写入响应头和请求上下文，日志和错误响应中都会带上它，用户反馈问题时据此查找对应的日志。
+// 请求头中同时写入该ID，镜像请求等转发出去的请求会一并带上
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// requestID 返回请求的ID，没有经过 requestIDMiddleware 的请求返回空字符串
+// 镜像请求等换了上下文的请求副本从请求头中读取
+func requestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey).(string); ok {
+		return id
+	}
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return ""
+}
+
+// logf 输出带有请求ID的日志
+func logf(r *http.Request, format string, args ...interface{}) {
+	if id := requestID(r); id != "" {
+		format = "[" + id + "] " + format
+	}
+	log.Printf(format, args...)
+}
+
+// validRequestID 客户端传入的请求ID是否可以沿用，避免换行等字符混入日志
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成随机的请求ID（64位，十六进制）
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
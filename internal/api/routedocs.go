@@ -0,0 +1,742 @@
+package api
+
+import (
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/health"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// routeDoc 接口说明，补充路由表中没有的信息，用于生成 OpenAPI 文档
+// Body、Response 为示例值（零值即可），按其类型生成 Schema
+type routeDoc struct {
+	Summary     string
+	Description string
+	Query       []queryParam
+	Body        interface{} // 请求体，为nil时没有请求体
+	Response    interface{} // 成功时的响应体，为nil时不描述响应内容
+	Status      int         // 成功时的状态码，为0时为200
+	ContentType string      // 成功时响应的媒体类型，为空时为 application/json
+	Cached      bool        // 是否返回 ETag，并在 If-None-Match 与之一致时返回 304
+	Conditional bool        // 是否支持 If-Match、If-Unmodified-Since，不满足时返回 412
+}
+
+// queryParam 查询参数
+type queryParam struct {
+	Name        string
+	Type        string // string、integer、number 或 boolean
+	Description string
+}
+
+// 列表接口共用的查询参数
+var (
+	sortParams = []queryParam{
+		{"sort", "string", "排序字段：id、title、priority、effective_priority、due_date、created_at、updated_at、position（手动排序）、relevance；多字段排序用逗号分隔（最多5个），`-` 前缀表示降序，如 `-priority,due_date`"},
+		{"order", "string", "排序方向 asc 或 desc，只在 sort 为单个字段且没有前缀时使用"},
+	}
+	pageParams = []queryParam{
+		{"page", "integer", "页码，从1开始"},
+		{"per_page", "integer", "每页数量，0表示不分页"},
+	}
+	filterParams = []queryParam{
+		{"category", "string", "精确匹配分类"},
+		{"completed", "string", "true、false 或 all，指定时忽略 show_completed"},
+		{"priority", "string", "匹配任一优先级，可以是数字或名称，如 `4,5` 或 `urgent,critical`"},
+		{"due_before", "string", "截止日期早于该时间，RFC 3339 时间或 2006-01-02 日期"},
+		{"due_after", "string", "截止日期不早于该时间，RFC 3339 时间或 2006-01-02 日期"},
+		{"overdue", "boolean", "true 只返回未完成且已过截止日期的事项，false 排除这些事项"},
+		{"tag", "string", "只返回带有全部这些标签的事项，如 `work,urgent`，也可以重复该参数"},
+	}
+	agendaParams = concatParams([]queryParam{
+		{"tz", "string", "划分日期使用的时区（IANA 名称，如 Asia/Shanghai），也可以用 X-Timezone 请求头指定"},
+		{"show_completed", "boolean", "是否包含已完成的事项，默认不包含"},
+		{"include_archived", "boolean", "是否包含已归档的事项，默认不包含"},
+	}, sortParams, pageParams)
+	listParams = concatParams(sortParams, pageParams, []queryParam{
+		{"show_completed", "boolean", "是否包含已完成的事项"},
+		{"include_archived", "boolean", "是否包含已归档的事项，默认不包含"},
+		{"near", "string", "`纬度,经度,半径公里`，只返回附近的事项，没有坐标的事项不会返回"},
+		{"q", "string", "只返回匹配的事项；启用 database.search_index 全文索引后支持分词和模糊匹配，并可用 `sort=relevance` 按相关度排序"},
+	}, filterParams)
+)
+
+// concatParams 合并多组查询参数
+func concatParams(groups ...[]queryParam) []queryParam {
+	var params []queryParam
+	for _, group := range groups {
+		params = append(params, group...)
+	}
+	return params
+}
+
+// messageResponse 只包含提示信息的响应
+var messageResponse = map[string]interface{}{"message": ""}
+
+// routeDocs 各接口的说明，key 为"方法 路径模板"，路径相对于 /api/v1；没有说明的路由仍会生成，只是没有描述
+var routeDocs = map[string]routeDoc{
+	"GET /todos": {
+		Summary: "获取待办事项列表",
+		Description: "未提供的参数使用配置文件 view 部分的默认值；过滤由存储完成（SQL 存储转换为查询条件）。" +
+			"响应头 X-Total-Count 为过滤后的总数，分页时 Link 响应头给出 first、last 以及存在时的 prev、next 页地址。" +
+			"ETag 为响应内容的哈希，请求头 If-None-Match 与之一致时返回 304 不带响应体。" +
+			"/api/v1 返回 `{\"data\": [...], \"meta\": {...}}` 信封格式，meta 中包含总数、页码和前后页的地址；/api/todos 仍返回数组",
+		Query:    listParams,
+		Response: listEnvelope{Data: []models.TodoResponse{}},
+		Cached:   true,
+	},
+	"POST /todos": {
+		Summary:     "创建待办事项",
+		Description: "location 可选，经纬度必须同时提供；未指定的分类、优先级由自动分类规则和分类默认设置填入。内存、文件存储达到配置的容量上限时返回 507",
+		Body:        models.TodoRequest{},
+		Response:    models.TodoResponse{},
+		Status:      201,
+	},
+	"DELETE /todos": {
+		Summary:     "按过滤条件删除待办事项",
+		Description: "过滤参数与列表接口相同，至少需要一个；启用回收站时移入回收站。`dry_run=true` 时不删除，在 todos 中返回将被删除的事项",
+		Query:       concatParams(filterParams, []queryParam{{"dry_run", "boolean", "只预览，不删除"}}),
+		Response:    map[string]interface{}{"message": "", "deleted": 0, "ids": []int{}, "dry_run": false, "todos": []models.TodoResponse{}},
+	},
+	"POST /todos/bulk": {
+		Summary:     "批量创建待办事项",
+		Description: "请求体为数组（最多1000项），每一项的格式与单个创建相同；任一项无效时一个都不创建",
+		Body:        []models.TodoRequest{},
+		Response:    []models.TodoResponse{},
+		Status:      201,
+	},
+	"PUT /todos/bulk": {
+		Summary:     "批量更新待办事项",
+		Description: "每一项包含 id 和与单个更新相同的字段；任一事项不存在时返回 404、带有 version 且版本不一致时返回 409，一个都不更新",
+		Body:        []bulkUpdateItem{},
+		Response:    []models.TodoResponse{},
+	},
+	"DELETE /todos/bulk": {
+		Summary:     "批量删除待办事项",
+		Description: "请求体为ID数组；任一事项不存在时返回 404，一个都不删除",
+		Body:        []int{},
+		Response:    map[string]interface{}{"message": "", "deleted": 0},
+	},
+	"PATCH /todos/complete": {
+		Summary: "批量标记完成",
+		Description: "请求体为 `{\"ids\": [1, 2, 3]}`，或者不带请求体、用与列表接口相同的过滤参数选择未完成的事项（两者不能同时使用，至少需要一个过滤参数）。" +
+			"每个事项单独处理，一项失败不影响其它项；status 为 completed、already_completed、not_found 或 failed",
+		Query:    filterParams,
+		Body:     bulkCompleteRequest{},
+		Response: map[string]interface{}{"results": []bulkCompleteResult{}, "completed": 0, "failed": 0},
+	},
+	"GET /todos/export": {
+		Summary:     "导出待办事项",
+		Description: "排序和过滤参数与列表接口相同，不分页。xlsx 工作簿中每个分类一个工作表，表头带自动筛选，优先级按级别着色",
+		Query:       concatParams([]queryParam{{"format", "string", "json（默认）、csv 或 xlsx"}}, sortParams, filterParams),
+		Response:    []models.Todo{},
+	},
+	"GET /todos/stream": {
+		Summary: "流式获取待办事项（NDJSON）",
+		Description: "每行一个待办事项（格式与列表接口中的元素相同），每写一行立即发送，适合逐行处理大量数据；排序和过滤参数与列表接口相同，不分页，" +
+			"X-Total-Count 为总数。开始发送后出错时最后一行为 `{\"error\": \"...\"}`",
+		Query:       concatParams(sortParams, filterParams),
+		ContentType: ndjsonMediaType,
+	},
+	"POST /todos/import": {
+		Summary: "导入待办事项",
+		Description: "请求体为 `GET /todos/export?format=json` 导出的待办事项数组，保留ID、完成状态和创建时间；没有ID的事项以新ID创建。" +
+			"全部事项在一个事务中导入，任一项无效时一个都不导入；每一项结果的 status 为 created、overwritten、skipped 或 renumbered",
+		Query: []queryParam{
+			{"format", "string", "目前只支持 json"},
+			{"strategy", "string", "ID已存在时的处理方式：skip（默认，跳过）、overwrite（覆盖现有事项）、new-id（以新ID创建）"},
+		},
+		Body:     []models.Todo{},
+		Response: map[string]interface{}{"created": 0, "updated": 0, "skipped": 0, "results": []importResult{}},
+	},
+	"GET /todos/calendar.ics": {
+		Summary: "订阅有截止时间的事项（iCalendar）",
+		Description: "以 iCalendar 格式导出有截止时间的未完成事项，可以在 Google 日历、Outlook、Apple 日历中按地址订阅（建议每小时刷新一次）。" +
+			"默认每个事项生成一个在截止时间的 VEVENT 和一个 VTODO；支持与列表接口相同的过滤参数",
+		Query:       concatParams([]queryParam{{"component", "string", "vevent 或 vtodo，只生成其中一种"}}, filterParams),
+		ContentType: "text/calendar",
+	},
+	"PATCH /todos/reorder": {
+		Summary: "调整手动排序",
+		Description: "ids 为按新顺序排列的事项ID，只在这些事项原来占据的位置之间重新排列；moves 依次把事项移动到另一个事项之前（before）或之后（after），两者只能使用一种。" +
+			"全部事项调整后从1开始重新编号（新建的事项位置为0，排在最前），列表用 ?sort=position&order=asc 按该顺序排列；位置变化的事项发布 todo.updated 事件。" +
+			"响应为全部事项调整后的位置，updated 为位置变化的事项数",
+		Body:     reorderRequest{},
+		Response: map[string]interface{}{"todos": []todoPosition{}, "updated": 0},
+	},
+	"GET /todos/search": {
+		Summary:     "搜索待办事项",
+		Description: "q 匹配标题或描述，结果按优先级、创建时间排列",
+		Cached:      true,
+		Query: concatParams([]queryParam{
+			{"q", "string", "搜索词"},
+			{"category", "string", "精确匹配分类"},
+			{"completed", "string", "true、false 或 all（默认，不区分完成状态）"},
+			{"include_archived", "boolean", "是否包含已归档的事项，默认不包含"},
+		}, pageParams),
+		Response: listEnvelope{Data: []models.TodoResponse{}},
+	},
+	"GET /todos/today": {
+		Summary: "今天到期的待办事项",
+		Description: "截止时间在用户时区的今天之内。时区依次取 ?tz=、X-Timezone 请求头和配置的 view.timezone，都没有时使用服务器时区；" +
+			"没有指定 show_completed 或 completed 时只返回未完成的事项，没有指定 sort 时按截止时间升序排列。响应为 v1 列表格式，附带 timezone 和截止时间范围 from、to",
+		Query:    agendaParams,
+		Response: agendaEnvelope{listEnvelope: listEnvelope{Data: []models.TodoResponse{}}},
+		Cached:   true,
+	},
+	"GET /todos/upcoming": {
+		Summary:     "近期到期的待办事项",
+		Description: "截止时间在用户时区明天零点之后的 days 天内（不包含今天）；时区、默认过滤和排序与 GET /todos/today 相同",
+		Query:       concatParams([]queryParam{{"days", "integer", "包含的天数，1到365，默认7"}}, agendaParams),
+		Response:    agendaEnvelope{listEnvelope: listEnvelope{Data: []models.TodoResponse{}}},
+		Cached:      true,
+	},
+	"GET /todos/overdue": {
+		Summary:     "已过期的待办事项",
+		Description: "未完成且截止时间早于当前时间的事项，与列表的 ?overdue=true 相同，默认按截止时间升序排列（最早过期的在前）；to 为判断时使用的当前时间",
+		Query:       agendaParams,
+		Response:    agendaEnvelope{listEnvelope: listEnvelope{Data: []models.TodoResponse{}}},
+		Cached:      true,
+	},
+	"GET /todos/next": {
+		Summary: "推荐下一步处理的事项",
+		Description: "返回最应该处理的一个未完成事项及其得分明细；得分由有效优先级、截止临近程度、已创建时间和是否在等待未完成的阻塞依赖（/todos/{id}/dependencies）决定，" +
+			"权重在配置的 next_action 部分调整。没有可推荐的事项时返回 204",
+		Query:    []queryParam{{"category", "string", "只在该分类中推荐"}},
+		Response: models.NextAction{},
+	},
+	"POST /todos/next/skip": {
+		Summary:     "暂时跳过推荐的事项",
+		Description: "推迟时间内不再推荐；不指定 id 时跳过当前推荐的事项，minutes 默认取配置的 skip_minutes。返回跳过记录和新的推荐事项",
+		Body:        nextSkipRequest{},
+		Response:    models.NextSkipResult{},
+	},
+	"GET /todos/archived": {
+		Summary:     "分页浏览归档的事项",
+		Description: "最近归档的在前",
+		Cached:      true,
+		Query:       concatParams([]queryParam{{"q", "string", "匹配标题、描述或分类"}, {"category", "string", "精确匹配分类"}}, pageParams),
+		Response:    listEnvelope{Data: []models.ArchivedTodo{}},
+	},
+	"GET /todos/{id}": {
+		Summary: "获取待办事项",
+		Description: "响应头 ETag 为事项的版本号（version），每次修改后加1，请求头 If-None-Match 与之一致时返回 304。status 为 in_progress、completed 或 overdue，" +
+			"display_status 为按 `?lang=` 或 Accept-Language 本地化的状态文字（zh-CN、en-US、en-GB、de-DE、ja-JP，默认使用配置的 ui.locale）。" +
+			"没有截止日期的事项不返回 due_date 字段",
+		Query:    []queryParam{{"lang", "string", "显示状态文字的语言区域"}},
+		Response: models.TodoResponse{},
+		Cached:   true,
+	},
+	"PUT /todos/{id}": {
+		Summary: "更新待办事项",
+		Description: "通过 If-Match 请求头（值为 GET 返回的 ETag）或 If-Unmodified-Since（值为 GET 返回的 Last-Modified）指定读取时的版本，与当前事项不一致时返回 412；" +
+			"请求体中的 version 字段与当前版本不一致时返回 409。都不指定时不检查版本。" +
+			"due_date 省略、为 null 或 \"0001-01-01T00:00:00Z\" 时表示没有截止日期",
+		Body:        models.TodoRequest{},
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"PATCH /todos/{id}": {
+		Summary:     "部分更新待办事项",
+		Description: "只修改请求体中提供的字段，其它字段保持不变；due_date、location 为 null 时清空。版本检查与 PUT 相同，没有任何要修改的字段时返回 400；Content-Type 为 application/json-patch+json 时请求体为 JSON Patch（RFC 6902）操作列表，作用于与 PUT 请求体相同的文档（如 /tags/- 追加标签、/version 指定版本），test 操作不满足时返回 409；Content-Type 为 application/merge-patch+json 时请求体为 JSON Merge Patch（RFC 7386），null 表示清空该字段，没有任何字段时返回 400",
+		Conditional: true,
+		Body:        models.TodoPatch{},
+		Response:    models.TodoResponse{},
+	},
+	"DELETE /todos/{id}": {
+		Summary:     "删除待办事项",
+		Description: "在配置的 trash 部分启用回收站时移入回收站，保留 retention_days 天后永久删除；If-Match 或 If-Unmodified-Since 与当前事项不一致时返回 412，不删除",
+		Conditional: true,
+		Response:    messageResponse,
+	},
+	"PATCH /todos/{id}/complete": {
+		Summary:     "标记待办事项为完成",
+		Description: "completed_at 记录完成时间；事项已完成时保留原来的完成时间",
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"PATCH /todos/{id}/uncomplete": {
+		Summary:     "重新打开已完成的待办事项",
+		Description: "把事项标记为未完成并清空 completed_at，发布 todo.updated 事件；事项未完成时只增加版本号",
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"POST /todos/{id}/archive": {
+		Summary:     "归档待办事项",
+		Description: "归档的事项保留在存储中，通过 GET /todos/{id} 仍可获取，但默认不出现在列表、搜索、导出、日历订阅和下一步推荐中；列表和搜索指定 ?include_archived=true 时一并返回。与按保留天数移入冷存储的归档（/archive）无关",
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"POST /todos/{id}/unarchive": {
+		Summary:     "取消归档待办事项",
+		Description: "事项重新出现在默认列表中",
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"GET /todos/{id}/history": {
+		Summary: "获取修改历史",
+		Description: "按修订号从旧到新返回每次创建、修改、完成和删除的操作者、时间和变化的字段；修订号为修改后的版本号（version）。" +
+			"修改历史来自审计日志，需在配置的 audit 部分启用，启用之前的修改没有记录；已删除事项的历史仍然可以查看",
+		Response: map[string]interface{}{"todo_id": 0, "revisions": []models.TodoRevision{}},
+	},
+	"POST /todos/{id}/revert/{revision}": {
+		Summary: "恢复到指定修订版本",
+		Description: "把标题、描述、完成状态、优先级、分类、截止时间、地点和归档状态恢复为该修订版本的内容，关联链接和手动排序的位置保持不变；" +
+			"恢复作为一次新的修改记入历史，内容与当前相同时不修改。修订版本不存在时返回 404，已删除的事项需先从回收站恢复",
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"GET /todos/{id}/links": {
+		Summary:     "获取关联链接",
+		Description: "返回事项的关联链接（links）和其它事项指向它的反向链接（backlinks）",
+		Response:    map[string]interface{}{"links": []models.TodoLink{}, "backlinks": []models.Backlink{}},
+	},
+	"POST /todos/{id}/links": {
+		Summary:     "添加关联链接",
+		Description: "类型可选 relates_to（相关）或 duplicates（重复）；等待其它事项完成请使用 /todos/{id}/dependencies",
+		Body:        linkRequest{},
+		Response:    models.TodoResponse{},
+		Status:      201,
+	},
+	"DELETE /todos/{id}/links/{target}": {
+		Summary:  "删除关联链接",
+		Query:    []queryParam{{"type", "string", "只删除该类型的链接，不指定时删除所有类型"}},
+		Response: messageResponse,
+	},
+	"GET /todos/{id}/dependencies": {
+		Summary:     "获取阻塞依赖",
+		Description: "返回事项需要等待的事项及其完成状态；blocked 表示是否还有未完成的依赖，未完成时下一步推荐会降低事项的得分",
+		Response:    dependenciesResponse{BlockedBy: []models.DependencyTarget{}},
+	},
+	"POST /todos/{id}/dependencies": {
+		Summary:     "添加阻塞依赖",
+		Description: "事项需要等待目标事项完成。不能依赖自身或不存在的事项（400）；依赖已存在或添加后会形成环时返回 409",
+		Body:        dependencyRequest{},
+		Response:    dependenciesResponse{BlockedBy: []models.DependencyTarget{}},
+		Status:      201,
+	},
+	"DELETE /todos/{id}/dependencies/{target}": {
+		Summary:  "删除阻塞依赖",
+		Response: messageResponse,
+	},
+	"GET /todos/{id}/subtasks": {
+		Summary:     "获取子任务",
+		Description: "返回事项的子任务和完成情况；子任务随事项保存，不出现在事项列表中。事项响应中的 progress 为同样的完成情况",
+		Response:    subtaskList{Subtasks: []models.Subtask{}},
+	},
+	"POST /todos/{id}/subtasks": {
+		Summary:     "添加子任务",
+		Description: "子任务ID在事项内递增，一个事项最多100个子任务；响应为更新后的事项，发布 todo.updated 事件",
+		Body:        subtaskRequest{},
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"PATCH /todos/{id}/subtasks/{subtask}": {
+		Summary:     "修改子任务",
+		Description: "修改子任务的标题或完成状态，未提供的字段保持不变；子任务不存在时返回 404",
+		Body:        subtaskPatch{},
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"DELETE /todos/{id}/subtasks/{subtask}": {
+		Summary:     "删除子任务",
+		Response:    models.TodoResponse{},
+		Conditional: true,
+	},
+	"GET /todos/{id}/shares": {
+		Summary:  "获取事项的分享链接",
+		Response: []shareResponse{},
+	},
+	"POST /todos/{id}/shares": {
+		Summary:     "创建公开分享链接",
+		Description: "创建只读的公开分享链接，返回的 url（/share/{token}）无需认证即可访问；默认7天后过期",
+		Body:        shareRequest{},
+		Response:    shareResponse{},
+		Status:      201,
+	},
+	"GET /todos/{id}/watchers": {
+		Summary:  "获取关注者",
+		Response: []watcherResponse{},
+	},
+	"POST /todos/{id}/watchers": {
+		Summary: "添加关注者",
+		Description: "关注者可以是没有账号的外部协作者，截止前（默认24小时）收到提醒邮件，完成后收到通知邮件。" +
+			"每封邮件都带有退订链接 /unsubscribe/{token}；发往同一邮箱的邮件每小时有数量上限，超出的推迟发送",
+		Body:     watcherRequest{},
+		Response: watcherResponse{},
+		Status:   201,
+	},
+	"DELETE /todos/{id}/watchers/{token}": {
+		Summary:  "移除关注者",
+		Response: messageResponse,
+	},
+	"GET /health": {
+		Summary: "健康检查",
+		Description: "检查存储（连接和耗时）、日志目录所在磁盘的剩余空间和内存压力，components 为各组件的状态（up、degraded、down）和详细信息；" +
+			"status 为汇总状态：全部正常时为 healthy，有组件降级时为 degraded，有组件异常时为 unhealthy 并返回 503。阈值见配置的 health 部分",
+		Response: map[string]interface{}{"status": "", "time": int64(0), "service": "", "version": "", "components": []health.ComponentStatus{}},
+	},
+	"GET /ratelimit": {
+		Summary: "查询限流配额",
+		Description: "返回当前客户端的限流配额（上限、剩余次数和重置时间），不消耗配额。" +
+			"routes 为 server.route_rate_limits 中单独限流的接口各自的配额，键与配置一致，没有单独限流的接口时不返回",
+		Query: []queryParam{{"route", "string", "查询该接口实际使用的配额，如 \"POST /todos/import\"，路径不含 /api 前缀；没有单独限流时为全局配额"}},
+		Response: map[string]interface{}{"enabled": false, "limit": 0, "remaining": 0, "reset": int64(0), "reset_at": time.Time{},
+			"routes": map[string]interface{}{}},
+	},
+	"GET /dashboard/widgets": {
+		Summary: "仪表盘数据",
+		Description: "一次返回计数器、7天内即将到期和已过期的前5项、最近10条活动。" +
+			"数据会缓存，事件发件箱中出现新事件（包括其它实例产生的）或超过30秒后重新生成，响应头 X-Cache 表示是否命中缓存；" +
+			"ETag 为响应内容的哈希，If-None-Match 与之一致时返回 304",
+		Response: models.DashboardWidgets{},
+		Cached:   true,
+	},
+	"GET /calendar/{year}/{month}": {
+		Summary: "日历月视图",
+		Description: "按截止日期把该月的事项分到每一天（包含没有事项的日期），供日历组件使用。日期按用户时区划分，时区取法与 GET /todos/today 相同；" +
+			"默认包含已完成的事项，每天的 completed 为其中已完成的数量；支持 category、priority 等过滤参数",
+		Query: concatParams([]queryParam{
+			{"tz", "string", "划分日期使用的时区（IANA 名称），也可以用 X-Timezone 请求头指定"},
+			{"show_completed", "boolean", "是否包含已完成的事项，默认包含"},
+			{"include_archived", "boolean", "是否包含已归档的事项，默认不包含"},
+		}, filterParams),
+		Response: models.CalendarMonth{Days: []models.CalendarDay{}},
+		Cached:   true,
+	},
+
+	"GET /tags": {
+		Summary:     "列出标签",
+		Description: "列出所有事项用到的标签，count 为带有该标签的事项数（包括已完成和已归档的），active 为其中未完成且未归档的数量；按 count 降序排列",
+		Response:    []models.TagCount{},
+	},
+	"POST /tags/{tag}/rename": {
+		Summary:     "重命名标签",
+		Description: "把所有事项上的该标签改为新名称；新名称已被使用时两个标签合并。没有事项使用该标签时返回 404；每个修改的事项发布 todo.updated 事件",
+		Body:        tagRenameRequest{},
+		Response:    map[string]interface{}{"tag": "", "updated": 0},
+	},
+	"POST /tags/merge": {
+		Summary:     "合并标签",
+		Description: "带有任一来源标签的事项改为带有目标标签，目标标签可以是新标签。没有事项使用这些标签时返回 404",
+		Body:        tagMergeRequest{},
+		Response:    map[string]interface{}{"tag": "", "updated": 0},
+	},
+
+	"GET /reminders": {
+		Summary: "列出待发送的提醒",
+		Description: "列出设置了 remind_at、未完成、未归档且还没有发送的提醒，按提醒时间排列。" +
+			"定时任务每分钟检查一次，到达提醒时间时记录 todo.reminder 事件（webhook 和事件流都会收到）并给关注者发送提醒邮件，每个提醒时间只发送一次",
+		Query:    []queryParam{{"include_fired", "boolean", "是否包含已经发送过的提醒"}},
+		Response: []models.Reminder{},
+	},
+	"POST /reminders/{id}/snooze": {
+		Summary:     "稍后提醒",
+		Description: "把事项的提醒时间推迟到 until，或从现在起推迟 minutes 分钟（请求体为空时推迟10分钟，最多推迟30天）；已经发送过的提醒到时会再次发送。事项没有提醒时返回 404，已完成时返回 409",
+		Body:        snoozeRequest{},
+		Response:    models.Reminder{},
+		Conditional: true,
+	},
+
+	"GET /categories/defaults": {
+		Summary:  "获取所有分类的默认设置",
+		Response: []models.CategoryDefaults{},
+	},
+	"GET /categories/{name}/defaults": {
+		Summary:  "获取分类的默认设置",
+		Response: models.CategoryDefaults{},
+	},
+	"PUT /categories/{name}/defaults": {
+		Summary:     "设置分类默认值",
+		Description: "在该分类下创建且未指定相应字段时生效；remind_offset 为截止时间前多少分钟提醒，只在创建时设置了截止时间且未指定 remind_at 时生效",
+		Body:        models.CategoryDefaults{},
+		Response:    models.CategoryDefaults{},
+	},
+	"DELETE /categories/{name}/defaults": {
+		Summary:  "删除分类默认值",
+		Response: messageResponse,
+	},
+	"GET /shares": {
+		Summary:  "获取全部分享链接",
+		Response: []shareResponse{},
+	},
+	"DELETE /shares/{token}": {
+		Summary:     "撤销分享链接",
+		Description: "令牌立即失效",
+		Response:    messageResponse,
+	},
+	"GET /rules": {
+		Summary:  "获取自动分类规则",
+		Response: []models.Rule{},
+	},
+	"POST /rules": {
+		Summary: "创建自动分类规则",
+		Description: "标题或描述包含任一关键字（不区分大小写）时，为创建时未指定的分类和优先级填入规则的值。" +
+			"多条规则按 order 依次匹配，先匹配的规则优先；规则在分类默认设置之前生效",
+		Body:     models.Rule{},
+		Response: models.Rule{},
+		Status:   201,
+	},
+	"GET /rules/preview": {
+		Summary:     "预览自动分类规则",
+		Description: "预览规则会如何重新分类已有的待办事项，只返回分类或优先级会变化的事项，不修改数据。GET 使用已保存的规则，POST 时请求体为规则数组，用于保存前试用草稿规则",
+		Response:    map[string]interface{}{"rules": 0, "checked": 0, "changes": []rulePreview{}},
+	},
+	"POST /rules/preview": {
+		Summary:  "预览草稿规则",
+		Body:     []models.Rule{},
+		Response: map[string]interface{}{"rules": 0, "checked": 0, "changes": []rulePreview{}},
+	},
+	"GET /rules/{id}": {
+		Summary:  "获取自动分类规则",
+		Response: models.Rule{},
+	},
+	"PUT /rules/{id}": {
+		Summary:  "修改自动分类规则",
+		Body:     models.Rule{},
+		Response: models.Rule{},
+	},
+	"DELETE /rules/{id}": {
+		Summary:  "删除自动分类规则",
+		Response: messageResponse,
+	},
+	"POST /rpc": {
+		Summary: "JSON-RPC 2.0 调用",
+		Description: "请求体为 JSON-RPC 2.0 请求对象，或最多100个请求对象的数组（批量调用，按顺序执行，结果按请求顺序返回）；没有 id 的请求为通知，不返回结果，全部是通知时返回 204。" +
+			"方法按对应的接口执行，params 为对象：todo.list、todo.search、todo.next 的参数同查询参数；todo.get、todo.complete、todo.uncomplete、todo.archive、todo.unarchive、todo.delete 需要 id；" +
+			"todo.create 的参数同创建的请求体；todo.update、todo.patch 为 id 加上修改的字段。result 与接口的响应体相同；" +
+			"接口返回 400 时错误码为 -32602，5xx 为 -32603，其它错误为 -32000，状态码在 error.data.status 中",
+		Body:     rpcRequest{},
+		Response: rpcResponse{},
+	},
+	"GET /webhooks": {
+		Summary:     "获取 webhook 订阅",
+		Description: "列出所有 webhook 订阅，不返回签名密钥",
+		Response:    []models.Webhook{},
+	},
+	"POST /webhooks": {
+		Summary: "创建 webhook 订阅",
+		Description: "订阅的事件（events：created、updated、completed、deleted、overdue，为空表示全部）发生后，" +
+			"以 POST 把事件 JSON（id、event、seq、todo_id、created_at、data）发送到 url。" +
+			"请求头 X-Webhook-Signature 为 sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + \".\" + 请求体) 的十六进制，接收方用密钥校验；" +
+			"返回 2xx 视为成功，否则按配置的间隔指数退避重试。未指定 secret 时自动生成，只在此响应中返回一次。只投递创建之后发生的事件",
+		Body:     models.Webhook{},
+		Response: models.Webhook{},
+		Status:   201,
+	},
+	"GET /webhooks/{id}": {
+		Summary:  "获取 webhook 订阅",
+		Response: models.Webhook{},
+	},
+	"PUT /webhooks/{id}": {
+		Summary:     "修改 webhook 订阅",
+		Description: "未指定 secret 时沿用原来的密钥；disabled 为 true 时暂停投递，暂停期间的事件不会补发",
+		Body:        models.Webhook{},
+		Response:    models.Webhook{},
+	},
+	"DELETE /webhooks/{id}": {
+		Summary:     "删除 webhook 订阅",
+		Description: "尚未发送的投递不再发送，投递记录一并删除",
+		Response:    messageResponse,
+	},
+	"GET /webhooks/{id}/deliveries": {
+		Summary:     "获取 webhook 投递记录",
+		Description: "最新的在前，包括发送的请求体、已发送次数、最近一次的响应状态码和错误，以及下一次重试的时间",
+		Query:       []queryParam{{"status", "string", "按状态筛选：pending、succeeded、failed"}, {"limit", "integer", "返回数量"}},
+		Response:    []models.WebhookDelivery{},
+	},
+	"POST /webhooks/{id}/deliveries/{delivery}/redeliver": {
+		Summary:     "重新发送 webhook 投递",
+		Description: "把投递（包括已成功和已失败的）重新放入队列，在下一次投递时发送，请求体和投递ID不变",
+		Response:    models.WebhookDelivery{},
+		Status:      202,
+	},
+	"GET /templates": {
+		Summary:     "列出通知模板",
+		Description: "列出可以自定义模板的通知（email.reminder 截止提醒邮件、email.completed 完成通知邮件）及当前使用的模板",
+		Response:    []templateResponse{},
+	},
+	"GET /templates/{name}": {
+		Summary:  "获取通知模板",
+		Response: templateResponse{},
+	},
+	"PUT /templates/{name}": {
+		Summary: "保存自定义模板",
+		Description: "Go text/template 语法，可引用 .Event、.Todo 的全部字段、.Email、.UnsubscribeURL、.Now，以及 date（可带格式参数）、upper、lower 函数。" +
+			"保存前用示例数据试渲染，语法错误或引用了不存在的字段时返回 400；邮件末尾的退订说明总是自动附加",
+		Body:     templateRequest{},
+		Response: templateResponse{},
+	},
+	"DELETE /templates/{name}": {
+		Summary:     "删除自定义模板",
+		Description: "恢复使用默认模板",
+		Response:    templateResponse{},
+	},
+	"POST /templates/{name}/preview": {
+		Summary:     "预览通知模板",
+		Description: "请求体提供模板时预览草稿，否则预览当前使用的模板；不指定 todo_id 时使用示例数据",
+		Query:       []queryParam{{"todo_id", "integer", "用该事项渲染"}},
+		Body:        templateRequest{},
+		Response:    templatePreview{},
+	},
+	"GET /archive": {
+		Summary:     "搜索归档的事项",
+		Description: "不区分大小写地匹配标题、描述或分类，最近归档的在前",
+		Query:       []queryParam{{"q", "string", "搜索词"}, {"category", "string", "精确匹配分类"}},
+		Response:    []models.ArchivedTodo{},
+	},
+	"GET /archive/{id}": {
+		Summary:  "获取归档的事项",
+		Response: models.ArchivedTodo{},
+	},
+	"POST /archive/{id}/rehydrate": {
+		Summary:     "恢复归档的事项",
+		Description: "按原ID恢复到存储，存储中已有该ID时返回 409；归档时清理的关联、分享链接和关注者不会恢复",
+		Response:    models.TodoResponse{},
+		Status:      201,
+	},
+	"GET /trash": {
+		Summary: "列出回收站中的事项",
+		Description: "最先被永久删除的在前；purge_in_seconds 和 days_left 为距离永久删除的倒计时，到期的事项按 interval（分钟）定期清理。" +
+			"需在配置的 trash 部分启用",
+		Response: []trashResponse{},
+	},
+	"POST /trash/{id}/restore": {
+		Summary:     "恢复回收站中的事项",
+		Description: "按原ID恢复到存储，存储中已有该ID时返回 409；删除时清理的关联、分享链接和关注者不会恢复",
+		Response:    models.TodoResponse{},
+		Status:      201,
+	},
+	"GET /audit": {
+		Summary: "查询审计日志",
+		Description: "最新的在前：每次创建、修改、完成和删除都记录操作者、来源地址、修改前后的事项和变化的字段。" +
+			"操作者取自配置的 audit.actor_header 请求头（默认 X-Forwarded-User），定时任务为 system；has_more 为 true 时用 before 加上 next_before 继续读取。需在配置的 audit 部分启用",
+		Query: []queryParam{
+			{"todo_id", "integer", "只返回该事项的记录"},
+			{"actor", "string", "操作者"},
+			{"action", "string", "create、update、complete 或 delete"},
+			{"since", "string", "起始时间，RFC 3339 格式"},
+			{"until", "string", "截止时间，RFC 3339 格式"},
+			{"before", "string", "只返回ID小于该值的记录（上一页的 next_before）"},
+			{"limit", "integer", "返回数量"},
+		},
+		Response: map[string]interface{}{"entries": []models.AuditEntry{}, "has_more": false, "next_before": ""},
+	},
+	"POST /admin/scrub": {
+		Summary:     "脱敏备份快照",
+		Description: "替换上传快照中的标题、描述、分类、地点和邮箱，保留ID、日期等结构，便于分享复现数据",
+		Query:       []queryParam{{"seed", "integer", "随机种子，相同的种子得到相同的结果"}},
+		Body:        models.Snapshot{},
+		Response:    models.Snapshot{},
+	},
+	"GET /events": {
+		Summary: "实时事件流（Server-Sent Events）",
+		Description: "以 text/event-stream 推送发件箱中的新事件：id 为序号，event 为事件类型（todo.created、todo.updated、todo.completed、todo.deleted 等），data 为事件 JSON（格式同 GET /admin/events）。" +
+			"浏览器的 EventSource 断线重连时带上 Last-Event-ID 请求头，补发期间错过的事件；首次连接可以用 last_event_id 指定起点，都没有时只推送连接之后的事件。没有事件时每15秒发送一个注释行保持连接",
+		Query:       []queryParam{{"last_event_id", "integer", "从该序号之后推送，与 Last-Event-ID 请求头相同"}},
+		ContentType: eventStreamMediaType,
+	},
+	"GET /admin/events": {
+		Summary: "读取事件发件箱",
+		Description: "按序号返回序号大于 after 的事件（todo.created、todo.updated、todo.completed、todo.deleted 等），用于集成方补发和重放。" +
+			"默认只记录 API 请求产生的修改；配置 database.journal 启用变更日志模式后，所有修改都在同一个事务中记录为事件",
+		Query:    []queryParam{{"after", "integer", "从该序号之后读取"}, {"limit", "integer", "返回数量"}},
+		Response: map[string]interface{}{"events": []models.Event{}, "last_seq": int64(0), "has_more": false},
+	},
+	"GET /admin/config": {
+		Summary:     "查看当前配置",
+		Description: "返回当前生效的配置（包括命令行参数覆盖和运行时修改后的值），数据库及副本的密码、SMTP 密码、对象存储密钥和管理令牌显示为 ******",
+		Response:    config.Config{},
+	},
+	"PUT /admin/config": {
+		Summary: "运行时修改配置",
+		Description: "修改日志级别、全局限流（每秒请求数，0表示不限流，不影响 route_rate_limits 中单独限流的接口）和 CORS 允许的来源，立即生效并写入 config.json，不需要重启；" +
+			"未提供的项保持不变，包含其它配置项时返回 400。返回修改后的配置",
+		Body:     runtimeConfigRequest{},
+		Response: config.Config{},
+	},
+	"GET /admin/events/offsets/{consumer}": {
+		Summary:  "获取集成方已确认的事件位置",
+		Response: models.EventOffset{},
+	},
+	"PUT /admin/events/offsets/{consumer}": {
+		Summary:     "确认已处理的事件序号",
+		Description: "重启后通过 GET 同一地址读取并从该序号之后继续",
+		Body:        eventOffsetRequest{},
+		Response:    models.EventOffset{},
+	},
+	"GET /admin/mirror": {
+		Summary:     "流量镜像统计",
+		Description: "已镜像、失败、状态码不一致和放弃的请求数。在配置的 mirror 部分启用后，按比例把 API 请求异步复制到 target_url",
+		Response:    map[string]interface{}{"enabled": false, "target_url": "", "percent": 0.0, "mirrored": int64(0), "failed": int64(0), "mismatched": int64(0), "dropped": int64(0)},
+	},
+	"GET /admin/replication": {
+		Summary: "副本同步状态",
+		Description: "每个副本等待同步的修改数、落后秒数、已同步数、失败次数和最近的错误。有副本落后超过 replica_max_lag 或同步失败时返回 503；" +
+			"未配置副本时返回 404",
+		Response: store.ReplicationStatus{},
+	},
+	"POST /admin/replication/resync": {
+		Summary:     "重新同步副本",
+		Description: "把主存储的全部数据重新同步到副本，在后台执行",
+		Response:    messageResponse,
+		Status:      202,
+	},
+	"GET /admin/degraded": {
+		Summary: "降级模式状态",
+		Description: "是否降级、开始时间、最近的错误、降级次数和只读副本的刷新时间；降级时返回 503，未启用时返回 404。" +
+			"降级时读请求返回内存只读副本中的数据并带有 X-Store-Degraded 和 Warning 头，写请求返回 503 和 Retry-After",
+		Response: store.DegradedStatus{},
+	},
+	"GET /admin/backups": {
+		Summary:     "列出备份文件",
+		Description: "最新的在前。在配置的 backup 部分启用后按 interval（分钟）定期备份，格式为 json（快照）或 sql（SQLite 脚本），只保留最近 keep 个",
+		Response:    []models.BackupInfo{},
+	},
+	"POST /admin/backups": {
+		Summary:  "立即备份",
+		Response: models.BackupInfo{},
+		Status:   201,
+	},
+	"POST /admin/restore": {
+		Summary: "从备份恢复",
+		Description: "用备份替换当前的全部待办事项和附属数据：通过 file 指定备份文件，或不带参数、在请求体中提交快照。" +
+			"启用定期备份时恢复前先自动备份当前数据；事件发件箱保持不变，已分配过的ID不会被重新使用",
+		Query:    []queryParam{{"file", "string", "备份文件名"}},
+		Body:     models.Snapshot{},
+		Response: models.RestoreResult{},
+	},
+	"POST /admin/archive": {
+		Summary: "立即归档",
+		Description: "把完成后超过 after_days 天未修改的事项写入 gzip 压缩的归档文件（本地目录或 S3 兼容的对象存储），写入成功后从存储中移除，并像删除一样产生 todo.deleted 事件。" +
+			"在配置的 archive 部分启用后按 interval（分钟）定期归档",
+		Response: models.ArchiveResult{},
+	},
+	"POST /admin/trash/purge": {
+		Summary:  "清理回收站",
+		Query:    []queryParam{{"all", "boolean", "为 true 时清空回收站，否则只删除已到期的事项"}},
+		Response: map[string]interface{}{"purged": 0, "ids": []int{}},
+	},
+	"POST /admin/trash/{id}/extend": {
+		Summary:  "延长回收站中事项的保留期",
+		Body:     map[string]interface{}{"days": 0},
+		Response: trashResponse{},
+	},
+	"DELETE /admin/trash/{id}": {
+		Summary:     "永久删除回收站中的事项",
+		Description: "不等到期立即永久删除",
+		Response:    messageResponse,
+	},
+	"GET /admin/fsck": {
+		Summary: "检查数据一致性",
+		Description: "检查无效、重复或指向已删除事项的关联链接和阻塞依赖，阻塞依赖形成的环，指向已删除事项的分享链接、关注者、阻塞依赖、CalDAV 资源和跳过记录，以及无法解析的附属数据。" +
+			"命令行可使用 `xstream fsck [--repair]`",
+		Response: models.FsckReport{},
+	},
+	"POST /admin/fsck": {
+		Summary:     "修复数据一致性问题",
+		Description: "在一个事务中修复可以自动修复的问题，环和无法解析的数据只报告",
+		Response:    models.FsckReport{},
+	},
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// jsonRPCVersion 支持的 JSON-RPC 版本
+const jsonRPCVersion = "2.0"
+
+// maxRPCBatch 一次批量调用最多包含的请求数
+const maxRPCBatch = 100
+
+// JSON-RPC 2.0 规范定义的错误码
+const (
+	rpcParseError     = -32700 // 请求不是有效的 JSON
+	rpcInvalidRequest = -32600 // 请求不是有效的 JSON-RPC 请求对象
+	rpcMethodNotFound = -32601 // 方法不存在
+	rpcInvalidParams  = -32602 // 参数无效（对应接口返回 400）
+	rpcInternalError  = -32603 // 服务器内部错误（对应接口返回 5xx）
+	rpcServerError    = -32000 // 对应接口返回的其它错误（404、409、412、429 等），状态码在 data.status 中
+)
+
+// rpcParams 方法参数的用法
+const (
+	rpcParamsQuery  = iota // 参数对象转换为查询参数
+	rpcParamsBody          // 参数对象作为请求体
+	rpcParamsID            // 只需要 id
+	rpcParamsIDBody        // id 之外的参数作为请求体
+)
+
+// rpcMethod JSON-RPC 方法对应的 REST 接口
+type rpcMethod struct {
+	httpMethod string
+	path       string // /api/v1 下的路径，{id} 替换为参数中的 id
+	params     int
+}
+
+// rpcMethods 支持的方法，按对应的 /api/v1 接口执行，参数和结果与接口相同
+var rpcMethods = map[string]rpcMethod{
+	"todo.list":       {http.MethodGet, "/todos", rpcParamsQuery},
+	"todo.search":     {http.MethodGet, "/todos/search", rpcParamsQuery},
+	"todo.next":       {http.MethodGet, "/todos/next", rpcParamsQuery},
+	"todo.get":        {http.MethodGet, "/todos/{id}", rpcParamsID},
+	"todo.create":     {http.MethodPost, "/todos", rpcParamsBody},
+	"todo.update":     {http.MethodPut, "/todos/{id}", rpcParamsIDBody},
+	"todo.patch":      {http.MethodPatch, "/todos/{id}", rpcParamsIDBody},
+	"todo.complete":   {http.MethodPatch, "/todos/{id}/complete", rpcParamsID},
+	"todo.uncomplete": {http.MethodPatch, "/todos/{id}/uncomplete", rpcParamsID},
+	"todo.archive":    {http.MethodPost, "/todos/{id}/archive", rpcParamsID},
+	"todo.unarchive":  {http.MethodPost, "/todos/{id}/unarchive", rpcParamsID},
+	"todo.delete":     {http.MethodDelete, "/todos/{id}", rpcParamsID},
+}
+
+// rpcRequest JSON-RPC 请求对象，没有 id 的请求为通知，不返回响应
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// rpcResponse JSON-RPC 响应对象，result 和 error 只有一个
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"` // 无法确定请求的 id 时为 null
+}
+
+// rpcError JSON-RPC 错误对象
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// rpcErrorData 接口返回错误时附带的信息
+type rpcErrorData struct {
+	Status    int    `json:"status"`               // 对应接口的状态码
+	RequestID string `json:"request_id,omitempty"` // 内部请求的请求ID，用于查找日志
+}
+
+// RPC 返回 JSON-RPC 2.0 接口（POST /api/rpc），方法按对应的 /api/v1 接口执行，经过限流、降级等中间件
+// 请求体为单个请求对象或请求对象数组（批量调用，按顺序依次执行）；全部是通知时返回 204
+func (h *Handler) RPC(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			sendDecodeError(w, "读取请求体失败", err)
+			return
+		}
+
+		body = bytes.TrimSpace(body)
+		if !json.Valid(body) {
+			sendJSON(w, rpcFailure(nil, rpcParseError, "无效的 JSON", nil), http.StatusOK)
+			return
+		}
+
+		// 单个请求
+		if body[0] != '[' {
+			if response := h.callRPC(router, r, body); response != nil {
+				sendJSON(w, response, http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+			sendJSON(w, rpcFailure(nil, rpcInvalidRequest, "批量调用不能为空", nil), http.StatusOK)
+			return
+		}
+		if len(batch) > maxRPCBatch {
+			sendJSON(w, rpcFailure(nil, rpcInvalidRequest, fmt.Sprintf("批量调用最多包含 %d 个请求", maxRPCBatch), nil), http.StatusOK)
+			return
+		}
+
+		responses := []*rpcResponse{}
+		for _, item := range batch {
+			if response := h.callRPC(router, r, item); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		sendJSON(w, responses, http.StatusOK)
+	}
+}
+
+// callRPC 执行一个 JSON-RPC 请求，通知返回nil
+func (h *Handler) callRPC(router *mux.Router, r *http.Request, data json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return rpcFailure(nil, rpcInvalidRequest, "无效的请求对象："+decodeErrorDetail(err), nil)
+	}
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" || !validRPCID(req.ID) {
+		return rpcFailure(req.ID, rpcInvalidRequest, `无效的请求对象：jsonrpc 必须为 "2.0"，method 不能为空，id 必须是字符串、数字或 null`, nil)
+	}
+
+	response := h.execRPC(router, r, &req)
+	if req.ID == nil {
+		return nil
+	}
+	return response
+}
+
+// execRPC 把请求转换为对应的 REST 接口执行，接口的响应体作为结果，错误按状态码转换为 JSON-RPC 错误
+func (h *Handler) execRPC(router *mux.Router, r *http.Request, req *rpcRequest) *rpcResponse {
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcFailure(req.ID, rpcMethodNotFound, fmt.Sprintf("方法 %q 不存在，支持的方法：%s", req.Method, strings.Join(rpcMethodNames(), "、")), nil)
+	}
+
+	path, body, err := method.request(req.Params)
+	if err != nil {
+		return rpcFailure(req.ID, rpcInvalidParams, err.Error(), nil)
+	}
+	status, result, err := dispatchInternal(router, r, method.httpMethod, path, body, nil)
+	if err != nil {
+		return rpcFailure(req.ID, rpcInternalError, err.Error(), nil)
+	}
+
+	if status >= 200 && status <= 299 {
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		return &rpcResponse{JSONRPC: jsonRPCVersion, Result: result, ID: req.ID}
+	}
+
+	var failure struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	json.Unmarshal(result, &failure)
+	if failure.Error == "" {
+		failure.Error = http.StatusText(status)
+	}
+	code := rpcServerError
+	switch {
+	case status == http.StatusBadRequest:
+		code = rpcInvalidParams
+	case status >= 500:
+		code = rpcInternalError
+	}
+	return rpcFailure(req.ID, code, failure.Error, rpcErrorData{Status: status, RequestID: failure.RequestID})
+}
+
+// request 根据参数生成接口的路径（含查询参数）和请求体
+// 参数必须是对象（按名称传参）或省略；需要 id 的方法 id 必须是正整数
+func (m rpcMethod) request(raw json.RawMessage) (string, []byte, error) {
+	params := map[string]json.RawMessage{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return "", nil, errors.New("params 必须是对象")
+		}
+	}
+
+	path := apiBasePath + m.path
+	if m.params == rpcParamsID || m.params == rpcParamsIDBody {
+		var id int
+		if err := json.Unmarshal(params["id"], &id); err != nil || id <= 0 {
+			return "", nil, errors.New("params.id 必须是正整数")
+		}
+		delete(params, "id")
+		path = strings.Replace(path, "{id}", strconv.Itoa(id), 1)
+	}
+
+	switch m.params {
+	case rpcParamsQuery:
+		query, err := rpcQuery(params)
+		if err != nil {
+			return "", nil, err
+		}
+		if encoded := query.Encode(); encoded != "" {
+			path += "?" + encoded
+		}
+		return path, nil, nil
+	case rpcParamsBody, rpcParamsIDBody:
+		body, err := json.Marshal(params)
+		return path, body, err
+	}
+	if len(params) > 0 {
+		return "", nil, errors.New("除 id 外不接受其它参数")
+	}
+	return path, nil, nil
+}
+
+// rpcQuery 把参数对象转换为查询参数，值可以是字符串、数字、布尔值或它们的数组（同名参数出现多次）
+func rpcQuery(params map[string]json.RawMessage) (url.Values, error) {
+	query := url.Values{}
+	for name, raw := range params {
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, item := range values {
+			switch v := item.(type) {
+			case string:
+				query.Add(name, v)
+			case json.Number:
+				query.Add(name, v.String())
+			case bool:
+				query.Add(name, strconv.FormatBool(v))
+			default:
+				return nil, fmt.Errorf("参数 %s 必须是字符串、数字、布尔值或它们的数组", name)
+			}
+		}
+	}
+	return query, nil
+}
+
+// validRPCID id 是否为字符串、数字或 null（省略表示通知）
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return false
+	}
+	switch value.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
+
+// rpcFailure 生成错误响应
+func rpcFailure(id json.RawMessage, code int, message string, data interface{}) *rpcResponse {
+	return &rpcResponse{JSONRPC: jsonRPCVersion, Error: &rpcError{Code: code, Message: message, Data: data}, ID: id}
+}
+
+// rpcMethodNames 按名称排序的方法列表
+func rpcMethodNames() []string {
+	names := make([]string, 0, len(rpcMethods))
+	for name := range rpcMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
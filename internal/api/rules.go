@@ -0,0 +1,229 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// ruleTarget 预览中事项的分类和优先级
+type ruleTarget struct {
+	Category string          `json:"category"`
+	Priority models.Priority `json:"priority"`
+}
+
+// rulePreview 预览中一个会被重新分类的事项
+type rulePreview struct {
+	TodoID   int        `json:"todo_id"`  // 待办事项ID
+	Title    string     `json:"title"`    // 标题
+	Current  ruleTarget `json:"current"`  // 当前的分类和优先级
+	Proposed ruleTarget `json:"proposed"` // 按规则重新分类后的分类和优先级
+	Rules    []string   `json:"rules"`    // 生效的规则ID
+}
+
+// ListRules 获取所有自动分类规则，按匹配顺序排列
+func (h *Handler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := store.ListRules(h.store)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, rules, http.StatusOK)
+}
+
+// GetRule 获取单个自动分类规则
+func (h *Handler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := store.GetRule(h.store, mux.Vars(r)["id"])
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, rule, http.StatusOK)
+}
+
+// CreateRule 创建自动分类规则
+func (h *Handler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.Rule
+	if err := decodeJSON(r, &rule); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := newRuleID()
+	if err != nil {
+		sendError(w, "生成规则ID失败", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	rule.ID = id
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	if err := store.SaveRule(h.store, &rule); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, rule, http.StatusCreated)
+}
+
+// UpdateRule 更新自动分类规则，ID和创建时间保持不变
+func (h *Handler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	existing, err := store.GetRule(h.store, mux.Vars(r)["id"])
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	var rule models.Rule
+	if err := decodeJSON(r, &rule); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = time.Now()
+
+	if err := store.SaveRule(h.store, &rule); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, rule, http.StatusOK)
+}
+
+// DeleteRule 删除自动分类规则
+func (h *Handler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := store.DeleteRule(h.store, mux.Vars(r)["id"]); err != nil {
+		sendError(w, "删除失败", http.StatusNotFound)
+		return
+	}
+
+	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
+}
+
+// PreviewRules 预览规则会如何重新分类已有的待办事项，不修改任何数据
+// GET 使用已保存的规则；POST 的请求体为规则数组，用于保存前试用草稿规则。
+// 每个事项按只有标题和描述的新建请求重新应用规则，只返回分类或优先级会发生变化的事项
+func (h *Handler) PreviewRules(w http.ResponseWriter, r *http.Request) {
+	var rules []*models.Rule
+	if r.Method == http.MethodPost {
+		if err := decodeJSON(r, &rules); err != nil && !errors.Is(err, io.EOF) {
+			sendDecodeError(w, "无效数据，请求体应为规则数组", err)
+			return
+		}
+		for i, rule := range rules {
+			if rule == nil {
+				sendError(w, fmt.Sprintf("第 %d 条规则：无效数据", i+1), http.StatusBadRequest)
+				return
+			}
+			if err := rule.Validate(); err != nil {
+				sendError(w, fmt.Sprintf("第 %d 条规则：%v", i+1, err), http.StatusBadRequest)
+				return
+			}
+			if rule.ID == "" {
+				rule.ID = fmt.Sprintf("draft-%d", i+1)
+			}
+		}
+		sort.SliceStable(rules, func(i, j int) bool { return rules[i].Order < rules[j].Order })
+	} else {
+		saved, err := store.ListRules(h.store)
+		if err != nil {
+			sendError(w, "获取规则失败", http.StatusInternalServerError)
+			return
+		}
+		rules = saved
+	}
+
+	todos, err := h.store.ListTodos(store.ListOptions{SortField: models.SortByID})
+	if err != nil {
+		sendError(w, "获取待办事项失败", http.StatusInternalServerError)
+		return
+	}
+
+	changes := make([]rulePreview, 0)
+	for _, todo := range todos {
+		req := &models.TodoRequest{Title: todo.Title, Description: todo.Description}
+		applied := models.ApplyRules(rules, req)
+		if len(applied) == 0 {
+			continue
+		}
+
+		current := ruleTarget{Category: todo.Category, Priority: todo.Priority}
+		proposed := current
+		if req.Category != "" {
+			proposed.Category = req.Category
+		}
+		if req.Priority != 0 {
+			proposed.Priority = req.Priority
+		}
+		if proposed == current {
+			continue
+		}
+
+		ids := make([]string, len(applied))
+		for i, rule := range applied {
+			ids[i] = rule.ID
+		}
+		changes = append(changes, rulePreview{
+			TodoID:   todo.ID,
+			Title:    todo.Title,
+			Current:  current,
+			Proposed: proposed,
+			Rules:    ids,
+		})
+	}
+
+	sendJSON(w, map[string]interface{}{
+		"rules":   len(rules),
+		"checked": len(todos),
+		"changes": changes,
+	}, http.StatusOK)
+}
+
+// applyRules 将已保存的自动分类规则应用到创建请求
+func (h *Handler) applyRules(req *models.TodoRequest) error {
+	rules, err := store.ListRules(h.store)
+	if err != nil {
+		return err
+	}
+	models.ApplyRules(rules, req)
+	return nil
+}
+
+// newRuleID 生成随机的规则ID
+func newRuleID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
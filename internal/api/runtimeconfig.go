@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+)
+
+// logLevels 日志级别，数值越大越严重
+var logLevels = map[string]int32{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+// currentLogLevel 当前的日志级别，启动时取自 logging.level，运行时可以通过 PUT /api/admin/config 修改
+var currentLogLevel atomic.Int32
+
+// setLogLevel 设置日志级别，未知的级别返回错误
+func setLogLevel(level string) error {
+	value, ok := logLevels[strings.ToLower(level)]
+	if !ok {
+		return fmt.Errorf("无效的日志级别：%q，可选 debug、info、warn、error", level)
+	}
+	currentLogLevel.Store(value)
+	return nil
+}
+
+// logEnabled 指定级别的日志是否需要记录；访问日志为 info 级别，日志级别为 warn 或 error 时不记录
+func logEnabled(level string) bool {
+	return logLevels[level] >= currentLogLevel.Load()
+}
+
+// runtimeConfigRequest 运行时修改配置的请求，只包含不需要重启就能安全生效的配置项，未提供的项保持不变
+type runtimeConfigRequest struct {
+	LogLevel       *string   `json:"log_level"`       // 日志级别：debug、info、warn、error
+	RateLimit      *int      `json:"rate_limit"`      // 每个客户端每秒允许的请求数，0表示不限流；不影响 route_rate_limits 中单独限流的接口
+	AllowedOrigins *[]string `json:"allowed_origins"` // CORS允许的来源，"*" 表示任意来源，[] 表示不允许跨域
+}
+
+// validate 检查请求中的配置项，来源必须是 "*" 或不带路径的 http(s) 地址
+func (req *runtimeConfigRequest) validate() error {
+	if req.LogLevel == nil && req.RateLimit == nil && req.AllowedOrigins == nil {
+		return errors.New("没有要修改的配置项")
+	}
+	if req.LogLevel != nil {
+		if _, ok := logLevels[strings.ToLower(*req.LogLevel)]; !ok {
+			return fmt.Errorf("无效的日志级别：%q，可选 debug、info、warn、error", *req.LogLevel)
+		}
+	}
+	if req.RateLimit != nil && *req.RateLimit < 0 {
+		return errors.New("rate_limit 不能小于0")
+	}
+	if req.AllowedOrigins != nil {
+		for _, origin := range *req.AllowedOrigins {
+			if origin == "*" {
+				continue
+			}
+			parsed, err := url.Parse(origin)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+				strings.TrimSuffix(parsed.Path, "/") != "" || parsed.RawQuery != "" {
+				return fmt.Errorf("无效的来源：%q，应为 \"*\" 或 https://app.example.com 形式的地址", origin)
+			}
+		}
+	}
+	return nil
+}
+
+// apply 把请求中的配置项写入配置
+func (req *runtimeConfigRequest) apply(cfg *config.Config) {
+	if req.LogLevel != nil {
+		cfg.Logging.Level = strings.ToLower(*req.LogLevel)
+	}
+	if req.RateLimit != nil {
+		cfg.Server.RateLimit = *req.RateLimit
+	}
+	if req.AllowedOrigins != nil {
+		cfg.Server.AllowedOrigins = append([]string{}, *req.AllowedOrigins...)
+	}
+}
+
+// GetConfig 返回当前生效的配置，数据库密码、SMTP 密码和对象存储密钥已隐藏
+// 包括命令行参数覆盖后的值和运行时修改过的值
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	h.configMu.RLock()
+	redacted := h.config.Redacted()
+	h.configMu.RUnlock()
+	sendJSON(w, redacted, http.StatusOK)
+}
+
+// UpdateConfig 运行时修改日志级别、限流和 CORS 允许的来源，立即生效，不需要重启
+// 修改同时写入 config.json：重新读取配置文件后只修改这几项再保存，命令行参数覆盖的端口等设置不会写入文件；
+// 其它配置项需要修改配置文件后重启，请求中包含它们时返回 400。
+// rate_limit 只修改全局限流，server.route_rate_limits 中单独限流的接口使用各自的配额，不受影响
+func (h *Handler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	var req runtimeConfigRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据，只能修改 log_level、rate_limit、allowed_origins", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.configMu.Lock()
+	if req.LogLevel != nil {
+		if err := setLogLevel(*req.LogLevel); err != nil {
+			h.configMu.Unlock()
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req.apply(h.config)
+	if req.RateLimit != nil {
+		h.limiter.setLimit(h.config.Server.RateLimit)
+	}
+	redacted := h.config.Redacted()
+
+	fileConfig := config.LoadConfig()
+	req.apply(fileConfig)
+	err := config.SaveConfig(fileConfig)
+	h.configMu.Unlock()
+
+	log.Printf("⚙️ 运行时配置已修改: 日志级别 %s，限流 %d，允许的来源 %v", redacted.Logging.Level, redacted.Server.RateLimit, redacted.Server.AllowedOrigins)
+	if err != nil {
+		log.Printf("⚠️ 保存配置文件失败: %v", err)
+		sendError(w, "配置已生效，但保存配置文件失败，重启后会恢复原来的配置", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, redacted, http.StatusOK)
+}
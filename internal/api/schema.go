@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// jsonSchema OpenAPI 3.0 中的 Schema 对象（JSON Schema 的子集）
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Nullable             bool                   `json:"nullable,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	AllOf                []*jsonSchema          `json:"allOf,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	priorityType   = reflect.TypeOf(models.Priority(0))
+)
+
+// schemaRegistry 根据 Go 类型生成 Schema，具名结构体登记为 components/schemas 中的组件并以 $ref 引用
+// 字段名和是否省略取自 json 标签，与 encoding/json 的编码结果一致
+type schemaRegistry struct {
+	schemas map[string]*jsonSchema  // 组件名 -> Schema
+	names   map[reflect.Type]string // 已登记的类型 -> 组件名
+}
+
+// newSchemaRegistry 创建空的组件登记表
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*jsonSchema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf 返回示例值的 Schema，value 为nil时返回空 Schema（任意值）
+// interface{} 字段和 map[string]interface{} 按示例值中实际存放的值生成，
+// 因此 listEnvelope{Data: []models.TodoResponse{}} 或 map[string]interface{}{"deleted": 0} 这样的响应也能得到准确的结构
+func (g *schemaRegistry) schemaOf(value interface{}) *jsonSchema {
+	if value == nil {
+		return &jsonSchema{}
+	}
+	v := reflect.ValueOf(value)
+	return g.schema(v.Type(), v)
+}
+
+// schema 返回类型的 Schema，v 为该类型的示例值，没有示例值时为无效的 reflect.Value
+func (g *schemaRegistry) schema(t reflect.Type, v reflect.Value) *jsonSchema {
+	switch t {
+	case timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &jsonSchema{Type: "integer", Format: "int64", Description: "纳秒"}
+	case rawMessageType:
+		return &jsonSchema{}
+	case priorityType:
+		return &jsonSchema{Type: "integer", Description: "1到5，0表示未设置；请求中也可以使用名称 low、normal、high、urgent、critical"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &jsonSchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Ptr:
+		return nullable(g.schema(t.Elem(), elem(v)))
+	case reflect.Interface:
+		if v.IsValid() && !v.IsNil() {
+			return g.schema(v.Elem().Type(), v.Elem())
+		}
+		return &jsonSchema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		// []*models.Todo 这样的指针切片中不会出现 null，元素按指向的类型生成
+		itemType, item := t.Elem(), reflect.Value{}
+		if v.IsValid() && v.Len() > 0 {
+			item = v.Index(0)
+		}
+		if itemType.Kind() == reflect.Ptr {
+			itemType, item = itemType.Elem(), elem(item)
+		}
+		return &jsonSchema{Type: "array", Items: g.schema(itemType, item)}
+	case reflect.Map:
+		if v.IsValid() && v.Len() > 0 && t.Elem().Kind() == reflect.Interface {
+			return g.mapSchema(v)
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: g.schema(t.Elem(), reflect.Value{})}
+	case reflect.Struct:
+		if isNullable(t) {
+			value := t.Field(1).Type // Nullable[T].Value 为 *T
+			return nullable(g.schema(value.Elem(), reflect.Value{}))
+		}
+		// 含有 interface{} 字段的结构体（如列表信封）按示例值内联，其它具名结构体登记为组件
+		if t.Name() == "" || hasInterfaceField(t) {
+			return g.structSchema(t, v)
+		}
+		return g.component(t)
+	}
+	return &jsonSchema{}
+}
+
+// component 登记具名结构体并返回对它的引用，先登记占位再生成字段，以支持自引用的类型
+func (g *schemaRegistry) component(t reflect.Type) *jsonSchema {
+	name, exists := g.names[t]
+	if !exists {
+		name = g.componentName(t)
+		g.names[t] = name
+		g.schemas[name] = &jsonSchema{}
+		*g.schemas[name] = *g.structSchema(t, reflect.Value{})
+	}
+	return &jsonSchema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName 返回组件名：首字母大写的类型名，与其它包的同名类型冲突时加上包名
+func (g *schemaRegistry) componentName(t reflect.Type) string {
+	name := exportedName(t.Name())
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	return exportedName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+}
+
+// structSchema 按 json 标签生成结构体的 Schema，匿名嵌入的结构体字段提升到外层
+func (g *schemaRegistry) structSchema(t reflect.Type, v reflect.Value) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		var fieldValue reflect.Value
+		if v.IsValid() {
+			fieldValue = v.Field(i)
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded, fieldValue = embedded.Elem(), elem(fieldValue)
+			}
+			if embedded.Kind() == reflect.Struct {
+				for key, property := range g.structSchema(embedded, fieldValue).Properties {
+					schema.Properties[key] = property
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = g.schema(field.Type, fieldValue)
+	}
+	return schema
+}
+
+// mapSchema 按示例 map 中的键和值生成对象的 Schema
+func (g *schemaRegistry) mapSchema(v reflect.Value) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		value := v.MapIndex(key)
+		if value.IsNil() {
+			schema.Properties[key.String()] = &jsonSchema{}
+			continue
+		}
+		schema.Properties[key.String()] = g.schema(value.Elem().Type(), value.Elem())
+	}
+	return schema
+}
+
+// nullable 把 Schema 标记为可以为 null；OpenAPI 3.0 中 $ref 的同级字段会被忽略，因此用 allOf 包装
+func nullable(schema *jsonSchema) *jsonSchema {
+	if schema.Ref != "" {
+		return &jsonSchema{AllOf: []*jsonSchema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+// elem 返回指针示例值指向的值，指针为nil或没有示例值时返回无效值
+func elem(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.IsNil() {
+		return reflect.Value{}
+	}
+	return v.Elem()
+}
+
+// isNullable 是否为 models.Nullable[T]（包含 Set 和 Value 两个字段的泛型结构体）
+func isNullable(t reflect.Type) bool {
+	return strings.HasPrefix(t.Name(), "Nullable[") && t.NumField() == 2 &&
+		t.Field(0).Name == "Set" && t.Field(1).Name == "Value"
+}
+
+// hasInterfaceField 结构体是否直接包含 interface{} 类型的字段
+func hasInterfaceField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() == reflect.Interface {
+			return true
+		}
+	}
+	return false
+}
+
+// exportedName 把名称的首字母转为大写
+func exportedName(name string) string {
+	runes := []rune(name)
+	if len(runes) == 0 {
+		return name
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseCompleted 解析三态的完成状态过滤条件
+// 为空或 all 时返回nil，表示不过滤；否则接受 true/false（以及 1/0 等 strconv.ParseBool 支持的写法）
+func parseCompleted(value string) (*bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "all") {
+		return nil, nil
+	}
+	completed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.New("completed 必须是 true、false 或 all")
+	}
+	return &completed, nil
+}
+
+// SearchTodos 搜索待办事项
+// ?q= 匹配标题或描述（子串），?category= 精确匹配分类，?completed= 为 true、false 或 all（默认，不过滤）；
+// 已归档的事项只在 ?include_archived=true 时返回；
+// 结果按优先级从高到低、创建时间从新到旧排列，通过 ?page=&per_page= 分页，响应格式与 v1 列表相同
+func (h *Handler) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	completed, err := parseCompleted(query.Get("completed"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	view := listView{Page: 1, PerPage: h.config.View.PageSize, ShowCompleted: true}
+	if err := view.parsePage(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := view.parseArchived(query); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := h.store.SearchTodos(strings.TrimSpace(query.Get("q")), strings.TrimSpace(query.Get("category")), completed)
+	if err != nil {
+		sendError(w, "搜索失败", http.StatusInternalServerError)
+		return
+	}
+	todos, total := view.apply(todos, nil)
+
+	setPaginationHeaders(w, r.URL, view, total)
+	sendJSONWithETag(w, r, h.newListEnvelope(r, h.todoResponses(r, todos), view, total))
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// defaultShareTTL 未指定有效期时分享链接的默认有效期
+const defaultShareTTL = 7 * 24 * time.Hour
+
+// shareLinkRetention 过期的分享链接保留多久后被清理，保留期内访问返回 410 而不是 404
+const shareLinkRetention = 30 * 24 * time.Hour
+
+// shareRequest 创建分享链接请求
+type shareRequest struct {
+	ExpiresIn int  `json:"expires_in"` // 有效期（秒），0表示使用默认的7天
+	Never     bool `json:"never"`      // 为true时永不过期，忽略 expires_in
+}
+
+// shareResponse 分享链接响应，附带公开访问地址和是否已过期
+type shareResponse struct {
+	*models.ShareLink
+	URL     string `json:"url"`     // 公开访问地址
+	Expired bool   `json:"expired"` // 是否已过期
+}
+
+// newShareResponse 生成分享链接响应
+func newShareResponse(link *models.ShareLink, now time.Time) shareResponse {
+	return shareResponse{ShareLink: link, URL: "/share/" + link.Token, Expired: link.Expired(now)}
+}
+
+// CreateShareLink 为待办事项创建只读的公开分享链接
+func (h *Handler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	// 请求体可以为空，此时使用默认有效期
+	var req shareRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if req.ExpiresIn < 0 {
+		sendError(w, "有效期不能为负数", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.store.GetTodoByID(id); err != nil {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		sendError(w, "生成令牌失败", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	link := &models.ShareLink{Token: token, TodoID: id, CreatedAt: now}
+	switch {
+	case req.Never:
+	case req.ExpiresIn > 0:
+		link.ExpiresAt = now.Add(time.Duration(req.ExpiresIn) * time.Second)
+	default:
+		link.ExpiresAt = now.Add(defaultShareTTL)
+	}
+
+	if err := store.SaveShareLink(h.store, link); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, newShareResponse(link, now), http.StatusCreated)
+}
+
+// GetTodoShareLinks 获取待办事项的所有分享链接（包括已过期的）
+func (h *Handler) GetTodoShareLinks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	h.sendShareLinks(w, func(link *models.ShareLink) bool { return link.TodoID == id })
+}
+
+// ListShareLinks 获取所有分享链接
+func (h *Handler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	h.sendShareLinks(w, func(*models.ShareLink) bool { return true })
+}
+
+// sendShareLinks 返回满足条件的分享链接列表
+func (h *Handler) sendShareLinks(w http.ResponseWriter, match func(*models.ShareLink) bool) {
+	links, err := store.ListShareLinks(h.store)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	responses := make([]shareResponse, 0, len(links))
+	for _, link := range links {
+		if match(link) {
+			responses = append(responses, newShareResponse(link, now))
+		}
+	}
+
+	sendJSON(w, responses, http.StatusOK)
+}
+
+// RevokeShareLink 撤销分享链接，撤销后令牌立即失效
+func (h *Handler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	if err := store.DeleteShareLink(h.store, mux.Vars(r)["token"]); err != nil {
+		sendError(w, "分享链接不存在", http.StatusNotFound)
+		return
+	}
+
+	sendJSON(w, map[string]string{"message": "撤销成功"}, http.StatusOK)
+}
+
+// SharedTodoPage 通过分享令牌公开查看待办事项（只读，无需认证）
+// 浏览器访问时返回网页，其它客户端返回JSON；响应中不包含关联链接，避免暴露其它事项的ID
+func (h *Handler) SharedTodoPage(w http.ResponseWriter, r *http.Request) {
+	link, err := store.GetShareLink(h.store, mux.Vars(r)["token"])
+	if err != nil {
+		sendError(w, "分享链接不存在", http.StatusNotFound)
+		return
+	}
+	if link.Expired(time.Now()) {
+		sendError(w, "分享链接已过期", http.StatusGone)
+		return
+	}
+
+	todo, err := h.store.GetTodoByID(link.TodoID)
+	if err != nil {
+		sendError(w, "分享的待办事项不存在", http.StatusNotFound)
+		return
+	}
+
+	response := todo.ToResponse()
+	response.Localize(h.locale(r))
+	response.Links = nil
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		sendJSON(w, response, http.StatusOK)
+		return
+	}
+
+	h.renderPage(w, "shared", response)
+}
+
+// removeShareLinks 删除指向已删除事项的分享链接
+func (h *Handler) removeShareLinks(ids ...int) {
+	links, err := store.ListShareLinks(h.store)
+	if err != nil {
+		log.Printf("清理分享链接失败: %v", err)
+		return
+	}
+
+	deleted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	for _, link := range links {
+		if !deleted[link.TodoID] {
+			continue
+		}
+		if err := store.DeleteShareLink(h.store, link.Token); err != nil {
+			log.Printf("清理分享链接 %s 失败: %v", link.Token, err)
+		}
+	}
+}
+
+// PurgeExpiredShareLinks 定时任务：删除过期超过保留期的分享链接
+func (h *Handler) PurgeExpiredShareLinks(ctx context.Context) error {
+	links, err := store.ListShareLinks(h.store)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-shareLinkRetention)
+	purged := 0
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !link.Expired(cutoff) {
+			continue
+		}
+		if err := store.DeleteShareLink(h.store, link.Token); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			return err
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		log.Printf("清理了 %d 个过期的分享链接", purged)
+	}
+	return nil
+}
+
+// newShareToken 生成随机的分享令牌（128位，URL安全的Base64编码）
+func newShareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 事件流参数
+const (
+	eventStreamMediaType    = "text/event-stream"
+	eventStreamPollInterval = time.Second      // 检查发件箱中新事件的间隔
+	eventStreamHeartbeat    = 15 * time.Second // 没有事件时发送注释行的间隔，避免代理断开空闲连接
+	eventStreamRetry        = 3000             // 建议客户端断开后重连的等待时间（毫秒）
+)
+
+// EventStream 以 Server-Sent Events 推送事件发件箱中的新事件（todo.created、todo.updated、todo.completed、todo.deleted 等）
+// 每个事件的 id 为序号，event 为事件类型，data 为事件 JSON；浏览器的 EventSource 断线重连时会带上 Last-Event-ID，
+// 从该序号之后补发期间错过的事件。首次连接可以用 ?last_event_id= 指定起点，都没有时只推送连接之后的新事件。
+// 事件从发件箱轮询读取，多个实例共用存储时任一实例上的修改都会推送
+func (h *Handler) EventStream(w http.ResponseWriter, r *http.Request) {
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+
+	var after int64
+	if lastID != "" {
+		parsed, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || parsed < 0 {
+			sendError(w, "无效的 Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		after = parsed
+	} else {
+		latest, err := h.latestEventSeq()
+		if err != nil {
+			sendError(w, "获取事件失败", http.StatusInternalServerError)
+			return
+		}
+		after = latest
+	}
+
+	// 服务器对整个响应设置了写入超时，事件流每次写入前延长
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	w.Header().Set("Content-Type", eventStreamMediaType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	poll := time.NewTicker(eventStreamPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.closing:
+			return
+		case <-poll.C:
+		}
+
+		events, err := h.events.ListEvents(after, maxEventLimit)
+		if err != nil {
+			logf(r, "读取事件失败: %v", err)
+			continue
+		}
+
+		controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				logf(r, "编码事件 %d 失败: %v", event.Seq, err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+			after = event.Seq
+		}
+		if len(events) == 0 {
+			if time.Since(lastWrite) < eventStreamHeartbeat {
+				continue
+			}
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+		lastWrite = time.Now()
+	}
+}
+
+// CloseStreams 结束所有事件流，服务器优雅关闭时调用（http.Server.RegisterOnShutdown），
+// 否则关闭时会一直等待这些长连接
+func (h *Handler) CloseStreams() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// latestEventSeq 返回发件箱中最后一个事件的序号，没有事件时为0
+// 发件箱只支持按序号向后读取，这里分批读到末尾
+func (h *Handler) latestEventSeq() (int64, error) {
+	var latest int64
+	for {
+		events, err := h.events.ListEvents(latest, maxEventLimit)
+		if err != nil {
+			return 0, err
+		}
+		if len(events) == 0 {
+			return latest, nil
+		}
+		latest = events[len(events)-1].Seq
+		if len(events) < maxEventLimit {
+			return latest, nil
+		}
+	}
+}
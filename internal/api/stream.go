@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ndjsonMediaType 按行分隔的 JSON（每行一个待办事项）
+const ndjsonMediaType = "application/x-ndjson"
+
+// streamWriteTimeout 流式响应中每一行的写入超时，代替服务器对整个响应的写入超时，数据量大时不会中途断开
+const streamWriteTimeout = 15 * time.Second
+
+// StreamTodos 以 NDJSON 格式流式返回待办事项，每行一个待办事项，每写一行立即发送
+// 排序和过滤参数与列表接口相同，不分页；客户端可以逐行处理，不必等待并缓存完整的 JSON 数组。
+// 开始发送后出错时无法再返回错误状态码，最后一行为 {"error": "..."}
+func (h *Handler) StreamTodos(w http.ResponseWriter, r *http.Request) {
+	view, err := h.parseListView(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	view.PerPage = 0
+
+	todos, err := h.listTodos(view)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	todos, total := view.apply(todos, h.agingPolicy())
+	responses := h.todoResponses(r, todos)
+
+	setPaginationHeaders(w, r.URL, view, total)
+	w.Header().Set("Content-Type", ndjsonMediaType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	for _, response := range responses {
+		controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := encoder.Encode(response); err != nil {
+			logf(r, "流式返回待办事项失败: %v", err)
+			encoder.Encode(map[string]string{"error": "编码失败"})
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			// 客户端已断开或连接不支持逐行发送
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// errSubtaskNotFound 修改子任务时在事务中检查出的错误
+var errSubtaskNotFound = errors.New("子任务不存在")
+
+// subtaskRequest 创建子任务请求
+type subtaskRequest struct {
+	Title     string `json:"title"`     // 标题
+	Completed bool   `json:"completed"` // 是否已完成，默认未完成
+}
+
+// subtaskPatch 修改子任务请求，未提供的字段保持不变
+type subtaskPatch struct {
+	Title     *string `json:"title"`
+	Completed *bool   `json:"completed"`
+}
+
+// subtaskList 子任务列表及完成情况
+type subtaskList struct {
+	Subtasks []models.Subtask       `json:"subtasks"`
+	Progress models.SubtaskProgress `json:"progress"`
+}
+
+// GetSubtasks 获取事项的子任务和完成情况
+func (h *Handler) GetSubtasks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := h.storeFor(r).GetTodoByID(id)
+	if err != nil {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+
+	subtasks := todo.Subtasks
+	if subtasks == nil {
+		subtasks = []models.Subtask{}
+	}
+	sendJSON(w, subtaskList{Subtasks: subtasks, Progress: todo.SubtaskProgress()}, http.StatusOK)
+}
+
+// CreateSubtask 为事项添加子任务，响应为更新后的事项
+func (h *Handler) CreateSubtask(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	var req subtaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	title, err := models.NormalizeSubtaskTitle(req.Title)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.modifySubtasks(w, r, id, http.StatusCreated, func(todo *models.Todo) error {
+		now := time.Now()
+		subtask, err := todo.AddSubtask(title, now)
+		if err != nil {
+			return err
+		}
+		subtask.SetCompleted(req.Completed, now)
+		return nil
+	})
+}
+
+// UpdateSubtask 修改子任务的标题或完成状态，响应为更新后的事项
+func (h *Handler) UpdateSubtask(w http.ResponseWriter, r *http.Request) {
+	id, subtaskID, ok := parseSubtaskIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var patch subtaskPatch
+	if err := decodeJSON(r, &patch); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if patch.Title == nil && patch.Completed == nil {
+		sendError(w, "没有要修改的字段", http.StatusBadRequest)
+		return
+	}
+	var title string
+	if patch.Title != nil {
+		normalized, err := models.NormalizeSubtaskTitle(*patch.Title)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		title = normalized
+	}
+
+	h.modifySubtasks(w, r, id, http.StatusOK, func(todo *models.Todo) error {
+		subtask := todo.Subtask(subtaskID)
+		if subtask == nil {
+			return errSubtaskNotFound
+		}
+		if patch.Title != nil {
+			subtask.Title = title
+		}
+		if patch.Completed != nil {
+			subtask.SetCompleted(*patch.Completed, time.Now())
+		}
+		return nil
+	})
+}
+
+// DeleteSubtask 删除子任务，响应为更新后的事项
+func (h *Handler) DeleteSubtask(w http.ResponseWriter, r *http.Request) {
+	id, subtaskID, ok := parseSubtaskIDs(w, r)
+	if !ok {
+		return
+	}
+
+	h.modifySubtasks(w, r, id, http.StatusOK, func(todo *models.Todo) error {
+		if !todo.RemoveSubtask(subtaskID) {
+			return errSubtaskNotFound
+		}
+		return nil
+	})
+}
+
+// parseSubtaskIDs 解析路径中的事项ID和子任务ID，无效时发送错误响应
+func parseSubtaskIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	subtaskID, err := strconv.Atoi(vars["subtask"])
+	if err != nil {
+		sendError(w, "无效的子任务ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return id, subtaskID, true
+}
+
+// modifySubtasks 在事务中读取事项、修改子任务并保存，发布 todo.updated 事件，响应为更新后的事项
+// modify 返回的 errSubtaskNotFound 对应404，其它错误作为请求错误返回400
+func (h *Handler) modifySubtasks(w http.ResponseWriter, r *http.Request, id int, status int, modify func(todo *models.Todo) error) {
+	var current, saved *models.Todo
+	var invalid error
+	err := h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		invalid = nil
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+		current = todo
+		if err := checkPreconditions(r, todo); err != nil {
+			return err
+		}
+		if err := modify(todo); err != nil {
+			if !errors.Is(err, errSubtaskNotFound) {
+				invalid = err
+			}
+			return err
+		}
+		saved, err = tx.SaveTodo(todo)
+		return err
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	case errors.Is(err, errSubtaskNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errPreconditionFailed):
+		sendPreconditionFailed(w, current)
+		return
+	case invalid != nil:
+		sendError(w, invalid.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+	h.publish(models.EventTodoUpdated, saved.ID, saved)
+
+	setVersionETag(w, saved)
+	sendJSON(w, h.todoResponse(r, saved), status)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// tagRenameRequest 重命名标签的请求
+type tagRenameRequest struct {
+	Name string `json:"name"` // 新的标签名，已存在时合并到该标签
+}
+
+// tagMergeRequest 合并标签的请求
+type tagMergeRequest struct {
+	Sources []string `json:"sources"` // 要合并掉的标签
+	Target  string   `json:"target"`  // 合并到的标签，可以是新标签
+}
+
+// ListTags 列出所有用到的标签及带有该标签的事项数，按事项数降序、标签名升序排列
+// active 为其中未完成且未归档的事项数
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	todos, err := h.storeFor(r).ListTodos(store.ListOptions{})
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	counts := make(map[string]*models.TagCount)
+	for _, todo := range todos {
+		for _, tag := range todo.Tags {
+			count, ok := counts[tag]
+			if !ok {
+				count = &models.TagCount{Name: tag}
+				counts[tag] = count
+			}
+			count.Count++
+			if !todo.Completed && !todo.Archived {
+				count.Active++
+			}
+		}
+	}
+
+	tags := make([]models.TagCount, 0, len(counts))
+	for _, count := range counts {
+		tags = append(tags, *count)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	sendJSON(w, tags, http.StatusOK)
+}
+
+// RenameTag 重命名标签，新名称已被其它事项使用时两个标签合并
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	source, err := models.NormalizeTag(mux.Vars(r)["tag"])
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req tagRenameRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为 {\"name\": \"...\"}", err)
+		return
+	}
+	target, err := models.NormalizeTag(req.Name)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.replaceTags(w, r, []string{source}, target)
+}
+
+// MergeTags 把多个标签合并为一个：带有任一来源标签的事项改为带有目标标签
+func (h *Handler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	var req tagMergeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据，请求体应为 {\"sources\": [...], \"target\": \"...\"}", err)
+		return
+	}
+	sources, err := models.NormalizeTags(req.Sources)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(sources) == 0 {
+		sendError(w, "需要提供 sources", http.StatusBadRequest)
+		return
+	}
+	target, err := models.NormalizeTag(req.Target)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.replaceTags(w, r, sources, target)
+}
+
+// replaceTags 在事务中把所有事项的来源标签替换为目标标签，保存有变化的事项并发布 todo.updated 事件
+// 响应为修改的事项数；没有事项带有来源标签时返回404
+func (h *Handler) replaceTags(w http.ResponseWriter, r *http.Request, sources []string, target string) {
+	var saved []*models.Todo
+	err := h.storeFor(r).Transaction(func(tx store.TodoStore) error {
+		saved = nil
+		todos, err := tx.ListTodos(store.ListOptions{})
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, todo := range todos {
+			if !todo.HasAnyTag(sources) {
+				continue
+			}
+			found = true
+			if !todo.ReplaceTags(sources, target) {
+				continue
+			}
+			updated, err := tx.SaveTodo(todo)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, updated)
+		}
+		if !found {
+			return store.ErrTodoNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, store.ErrTodoNotFound) {
+		sendError(w, "没有事项使用该标签", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "修改标签失败", http.StatusInternalServerError)
+		return
+	}
+
+	for _, todo := range saved {
+		h.publish(models.EventTodoUpdated, todo.ID, todo)
+	}
+	sendJSON(w, map[string]interface{}{
+		"tag":     target,
+		"updated": len(saved),
+	}, http.StatusOK)
+}
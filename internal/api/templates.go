@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/notify"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// templateResponse 通知模板响应：通知类型的说明、当前使用的模板，以及是否为自定义模板
+type templateResponse struct {
+	notify.TemplateKind
+	Subject   string     `json:"subject,omitempty"`    // 当前使用的主题模板
+	Body      string     `json:"body"`                 // 当前使用的正文模板
+	Custom    bool       `json:"custom"`               // 是否为自定义模板，false 表示使用默认模板
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // 自定义模板的保存时间
+}
+
+// templateRequest 保存或预览通知模板的请求
+type templateRequest struct {
+	Subject string `json:"subject"` // 主题模板，没有主题的通知类型不能提供
+	Body    string `json:"body"`    // 正文模板
+}
+
+// templatePreview 通知模板的预览结果
+type templatePreview struct {
+	Subject string `json:"subject,omitempty"` // 渲染后的主题
+	Body    string `json:"body"`              // 渲染后的正文（不含自动附加的退订说明）
+}
+
+// notificationTemplate 返回通知类型当前使用的模板，没有自定义模板或自定义模板无效时使用默认模板
+func (h *Handler) notificationTemplate(kind notify.TemplateKind) *notify.Template {
+	custom, err := store.GetNotificationTemplate(h.store, kind.Name)
+	if err != nil {
+		if !errors.Is(err, store.ErrMetaNotFound) {
+			log.Printf("⚠️ 读取通知模板 %s 失败，使用默认模板: %v", kind.Name, err)
+		}
+		return notify.DefaultTemplate(kind)
+	}
+	t, err := notify.ParseTemplate(kind, custom.Subject, custom.Body)
+	if err != nil {
+		log.Printf("⚠️ 通知模板 %s 无效，使用默认模板: %v", kind.Name, err)
+		return notify.DefaultTemplate(kind)
+	}
+	return t
+}
+
+// newTemplateResponse 生成通知模板响应，custom 为nil时表示使用默认模板
+func newTemplateResponse(kind notify.TemplateKind, custom *models.NotificationTemplate) templateResponse {
+	response := templateResponse{TemplateKind: kind, Subject: kind.DefaultSubject, Body: kind.DefaultBody}
+	if custom != nil {
+		response.Subject, response.Body, response.Custom = custom.Subject, custom.Body, true
+		response.UpdatedAt = &custom.UpdatedAt
+	}
+	return response
+}
+
+// templateKindFromRequest 根据路径中的通知类型查找，不存在时发送404
+func templateKindFromRequest(w http.ResponseWriter, r *http.Request) (notify.TemplateKind, bool) {
+	kind, ok := notify.LookupTemplateKind(mux.Vars(r)["name"])
+	if !ok {
+		sendError(w, "通知类型不存在", http.StatusNotFound)
+	}
+	return kind, ok
+}
+
+// ListTemplates 列出所有可以自定义模板的通知类型及当前使用的模板
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	customs, err := store.ListNotificationTemplates(h.store)
+	if err != nil {
+		sendError(w, "获取通知模板失败", http.StatusInternalServerError)
+		return
+	}
+	byName := make(map[string]*models.NotificationTemplate, len(customs))
+	for _, custom := range customs {
+		byName[custom.Name] = custom
+	}
+
+	kinds := notify.TemplateKinds()
+	responses := make([]templateResponse, len(kinds))
+	for i, kind := range kinds {
+		responses[i] = newTemplateResponse(kind, byName[kind.Name])
+	}
+	sendJSON(w, responses, http.StatusOK)
+}
+
+// GetTemplate 获取通知类型当前使用的模板
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	kind, ok := templateKindFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	custom, err := store.GetNotificationTemplate(h.store, kind.Name)
+	if err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "获取通知模板失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, newTemplateResponse(kind, custom), http.StatusOK)
+}
+
+// UpdateTemplate 保存自定义通知模板
+// 保存前编译模板并用示例数据试渲染，语法错误或引用了不存在的字段时返回 400
+func (h *Handler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	kind, ok := templateKindFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req templateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if _, err := notify.ParseTemplate(kind, req.Subject, req.Body); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	custom := &models.NotificationTemplate{Name: kind.Name, Subject: req.Subject, Body: req.Body, UpdatedAt: time.Now()}
+	if err := store.SaveNotificationTemplate(h.store, custom); err != nil {
+		sendError(w, "保存通知模板失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, newTemplateResponse(kind, custom), http.StatusOK)
+}
+
+// DeleteTemplate 删除自定义通知模板，恢复使用默认模板
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	kind, ok := templateKindFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := store.DeleteNotificationTemplate(h.store, kind.Name)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "没有自定义模板", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "删除通知模板失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, newTemplateResponse(kind, nil), http.StatusOK)
+}
+
+// PreviewTemplate 预览通知模板的渲染结果
+// 请求体中提供模板时预览该模板（不保存），否则预览当前使用的模板；
+// ?todo_id= 指定使用的待办事项，未指定时使用示例数据
+func (h *Handler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	kind, ok := templateKindFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req templateRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			sendDecodeError(w, "无效数据", err)
+			return
+		}
+	}
+	t := h.notificationTemplate(kind)
+	if req.Subject != "" || req.Body != "" {
+		parsed, err := notify.ParseTemplate(kind, req.Subject, req.Body)
+		if err != nil {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t = parsed
+	}
+
+	data := notify.SampleTemplateData(kind, time.Now())
+	if value := r.URL.Query().Get("todo_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			sendError(w, "无效ID", http.StatusBadRequest)
+			return
+		}
+		todo, err := h.store.GetTodoByID(id)
+		if err != nil {
+			sendError(w, "待办事项不存在", http.StatusNotFound)
+			return
+		}
+		data.Todo = todo
+	}
+
+	subject, body, err := t.Render(data)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	sendJSON(w, templatePreview{Subject: subject, Body: body}, http.StatusOK)
+}
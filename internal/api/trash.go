@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// maxTrashExtendDays 管理员一次最多延长的保留天数
+const maxTrashExtendDays = 3650
+
+// trashResponse 回收站中的待办事项，附带距离永久删除的倒计时
+type trashResponse struct {
+	*models.TrashedTodo
+	PurgeInSeconds int64 `json:"purge_in_seconds"` // 距离永久删除的秒数，已到期等待清理时为0
+	DaysLeft       int   `json:"days_left"`        // 距离永久删除的天数（向上取整）
+}
+
+// newTrashResponse 生成回收站响应
+func newTrashResponse(trashed *models.TrashedTodo, now time.Time) trashResponse {
+	remaining := trashed.Remaining(now)
+	day := 24 * time.Hour
+	return trashResponse{
+		TrashedTodo:    trashed,
+		PurgeInSeconds: int64(remaining / time.Second),
+		DaysLeft:       int((remaining + day - 1) / day),
+	}
+}
+
+// trashRetention 事项在回收站中的保留时间，未配置时为30天
+func (h *Handler) trashRetention() time.Duration {
+	if h.config.Trash.RetentionDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(h.config.Trash.RetentionDays) * 24 * time.Hour
+}
+
+// TrashInterval 定期清理回收站的间隔，未配置时为一小时
+func (h *Handler) TrashInterval() time.Duration {
+	if h.config.Trash.Interval <= 0 {
+		return time.Hour
+	}
+	return time.Duration(h.config.Trash.Interval) * time.Minute
+}
+
+// moveToTrash 以请求者身份把待办事项移入回收站
+func (h *Handler) moveToTrash(r *http.Request, ids ...int) error {
+	return store.MoveToTrash(h.storeFor(r), ids, time.Now(), h.trashRetention())
+}
+
+// trashEnabled 检查是否启用了回收站，未启用时发送404
+func (h *Handler) trashEnabled(w http.ResponseWriter) bool {
+	if !h.config.Trash.Enabled {
+		sendError(w, "未启用回收站", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// PurgeExpiredTrash 定时任务：永久删除回收站中超过保留期的事项
+func (h *Handler) PurgeExpiredTrash(ctx context.Context) error {
+	purged, err := store.PurgeTrash(h.store, time.Now(), false)
+	if len(purged) > 0 {
+		log.Printf("🗑️ 永久删除了回收站中 %d 个超过保留期的待办事项", len(purged))
+	}
+	return err
+}
+
+// ListTrash 列出回收站中的待办事项，最先被永久删除的在前
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	if !h.trashEnabled(w) {
+		return
+	}
+
+	trashed, err := store.ListTrashedTodos(h.store)
+	if err != nil {
+		sendError(w, "获取回收站失败", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	responses := make([]trashResponse, len(trashed))
+	for i, item := range trashed {
+		responses[i] = newTrashResponse(item, now)
+	}
+	sendJSON(w, responses, http.StatusOK)
+}
+
+// RestoreTrashedTodo 把回收站中的事项按原ID恢复到存储
+// 存储中已有该ID的事项时返回 409；删除时被清理的关联、分享链接和关注者不会恢复
+func (h *Handler) RestoreTrashedTodo(w http.ResponseWriter, r *http.Request) {
+	if !h.trashEnabled(w) {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store.RestoreFromTrash(h.storeFor(r), id)
+	switch {
+	case errors.Is(err, store.ErrMetaNotFound):
+		sendError(w, "回收站中没有该待办事项", http.StatusNotFound)
+		return
+	case errors.Is(err, store.ErrDuplicateID):
+		sendError(w, "存储中已有该ID的待办事项", http.StatusConflict)
+		return
+	case errors.Is(err, store.ErrLoadUnsupported):
+		sendError(w, "当前存储不支持恢复删除的事项", http.StatusNotImplemented)
+		return
+	case err != nil:
+		logf(r, "❌ 从回收站恢复待办事项 %d 失败: %v", id, err)
+		sendError(w, "恢复失败", http.StatusInternalServerError)
+		return
+	}
+
+	restored, err := h.store.GetTodoByID(id)
+	if err != nil {
+		restored = todo
+	}
+	h.publish(models.EventTodoCreated, restored.ID, restored)
+	logf(r, "♻️ 已从回收站恢复待办事项 %d", id)
+	sendJSON(w, h.todoResponse(r, restored), http.StatusCreated)
+}
+
+// ExtendTrashedTodo 管理接口：延长回收站中事项的保留期
+// 请求体为 {"days": 7}，从当前的到期时间（已到期时从现在）开始延长
+func (h *Handler) ExtendTrashedTodo(w http.ResponseWriter, r *http.Request) {
+	if !h.trashEnabled(w) {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Days int `json:"days"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if req.Days < 1 || req.Days > maxTrashExtendDays {
+		sendError(w, "days 必须在1-"+strconv.Itoa(maxTrashExtendDays)+"之间", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var extended *models.TrashedTodo
+	err = h.store.Transaction(func(tx store.TodoStore) error {
+		trashed, err := store.GetTrashedTodo(tx, id)
+		if err != nil {
+			return err
+		}
+		if trashed.Expired(now) {
+			trashed.PurgeAt = now
+		}
+		trashed.PurgeAt = trashed.PurgeAt.Add(time.Duration(req.Days) * 24 * time.Hour)
+		extended = trashed
+		return store.SaveTrashedTodo(tx, trashed)
+	})
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "回收站中没有该待办事项", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "延长保留期失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSON(w, newTrashResponse(extended, now), http.StatusOK)
+}
+
+// PurgeTrashedTodo 管理接口：不等到期，立即永久删除回收站中的事项
+func (h *Handler) PurgeTrashedTodo(w http.ResponseWriter, r *http.Request) {
+	if !h.trashEnabled(w) {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	err = store.DeleteTrashedTodo(h.store, id)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "回收站中没有该待办事项", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "永久删除失败", http.StatusInternalServerError)
+		return
+	}
+	logf(r, "🗑️ 已永久删除回收站中的待办事项 %d", id)
+	sendJSON(w, map[string]string{"message": "已永久删除"}, http.StatusOK)
+}
+
+// PurgeTrash 管理接口：立即永久删除回收站中超过保留期的事项，?all=true 时清空回收站
+func (h *Handler) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	if !h.trashEnabled(w) {
+		return
+	}
+
+	all := r.URL.Query().Get("all") == "true"
+	purged, err := store.PurgeTrash(h.store, time.Now(), all)
+	if err != nil {
+		logf(r, "❌ 清理回收站失败: %v", err)
+		sendError(w, "清理回收站失败", http.StatusInternalServerError)
+		return
+	}
+	if purged == nil {
+		purged = []int{}
+	}
+	if len(purged) > 0 {
+		logf(r, "🗑️ 已永久删除回收站中 %d 个待办事项", len(purged))
+	}
+	sendJSON(w, map[string]interface{}{"purged": len(purged), "ids": purged}, http.StatusOK)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// listEnvelope v1 列表响应
+type listEnvelope struct {
+	Data  interface{}            `json:"data"`             // 当前页的数据
+	Meta  listMeta               `json:"meta"`             // 分页信息
+	Links map[string]models.Link `json:"_links,omitempty"` // 超媒体链接（当前页、首尾页和前后页），仅在启用时返回
+}
+
+// listMeta v1 列表响应的分页信息
+// 不方便读取 Link 响应头的客户端可以直接从响应体中取得总数和前后页的地址
+type listMeta struct {
+	Total      int     `json:"total"`       // 过滤后的总数
+	Page       int     `json:"page"`        // 当前页码
+	PerPage    int     `json:"per_page"`    // 每页数量，0表示不分页
+	TotalPages int     `json:"total_pages"` // 总页数，不分页时为1
+	Next       *string `json:"next"`        // 下一页的地址（保留其它查询参数），没有下一页时为null
+	Prev       *string `json:"prev"`        // 上一页的地址，没有上一页时为null
+}
+
+// ListTodosV1 获取待办事项列表（v1）
+// 查询参数与 GET /api/todos 相同，响应中额外包含分页信息
+func (h *Handler) ListTodosV1(w http.ResponseWriter, r *http.Request) {
+	view, err := h.parseListView(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := h.listTodos(view)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+	todos, total := view.apply(todos, h.agingPolicy())
+
+	setPaginationHeaders(w, r.URL, view, total)
+	sendJSONWithETag(w, r, h.newListEnvelope(r, h.todoResponses(r, todos), view, total))
+}
+
+// newListEnvelope 生成 v1 列表响应，按需附加列表的超媒体链接
+func (h *Handler) newListEnvelope(r *http.Request, data interface{}, view listView, total int) listEnvelope {
+	return listEnvelope{
+		Data:  data,
+		Meta:  newListMeta(r.URL, view, total),
+		Links: h.listLinks(r, view, total),
+	}
+}
+
+// newListMeta 根据视图设置和总数生成分页信息
+func newListMeta(u *url.URL, view listView, total int) listMeta {
+	meta := listMeta{Total: total, Page: view.Page, PerPage: view.PerPage, TotalPages: 1}
+	if view.PerPage <= 0 {
+		return meta
+	}
+
+	meta.TotalPages = (total + view.PerPage - 1) / view.PerPage
+	if meta.TotalPages == 0 {
+		meta.TotalPages = 1
+	}
+	if view.Page < meta.TotalPages {
+		meta.Next = pageURL(u, view.Page+1)
+	}
+	if view.Page > 1 {
+		// 页码超出范围时上一页指向最后一页
+		prev := view.Page - 1
+		if prev > meta.TotalPages {
+			prev = meta.TotalPages
+		}
+		meta.Prev = pageURL(u, prev)
+	}
+	return meta
+}
+
+// setPaginationHeaders 设置分页响应头
+// X-Total-Count 为过滤后（分页前）的总数；分页时 Link 按 RFC 8288 给出 first、last 以及存在时的 prev、next 页地址
+func setPaginationHeaders(w http.ResponseWriter, u *url.URL, view listView, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if view.PerPage <= 0 {
+		return
+	}
+
+	meta := newListMeta(u, view, total)
+	links := []string{
+		fmt.Sprintf(`<%s>; rel="first"`, *pageURL(u, 1)),
+		fmt.Sprintf(`<%s>; rel="last"`, *pageURL(u, meta.TotalPages)),
+	}
+	if meta.Prev != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, *meta.Prev))
+	}
+	if meta.Next != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, *meta.Next))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// pageURL 返回把页码替换为page后的请求地址（路径加查询参数）
+func pageURL(u *url.URL, page int) *string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	link := u.Path + "?" + query.Encode()
+	return &link
+}
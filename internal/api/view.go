@@ -0,0 +1,377 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// listView 列表视图设置
+// 由配置中的默认值和请求的查询参数共同决定
+type listView struct {
+	Sort          []models.SortKey // 排序字段，前一个字段相同时比较下一个字段
+	Page          int              // 页码，从1开始
+	PerPage       int              // 每页数量，0表示不分页
+	ShowCompleted bool             // 是否包含已完成的事项
+	ShowArchived  bool             // 是否包含已归档的事项
+	Near          *near            // 按地点距离过滤，为nil时不过滤
+	Query         string           // 搜索词，只返回匹配的事项，为空时不过滤
+
+	Filter store.TodoFilter // 分类、完成状态、优先级、截止日期等过滤条件，交给存储完成
+}
+
+// near 地点距离过滤条件
+type near struct {
+	Lat      float64 // 中心点纬度
+	Lng      float64 // 中心点经度
+	RadiusKm float64 // 半径（公里）
+}
+
+// parseListView 解析列表视图设置
+// 查询参数 ?sort=&order=&page=&per_page=&show_completed= 优先，未提供时使用配置中的默认值；
+// 已归档的事项默认不返回，?include_archived=true 时一并返回；
+// ?sort=priority,-due_date 按多个字段排序，- 前缀表示降序，此时不能再指定 order；
+// ?near=lat,lng,radius 只保留距离中心点不超过radius公里的事项（没有坐标的事项不会返回）；
+// ?q= 只保留匹配搜索词的事项，启用全文索引时可以用 ?sort=relevance 按相关度排序；
+// 过滤参数见 parseFilter
+func (h *Handler) parseListView(r *http.Request) (listView, error) {
+	defaults := h.config.View
+	query := r.URL.Query()
+
+	view := listView{
+		Page:          1,
+		PerPage:       defaults.PageSize,
+		ShowCompleted: defaults.ShowCompleted,
+	}
+
+	// 配置中的排序字段无效时回退到按创建时间排序，按相关度排序需要搜索词，也不能作为默认值
+	key := models.SortKey{Field: defaults.SortField, Desc: defaults.SortOrder != "asc"}
+	if !models.IsValidSortField(key.Field) || key.Field == models.SortByRelevance {
+		key.Field = models.SortByCreatedAt
+	}
+
+	order := query.Get("order")
+	switch order {
+	case "":
+	case "asc":
+		key.Desc = false
+	case "desc":
+		key.Desc = true
+	default:
+		return view, errors.New("排序方向必须是 asc 或 desc")
+	}
+
+	// ?sort=priority 只指定一个字段时方向由 order 决定；?sort=priority,-due_date 多字段排序时用前缀指定方向
+	sortParam := query.Get("sort")
+	switch {
+	case sortParam == "":
+		view.Sort = []models.SortKey{key}
+	case !strings.ContainsAny(sortParam, ",+-"):
+		if !models.IsValidSortField(sortParam) {
+			return view, errors.New("无效的排序字段")
+		}
+		key.Field = sortParam
+		view.Sort = []models.SortKey{key}
+	default:
+		if order != "" {
+			return view, errors.New("用 +、- 前缀指定排序方向时不能同时指定 order")
+		}
+		keys, err := models.ParseSortKeys(sortParam)
+		if err != nil {
+			return view, err
+		}
+		view.Sort = keys
+	}
+
+	if err := view.parsePage(query); err != nil {
+		return view, err
+	}
+
+	if value := query.Get("show_completed"); value != "" {
+		showCompleted, err := strconv.ParseBool(value)
+		if err != nil {
+			return view, errors.New("show_completed 必须是 true 或 false")
+		}
+		view.ShowCompleted = showCompleted
+	}
+
+	if err := view.parseArchived(query); err != nil {
+		return view, err
+	}
+
+	if err := view.parseFilter(query); err != nil {
+		return view, err
+	}
+
+	if value := query.Get("near"); value != "" {
+		filter, err := parseNear(value)
+		if err != nil {
+			return view, err
+		}
+		view.Near = filter
+	}
+
+	view.Query = strings.TrimSpace(query.Get("q"))
+	if view.sortsBy(models.SortByRelevance) {
+		if view.Query == "" {
+			return view, errors.New("按相关度排序需要提供搜索词 q")
+		}
+		if store.FindSearcher(h.store) == nil {
+			return view, errors.New("未启用全文索引，不能按相关度排序")
+		}
+	}
+
+	return view, nil
+}
+
+// sortsBy 是否按指定字段排序
+func (v listView) sortsBy(field string) bool {
+	for _, key := range v.Sort {
+		if key.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePage 解析分页参数 ?page=&per_page=，未提供的参数保持原值
+func (v *listView) parsePage(query url.Values) error {
+	if value := query.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return errors.New("无效的页码")
+		}
+		v.Page = page
+	}
+
+	if value := query.Get("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 0 {
+			return errors.New("无效的每页数量")
+		}
+		v.PerPage = perPage
+	}
+	return nil
+}
+
+// parseArchived 解析 ?include_archived=，未提供时保持原值
+func (v *listView) parseArchived(query url.Values) error {
+	if value := query.Get("include_archived"); value != "" {
+		showArchived, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("include_archived 必须是 true 或 false")
+		}
+		v.ShowArchived = showArchived
+	}
+	return nil
+}
+
+// parseFilter 解析过滤参数，过滤由存储完成（SQL 存储转换为查询条件）
+// ?category= 精确匹配分类；?completed=true|false|all，指定时忽略 show_completed；?priority=4、?priority=4,5 或 ?priority=urgent,critical；
+// ?due_before=、?due_after= 为 RFC 3339 时间或 2006-01-02 格式的日期（服务器时区的零点），范围为 [due_after, due_before)；
+// ?overdue=true 只返回未完成且已过截止日期的事项，false 排除这些事项；
+// ?tag=work 或 ?tag=work,urgent（也可重复 tag 参数）只返回带有全部这些标签的事项，不区分大小写
+func (v *listView) parseFilter(query url.Values) error {
+	v.Filter.Category = strings.TrimSpace(query.Get("category"))
+
+	if query.Has("completed") {
+		completed, err := parseCompleted(query.Get("completed"))
+		if err != nil {
+			return err
+		}
+		v.Filter.Completed = completed
+		v.ShowCompleted = true
+	}
+
+	if value := query.Get("priority"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			priority, err := models.ParsePriority(part)
+			if err != nil || priority == models.PriorityNone {
+				return errors.New("priority 必须是1到5之间的整数或 low、normal、high、urgent、critical，多个值用逗号分隔")
+			}
+			v.Filter.Priorities = append(v.Filter.Priorities, priority)
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"due_before": &v.Filter.DueBefore, "due_after": &v.Filter.DueAfter} {
+		if value := query.Get(name); value != "" {
+			parsed, err := parseDateParam(value)
+			if err != nil {
+				return fmt.Errorf("无效的%s参数，应为 RFC 3339 格式的时间或 2006-01-02 格式的日期", name)
+			}
+			*target = &parsed
+		}
+	}
+
+	if value := query.Get("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("overdue 必须是 true 或 false")
+		}
+		v.Filter.Overdue = &overdue
+	}
+
+	for _, value := range query["tag"] {
+		for _, part := range strings.Split(value, ",") {
+			tag, err := models.NormalizeTag(part)
+			if err != nil {
+				return fmt.Errorf("无效的tag参数：%w", err)
+			}
+			if !slices.Contains(v.Filter.Tags, tag) {
+				v.Filter.Tags = append(v.Filter.Tags, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// parseDateParam 解析 RFC 3339 格式的时间，或 2006-01-02 格式的日期（服务器时区当天零点）
+func parseDateParam(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// parseNear 解析 lat,lng,radius 格式的地点距离过滤条件
+func parseNear(value string) (*near, error) {
+	invalid := errors.New("near 格式必须是 lat,lng,radius（半径单位为公里）")
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, invalid
+	}
+	numbers := make([]float64, len(parts))
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, invalid
+		}
+		numbers[i] = number
+	}
+
+	filter := &near{Lat: numbers[0], Lng: numbers[1], RadiusKm: numbers[2]}
+	location := models.Location{Lat: &filter.Lat, Lng: &filter.Lng}
+	if err := location.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.RadiusKm < 0 {
+		return nil, errors.New("半径不能为负数")
+	}
+	return filter, nil
+}
+
+// listOptions 返回交给存储排序和过滤的选项
+// 有效优先级依赖老化策略，相关度依赖搜索结果，存储无法排序，此时按默认顺序取出，分别由 apply 和 listTodos 排序；
+// 不包含已完成或已归档的事项时同样交给存储过滤
+func (v listView) listOptions() store.ListOptions {
+	filter := v.Filter
+	if !v.ShowCompleted && filter.Completed == nil {
+		completed := false
+		filter.Completed = &completed
+	}
+	if !v.ShowArchived && filter.Archived == nil {
+		archived := false
+		filter.Archived = &archived
+	}
+	if v.sortsBy(models.SortByEffectivePriority) || v.sortsBy(models.SortByRelevance) {
+		return store.ListOptions{Filter: filter}
+	}
+	return store.ListOptions{Sort: v.Sort, Filter: filter}
+}
+
+// listTodos 按视图设置的排序从存储取出待办事项，有搜索词时只保留匹配的事项
+// 启用全文索引时按索引匹配（分词、模糊匹配），按相关度排序时按得分从高到低排列（?order=asc 时反过来）；
+// 未启用时由存储的 SearchTodos 做子串匹配。返回的结果还需要经过 apply 过滤和分页
+func (h *Handler) listTodos(v listView) ([]*models.Todo, error) {
+	todos, err := h.store.ListTodos(v.listOptions())
+	if err != nil || v.Query == "" {
+		return todos, err
+	}
+
+	var hits []store.SearchHit
+	if searcher := store.FindSearcher(h.store); searcher != nil {
+		hits, err = searcher.Search(v.Query)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		matched, err := h.store.SearchTodos(v.Query, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		hits = make([]store.SearchHit, len(matched))
+		for i, todo := range matched {
+			hits[i] = store.SearchHit{ID: todo.ID}
+		}
+	}
+
+	rank := make(map[int]int, len(hits))
+	for i, hit := range hits {
+		rank[hit.ID] = i
+	}
+	results := make([]*models.Todo, 0, len(hits))
+	for _, todo := range todos {
+		if _, matched := rank[todo.ID]; matched {
+			results = append(results, todo)
+		}
+	}
+
+	if v.sortsBy(models.SortByRelevance) {
+		sort.SliceStable(results, func(i, j int) bool {
+			if v.Sort[0].Desc {
+				return rank[results[i].ID] < rank[results[j].ID]
+			}
+			return rank[results[i].ID] > rank[results[j].ID]
+		})
+	}
+	return results, nil
+}
+
+// apply 按视图设置对待办事项进行过滤、排序和分页
+// todos 应为按 listOptions 从存储取出的结果，已经排好序；
+// 只有排序字段中包含有效优先级时才在这里按全部排序字段排序，有效优先级使用policy计算，policy为nil时等同于按优先级排序。
+// 返回当前页的事项，以及过滤后（分页前）的总数
+func (v listView) apply(todos []*models.Todo, policy *models.AgingPolicy) ([]*models.Todo, int) {
+	results := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !v.ShowCompleted && todo.Completed {
+			continue
+		}
+		if !v.ShowArchived && todo.Archived {
+			continue
+		}
+		if v.Near != nil && todo.Location.DistanceKm(v.Near.Lat, v.Near.Lng) > v.Near.RadiusKm {
+			continue
+		}
+		results = append(results, todo)
+	}
+
+	if v.sortsBy(models.SortByEffectivePriority) {
+		models.SortTodosBy(results, v.Sort, policy)
+	}
+
+	total := len(results)
+	if v.PerPage <= 0 {
+		return results, total
+	}
+
+	start := (v.Page - 1) * v.PerPage
+	if start >= total {
+		return []*models.Todo{}, total
+	}
+	end := start + v.PerPage
+	if end > total {
+		end = total
+	}
+	return results[start:end], total
+}
@@ -0,0 +1,410 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/notify"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// 添加关注者时的错误
+var (
+	errWatcherExists   = errors.New("该邮箱已关注此待办事项")
+	errTooManyWatchers = errors.New("关注者数量已达上限")
+)
+
+// watcherRequest 添加关注者请求
+type watcherRequest struct {
+	Email string `json:"email"` // 邮箱地址
+}
+
+// watcherResponse 关注者响应，附带退订地址
+type watcherResponse struct {
+	*models.Watcher
+	UnsubscribeURL string `json:"unsubscribe_url"` // 退订地址，与邮件中的链接相同
+}
+
+// unsubscribePage 退订页面数据
+type unsubscribePage struct {
+	Token string
+	Email string
+	Title string // 关注的待办事项标题，事项已删除时为空
+	Done  bool   // 是否已退订
+}
+
+// CreateWatcher 为待办事项添加关注者，关注者会收到截止提醒和完成通知
+// 同一个邮箱只能关注同一个事项一次，每个事项的关注者数量受配置限制
+func (h *Handler) CreateWatcher(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	var req watcherRequest
+	if err := decodeJSON(r, &req); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		sendError(w, "无效的邮箱地址", http.StatusBadRequest)
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		sendError(w, "生成令牌失败", http.StatusInternalServerError)
+		return
+	}
+
+	// 检查重复和数量上限后再保存，放在同一个事务中避免并发添加超出上限
+	var watcher *models.Watcher
+	err = h.store.Transaction(func(tx store.TodoStore) error {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return err
+		}
+
+		watchers, err := store.ListWatchers(tx)
+		if err != nil {
+			return err
+		}
+		count := 0
+		for _, existing := range watchers {
+			if existing.TodoID != id {
+				continue
+			}
+			if existing.Email == email {
+				return errWatcherExists
+			}
+			count++
+		}
+		if limit := h.config.Notifications.MaxWatchers; limit > 0 && count >= limit {
+			return errTooManyWatchers
+		}
+
+		// 已完成的事项不再发送完成通知
+		watcher = &models.Watcher{
+			Token:             token,
+			TodoID:            id,
+			Email:             email,
+			CreatedAt:         time.Now(),
+			CompletedNotified: todo.Completed,
+		}
+		return store.SaveWatcher(tx, watcher)
+	})
+	switch {
+	case errors.Is(err, store.ErrTodoNotFound):
+		sendError(w, "未找到", http.StatusNotFound)
+	case errors.Is(err, errWatcherExists):
+		sendError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errTooManyWatchers):
+		sendError(w, fmt.Sprintf("%s（%d）", err, h.config.Notifications.MaxWatchers), http.StatusBadRequest)
+	case err != nil:
+		sendError(w, "保存失败", http.StatusInternalServerError)
+	default:
+		sendJSON(w, h.newWatcherResponse(watcher), http.StatusCreated)
+	}
+}
+
+// GetTodoWatchers 获取待办事项的所有关注者
+func (h *Handler) GetTodoWatchers(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	watchers, err := store.ListWatchers(h.store)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]watcherResponse, 0, len(watchers))
+	for _, watcher := range watchers {
+		if watcher.TodoID == id {
+			responses = append(responses, h.newWatcherResponse(watcher))
+		}
+	}
+
+	sendJSON(w, responses, http.StatusOK)
+}
+
+// DeleteWatcher 移除待办事项的关注者
+func (h *Handler) DeleteWatcher(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		sendError(w, "无效ID", http.StatusBadRequest)
+		return
+	}
+
+	watcher, err := store.GetWatcher(h.store, vars["token"])
+	if err != nil || watcher.TodoID != id {
+		sendError(w, "关注者不存在", http.StatusNotFound)
+		return
+	}
+	if err := store.DeleteWatcher(h.store, watcher.Token); err != nil {
+		sendError(w, "删除失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
+}
+
+// UnsubscribePage 退订确认页面（公开访问，令牌即凭证）
+// 只显示确认按钮，不直接退订，避免邮件安全扫描等自动打开链接时误退订
+func (h *Handler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	watcher, err := store.GetWatcher(h.store, mux.Vars(r)["token"])
+	if err != nil {
+		sendError(w, "退订链接无效或已退订", http.StatusNotFound)
+		return
+	}
+
+	page := unsubscribePage{Token: watcher.Token, Email: watcher.Email}
+	if todo, err := h.store.GetTodoByID(watcher.TodoID); err == nil {
+		page.Title = todo.Title
+	}
+	h.renderPage(w, "unsubscribe", page)
+}
+
+// Unsubscribe 退订（公开访问，令牌即凭证）
+// 既处理退订页面的表单，也处理邮件客户端根据 List-Unsubscribe-Post 头发送的一键退订请求
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	watcher, err := store.GetWatcher(h.store, mux.Vars(r)["token"])
+	if err != nil {
+		sendError(w, "退订链接无效或已退订", http.StatusNotFound)
+		return
+	}
+	if err := store.DeleteWatcher(h.store, watcher.Token); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "退订失败", http.StatusInternalServerError)
+		return
+	}
+	logf(r, "✉️ %s 退订了待办事项 %d 的通知", watcher.Email, watcher.TodoID)
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		sendJSON(w, map[string]string{"message": "退订成功"}, http.StatusOK)
+		return
+	}
+	h.renderPage(w, "unsubscribe", unsubscribePage{Email: watcher.Email, Done: true})
+}
+
+// NotifyWatchers 定时任务：给关注者发送截止提醒和完成通知
+// 每个关注者记录已经发送过的通知，发送失败或超出频率限制的通知在下次执行时重试；
+// 事项截止时间修改后会再次提醒，重新打开后再次完成也会再次通知
+func (h *Handler) NotifyWatchers(ctx context.Context) error {
+	watchers, err := store.ListWatchers(h.store)
+	if err != nil {
+		return err
+	}
+
+	cfg := h.config.Notifications
+	remindBefore := time.Duration(cfg.RemindBefore) * time.Hour
+	now := time.Now()
+	sent, deferred := 0, 0
+	for _, watcher := range watchers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		todo, err := h.store.GetTodoByID(watcher.TodoID)
+		if errors.Is(err, store.ErrTodoNotFound) {
+			// 事项已被删除但关注者没有清理（如删除时清理失败）
+			store.DeleteWatcher(h.store, watcher.Token)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		changed := false
+		if !todo.Completed && watcher.CompletedNotified {
+			watcher.CompletedNotified = false
+			changed = true
+		}
+
+		var msg *notify.Message
+		switch {
+		case todo.Completed && !watcher.CompletedNotified:
+			msg = h.completedMessage(watcher, todo)
+		case !todo.Completed && dueWithin(todo, now, remindBefore) && !watcher.RemindedDue.Equal(todo.DueAt()):
+			msg = h.reminderMessage(watcher, todo)
+		}
+
+		if msg != nil {
+			allowed, err := h.takeNotificationQuota(watcher.Email, now)
+			switch {
+			case err != nil:
+				return err
+			case !allowed:
+				deferred++
+			default:
+				if err := h.mailer.Send(msg); err != nil {
+					log.Printf("⚠️ 发送通知给 %s 失败: %v", watcher.Email, err)
+					break
+				}
+				if todo.Completed {
+					watcher.CompletedNotified = true
+				} else {
+					watcher.RemindedDue = todo.DueAt()
+				}
+				changed = true
+				sent++
+			}
+		}
+
+		if changed {
+			// 发送期间关注者可能已经退订，只更新仍然存在的关注者
+			err := h.store.Transaction(func(tx store.TodoStore) error {
+				if _, err := store.GetWatcher(tx, watcher.Token); err != nil {
+					return err
+				}
+				return store.SaveWatcher(tx, watcher)
+			})
+			if err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+				return err
+			}
+		}
+	}
+
+	if sent > 0 || deferred > 0 {
+		log.Printf("✉️ 发送了 %d 封关注者通知，%d 封因超出频率限制推迟发送", sent, deferred)
+	}
+	return h.purgeNotificationQuotas(now)
+}
+
+// takeNotificationQuota 占用邮箱在当前小时内的一次发送配额，配额用完时返回 false
+// 计数保存在存储中，多个实例轮流执行定时任务时依然有效
+func (h *Handler) takeNotificationQuota(email string, now time.Time) (bool, error) {
+	limit := h.config.Notifications.MaxPerHour
+	if limit <= 0 {
+		return true, nil
+	}
+
+	window := now.Truncate(time.Hour)
+	quota, err := store.GetNotificationQuota(h.store, email)
+	if errors.Is(err, store.ErrMetaNotFound) || (err == nil && !quota.WindowStart.Equal(window)) {
+		quota, err = &models.NotificationQuota{Email: email, WindowStart: window}, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if quota.Sent >= limit {
+		return false, nil
+	}
+
+	quota.Sent++
+	return true, store.SaveNotificationQuota(h.store, quota)
+}
+
+// purgeNotificationQuotas 删除已经结束的计数窗口
+func (h *Handler) purgeNotificationQuotas(now time.Time) error {
+	items, err := h.store.ListMeta(store.NotificationQuotasNamespace)
+	if err != nil {
+		return err
+	}
+
+	window := now.Truncate(time.Hour)
+	for email, data := range items {
+		var quota models.NotificationQuota
+		if err := json.Unmarshal(data, &quota); err == nil && !quota.WindowStart.Before(window) {
+			continue
+		}
+		if err := store.DeleteNotificationQuota(h.store, email); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeWatchers 删除已删除事项的关注者
+func (h *Handler) removeWatchers(ids ...int) {
+	watchers, err := store.ListWatchers(h.store)
+	if err != nil {
+		log.Printf("清理关注者失败: %v", err)
+		return
+	}
+
+	deleted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	for _, watcher := range watchers {
+		if !deleted[watcher.TodoID] {
+			continue
+		}
+		if err := store.DeleteWatcher(h.store, watcher.Token); err != nil {
+			log.Printf("清理关注者 %s 失败: %v", watcher.Token, err)
+		}
+	}
+}
+
+// reminderMessage 生成截止提醒邮件
+func (h *Handler) reminderMessage(watcher *models.Watcher, todo *models.Todo) *notify.Message {
+	return h.watcherMessage(notify.TemplateEmailReminder, watcher, todo)
+}
+
+// completedMessage 生成完成通知邮件
+func (h *Handler) completedMessage(watcher *models.Watcher, todo *models.Todo) *notify.Message {
+	return h.watcherMessage(notify.TemplateEmailCompleted, watcher, todo)
+}
+
+// watcherMessage 按通知模板生成发给关注者的邮件，正文末尾附上退订链接（不能通过模板去掉）
+// 自定义模板读取或渲染失败时使用默认模板
+func (h *Handler) watcherMessage(name string, watcher *models.Watcher, todo *models.Todo) *notify.Message {
+	url := h.unsubscribeURL(watcher)
+	_, event, _ := strings.Cut(name, ".")
+	data := &notify.TemplateData{Event: event, Todo: todo, Email: watcher.Email, UnsubscribeURL: url, Now: time.Now()}
+
+	kind, _ := notify.LookupTemplateKind(name)
+	subject, body, err := h.notificationTemplate(kind).Render(data)
+	if err != nil {
+		log.Printf("⚠️ 通知模板 %s 渲染失败，使用默认模板: %v", name, err)
+		subject, body, _ = notify.DefaultTemplate(kind).Render(data)
+	}
+
+	body += fmt.Sprintf("\n---\n您收到这封邮件是因为 %s 被添加为此待办事项的关注者。\n如不想再收到此事项的通知，请打开以下链接退订：\n%s\n", watcher.Email, url)
+	return &notify.Message{To: watcher.Email, Subject: subject, Body: body, UnsubscribeURL: url}
+}
+
+// newWatcherResponse 生成关注者响应
+func (h *Handler) newWatcherResponse(watcher *models.Watcher) watcherResponse {
+	return watcherResponse{Watcher: watcher, UnsubscribeURL: h.unsubscribeURL(watcher)}
+}
+
+// unsubscribeURL 关注者的退订地址
+func (h *Handler) unsubscribeURL(watcher *models.Watcher) string {
+	return strings.TrimRight(h.config.Notifications.BaseURL, "/") + "/unsubscribe/" + watcher.Token
+}
+
+// dueWithin 判断未过期的事项是否将在 d 之内到期
+func dueWithin(todo *models.Todo, now time.Time, d time.Duration) bool {
+	return todo.HasDueDate() && todo.DueDate.After(now) && todo.DueDate.Sub(now) <= d
+}
+
+// normalizeEmail 校验邮箱地址并转换为小写，只接受不带显示名称的地址
+func normalizeEmail(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	if addr.Address != value {
+		return "", errors.New("邮箱地址不能包含显示名称")
+	}
+	return strings.ToLower(addr.Address), nil
+}
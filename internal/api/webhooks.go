@@ -0,0 +1,546 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"github.com/gorilla/mux"
+)
+
+// webhookConsumer 投递 webhook 时在事件发件箱中确认位置使用的集成方名称
+const webhookConsumer = "webhook"
+
+// webhookMaxRetryDelay 重试间隔的上限
+const webhookMaxRetryDelay = time.Hour
+
+// 投递记录列表分页参数
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 1000
+)
+
+// webhook 请求头
+const (
+	webhookIDHeader        = "X-Webhook-ID"        // 投递ID，重试时不变，接收方可据此去重
+	webhookEventHeader     = "X-Webhook-Event"     // 事件类型
+	webhookTimestampHeader = "X-Webhook-Timestamp" // 发送时间（Unix 秒）
+	webhookSignatureHeader = "X-Webhook-Signature" // sha256=HMAC-SHA256(密钥, 时间戳 + "." + 请求体) 的十六进制
+)
+
+// webhookPayload webhook 请求体
+type webhookPayload struct {
+	ID        string          `json:"id"`             // 投递ID
+	Event     string          `json:"event"`          // 事件类型，如 todo.created
+	Seq       int64           `json:"seq"`            // 事件序号，接收方可据此排序
+	TodoID    int             `json:"todo_id"`        // 相关的待办事项ID
+	CreatedAt time.Time       `json:"created_at"`     // 事件时间
+	Data      json.RawMessage `json:"data,omitempty"` // 事件发生后的待办事项，删除事件没有数据
+}
+
+// newWebhookClient 创建发送 webhook 的客户端
+// 不跟随重定向：POST 被重定向后会变成 GET 并丢掉请求体，3xx 响应按发送失败处理
+func newWebhookClient(cfg config.WebhookConfig) *http.Client {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ListWebhooks 获取所有 webhook 订阅，不返回密钥
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := store.ListWebhooks(h.store)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	for i, webhook := range webhooks {
+		webhooks[i] = withoutSecret(webhook)
+	}
+	sendJSON(w, webhooks, http.StatusOK)
+}
+
+// GetWebhook 获取单个 webhook 订阅，不返回密钥
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := store.GetWebhook(h.store, mux.Vars(r)["id"])
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, withoutSecret(webhook), http.StatusOK)
+}
+
+// CreateWebhook 创建 webhook 订阅
+// 未指定密钥时自动生成，密钥只在这里返回一次，接收方用它校验 X-Webhook-Signature；
+// 只投递创建之后发生的事件
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook models.Webhook
+	if err := decodeJSON(r, &webhook); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if err := webhook.Validate(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := randomHex(6)
+	if err != nil {
+		sendError(w, "生成订阅ID失败", http.StatusInternalServerError)
+		return
+	}
+	if webhook.Secret == "" {
+		if webhook.Secret, err = randomHex(32); err != nil {
+			sendError(w, "生成密钥失败", http.StatusInternalServerError)
+			return
+		}
+	}
+	now := time.Now()
+	webhook.ID = id
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	if err := store.SaveWebhook(h.store, &webhook); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, webhook, http.StatusCreated)
+}
+
+// UpdateWebhook 更新 webhook 订阅，ID和创建时间保持不变，未指定密钥时沿用原来的密钥
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	existing, err := store.GetWebhook(h.store, mux.Vars(r)["id"])
+	if errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := decodeJSON(r, &webhook); err != nil {
+		sendDecodeError(w, "无效数据", err)
+		return
+	}
+	if err := webhook.Validate(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook.ID = existing.ID
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now()
+	if webhook.Secret == "" {
+		webhook.Secret = existing.Secret
+	}
+
+	if err := store.SaveWebhook(h.store, &webhook); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, withoutSecret(&webhook), http.StatusOK)
+}
+
+// DeleteWebhook 删除 webhook 订阅，尚未发送的投递不再发送，投递记录一并删除
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := store.DeleteWebhook(h.store, id); err != nil {
+		sendError(w, "删除失败", http.StatusNotFound)
+		return
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(h.store, id)
+	if err != nil {
+		logf(r, "清理 webhook %s 的投递记录失败: %v", id, err)
+	}
+	for _, delivery := range deliveries {
+		if err := store.DeleteWebhookDelivery(h.store, delivery.ID); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			logf(r, "清理投递记录 %s 失败: %v", delivery.ID, err)
+		}
+	}
+
+	sendJSON(w, map[string]string{"message": "删除成功"}, http.StatusOK)
+}
+
+// ListWebhookDeliveries 获取 webhook 订阅的投递记录，最新的在前
+// ?status= 按状态（pending、succeeded、failed）筛选，?limit= 为最多返回的数量
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := store.GetWebhook(h.store, id); errors.Is(err, store.ErrMetaNotFound) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	} else if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		sendError(w, "status参数必须是 pending、succeeded 或 failed", http.StatusBadRequest)
+		return
+	}
+	limit := defaultDeliveryLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeliveryLimit {
+			sendError(w, "limit参数必须在1到1000之间", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(h.store, id)
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	results := []*models.WebhookDelivery{}
+	for i := len(deliveries) - 1; i >= 0 && len(results) < limit; i-- {
+		if status == "" || deliveries[i].Status == status {
+			results = append(results, deliveries[i])
+		}
+	}
+	sendJSON(w, results, http.StatusOK)
+}
+
+// RedeliverWebhook 重新发送一个投递（包括已成功和已失败的），在下一次投递时发送，已发送次数重新计算
+func (h *Handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	delivery, err := store.GetWebhookDelivery(h.store, vars["delivery"])
+	if errors.Is(err, store.ErrMetaNotFound) || (err == nil && delivery.WebhookID != vars["id"]) {
+		sendError(w, "未找到", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendError(w, "获取失败", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	if err := store.SaveWebhookDelivery(h.store, delivery); err != nil {
+		sendError(w, "保存失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, delivery, http.StatusAccepted)
+}
+
+// WebhookInterval webhook 的投递间隔
+func (h *Handler) WebhookInterval() time.Duration {
+	if h.config.Webhooks.Interval <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(h.config.Webhooks.Interval) * time.Second
+}
+
+// DeliverWebhooks 定时任务：把发件箱中的新事件加入投递队列，发送到期的投递，并清理旧的投递记录
+// 不同订阅的投递并行发送，同一个订阅按事件序号依次发送；发送失败的投递稍后重试，不阻塞后面的事件，
+// 因此接收方看到的顺序可能与事件顺序不同，可以按请求体中的 seq 排序
+func (h *Handler) DeliverWebhooks(ctx context.Context) error {
+	webhooks, err := store.ListWebhooks(h.store)
+	if err != nil {
+		return err
+	}
+	if err := h.enqueueWebhookDeliveries(webhooks); err != nil {
+		return err
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(h.store, "")
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+	now := time.Now()
+	due := make(map[string][]*models.WebhookDelivery)
+	for _, delivery := range deliveries {
+		webhook := byID[delivery.WebhookID]
+		if webhook == nil || webhook.Disabled || delivery.Status != models.DeliveryPending {
+			continue
+		}
+		if delivery.NextAttemptAt != nil && delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		due[webhook.ID] = append(due[webhook.ID], delivery)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, failed := 0, 0
+	for id, pending := range due {
+		wg.Add(1)
+		go func(webhook *models.Webhook, pending []*models.WebhookDelivery) {
+			defer wg.Done()
+			for _, delivery := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+				ok := h.sendWebhook(ctx, webhook, delivery)
+				mu.Lock()
+				if ok {
+					succeeded++
+				} else {
+					failed++
+				}
+				mu.Unlock()
+			}
+		}(byID[id], pending)
+	}
+	wg.Wait()
+
+	if succeeded > 0 || failed > 0 {
+		log.Printf("🪝 投递了 %d 个 webhook，%d 个发送失败", succeeded, failed)
+	}
+	return h.pruneWebhookDeliveries(byID)
+}
+
+// enqueueWebhookDeliveries 从发件箱中读取上次确认位置之后的事件，为订阅了该事件的订阅创建投递
+// 投递ID由订阅ID和事件序号组成，确认位置保存失败后重新读取到同一个事件时不会重复投递；
+// 第一次运行时从发件箱的末尾开始，不投递之前的历史事件
+func (h *Handler) enqueueWebhookDeliveries(webhooks []*models.Webhook) error {
+	var after int64
+	offset, err := store.GetEventOffset(h.store, webhookConsumer)
+	switch {
+	case errors.Is(err, store.ErrMetaNotFound):
+		if after, err = h.latestEventSeq(); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		after = offset.Seq
+	}
+
+	for {
+		events, err := h.events.ListEvents(after, maxEventLimit)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			for _, webhook := range webhooks {
+				// 订阅创建之前的事件不投递
+				if webhook.Disabled || !webhook.Matches(event.Type) || event.CreatedAt.Before(webhook.CreatedAt) {
+					continue
+				}
+				if err := h.enqueueWebhookDelivery(webhook, event); err != nil {
+					return err
+				}
+			}
+			after = event.Seq
+		}
+
+		offset := &models.EventOffset{Consumer: webhookConsumer, Seq: after, UpdatedAt: time.Now()}
+		if err := store.SaveEventOffset(h.store, offset); err != nil {
+			return err
+		}
+		if len(events) < maxEventLimit {
+			return nil
+		}
+	}
+}
+
+// enqueueWebhookDelivery 为订阅创建一个事件的投递，已存在时不覆盖
+func (h *Handler) enqueueWebhookDelivery(webhook *models.Webhook, event *models.Event) error {
+	id := fmt.Sprintf("%s-%d", webhook.ID, event.Seq)
+	if _, err := store.GetWebhookDelivery(h.store, id); err == nil {
+		return nil
+	} else if !errors.Is(err, store.ErrMetaNotFound) {
+		return err
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:        id,
+		Event:     event.Type,
+		Seq:       event.Seq,
+		TodoID:    event.TodoID,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return store.SaveWebhookDelivery(h.store, &models.WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhook.ID,
+		EventSeq:      event.Seq,
+		EventType:     event.Type,
+		TodoID:        event.TodoID,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		CreatedAt:     now,
+		NextAttemptAt: &now,
+	})
+}
+
+// sendWebhook 发送一次投递并保存结果，对方返回 2xx 时视为成功
+// 失败时按已发送的次数计算下一次重试的时间，次数用完后标记为失败
+func (h *Handler) sendWebhook(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) bool {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseCode = 0
+
+	err := h.postWebhook(ctx, webhook, delivery, now)
+	cfg := h.config.Webhooks
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+		log.Printf("⚠️ webhook %s 投递 %s 失败，已发送 %d 次，不再重试: %v", webhook.ID, delivery.ID, delivery.Attempts, err)
+	default:
+		next := now.Add(webhookRetryDelay(cfg, delivery.Attempts))
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := store.SaveWebhookDelivery(h.store, delivery); err != nil {
+		log.Printf("保存投递记录 %s 失败: %v", delivery.ID, err)
+	}
+	return delivery.Status == models.DeliverySucceeded
+}
+
+// postWebhook 发送 webhook 请求，请求体用订阅的密钥签名
+func (h *Handler) postWebhook(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "xStreamTool-Webhook/1.0")
+	req.Header.Set(webhookIDHeader, delivery.ID)
+	req.Header.Set(webhookEventHeader, delivery.EventType)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, "sha256="+signWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := h.webhooks.Do(req)
+	if err != nil {
+		return err
+	}
+	// 读完响应体以便复用连接，过长的响应体不再读取
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	delivery.ResponseCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("对方返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signWebhook 计算签名：HMAC-SHA256(密钥, 时间戳 + "." + 请求体) 的十六进制
+// 签名包含时间戳，接收方可以拒绝时间相差过大的请求，防止请求被截获后重放
+func signWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay 第 attempts 次发送失败后到下一次重试的间隔：retry_delay × 2^(attempts-1)，最长1小时
+func webhookRetryDelay(cfg config.WebhookConfig, attempts int) time.Duration {
+	delay := time.Duration(cfg.RetryDelay) * time.Second
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetryDelay {
+		delay = webhookMaxRetryDelay
+	}
+	return delay
+}
+
+// pruneWebhookDeliveries 每个订阅只保留最近的 keep_deliveries 条已结束的投递记录，已删除的订阅的投递记录全部删除
+func (h *Handler) pruneWebhookDeliveries(webhooks map[string]*models.Webhook) error {
+	deliveries, err := store.ListWebhookDeliveries(h.store, "")
+	if err != nil {
+		return err
+	}
+
+	keep := h.config.Webhooks.KeepDeliveries
+	finished := make(map[string]int)
+	// 从最新的开始计数，超出保留数量的旧记录被删除
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		delivery := deliveries[i]
+		if webhooks[delivery.WebhookID] != nil {
+			if delivery.Status == models.DeliveryPending {
+				continue
+			}
+			finished[delivery.WebhookID]++
+			if keep <= 0 || finished[delivery.WebhookID] <= keep {
+				continue
+			}
+		}
+		if err := store.DeleteWebhookDelivery(h.store, delivery.ID); err != nil && !errors.Is(err, store.ErrMetaNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutSecret 返回不含密钥的订阅副本
+func withoutSecret(webhook *models.Webhook) *models.Webhook {
+	copied := *webhook
+	copied.Secret = ""
+	return &copied
+}
+
+// randomHex 生成 n 个随机字节的十六进制字符串
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,389 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// WebSocket 连接参数
+const (
+	wsSendBuffer   = 64               // 每个连接待发送消息的缓冲数量，客户端读取过慢导致缓冲写满时断开连接
+	wsWriteTimeout = 10 * time.Second // 单条消息的写入超时
+	wsPongTimeout  = 60 * time.Second // 超过该时间没有收到客户端的消息或 pong 时断开
+	wsPingInterval = 30 * time.Second // 发送 ping 的间隔，需小于 wsPongTimeout
+	wsMaxMessage   = 1 << 20          // 客户端消息的最大长度
+)
+
+// wsMessage 服务器发给客户端的消息
+// type 为 hello（连接建立，last_seq 为当前最后一个事件的序号）、event（数据变化）、result（命令执行结果）
+type wsMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`       // 对应命令的ID
+	Status  int             `json:"status,omitempty"`   // 命令的执行结果，与对应 REST 接口的状态码相同
+	Data    json.RawMessage `json:"data,omitempty"`     // 命令的响应，与对应 REST 接口的响应体相同
+	Event   *models.Event   `json:"event,omitempty"`    // 事件，格式同 GET /api/admin/events
+	LastSeq *int64          `json:"last_seq,omitempty"` // 连接建立时最后一个事件的序号，只在 hello 中返回
+}
+
+// wsCommand 客户端发给服务器的修改命令，按对应的 REST 接口执行
+type wsCommand struct {
+	ID      string          `json:"id"`       // 客户端指定的命令ID，原样返回在结果中
+	Action  string          `json:"action"`   // create、update、patch、complete、uncomplete 或 delete
+	TodoID  int             `json:"todo_id"`  // 待办事项ID，create 时不需要
+	IfMatch string          `json:"if_match"` // 可选，与 If-Match 请求头相同，版本不一致时返回 412
+	Data    json.RawMessage `json:"data"`     // 请求体，与对应 REST 接口相同
+}
+
+// route 返回命令对应的 REST 接口，命令无效时返回错误
+func (c *wsCommand) route() (method, path string, err error) {
+	switch c.Action {
+	case "create":
+		return http.MethodPost, apiBasePath + "/todos", nil
+	case "update", "patch", "complete", "uncomplete", "delete":
+		if c.TodoID <= 0 {
+			return "", "", fmt.Errorf("%s 命令需要 todo_id", c.Action)
+		}
+	default:
+		return "", "", fmt.Errorf("未知的命令 %q，应为 create、update、patch、complete、uncomplete 或 delete", c.Action)
+	}
+
+	todo := fmt.Sprintf("%s/todos/%d", apiBasePath, c.TodoID)
+	switch c.Action {
+	case "update":
+		return http.MethodPut, todo, nil
+	case "patch":
+		return http.MethodPatch, todo, nil
+	case "complete":
+		return http.MethodPatch, todo + "/complete", nil
+	case "uncomplete":
+		return http.MethodPatch, todo + "/uncomplete", nil
+	default:
+		return http.MethodDelete, todo, nil
+	}
+}
+
+// wsHub 管理 WebSocket 连接，把事件发件箱中的新事件广播给所有连接
+// 与事件流一样轮询发件箱，因此多个实例共用存储时任一实例上的修改都会广播；第一个连接建立时开始轮询
+type wsHub struct {
+	h *Handler
+
+	mu      sync.Mutex
+	clients map[*wsClient]bool
+	started bool
+	lastSeq int64 // 已广播的最后一个事件的序号
+}
+
+// wsClient 单个 WebSocket 连接
+type wsClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// newWSHub 创建连接管理器
+func newWSHub(h *Handler) *wsHub {
+	return &wsHub{h: h, clients: make(map[*wsClient]bool)}
+}
+
+// register 登记连接，返回当前最后一个事件的序号
+func (hub *wsHub) register(client *wsClient) (int64, error) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if !hub.started {
+		latest, err := hub.h.latestEventSeq()
+		if err != nil {
+			return 0, err
+		}
+		hub.lastSeq = latest
+		hub.started = true
+		go hub.run()
+	}
+	hub.clients[client] = true
+	return hub.lastSeq, nil
+}
+
+// unregister 移除连接并关闭它的发送队列
+func (hub *wsHub) unregister(client *wsClient) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.clients[client] {
+		delete(hub.clients, client)
+		close(client.send)
+	}
+}
+
+// run 轮询发件箱并广播新事件，服务器关闭时断开所有连接
+func (hub *wsHub) run() {
+	poll := time.NewTicker(eventStreamPollInterval)
+	defer poll.Stop()
+	for {
+		select {
+		case <-hub.h.closing:
+			hub.closeAll()
+			return
+		case <-poll.C:
+		}
+
+		hub.mu.Lock()
+		after := hub.lastSeq
+		hub.mu.Unlock()
+
+		events, err := hub.h.events.ListEvents(after, maxEventLimit)
+		if err != nil {
+			log.Printf("读取事件失败: %v", err)
+			continue
+		}
+		for _, event := range events {
+			data, err := json.Marshal(wsMessage{Type: "event", Event: event})
+			if err != nil {
+				continue
+			}
+			hub.broadcast(data)
+			after = event.Seq
+		}
+
+		hub.mu.Lock()
+		hub.lastSeq = after
+		hub.mu.Unlock()
+	}
+}
+
+// broadcast 把消息放入每个连接的发送队列，队列已满的连接断开
+func (hub *wsHub) broadcast(data []byte) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for client := range hub.clients {
+		select {
+		case client.send <- data:
+		default:
+			delete(hub.clients, client)
+			close(client.send)
+		}
+	}
+}
+
+// closeAll 断开所有连接
+func (hub *wsHub) closeAll() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for client := range hub.clients {
+		delete(hub.clients, client)
+		close(client.send)
+	}
+}
+
+// WebSocket 实时同步接口 /ws
+// 连接建立后先收到 hello 消息，之后每次数据变化收到 event 消息（格式同 GET /api/admin/events 中的事件），
+// 多个浏览器标签页和客户端据此保持同步；断线期间错过的事件可以用 hello 中的 last_seq 从 GET /api/admin/events（需要管理令牌）补上。
+// 客户端可以发送修改命令 {"id": "1", "action": "patch", "todo_id": 3, "data": {...}}，按对应的 REST 接口执行
+// （校验、限流、事件与直接调用接口相同），结果以 result 消息返回。来源检查与跨域配置 server.allowed_origins 相同
+func (h *Handler) WebSocket(router *mux.Router) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || h.allowedOrigin(origin) != "" || sameOrigin(r, origin)
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade 已经返回了错误响应
+			return
+		}
+
+		client := &wsClient{conn: conn, send: make(chan []byte, wsSendBuffer)}
+		lastSeq, err := h.hub.register(client)
+		if err != nil {
+			logf(r, "建立 WebSocket 连接失败: %v", err)
+			conn.Close()
+			return
+		}
+
+		hello, _ := json.Marshal(wsMessage{Type: "hello", LastSeq: &lastSeq})
+		client.enqueue(h.hub, hello)
+
+		go client.writeLoop()
+		client.readLoop(r, func(command *wsCommand) wsMessage {
+			return h.execCommand(router, r, command)
+		}, h.hub)
+	}
+}
+
+// readLoop 读取客户端的命令，连接断开时返回
+func (c *wsClient) readLoop(r *http.Request, exec func(*wsCommand) wsMessage, hub *wsHub) {
+	defer func() {
+		hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(wsMaxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logf(r, "WebSocket 连接异常断开: %v", err)
+			}
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+
+		var command wsCommand
+		result := wsMessage{Type: "result", Status: http.StatusBadRequest}
+		if err := json.Unmarshal(data, &command); err != nil {
+			result.Data = errorPayload("无效的命令: " + err.Error())
+		} else {
+			result = exec(&command)
+		}
+
+		reply, err := json.Marshal(result)
+		if err != nil {
+			continue
+		}
+		if !c.enqueue(hub, reply) {
+			return
+		}
+	}
+}
+
+// enqueue 把命令结果放入发送队列，连接已被移除或队列已满时返回 false
+func (c *wsClient) enqueue(hub *wsHub, data []byte) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if !hub.clients[c] {
+		return false
+	}
+	select {
+	case c.send <- data:
+		return true
+	default:
+		delete(hub.clients, c)
+		close(c.send)
+		return false
+	}
+}
+
+// writeLoop 发送队列中的消息并定时发送 ping，队列关闭时关闭连接
+func (c *wsClient) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// execCommand 把命令转换为对应 REST 接口的请求，经过路由（包括限流、降级等中间件）执行
+func (h *Handler) execCommand(router *mux.Router, r *http.Request, command *wsCommand) wsMessage {
+	result := wsMessage{Type: "result", ID: command.ID}
+	method, path, err := command.route()
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Data = errorPayload(err.Error())
+		return result
+	}
+
+	header := make(http.Header)
+	if command.IfMatch != "" {
+		header.Set("If-Match", command.IfMatch)
+	}
+	status, body, err := dispatchInternal(router, r, method, path, command.Data, header)
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Data = errorPayload(err.Error())
+		return result
+	}
+
+	result.Status = status
+	result.Data = body
+	return result
+}
+
+// dispatchInternal 以原请求的客户端身份构造对 REST 接口的内部请求，经过路由（包括限流、降级等中间件）执行
+// 返回状态码和 JSON 响应体，响应体为空或不是 JSON 时返回nil；header 为额外的请求头，可为nil
+func dispatchInternal(router http.Handler, r *http.Request, method, path string, body []byte, header http.Header) (int, json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(r.Context(), method, path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.RemoteAddr = r.RemoteAddr // 限流按原连接的客户端计算
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", r.Header.Get("Accept-Language"))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	recorder := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	router.ServeHTTP(recorder, req)
+
+	if data := bytes.TrimSpace(recorder.body.Bytes()); len(data) > 0 && json.Valid(data) {
+		return recorder.status, data, nil
+	}
+	return recorder.status, nil, nil
+}
+
+// bufferedResponse 缓存命令执行结果的 ResponseWriter
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header 返回响应头
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+// Write 缓存响应体
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+// WriteHeader 记录状态码
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+// errorPayload 与 sendError 相同格式的错误内容
+func errorPayload(message string) json.RawMessage {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	return data
+}
+
+// sameOrigin 来源是否与请求的地址相同（页面和接口在同一个服务上）
+func sameOrigin(r *http.Request, origin string) bool {
+	return origin == "http://"+r.Host || origin == "https://"+r.Host
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlMediaType YAML 请求体和响应使用的媒体类型
+const yamlMediaType = "application/yaml"
+
+// isYAMLMediaType 是否为 YAML 的媒体类型，兼容常见的非标准写法
+func isYAMLMediaType(mediaType string) bool {
+	switch mediaType {
+	case yamlMediaType, "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+	return false
+}
+
+// wantsYAML 客户端是否希望得到 YAML 响应：Accept 中 YAML 出现在 application/json 和 */* 之前
+func wantsYAML(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if isYAMLMediaType(mediaType) {
+			return true
+		}
+		if mediaType == "application/json" || mediaType == "*/*" {
+			return false
+		}
+	}
+	return false
+}
+
+// yamlMiddleware YAML 内容协商中间件，接口本身只处理 JSON
+// Content-Type 为 YAML 的请求体转换为 JSON 后交给接口（同样按严格的 JSON 解码）；
+// Accept 要求 YAML 时把 JSON 响应转换为 YAML，字段顺序与 JSON 相同，其它类型的响应（CSV、ICS 等）原样返回
+func yamlMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); isYAMLMediaType(mediaType) {
+			body, err := yamlToJSON(r.Body)
+			if err != nil {
+				sendDecodeError(w, "无效的 YAML 数据", err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		if !wantsYAML(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writer := &yamlResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(writer, r)
+		writer.finish()
+	})
+}
+
+// yamlToJSON 把 YAML 请求体转换为 JSON，请求体为空时返回空内容，由接口按空请求体处理
+func yamlToJSON(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// jsonToYAML 把 JSON 响应转换为 YAML
+// 以 yaml.Node 解析（JSON 是 YAML 的子集），保留字段顺序，并把流式风格改为块风格便于阅读
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle 清除节点的风格，编码时使用块风格；字符串的值与其它类型冲突时（如 "123"）编码器会自动加引号
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// yamlResponseWriter 缓存 JSON 响应，处理结束后转换为 YAML 发送；其它类型的响应直接写出
+type yamlResponseWriter struct {
+	http.ResponseWriter
+	status      int          // 响应状态码
+	wroteHeader bool         // 是否已调用 WriteHeader
+	buffering   bool         // 是否为需要转换的 JSON 响应
+	buf         bytes.Buffer // 缓存的 JSON 响应
+}
+
+// WriteHeader 根据响应的 Content-Type 决定是否转换，需要转换时推迟发送响应头
+func (yw *yamlResponseWriter) WriteHeader(status int) {
+	if yw.wroteHeader {
+		return
+	}
+	yw.wroteHeader = true
+	yw.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(yw.Header().Get("Content-Type"))
+	yw.buffering = mediaType == "application/json"
+	if !yw.buffering {
+		yw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write 写入响应体，JSON 响应先缓存
+func (yw *yamlResponseWriter) Write(data []byte) (int, error) {
+	if !yw.wroteHeader {
+		yw.WriteHeader(http.StatusOK)
+	}
+	if yw.buffering {
+		return yw.buf.Write(data)
+	}
+	return yw.ResponseWriter.Write(data)
+}
+
+// Flush 支持流式响应，缓存中的 JSON 响应在处理结束后才发送
+func (yw *yamlResponseWriter) Flush() {
+	if !yw.buffering {
+		http.NewResponseController(yw.ResponseWriter).Flush()
+	}
+}
+
+// Unwrap 返回原始的 ResponseWriter，供 http.ResponseController 使用
+func (yw *yamlResponseWriter) Unwrap() http.ResponseWriter {
+	return yw.ResponseWriter
+}
+
+// finish 把缓存的 JSON 响应转换为 YAML 发送，转换失败时原样发送 JSON
+func (yw *yamlResponseWriter) finish() {
+	if !yw.buffering {
+		return
+	}
+	body := yw.buf.Bytes()
+	if converted, err := jsonToYAML(body); err == nil {
+		body = converted
+		yw.Header().Set("Content-Type", yamlMediaType)
+	}
+	yw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	yw.ResponseWriter.WriteHeader(yw.status)
+	yw.ResponseWriter.Write(body)
+}
@@ -0,0 +1,270 @@
+// Package archive 把已完成的旧事项导出到压缩的归档文件（本地目录或 S3 兼容的对象存储）后从存储中移除
+// 归档文件为 gzip 压缩的 JSON Lines，每行一个待办事项，写入后不再修改；
+// 归档的事项可以按需搜索，并按原ID恢复（rehydrate）到存储
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// ErrNotArchived 归档文件中没有指定的待办事项时返回的错误
+var ErrNotArchived = errors.New("归档中没有该待办事项")
+
+// timeLayout 归档文件名中的时间格式（UTC，精确到毫秒）
+const timeLayout = "20060102-150405.000"
+
+// namePattern 归档文件名
+var namePattern = regexp.MustCompile(`^archive-(\d{8}-\d{6}\.\d{3})\.jsonl\.gz$`)
+
+// Manager 归档管理器
+type Manager struct {
+	store   store.TodoStore
+	cfg     config.ArchiveConfig
+	storage Storage
+	err     error // 创建存储失败时的错误，之后每次使用归档时返回
+
+	mu sync.Mutex // 保证同一时间只有一次归档在执行
+}
+
+// New 创建归档管理器
+// 存储位置配置错误时不返回错误，而是在每次归档、搜索时返回，不影响服务启动
+func New(s store.TodoStore, cfg config.ArchiveConfig) *Manager {
+	storage, err := NewStorage(cfg)
+	return &Manager{store: s, cfg: cfg, storage: storage, err: err}
+}
+
+// Enabled 是否启用了定期归档
+func (m *Manager) Enabled() bool {
+	return m.cfg.Enabled
+}
+
+// Interval 定期归档的间隔，未配置时为一天
+func (m *Manager) Interval() time.Duration {
+	if m.cfg.Interval <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(m.cfg.Interval) * time.Minute
+}
+
+// Archive 把完成后超过 after_days 天未修改的事项写入一个新的归档文件，写入成功后从存储中移除
+// 写入归档文件之后被修改（版本号变化）或删除的事项不会被移除，记入 Skipped；
+// 移除事项只删除待办事项本身，指向它们的关联、分享链接等由调用方清理
+func (m *Manager) Archive(ctx context.Context, now time.Time) (*models.ArchiveResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := now.AddDate(0, 0, -m.cfg.AfterDays)
+	todos, err := m.store.ListTodos(store.ListOptions{SortField: models.SortByID})
+	if err != nil {
+		return nil, fmt.Errorf("读取待办事项失败: %w", err)
+	}
+	var candidates []*models.Todo
+	for _, todo := range todos {
+		if todo.Completed && todo.UpdatedAt.Before(cutoff) {
+			candidates = append(candidates, todo)
+		}
+	}
+	result := &models.ArchiveResult{IDs: []int{}}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	data, err := encode(candidates)
+	if err != nil {
+		return nil, fmt.Errorf("生成归档文件失败: %w", err)
+	}
+	name := "archive-" + now.UTC().Format(timeLayout) + ".jsonl.gz"
+	if err := m.storage.Put(ctx, name, data); err != nil {
+		return nil, fmt.Errorf("写入归档文件失败: %w", err)
+	}
+	result.Archive, result.Size = name, int64(len(data))
+
+	// 归档文件已经写入，只移除之后没有变化的事项
+	err = m.store.Transaction(func(tx store.TodoStore) error {
+		result.IDs, result.Skipped = []int{}, 0
+		for _, todo := range candidates {
+			current, err := tx.GetTodoByID(todo.ID)
+			if errors.Is(err, store.ErrTodoNotFound) {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if current.Version != todo.Version {
+				result.Skipped++
+				continue
+			}
+			result.IDs = append(result.IDs, todo.ID)
+		}
+		if len(result.IDs) == 0 {
+			return nil
+		}
+		return tx.BulkDelete(result.IDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("已写入归档文件 %s，但从存储中移除事项失败: %w", name, err)
+	}
+	result.Archived = len(result.IDs)
+	return result, nil
+}
+
+// encode 把待办事项编码为 gzip 压缩的 JSON Lines
+func encode(todos []*models.Todo) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(writer)
+	for _, todo := range todos {
+		if err := encoder.Encode(todo); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// load 读取归档文件中的待办事项
+func (m *Manager) load(ctx context.Context, name string) ([]*models.Todo, error) {
+	data, err := m.storage.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解压归档文件 %s 失败: %w", name, err)
+	}
+	defer reader.Close()
+
+	var todos []*models.Todo
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var todo models.Todo
+		if err := json.Unmarshal(scanner.Bytes(), &todo); err != nil {
+			return nil, fmt.Errorf("解析归档文件 %s 失败: %w", name, err)
+		}
+		todos = append(todos, &todo)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取归档文件 %s 失败: %w", name, err)
+	}
+	return todos, nil
+}
+
+// archives 列出全部归档文件名和归档时间，最新的在前
+func (m *Manager) archives(ctx context.Context) ([]string, map[string]time.Time, error) {
+	names, err := m.storage.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("列出归档文件失败: %w", err)
+	}
+	archivedAt := make(map[string]time.Time, len(names))
+	results := make([]string, 0, len(names))
+	for _, name := range names {
+		match := namePattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		archivedAt[name], _ = time.Parse(timeLayout, match[1])
+		results = append(results, name)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return archivedAt[results[i]].After(archivedAt[results[j]])
+	})
+	return results, archivedAt, nil
+}
+
+// each 从最新的归档文件开始逐个读取，同一个事项被多次归档时（恢复后再次归档）只处理最新的一次
+// fn 返回false时停止读取
+func (m *Manager) each(ctx context.Context, fn func(archived models.ArchivedTodo) bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	names, archivedAt, err := m.archives(ctx)
+	if err != nil {
+		return err
+	}
+	seen := make(map[int]bool)
+	for _, name := range names {
+		todos, err := m.load(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			if seen[todo.ID] {
+				continue
+			}
+			seen[todo.ID] = true
+			if !fn(models.ArchivedTodo{Todo: todo, Archive: name, ArchivedAt: archivedAt[name]}) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// Search 搜索归档的事项，最近归档的在前
+// query 不区分大小写地匹配标题、描述或分类中的子串，为空时不过滤；category 不为空时只返回该分类的事项。
+// 归档文件按需从存储中读取，归档较多时搜索较慢
+func (m *Manager) Search(ctx context.Context, query, category string) ([]models.ArchivedTodo, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := []models.ArchivedTodo{}
+	err := m.each(ctx, func(archived models.ArchivedTodo) bool {
+		todo := archived.Todo
+		if category != "" && todo.Category != category {
+			return true
+		}
+		if query != "" && !strings.Contains(strings.ToLower(todo.Title), query) &&
+			!strings.Contains(strings.ToLower(todo.Description), query) &&
+			!strings.Contains(strings.ToLower(todo.Category), query) {
+			return true
+		}
+		results = append(results, archived)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Find 查找最近一次归档的指定事项，没有找到时返回 ErrNotArchived
+func (m *Manager) Find(ctx context.Context, id int) (*models.ArchivedTodo, error) {
+	var found *models.ArchivedTodo
+	err := m.each(ctx, func(archived models.ArchivedTodo) bool {
+		if archived.Todo.ID == id {
+			found = &archived
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, ErrNotArchived
+	}
+	return found, nil
+}
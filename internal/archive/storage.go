@@ -0,0 +1,188 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+)
+
+// 归档文件的存储位置
+const (
+	StorageLocal = "local" // 本地目录
+	StorageS3    = "s3"    // S3 兼容的对象存储
+)
+
+// ErrUnknownStorage 配置的存储位置既不是 local 也不是 s3 时返回的错误
+var ErrUnknownStorage = errors.New("不支持的归档存储位置")
+
+// Storage 归档文件的存储
+// 归档文件写入后不再修改；Get 在文件不存在时返回 os.ErrNotExist
+type Storage interface {
+	Put(ctx context.Context, name string, data []byte) error // 写入归档文件
+	Get(ctx context.Context, name string) ([]byte, error)    // 读取归档文件
+	List(ctx context.Context) ([]string, error)              // 列出全部归档文件名
+}
+
+// NewStorage 根据配置创建归档文件的存储
+func NewStorage(cfg config.ArchiveConfig) (Storage, error) {
+	switch cfg.Storage {
+	case "", StorageLocal:
+		return &localStorage{dir: cfg.Dir}, nil
+	case StorageS3:
+		return newS3Storage(cfg.S3)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownStorage, cfg.Storage)
+}
+
+// localStorage 保存在本地目录中的归档文件
+type localStorage struct {
+	dir string
+}
+
+// Put 先写入临时文件并同步到磁盘，再重命名为目标文件，避免留下不完整的归档文件
+func (s *localStorage) Put(ctx context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+// Get 读取归档文件
+func (s *localStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, name))
+}
+
+// List 列出归档目录中的文件，目录不存在时返回空列表
+func (s *localStorage) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// s3Storage 保存在对象存储中的归档文件，对象键为前缀加文件名
+type s3Storage struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// newS3Storage 创建对象存储客户端
+// 配置了访问密钥时使用配置的密钥，否则使用环境变量、共享凭证文件等默认凭证
+func newS3Storage(cfg config.S3Config) (*s3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("未配置归档使用的存储桶")
+	}
+
+	options := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(cfg.Region)}
+	if cfg.AccessKey != "" {
+		options = append(options, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("加载对象存储配置失败: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.PathStyle
+	})
+	return &s3Storage{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+// Put 上传归档文件
+func (s *s3Storage) Put(ctx context.Context, name string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.prefix + name),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/gzip"),
+	})
+	return err
+}
+
+// Get 下载归档文件，对象不存在时返回 os.ErrNotExist
+func (s *s3Storage) Get(ctx context.Context, name string) ([]byte, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + name),
+	})
+	var notFound *types.NoSuchKey
+	if errors.As(err, &notFound) {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// List 列出前缀下的全部归档文件名（不包含前缀）
+func (s *s3Storage) List(ctx context.Context) ([]string, error) {
+	names := []string{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			name := strings.TrimPrefix(aws.ToString(object.Key), s.prefix)
+			// 前缀下子目录中的对象不是本模块写入的归档文件
+			if name != "" && !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,224 @@
+// Package backup 定期把存储中的全部数据导出到带时间戳的备份文件
+// 备份包含全部待办事项和需要迁移的附属数据（store.MigrateNamespaces），格式为快照JSON或 SQLite 方言的SQL脚本；
+// 超过保留数量的旧备份在每次备份后删除
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// 备份格式
+const (
+	FormatJSON = "json" // 快照格式，与 /api/admin/scrub 等接口使用的格式相同
+	FormatSQL  = "sql"  // SQLite 方言的SQL脚本，可以用 sqlite3 命令行工具直接导入
+)
+
+// 备份相关的错误
+var (
+	ErrInvalidName   = errors.New("无效的备份文件名") // 文件名不是本模块生成的备份文件名时返回的错误，避免读取备份目录之外的文件
+	ErrUnknownFormat = errors.New("不支持的备份格式") // 配置的备份格式既不是 json 也不是 sql 时返回的错误
+)
+
+// timeLayout 备份文件名中的时间格式（UTC）
+const timeLayout = "20060102-150405"
+
+// namePattern 备份文件名
+var namePattern = regexp.MustCompile(`^backup-(\d{8}-\d{6})\.(json|sql)$`)
+
+// Manager 备份管理器
+type Manager struct {
+	store store.TodoStore
+	cfg   config.BackupConfig
+
+	mu sync.Mutex // 保证同一时间只有一个备份在写入
+}
+
+// New 创建备份管理器
+func New(s store.TodoStore, cfg config.BackupConfig) *Manager {
+	if cfg.Format == "" {
+		cfg.Format = FormatJSON
+	}
+	return &Manager{store: s, cfg: cfg}
+}
+
+// Enabled 是否启用了定期备份
+func (m *Manager) Enabled() bool {
+	return m.cfg.Enabled
+}
+
+// Interval 定期备份的间隔，未配置时为一天
+func (m *Manager) Interval() time.Duration {
+	if m.cfg.Interval <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(m.cfg.Interval) * time.Minute
+}
+
+// Run 执行一次备份，作为定时任务使用
+func (m *Manager) Run(ctx context.Context) error {
+	info, err := m.Backup()
+	if err != nil {
+		return err
+	}
+	log.Printf("💾 已备份 %s（%d 字节）", info.Name, info.Size)
+	return nil
+}
+
+// Backup 立即导出一次备份，完成后删除超过保留数量的旧备份
+// 同一秒内的多次备份写入同一个文件，后一次覆盖前一次
+func (m *Manager) Backup() (*models.BackupInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot, err := store.Dump(m.store)
+	if err != nil {
+		return nil, fmt.Errorf("导出数据失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	switch m.cfg.Format {
+	case FormatJSON:
+		data, err := json.Marshal(snapshot) // 不缩进，附属数据的值按原样保存
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	case FormatSQL:
+		if err := store.WriteSQLiteDump(&buf, snapshot); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, m.cfg.Format)
+	}
+
+	if err := os.MkdirAll(m.cfg.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	now := snapshot.ExportedAt.UTC()
+	name := "backup-" + now.Format(timeLayout) + "." + m.cfg.Format
+	if err := writeFile(filepath.Join(m.cfg.Dir, name), buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("写入备份文件失败: %w", err)
+	}
+
+	if err := m.prune(); err != nil {
+		log.Printf("⚠️ 删除旧备份失败: %v", err)
+	}
+	return &models.BackupInfo{Name: name, Format: m.cfg.Format, Size: int64(buf.Len()), CreatedAt: now.Truncate(time.Second)}, nil
+}
+
+// List 列出备份目录中的备份文件，最新的在前；目录不存在时返回空列表
+func (m *Manager) List() ([]models.BackupInfo, error) {
+	entries, err := os.ReadDir(m.cfg.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []models.BackupInfo{}
+	for _, entry := range entries {
+		match := namePattern.FindStringSubmatch(entry.Name())
+		if match == nil || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		createdAt, _ := time.Parse(timeLayout, match[1])
+		backups = append(backups, models.BackupInfo{
+			Name:      entry.Name(),
+			Format:    match[2],
+			Size:      info.Size(),
+			CreatedAt: createdAt,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Load 读取备份文件中的数据
+// name 只能是 List 返回的文件名，不能包含目录
+func (m *Manager) Load(name string) (*models.Snapshot, error) {
+	match := namePattern.FindStringSubmatch(name)
+	if match == nil {
+		return nil, ErrInvalidName
+	}
+
+	file, err := os.Open(filepath.Join(m.cfg.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if match[2] == FormatSQL {
+		return store.ReadSQLiteDump(file)
+	}
+	var snapshot models.Snapshot
+	if err := json.NewDecoder(file).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("解析备份文件失败: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// prune 删除超过保留数量的旧备份
+func (m *Manager) prune() error {
+	if m.cfg.Keep <= 0 {
+		return nil
+	}
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(m.cfg.Keep, len(backups)):] {
+		if err := os.Remove(filepath.Join(m.cfg.Dir, backup.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile 先写入同目录下的临时文件并同步到磁盘，再重命名为目标文件，避免留下不完整的备份
+// 临时文件以 . 开头，不会被 List 列出
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
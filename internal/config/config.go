@@ -7,29 +7,99 @@ import (
 )
 
 // Config 应用配置 - 这是应用程序的完整配置结构
-// 它包含了服务器、数据库和日志三个主要部分的配置
+// 它包含了服务器、数据库、日志、列表视图、优先级老化、网页界面、定时任务、流量镜像、邮件通知、webhook、备份、下一步推荐和冷存储归档、回收站、审计日志和健康检查几个主要部分的配置
 type Config struct {
-	Server   ServerConfig   `json:"server"`   // 服务器相关配置
-	Database DatabaseConfig `json:"database"` // 数据库相关配置
-	Logging  LoggingConfig  `json:"logging"`  // 日志相关配置
+	Server        ServerConfig        `json:"server"`         // 服务器相关配置
+	Database      DatabaseConfig      `json:"database"`       // 数据库相关配置
+	Logging       LoggingConfig       `json:"logging"`        // 日志相关配置
+	View          ViewConfig          `json:"view"`           // 列表视图默认设置
+	PriorityAging PriorityAgingConfig `json:"priority_aging"` // 优先级老化策略
+	UI            UIConfig            `json:"ui"`             // 网页界面主题和品牌
+	Scheduler     SchedulerConfig     `json:"scheduler"`      // 定时任务调度
+	Mirror        MirrorConfig        `json:"mirror"`         // 流量镜像
+	Notifications NotificationConfig  `json:"notifications"`  // 关注者邮件通知
+	Webhooks      WebhookConfig       `json:"webhooks"`       // webhook 事件投递
+	Backup        BackupConfig        `json:"backup"`         // 定期备份
+	NextAction    NextActionConfig    `json:"next_action"`    // 下一步推荐的评分
+	Archive       ArchiveConfig       `json:"archive"`        // 已完成旧事项的冷存储归档
+	Trash         TrashConfig         `json:"trash"`          // 删除事项的回收站
+	Audit         AuditConfig         `json:"audit"`          // 修改待办事项的审计日志
+	Health        HealthConfig        `json:"health"`         // 健康检查的阈值
+	Admin         AdminConfig         `json:"admin"`          // 管理接口的访问控制
 }
 
 // ServerConfig 服务器配置 - 定义Web服务器的运行参数
 type ServerConfig struct {
 	Port           string   `json:"port"`            // 服务器监听的端口号，如 "8080"
 	Debug          bool     `json:"debug"`           // 是否启用调试模式，true时可能输出更多信息
-	AllowedOrigins []string `json:"allowed_origins"` // CORS允许的来源，用于跨域请求控制
+	AllowedOrigins []string `json:"allowed_origins"` // CORS允许的来源，用于跨域请求控制，如 "https://app.example.com"，"*" 表示任意来源，为空时不允许跨域
+	AllowedMethods []string `json:"allowed_methods"` // CORS允许的请求方法，预检请求的响应中返回
+	AllowedHeaders []string `json:"allowed_headers"` // CORS允许的请求头，"*" 表示允许预检请求中列出的任意请求头
+	CORSMaxAge     int      `json:"cors_max_age"`    // 浏览器缓存预检结果的时间（秒），为0时不缓存
 	RateLimit      int      `json:"rate_limit"`      // 速率限制，单位时间内允许的最大请求数
+	Hypermedia     bool     `json:"hypermedia"`      // 是否在响应中返回 _links 超媒体链接
+	MaxBodyBytes   int64    `json:"max_body_bytes"`  // API 请求体的最大字节数，超过时返回 413，0表示不限制
+	ShutdownDelay  int      `json:"shutdown_delay"`  // 收到停止信号后 /readyz 先返回 503，等待多少秒再关闭连接，让负载均衡器有时间摘除实例，0表示立即关闭
+
+	// RouteRateLimits 单独限流的接口，键为 "POST /todos/import" 或 "/search"（不限方法），路径不含 /api 或 /api/v1 前缀，
+	// 路径参数写作 {id}；值为该接口每秒允许的请求数，0 表示不限流。这些接口使用各自的配额，不消耗 rate_limit 的配额
+	RouteRateLimits map[string]int `json:"route_rate_limits"`
 }
 
 // DatabaseConfig 数据库配置 - 定义数据库连接参数
 type DatabaseConfig struct {
-	Type     string `json:"type"`     // 数据库类型，如 "mysql", "postgres", "memory"（内存数据库）
+	Type     string `json:"type"`     // 数据库类型，如 "memory"（内存数据库）, "file"（JSON文件）, "sqlite", "postgres", "mysql", "redis"
 	Host     string `json:"host"`     // 数据库服务器主机名或IP地址
 	Port     int    `json:"port"`     // 数据库服务器端口号
-	Name     string `json:"name"`     // 数据库名称
+	Name     string `json:"name"`     // 数据库名称，redis 中作为键前缀
 	Username string `json:"username"` // 数据库用户名
 	Password string `json:"password"` // 数据库密码
+	Path     string `json:"path"`     // 数据文件路径，file、sqlite 等文件型存储使用，如 "data/xstreamtool.db"
+	SSLMode  string `json:"ssl_mode"` // PostgreSQL 的 SSL 模式，如 "disable", "require"
+
+	// 连接池配置（PostgreSQL、MySQL 等网络数据库使用，redis 只使用 MaxOpenConns 作为连接池大小）
+	MaxOpenConns    int `json:"max_open_conns"`    // 最大打开连接数
+	MaxIdleConns    int `json:"max_idle_conns"`    // 最大空闲连接数
+	ConnMaxLifetime int `json:"conn_max_lifetime"` // 连接最长存活时间（秒）
+
+	// 容量上限（memory、file 等数据保存在进程内存中的存储使用），避免数据无限增长直到进程被 OOM 终止
+	MaxItems    int    `json:"max_items"`    // 最多保存的待办事项数，0表示不限制
+	MaxBytes    int64  `json:"max_bytes"`    // 待办事项和附属数据的最大估算字节数，0表示不限制
+	LimitPolicy string `json:"limit_policy"` // 达到上限后创建事项时的处理：reject（拒绝创建）或 evict_completed（淘汰最早完成的事项）
+
+	// 操作日志（memory 存储使用），进程崩溃或重启后从检查点快照和之后的操作日志恢复数据
+	WALDir           string `json:"wal_dir"`           // 操作日志和检查点快照所在目录，为空表示不启用，数据只保存在内存中
+	SnapshotInterval int    `json:"snapshot_interval"` // 写入检查点的间隔（秒），0表示使用默认值（300秒）
+
+	// 读缓存（适合 PostgreSQL、MySQL 等较慢的网络数据库），缓存按ID读取的事项和全部事项列表，通过本实例的写操作会使缓存失效
+	CacheTTL        int `json:"cache_ttl"`         // 缓存有效期（毫秒），0表示不启用；多个实例共用数据库时，其它实例的修改最多延迟这么久才能读到
+	CacheMaxEntries int `json:"cache_max_entries"` // 按ID缓存的最多事项数，0表示使用默认值（1000）
+
+	// 监控：记录存储每个方法的调用次数、耗时和错误率，通过 /debug/vars 的 store_metrics 导出（统计的是后端本身，不包括读缓存命中）
+	Instrument bool `json:"instrument"` // 是否记录存储方法的调用统计
+
+	// 全文索引：在内存中为标题、描述和分类建立 bleve 索引，支持分词、按相关度排序和模糊搜索（?q= 与 ?sort=relevance）
+	SearchIndex     bool `json:"search_index"`     // 是否启用全文索引，启动时为全部事项建立索引
+	SearchFuzziness int  `json:"search_fuzziness"` // 模糊匹配允许的编辑距离（0-2），0表示只做精确匹配
+
+	// 初始数据（memory、file 存储首次运行、还没有任何数据时写入），默认写入三条内置的示例数据
+	NoSeed   bool   `json:"no_seed"`   // 不写入任何初始数据，生产环境建议启用
+	SeedFile string `json:"seed_file"` // 从 JSON 文件加载初始数据代替内置的示例数据，格式为备份快照或待办事项数组
+
+	// 变更日志：待办事项的每一次修改（包括规则、归档、回收站等内部修改）都在同一个事务中记录为事件发件箱中的事件，
+	// 集成方通过 /api/admin/events?after= 从上次处理的序号之后可靠地读取全部修改
+	Journal bool `json:"journal"` // 是否启用变更日志模式，未启用时只有 API 请求产生的修改写入事件
+
+	// 副本：写操作同步写入本存储后异步复制到副本（例如 memory 主存储加 file 备份），启动时先把全部数据同步到副本。
+	// 副本的数据在启动时被主存储的数据覆盖，内存主存储需要配合 wal_dir 使用，否则重启后副本只剩示例数据
+	Replicas      []DatabaseConfig `json:"replicas"`        // 副本的连接配置，格式与 database 相同（副本本身的 replicas 被忽略）
+	ReplicaMaxLag int              `json:"replica_max_lag"` // 副本允许落后的最长时间（秒），超过后 /api/admin/replication 报告异常，0表示使用默认值（30秒）
+
+	// 降级模式：PostgreSQL、MySQL、Redis 等后端不可用时，读请求返回内存中只读副本的数据（响应带有 X-Store-Degraded 头），
+	// 写请求返回 503 和 Retry-After，后端恢复后自动退出降级模式并写入 store.recovered 事件
+	DegradedMode    bool `json:"degraded_mode"`    // 是否启用降级模式
+	HealthInterval  int  `json:"health_interval"`  // 检查后端是否可用的间隔（秒），也是 Retry-After 的值，0表示使用默认值（5秒）
+	FallbackRefresh int  `json:"fallback_refresh"` // 从后端全量刷新只读副本的间隔（秒），0表示使用默认值（60秒）
 }
 
 // LoggingConfig 日志配置 - 定义日志记录的行为和参数
@@ -41,6 +111,158 @@ type LoggingConfig struct {
 	MaxAge     int    `json:"max_age"`     // 日志文件保留的最大天数
 }
 
+// ViewConfig 列表视图配置 - 定义列表接口和网页的默认排序与显示方式
+// 每个请求都可以通过查询参数 ?sort=&order=&per_page=&show_completed= 覆盖这些默认值
+type ViewConfig struct {
+	SortField     string `json:"sort_field"`     // 默认排序字段：id, title, priority, effective_priority, due_date, created_at, updated_at, position
+	SortOrder     string `json:"sort_order"`     // 默认排序方向：asc 或 desc
+	PageSize      int    `json:"page_size"`      // 默认每页数量，0表示不分页
+	ShowCompleted bool   `json:"show_completed"` // 默认列表中是否包含已完成的事项
+	Timezone      string `json:"timezone"`       // 今天、近期视图划分日期使用的默认时区（IANA 名称，如 Asia/Shanghai），为空时使用服务器时区
+}
+
+// PriorityAgingConfig 优先级老化配置 - 定义未完成事项的有效优先级如何随截止日期提升
+// 有效优先级只用于响应中的 effective_priority 字段和 ?sort=effective_priority 排序，不会修改事项本身的优先级
+type PriorityAgingConfig struct {
+	Enabled         bool `json:"enabled"`           // 是否启用优先级老化
+	DueSoonDays     int  `json:"due_soon_days"`     // 距截止日期不超过多少天时开始提升
+	DueSoonBoost    int  `json:"due_soon_boost"`    // 临近截止日期时提升的级数
+	OverdueBoost    int  `json:"overdue_boost"`     // 过期时提升的级数
+	OverdueStepDays int  `json:"overdue_step_days"` // 过期后每隔多少天再提升一级，0表示不再继续提升
+	MaxPriority     int  `json:"max_priority"`      // 有效优先级上限，0表示不限制
+}
+
+// UIConfig 网页界面配置 - 定义网页的主题、日期格式和品牌
+// 自托管时只需修改配置即可定制界面，无需修改代码
+type UIConfig struct {
+	Theme       string `json:"theme"`        // 主题：light（浅色）或 dark（深色）
+	Locale      string `json:"locale"`       // 日期格式使用的语言区域：zh-CN, en-US, en-GB, de-DE, ja-JP
+	BrandName   string `json:"brand_name"`   // 显示在页面标题和首页的名称
+	AccentColor string `json:"accent_color"` // 主色调（按钮、链接等），十六进制颜色如 "#007bff"，为空时使用主题默认值
+	CustomCSS   string `json:"custom_css"`   // 自定义样式表文件路径，内容追加在主题样式之后，为空表示不使用
+
+	SwaggerUIURL string `json:"swagger_ui_url"` // API 文档页面加载 Swagger UI（swagger-ui-dist）静态资源的地址，无法访问公网时可改为内网地址
+}
+
+// SchedulerConfig 定时任务配置 - 定义清理等后台定时任务的调度方式
+// 多个实例共用 SQL 或 Redis 存储时，每个任务每个间隔只会在其中一个实例上执行
+type SchedulerConfig struct {
+	Enabled bool   `json:"enabled"` // 是否在本实例上运行定时任务
+	NodeID  string `json:"node_id"` // 实例标识，作为分布式锁的持有者，为空时使用 主机名-进程号
+}
+
+// MirrorConfig 流量镜像配置 - 把一部分API请求复制发送到另一个服务
+// 用于在切换前用生产流量验证新的存储后端或版本；镜像请求在原请求处理完成后异步发送，响应被丢弃，不影响原请求
+type MirrorConfig struct {
+	Enabled     bool    `json:"enabled"`       // 是否启用流量镜像
+	TargetURL   string  `json:"target_url"`    // 镜像目标的基础地址，如 "http://staging:8080"
+	Percent     float64 `json:"percent"`       // 镜像的请求比例（0-100）
+	Timeout     int     `json:"timeout"`       // 镜像请求超时时间（毫秒）
+	MaxInFlight int     `json:"max_in_flight"` // 同时进行的镜像请求上限，超出时放弃镜像
+}
+
+// NotificationConfig 邮件通知配置 - 定义发给待办事项关注者（可以是没有账号的外部协作者）的提醒和完成通知
+// 通知由定时任务发送，未启用时只记录日志不发送邮件
+type NotificationConfig struct {
+	Enabled      bool   `json:"enabled"`       // 是否通过 SMTP 发送邮件
+	SMTPHost     string `json:"smtp_host"`     // SMTP 服务器地址
+	SMTPPort     int    `json:"smtp_port"`     // SMTP 服务器端口，服务器支持时自动使用 STARTTLS
+	Username     string `json:"username"`      // SMTP 用户名，为空时不认证
+	Password     string `json:"password"`      // SMTP 密码
+	From         string `json:"from"`          // 发件人地址
+	BaseURL      string `json:"base_url"`      // 服务对外访问的基础地址，用于生成邮件中的链接，如 "https://todo.example.com"
+	RemindBefore int    `json:"remind_before"` // 截止前多少小时发送提醒
+	MaxPerHour   int    `json:"max_per_hour"`  // 每个邮箱每小时最多收到的邮件数，超出的通知推迟到下一个小时发送
+	MaxWatchers  int    `json:"max_watchers"`  // 每个待办事项最多的关注者数
+}
+
+// WebhookConfig webhook 配置 - 定义如何把事件投递给通过 /api/webhooks 注册的订阅
+// 投递由定时任务执行：从事件发件箱读取新事件加入投递队列，发送失败的投递按 retry_delay、2×retry_delay、4×retry_delay…… 的间隔重试
+type WebhookConfig struct {
+	Interval       int `json:"interval"`        // 投递间隔（秒），事件最多延迟这么久发出
+	Timeout        int `json:"timeout"`         // 每次发送的超时时间（秒）
+	MaxAttempts    int `json:"max_attempts"`    // 每个投递最多发送的次数，用完后标记为失败
+	RetryDelay     int `json:"retry_delay"`     // 第一次重试前等待的时间（秒），之后每次翻倍，最长1小时
+	KeepDeliveries int `json:"keep_deliveries"` // 每个订阅保留的已结束投递记录数
+}
+
+// BackupConfig 备份配置 - 定义定期把全部数据导出到备份文件的方式
+// 备份文件名包含导出时间（UTC），如 backup-20260101-030000.json；可通过 POST /api/admin/restore 从备份文件恢复
+type BackupConfig struct {
+	Enabled  bool   `json:"enabled"`  // 是否定期备份
+	Dir      string `json:"dir"`      // 备份文件所在目录
+	Interval int    `json:"interval"` // 备份间隔（分钟）
+	Format   string `json:"format"`   // 备份格式：json（快照格式）或 sql（SQLite 方言的SQL脚本）
+	Keep     int    `json:"keep"`     // 保留最近的备份文件数，0表示全部保留
+}
+
+// ArchiveConfig 冷存储归档配置 - 定义如何把已完成的旧事项导出到压缩的归档文件后从存储中移除，而不是直接删除
+// 归档文件名包含归档时间（UTC），如 archive-20260101-030000.jsonl.gz，每行一个待办事项；
+// 可通过 GET /api/archive 搜索归档的事项，POST /api/archive/{id}/rehydrate 按原ID恢复到存储
+type ArchiveConfig struct {
+	Enabled   bool     `json:"enabled"`    // 是否定期归档
+	AfterDays int      `json:"after_days"` // 完成后超过多少天（按最后修改时间计算）的事项被归档
+	Interval  int      `json:"interval"`   // 归档间隔（分钟）
+	Storage   string   `json:"storage"`    // 归档文件的存储位置：local（本地目录）或 s3（S3 兼容的对象存储）
+	Dir       string   `json:"dir"`        // 本地归档目录，storage 为 local 时使用
+	S3        S3Config `json:"s3"`         // 对象存储配置，storage 为 s3 时使用
+}
+
+// TrashConfig 回收站配置 - 定义删除的待办事项是否先移入回收站，以及在回收站中保留多久
+// 启用后删除的事项在保留期内可以通过 POST /api/trash/{id}/restore 按原ID恢复，超过保留期后由定时任务永久删除；
+// 管理员可以延长单个事项的保留期，或者不等到期直接永久删除
+type TrashConfig struct {
+	Enabled       bool `json:"enabled"`        // 是否启用回收站，未启用时删除立即生效
+	RetentionDays int  `json:"retention_days"` // 事项在回收站中保留的天数
+	Interval      int  `json:"interval"`       // 清理间隔（分钟）
+}
+
+// AuditConfig 审计日志配置 - 定义是否记录每次创建、修改、完成和删除待办事项的操作者和前后内容
+// 审计日志只追加不修改，可通过 GET /api/audit 按事项、操作者、操作类型和时间查询
+type AuditConfig struct {
+	Enabled     bool   `json:"enabled"`      // 是否记录审计日志，启用后每次修改都在存储的事务中执行，修改和审计记录同时生效
+	ActorHeader string `json:"actor_header"` // 操作者所在的请求头，通常由前面的认证代理设置；请求中没有时操作者记为 anonymous
+}
+
+// AdminConfig 管理接口配置 - 定义查看和修改配置、恢复备份等高风险管理接口的访问令牌
+// 请求需要带有 Authorization: Bearer <token> 请求头；未设置令牌时这些接口不可用，返回 404
+type AdminConfig struct {
+	Token string `json:"token"` // 管理接口的访问令牌，为空表示不启用这些接口
+}
+
+// HealthConfig 健康检查配置 - 定义 GET /api/health 中各组件降级（degraded）和异常（unhealthy）的阈值
+// 降级的组件不影响 /readyz 的就绪状态，异常的组件会让实例被摘除
+type HealthConfig struct {
+	SlowStoreMs        int `json:"slow_store_ms"`         // 存储检查耗时超过多少毫秒时降级，0表示不检查
+	MinDiskFreeMB      int `json:"min_disk_free_mb"`      // 日志目录所在磁盘剩余空间低于多少MB时降级，0表示不检查
+	CriticalDiskFreeMB int `json:"critical_disk_free_mb"` // 日志目录所在磁盘剩余空间低于多少MB时异常，0表示不检查
+	MaxHeapMB          int `json:"max_heap_mb"`           // 堆内存上限（MB），达到90%时降级；0表示使用 GOMEMLIMIT，都没有设置时不检查
+}
+
+// S3Config 对象存储配置 - 支持 AWS S3 以及 MinIO 等兼容 S3 接口的服务
+type S3Config struct {
+	Endpoint  string `json:"endpoint"`   // 服务地址，如 "http://localhost:9000"，为空时使用 AWS S3
+	Region    string `json:"region"`     // 区域，如 "us-east-1"
+	Bucket    string `json:"bucket"`     // 存储桶名称
+	Prefix    string `json:"prefix"`     // 归档文件的键前缀，如 "xstream/archive/"
+	AccessKey string `json:"access_key"` // 访问密钥ID，为空时使用环境变量等默认凭证
+	SecretKey string `json:"secret_key"` // 访问密钥
+	PathStyle bool   `json:"path_style"` // 是否使用路径形式的地址（MinIO 等通常需要）
+}
+
+// NextActionConfig 下一步推荐配置 - 定义 GET /api/todos/next 如何为未完成的事项评分
+// 得分 = 有效优先级 × priority_weight + 截止临近程度（0-1）× due_weight + 已创建天数 × age_weight − 被阻塞时的 blocked_penalty，
+// 返回得分最高的事项
+type NextActionConfig struct {
+	PriorityWeight float64 `json:"priority_weight"` // 每级有效优先级（含优先级老化）的得分
+	DueWeight      float64 `json:"due_weight"`      // 截止临近程度的最高得分，已过期时得满分
+	DueWindowDays  int     `json:"due_window_days"` // 距截止日期多少天内开始加分，越近得分越高
+	AgeWeight      float64 `json:"age_weight"`      // 每天的已创建时间得分，避免旧事项一直排不上
+	MaxAgeDays     int     `json:"max_age_days"`    // 已创建时间最多计算的天数
+	BlockedPenalty float64 `json:"blocked_penalty"` // 阻塞依赖中等待的事项尚未完成时扣除的分数
+	SkipMinutes    int     `json:"skip_minutes"`    // 跳过后默认推迟的时间（分钟）
+}
+
 // LoadConfig 加载配置
 // 这个函数尝试从config.json文件加载配置，如果文件不存在或读取失败，则使用默认配置
 // 工作流程：
@@ -54,10 +276,16 @@ func LoadConfig() *Config {
 	// 这是当没有配置文件或配置文件读取失败时使用的配置
 	config := &Config{
 		Server: ServerConfig{
-			Port:           "8080",        // 默认监听8080端口
-			Debug:          false,         // 默认关闭调试模式
-			AllowedOrigins: []string{"*"}, // 默认允许所有来源（开发环境方便，生产环境应限制）
-			RateLimit:      100,           // 默认每秒100个请求的速率限制
+			Port:           "8080",                                                                                                            // 默认监听8080端口
+			Debug:          false,                                                                                                             // 默认关闭调试模式
+			AllowedOrigins: []string{"*"},                                                                                                     // 默认允许所有来源（开发环境方便，生产环境应限制）
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},                                                                 // 默认允许API使用的全部方法
+			AllowedHeaders: []string{"Content-Type", "Authorization", "If-Match", "If-None-Match", "If-Unmodified-Since", "X-Requested-With"}, // 默认允许的请求头
+			CORSMaxAge:     600,                                                                                                               // 默认缓存预检结果10分钟
+			RateLimit:      100,                                                                                                               // 默认每秒100个请求的速率限制
+			MaxBodyBytes:   4 << 20,                                                                                                           // 默认4MB，足够导入或恢复数千个待办事项
+			Hypermedia:     false,                                                                                                             // 默认不返回超媒体链接，客户端可通过 Accept: application/hal+json 按需获取
+			ShutdownDelay:  5,                                                                                                                 // 默认等待5秒，大于 Kubernetes 默认的就绪检查间隔
 		},
 		Database: DatabaseConfig{
 			Type:     "memory",      // 默认使用内存数据库（无需安装外部数据库）
@@ -66,6 +294,30 @@ func LoadConfig() *Config {
 			Name:     "xstreamtool", // 默认数据库名称
 			Username: "",            // 默认无用户名
 			Password: "",            // 默认无密码
+			Path:     "",            // 默认为空，由各文件型存储决定默认路径
+			SSLMode:  "disable",     // 默认不使用SSL（本地开发环境）
+
+			MaxOpenConns:    10,  // 默认最多10个连接
+			MaxIdleConns:    5,   // 默认保留5个空闲连接
+			ConnMaxLifetime: 300, // 默认连接最长存活5分钟
+
+			MaxItems:    0,        // 默认不限制事项数
+			MaxBytes:    0,        // 默认不限制占用空间
+			LimitPolicy: "reject", // 默认达到上限后拒绝创建
+
+			WALDir:           "", // 默认不启用操作日志
+			SnapshotInterval: 0,  // 默认每5分钟写入一次检查点
+
+			CacheTTL:        0, // 默认不启用读缓存
+			CacheMaxEntries: 0, // 默认最多缓存1000个事项
+
+			Instrument: false, // 默认不记录存储方法的调用统计
+
+			SearchIndex:     false, // 默认不启用全文索引，搜索只做子串匹配
+			SearchFuzziness: 1,     // 默认允许一个字符的差异
+
+			NoSeed:   false, // 默认首次运行时写入示例数据
+			SeedFile: "",    // 默认使用内置的示例数据
 		},
 		Logging: LoggingConfig{
 			Level:      "info",         // 默认日志级别：info（记录info及以上级别）
@@ -74,6 +326,100 @@ func LoadConfig() *Config {
 			MaxBackups: 5,              // 默认保留5个旧日志文件
 			MaxAge:     30,             // 默认日志文件保留30天
 		},
+		View: ViewConfig{
+			SortField:     "created_at", // 默认按创建时间排序
+			SortOrder:     "desc",       // 默认倒序（最新的在前）
+			PageSize:      0,            // 默认不分页，返回全部数据
+			ShowCompleted: true,         // 默认显示已完成的事项
+			Timezone:      "",           // 默认使用服务器时区，请求可通过 ?tz= 或 X-Timezone 请求头指定
+		},
+		PriorityAging: PriorityAgingConfig{
+			Enabled:         false, // 默认不启用，有效优先级等于事项本身的优先级
+			DueSoonDays:     3,     // 默认截止前3天开始提升
+			DueSoonBoost:    1,     // 默认临近截止时提升1级
+			OverdueBoost:    2,     // 默认过期时提升2级
+			OverdueStepDays: 7,     // 默认过期后每周再提升1级
+			MaxPriority:     5,     // 默认不超过最高优先级5
+		},
+		UI: UIConfig{
+			Theme:     "light",          // 默认浅色主题
+			Locale:    "zh-CN",          // 默认中文日期格式
+			BrandName: "xStreamTool Go", // 默认名称
+
+			SwaggerUIURL: "https://unpkg.com/swagger-ui-dist@5", // 默认从 CDN 加载
+		},
+		Scheduler: SchedulerConfig{
+			Enabled: true, // 默认运行定时任务
+			NodeID:  "",   // 默认自动生成实例标识
+		},
+		Mirror: MirrorConfig{
+			Enabled:     false, // 默认不镜像
+			TargetURL:   "",    // 默认无镜像目标
+			Percent:     10,    // 启用后默认镜像10%的请求
+			Timeout:     5000,  // 默认镜像请求5秒超时
+			MaxInFlight: 50,    // 默认最多同时50个镜像请求
+		},
+		Notifications: NotificationConfig{
+			Enabled:      false,                   // 默认不发送邮件
+			SMTPHost:     "localhost",             // 默认本机 SMTP 服务器
+			SMTPPort:     587,                     // 默认邮件提交端口
+			From:         "noreply@localhost",     // 默认发件人
+			BaseURL:      "http://localhost:8080", // 默认本机地址
+			RemindBefore: 24,                      // 默认截止前24小时提醒
+			MaxPerHour:   5,                       // 默认每个邮箱每小时最多5封
+			MaxWatchers:  10,                      // 默认每个事项最多10个关注者
+		},
+		Webhooks: WebhookConfig{
+			Interval:       10,  // 默认每10秒投递一次
+			Timeout:        5,   // 默认5秒超时
+			MaxAttempts:    6,   // 默认最多发送6次
+			RetryDelay:     30,  // 默认30秒后第一次重试
+			KeepDeliveries: 100, // 默认每个订阅保留最近100条投递记录
+		},
+		NextAction: NextActionConfig{
+			PriorityWeight: 10,  // 默认每级优先级10分
+			DueWeight:      30,  // 默认截止临近最多30分
+			DueWindowDays:  7,   // 默认截止前7天开始加分
+			AgeWeight:      0.5, // 默认每天0.5分
+			MaxAgeDays:     30,  // 默认最多计算30天
+			BlockedPenalty: 100, // 默认被阻塞的事项排在所有未阻塞的事项之后
+			SkipMinutes:    60,  // 默认跳过1小时
+		},
+		Backup: BackupConfig{
+			Enabled:  false,     // 默认不定期备份
+			Dir:      "backups", // 默认备份到当前目录下的 backups 目录
+			Interval: 1440,      // 默认每天备份一次
+			Format:   "json",    // 默认使用快照格式
+			Keep:     7,         // 默认保留最近7个备份
+		},
+		Archive: ArchiveConfig{
+			Enabled:   false,     // 默认不归档，已完成的事项一直保留在存储中
+			AfterDays: 90,        // 默认归档完成90天以上的事项
+			Interval:  1440,      // 默认每天归档一次
+			Storage:   "local",   // 默认归档到本地目录
+			Dir:       "archive", // 默认归档到当前目录下的 archive 目录
+			S3: S3Config{
+				Region: "us-east-1", // 默认区域
+			},
+		},
+		Trash: TrashConfig{
+			Enabled:       false, // 默认不启用回收站，删除立即生效
+			RetentionDays: 30,    // 默认保留30天
+			Interval:      60,    // 默认每小时清理一次
+		},
+		Audit: AuditConfig{
+			Enabled:     false,              // 默认不记录审计日志
+			ActorHeader: "X-Forwarded-User", // 默认使用认证代理设置的用户名
+		},
+		Admin: AdminConfig{
+			Token: "", // 默认不启用高风险的管理接口
+		},
+		Health: HealthConfig{
+			SlowStoreMs:        500, // 默认存储检查超过0.5秒时降级
+			MinDiskFreeMB:      500, // 默认剩余空间低于500MB时降级
+			CriticalDiskFreeMB: 50,  // 默认剩余空间低于50MB时异常
+			MaxHeapMB:          0,   // 默认使用 GOMEMLIMIT
+		},
 	}
 
 	// 尝试从配置文件加载
@@ -103,6 +449,40 @@ func LoadConfig() *Config {
 	return config
 }
 
+// redactedValue 脱敏后的敏感配置项
+const redactedValue = "******"
+
+// Redacted 返回隐藏了密码、密钥和访问令牌的副本，用于通过接口查看当前配置
+// 未设置的敏感项保持为空，便于区分“没有设置”和“已隐藏”；副本不与原配置共用可能包含敏感项的切片
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Database = c.Database.redacted()
+	redactSecret(&redacted.Notifications.Password)
+	redactSecret(&redacted.Archive.S3.SecretKey)
+	redactSecret(&redacted.Admin.Token)
+	return &redacted
+}
+
+// redacted 返回隐藏了密码的数据库配置副本，副本（replicas）逐个复制并隐藏
+func (d DatabaseConfig) redacted() DatabaseConfig {
+	redactSecret(&d.Password)
+	if d.Replicas != nil {
+		replicas := make([]DatabaseConfig, len(d.Replicas))
+		for i, replica := range d.Replicas {
+			replicas[i] = replica.redacted()
+		}
+		d.Replicas = replicas
+	}
+	return d
+}
+
+// redactSecret 已设置的敏感项替换为 ******
+func redactSecret(secret *string) {
+	if *secret != "" {
+		*secret = redactedValue
+	}
+}
+
 // SaveConfig 保存配置到文件
 // 这个函数将当前的配置对象保存到config.json文件中
 // 通常用于：
@@ -0,0 +1,90 @@
+// Package export 把待办事项导出为表格文件
+// 支持 CSV（字段名与 JSON 一致，便于其它程序读取）和 XLSX（面向直接在 Excel 中查看的用户，
+// 每个分类一个工作表，带筛选表头和按优先级着色的单元格）
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// 导出格式
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// 各格式的媒体类型
+const (
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// IsValidFormat 判断导出格式是否受支持
+func IsValidFormat(format string) bool {
+	switch format {
+	case FormatJSON, FormatCSV, FormatXLSX:
+		return true
+	}
+	return false
+}
+
+// csvHeader CSV 的表头，与 models.Todo 的 JSON 字段名一致，地点拆分为三列
+var csvHeader = []string{
+	"id", "title", "description", "completed", "priority", "category",
+	"due_date", "created_at", "updated_at", "location_name", "lat", "lng",
+}
+
+// WriteCSV 以 CSV 格式写出待办事项
+// 时间为 RFC 3339 格式，没有截止日期或坐标时为空
+func WriteCSV(w io.Writer, todos []*models.Todo) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, todo := range todos {
+		var locationName, lat, lng string
+		if todo.Location != nil {
+			locationName = todo.Location.Name
+			if todo.Location.Lat != nil && todo.Location.Lng != nil {
+				lat = strconv.FormatFloat(*todo.Location.Lat, 'f', -1, 64)
+				lng = strconv.FormatFloat(*todo.Location.Lng, 'f', -1, 64)
+			}
+		}
+
+		record := []string{
+			strconv.Itoa(todo.ID),
+			todo.Title,
+			todo.Description,
+			strconv.FormatBool(todo.Completed),
+			strconv.Itoa(int(todo.Priority)),
+			todo.Category,
+			formatTime(todo.DueAt()),
+			formatTime(todo.CreatedAt),
+			formatTime(todo.UpdatedAt),
+			locationName,
+			lat,
+			lng,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatTime 把时间格式化为 RFC 3339，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
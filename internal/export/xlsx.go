@@ -0,0 +1,289 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// uncategorizedSheet 没有分类的事项所在的工作表，排在最后
+const uncategorizedSheet = "未分类"
+
+// maxSheetName Excel 工作表名称的最大长度（字符数）
+const maxSheetName = 31
+
+// xlsxColumn 工作表中的一列
+type xlsxColumn struct {
+	title string  // 表头
+	width float64 // 列宽（字符数）
+}
+
+// xlsxColumns 工作表的列，顺序与 xlsxRow 一致
+var xlsxColumns = []xlsxColumn{
+	{"ID", 8},
+	{"标题", 30},
+	{"描述", 50},
+	{"状态", 10},
+	{"优先级", 8},
+	{"截止日期", 18},
+	{"创建时间", 18},
+	{"更新时间", 18},
+	{"地点", 24},
+}
+
+// 优先级所在的列和时间列的范围（从1开始）
+const (
+	priorityColumn  = 5
+	firstTimeColumn = 6
+	lastTimeColumn  = 8
+)
+
+// priorityColors 优先级单元格的填充色和文字颜色，与网页中优先级徽章的颜色一致
+var priorityColors = map[int][2]string{
+	5: {"DC3545", "FFFFFF"}, // 紧急：红色
+	4: {"FFC107", "212529"}, // 高：黄色
+	3: {"007BFF", "FFFFFF"}, // 中：蓝色
+	2: {"6C757D", "FFFFFF"}, // 低：灰色
+	1: {"6C757D", "FFFFFF"}, // 最低：灰色
+}
+
+// xlsxStyles 工作簿中使用的样式ID
+type xlsxStyles struct {
+	header   int
+	dateTime int
+	priority map[int]int
+}
+
+// WriteXLSX 以 Excel 工作簿格式写出待办事项
+// 每个分类一个工作表（没有分类的事项在"未分类"工作表中），事项顺序与传入的顺序一致；
+// 表头冻结并带有自动筛选，优先级按级别着色，时间以本地时区的 Excel 日期时间保存
+func WriteXLSX(w io.Writer, todos []*models.Todo) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+
+	groups, names := groupByCategory(todos)
+	if len(names) == 0 {
+		// 没有事项时仍然输出带表头的空工作表
+		names = []string{uncategorizedSheet}
+	}
+
+	sheets := newSheetNamer()
+	for i, category := range names {
+		sheet := sheets.name(category)
+		if i == 0 {
+			if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+		if err := writeSheet(f, sheet, groups[category], styles); err != nil {
+			return fmt.Errorf("写入工作表 %s 失败: %w", sheet, err)
+		}
+	}
+
+	f.SetActiveSheet(0)
+	return f.Write(w)
+}
+
+// newXLSXStyles 创建表头、时间和各优先级的样式
+func newXLSXStyles(f *excelize.File) (*xlsxStyles, error) {
+	styles := &xlsxStyles{priority: make(map[int]int, len(priorityColors))}
+	var err error
+
+	styles.header, err = f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"E9ECEF"}},
+		Border: []excelize.Border{{Type: "bottom", Color: "ADB5BD", Style: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dateFormat := "yyyy-mm-dd hh:mm"
+	styles.dateTime, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return nil, err
+	}
+
+	for priority, colors := range priorityColors {
+		styles.priority[priority], err = f.NewStyle(&excelize.Style{
+			Font:      &excelize.Font{Bold: true, Color: colors[1]},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{colors[0]}},
+			Alignment: &excelize.Alignment{Horizontal: "center"},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return styles, nil
+}
+
+// groupByCategory 按分类分组，返回分组和排好序的分类名称（按名称排序，未分类在最后）
+func groupByCategory(todos []*models.Todo) (map[string][]*models.Todo, []string) {
+	groups := make(map[string][]*models.Todo)
+	var names []string
+	for _, todo := range todos {
+		category := todo.Category
+		if category == "" {
+			category = uncategorizedSheet
+		}
+		if _, exists := groups[category]; !exists {
+			names = append(names, category)
+		}
+		groups[category] = append(groups[category], todo)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == uncategorizedSheet) != (names[j] == uncategorizedSheet) {
+			return names[j] == uncategorizedSheet
+		}
+		return names[i] < names[j]
+	})
+	return groups, names
+}
+
+// writeSheet 写入一个工作表：表头、数据、列宽、冻结表头和自动筛选
+func writeSheet(f *excelize.File, sheet string, todos []*models.Todo, styles *xlsxStyles) error {
+	header := make([]interface{}, len(xlsxColumns))
+	for i, column := range xlsxColumns {
+		header[i] = column.title
+		name, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetColWidth(sheet, name, name, column.width); err != nil {
+			return err
+		}
+	}
+	if err := f.SetSheetRow(sheet, "A1", &header); err != nil {
+		return err
+	}
+	lastColumn, _ := excelize.ColumnNumberToName(len(xlsxColumns))
+	if err := f.SetCellStyle(sheet, "A1", lastColumn+"1", styles.header); err != nil {
+		return err
+	}
+
+	for i, todo := range todos {
+		row := i + 2
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		values := xlsxRow(todo)
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+
+		first, _ := excelize.CoordinatesToCellName(firstTimeColumn, row)
+		last, _ := excelize.CoordinatesToCellName(lastTimeColumn, row)
+		if err := f.SetCellStyle(sheet, first, last, styles.dateTime); err != nil {
+			return err
+		}
+		if style, exists := styles.priority[int(todo.Priority)]; exists {
+			cell, _ := excelize.CoordinatesToCellName(priorityColumn, row)
+			if err := f.SetCellStyle(sheet, cell, cell, style); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	lastRow := len(todos) + 1
+	return f.AutoFilter(sheet, fmt.Sprintf("A1:%s%d", lastColumn, lastRow), nil)
+}
+
+// xlsxRow 待办事项在工作表中的一行，顺序与 xlsxColumns 一致
+func xlsxRow(todo *models.Todo) []interface{} {
+	status := "进行中"
+	if todo.Completed {
+		status = "已完成"
+	}
+
+	location := ""
+	if todo.Location != nil {
+		location = todo.Location.Name
+		if location == "" && todo.Location.Lat != nil && todo.Location.Lng != nil {
+			location = fmt.Sprintf("%g, %g", *todo.Location.Lat, *todo.Location.Lng)
+		}
+	}
+
+	return []interface{}{
+		todo.ID,
+		todo.Title,
+		todo.Description,
+		status,
+		todo.Priority,
+		xlsxTime(todo.DueAt()),
+		xlsxTime(todo.CreatedAt),
+		xlsxTime(todo.UpdatedAt),
+		location,
+	}
+}
+
+// xlsxTime 把时间转换为本地时区，零值返回nil（空单元格）
+func xlsxTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Local()
+}
+
+// sheetNamer 生成合法且不重复的工作表名称
+type sheetNamer struct {
+	used map[string]bool // 已使用的名称（小写，Excel 比较名称时不区分大小写）
+}
+
+// newSheetNamer 创建工作表名称生成器
+func newSheetNamer() *sheetNamer {
+	return &sheetNamer{used: make(map[string]bool)}
+}
+
+// name 把分类名称转换为工作表名称
+// 替换 Excel 不允许的字符，截断到31个字符，与已有名称重复时追加序号
+func (n *sheetNamer) name(category string) string {
+	base := strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '\\', '/', '?', '*', '[', ']':
+			return '_'
+		}
+		return r
+	}, category)
+	base = strings.Trim(base, "'")
+	if base == "" {
+		base = "_"
+	}
+
+	name := truncate(base, maxSheetName)
+	for i := 2; n.used[strings.ToLower(name)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		name = truncate(base, maxSheetName-len(suffix)) + suffix
+	}
+	n.used[strings.ToLower(name)] = true
+	return name
+}
+
+// truncate 把字符串截断到最多max个字符
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
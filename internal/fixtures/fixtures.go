@@ -0,0 +1,407 @@
+// Package fixtures 声明式的测试数据
+// 用一个 YAML 文件描述用户、项目和待办事项，时间可以写成相对于当前时间的 "-2d"、"+1w"，
+// 加载到任意存储后端后得到结构相同的数据集，演示、截图和集成测试共用同一份数据
+package fixtures
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures 测试数据集
+type Fixtures struct {
+	Now      string    `yaml:"now,omitempty"` // 相对时间的参照时间，为空时使用加载时的当前时间；固定后每次加载的数据完全相同
+	Users    []User    `yaml:"users"`         // 用户，待办事项的关注者引用用户名称
+	Projects []Project `yaml:"projects"`      // 项目，对应待办事项的分类
+	Todos    []Todo    `yaml:"todos"`         // 待办事项，按顺序分配ID（从1开始）
+}
+
+// User 用户
+type User struct {
+	Name  string `yaml:"name"`  // 名称，在数据集内唯一
+	Email string `yaml:"email"` // 邮箱地址
+}
+
+// Project 项目，加载后成为分类，并保存为该分类的默认设置
+type Project struct {
+	Name        string `yaml:"name"`                  // 名称，即分类名称
+	Priority    int    `yaml:"priority,omitempty"`    // 默认优先级（1-5，0表示不设置）
+	Description string `yaml:"description,omitempty"` // 默认描述模板
+}
+
+// Todo 待办事项
+type Todo struct {
+	Key         string           `yaml:"key,omitempty"`         // 数据集内的引用名，关联链接和阻塞依赖通过它指向其它事项
+	Title       string           `yaml:"title"`                 // 标题（必填）
+	Description string           `yaml:"description,omitempty"` // 描述
+	Project     string           `yaml:"project,omitempty"`     // 所属项目
+	Priority    int              `yaml:"priority,omitempty"`    // 优先级（1-5），为0时使用项目的默认优先级
+	Completed   bool             `yaml:"completed,omitempty"`   // 是否已完成
+	Due         string           `yaml:"due,omitempty"`         // 截止时间
+	Created     string           `yaml:"created,omitempty"`     // 创建时间，默认为参照时间
+	Updated     string           `yaml:"updated,omitempty"`     // 更新时间，默认与创建时间相同
+	Location    *models.Location `yaml:"location,omitempty"`    // 地点
+	Watchers    []string         `yaml:"watchers,omitempty"`    // 关注者的用户名称
+	Links       []Link           `yaml:"links,omitempty"`       // 关联链接
+	BlockedBy   []string         `yaml:"blocked_by,omitempty"`  // 需要先完成的事项的引用名
+}
+
+// Link 指向数据集内其它待办事项的关联链接
+type Link struct {
+	Type   string `yaml:"type"`   // 关联类型：relates_to、duplicates
+	Target string `yaml:"target"` // 目标事项的引用名
+}
+
+// Load 读取并解析测试数据文件
+func Load(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse 解析 YAML 格式的测试数据并校验引用，未知字段视为错误
+func Parse(data []byte) (*Fixtures, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var f Fixtures
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("解析测试数据失败: %w", err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate 校验测试数据：名称不能重复，引用的项目、用户和事项必须存在，时间必须能解析
+func (f *Fixtures) Validate() error {
+	if _, err := f.reference(time.Now()); err != nil {
+		return err
+	}
+
+	users := make(map[string]bool, len(f.Users))
+	for i, user := range f.Users {
+		if user.Name == "" || !strings.Contains(user.Email, "@") {
+			return fmt.Errorf("第 %d 个用户缺少名称或邮箱地址无效", i+1)
+		}
+		if users[user.Name] {
+			return fmt.Errorf("用户 %s 重复", user.Name)
+		}
+		users[user.Name] = true
+	}
+
+	projects := make(map[string]bool, len(f.Projects))
+	for i, project := range f.Projects {
+		if project.Name == "" {
+			return fmt.Errorf("第 %d 个项目缺少名称", i+1)
+		}
+		if projects[project.Name] {
+			return fmt.Errorf("项目 %s 重复", project.Name)
+		}
+		if project.Priority < 0 || project.Priority > 5 {
+			return fmt.Errorf("项目 %s 的优先级必须在0-5之间", project.Name)
+		}
+		projects[project.Name] = true
+	}
+
+	keys := make(map[string]bool, len(f.Todos))
+	for _, todo := range f.Todos {
+		if todo.Key == "" {
+			continue
+		}
+		if keys[todo.Key] {
+			return fmt.Errorf("待办事项引用名 %s 重复", todo.Key)
+		}
+		keys[todo.Key] = true
+	}
+
+	for i, todo := range f.Todos {
+		name := fmt.Sprintf("第 %d 个待办事项", i+1)
+		if todo.Key != "" {
+			name = fmt.Sprintf("待办事项 %s", todo.Key)
+		}
+		if strings.TrimSpace(todo.Title) == "" {
+			return fmt.Errorf("%s缺少标题", name)
+		}
+		if todo.Project != "" && !projects[todo.Project] {
+			return fmt.Errorf("%s引用了不存在的项目 %s", name, todo.Project)
+		}
+		if todo.Priority < 0 || todo.Priority > 5 {
+			return fmt.Errorf("%s的优先级必须在0-5之间", name)
+		}
+		for field, value := range map[string]string{"due": todo.Due, "created": todo.Created, "updated": todo.Updated} {
+			if _, err := ParseTime(value, time.Now()); err != nil {
+				return fmt.Errorf("%s的 %s: %w", name, field, err)
+			}
+		}
+		if todo.Location != nil {
+			if err := todo.Location.Validate(); err != nil {
+				return fmt.Errorf("%s的地点无效: %w", name, err)
+			}
+		}
+		for _, watcher := range todo.Watchers {
+			if !users[watcher] {
+				return fmt.Errorf("%s的关注者 %s 不存在", name, watcher)
+			}
+		}
+		for _, link := range todo.Links {
+			if !models.IsValidLinkType(link.Type) {
+				return fmt.Errorf("%s的关联类型 %s 无效", name, link.Type)
+			}
+			if !keys[link.Target] {
+				return fmt.Errorf("%s关联的事项 %s 不存在", name, link.Target)
+			}
+			if link.Target == todo.Key {
+				return fmt.Errorf("%s不能关联自身", name)
+			}
+		}
+		for _, target := range todo.BlockedBy {
+			if !keys[target] {
+				return fmt.Errorf("%s依赖的事项 %s 不存在", name, target)
+			}
+			if target == todo.Key {
+				return fmt.Errorf("%s不能依赖自身", name)
+			}
+		}
+	}
+	return nil
+}
+
+// reference 返回相对时间的参照时间：数据集指定了 now 时使用它，否则使用精确到秒的 now
+func (f *Fixtures) reference(now time.Time) (time.Time, error) {
+	if f.Now == "" {
+		return now.Truncate(time.Second), nil
+	}
+	t, err := ParseTime(f.Now, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("now: %w", err)
+	}
+	return t, nil
+}
+
+// Snapshot 把测试数据转换为快照，now 为相对时间的参照时间（数据集指定了 now 时忽略）
+// 待办事项按顺序分配ID，项目保存为分类默认设置，关注者生成新的退订令牌
+func (f *Fixtures) Snapshot(now time.Time) (*models.Snapshot, error) {
+	ref, err := f.reference(now)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int, len(f.Todos))
+	for i, todo := range f.Todos {
+		if todo.Key != "" {
+			ids[todo.Key] = i + 1
+		}
+	}
+	emails := make(map[string]string, len(f.Users))
+	for _, user := range f.Users {
+		emails[user.Name] = strings.ToLower(user.Email)
+	}
+	defaults := make(map[string]Project, len(f.Projects))
+	meta := map[string]map[string]json.RawMessage{
+		store.CategoryDefaultsNamespace: {},
+		store.WatchersNamespace:         {},
+		store.DependenciesNamespace:     {},
+	}
+	for _, project := range f.Projects {
+		defaults[project.Name] = project
+		if project.Priority == 0 && project.Description == "" {
+			continue
+		}
+		data, err := json.Marshal(&models.CategoryDefaults{Category: project.Name, Priority: models.Priority(project.Priority), Description: project.Description})
+		if err != nil {
+			return nil, err
+		}
+		meta[store.CategoryDefaultsNamespace][project.Name] = data
+	}
+
+	todos := make([]*models.Todo, len(f.Todos))
+	for i, item := range f.Todos {
+		due, err := ParseTime(item.Due, ref)
+		if err != nil {
+			return nil, err
+		}
+		created, err := ParseTime(item.Created, ref)
+		if err != nil {
+			return nil, err
+		}
+		if created.IsZero() {
+			created = ref
+		}
+		updated, err := ParseTime(item.Updated, ref)
+		if err != nil {
+			return nil, err
+		}
+		if updated.IsZero() {
+			updated = created
+		}
+		priority := item.Priority
+		if priority == 0 {
+			priority = defaults[item.Project].Priority
+		}
+
+		todo := &models.Todo{
+			ID:          i + 1,
+			Title:       item.Title,
+			Description: item.Description,
+			Completed:   item.Completed,
+			Priority:    models.Priority(priority),
+			Category:    item.Project,
+			DueDate:     models.CloneTime(&due),
+			CreatedAt:   created,
+			UpdatedAt:   updated,
+			Version:     1,
+			Location:    item.Location.Clone(),
+		}
+		for _, link := range item.Links {
+			todo.Links = append(todo.Links, models.TodoLink{Type: link.Type, TargetID: ids[link.Target]})
+		}
+		if len(item.BlockedBy) > 0 {
+			deps := &models.Dependencies{TodoID: todo.ID}
+			for _, target := range item.BlockedBy {
+				if !deps.IsBlockedBy(ids[target]) {
+					deps.BlockedBy = append(deps.BlockedBy, ids[target])
+				}
+			}
+			data, err := json.Marshal(deps)
+			if err != nil {
+				return nil, err
+			}
+			meta[store.DependenciesNamespace][strconv.Itoa(todo.ID)] = data
+		}
+		// 已完成的事项以最后更新时间作为完成时间
+		if todo.Completed {
+			todo.CompletedAt = models.CloneTime(&updated)
+		}
+		todos[i] = todo
+
+		for _, name := range item.Watchers {
+			token, err := newToken()
+			if err != nil {
+				return nil, err
+			}
+			watcher := &models.Watcher{Token: token, TodoID: todo.ID, Email: emails[name], CreatedAt: created}
+			if item.Completed {
+				// 已完成的事项不再发送完成通知
+				watcher.CompletedNotified = true
+			}
+			data, err := json.Marshal(watcher)
+			if err != nil {
+				return nil, err
+			}
+			meta[store.WatchersNamespace][token] = data
+		}
+	}
+
+	return &models.Snapshot{
+		Version:    models.SnapshotVersion,
+		ExportedAt: ref,
+		NextID:     len(todos) + 1,
+		Todos:      todos,
+		Meta:       meta,
+	}, nil
+}
+
+// Seed 用测试数据替换存储中的全部待办事项和附属数据，now 为相对时间的参照时间
+func Seed(s store.TodoStore, f *Fixtures, now time.Time) (*models.Snapshot, error) {
+	snapshot, err := f.Snapshot(now)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.RestoreSnapshot(s, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// ParseTime 解析测试数据中的时间，空字符串返回零值
+// 支持相对于 now 的时间（"-2d"、"+1w"、"-1d12h"、"+30m"，单位为 w、d、h、m）、"now"、
+// RFC3339 格式的时间和 2006-01-02 格式的日期（按本地时区）
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return time.Time{}, nil
+	case value == "now":
+		return now, nil
+	case value[0] == '+' || value[0] == '-':
+		offset, err := parseOffset(value[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的相对时间 %q: %w", value, err)
+		}
+		if value[0] == '-' {
+			offset = -offset
+		}
+		return now.Add(offset), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间 %q，应为 -2d、+1w 这样的相对时间、RFC3339 时间或 2006-01-02 格式的日期", value)
+}
+
+// offsetUnits 相对时间的单位
+var offsetUnits = map[byte]time.Duration{
+	'w': 7 * 24 * time.Hour,
+	'd': 24 * time.Hour,
+	'h': time.Hour,
+	'm': time.Minute,
+}
+
+// parseOffset 解析 "1d12h" 这样由数字和单位组成的时长
+func parseOffset(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, errors.New("缺少时长")
+	}
+	var total time.Duration
+	for value != "" {
+		i := 0
+		for i < len(value) && value[i] >= '0' && value[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(value) {
+			return 0, errors.New("时长应由数字和单位（w、d、h、m）组成")
+		}
+		unit, ok := offsetUnits[value[i]]
+		if !ok {
+			return 0, fmt.Errorf("未知的时间单位 %q", value[i])
+		}
+		n, err := strconv.Atoi(value[:i])
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(n) * unit
+		value = value[i+1:]
+	}
+	return total, nil
+}
+
+// newToken 生成关注者的退订令牌，格式与 API 添加关注者时生成的令牌相同
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
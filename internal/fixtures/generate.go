@@ -0,0 +1,150 @@
+package fixtures
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// GenerateOptions 生成演示数据的选项
+type GenerateOptions struct {
+	Count int       // 待办事项数
+	Users int       // 用户数，事项的关注者从中选择；为0时没有关注者
+	Seed  int64     // 随机数种子，相同的种子和参照时间生成完全相同的数据
+	Now   time.Time // 参照时间，为零值时使用当前时间
+}
+
+// weighted 带权重的取值
+type weighted[T any] struct {
+	value  T
+	weight int
+}
+
+// generatedProjects 生成数据中的项目及其出现权重，少数项目包含大部分事项；名称为空表示不属于任何项目
+var generatedProjects = []weighted[Project]{
+	{Project{Name: "工作", Priority: 3}, 36},
+	{Project{Name: "个人", Priority: 2}, 22},
+	{Project{Name: "学习", Description: "学习目标："}, 14},
+	{Project{Name: "健康"}, 9},
+	{Project{Name: "家庭"}, 7},
+	{Project{Name: "财务", Priority: 4}, 4},
+	{Project{Name: "旅行"}, 3},
+	{Project{}, 5},
+}
+
+// generatedPriorities 优先级的分布，中等优先级最多
+var generatedPriorities = []weighted[int]{{1, 14}, {2, 28}, {3, 32}, {4, 18}, {5, 8}}
+
+// generatedTitles 各项目的标题模板，%d 替换为序号避免大量重复的标题
+var generatedTitles = map[string][]string{
+	"工作": {"提交第 %d 周的周报", "评审合并请求 #%d", "准备第 %d 次项目例会", "修复缺陷 #%d", "整理需求文档（第 %d 版）", "回复客户邮件 %d"},
+	"个人": {"购买日用品（清单 %d）", "预约理发 %d", "整理房间第 %d 区", "给朋友 %d 回电话"},
+	"学习": {"阅读第 %d 章", "完成练习 %d", "观看课程第 %d 讲", "复习笔记 %d"},
+	"健康": {"第 %d 次跑步训练", "预约体检 %d", "记录第 %d 周的体重"},
+	"家庭": {"缴纳第 %d 期物业费", "陪孩子完成作业 %d", "家庭聚餐计划 %d"},
+	"财务": {"核对第 %d 月账单", "提交报销单 #%d", "缴纳第 %d 期保险"},
+	"旅行": {"预订行程 %d 的机票", "整理行程 %d 的攻略", "办理签证 %d"},
+	"":   {"待整理事项 %d", "临时想法 %d"},
+}
+
+// generatedDescriptions 描述模板，约一半的事项有描述
+var generatedDescriptions = []string{
+	"尽快完成，完成后通知相关人员",
+	"需要先确认细节再开始",
+	"参考上次的记录",
+	"可以拆分成几个小任务",
+}
+
+// Generate 按选项生成大规模的演示数据，用于评估分页、搜索索引、统计等功能在大数据量下的表现
+// 项目、优先级按固定权重分布；创建时间集中在最近几周并向半年前逐渐减少，越早创建的事项越可能已完成；
+// 约五分之一的事项没有截止日期，其余的截止日期在创建后的几小时到两个月之间，因此同时有已过期和即将到期的事项
+func Generate(opts GenerateOptions) (*Fixtures, error) {
+	if opts.Count < 0 || opts.Users < 0 {
+		return nil, fmt.Errorf("事项数和用户数不能为负数")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.Truncate(time.Second)
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	f := &Fixtures{Now: now.Format(time.RFC3339)}
+	for i := 1; i <= opts.Users; i++ {
+		name := fmt.Sprintf("user%02d", i)
+		f.Users = append(f.Users, User{Name: name, Email: name + "@example.com"})
+	}
+	for _, project := range generatedProjects {
+		if project.value.Name != "" {
+			f.Projects = append(f.Projects, project.value)
+		}
+	}
+
+	const maxAge = 180 * 24 * time.Hour
+	f.Todos = make([]Todo, opts.Count)
+	for i := range f.Todos {
+		project := pick(rng, generatedProjects).Name
+		titles := generatedTitles[project]
+		todo := Todo{
+			Title:    fmt.Sprintf(titles[rng.Intn(len(titles))], i+1),
+			Project:  project,
+			Priority: pick(rng, generatedPriorities),
+		}
+		if rng.Intn(2) == 0 {
+			todo.Description = generatedDescriptions[rng.Intn(len(generatedDescriptions))]
+		}
+
+		// 指数分布：平均约三周前创建，最早不超过半年
+		age := time.Duration(rng.ExpFloat64() * float64(21*24*time.Hour))
+		if age > maxAge {
+			age = time.Duration(rng.Int63n(int64(maxAge)))
+		}
+		age = age.Truncate(time.Minute)
+		todo.Created = offset(-age)
+		// 更新时间在创建之后，最近更新的居多
+		updatedAge := time.Duration(rng.Float64() * rng.Float64() * float64(age))
+		todo.Updated = offset(-updatedAge.Truncate(time.Minute))
+
+		// 创建越早越可能已完成：刚创建的约一成，两个月以上的约八成
+		completedRate := min(0.8, 0.1+0.7*float64(age)/float64(60*24*time.Hour))
+		todo.Completed = rng.Float64() < completedRate
+
+		if rng.Intn(5) != 0 {
+			due := time.Duration(6+rng.Intn(60*24)) * time.Hour
+			todo.Due = offset(due - age)
+		}
+
+		if opts.Users > 0 && rng.Intn(10) < 3 {
+			for _, n := range rng.Perm(opts.Users)[:1+rng.Intn(min(3, opts.Users))] {
+				todo.Watchers = append(todo.Watchers, f.Users[n].Name)
+			}
+		}
+		f.Todos[i] = todo
+	}
+	return f, nil
+}
+
+// pick 按权重随机选择一个取值
+func pick[T any](rng *rand.Rand, choices []weighted[T]) T {
+	total := 0
+	for _, choice := range choices {
+		total += choice.weight
+	}
+	n := rng.Intn(total)
+	for _, choice := range choices {
+		if n < choice.weight {
+			return choice.value
+		}
+		n -= choice.weight
+	}
+	return choices[len(choices)-1].value
+}
+
+// offset 把相对于参照时间的时长格式化为 "-90m"、"+1440m" 这样的相对时间
+func offset(d time.Duration) string {
+	minutes := int64(d / time.Minute)
+	if minutes < 0 {
+		return fmt.Sprintf("-%dm", -minutes)
+	}
+	return fmt.Sprintf("+%dm", minutes)
+}
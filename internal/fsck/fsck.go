@@ -0,0 +1,383 @@
+// Package fsck 检查存储中数据之间的引用是否一致，并修复可以安全修复的问题
+// 数据在不同存储后端之间迁移、从备份恢复或被手工编辑后，可能出现指向已删除事项的关联链接、
+// 分享链接、关注者等；这些数据不会导致请求失败，但会在列表、通知和同步中留下无效的内容
+package fsck
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// 问题类型
+const (
+	KindInvalidLinkType = "invalid_link_type" // 不支持的关联类型
+	KindSelfLink        = "self_link"         // 关联指向自身
+	KindDuplicateLink   = "duplicate_link"    // 重复的关联
+	KindDanglingLink    = "dangling_link"     // 关联指向不存在的事项
+	KindBadDependency   = "bad_dependency"    // 阻塞依赖指向自身、重复或指向不存在的事项
+	KindBlockedCycle    = "blocked_cycle"     // 阻塞依赖形成环，环中的事项互相等待
+	KindOrphanedMeta    = "orphaned_meta"     // 附属数据指向不存在的事项
+	KindCorruptMeta     = "corrupt_meta"      // 附属数据无法解析
+)
+
+// todoRefNamespaces 值中通过 todo_id 引用待办事项的附属数据命名空间
+// 删除事项时这些数据会被一起删除，残留的数据是孤立的
+var todoRefNamespaces = []string{
+	store.ShareLinksNamespace,
+	store.WatchersNamespace,
+	store.DependenciesNamespace,
+	store.CalDAVResourcesNamespace,
+	store.NextSkipsNamespace,
+}
+
+// decodeOnlyNamespaces 只检查能否解析为对应类型的附属数据命名空间
+var decodeOnlyNamespaces = []struct {
+	namespace string
+	newValue  func() interface{}
+}{
+	{store.CategoryDefaultsNamespace, func() interface{} { return &models.CategoryDefaults{} }},
+	{store.RulesNamespace, func() interface{} { return &models.Rule{} }},
+	{store.NotificationTemplatesNamespace, func() interface{} { return &models.NotificationTemplate{} }},
+	{store.AuditNamespace, func() interface{} { return &models.AuditEntry{} }},
+	{store.TrashNamespace, func() interface{} { return &models.TrashedTodo{} }},
+	{store.WebhooksNamespace, func() interface{} { return &models.Webhook{} }},
+	{store.WebhookDeliveriesNamespace, func() interface{} { return &models.WebhookDelivery{} }},
+}
+
+// Check 检查存储中的数据，不做任何修改
+func Check(s store.TodoStore) (*models.FsckReport, error) {
+	return check(s)
+}
+
+// Repair 在一个事务中检查并修复可以自动修复的问题
+// 无效、重复和指向不存在事项的关联链接从事项中删除（事项的版本号会增加），同样的阻塞依赖从依赖记录中删除，
+// 孤立的附属数据被删除；无法解析的附属数据和阻塞依赖的环需要人工处理，只报告不修复
+func Repair(s store.TodoStore) (*models.FsckReport, error) {
+	var report *models.FsckReport
+	err := s.Transaction(func(tx store.TodoStore) error {
+		var err error
+		report, err = check(tx)
+		if err != nil {
+			return err
+		}
+		return repair(tx, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// check 检查关联链接、阻塞依赖和附属数据
+func check(s store.TodoStore) (*models.FsckReport, error) {
+	todos, err := s.ListTodos(store.ListOptions{SortField: models.SortByID})
+	if err != nil {
+		return nil, fmt.Errorf("读取待办事项失败: %w", err)
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+
+	report := &models.FsckReport{CheckedAt: time.Now(), Todos: len(todos), Issues: []models.FsckIssue{}}
+	byID := make(map[int]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	for _, todo := range todos {
+		report.Issues = append(report.Issues, checkLinks(todo, byID)...)
+	}
+
+	for _, namespace := range todoRefNamespaces {
+		items, err := s.ListMeta(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("读取附属数据 %s 失败: %w", namespace, err)
+		}
+		report.Meta += len(items)
+		for _, key := range sortedKeys(items) {
+			var ref struct {
+				TodoID int `json:"todo_id"`
+			}
+			if err := json.Unmarshal(items[key], &ref); err != nil {
+				report.Issues = append(report.Issues, corruptMeta(namespace, key, err))
+				continue
+			}
+			if _, exists := byID[ref.TodoID]; !exists {
+				report.Issues = append(report.Issues, models.FsckIssue{
+					Kind:       KindOrphanedMeta,
+					TodoID:     ref.TodoID,
+					Namespace:  namespace,
+					Key:        key,
+					Message:    fmt.Sprintf("%s/%s 指向不存在的待办事项 %d", namespace, key, ref.TodoID),
+					Repairable: true,
+				})
+			}
+		}
+	}
+
+	deps, issues, err := checkDependencies(s, byID)
+	if err != nil {
+		return nil, err
+	}
+	report.Issues = append(report.Issues, issues...)
+	report.Issues = append(report.Issues, blockedCycles(todos, deps)...)
+
+	for _, decodeOnly := range decodeOnlyNamespaces {
+		items, err := s.ListMeta(decodeOnly.namespace)
+		if err != nil {
+			return nil, fmt.Errorf("读取附属数据 %s 失败: %w", decodeOnly.namespace, err)
+		}
+		report.Meta += len(items)
+		for _, key := range sortedKeys(items) {
+			if err := json.Unmarshal(items[key], decodeOnly.newValue()); err != nil {
+				report.Issues = append(report.Issues, corruptMeta(decodeOnly.namespace, key, err))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// checkLinks 检查待办事项的关联链接
+func checkLinks(todo *models.Todo, byID map[int]*models.Todo) []models.FsckIssue {
+	var issues []models.FsckIssue
+	seen := make(map[models.TodoLink]bool, len(todo.Links))
+	for _, link := range todo.Links {
+		issue := models.FsckIssue{TodoID: todo.ID, Repairable: true}
+		switch {
+		case !models.IsValidLinkType(link.Type):
+			issue.Kind = KindInvalidLinkType
+			issue.Message = fmt.Sprintf("事项 %d 的关联类型 %q 不受支持", todo.ID, link.Type)
+		case link.TargetID == todo.ID:
+			issue.Kind = KindSelfLink
+			issue.Message = fmt.Sprintf("事项 %d 的 %s 关联指向自身", todo.ID, link.Type)
+		case seen[link]:
+			issue.Kind = KindDuplicateLink
+			issue.Message = fmt.Sprintf("事项 %d 有重复的 %s 关联指向事项 %d", todo.ID, link.Type, link.TargetID)
+		case byID[link.TargetID] == nil:
+			issue.Kind = KindDanglingLink
+			issue.Message = fmt.Sprintf("事项 %d 的 %s 关联指向不存在的事项 %d", todo.ID, link.Type, link.TargetID)
+		default:
+			seen[link] = true
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkDependencies 检查阻塞依赖，返回其中有效的依赖用于查找环
+// 无法解析和被阻塞事项不存在的记录已在附属数据检查中报告，这里跳过
+func checkDependencies(s store.TodoStore, byID map[int]*models.Todo) (map[int][]int, []models.FsckIssue, error) {
+	items, err := s.ListMeta(store.DependenciesNamespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取附属数据 %s 失败: %w", store.DependenciesNamespace, err)
+	}
+
+	valid := make(map[int][]int, len(items))
+	var issues []models.FsckIssue
+	for _, key := range sortedKeys(items) {
+		var deps models.Dependencies
+		if err := json.Unmarshal(items[key], &deps); err != nil || byID[deps.TodoID] == nil {
+			continue
+		}
+		valid[deps.TodoID] = validDependencies(&deps, byID)
+		for _, problem := range dependencyProblems(&deps, byID) {
+			issues = append(issues, models.FsckIssue{
+				Kind:       KindBadDependency,
+				TodoID:     deps.TodoID,
+				Namespace:  store.DependenciesNamespace,
+				Key:        key,
+				Message:    problem,
+				Repairable: true,
+			})
+		}
+	}
+	return valid, issues, nil
+}
+
+// dependencyProblems 描述阻塞依赖中指向自身、重复或指向不存在事项的目标
+func dependencyProblems(deps *models.Dependencies, byID map[int]*models.Todo) []string {
+	var problems []string
+	seen := make(map[int]bool, len(deps.BlockedBy))
+	for _, targetID := range deps.BlockedBy {
+		switch {
+		case targetID == deps.TodoID:
+			problems = append(problems, fmt.Sprintf("事项 %d 的阻塞依赖指向自身", deps.TodoID))
+		case seen[targetID]:
+			problems = append(problems, fmt.Sprintf("事项 %d 重复依赖事项 %d", deps.TodoID, targetID))
+		case byID[targetID] == nil:
+			problems = append(problems, fmt.Sprintf("事项 %d 的阻塞依赖指向不存在的事项 %d", deps.TodoID, targetID))
+		default:
+			seen[targetID] = true
+		}
+	}
+	return problems
+}
+
+// validDependencies 返回阻塞依赖中不重复并指向其它存在的事项的目标
+func validDependencies(deps *models.Dependencies, byID map[int]*models.Todo) []int {
+	var targets []int
+	seen := make(map[int]bool, len(deps.BlockedBy))
+	for _, targetID := range deps.BlockedBy {
+		if targetID == deps.TodoID || seen[targetID] || byID[targetID] == nil {
+			continue
+		}
+		seen[targetID] = true
+		targets = append(targets, targetID)
+	}
+	return targets
+}
+
+// blockedCycles 查找阻塞依赖形成的环，每个环报告一次，由环中ID最小的事项报告
+func blockedCycles(todos []*models.Todo, deps map[int][]int) []models.FsckIssue {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int]int, len(todos))
+	var issues []models.FsckIssue
+	var path []int
+
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = visiting
+		path = append(path, id)
+		for _, targetID := range deps[id] {
+			switch state[targetID] {
+			case unvisited:
+				visit(targetID)
+			case visiting:
+				// 从 path 中目标事项的位置到末尾就是一个环
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == targetID {
+						issues = append(issues, cycleIssue(path[i:]))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+
+	for _, todo := range todos {
+		if state[todo.ID] == unvisited {
+			visit(todo.ID)
+		}
+	}
+	return issues
+}
+
+// cycleIssue 生成阻塞依赖环的问题，环从ID最小的事项开始描述
+func cycleIssue(cycle []int) models.FsckIssue {
+	start := 0
+	for i, id := range cycle {
+		if id < cycle[start] {
+			start = i
+		}
+	}
+	ids := make([]string, 0, len(cycle)+1)
+	for i := range cycle {
+		ids = append(ids, fmt.Sprint(cycle[(start+i)%len(cycle)]))
+	}
+	ids = append(ids, ids[0])
+	return models.FsckIssue{
+		Kind:    KindBlockedCycle,
+		TodoID:  cycle[start],
+		Message: "阻塞依赖形成环，事项互相等待: " + strings.Join(ids, " → "),
+	}
+}
+
+// corruptMeta 生成无法解析的附属数据的问题
+func corruptMeta(namespace, key string, err error) models.FsckIssue {
+	return models.FsckIssue{
+		Kind:      KindCorruptMeta,
+		Namespace: namespace,
+		Key:       key,
+		Message:   fmt.Sprintf("%s/%s 无法解析: %v", namespace, key, err),
+	}
+}
+
+// repair 修复报告中可以自动修复的问题，并标记为已修复
+func repair(tx store.TodoStore, report *models.FsckReport) error {
+	todos, err := tx.GetAllTodos()
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	fixed := make(map[int]bool)        // 已经清理过关联链接的事项
+	fixedDeps := make(map[string]bool) // 已经清理过的阻塞依赖记录
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+		if !issue.Repairable {
+			continue
+		}
+
+		switch issue.Kind {
+		case KindOrphanedMeta:
+			if err := tx.DeleteMeta(issue.Namespace, issue.Key); err != nil {
+				return fmt.Errorf("删除附属数据 %s/%s 失败: %w", issue.Namespace, issue.Key, err)
+			}
+		case KindBadDependency:
+			// 一次保留记录中全部有效的依赖
+			if !fixedDeps[issue.Key] {
+				deps, err := store.GetDependencies(tx, issue.TodoID)
+				if err != nil {
+					return fmt.Errorf("读取待办事项 %d 的阻塞依赖失败: %w", issue.TodoID, err)
+				}
+				deps.BlockedBy = validDependencies(deps, byID)
+				if err := store.SaveDependencies(tx, deps); err != nil {
+					return fmt.Errorf("保存待办事项 %d 的阻塞依赖失败: %w", issue.TodoID, err)
+				}
+				fixedDeps[issue.Key] = true
+			}
+		default:
+			// 关联链接的问题：一次保留事项中全部有效的关联
+			if !fixed[issue.TodoID] {
+				todo := byID[issue.TodoID]
+				todo.Links = validLinks(todo, byID)
+				if _, err := tx.SaveTodo(todo); err != nil {
+					return fmt.Errorf("保存待办事项 %d 失败: %w", todo.ID, err)
+				}
+				fixed[issue.TodoID] = true
+			}
+		}
+		issue.Repaired = true
+		report.Repaired++
+	}
+	return nil
+}
+
+// validLinks 返回待办事项中有效、不重复并指向存在的事项的关联
+func validLinks(todo *models.Todo, byID map[int]*models.Todo) []models.TodoLink {
+	var links []models.TodoLink
+	seen := make(map[models.TodoLink]bool, len(todo.Links))
+	for _, link := range todo.Links {
+		if !models.IsValidLinkType(link.Type) || link.TargetID == todo.ID || seen[link] || byID[link.TargetID] == nil {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+// sortedKeys 返回按字母排序的键，使报告中的问题顺序固定
+func sortedKeys(items map[string][]byte) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
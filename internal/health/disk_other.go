@@ -0,0 +1,13 @@
+//go:build !unix
+
+package health
+
+import "errors"
+
+// errDiskUsageUnsupported 当前平台不支持查询磁盘空间
+var errDiskUsageUnsupported = errors.New("当前平台不支持查询磁盘空间")
+
+// diskUsage 当前平台不支持，磁盘检查报告降级而不是异常
+func diskUsage(dir string) (free, total uint64, err error) {
+	return 0, 0, Degraded(errDiskUsageUnsupported)
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package health
+
+import "syscall"
+
+// diskUsage 返回目录所在文件系统的可用空间和总空间（字节），可用空间为非特权用户可用的部分
+func diskUsage(dir string) (free, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,231 @@
+// Package health 实现依赖组件的健康检查注册表
+// 各子系统（存储、调度器、任务队列、Webhook 分发、搜索索引等）在启动时注册自己的检查函数，
+// 就绪检查接口汇总所有组件的状态、检查耗时和最近一次错误
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 组件状态
+const (
+	StatusUp       = "up"       // 正常
+	StatusDegraded = "degraded" // 可用但性能或资源接近上限，不影响就绪状态
+	StatusDown     = "down"     // 异常
+)
+
+// 汇总状态（Report.Health），用于健康检查接口
+const (
+	HealthHealthy   = "healthy"   // 所有组件正常
+	HealthDegraded  = "degraded"  // 有组件降级，但没有组件异常
+	HealthUnhealthy = "unhealthy" // 有组件异常
+)
+
+// defaultTimeout 单个组件检查的默认超时时间
+const defaultTimeout = 5 * time.Second
+
+// CheckFunc 健康检查函数，返回nil表示组件正常
+// 检查函数应当遵守 ctx 的超时设置
+type CheckFunc func(ctx context.Context) error
+
+// DetailFunc 带详细信息的健康检查函数，详细信息（如剩余空间、内存占用）在结果的 details 中返回，失败时也会返回
+type DetailFunc func(ctx context.Context) (map[string]interface{}, error)
+
+// degradedError 表示组件降级的错误
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string { return e.err.Error() }
+func (e *degradedError) Unwrap() error { return e.err }
+
+// Degraded 把检查函数的错误标记为降级：组件仍然可用，结果为 degraded 而不是 down
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &degradedError{err: err}
+}
+
+// IsDegraded 错误是否为 Degraded 标记的降级
+func IsDegraded(err error) bool {
+	var degraded *degradedError
+	return errors.As(err, &degraded)
+}
+
+// ComponentStatus 单个组件的检查结果
+type ComponentStatus struct {
+	Name        string                 `json:"name"`                    // 组件名称
+	Status      string                 `json:"status"`                  // up、degraded 或 down
+	LatencyMs   float64                `json:"latency_ms"`              // 本次检查耗时（毫秒）
+	Details     map[string]interface{} `json:"details,omitempty"`       // 检查函数返回的详细信息
+	Error       string                 `json:"error,omitempty"`         // 本次检查的错误或降级原因
+	LastError   string                 `json:"last_error,omitempty"`    // 最近一次失败的错误，组件恢复后仍然保留，便于排查间歇性故障
+	LastErrorAt *time.Time             `json:"last_error_at,omitempty"` // 最近一次失败的时间
+	CheckedAt   time.Time              `json:"checked_at"`              // 本次检查时间
+}
+
+// Report 所有组件的汇总检查结果
+type Report struct {
+	Status     string            `json:"status"`     // 没有组件异常时为 up（降级的组件不影响就绪），否则为 down
+	Health     string            `json:"health"`     // 汇总状态：healthy、degraded 或 unhealthy
+	Components []ComponentStatus `json:"components"` // 按名称排序的组件结果
+	CheckedAt  time.Time         `json:"checked_at"` // 检查时间
+}
+
+// Ready 是否所有组件都正常
+func (r *Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// component 已注册的组件
+type component struct {
+	check       DetailFunc
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Registry 健康检查注册表，并发安全
+type Registry struct {
+	mu         sync.Mutex
+	components map[string]*component
+	timeout    time.Duration // 单个组件检查的超时时间
+}
+
+// NewRegistry 创建健康检查注册表，timeout 为单个组件检查的超时时间，不大于0时使用默认的5秒
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Registry{
+		components: make(map[string]*component),
+		timeout:    timeout,
+	}
+}
+
+// Register 注册组件的检查函数，同名组件会被替换
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.RegisterDetailed(name, func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, check(ctx)
+	})
+}
+
+// RegisterDetailed 注册带详细信息的检查函数，同名组件会被替换
+func (r *Registry) RegisterDetailed(name string, check DetailFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[name] = &component{check: check}
+}
+
+// Unregister 移除组件
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.components, name)
+}
+
+// Check 并发检查所有组件并汇总结果
+// 每个组件使用独立的超时时间，一个组件卡住不会影响其它组件的结果
+func (r *Registry) Check(ctx context.Context) *Report {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.components))
+	checks := make([]*component, 0, len(r.components))
+	for name, c := range r.components {
+		names = append(names, name)
+		checks = append(checks, c)
+	}
+	r.mu.Unlock()
+
+	results := make([]ComponentStatus, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = r.checkOne(ctx, names[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := &Report{Status: StatusUp, Health: HealthHealthy, Components: results, CheckedAt: time.Now()}
+	for _, result := range results {
+		switch result.Status {
+		case StatusDown:
+			report.Status, report.Health = StatusDown, HealthUnhealthy
+		case StatusDegraded:
+			if report.Health == HealthHealthy {
+				report.Health = HealthDegraded
+			}
+		}
+	}
+	return report
+}
+
+// checkOne 检查单个组件并记录最近一次错误
+func (r *Registry) checkOne(ctx context.Context, name string, c *component) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := run(ctx, c.check)
+	latency := time.Since(start)
+
+	status := ComponentStatus{
+		Name:      name,
+		Status:    StatusUp,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		Details:   details,
+		CheckedAt: start,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		status.Status = StatusDown
+		if IsDegraded(err) {
+			status.Status = StatusDegraded
+		}
+		status.Error = err.Error()
+		c.lastError, c.lastErrorAt = err.Error(), start
+	}
+	if c.lastError != "" {
+		lastErrorAt := c.lastErrorAt
+		status.LastError, status.LastErrorAt = c.lastError, &lastErrorAt
+	}
+	return status
+}
+
+// run 执行检查函数，检查函数超时未返回或发生 panic 时视为失败
+func run(ctx context.Context, check DetailFunc) (map[string]interface{}, error) {
+	type result struct {
+		details map[string]interface{}
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- result{err: fmt.Errorf("检查异常: %v", recovered)}
+			}
+		}()
+		details, err := check(ctx)
+		done <- result{details: details, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.details, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, errors.New("检查超时")
+		}
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+)
+
+// bytesPerMB 每MB的字节数
+const bytesPerMB = 1 << 20
+
+// memoryPressureRatio 堆内存达到上限的这个比例时报告降级
+const memoryPressureRatio = 0.9
+
+// DiskCheck 检查目录所在磁盘的剩余空间：低于 minFreeMB 时降级，低于 criticalFreeMB 时异常，阈值为0时不检查
+// 目录不存在时检查最近一个存在的上级目录（日志目录在第一次写入前可能还没有创建）
+func DiskCheck(dir string, minFreeMB, criticalFreeMB int) DetailFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		path := existingDir(dir)
+		free, total, err := diskUsage(path)
+		if err != nil {
+			return map[string]interface{}{"path": path}, err
+		}
+		freeMB := free / bytesPerMB
+		details := map[string]interface{}{
+			"path":     path,
+			"free_mb":  freeMB,
+			"total_mb": total / bytesPerMB,
+		}
+		switch {
+		case criticalFreeMB > 0 && freeMB < uint64(criticalFreeMB):
+			return details, fmt.Errorf("磁盘剩余空间 %dMB，低于 %dMB", freeMB, criticalFreeMB)
+		case minFreeMB > 0 && freeMB < uint64(minFreeMB):
+			return details, Degraded(fmt.Errorf("磁盘剩余空间 %dMB，低于 %dMB", freeMB, minFreeMB))
+		}
+		return details, nil
+	}
+}
+
+// existingDir 返回 dir 或最近一个存在的上级目录
+func existingDir(dir string) string {
+	if dir == "" {
+		dir = "."
+	}
+	dir, _ = filepath.Abs(dir)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// MemoryCheck 检查内存压力：堆内存达到上限的90%时降级
+// maxHeapMB 为0时使用 GOMEMLIMIT 设置的内存上限，两者都没有设置时只返回内存占用
+func MemoryCheck(maxHeapMB int) DetailFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		details := map[string]interface{}{
+			"heap_alloc_mb": stats.HeapAlloc / bytesPerMB,
+			"sys_mb":        stats.Sys / bytesPerMB,
+			"goroutines":    runtime.NumGoroutine(),
+			"gc_count":      stats.NumGC,
+		}
+
+		limit := uint64(maxHeapMB) * bytesPerMB
+		if limit == 0 {
+			if memLimit := debug.SetMemoryLimit(-1); memLimit > 0 && memLimit < math.MaxInt64 {
+				limit = uint64(memLimit)
+			}
+		}
+		if limit == 0 {
+			return details, nil
+		}
+		details["limit_mb"] = limit / bytesPerMB
+		if float64(stats.HeapAlloc) >= float64(limit)*memoryPressureRatio {
+			return details, Degraded(fmt.Errorf("堆内存 %dMB，接近上限 %dMB", stats.HeapAlloc/bytesPerMB, limit/bytesPerMB))
+		}
+		return details, nil
+	}
+}
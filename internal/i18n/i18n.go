@@ -0,0 +1,134 @@
+// Package i18n 响应中面向用户的文字的本地化
+// 按请求者的语言区域（?lang= 或 Accept-Language）查找消息目录，不支持的语言区域使用 zh-CN
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale 默认语言区域，其它语言区域缺少的消息也使用它的文字
+const DefaultLocale = "zh-CN"
+
+// catalogs 各语言区域的消息目录，键为消息ID
+var catalogs = map[string]map[string]string{
+	"zh-CN": {
+		"status.in_progress": "进行中",
+		"status.completed":   "已完成",
+		"status.overdue":     "已过期",
+	},
+	"en-US": {
+		"status.in_progress": "In progress",
+		"status.completed":   "Completed",
+		"status.overdue":     "Overdue",
+	},
+	"en-GB": {
+		"status.in_progress": "In progress",
+		"status.completed":   "Completed",
+		"status.overdue":     "Overdue",
+	},
+	"de-DE": {
+		"status.in_progress": "In Bearbeitung",
+		"status.completed":   "Erledigt",
+		"status.overdue":     "Überfällig",
+	},
+	"ja-JP": {
+		"status.in_progress": "進行中",
+		"status.completed":   "完了",
+		"status.overdue":     "期限切れ",
+	},
+}
+
+// locales 支持的语言区域，同一语言的多个语言区域中排在前面的优先匹配只有语言的标签（如 en 匹配 en-US）
+var locales = []string{"zh-CN", "en-US", "en-GB", "de-DE", "ja-JP"}
+
+// Locales 返回支持的语言区域
+func Locales() []string {
+	return append([]string(nil), locales...)
+}
+
+// Supported 是否支持该语言区域（名称区分大小写，应先经过 Match 规范化）
+func Supported(locale string) bool {
+	_, exists := catalogs[locale]
+	return exists
+}
+
+// T 返回消息在语言区域中的文字
+// 语言区域不支持或缺少该消息时使用 zh-CN 的文字，仍然没有时返回消息ID
+func T(locale, id string) string {
+	if text, exists := catalogs[locale][id]; exists {
+		return text
+	}
+	if text, exists := catalogs[DefaultLocale][id]; exists {
+		return text
+	}
+	return id
+}
+
+// Match 把语言标签匹配到支持的语言区域，不区分大小写，"_" 等同于 "-"
+// 完全一致时直接使用；只有语言相同时（如 en、en-AU）使用该语言的首选语言区域；都不匹配时返回空字符串
+func Match(tag string) string {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return ""
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	match := ""
+	for _, locale := range locales {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+		if prefix, _, _ := strings.Cut(locale, "-"); match == "" && strings.EqualFold(prefix, language) {
+			match = locale
+		}
+	}
+	return match
+}
+
+// Negotiate 按 Accept-Language 请求头选择语言区域，依次尝试权重最高的语言标签，都不支持时返回 fallback
+func Negotiate(acceptLanguage, fallback string) string {
+	type candidate struct {
+		tag    string
+		weight float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if tag == "" || tag == "*" || weight <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag, weight})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+
+	for _, c := range candidates {
+		if locale := Match(c.tag); locale != "" {
+			return locale
+		}
+	}
+	return fallback
+}
+
+// FromRequest 返回请求者的语言区域
+// ?lang= 优先，其次是 Accept-Language 请求头，都没有或不支持时使用 fallback（不支持时使用 zh-CN）
+func FromRequest(r *http.Request, fallback string) string {
+	if !Supported(fallback) {
+		fallback = DefaultLocale
+	}
+	if locale := Match(r.URL.Query().Get("lang")); locale != "" {
+		return locale
+	}
+	return Negotiate(r.Header.Get("Accept-Language"), fallback)
+}
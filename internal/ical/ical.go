@@ -0,0 +1,421 @@
+// Package ical 实现待办事项与 iCalendar（RFC 5545）VTODO 组件之间的转换，以及供日历应用订阅的 VEVENT 编码
+// 只支持本项目需要的属性：SUMMARY、DESCRIPTION、STATUS、COMPLETED、PRIORITY、
+// CATEGORIES、DUE、LOCATION、GEO、CREATED、LAST-MODIFIED 和 UID
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// 时间格式
+const (
+	utcFormat      = "20060102T150405Z" // UTC时间
+	floatingFormat = "20060102T150405"  // 不带时区的本地时间
+	dateFormat     = "20060102"         // 全天日期
+)
+
+// ContentType iCalendar 数据的媒体类型
+const ContentType = "text/calendar; charset=utf-8"
+
+// ErrNoTodo 数据中没有 VTODO 组件
+var ErrNoTodo = errors.New("数据中没有VTODO组件")
+
+// Entry 要编码的一个待办事项
+type Entry struct {
+	Todo *models.Todo
+	UID  string // 组件的UID，同一个事项在多次同步之间必须保持不变
+}
+
+// Encode 将待办事项编码为一个 VCALENDAR 文档，每个事项一个 VTODO 组件
+func Encode(entries []Entry) []byte {
+	return EncodeFeed(entries, FeedOptions{Todos: true})
+}
+
+// FeedOptions 订阅日历的编码选项
+type FeedOptions struct {
+	Name    string        // 日历名称（X-WR-CALNAME），为空时不输出
+	Refresh time.Duration // 建议客户端刷新订阅的间隔（REFRESH-INTERVAL、X-PUBLISHED-TTL），0表示不指定
+	Events  bool          // 是否为有截止时间的事项生成 VEVENT 组件（Google 日历、Outlook 的订阅只显示事件）
+	Todos   bool          // 是否生成 VTODO 组件
+}
+
+// EncodeFeed 将待办事项编码为供日历应用订阅的 VCALENDAR 文档
+// 每个事项按选项生成 VTODO 和/或在截止时间的 VEVENT，VEVENT 的 UID 在事项UID前加 "due-"，避免与 VTODO 冲突
+func EncodeFeed(entries []Entry, opts FeedOptions) []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//xStreamTool//xStreamTool Go//ZH")
+	if opts.Name != "" {
+		writeLine(&buf, "METHOD:PUBLISH")
+		writeLine(&buf, "X-WR-CALNAME:"+escapeText(opts.Name))
+	}
+	if opts.Refresh > 0 {
+		duration := fmt.Sprintf("PT%dM", int(opts.Refresh.Minutes()))
+		writeLine(&buf, "REFRESH-INTERVAL;VALUE=DURATION:"+duration)
+		writeLine(&buf, "X-PUBLISHED-TTL:"+duration)
+	}
+	for _, entry := range entries {
+		if opts.Todos {
+			encodeTodo(&buf, entry)
+		}
+		if opts.Events && entry.Todo.HasDueDate() {
+			encodeEvent(&buf, entry)
+		}
+	}
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// encodeEvent 把事项的截止时间编码为一个 VEVENT 组件，开始和结束都是截止时间
+func encodeEvent(buf *bytes.Buffer, entry Entry) {
+	todo := entry.Todo
+	due := todo.DueDate.UTC().Format(utcFormat)
+
+	writeLine(buf, "BEGIN:VEVENT")
+	writeLine(buf, "UID:"+escapeText("due-"+entry.UID))
+	writeLine(buf, "DTSTAMP:"+time.Now().UTC().Format(utcFormat))
+	writeLine(buf, "DTSTART:"+due)
+	writeLine(buf, "DTEND:"+due)
+	writeLine(buf, "SUMMARY:"+escapeText(todo.Title))
+	if todo.Description != "" {
+		writeLine(buf, "DESCRIPTION:"+escapeText(todo.Description))
+	}
+	if todo.Category != "" {
+		writeLine(buf, "CATEGORIES:"+escapeText(todo.Category))
+	}
+	if priority := ToICalPriority(todo.Priority); priority > 0 {
+		writeLine(buf, "PRIORITY:"+strconv.Itoa(priority))
+	}
+	if location := todo.Location; location != nil && location.Name != "" {
+		writeLine(buf, "LOCATION:"+escapeText(location.Name))
+	}
+	writeLine(buf, "TRANSP:TRANSPARENT") // 截止时间不占用日程，不影响忙闲状态
+	if !todo.UpdatedAt.IsZero() {
+		writeLine(buf, "LAST-MODIFIED:"+todo.UpdatedAt.UTC().Format(utcFormat))
+	}
+	writeLine(buf, "END:VEVENT")
+}
+
+// encodeTodo 编码单个 VTODO 组件
+func encodeTodo(buf *bytes.Buffer, entry Entry) {
+	todo := entry.Todo
+	now := time.Now()
+
+	writeLine(buf, "BEGIN:VTODO")
+	writeLine(buf, "UID:"+escapeText(entry.UID))
+	writeLine(buf, "DTSTAMP:"+now.UTC().Format(utcFormat))
+	writeLine(buf, "SUMMARY:"+escapeText(todo.Title))
+	if todo.Description != "" {
+		writeLine(buf, "DESCRIPTION:"+escapeText(todo.Description))
+	}
+	if todo.Category != "" {
+		writeLine(buf, "CATEGORIES:"+escapeText(todo.Category))
+	}
+	if priority := ToICalPriority(todo.Priority); priority > 0 {
+		writeLine(buf, "PRIORITY:"+strconv.Itoa(priority))
+	}
+	if todo.HasDueDate() {
+		writeLine(buf, "DUE:"+todo.DueDate.UTC().Format(utcFormat))
+	}
+	if todo.Completed {
+		writeLine(buf, "STATUS:COMPLETED")
+		writeLine(buf, "PERCENT-COMPLETE:100")
+		writeLine(buf, "COMPLETED:"+todo.UpdatedAt.UTC().Format(utcFormat))
+	} else {
+		writeLine(buf, "STATUS:NEEDS-ACTION")
+	}
+	if location := todo.Location; location != nil {
+		if location.Name != "" {
+			writeLine(buf, "LOCATION:"+escapeText(location.Name))
+		}
+		if location.HasCoordinates() {
+			writeLine(buf, fmt.Sprintf("GEO:%s;%s",
+				strconv.FormatFloat(*location.Lat, 'f', -1, 64),
+				strconv.FormatFloat(*location.Lng, 'f', -1, 64)))
+		}
+	}
+	if !todo.CreatedAt.IsZero() {
+		writeLine(buf, "CREATED:"+todo.CreatedAt.UTC().Format(utcFormat))
+	}
+	if !todo.UpdatedAt.IsZero() {
+		writeLine(buf, "LAST-MODIFIED:"+todo.UpdatedAt.UTC().Format(utcFormat))
+	}
+	writeLine(buf, "END:VTODO")
+}
+
+// Todo 从 iCalendar 数据中解析出的待办事项
+type Todo struct {
+	UID     string              // 组件的UID
+	Request *models.TodoRequest // 可以直接用于创建或更新待办事项的请求
+}
+
+// Decode 解析 iCalendar 数据中的第一个 VTODO 组件
+// 不认识的属性会被忽略，其它组件（如 VTIMEZONE）会被跳过
+func Decode(data []byte) (*Todo, error) {
+	lines := unfold(data)
+
+	var result *Todo
+	depth := 0 // 当前所在的 VTODO 内部嵌套层级（如 VALARM），只处理 VTODO 本身的属性
+	for _, line := range lines {
+		name, params, value, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO") && result == nil:
+			result = &Todo{Request: &models.TodoRequest{}}
+			depth = 1
+			continue
+		case name == "BEGIN" && depth > 0:
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			if depth == 0 {
+				if result.Request.Title == "" {
+					return nil, errors.New("VTODO缺少SUMMARY")
+				}
+				return result, nil
+			}
+			continue
+		}
+		if depth != 1 {
+			continue
+		}
+
+		req := result.Request
+		switch name {
+		case "UID":
+			result.UID = unescapeText(value)
+		case "SUMMARY":
+			req.Title = unescapeText(value)
+		case "DESCRIPTION":
+			req.Description = unescapeText(value)
+		case "CATEGORIES":
+			// 待办事项只有一个分类，多个分类时取第一个
+			req.Category = unescapeText(splitText(value)[0])
+		case "PRIORITY":
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("无效的PRIORITY: %s", value)
+			}
+			req.Priority = FromICalPriority(priority)
+		case "DUE":
+			due, err := parseTime(value, params)
+			if err != nil {
+				return nil, err
+			}
+			req.DueDate = &due
+		case "STATUS":
+			if strings.EqualFold(value, "COMPLETED") {
+				req.Completed = true
+			}
+		case "COMPLETED":
+			req.Completed = true
+		case "PERCENT-COMPLETE":
+			if value == "100" {
+				req.Completed = true
+			}
+		case "LOCATION":
+			if req.Location == nil {
+				req.Location = &models.Location{}
+			}
+			req.Location.Name = unescapeText(value)
+		case "GEO":
+			lat, lng, err := parseGeo(value)
+			if err != nil {
+				return nil, err
+			}
+			if req.Location == nil {
+				req.Location = &models.Location{}
+			}
+			req.Location.Lat, req.Location.Lng = &lat, &lng
+		}
+	}
+
+	if result != nil {
+		return nil, errors.New("VTODO没有结束")
+	}
+	return nil, ErrNoTodo
+}
+
+// ToICalPriority 将待办事项优先级（1-5，5最高）转换为 iCalendar 优先级（1-9，1最高，0表示未定义）
+func ToICalPriority(priority models.Priority) int {
+	if priority < 1 || priority > 5 {
+		return 0
+	}
+	return 11 - 2*int(priority) // 5->1, 4->3, 3->5, 2->7, 1->9
+}
+
+// FromICalPriority 将 iCalendar 优先级转换为待办事项优先级，ToICalPriority 的逆运算
+func FromICalPriority(priority int) models.Priority {
+	if priority < 1 || priority > 9 {
+		return 0
+	}
+	return models.Priority((11 - priority) / 2) // 1,2->5, 3,4->4, 5,6->3, 7,8->2, 9->1
+}
+
+// writeLine 写入一行内容，超过75字节时按 RFC 5545 折行（不会截断多字节字符）
+func writeLine(buf *bytes.Buffer, line string) {
+	const limit = 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// isRuneStart 判断字节是否是一个UTF-8字符的起始字节
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// unfold 按行拆分并合并折行
+func unfold(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseLine 解析一行内容，返回大写的属性名、参数和值
+// 例如 DUE;TZID=Asia/Shanghai:20240101T090000
+func parseLine(line string) (string, map[string]string, string, error) {
+	// 冒号可能出现在带引号的参数值中，需要跳过引号内的内容
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("无效的iCalendar行: %s", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], nil
+}
+
+// parseTime 解析日期时间，支持UTC时间、带TZID的本地时间、不带时区的本地时间和全天日期
+func parseTime(value string, params map[string]string) (time.Time, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(dateFormat) {
+		return time.ParseInLocation(dateFormat, value, time.Local)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(utcFormat, value)
+	}
+
+	location := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	parsed, err := time.ParseInLocation(floatingFormat, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间: %s", value)
+	}
+	return parsed, nil
+}
+
+// parseGeo 解析 GEO 属性，格式为 纬度;经度
+func parseGeo(value string) (float64, float64, error) {
+	latText, lngText, ok := strings.Cut(value, ";")
+	if !ok {
+		return 0, 0, fmt.Errorf("无效的GEO: %s", value)
+	}
+	lat, err := strconv.ParseFloat(latText, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的GEO: %s", value)
+	}
+	lng, err := strconv.ParseFloat(lngText, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的GEO: %s", value)
+	}
+	return lat, lng, nil
+}
+
+// textEscaper 文本值转义
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText 转义文本值中的特殊字符
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// unescapeText 还原转义的文本值
+func unescapeText(text string) string {
+	var builder strings.Builder
+	escaped := false
+	for _, r := range text {
+		if escaped {
+			switch r {
+			case 'n', 'N':
+				builder.WriteRune('\n')
+			default:
+				builder.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// splitText 按未转义的逗号拆分多值文本
+func splitText(text string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++ // 跳过被转义的字符
+		case ',':
+			values = append(values, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, text[start:])
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// AgingPolicy 优先级老化策略
+// 启用后，未完成事项的有效优先级会随着截止日期临近而提高，过期后继续逐步提高，
+// 避免低优先级事项长期无人处理；事项本身保存的优先级不会被修改
+type AgingPolicy struct {
+	Enabled         bool // 是否启用
+	DueSoonDays     int  // 距截止日期不超过多少天时开始提升
+	DueSoonBoost    int  // 临近截止日期时提升的级数
+	OverdueBoost    int  // 过期时提升的级数
+	OverdueStepDays int  // 过期后每隔多少天再提升一级，0表示不再继续提升
+	MaxPriority     int  // 有效优先级上限，0表示不限制
+}
+
+// EffectivePriority 计算待办事项在指定时间的有效优先级
+// 未启用策略、事项已完成或没有截止日期时，有效优先级等于事项本身的优先级
+func (p *AgingPolicy) EffectivePriority(todo *Todo, now time.Time) int {
+	priority := int(todo.Priority)
+	if p == nil || !p.Enabled || todo.Completed || !todo.HasDueDate() {
+		return priority
+	}
+	due := *todo.DueDate
+
+	switch {
+	case due.Before(now):
+		priority += p.OverdueBoost
+		if p.OverdueStepDays > 0 {
+			overdueDays := int(now.Sub(due) / (24 * time.Hour))
+			priority += overdueDays / p.OverdueStepDays
+		}
+	case due.Sub(now) <= time.Duration(p.DueSoonDays)*24*time.Hour:
+		priority += p.DueSoonBoost
+	}
+
+	if p.MaxPriority > 0 && priority > p.MaxPriority {
+		priority = p.MaxPriority
+	}
+	return priority
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ArchivedTodo 归档文件中的待办事项
+type ArchivedTodo struct {
+	Todo       *Todo     `json:"todo"`        // 归档时的待办事项
+	Archive    string    `json:"archive"`     // 所在的归档文件名
+	ArchivedAt time.Time `json:"archived_at"` // 归档时间
+}
+
+// ArchiveResult 一次归档的结果
+type ArchiveResult struct {
+	Archive  string `json:"archive,omitempty"` // 归档文件名，没有需要归档的事项时为空
+	Archived int    `json:"archived"`          // 归档并从存储中移除的事项数
+	Skipped  int    `json:"skipped"`           // 写入归档文件后被修改或删除、因此没有移除的事项数
+	Size     int64  `json:"size"`              // 归档文件大小（字节）
+	IDs      []int  `json:"ids"`               // 从存储中移除的事项ID
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// 审计日志的操作类型
+const (
+	AuditCreate   = "create"   // 创建待办事项
+	AuditUpdate   = "update"   // 修改待办事项
+	AuditComplete = "complete" // 把未完成的事项标记为完成
+	AuditDelete   = "delete"   // 删除待办事项
+)
+
+// 审计日志中的特殊操作者
+const (
+	AuditActorSystem    = "system"    // 定时任务等不是由请求触发的修改
+	AuditActorAnonymous = "anonymous" // 请求中没有操作者
+)
+
+// auditIgnoredFields 比较前后内容时忽略的字段，每次修改都会变化
+var auditIgnoredFields = map[string]bool{"updated_at": true, "version": true}
+
+// IsValidAuditAction 检查审计日志的操作类型是否有效
+func IsValidAuditAction(action string) bool {
+	switch action {
+	case AuditCreate, AuditUpdate, AuditComplete, AuditDelete:
+		return true
+	}
+	return false
+}
+
+// AuditChange 一个字段的变化，值为字段的JSON编码，创建前和删除后的值为空
+type AuditChange struct {
+	Field  string          `json:"field"`            // 字段名，与待办事项的JSON字段名一致
+	Before json.RawMessage `json:"before,omitempty"` // 修改前的值
+	After  json.RawMessage `json:"after,omitempty"`  // 修改后的值
+}
+
+// AuditEntry 审计日志中的一条记录
+type AuditEntry struct {
+	ID         string        `json:"id"`                    // 记录ID，按时间先后排序
+	Action     string        `json:"action"`                // 操作类型
+	TodoID     int           `json:"todo_id"`               // 被修改的待办事项ID
+	Actor      string        `json:"actor"`                 // 操作者
+	RemoteAddr string        `json:"remote_addr,omitempty"` // 请求来源地址，定时任务没有
+	Before     *Todo         `json:"before,omitempty"`      // 修改前的待办事项，创建时没有
+	After      *Todo         `json:"after,omitempty"`       // 修改后的待办事项，删除时没有
+	Changes    []AuditChange `json:"changes"`               // 前后不同的字段，不包括更新时间和版本号
+	CreatedAt  time.Time     `json:"created_at"`            // 操作时间
+}
+
+// NewAuditEntry 根据修改前后的待办事项生成审计记录，操作类型由前后内容推导
+func NewAuditEntry(before, after *Todo, actor, remoteAddr string, now time.Time) *AuditEntry {
+	entry := &AuditEntry{
+		Actor:      actor,
+		RemoteAddr: remoteAddr,
+		Before:     before,
+		After:      after,
+		Changes:    DiffTodos(before, after),
+		CreatedAt:  now,
+	}
+	switch {
+	case before == nil:
+		entry.Action, entry.TodoID = AuditCreate, after.ID
+	case after == nil:
+		entry.Action, entry.TodoID = AuditDelete, before.ID
+	case !before.Completed && after.Completed:
+		entry.Action, entry.TodoID = AuditComplete, after.ID
+	default:
+		entry.Action, entry.TodoID = AuditUpdate, after.ID
+	}
+	return entry
+}
+
+// DiffTodos 比较两个待办事项JSON编码后的各个字段，按字段名返回不同的字段
+// 任一方为nil时视为所有字段都不存在
+func DiffTodos(before, after *Todo) []AuditChange {
+	beforeFields, afterFields := todoFields(before), todoFields(after)
+
+	names := make(map[string]bool, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names[name] = true
+	}
+	for name := range afterFields {
+		names[name] = true
+	}
+
+	changes := []AuditChange{}
+	for name := range names {
+		if auditIgnoredFields[name] || bytes.Equal(beforeFields[name], afterFields[name]) {
+			continue
+		}
+		changes = append(changes, AuditChange{Field: name, Before: beforeFields[name], After: afterFields[name]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// todoFields 把待办事项编码为字段名到JSON值的映射
+func todoFields(todo *Todo) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if todo == nil {
+		return fields
+	}
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// BackupInfo 备份文件信息
+type BackupInfo struct {
+	Name      string    `json:"name"`       // 文件名，恢复时通过 ?file= 指定
+	Format    string    `json:"format"`     // 格式：json 或 sql
+	Size      int64     `json:"size"`       // 文件大小（字节）
+	CreatedAt time.Time `json:"created_at"` // 备份时间
+}
+
+// RestoreResult 从备份恢复的结果
+type RestoreResult struct {
+	Source       string `json:"source"`                  // 数据来源：备份文件名，或 request 表示请求体
+	Todos        int    `json:"todos"`                   // 恢复的待办事项数
+	Meta         int    `json:"meta"`                    // 恢复的附属数据条数
+	SafetyBackup string `json:"safety_backup,omitempty"` // 恢复前自动备份当前数据的文件名，未启用定期备份时为空
+}
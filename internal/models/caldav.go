@@ -0,0 +1,10 @@
+package models
+
+// CalDAVResource CalDAV 资源与待办事项的对应关系
+// CalDAV 客户端创建任务时会自己决定资源名称和UID，同步时必须原样返回，因此需要保存这个对应关系；
+// 服务端创建的事项没有对应关系，资源名称为 {id}.ics
+type CalDAVResource struct {
+	Name   string `json:"name"`    // 资源名称，例如 "6F1C2A.ics"
+	TodoID int    `json:"todo_id"` // 对应的待办事项ID
+	UID    string `json:"uid"`     // 客户端提供的 VTODO UID
+}
@@ -0,0 +1,18 @@
+package models
+
+// CalendarMonth 日历月视图，按截止日期把一个月的待办事项分到每一天
+type CalendarMonth struct {
+	Year     int           `json:"year"`     // 年
+	Month    int           `json:"month"`    // 月，1到12
+	Timezone string        `json:"timezone"` // 划分日期使用的时区
+	Total    int           `json:"total"`    // 本月到期的事项总数
+	Days     []CalendarDay `json:"days"`     // 本月每一天，没有事项的日期也包含在内
+}
+
+// CalendarDay 日历中的一天
+type CalendarDay struct {
+	Date      string         `json:"date"`      // 日期，2006-01-02 格式
+	Weekday   int            `json:"weekday"`   // 星期，0为星期日
+	Completed int            `json:"completed"` // 当天到期的事项中已完成的数量
+	Todos     []TodoResponse `json:"todos"`     // 当天到期的事项，按截止时间升序
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidRemindOffset 默认提醒时间为负数
+var ErrInvalidRemindOffset = errors.New("remind_offset 不能为负数")
+
+// CategoryDefaults 分类默认设置
+// 在某个分类下创建待办事项且未显式指定相应字段时，使用这里的默认值
+type CategoryDefaults struct {
+	Category     string   `json:"category"`                // 分类名称
+	Priority     Priority `json:"priority,omitempty"`      // 默认优先级（1-5 或名称，0表示不设置）
+	Description  string   `json:"description,omitempty"`   // 默认描述模板
+	RemindOffset int      `json:"remind_offset,omitempty"` // 默认在截止时间前多少分钟提醒（0表示不设置）
+}
+
+// Apply 将默认设置应用到创建请求
+// 只填充请求中未显式给出的字段，已有的值保持不变；提醒时间只在设置了截止时间时按 RemindOffset 计算
+func (d *CategoryDefaults) Apply(req *TodoRequest) {
+	if req.Priority == 0 && d.Priority != 0 {
+		req.Priority = d.Priority
+	}
+	if req.Description == "" && d.Description != "" {
+		req.Description = d.Description
+	}
+	if req.RemindAt == nil && req.DueDate != nil && !req.DueDate.IsZero() && d.RemindOffset > 0 {
+		remindAt := req.DueDate.Add(-time.Duration(d.RemindOffset) * time.Minute)
+		req.RemindAt = &remindAt
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DashboardWidgets 仪表盘一次请求所需的全部数据块
+type DashboardWidgets struct {
+	Counters       DashboardCounters `json:"counters"`        // 计数器
+	Upcoming       []TodoResponse    `json:"upcoming"`        // 即将到期的待办事项，按截止时间升序
+	Overdue        []TodoResponse    `json:"overdue"`         // 已过期的待办事项，过期最久的在前
+	RecentActivity []Activity        `json:"recent_activity"` // 最近的活动，最新的在前
+	GeneratedAt    time.Time         `json:"generated_at"`    // 数据生成时间，缓存命中时早于请求时间
+	LastSeq        int64             `json:"last_seq"`        // 生成数据时已处理的最后一个事件序号
+}
+
+// DashboardCounters 仪表盘计数器
+type DashboardCounters struct {
+	Total      int            `json:"total"`       // 全部待办事项
+	Active     int            `json:"active"`      // 未完成
+	Completed  int            `json:"completed"`   // 已完成
+	Overdue    int            `json:"overdue"`     // 已过期
+	DueToday   int            `json:"due_today"`   // 今天到期（未完成）
+	Upcoming   int            `json:"upcoming"`    // 即将到期（未完成）
+	ByCategory map[string]int `json:"by_category"` // 按分类统计的未完成数量，没有分类的计入空字符串
+}
+
+// Activity 最近活动，由事件生成
+type Activity struct {
+	Seq       int64     `json:"seq"`             // 事件序号
+	Type      string    `json:"type"`            // 事件类型
+	TodoID    int       `json:"todo_id"`         // 相关的待办事项ID
+	Title     string    `json:"title,omitempty"` // 事件发生时的标题，删除事件没有标题
+	CreatedAt time.Time `json:"created_at"`      // 事件时间
+}
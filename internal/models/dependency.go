@@ -0,0 +1,30 @@
+package models
+
+import "slices"
+
+// Dependencies 待办事项的阻塞依赖：需要等待 BlockedBy 中的事项完成，目标未完成时下一步推荐会降低本事项的得分
+// 依赖与关联链接（relates_to、duplicates）不同：它有方向并影响推荐，单独保存，不在 Links 中
+type Dependencies struct {
+	TodoID    int   `json:"todo_id"`    // 被阻塞的待办事项ID
+	BlockedBy []int `json:"blocked_by"` // 需要先完成的待办事项ID，按添加顺序排列
+}
+
+// DependencyTarget 阻塞依赖的目标事项及其完成状态
+type DependencyTarget struct {
+	ID        int    `json:"id"`        // 目标待办事项ID
+	Title     string `json:"title"`     // 目标事项标题
+	Completed bool   `json:"completed"` // 目标事项是否已完成，全部完成后不再阻塞
+}
+
+// IsBlockedBy 判断是否等待指定的事项
+func (d *Dependencies) IsBlockedBy(targetID int) bool {
+	return slices.Contains(d.BlockedBy, targetID)
+}
+
+// Remove 删除对指定事项的依赖，返回是否有依赖被删除
+func (d *Dependencies) Remove(targetID int) bool {
+	kept := slices.DeleteFunc(d.BlockedBy, func(id int) bool { return id == targetID })
+	removed := len(kept) != len(d.BlockedBy)
+	d.BlockedBy = kept
+	return removed
+}
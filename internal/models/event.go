@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 事件类型
+const (
+	EventTodoCreated   = "todo.created"   // 创建待办事项
+	EventTodoUpdated   = "todo.updated"   // 更新待办事项（包括关联链接的变化）
+	EventTodoCompleted = "todo.completed" // 标记完成
+	EventTodoDeleted   = "todo.deleted"   // 删除待办事项
+	EventTodoOverdue   = "todo.overdue"   // 未完成的事项超过了截止时间，由定时任务检查，每个截止时间只记录一次
+	EventTodoReminder  = "todo.reminder"  // 到达事项的提醒时间，由定时任务检查，每个提醒时间只记录一次
+
+	EventStoreRecovered = "store.recovered" // 存储后端从不可用恢复，降级期间被拒绝的写请求可以重试；数据为降级和恢复的时间
+)
+
+// Event 发件箱中的事件
+// 序号单调递增，集成方保存最后处理的序号，崩溃重启后从该序号之后继续读取即可补上遗漏的事件
+type Event struct {
+	Seq       int64           `json:"seq"`            // 序号，由存储分配
+	Type      string          `json:"type"`           // 事件类型
+	TodoID    int             `json:"todo_id"`        // 相关的待办事项ID
+	Data      json.RawMessage `json:"data,omitempty"` // 事件发生后的待办事项，删除事件没有数据
+	CreatedAt time.Time       `json:"created_at"`     // 事件时间
+}
+
+// EventOffset 集成方已确认处理的事件位置
+type EventOffset struct {
+	Consumer  string    `json:"consumer"`   // 集成方名称，如 webhook、kafka
+	Seq       int64     `json:"seq"`        // 已处理的最后一个事件序号
+	UpdatedAt time.Time `json:"updated_at"` // 确认时间
+}
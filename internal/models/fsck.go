@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// FsckIssue 数据一致性检查发现的一个问题
+type FsckIssue struct {
+	Kind       string `json:"kind"`                // 问题类型
+	TodoID     int    `json:"todo_id,omitempty"`   // 相关的待办事项ID
+	Namespace  string `json:"namespace,omitempty"` // 相关附属数据的命名空间
+	Key        string `json:"key,omitempty"`       // 相关附属数据的键
+	Message    string `json:"message"`             // 问题描述
+	Repairable bool   `json:"repairable"`          // 是否可以自动修复
+	Repaired   bool   `json:"repaired"`            // 是否已修复
+}
+
+// FsckReport 数据一致性检查报告
+type FsckReport struct {
+	CheckedAt time.Time   `json:"checked_at"` // 检查时间
+	Todos     int         `json:"todos"`      // 检查的待办事项数
+	Meta      int         `json:"meta"`       // 检查的附属数据条数
+	Issues    []FsckIssue `json:"issues"`     // 发现的问题，没有问题时为空数组
+	Repaired  int         `json:"repaired"`   // 已修复的问题数
+}
+
+// Unresolved 返回尚未修复的问题数
+func (r *FsckReport) Unresolved() int {
+	return len(r.Issues) - r.Repaired
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// TodoRevision 待办事项的一个修订版本，由审计日志中的一条记录生成
+type TodoRevision struct {
+	Revision  int           `json:"revision"`   // 修订号，即这次修改后的版本号；删除记录为删除前的版本号
+	Action    string        `json:"action"`     // 操作类型：create、update、complete 或 delete
+	Actor     string        `json:"actor"`      // 操作者
+	Changes   []AuditChange `json:"changes"`    // 变化的字段及修改前后的值
+	CreatedAt time.Time     `json:"created_at"` // 修改时间
+	AuditID   string        `json:"audit_id"`   // 对应的审计记录ID
+}
+
+// NewTodoRevision 根据审计记录生成修订版本
+func NewTodoRevision(entry *AuditEntry) TodoRevision {
+	return TodoRevision{
+		Revision:  entry.Revision(),
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		Changes:   entry.Changes,
+		CreatedAt: entry.CreatedAt,
+		AuditID:   entry.ID,
+	}
+}
+
+// Revision 返回记录对应的修订号：修改后的版本号，删除时为删除前的版本号
+func (e *AuditEntry) Revision() int {
+	if e.After != nil {
+		return e.After.Version
+	}
+	if e.Before != nil {
+		return e.Before.Version
+	}
+	return 0
+}
+
+// RevertTo 把事项的内容恢复为修订版本中的内容
+// 恢复标题、描述、完成状态（含完成时间）、优先级、分类、截止时间、提醒时间、地点、标签和归档状态；
+// 关联链接指向的事项可能已经不存在，手动排序的位置与其它事项相关，这两项保持当前的值；子任务只通过子任务接口修改，也保持不变
+func (t *Todo) RevertTo(revision *Todo) {
+	t.Title = revision.Title
+	t.Description = revision.Description
+	t.Completed = revision.Completed
+	t.CompletedAt = CloneTime(revision.CompletedAt)
+	t.Priority = revision.Priority
+	t.Category = revision.Category
+	t.DueDate = CloneTime(revision.DueDate)
+	t.RemindAt = CloneTime(revision.RemindAt)
+	t.Location = revision.Location.Clone()
+	t.Tags = slices.Clone(revision.Tags)
+	t.Archived = revision.Archived
+}
@@ -0,0 +1,318 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONPatchContentType JSON Patch（RFC 6902）请求体的媒体类型
+const JSONPatchContentType = "application/json-patch+json"
+
+// ErrJSONPatchTestFailed JSON Patch 中的 test 操作与当前值不一致
+var ErrJSONPatchTestFailed = errors.New("test 操作与当前值不一致")
+
+// JSONPatchOperation JSON Patch 中的一个操作
+// op 为 add、remove、replace、move、copy 或 test；path、from 为 JSON Pointer（RFC 6901）
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`  // move、copy 的来源位置
+	Value json.RawMessage `json:"value,omitempty"` // add、replace、test 的值
+}
+
+// JSONPatch JSON Patch 文档，按顺序执行的操作列表，任一操作失败时整个文档都不生效
+type JSONPatch []JSONPatchOperation
+
+// Validate 检查每个操作的类型和必需的成员，不涉及要修改的文档
+func (p JSONPatch) Validate() error {
+	if len(p) == 0 {
+		return errors.New("JSON Patch 不能为空")
+	}
+	for i, op := range p {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return fmt.Errorf("第 %d 个操作（%s）缺少 value", i+1, op.Op)
+			}
+		case "move", "copy":
+			if _, err := parseJSONPointer(op.From); err != nil {
+				return fmt.Errorf("第 %d 个操作（%s）的 from 无效：%w", i+1, op.Op, err)
+			}
+		case "remove":
+		default:
+			return fmt.Errorf("第 %d 个操作的 op 无效：%q", i+1, op.Op)
+		}
+		if _, err := parseJSONPointer(op.Path); err != nil {
+			return fmt.Errorf("第 %d 个操作（%s）的 path 无效：%w", i+1, op.Op, err)
+		}
+	}
+	return nil
+}
+
+// ApplyTo 对完整的更新请求执行 JSON Patch，结果仍须是合法的更新请求（不允许未知字段）
+// 没有标签时 tags 视为 []，因此可以直接用 /tags/- 追加标签；test 失败时返回 ErrJSONPatchTestFailed
+func (p JSONPatch) ApplyTo(req *TodoRequest) error {
+	current := *req
+	if current.Tags == nil {
+		current.Tags = []string{}
+	}
+	data, err := json.Marshal(&current)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	for i, op := range p {
+		if doc, err = op.apply(doc); err != nil {
+			if errors.Is(err, ErrJSONPatchTestFailed) {
+				return fmt.Errorf("第 %d 个操作（test %s）：%w", i+1, op.Path, err)
+			}
+			return fmt.Errorf("第 %d 个操作（%s %s）失败：%w", i+1, op.Op, op.Path, err)
+		}
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	var patched TodoRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return fmt.Errorf("修改后的待办事项无效：%w", err)
+	}
+	*req = patched
+	return nil
+}
+
+// apply 对文档执行一个操作，返回修改后的文档（替换根节点时文档本身会改变）
+func (op JSONPatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add":
+		value, err := decodePatchValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(doc, path, value)
+	case "remove":
+		doc, _, err = removeJSONValue(doc, path)
+		return doc, err
+	case "replace":
+		value, err := decodePatchValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if doc, _, err = removeJSONValue(doc, path); err != nil {
+			return nil, err
+		}
+		return addJSONValue(doc, path, value)
+	case "move":
+		from, _ := parseJSONPointer(op.From)
+		if len(path) > len(from) && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("不能移动到自己的子节点")
+		}
+		doc, value, err := removeJSONValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(doc, path, value)
+	case "copy":
+		from, _ := parseJSONPointer(op.From)
+		value, err := getJSONValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(doc, path, deepCopyJSON(value))
+	case "test":
+		value, err := decodePatchValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		current, err := getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrJSONPatchTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("op 无效：%q", op.Op)
+}
+
+// parseJSONPointer 解析 JSON Pointer，"" 表示整个文档，~1 和 ~0 分别还原为 / 和 ~
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%q 必须以 / 开头", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// decodePatchValue 把操作中的 value 解码为通用的 JSON 值
+func decodePatchValue(raw json.RawMessage) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("value 无效：%w", err)
+	}
+	return value, nil
+}
+
+// arrayIndex 解析数组下标，allowEnd 为 true 时允许等于数组长度（用于 add 和 "-"）
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("数组下标无效：%q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("数组下标无效：%q", token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("数组下标超出范围：%d", index)
+	}
+	return index, nil
+}
+
+// getJSONValue 读取路径上的值，路径不存在时返回错误
+func getJSONValue(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("字段不存在：%q", token)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%q 的上级不是对象或数组", token)
+		}
+	}
+	return current, nil
+}
+
+// addJSONValue 在路径上添加值：对象中新增或覆盖字段，数组中在下标处插入（"-" 表示末尾）
+func addJSONValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getJSONValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return setJSONValue(doc, path[:len(path)-1], node)
+	}
+	return nil, fmt.Errorf("%q 的上级不是对象或数组", last)
+}
+
+// removeJSONValue 删除路径上的值并返回被删除的值，路径不存在时返回错误
+func removeJSONValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := getJSONValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("字段不存在：%q", last)
+		}
+		delete(node, last)
+		return doc, value, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[index]
+		node = append(node[:index:index], node[index+1:]...)
+		doc, err = setJSONValue(doc, path[:len(path)-1], node)
+		return doc, value, err
+	}
+	return nil, nil, fmt.Errorf("%q 的上级不是对象或数组", last)
+}
+
+// setJSONValue 用新值替换已存在的路径上的值，数组长度改变后需要写回上级
+func setJSONValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getJSONValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	}
+	return doc, nil
+}
+
+// deepCopyJSON 复制通用的 JSON 值，copy 操作之后两处的修改互不影响
+func deepCopyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopyJSON(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopyJSON(item)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,53 @@
+package models
+
+// 待办事项之间的关联类型
+// 关联只用于表达事项之间的关系，不会像阻塞依赖（见 Dependencies）那样影响推荐
+const (
+	LinkRelatesTo  = "relates_to" // 相关
+	LinkDuplicates = "duplicates" // 重复于目标事项
+)
+
+// TodoLink 待办事项之间的关联链接
+type TodoLink struct {
+	Type     string `json:"type"`      // 关联类型
+	TargetID int    `json:"target_id"` // 目标待办事项ID
+}
+
+// Backlink 反向链接，表示其它待办事项指向当前事项的关联
+type Backlink struct {
+	Type     string `json:"type"`      // 关联类型（从发起方的角度描述）
+	SourceID int    `json:"source_id"` // 发起关联的待办事项ID
+}
+
+// IsValidLinkType 判断关联类型是否受支持
+func IsValidLinkType(linkType string) bool {
+	return linkType == LinkRelatesTo || linkType == LinkDuplicates
+}
+
+// HasLink 判断是否已存在指定类型和目标的关联
+func (t *Todo) HasLink(linkType string, targetID int) bool {
+	for _, link := range t.Links {
+		if link.Type == linkType && link.TargetID == targetID {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveLinks 删除指向目标事项的关联，linkType为空时删除所有类型，返回是否有关联被删除
+func (t *Todo) RemoveLinks(linkType string, targetID int) bool {
+	kept := t.Links[:0]
+	for _, link := range t.Links {
+		if link.TargetID == targetID && (linkType == "" || link.Type == linkType) {
+			continue
+		}
+		kept = append(kept, link)
+	}
+
+	removed := len(kept) != len(t.Links)
+	t.Links = kept
+	if len(t.Links) == 0 {
+		t.Links = nil
+	}
+	return removed
+}
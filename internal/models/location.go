@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// earthRadiusKm 地球平均半径（公里），用于计算两点间的距离
+const earthRadiusKm = 6371.0
+
+// Location 待办事项的地点
+// Name 为自由文本，例如"3号仓库"；Lat/Lng 为可选的经纬度坐标，必须同时提供
+type Location struct {
+	Name string   `json:"name,omitempty"` // 地点名称或地址
+	Lat  *float64 `json:"lat,omitempty"`  // 纬度，-90 ~ 90
+	Lng  *float64 `json:"lng,omitempty"`  // 经度，-180 ~ 180
+}
+
+// Clone 深拷贝地点
+func (l *Location) Clone() *Location {
+	if l == nil {
+		return nil
+	}
+	cloned := &Location{Name: l.Name}
+	if l.Lat != nil {
+		lat := *l.Lat
+		cloned.Lat = &lat
+	}
+	if l.Lng != nil {
+		lng := *l.Lng
+		cloned.Lng = &lng
+	}
+	return cloned
+}
+
+// HasCoordinates 判断地点是否包含经纬度坐标
+func (l *Location) HasCoordinates() bool {
+	return l != nil && l.Lat != nil && l.Lng != nil
+}
+
+// Validate 校验地点：经纬度必须同时提供且在有效范围内，名称和坐标不能都为空
+func (l *Location) Validate() error {
+	if (l.Lat == nil) != (l.Lng == nil) {
+		return errors.New("纬度和经度必须同时提供")
+	}
+	if l.Name == "" && l.Lat == nil {
+		return errors.New("地点名称和坐标不能都为空")
+	}
+	if l.Lat != nil && (*l.Lat < -90 || *l.Lat > 90) {
+		return errors.New("纬度必须在 -90 到 90 之间")
+	}
+	if l.Lng != nil && (*l.Lng < -180 || *l.Lng > 180) {
+		return errors.New("经度必须在 -180 到 180 之间")
+	}
+	return nil
+}
+
+// DistanceKm 计算地点到指定坐标的球面距离（公里），使用 haversine 公式
+// 地点没有坐标时返回 +Inf
+func (l *Location) DistanceKm(lat, lng float64) float64 {
+	if !l.HasCoordinates() {
+		return math.Inf(1)
+	}
+
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat - *l.Lat)
+	dLng := toRad(lng - *l.Lng)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(*l.Lat))*math.Cos(toRad(lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
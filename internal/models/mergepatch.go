@@ -0,0 +1,79 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MergePatchContentType JSON Merge Patch（RFC 7386）请求体的媒体类型
+const MergePatchContentType = "application/merge-patch+json"
+
+// MergePatch JSON Merge Patch 文档：对象中的字段覆盖原值，null 删除字段（清空），嵌套对象递归合并，数组整体替换
+type MergePatch map[string]json.RawMessage
+
+// UnmarshalJSON 要求请求体为 JSON 对象；RFC 7386 允许用非对象替换整个文档，但待办事项只能按字段修改
+func (p *MergePatch) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return errors.New("JSON Merge Patch 必须是对象")
+	}
+	*p = fields
+	return nil
+}
+
+// ApplyTo 把合并补丁应用到完整的更新请求，结果仍须是合法的更新请求（不允许未知字段）
+// 标量字段为 null 时恢复零值，due_date、remind_at、location、tags 为 null 时清空
+func (p MergePatch) ApplyTo(req *TodoRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for name, raw := range p {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("字段 %s 的值无效：%w", name, err)
+		}
+		doc[name] = mergeJSONValue(doc[name], value)
+		if doc[name] == nil {
+			delete(doc, name)
+		}
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	var patched TodoRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return fmt.Errorf("修改后的待办事项无效：%w", err)
+	}
+	*req = patched
+	return nil
+}
+
+// mergeJSONValue 按 RFC 7386 合并一个值：补丁不是对象时直接替换，是对象时与原对象逐个字段合并
+func mergeJSONValue(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	merged, ok := target.(map[string]interface{})
+	if !ok {
+		merged = make(map[string]interface{}, len(fields))
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = mergeJSONValue(merged[name], value)
+	}
+	return merged
+}
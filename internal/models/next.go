@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// NextAction 下一步推荐的事项
+type NextAction struct {
+	Todo       TodoResponse `json:"todo"`       // 得分最高的待办事项
+	Score      float64      `json:"score"`      // 总得分
+	Breakdown  ScoreDetail  `json:"breakdown"`  // 各项得分
+	Candidates int          `json:"candidates"` // 参与评分的未完成事项数（不包括跳过的）
+	Skipped    int          `json:"skipped"`    // 暂时跳过的事项数
+}
+
+// ScoreDetail 下一步推荐的各项得分
+type ScoreDetail struct {
+	Priority float64 `json:"priority"` // 有效优先级得分
+	Due      float64 `json:"due"`      // 截止临近得分
+	Age      float64 `json:"age"`      // 已创建时间得分
+	Blocked  float64 `json:"blocked"`  // 被阻塞扣分（不大于0）
+}
+
+// NextSkip 下一步推荐中暂时跳过的事项
+type NextSkip struct {
+	TodoID    int       `json:"todo_id"`    // 待办事项ID
+	Until     time.Time `json:"until"`      // 跳过到什么时间，之后重新参与推荐
+	CreatedAt time.Time `json:"created_at"` // 跳过时间
+}
+
+// NextSkipResult 跳过推荐事项的结果
+type NextSkipResult struct {
+	Skipped NextSkip    `json:"skipped"` // 跳过记录
+	Next    *NextAction `json:"next"`    // 跳过之后的推荐事项，没有可推荐的事项时为null
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Nullable 部分更新中可以显式设为null的字段
+// Set 表示请求中包含该字段，Value 为nil表示请求中的值为null（清空）
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON 只有请求中包含该字段时才会被调用，因此可以区分未提供和 null
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		n.Value = nil
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
+
+// TodoPatch 部分更新待办事项请求（PATCH），只修改请求中提供的字段
+// due_date、location 为 null 时清空，其它字段为 null 时视为未提供
+type TodoPatch struct {
+	Title       *string             `json:"title"`
+	Description *string             `json:"description"`
+	Completed   *bool               `json:"completed"`
+	Priority    *Priority           `json:"priority"`
+	Category    *string             `json:"category"`
+	DueDate     Nullable[time.Time] `json:"due_date"`
+	RemindAt    Nullable[time.Time] `json:"remind_at"`
+	Location    Nullable[Location]  `json:"location"`
+	Tags        *[]string           `json:"tags"`    // 替换全部标签，[] 清空
+	Version     int                 `json:"version"` // 客户端读取到的版本号，非0时只有与当前版本一致才会更新，为0时不检查
+}
+
+// IsEmpty 请求中是否没有任何要修改的字段
+func (p *TodoPatch) IsEmpty() bool {
+	return p.Title == nil && p.Description == nil && p.Completed == nil && p.Priority == nil &&
+		p.Category == nil && !p.DueDate.Set && !p.RemindAt.Set && !p.Location.Set && p.Tags == nil
+}
+
+// Apply 把请求中提供的字段写入完整的更新请求，未提供的字段保持不变
+// 截止时间为零值时与 null 一样表示没有截止时间
+func (p *TodoPatch) Apply(req *TodoRequest) {
+	if p.Title != nil {
+		req.Title = *p.Title
+	}
+	if p.Description != nil {
+		req.Description = *p.Description
+	}
+	if p.Completed != nil {
+		req.Completed = *p.Completed
+	}
+	if p.Priority != nil {
+		req.Priority = *p.Priority
+	}
+	if p.Category != nil {
+		req.Category = *p.Category
+	}
+	if p.DueDate.Set {
+		req.DueDate = CloneTime(p.DueDate.Value)
+	}
+	if p.RemindAt.Set {
+		req.RemindAt = CloneTime(p.RemindAt.Value)
+	}
+	if p.Location.Set {
+		req.Location = p.Location.Value.Clone()
+	}
+	if p.Tags != nil {
+		req.Tags = append([]string(nil), *p.Tags...)
+	}
+	if p.Version != 0 {
+		req.Version = p.Version
+	}
+}
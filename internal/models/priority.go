@@ -0,0 +1,93 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Priority 优先级，1到5，数字越大越重要；0表示未设置（创建时由自动分类规则和分类默认设置填充）
+// 请求中可以使用数字或名称（如 "high"），响应和存储中始终为数字
+type Priority int
+
+// 优先级
+const (
+	PriorityNone     Priority = 0 // 未设置
+	PriorityLow      Priority = 1 // 低
+	PriorityNormal   Priority = 2 // 普通
+	PriorityHigh     Priority = 3 // 高
+	PriorityUrgent   Priority = 4 // 紧急
+	PriorityCritical Priority = 5 // 非常紧急
+)
+
+// priorityNames 优先级的名称，下标为优先级
+var priorityNames = []string{"", "low", "normal", "high", "urgent", "critical"}
+
+// ErrInvalidPriority 优先级不在0到5之间或名称无效
+var ErrInvalidPriority = fmt.Errorf("优先级必须是1到5之间的整数，或 %s 之一", strings.Join(priorityNames[1:], "、"))
+
+// IsValid 是否为有效的优先级（包括未设置）
+func (p Priority) IsValid() bool {
+	return p >= PriorityNone && p <= PriorityCritical
+}
+
+// Name 返回优先级的名称，未设置或无效时为空字符串
+func (p Priority) Name() string {
+	if p <= PriorityNone || p > PriorityCritical {
+		return ""
+	}
+	return priorityNames[p]
+}
+
+// String 返回优先级的名称，没有名称时返回数字
+func (p Priority) String() string {
+	if name := p.Name(); name != "" {
+		return name
+	}
+	return strconv.Itoa(int(p))
+}
+
+// ParsePriority 解析数字或名称（不区分大小写）形式的优先级，结果必须在0到5之间
+func ParsePriority(value string) (Priority, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if n, err := strconv.Atoi(value); err == nil {
+		if priority := Priority(n); priority.IsValid() {
+			return priority, nil
+		}
+		return 0, ErrInvalidPriority
+	}
+	for i, name := range priorityNames[1:] {
+		if value == name {
+			return Priority(i + 1), nil
+		}
+	}
+	return 0, ErrInvalidPriority
+}
+
+// UnmarshalJSON 接受数字或名称字符串；数字不检查范围，由接口校验时返回更明确的错误
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		priority, err := ParsePriority(value)
+		if err != nil {
+			return err
+		}
+		*p = priority
+		return nil
+	}
+
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return ErrInvalidPriority
+	}
+	*p = Priority(n)
+	return nil
+}
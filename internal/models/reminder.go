@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Reminder 事项的提醒
+type Reminder struct {
+	TodoID   int        `json:"todo_id"`            // 待办事项ID
+	Title    string     `json:"title"`              // 待办事项标题
+	RemindAt time.Time  `json:"remind_at"`          // 提醒时间
+	DueDate  *time.Time `json:"due_date,omitempty"` // 截止时间，没有时省略
+	Fired    bool       `json:"fired"`              // 是否已经发送
+}
+
+// NewReminder 生成事项的提醒，事项没有提醒时间时返回nil
+func NewReminder(todo *Todo, fired bool) *Reminder {
+	if todo.RemindAt == nil {
+		return nil
+	}
+	return &Reminder{
+		TodoID:   todo.ID,
+		Title:    todo.Title,
+		RemindAt: *todo.RemindAt,
+		DueDate:  CloneTime(todo.DueDate),
+		Fired:    fired,
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Rule 自动分类规则
+// 创建待办事项时，标题或描述中包含任一关键词（不区分大小写）即视为匹配，
+// 匹配的规则为请求中未显式给出的分类、优先级填入规则的值
+type Rule struct {
+	ID        string    `json:"id"`                 // 规则ID
+	Name      string    `json:"name,omitempty"`     // 规则名称，便于识别
+	Keywords  []string  `json:"keywords"`           // 关键词
+	Category  string    `json:"category,omitempty"` // 匹配时设置的分类，为空表示不设置
+	Priority  Priority  `json:"priority,omitempty"` // 匹配时设置的优先级（1-5 或名称，0表示不设置）
+	Order     int       `json:"order"`              // 匹配顺序，从小到大依次匹配
+	Disabled  bool      `json:"disabled,omitempty"` // 是否停用
+	CreatedAt time.Time `json:"created_at"`         // 创建时间
+	UpdatedAt time.Time `json:"updated_at"`         // 更新时间
+}
+
+// Validate 检查规则：至少有一个非空关键词，并且至少设置分类或优先级之一
+func (r *Rule) Validate() error {
+	if len(r.Keywords) == 0 {
+		return errors.New("至少需要一个关键词")
+	}
+	for _, keyword := range r.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return errors.New("关键词不能为空")
+		}
+	}
+	if !r.Priority.IsValid() {
+		return ErrInvalidPriority
+	}
+	if r.Category == "" && r.Priority == 0 {
+		return errors.New("规则至少需要设置分类或优先级")
+	}
+	return nil
+}
+
+// Matches 判断标题或描述是否包含规则的任一关键词（不区分大小写），停用的规则不匹配任何内容
+func (r *Rule) Matches(title, description string) bool {
+	if r.Disabled {
+		return false
+	}
+	text := strings.ToLower(title + "\n" + description)
+	for _, keyword := range r.Keywords {
+		if strings.Contains(text, strings.ToLower(strings.TrimSpace(keyword))) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyRules 按顺序把匹配的规则应用到创建请求，返回实际修改了请求的规则
+// 每个规则只填充请求中仍未给出的字段，因此显式指定的值和先匹配的规则优先
+func ApplyRules(rules []*Rule, req *TodoRequest) []*Rule {
+	var applied []*Rule
+	for _, rule := range rules {
+		if !rule.Matches(req.Title, req.Description) {
+			continue
+		}
+
+		changed := false
+		if req.Category == "" && rule.Category != "" {
+			req.Category = rule.Category
+			changed = true
+		}
+		if req.Priority == 0 && rule.Priority != 0 {
+			req.Priority = rule.Priority
+			changed = true
+		}
+		if changed {
+			applied = append(applied, rule)
+		}
+	}
+	return applied
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ShareLink 公开分享链接
+// 持有令牌的任何人都可以只读地查看对应的待办事项，无需账号；令牌可以设置过期时间，也可以随时撤销
+type ShareLink struct {
+	Token     string    `json:"token"`                // 访问令牌
+	TodoID    int       `json:"todo_id"`              // 分享的待办事项ID
+	CreatedAt time.Time `json:"created_at"`           // 创建时间
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // 过期时间，零值表示永不过期
+}
+
+// Expired 判断分享链接在指定时间是否已过期
+func (l *ShareLink) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SnapshotVersion 当前快照格式版本
+const SnapshotVersion = 1
+
+// Snapshot 数据快照
+// 完整记录存储中的数据（包括ID和时间戳），用于备份恢复以及在不同存储后端之间迁移
+type Snapshot struct {
+	Version    int                                   `json:"version"`        // 快照格式版本
+	ExportedAt time.Time                             `json:"exported_at"`    // 导出时间
+	NextID     int                                   `json:"next_id"`        // 导出时的下一个可用ID，恢复后不会再分配小于它的ID
+	Todos      []*Todo                               `json:"todos"`          // 全部待办事项
+	Meta       map[string]map[string]json.RawMessage `json:"meta,omitempty"` // 附属数据，按命名空间和键组织
+
+	LogSeq int64 `json:"log_seq,omitempty"` // 快照已包含的最后一条操作日志序号，仅内存存储的检查点使用
+}
+
+// MaxTodoID 返回快照中最大的待办事项ID，没有数据时返回0
+func (s *Snapshot) MaxTodoID() int {
+	maxID := 0
+	for _, todo := range s.Todos {
+		if todo.ID > maxID {
+			maxID = todo.ID
+		}
+	}
+	return maxID
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 可用的排序字段
+const (
+	SortByID        = "id"
+	SortByTitle     = "title"
+	SortByPriority  = "priority"
+	SortByDueDate   = "due_date"
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByPosition  = "position" // 按手动排序的位置排序
+
+	SortByEffectivePriority = "effective_priority" // 按优先级老化后的有效优先级排序
+	SortByRelevance         = "relevance"          // 按与搜索词的相关度排序，只能配合搜索词使用，需要启用全文索引
+)
+
+// IsValidSortField 判断排序字段是否受支持
+func IsValidSortField(field string) bool {
+	switch field {
+	case SortByID, SortByTitle, SortByPriority, SortByDueDate, SortByCreatedAt, SortByUpdatedAt, SortByPosition, SortByEffectivePriority, SortByRelevance:
+		return true
+	}
+	return false
+}
+
+// SortKey 多字段排序中的一个排序字段
+type SortKey struct {
+	Field string // 排序字段
+	Desc  bool   // 是否降序
+}
+
+// maxSortKeys 多字段排序最多允许的字段数
+const maxSortKeys = 5
+
+// ParseSortKeys 解析 "priority,-due_date" 格式的多字段排序
+// 字段之间用逗号分隔，前缀 - 表示降序，+ 或没有前缀表示升序；字段必须受支持且不能重复，
+// 按相关度排序不能与其它字段组合
+func ParseSortKeys(value string) ([]SortKey, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) > maxSortKeys {
+		return nil, fmt.Errorf("最多按 %d 个字段排序", maxSortKeys)
+	}
+
+	keys := make([]SortKey, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		key := SortKey{Field: part}
+		switch {
+		case strings.HasPrefix(part, "-"):
+			key = SortKey{Field: part[1:], Desc: true}
+		case strings.HasPrefix(part, "+"):
+			key.Field = part[1:]
+		}
+		if !IsValidSortField(key.Field) {
+			return nil, fmt.Errorf("无效的排序字段: %q", part)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("排序字段重复: %s", key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	if seen[SortByRelevance] && len(keys) > 1 {
+		return nil, errors.New("按相关度排序不能与其它字段组合")
+	}
+	return keys, nil
+}
+
+// SortTodos 按指定字段对待办事项排序
+// desc为true时降序排列；字段值相同时按ID升序，保证结果稳定；
+// 按截止日期排序时，没有截止日期的事项无论升序降序都排在最后
+func SortTodos(todos []*Todo, field string, desc bool) {
+	SortTodosWithAging(todos, field, desc, nil)
+}
+
+// SortTodosWithAging 与 SortTodos 相同，按有效优先级排序时使用指定的老化策略
+// policy为nil或未启用时，有效优先级等于事项本身的优先级
+func SortTodosWithAging(todos []*Todo, field string, desc bool, policy *AgingPolicy) {
+	SortTodosBy(todos, []SortKey{{Field: field, Desc: desc}}, policy)
+}
+
+// SortTodosBy 按多个字段对待办事项排序，前一个字段相同时比较下一个字段，全部相同时按ID升序
+// 每个字段的规则与 SortTodos 一致：按截止日期排序时，没有截止日期的事项无论升序降序都排在后面
+func SortTodosBy(todos []*Todo, keys []SortKey, policy *AgingPolicy) {
+	now := time.Now()
+	sort.SliceStable(todos, func(i, j int) bool {
+		a, b := todos[i], todos[j]
+		for _, key := range keys {
+			// 没有截止日期的排在后面
+			if key.Field == SortByDueDate && a.HasDueDate() != b.HasDueDate() {
+				return a.HasDueDate()
+			}
+
+			var cmp int
+			if key.Field == SortByEffectivePriority {
+				cmp = compareInts(policy.EffectivePriority(a, now), policy.EffectivePriority(b, now))
+			} else {
+				cmp = compareTodos(a, b, key.Field)
+			}
+			if cmp != 0 {
+				if key.Desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+		}
+		return a.ID < b.ID
+	})
+}
+
+// compareTodos 比较两个待办事项的指定字段，返回-1、0或1
+func compareTodos(a, b *Todo, field string) int {
+	switch field {
+	case SortByTitle:
+		return strings.Compare(a.Title, b.Title)
+	case SortByPriority:
+		return compareInts(int(a.Priority), int(b.Priority))
+	case SortByDueDate:
+		return a.DueAt().Compare(b.DueAt())
+	case SortByCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case SortByUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortByPosition:
+		return compareInts(a.Position, b.Position)
+	default:
+		return compareInts(a.ID, b.ID)
+	}
+}
+
+// compareInts 比较两个整数，返回-1、0或1
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
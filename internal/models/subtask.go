@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxSubtasks 一个事项最多的子任务数
+const MaxSubtasks = 100
+
+// maxSubtaskTitleLength 子任务标题最多的字符数，与事项标题一致
+const maxSubtaskTitleLength = 200
+
+// Subtask 事项下的子任务，随事项一起保存，不出现在事项列表中
+type Subtask struct {
+	ID          int        `json:"id"`                     // 子任务ID，在所属事项内唯一
+	Title       string     `json:"title"`                  // 标题
+	Completed   bool       `json:"completed"`              // 是否已完成
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // 完成时间，未完成时为nil
+	CreatedAt   time.Time  `json:"created_at"`             // 创建时间
+}
+
+// SubtaskProgress 子任务的完成情况
+type SubtaskProgress struct {
+	Total     int `json:"total"`     // 子任务数
+	Completed int `json:"completed"` // 已完成的子任务数
+	Percent   int `json:"percent"`   // 完成百分比（向下取整），没有子任务时为0
+}
+
+// NormalizeSubtaskTitle 去掉标题首尾空白并检查长度
+func NormalizeSubtaskTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errors.New("子任务标题必填")
+	}
+	if utf8.RuneCountInString(title) > maxSubtaskTitleLength {
+		return "", fmt.Errorf("子任务标题不能超过 %d 个字符", maxSubtaskTitleLength)
+	}
+	return title, nil
+}
+
+// AddSubtask 添加子任务，ID为现有最大ID加1，返回新增的子任务
+func (t *Todo) AddSubtask(title string, now time.Time) (*Subtask, error) {
+	if len(t.Subtasks) >= MaxSubtasks {
+		return nil, fmt.Errorf("一个事项最多 %d 个子任务", MaxSubtasks)
+	}
+	id := 1
+	for _, subtask := range t.Subtasks {
+		if subtask.ID >= id {
+			id = subtask.ID + 1
+		}
+	}
+	t.Subtasks = append(t.Subtasks, Subtask{ID: id, Title: title, CreatedAt: now})
+	return &t.Subtasks[len(t.Subtasks)-1], nil
+}
+
+// Subtask 按ID查找子任务，不存在时返回nil
+func (t *Todo) Subtask(id int) *Subtask {
+	for i := range t.Subtasks {
+		if t.Subtasks[i].ID == id {
+			return &t.Subtasks[i]
+		}
+	}
+	return nil
+}
+
+// RemoveSubtask 删除子任务，返回是否存在
+func (t *Todo) RemoveSubtask(id int) bool {
+	for i, subtask := range t.Subtasks {
+		if subtask.ID == id {
+			t.Subtasks = append(t.Subtasks[:i:i], t.Subtasks[i+1:]...)
+			if len(t.Subtasks) == 0 {
+				t.Subtasks = nil
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// SetCompleted 修改子任务的完成状态，完成时记录完成时间，重新打开时清除
+func (s *Subtask) SetCompleted(completed bool, now time.Time) {
+	if s.Completed == completed {
+		return
+	}
+	s.Completed = completed
+	if completed {
+		s.CompletedAt = &now
+	} else {
+		s.CompletedAt = nil
+	}
+}
+
+// SubtaskProgress 统计子任务的完成情况
+func (t *Todo) SubtaskProgress() SubtaskProgress {
+	progress := SubtaskProgress{Total: len(t.Subtasks)}
+	for _, subtask := range t.Subtasks {
+		if subtask.Completed {
+			progress.Completed++
+		}
+	}
+	if progress.Total > 0 {
+		progress.Percent = progress.Completed * 100 / progress.Total
+	}
+	return progress
+}
+
+// cloneSubtasks 深拷贝子任务列表
+func cloneSubtasks(subtasks []Subtask) []Subtask {
+	if subtasks == nil {
+		return nil
+	}
+	cloned := make([]Subtask, len(subtasks))
+	for i, subtask := range subtasks {
+		cloned[i] = subtask
+		cloned[i].CompletedAt = CloneTime(subtask.CompletedAt)
+	}
+	return cloned
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// 标签限制
+const (
+	MaxTags      = 20 // 一个事项最多的标签数
+	MaxTagLength = 50 // 单个标签最多的字符数
+)
+
+// TagCount 标签及使用它的事项数
+type TagCount struct {
+	Name   string `json:"name"`   // 标签
+	Count  int    `json:"count"`  // 带有该标签的事项数
+	Active int    `json:"active"` // 其中未完成的事项数
+}
+
+// NormalizeTag 规范化单个标签：去掉首尾空白并转为小写，标签为空或包含不允许的字符时返回错误
+// 标签不能包含逗号（?tag= 用逗号分隔多个标签）、双引号和反斜杠
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case tag == "":
+		return "", fmt.Errorf("标签不能为空")
+	case utf8.RuneCountInString(tag) > MaxTagLength:
+		return "", fmt.Errorf("标签 %q 超过 %d 个字符", tag, MaxTagLength)
+	case strings.ContainsAny(tag, `,"\`):
+		return "", fmt.Errorf("标签 %q 不能包含逗号、双引号或反斜杠", tag)
+	}
+	return tag, nil
+}
+
+// NormalizeTags 规范化标签列表：逐个规范化并去掉重复项，保持原有顺序；没有标签时返回nil
+func NormalizeTags(tags []string) ([]string, error) {
+	var results []string
+	for _, tag := range tags {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(results, normalized) {
+			results = append(results, normalized)
+		}
+	}
+	if len(results) > MaxTags {
+		return nil, fmt.Errorf("一个事项最多 %d 个标签", MaxTags)
+	}
+	return results, nil
+}
+
+// HasTags 事项是否带有全部指定的标签
+func (t *Todo) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasAnyTag 事项是否带有任一指定的标签
+func (t *Todo) HasAnyTag(tags []string) bool {
+	return slices.ContainsFunc(t.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+}
+
+// ReplaceTags 把事项中属于 sources 的标签替换为 target（已有 target 时只去掉这些标签），返回标签是否有变化
+func (t *Todo) ReplaceTags(sources []string, target string) bool {
+	tags := make([]string, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		if slices.Contains(sources, tag) {
+			tag = target
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if slices.Equal(tags, t.Tags) {
+		return false
+	}
+	t.Tags = tags
+	return true
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// NotificationTemplate 自定义的通知模板
+// 模板使用 Go 的 text/template 语法，可以引用事件和待办事项的字段，如 {{.Todo.Title}}；
+// 每种通知（集成方和消息类型，如 email.reminder）最多保存一个自定义模板，没有时使用内置的默认模板
+type NotificationTemplate struct {
+	Name      string    `json:"name"`              // 通知类型，如 email.reminder
+	Subject   string    `json:"subject,omitempty"` // 主题模板，只有邮件使用
+	Body      string    `json:"body"`              // 正文模板
+	UpdatedAt time.Time `json:"updated_at"`        // 保存时间
+}
@@ -1,83 +1,221 @@
 package models
 
 import (
+	"slices"
 	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/i18n"
 )
 
 // Todo 待办事项模型
 type Todo struct {
-	ID          int       `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Description string    `json:"description,omitempty" db:"description"`
-	Completed   bool      `json:"completed" db:"completed"`
-	Priority    int       `json:"priority" db:"priority"`
-	Category    string    `json:"category,omitempty" db:"category"`
-	DueDate     time.Time `json:"due_date,omitempty" db:"due_date"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int        `json:"id" db:"id"`
+	Title       string     `json:"title" db:"title"`
+	Description string     `json:"description,omitempty" db:"description"`
+	Completed   bool       `json:"completed" db:"completed"`
+	Priority    Priority   `json:"priority" db:"priority"`
+	Category    string     `json:"category,omitempty" db:"category"`
+	DueDate     *time.Time `json:"due_date,omitempty" db:"due_date"` // 截止时间，为nil表示没有截止时间
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	Version     int        `json:"version" db:"version"`                     // 版本号，创建时为1，每次修改加1，用于乐观并发控制
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"` // 完成时间，未完成时为nil；记录完成时间之前就已完成的旧数据也为nil
+	Archived    bool       `json:"archived,omitempty" db:"archived"`         // 是否已归档，归档的事项默认不出现在列表中，仍保留在存储里
+	Position    int        `json:"position" db:"position"`                   // 手动排序的位置，按 position 排序时小的在前；新建的事项为0
+	RemindAt    *time.Time `json:"remind_at,omitempty" db:"remind_at"`       // 提醒时间，到达时由定时任务发送提醒，为nil表示没有提醒
+
+	Links    []TodoLink `json:"links,omitempty" db:"links"`       // 指向其它待办事项的关联链接
+	Location *Location  `json:"location,omitempty" db:"location"` // 地点，可选
+	Tags     []string   `json:"tags,omitempty" db:"tags"`         // 标签，已规范化为小写且不重复
+	Subtasks []Subtask  `json:"subtasks,omitempty" db:"subtasks"` // 子任务，通过子任务接口修改
+}
+
+// Clone 深拷贝待办事项，修改副本不会影响原对象
+func (t *Todo) Clone() *Todo {
+	cloned := *t
+	if t.Links != nil {
+		cloned.Links = append([]TodoLink(nil), t.Links...)
+	}
+	cloned.Location = t.Location.Clone()
+	cloned.Tags = slices.Clone(t.Tags)
+	cloned.Subtasks = cloneSubtasks(t.Subtasks)
+	cloned.DueDate = CloneTime(t.DueDate)
+	cloned.CompletedAt = CloneTime(t.CompletedAt)
+	cloned.RemindAt = CloneTime(t.RemindAt)
+	return &cloned
+}
+
+// HasDueDate 是否设置了截止时间
+func (t *Todo) HasDueDate() bool {
+	return t.DueDate != nil && !t.DueDate.IsZero()
+}
+
+// DueAt 返回截止时间，没有截止时间时返回零值
+func (t *Todo) DueAt() time.Time {
+	if !t.HasDueDate() {
+		return time.Time{}
+	}
+	return *t.DueDate
+}
+
+// CloneTime 复制可为空的时间，nil 和零值都返回nil
+// 旧数据和旧客户端用零值 "0001-01-01T00:00:00Z" 表示没有截止时间，统一转换为nil
+func CloneTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	cloned := *t
+	return &cloned
 }
 
 // TodoRequest 创建/更新待办事项请求
 type TodoRequest struct {
-	Title       string    `json:"title" binding:"required,min=1,max=200"`
-	Description string    `json:"description" binding:"max=1000"`
-	Completed   bool      `json:"completed"`
-	Priority    int       `json:"priority" binding:"min=1,max=5"`
-	Category    string    `json:"category" binding:"max=50"`
-	DueDate     time.Time `json:"due_date"`
+	Title       string     `json:"title" binding:"required,min=1,max=200"`
+	Description string     `json:"description" binding:"max=1000"`
+	Completed   bool       `json:"completed"`
+	Priority    Priority   `json:"priority" binding:"min=0,max=5"` // 优先级，可以是数字或名称（low、normal、high、urgent、critical），0表示未设置
+	Category    string     `json:"category" binding:"max=50"`
+	DueDate     *time.Time `json:"due_date"`  // 截止时间，为空或null表示没有截止时间
+	RemindAt    *time.Time `json:"remind_at"` // 提醒时间，为空或null表示没有提醒
+	Location    *Location  `json:"location"`  // 地点，为空表示没有地点
+	Tags        []string   `json:"tags"`      // 标签，不区分大小写，保存时统一为小写并去掉重复项
+	Version     int        `json:"version"`   // 客户端读取到的版本号，非0时只有与当前版本一致才会更新，为0时不检查
 }
 
 // TodoResponse 待办事项响应
 type TodoResponse struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description,omitempty"`
-	Completed   bool      `json:"completed"`
-	Priority    int       `json:"priority"`
-	Category    string    `json:"category,omitempty"`
-	DueDate     time.Time `json:"due_date,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Status      string    `json:"status"`
-	IsOverdue   bool      `json:"is_overdue"`
+	ID                int              `json:"id"`
+	Title             string           `json:"title"`
+	Description       string           `json:"description,omitempty"`
+	Completed         bool             `json:"completed"`
+	Priority          Priority         `json:"priority"`
+	PriorityName      string           `json:"priority_name,omitempty"`      // 优先级的名称，未设置时省略
+	EffectivePriority int              `json:"effective_priority,omitempty"` // 优先级老化后的有效优先级，仅在启用老化策略时返回
+	Category          string           `json:"category,omitempty"`
+	DueDate           *time.Time       `json:"due_date,omitempty"` // 没有截止时间时省略
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	CompletedAt       *time.Time       `json:"completed_at,omitempty"` // 完成时间，未完成时省略
+	Archived          bool             `json:"archived,omitempty"`     // 是否已归档，未归档时省略
+	Position          int              `json:"position"`               // 手动排序的位置
+	RemindAt          *time.Time       `json:"remind_at,omitempty"`    // 提醒时间，没有提醒时省略
+	Version           int              `json:"version"`
+	Status            TodoStatus       `json:"status"`         // 状态：in_progress、completed 或 overdue
+	DisplayStatus     string           `json:"display_status"` // 按请求者的语言区域显示的状态
+	IsOverdue         bool             `json:"is_overdue"`
+	Location          *Location        `json:"location,omitempty"`
+	Links             []TodoLink       `json:"links,omitempty"`
+	Tags              []string         `json:"tags,omitempty"`
+	Subtasks          []Subtask        `json:"subtasks,omitempty"`
+	Progress          *SubtaskProgress `json:"progress,omitempty"` // 子任务的完成情况，没有子任务时省略
+	Backlinks         []Backlink       `json:"backlinks,omitempty"`
+
+	Hypermedia map[string]Link `json:"_links,omitempty"` // 超媒体链接，仅在启用时返回
+}
+
+// Link 超媒体链接
+type Link struct {
+	Href   string `json:"href"`             // 链接地址
+	Method string `json:"method,omitempty"` // 请求方法，GET时省略
 }
 
-// ToResponse 转换为响应格式
+// TodoStatus 待办事项的状态，由是否完成和截止时间计算得出
+type TodoStatus string
+
+// 待办事项的状态
+const (
+	TodoStatusInProgress TodoStatus = "in_progress" // 进行中
+	TodoStatusCompleted  TodoStatus = "completed"   // 已完成
+	TodoStatusOverdue    TodoStatus = "overdue"     // 未完成且已过截止时间
+)
+
+// ToResponse 转换为响应格式，display_status 使用默认语言区域，按请求者的语言区域显示时再调用 Localize
 func (t *Todo) ToResponse() TodoResponse {
 	now := time.Now()
-	isOverdue := !t.Completed && !t.DueDate.IsZero() && t.DueDate.Before(now)
+	isOverdue := !t.Completed && t.HasDueDate() && t.DueDate.Before(now)
 
-	status := "进行中"
+	status := TodoStatusInProgress
 	if t.Completed {
-		status = "已完成"
+		status = TodoStatusCompleted
 	} else if isOverdue {
-		status = "已过期"
+		status = TodoStatusOverdue
 	}
 
-	return TodoResponse{
-		ID:          t.ID,
-		Title:       t.Title,
-		Description: t.Description,
-		Completed:   t.Completed,
-		Priority:    t.Priority,
-		Category:    t.Category,
-		DueDate:     t.DueDate,
-		CreatedAt:   t.CreatedAt,
-		UpdatedAt:   t.UpdatedAt,
-		Status:      status,
-		IsOverdue:   isOverdue,
+	response := TodoResponse{
+		ID:           t.ID,
+		Title:        t.Title,
+		Description:  t.Description,
+		Completed:    t.Completed,
+		Priority:     t.Priority,
+		PriorityName: t.Priority.Name(),
+		Category:     t.Category,
+		DueDate:      CloneTime(t.DueDate),
+		RemindAt:     CloneTime(t.RemindAt),
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+		CompletedAt:  CloneTime(t.CompletedAt),
+		Archived:     t.Archived,
+		Position:     t.Position,
+		Version:      t.Version,
+		Status:       status,
+		IsOverdue:    isOverdue,
+		Location:     t.Location,
+		Links:        t.Links,
+		Tags:         t.Tags,
+		Subtasks:     t.Subtasks,
+	}
+	if len(t.Subtasks) > 0 {
+		progress := t.SubtaskProgress()
+		response.Progress = &progress
 	}
+	response.Localize(i18n.DefaultLocale)
+	return response
+}
+
+// Localize 按语言区域设置 display_status
+func (r *TodoResponse) Localize(locale string) {
+	r.DisplayStatus = i18n.T(locale, "status."+string(r.Status))
 }
 
 // FromRequest 从请求创建模型
 func (t *Todo) FromRequest(req *TodoRequest) {
+	now := time.Now()
 	t.Title = req.Title
 	t.Description = req.Description
-	t.Completed = req.Completed
+	t.SetCompleted(req.Completed, now)
 	t.Priority = req.Priority
 	t.Category = req.Category
-	t.DueDate = req.DueDate
-	t.UpdatedAt = time.Now()
+	t.DueDate = CloneTime(req.DueDate)
+	t.RemindAt = CloneTime(req.RemindAt)
+	t.Location = req.Location.Clone()
+	t.Tags = slices.Clone(req.Tags)
+	t.UpdatedAt = now
+	t.Version++
+}
+
+// SetCompleted 设置是否完成：由未完成变为完成时记录完成时间，已完成时保持原来的完成时间，重新打开时清除
+func (t *Todo) SetCompleted(completed bool, now time.Time) {
+	if !completed {
+		t.CompletedAt = nil
+	} else if !t.Completed {
+		t.CompletedAt = &now
+	}
+	t.Completed = completed
+}
+
+// Request 返回与待办事项当前内容一致的更新请求，用于只修改部分字段的更新
+func (t *Todo) Request() *TodoRequest {
+	return &TodoRequest{
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		Priority:    t.Priority,
+		Category:    t.Category,
+		DueDate:     CloneTime(t.DueDate),
+		RemindAt:    CloneTime(t.RemindAt),
+		Location:    t.Location.Clone(),
+		Tags:        slices.Clone(t.Tags),
+	}
 }
 
 // User 用户模型
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TrashedTodo 回收站中的待办事项
+// 删除的事项保留到 PurgeAt，期间可以按原ID恢复，之后被永久删除
+type TrashedTodo struct {
+	Todo      *Todo     `json:"todo"`       // 删除时的待办事项
+	DeletedAt time.Time `json:"deleted_at"` // 删除时间
+	PurgeAt   time.Time `json:"purge_at"`   // 永久删除的时间，管理员可以延长
+}
+
+// Expired 是否已超过保留期
+func (t *TrashedTodo) Expired(now time.Time) bool {
+	return !now.Before(t.PurgeAt)
+}
+
+// Remaining 距离永久删除的剩余时间，已超过保留期时为0
+func (t *TrashedTodo) Remaining(now time.Time) time.Duration {
+	if t.Expired(now) {
+		return 0
+	}
+	return t.PurgeAt.Sub(now)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Watcher 待办事项的关注者
+// 关注者只需要邮箱地址（不需要是注册用户），截止前会收到提醒，完成后会收到通知；邮件中的退订链接包含令牌，打开后即可取消关注
+type Watcher struct {
+	Token     string    `json:"token"`      // 退订令牌，同时作为关注者的ID
+	TodoID    int       `json:"todo_id"`    // 关注的待办事项ID
+	Email     string    `json:"email"`      // 邮箱地址（小写）
+	CreatedAt time.Time `json:"created_at"` // 添加时间
+
+	RemindedDue       time.Time `json:"reminded_due,omitempty"`       // 已发送过提醒的截止时间，截止时间修改后会再次提醒
+	CompletedNotified bool      `json:"completed_notified,omitempty"` // 是否已发送完成通知，事项重新打开后复位
+}
+
+// NotificationQuota 邮箱在当前小时内已收到的邮件数，用于限制发往同一邮箱的邮件频率
+type NotificationQuota struct {
+	Email       string    `json:"email"`        // 邮箱地址（小写）
+	WindowStart time.Time `json:"window_start"` // 当前计数窗口的开始时间（整点）
+	Sent        int       `json:"sent"`         // 窗口内已发送的邮件数
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebhookEvents webhook 可以订阅的事件，对应事件类型 todo.<名称>
+var WebhookEvents = []string{"created", "updated", "completed", "deleted", "overdue", "reminder"}
+
+// 投递状态
+const (
+	DeliveryPending   = "pending"   // 等待发送或等待重试
+	DeliverySucceeded = "succeeded" // 对方返回了 2xx
+	DeliveryFailed    = "failed"    // 重试次数用完仍未成功
+)
+
+// Webhook 事件订阅
+// 订阅的事件发生后，服务以 POST 把事件的 JSON 发送到 URL，请求带有用密钥计算的 HMAC-SHA256 签名
+type Webhook struct {
+	ID          string    `json:"id"`                    // 订阅ID
+	URL         string    `json:"url"`                   // 接收事件的地址（http 或 https）
+	Events      []string  `json:"events,omitempty"`      // 订阅的事件（created、updated、completed、deleted、overdue、reminder），为空表示全部
+	Secret      string    `json:"secret,omitempty"`      // 签名密钥，创建时未指定则自动生成，只在创建的响应中返回
+	Description string    `json:"description,omitempty"` // 说明，便于识别
+	Disabled    bool      `json:"disabled,omitempty"`    // 是否暂停投递，暂停期间的事件不会补发
+	CreatedAt   time.Time `json:"created_at"`            // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`            // 更新时间
+}
+
+// Validate 检查订阅：URL 必须是 http 或 https 的绝对地址，事件必须是可以订阅的事件
+// 事件名称统一为小写并去掉 todo. 前缀和重复项
+func (w *Webhook) Validate() error {
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url 必须是 http 或 https 的绝对地址")
+	}
+
+	events := make([]string, 0, len(w.Events))
+	seen := make(map[string]bool, len(w.Events))
+	for _, event := range w.Events {
+		event = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(event)), "todo.")
+		if !isWebhookEvent(event) {
+			return fmt.Errorf("未知的事件 %q，应为 %s", event, strings.Join(WebhookEvents, "、"))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	w.Events = events
+	return nil
+}
+
+// Matches 订阅是否包含事件类型（如 todo.created）
+func (w *Webhook) Matches(eventType string) bool {
+	name, ok := strings.CutPrefix(eventType, "todo.")
+	if !ok || !isWebhookEvent(name) {
+		return false
+	}
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebhookEvent 是否为可以订阅的事件
+func isWebhookEvent(name string) bool {
+	for _, event := range WebhookEvents {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery 一次事件投递及其结果
+// 请求体在加入队列时生成，重试时发送相同的内容
+type WebhookDelivery struct {
+	ID            string          `json:"id"`                        // 投递ID，同时在 X-Webhook-ID 请求头中发送，接收方可据此去重
+	WebhookID     string          `json:"webhook_id"`                // 订阅ID
+	EventSeq      int64           `json:"event_seq"`                 // 事件序号
+	EventType     string          `json:"event_type"`                // 事件类型
+	TodoID        int             `json:"todo_id"`                   // 相关的待办事项ID
+	Payload       json.RawMessage `json:"payload"`                   // 发送的请求体
+	Status        string          `json:"status"`                    // 状态：pending、succeeded、failed
+	Attempts      int             `json:"attempts"`                  // 已发送的次数
+	ResponseCode  int             `json:"response_code,omitempty"`   // 最近一次的响应状态码，没有收到响应时为0
+	Error         string          `json:"error,omitempty"`           // 最近一次失败的原因
+	CreatedAt     time.Time       `json:"created_at"`                // 加入队列的时间
+	LastAttemptAt *time.Time      `json:"last_attempt_at,omitempty"` // 最近一次发送的时间
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"` // 下一次发送的时间，只有等待中的投递有
+}
@@ -0,0 +1,126 @@
+// Package notify 发送邮件通知
+// 邮件正文为纯文本（UTF-8，quoted-printable 编码）；带有退订地址的邮件同时设置 List-Unsubscribe 头，
+// 邮件客户端可以直接显示退订按钮（RFC 8058 一键退订）
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+)
+
+// Message 邮件
+type Message struct {
+	To             string // 收件人地址
+	Subject        string // 主题
+	Body           string // 纯文本正文
+	UnsubscribeURL string // 退订地址，为空时不设置退订头
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// NewMailer 根据通知配置创建邮件发送器，未启用时返回只记录日志的发送器
+func NewMailer(cfg config.NotificationConfig) Mailer {
+	if !cfg.Enabled {
+		return logMailer{}
+	}
+	return &smtpMailer{cfg: cfg}
+}
+
+// logMailer 只记录日志，不发送邮件
+type logMailer struct{}
+
+// Send 记录邮件的收件人和主题
+func (logMailer) Send(msg *Message) error {
+	log.Printf("✉️ 邮件通知未启用，未发送给 %s: %s", msg.To, msg.Subject)
+	return nil
+}
+
+// smtpMailer 通过 SMTP 服务器发送邮件
+type smtpMailer struct {
+	cfg config.NotificationConfig
+}
+
+// Send 发送邮件，服务器支持时使用 STARTTLS，配置了用户名时使用 PLAIN 认证
+func (m *smtpMailer) Send(msg *Message) error {
+	data, err := buildMessage(m.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.SMTPHost)
+	}
+	addr := net.JoinHostPort(m.cfg.SMTPHost, strconv.Itoa(m.cfg.SMTPPort))
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// buildMessage 生成完整的邮件内容（头部和编码后的正文）
+func buildMessage(from string, msg *Message, now time.Time) ([]byte, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("无效的发件人地址: %w", err)
+	}
+	toAddr, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("无效的收件人地址: %w", err)
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", fromAddr.String())
+	header("To", toAddr.String())
+	header("Subject", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(fromAddr.Address))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	header("Auto-Submitted", "auto-generated")
+	if msg.UnsubscribeURL != "" {
+		header("List-Unsubscribe", "<"+msg.UnsubscribeURL+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID 生成邮件ID，域名取自发件人地址
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return "<" + hex.EncodeToString(buf) + "@" + domain + ">"
+}
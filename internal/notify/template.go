@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// 可以自定义模板的通知类型，名称为 集成方.消息类型
+const (
+	TemplateEmailReminder  = "email.reminder"  // 发给关注者的截止提醒邮件
+	TemplateEmailCompleted = "email.completed" // 发给关注者的完成通知邮件
+	TemplateEmailRemindAt  = "email.remind_at" // 到达提醒时间时发给关注者的提醒邮件
+)
+
+// 模板长度上限（字符数）
+const (
+	maxSubjectTemplate = 500
+	maxBodyTemplate    = 20000
+)
+
+// TemplateKind 可以自定义模板的通知类型
+type TemplateKind struct {
+	Name           string `json:"name"`                      // 通知类型
+	Description    string `json:"description"`               // 说明
+	HasSubject     bool   `json:"has_subject"`               // 是否有主题（邮件）
+	DefaultSubject string `json:"default_subject,omitempty"` // 默认主题模板
+	DefaultBody    string `json:"default_body"`              // 默认正文模板
+}
+
+// templateKinds 所有可以自定义模板的通知类型
+var templateKinds = []TemplateKind{
+	{
+		Name:           TemplateEmailReminder,
+		Description:    "关注的事项即将到期时发给关注者的提醒邮件",
+		HasSubject:     true,
+		DefaultSubject: "提醒：「{{.Todo.Title}}」即将到期",
+		DefaultBody:    "您好，\n\n您关注的待办事项「{{.Todo.Title}}」将于 {{date .Todo.DueDate}} 到期。\n{{if .Todo.Description}}\n{{.Todo.Description}}\n{{end}}",
+	},
+	{
+		Name:           TemplateEmailCompleted,
+		Description:    "关注的事项完成后发给关注者的通知邮件",
+		HasSubject:     true,
+		DefaultSubject: "已完成：「{{.Todo.Title}}」",
+		DefaultBody:    "您好，\n\n您关注的待办事项「{{.Todo.Title}}」已于 {{date .Todo.UpdatedAt}} 完成。\n",
+	},
+	{
+		Name:           TemplateEmailRemindAt,
+		Description:    "到达事项设置的提醒时间时发给关注者的提醒邮件",
+		HasSubject:     true,
+		DefaultSubject: "提醒：「{{.Todo.Title}}」",
+		DefaultBody:    "您好，\n\n这是待办事项「{{.Todo.Title}}」在 {{date .Todo.RemindAt}} 的提醒。\n{{if .Todo.DueDate}}截止时间：{{date .Todo.DueDate}}\n{{end}}{{if .Todo.Description}}\n{{.Todo.Description}}\n{{end}}",
+	},
+}
+
+// TemplateKinds 返回所有可以自定义模板的通知类型
+func TemplateKinds() []TemplateKind {
+	return append([]TemplateKind(nil), templateKinds...)
+}
+
+// LookupTemplateKind 根据名称查找通知类型
+func LookupTemplateKind(name string) (TemplateKind, bool) {
+	for _, kind := range templateKinds {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+	return TemplateKind{}, false
+}
+
+// TemplateData 渲染通知模板时可以引用的数据
+type TemplateData struct {
+	Event          string       // 通知的消息类型，如 reminder、completed
+	Todo           *models.Todo // 相关的待办事项，可以引用 .Todo.Title、.Todo.DueDate 等全部字段
+	Email          string       // 收件人邮箱
+	UnsubscribeURL string       // 退订地址
+	Now            time.Time    // 发送时间
+}
+
+// SampleTemplateData 校验和预览模板时使用的示例数据
+func SampleTemplateData(kind TemplateKind, now time.Time) *TemplateData {
+	_, event, _ := strings.Cut(kind.Name, ".")
+	due := now.Add(24 * time.Hour)
+	return &TemplateData{
+		Event: event,
+		Todo: &models.Todo{
+			ID:          42,
+			Title:       "提交季度报告",
+			Description: "汇总本季度的数据并提交给财务部",
+			Completed:   kind.Name == TemplateEmailCompleted,
+			Priority:    3,
+			Category:    "工作",
+			DueDate:     &due,
+			RemindAt:    &now,
+			CreatedAt:   now.Add(-72 * time.Hour),
+			UpdatedAt:   now,
+			Version:     1,
+		},
+		Email:          "someone@example.com",
+		UnsubscribeURL: "http://localhost:8080/unsubscribe/example",
+		Now:            now,
+	}
+}
+
+// templateFuncs 模板中可以使用的函数
+var templateFuncs = template.FuncMap{
+	// date 按本地时区格式化时间，默认格式为 2006-01-02 15:04，零值或nil（如没有截止时间）时返回空字符串
+	"date": func(value interface{}, layout ...string) (string, error) {
+		var t time.Time
+		switch v := value.(type) {
+		case time.Time:
+			t = v
+		case *time.Time:
+			if v != nil {
+				t = *v
+			}
+		case nil:
+		default:
+			return "", fmt.Errorf("date 不能格式化 %T", value)
+		}
+		if t.IsZero() {
+			return "", nil
+		}
+		if len(layout) > 0 {
+			return t.Local().Format(layout[0]), nil
+		}
+		return t.Local().Format("2006-01-02 15:04"), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Template 编译后的通知模板
+type Template struct {
+	subject *template.Template // 没有主题的通知类型为nil
+	body    *template.Template
+}
+
+// ParseTemplate 编译通知模板，并用示例数据试渲染一次，引用不存在的字段等错误在保存时就能发现
+// 有主题的通知类型必须提供主题模板
+func ParseTemplate(kind TemplateKind, subject, body string) (*Template, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, errors.New("正文模板不能为空")
+	}
+	if utf8.RuneCountInString(body) > maxBodyTemplate {
+		return nil, fmt.Errorf("正文模板不能超过 %d 个字符", maxBodyTemplate)
+	}
+
+	t := &Template{}
+	var err error
+	if kind.HasSubject {
+		if strings.TrimSpace(subject) == "" {
+			return nil, errors.New("主题模板不能为空")
+		}
+		if utf8.RuneCountInString(subject) > maxSubjectTemplate {
+			return nil, fmt.Errorf("主题模板不能超过 %d 个字符", maxSubjectTemplate)
+		}
+		if t.subject, err = template.New("subject").Funcs(templateFuncs).Parse(subject); err != nil {
+			return nil, fmt.Errorf("主题模板语法错误: %w", err)
+		}
+	} else if subject != "" {
+		return nil, fmt.Errorf("%s 没有主题", kind.Name)
+	}
+	if t.body, err = template.New("body").Funcs(templateFuncs).Parse(body); err != nil {
+		return nil, fmt.Errorf("正文模板语法错误: %w", err)
+	}
+
+	if _, _, err := t.Render(SampleTemplateData(kind, time.Now())); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DefaultTemplate 返回通知类型的默认模板
+func DefaultTemplate(kind TemplateKind) *Template {
+	t, err := ParseTemplate(kind, kind.DefaultSubject, kind.DefaultBody)
+	if err != nil {
+		panic("notify: 默认模板 " + kind.Name + " 无效: " + err.Error())
+	}
+	return t
+}
+
+// Render 渲染模板，返回主题和正文
+// 主题中的换行替换为空格，避免生成多行的邮件头
+func (t *Template) Render(data *TemplateData) (string, string, error) {
+	var subject string
+	if t.subject != nil {
+		var buf bytes.Buffer
+		if err := t.subject.Execute(&buf, data); err != nil {
+			return "", "", fmt.Errorf("渲染主题模板失败: %w", err)
+		}
+		subject = strings.Join(strings.Fields(buf.String()), " ")
+	}
+
+	var buf bytes.Buffer
+	if err := t.body.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("渲染正文模板失败: %w", err)
+	}
+	return subject, buf.String(), nil
+}
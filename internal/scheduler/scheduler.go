@@ -0,0 +1,157 @@
+// Package scheduler 实现多实例安全的定时任务调度
+// 每个任务按固定间隔执行，执行时刻对齐到间隔的整数倍（例如每小时任务在整点执行）。
+// 多个服务实例共用同一个存储时，每个时间段内通过分布式锁选出一个实例执行，保证任务每个间隔只执行一次
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// maxClockSkew 锁的过期时间比任务间隔提前的最大时长，用于容忍实例之间的时钟偏差
+const maxClockSkew = 5 * time.Second
+
+// Job 定时任务函数
+type Job func(ctx context.Context) error
+
+// job 已注册的任务
+type job struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	locker store.Locker
+	owner  string // 当前实例的标识，作为锁的持有者
+
+	mu      sync.Mutex
+	jobs    []*job
+	lastErr error // 最近一次获取锁的错误，用于健康检查
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器，owner 为空时使用 主机名-进程号 作为实例标识
+func New(locker store.Locker, owner string) *Scheduler {
+	if owner == "" {
+		hostname, _ := os.Hostname()
+		owner = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return &Scheduler{locker: locker, owner: owner}
+}
+
+// Owner 返回当前实例的标识
+func (s *Scheduler) Owner() string {
+	return s.owner
+}
+
+// Every 注册按固定间隔执行的任务，需要在 Start 之前调用
+// 任务名称同时作为锁的名称，所有实例中同名任务的间隔必须相同
+func (s *Scheduler) Every(name string, interval time.Duration, run Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{name: name, interval: interval, run: run})
+}
+
+// Start 启动所有任务，每个任务在独立的 goroutine 中运行
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.cancel = cancel
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	for _, j := range jobs {
+		s.wg.Add(1)
+		go func(j *job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Stop 停止调度并等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// Check 健康检查：最近一次获取锁失败时返回错误
+func (s *Scheduler) Check(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr != nil {
+		return fmt.Errorf("获取任务锁失败: %w", s.lastErr)
+	}
+	return nil
+}
+
+// loop 在每个间隔的起点尝试执行任务
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		now := time.Now()
+		next := now.Truncate(j.interval).Add(j.interval)
+		timer := time.NewTimer(next.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx, j)
+	}
+}
+
+// runOnce 获取锁成功时执行一次任务
+// 锁在下一个间隔开始前过期且执行后不释放：同一间隔内其它实例无法再获取锁，下一个间隔所有实例重新竞争
+func (s *Scheduler) runOnce(ctx context.Context, j *job) {
+	ttl := j.interval - lockMargin(j.interval)
+
+	acquired, err := s.locker.TryLock(ctx, "job:"+j.name, s.owner, ttl)
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("获取任务 %s 的锁失败: %v", j.name, err)
+		return
+	}
+	if !acquired {
+		return // 本间隔由其它实例执行
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, ttl)
+	defer cancel()
+
+	start := time.Now()
+	if err := j.run(jobCtx); err != nil {
+		log.Printf("定时任务 %s 执行失败: %v", j.name, err)
+		return
+	}
+	log.Printf("定时任务 %s 执行完成，耗时 %v", j.name, time.Since(start))
+}
+
+// lockMargin 锁的过期时间比任务间隔提前的时长：间隔的十分之一，最多 maxClockSkew
+func lockMargin(interval time.Duration) time.Duration {
+	margin := interval / 10
+	if margin > maxClockSkew {
+		margin = maxClockSkew
+	}
+	return margin
+}
@@ -0,0 +1,189 @@
+package scrub
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+	"github.com/MGter/xStreamTool_go/internal/store"
+)
+
+// emailPattern 匹配文本中的邮箱地址
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// 生成假数据使用的词库
+var (
+	verbs = []string{"整理", "准备", "检查", "更新", "编写", "评审", "安排", "跟进", "优化", "提交", "确认", "归档"}
+	nouns = []string{
+		"季度报告", "会议纪要", "项目计划", "测试用例", "部署脚本", "客户反馈", "预算表",
+		"设计文档", "周报", "培训材料", "采购清单", "发布说明", "数据备份", "接口文档",
+	}
+	details = []string{
+		"需要在本周内完成", "和团队同步进度", "注意检查细节", "完成后通知相关人员",
+		"参考上次的模板", "优先处理紧急部分", "留出评审时间", "记录遇到的问题",
+	}
+	categoryNames = []string{"工作", "生活", "学习", "健康", "财务", "家庭", "旅行", "阅读", "运动", "杂项"}
+	places        = []string{"1号仓库", "东区办公室", "客户现场", "会议室A", "城南门店", "研发中心", "物流园区", "展厅"}
+)
+
+// Scrubber 数据脱敏器
+// 把标题、描述、分类、地点和邮箱替换为看起来真实的假数据，同时保留ID、日期、优先级等结构信息，
+// 便于用户在提交问题时分享可复现的数据集。同一个原始值总是被替换为同一个假值，保证数据间的关联不变
+type Scrubber struct {
+	rng        *rand.Rand
+	categories map[string]string // 原始分类 -> 假分类
+	emails     map[string]string // 原始邮箱 -> 假邮箱
+}
+
+// New 创建脱敏器，相同的种子会生成相同的假数据
+func New(seed int64) *Scrubber {
+	return &Scrubber{
+		rng:        rand.New(rand.NewSource(seed)),
+		categories: make(map[string]string),
+		emails:     make(map[string]string),
+	}
+}
+
+// Snapshot 对快照进行脱敏，返回新的快照，不修改原始数据
+func (s *Scrubber) Snapshot(snapshot *models.Snapshot) (*models.Snapshot, error) {
+	result := *snapshot
+	result.Todos = make([]*models.Todo, 0, len(snapshot.Todos))
+	for _, todo := range snapshot.Todos {
+		result.Todos = append(result.Todos, s.Todo(todo))
+	}
+
+	// 按命名空间顺序处理，保证相同种子的结果可复现
+	// 分享链接的令牌可以直接访问数据，事件中包含未脱敏的待办事项副本，这两个命名空间整个丢弃
+	result.Meta = make(map[string]map[string]json.RawMessage, len(snapshot.Meta))
+	for _, namespace := range sortedKeys(snapshot.Meta) {
+		if namespace == store.ShareLinksNamespace || namespace == store.EventsNamespace {
+			continue
+		}
+		scrubbed, err := s.meta(namespace, snapshot.Meta[namespace])
+		if err != nil {
+			return nil, fmt.Errorf("脱敏附属数据 %s 失败: %w", namespace, err)
+		}
+		result.Meta[namespace] = scrubbed
+	}
+
+	return &result, nil
+}
+
+// Todo 对单个待办事项进行脱敏，返回新的待办事项
+func (s *Scrubber) Todo(todo *models.Todo) *models.Todo {
+	scrubbed := *todo
+	scrubbed.Title = s.title()
+	if todo.Description != "" {
+		scrubbed.Description = s.description()
+	}
+	scrubbed.Category = s.Category(todo.Category)
+	scrubbed.Location = s.location(todo.Location)
+	return &scrubbed
+}
+
+// location 替换地点名称和坐标，没有地点时保持为空
+// 坐标替换为随机位置，只保留"有没有坐标"这一结构信息
+func (s *Scrubber) location(location *models.Location) *models.Location {
+	if location == nil {
+		return nil
+	}
+	scrubbed := &models.Location{}
+	if location.Name != "" {
+		scrubbed.Name = s.pick(places)
+	}
+	if location.HasCoordinates() {
+		lat := s.rng.Float64()*180 - 90
+		lng := s.rng.Float64()*360 - 180
+		scrubbed.Lat, scrubbed.Lng = &lat, &lng
+	}
+	return scrubbed
+}
+
+// Category 替换分类名称，空分类保持为空
+func (s *Scrubber) Category(category string) string {
+	if category == "" {
+		return ""
+	}
+	if fake, exists := s.categories[category]; exists {
+		return fake
+	}
+
+	// 词库用完后在名称后追加序号，保证不同的分类不会被合并
+	fake := categoryNames[len(s.categories)%len(categoryNames)]
+	if round := len(s.categories) / len(categoryNames); round > 0 {
+		fake = fmt.Sprintf("%s%d", fake, round+1)
+	}
+	s.categories[category] = fake
+	return fake
+}
+
+// Text 替换文本中出现的邮箱地址，其余内容保持不变
+func (s *Scrubber) Text(text string) string {
+	return emailPattern.ReplaceAllStringFunc(text, func(email string) string {
+		key := strings.ToLower(email)
+		if fake, exists := s.emails[key]; exists {
+			return fake
+		}
+		fake := fmt.Sprintf("user%d@example.com", len(s.emails)+1)
+		s.emails[key] = fake
+		return fake
+	})
+}
+
+// title 生成假标题，例如"整理 季度报告"
+func (s *Scrubber) title() string {
+	return s.pick(verbs) + " " + s.pick(nouns)
+}
+
+// description 生成假描述
+func (s *Scrubber) description() string {
+	return s.pick(nouns) + "：" + s.pick(details)
+}
+
+// pick 从词库中随机选择一个词
+func (s *Scrubber) pick(words []string) string {
+	return words[s.rng.Intn(len(words))]
+}
+
+// meta 对附属数据进行脱敏
+// 分类默认设置的键和内容都包含分类名称，需要与待办事项使用同一套映射；其它附属数据只替换邮箱地址
+func (s *Scrubber) meta(namespace string, items map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	result := make(map[string]json.RawMessage, len(items))
+	for _, key := range sortedKeys(items) {
+		value := items[key]
+		if namespace != store.CategoryDefaultsNamespace {
+			result[s.Text(key)] = json.RawMessage(s.Text(string(value)))
+			continue
+		}
+
+		var defaults models.CategoryDefaults
+		if err := json.Unmarshal(value, &defaults); err != nil {
+			return nil, err
+		}
+		defaults.Category = s.Category(defaults.Category)
+		if defaults.Description != "" {
+			defaults.Description = s.description()
+		}
+
+		data, err := json.Marshal(defaults)
+		if err != nil {
+			return nil, err
+		}
+		result[defaults.Category] = data
+	}
+	return result, nil
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
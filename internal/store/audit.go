@@ -0,0 +1,376 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// AuditNamespace 审计日志在附属数据中的命名空间
+// 键为补零的纳秒时间戳加随机后缀，按字符串排序即按时间排序，多个实例同时写入也不会冲突
+const AuditNamespace = "audit"
+
+// AuditFilter 查询审计日志的条件，零值的条件不过滤
+type AuditFilter struct {
+	TodoID int       // 只返回该待办事项的记录
+	Actor  string    // 只返回该操作者的记录
+	Action string    // 只返回该类型的操作
+	Since  time.Time // 只返回不早于该时间的记录
+	Until  time.Time // 只返回早于该时间的记录
+	Before string    // 只返回ID小于该值的记录，用于翻页
+	Limit  int       // 最多返回的记录数，0表示不限制
+}
+
+// matches 判断记录是否符合条件
+func (f AuditFilter) matches(entry *models.AuditEntry) bool {
+	switch {
+	case f.TodoID != 0 && entry.TodoID != f.TodoID,
+		f.Actor != "" && entry.Actor != f.Actor,
+		f.Action != "" && entry.Action != f.Action,
+		!f.Since.IsZero() && entry.CreatedAt.Before(f.Since),
+		!f.Until.IsZero() && !entry.CreatedAt.Before(f.Until),
+		f.Before != "" && entry.ID >= f.Before:
+		return false
+	}
+	return true
+}
+
+// auditKey 生成审计记录的键
+func auditKey(now time.Time) string {
+	return fmt.Sprintf("%020d-%08x", now.UnixNano(), rand.Uint32())
+}
+
+// AppendAuditEntries 追加审计记录，记录的ID由此分配
+func AppendAuditEntries(s MetaStore, entries []*models.AuditEntry) error {
+	for _, entry := range entries {
+		entry.ID = auditKey(entry.CreatedAt)
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := s.PutMeta(AuditNamespace, entry.ID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListAuditEntries 按条件查询审计记录，最新的在前
+func ListAuditEntries(s MetaStore, filter AuditFilter) ([]*models.AuditEntry, error) {
+	items, err := s.ListMeta(AuditNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.AuditEntry, 0)
+	for _, data := range items {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		if filter.matches(&entry) {
+			results = append(results, &entry)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID > results[j].ID
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// AuditedStore 审计装饰器，把每次创建、修改、完成和删除待办事项的操作者和前后内容追加到审计日志
+// 每次修改都在被包装存储的事务中执行，审计记录与修改在同一个事务中写入，不会出现有修改没有记录的情况；
+// 附属数据的修改不记录。通过 As 获取以指定操作者身份修改数据的存储，直接使用时操作者为 system
+type AuditedStore struct {
+	inner      TodoStore
+	actor      string
+	remoteAddr string
+	inTx       bool // 是否是事务中使用的包装，此时修改直接在事务中执行
+}
+
+// NewAuditedStore 创建审计装饰器
+func NewAuditedStore(inner TodoStore) *AuditedStore {
+	return &AuditedStore{inner: inner, actor: models.AuditActorSystem}
+}
+
+// Unwrap 返回被包装的存储
+func (s *AuditedStore) Unwrap() TodoStore {
+	return s.inner
+}
+
+// As 返回以指定操作者身份修改数据的存储，remoteAddr 为请求来源地址
+func (s *AuditedStore) As(actor, remoteAddr string) *AuditedStore {
+	return &AuditedStore{inner: s.inner, actor: actor, remoteAddr: remoteAddr, inTx: s.inTx}
+}
+
+// entry 生成一条审计记录
+func (s *AuditedStore) entry(before, after *models.Todo) *models.AuditEntry {
+	return models.NewAuditEntry(before, after, s.actor, s.remoteAddr, time.Now())
+}
+
+// mutate 在事务中执行修改并写入修改产生的审计记录；已经在事务中时直接执行
+func (s *AuditedStore) mutate(fn func(tx TodoStore) ([]*models.AuditEntry, error)) error {
+	run := func(tx TodoStore) error {
+		entries, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		return AppendAuditEntries(tx, entries)
+	}
+	if s.inTx {
+		return run(s.inner)
+	}
+	return s.inner.Transaction(run)
+}
+
+// GetAllTodos 获取所有待办事项
+func (s *AuditedStore) GetAllTodos() ([]*models.Todo, error) {
+	return s.inner.GetAllTodos()
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (s *AuditedStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	return s.inner.ListTodos(opts)
+}
+
+// GetTodoByID 根据ID获取单个待办事项
+func (s *AuditedStore) GetTodoByID(id int) (*models.Todo, error) {
+	return s.inner.GetTodoByID(id)
+}
+
+// CreateTodo 创建新的待办事项
+func (s *AuditedStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	var created *models.Todo
+	err := s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		var err error
+		if created, err = tx.CreateTodo(req); err != nil {
+			return nil, err
+		}
+		return []*models.AuditEntry{s.entry(nil, created)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateTodo 更新待办事项
+func (s *AuditedStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	var updated *models.Todo
+	err := s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		before, err := tx.GetTodoByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if updated, err = tx.UpdateTodo(id, req); err != nil {
+			return nil, err
+		}
+		return []*models.AuditEntry{s.entry(before, updated)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteTodo 删除待办事项
+func (s *AuditedStore) DeleteTodo(id int) error {
+	return s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		before, err := tx.GetTodoByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.DeleteTodo(id); err != nil {
+			return nil, err
+		}
+		return []*models.AuditEntry{s.entry(before, nil)}, nil
+	})
+}
+
+// SaveTodo 保存完整的待办事项
+func (s *AuditedStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	var saved *models.Todo
+	err := s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		before, err := tx.GetTodoByID(todo.ID)
+		if err != nil {
+			return nil, err
+		}
+		if saved, err = tx.SaveTodo(todo); err != nil {
+			return nil, err
+		}
+		return []*models.AuditEntry{s.entry(before, saved)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// SearchTodos 搜索待办事项
+func (s *AuditedStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	return s.inner.SearchTodos(query, category, completed)
+}
+
+// BulkCreate 批量创建待办事项，每个事项一条审计记录
+func (s *AuditedStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	var created []*models.Todo
+	err := s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		var err error
+		if created, err = tx.BulkCreate(reqs); err != nil {
+			return nil, err
+		}
+		entries := make([]*models.AuditEntry, len(created))
+		for i, todo := range created {
+			entries[i] = s.entry(nil, todo)
+		}
+		return entries, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// BulkUpdate 批量更新待办事项，每个事项一条审计记录
+func (s *AuditedStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	var updated []*models.Todo
+	err := s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		befores, err := s.befores(tx, updateIDs(updates))
+		if err != nil {
+			return nil, err
+		}
+		if updated, err = tx.BulkUpdate(updates); err != nil {
+			return nil, err
+		}
+		entries := make([]*models.AuditEntry, len(updated))
+		for i, todo := range updated {
+			entries[i] = s.entry(befores[i], todo)
+		}
+		return entries, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// BulkDelete 批量删除待办事项，每个事项一条审计记录
+func (s *AuditedStore) BulkDelete(ids []int) error {
+	return s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		befores, err := s.befores(tx, ids)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.BulkDelete(ids); err != nil {
+			return nil, err
+		}
+		entries := make([]*models.AuditEntry, len(befores))
+		for i, before := range befores {
+			entries[i] = s.entry(before, nil)
+		}
+		return entries, nil
+	})
+}
+
+// befores 读取批量修改前的待办事项，任一事项不存在时返回与批量操作相同的 ErrTodoNotFound
+func (s *AuditedStore) befores(tx TodoStore, ids []int) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, len(ids))
+	for i, id := range ids {
+		todo, err := tx.GetTodoByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %d", err, id)
+		}
+		todos[i] = todo
+	}
+	return todos, nil
+}
+
+// GetStats 获取统计信息
+func (s *AuditedStore) GetStats() (map[string]interface{}, error) {
+	return s.inner.GetStats()
+}
+
+// Transaction 在被包装存储的事务中执行fn，事务中的修改与审计记录一起提交或撤销
+// 已经在事务中时（嵌套事务）直接执行
+func (s *AuditedStore) Transaction(fn func(tx TodoStore) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+	return s.inner.Transaction(func(tx TodoStore) error {
+		return fn(&AuditedStore{inner: tx, actor: s.actor, remoteAddr: s.remoteAddr, inTx: true})
+	})
+}
+
+// Export 把全部数据以快照JSON写入w
+func (s *AuditedStore) Export(w io.Writer) error {
+	return s.inner.Export(w)
+}
+
+// Import 从r读取快照并替换全部数据，删除和写入的事项与其它修改一样记录审计日志
+func (s *AuditedStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// GetMeta 读取附属数据
+func (s *AuditedStore) GetMeta(namespace, key string) ([]byte, error) {
+	return s.inner.GetMeta(namespace, key)
+}
+
+// PutMeta 写入附属数据
+func (s *AuditedStore) PutMeta(namespace, key string, value []byte) error {
+	return s.inner.PutMeta(namespace, key, value)
+}
+
+// DeleteMeta 删除附属数据
+func (s *AuditedStore) DeleteMeta(namespace, key string) error {
+	return s.inner.DeleteMeta(namespace, key)
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *AuditedStore) ListMeta(namespace string) (map[string][]byte, error) {
+	return s.inner.ListMeta(namespace)
+}
+
+// LoadTodos 按原样写入待办事项，每个事项一条创建记录；被包装的存储不支持时返回 ErrLoadUnsupported
+// 按原样写入的事项可能来自其它存储或归档，记录中的内容为写入的内容
+func (s *AuditedStore) LoadTodos(todos []*models.Todo) error {
+	return s.mutate(func(tx TodoStore) ([]*models.AuditEntry, error) {
+		loader, ok := tx.(Loader)
+		if !ok {
+			return nil, ErrLoadUnsupported
+		}
+		if err := loader.LoadTodos(todos); err != nil {
+			return nil, err
+		}
+		entries := make([]*models.AuditEntry, len(todos))
+		for i, todo := range todos {
+			entries[i] = s.entry(nil, todo.Clone())
+		}
+		return entries, nil
+	})
+}
+
+// Ping 检查被包装的存储是否可用
+func (s *AuditedStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Close 关闭被包装的存储
+func (s *AuditedStore) Close() error {
+	if closer, ok := s.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
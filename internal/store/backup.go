@@ -0,0 +1,163 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// snapshotter 可选接口，由能直接导出完整快照（包括准确的下一个可用ID）的存储实现
+type snapshotter interface {
+	Snapshot() *models.Snapshot
+}
+
+// Dump 导出存储中的待办事项和 MigrateNamespaces 中的附属数据，用于备份
+// 存储不能直接导出快照时，下一个可用ID取最大ID+1
+func Dump(s TodoStore) (*models.Snapshot, error) {
+	if source, ok := Unwrap(s).(snapshotter); ok {
+		full := source.Snapshot()
+		meta := make(map[string]map[string]json.RawMessage)
+		for _, namespace := range MigrateNamespaces {
+			if items := full.Meta[namespace]; len(items) > 0 {
+				meta[namespace] = items
+			}
+		}
+		full.Meta = meta
+		return full, nil
+	}
+
+	todos, err := s.ListTodos(ListOptions{SortField: models.SortByID})
+	if err != nil {
+		return nil, fmt.Errorf("读取待办事项失败: %w", err)
+	}
+	snapshot := &models.Snapshot{
+		Version:    models.SnapshotVersion,
+		ExportedAt: time.Now(),
+		Todos:      todos,
+		Meta:       make(map[string]map[string]json.RawMessage),
+	}
+	sort.Slice(snapshot.Todos, func(i, j int) bool {
+		return snapshot.Todos[i].ID < snapshot.Todos[j].ID
+	})
+	snapshot.NextID = snapshot.MaxTodoID() + 1
+
+	for _, namespace := range MigrateNamespaces {
+		items, err := s.ListMeta(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("读取附属数据 %s 失败: %w", namespace, err)
+		}
+		if len(items) == 0 {
+			continue
+		}
+		snapshot.Meta[namespace] = make(map[string]json.RawMessage, len(items))
+		for key, value := range items {
+			snapshot.Meta[namespace][key] = json.RawMessage(value)
+		}
+	}
+	return snapshot, nil
+}
+
+// ExportSnapshot 把 Dump 导出的快照以JSON写入w，各存储的 Export 使用
+func ExportSnapshot(s TodoStore, w io.Writer) error {
+	snapshot, err := Dump(s)
+	if err != nil {
+		return err
+	}
+	if snapshot.ExportedAt.IsZero() {
+		snapshot.ExportedAt = time.Now()
+	}
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// ImportSnapshot 从r读取 ExportSnapshot 写出的快照（与备份文件的JSON格式相同），用 RestoreSnapshot 替换存储中的数据，各存储的 Import 使用
+func ImportSnapshot(s TodoStore, r io.Reader) error {
+	snapshot := &models.Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return fmt.Errorf("解析快照失败: %w", err)
+	}
+	if snapshot.Version > models.SnapshotVersion {
+		return fmt.Errorf("不支持的快照格式版本 %d", snapshot.Version)
+	}
+	return RestoreSnapshot(s, snapshot)
+}
+
+// RestoreSnapshot 用快照替换存储中的待办事项和 MigrateNamespaces 中的附属数据，在一个事务中完成
+// 快照中其它命名空间的数据被忽略，事件发件箱和集成方确认位置保持不变；
+// 恢复后新建事项的ID大于快照和当前存储中的最大ID，且不小于快照中的 next_id，已经分配过的ID不会被重新使用。
+// 存储的事务不支持按原样写入待办事项时返回 ErrLoadUnsupported
+func RestoreSnapshot(s TodoStore, snapshot *models.Snapshot) error {
+	ids := make([]int, len(snapshot.Todos))
+	for i, todo := range snapshot.Todos {
+		if todo.ID <= 0 {
+			return ErrInvalidID
+		}
+		ids[i] = todo.ID
+	}
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+	todos := make([]*models.Todo, len(snapshot.Todos))
+	for i, todo := range snapshot.Todos {
+		todos[i] = todo.Clone()
+		// 没有版本号的旧数据从版本1开始
+		if todos[i].Version == 0 {
+			todos[i].Version = 1
+		}
+	}
+
+	return s.Transaction(func(tx TodoStore) error {
+		loader, ok := tx.(Loader)
+		if !ok {
+			return ErrLoadUnsupported
+		}
+
+		existing, err := tx.GetAllTodos()
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			existingIDs := make([]int, len(existing))
+			for i, todo := range existing {
+				existingIDs[i] = todo.ID
+			}
+			if err := tx.BulkDelete(existingIDs); err != nil {
+				return fmt.Errorf("删除现有待办事项失败: %w", err)
+			}
+		}
+		for _, namespace := range MigrateNamespaces {
+			items, err := tx.ListMeta(namespace)
+			if err != nil {
+				return err
+			}
+			for key := range items {
+				if err := tx.DeleteMeta(namespace, key); err != nil {
+					return fmt.Errorf("删除附属数据 %s/%s 失败: %w", namespace, key, err)
+				}
+			}
+		}
+
+		if len(todos) > 0 {
+			if err := loader.LoadTodos(todos); err != nil {
+				return fmt.Errorf("写入待办事项失败: %w", err)
+			}
+		}
+		for _, namespace := range MigrateNamespaces {
+			for key, value := range snapshot.Meta[namespace] {
+				if err := tx.PutMeta(namespace, key, value); err != nil {
+					return fmt.Errorf("写入附属数据 %s/%s 失败: %w", namespace, key, err)
+				}
+			}
+		}
+		// 快照中的下一个可用ID可能大于其中的最大ID（最大ID的事项在导出前已被删除），需要保留
+		if reserver, ok := Unwrap(tx).(IDReserver); ok && snapshot.NextID > 1 {
+			if err := reserver.ReserveIDs(snapshot.NextID); err != nil {
+				return fmt.Errorf("保留下一个可用ID失败: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,384 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// DefaultCacheMaxEntries 未配置时缓存最多保存的待办事项数
+const DefaultCacheMaxEntries = 1000
+
+// maxCachedLists 最多缓存的 ListTodos 结果数（按不同的排序和过滤选项），超出时不再缓存新的查询
+const maxCachedLists = 100
+
+// Wrapper 可选接口，由包装其它存储的装饰器实现
+// 分布式锁、事件发件箱等可选能力由被包装的存储提供，NewLocker、NewEventLog 等通过 Unwrap 找到它们
+type Wrapper interface {
+	Unwrap() TodoStore
+}
+
+// Unwrap 逐层取出被包装的存储，返回最内层的存储
+func Unwrap(s TodoStore) TodoStore {
+	for {
+		wrapper, ok := s.(Wrapper)
+		if !ok {
+			return s
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	Hits      int64 `json:"hits"`      // 命中次数
+	Misses    int64 `json:"misses"`    // 未命中次数（包括已过期）
+	Evictions int64 `json:"evictions"` // 超过容量上限被淘汰的事项数
+	Entries   int   `json:"entries"`   // 当前缓存的事项数
+}
+
+// listEntry 缓存的一次 ListTodos 结果
+type listEntry struct {
+	todos   []*models.Todo
+	expires time.Time
+}
+
+// cacheEntry 缓存中的一个待办事项
+type cacheEntry struct {
+	todo    *models.Todo
+	expires time.Time
+	elem    *list.Element // 在LRU链表中的位置，值为ID
+}
+
+// CachedStore 缓存装饰器，在较慢的存储（如网络数据库）前缓存 GetTodoByID、GetAllTodos 和 ListTodos 的结果
+// 通过本存储执行的写操作会使相关缓存失效；事务提交后清空全部缓存。
+// 多个实例共用数据库时，其它实例的修改要等缓存过期后才能读到，因此有效期应设置得较短。
+// 其它读操作直接访问被包装的存储
+type CachedStore struct {
+	inner      TodoStore
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	todos   map[int]*cacheEntry   // 按ID缓存的待办事项
+	lru     *list.List            // 最近使用的在前
+	all     []*models.Todo        // 缓存的 GetAllTodos 结果，为nil表示没有缓存
+	allTill time.Time             // all 的过期时间
+	lists   map[string]*listEntry // 按排序和过滤选项缓存的 ListTodos 结果
+	gen     uint64                // 每次失效时递增，读取期间发生过失效时不写入缓存，避免缓存旧数据
+	stats   CacheStats
+}
+
+// NewCachedStore 创建缓存装饰器
+// ttl 为缓存有效期，maxEntries 为按ID缓存的最多事项数（不大于0时使用 DefaultCacheMaxEntries），超出时淘汰最久未使用的
+func NewCachedStore(inner TodoStore, ttl time.Duration, maxEntries int) *CachedStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return &CachedStore{
+		inner:      inner,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		todos:      make(map[int]*cacheEntry),
+		lru:        list.New(),
+		lists:      make(map[string]*listEntry),
+	}
+}
+
+// Unwrap 返回被包装的存储
+func (s *CachedStore) Unwrap() TodoStore {
+	return s.inner
+}
+
+// Stats 返回缓存统计
+func (s *CachedStore) Stats() CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Entries = len(s.todos)
+	return stats
+}
+
+// Purge 清空全部缓存
+func (s *CachedStore) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gen++
+	s.todos = make(map[int]*cacheEntry)
+	s.lru.Init()
+	s.all = nil
+	s.lists = make(map[string]*listEntry)
+}
+
+// invalidate 使指定事项和全部列表（包括 ListTodos 结果）的缓存失效
+func (s *CachedStore) invalidate(ids ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gen++
+	for _, id := range ids {
+		if entry, exists := s.todos[id]; exists {
+			s.lru.Remove(entry.elem)
+			delete(s.todos, id)
+		}
+	}
+	s.all = nil
+	s.lists = make(map[string]*listEntry)
+}
+
+// GetTodoByID 根据ID获取单个待办事项，优先使用缓存
+func (s *CachedStore) GetTodoByID(id int) (*models.Todo, error) {
+	now := time.Now()
+	s.mu.Lock()
+	if entry, exists := s.todos[id]; exists && now.Before(entry.expires) {
+		s.lru.MoveToFront(entry.elem)
+		s.stats.Hits++
+		todo := entry.todo.Clone()
+		s.mu.Unlock()
+		return todo, nil
+	}
+	s.stats.Misses++
+	gen := s.gen
+	s.mu.Unlock()
+
+	todo, err := s.inner.GetTodoByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.put(gen, todo, now)
+	return todo, nil
+}
+
+// put 缓存一个待办事项，读取期间发生过失效时不缓存
+func (s *CachedStore) put(gen uint64, todo *models.Todo, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.gen != gen {
+		return
+	}
+	if entry, exists := s.todos[todo.ID]; exists {
+		entry.todo, entry.expires = todo.Clone(), now.Add(s.ttl)
+		s.lru.MoveToFront(entry.elem)
+		return
+	}
+	s.todos[todo.ID] = &cacheEntry{todo: todo.Clone(), expires: now.Add(s.ttl), elem: s.lru.PushFront(todo.ID)}
+	for len(s.todos) > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.todos, oldest.Value.(int))
+		s.stats.Evictions++
+	}
+}
+
+// GetAllTodos 获取所有待办事项，优先使用缓存
+func (s *CachedStore) GetAllTodos() ([]*models.Todo, error) {
+	now := time.Now()
+	s.mu.Lock()
+	if s.all != nil && now.Before(s.allTill) {
+		s.stats.Hits++
+		todos := cloneTodos(s.all)
+		s.mu.Unlock()
+		return todos, nil
+	}
+	s.stats.Misses++
+	gen := s.gen
+	s.mu.Unlock()
+
+	todos, err := s.inner.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.gen == gen {
+		s.all, s.allTill = cloneTodos(todos), now.Add(s.ttl)
+	}
+	s.mu.Unlock()
+	return todos, nil
+}
+
+// cloneTodos 复制待办事项列表，缓存中的数据不会被调用方修改
+func cloneTodos(todos []*models.Todo) []*models.Todo {
+	cloned := make([]*models.Todo, len(todos))
+	for i, todo := range todos {
+		cloned[i] = todo.Clone()
+	}
+	return cloned
+}
+
+// ListTodos 按排序和过滤选项获取待办事项，优先使用缓存
+// 结果按选项分别缓存，与 GetAllTodos 一样在写操作后全部失效
+func (s *CachedStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return s.inner.ListTodos(opts)
+	}
+	key := string(data)
+
+	now := time.Now()
+	s.mu.Lock()
+	if entry, exists := s.lists[key]; exists && now.Before(entry.expires) {
+		s.stats.Hits++
+		todos := cloneTodos(entry.todos)
+		s.mu.Unlock()
+		return todos, nil
+	}
+	s.stats.Misses++
+	gen := s.gen
+	s.mu.Unlock()
+
+	todos, err := s.inner.ListTodos(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.gen == gen {
+		s.putList(key, todos, now)
+	}
+	s.mu.Unlock()
+	return todos, nil
+}
+
+// putList 缓存一次 ListTodos 结果，已满时先清除过期的结果，仍然已满时不缓存
+// 调用方需持有锁
+func (s *CachedStore) putList(key string, todos []*models.Todo, now time.Time) {
+	if _, exists := s.lists[key]; !exists && len(s.lists) >= maxCachedLists {
+		for cached, entry := range s.lists {
+			if !now.Before(entry.expires) {
+				delete(s.lists, cached)
+			}
+		}
+		if len(s.lists) >= maxCachedLists {
+			return
+		}
+	}
+	s.lists[key] = &listEntry{todos: cloneTodos(todos), expires: now.Add(s.ttl)}
+}
+
+// CreateTodo 创建新的待办事项
+func (s *CachedStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	defer s.invalidate()
+	return s.inner.CreateTodo(req)
+}
+
+// UpdateTodo 更新待办事项
+func (s *CachedStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	defer s.invalidate(id)
+	return s.inner.UpdateTodo(id, req)
+}
+
+// DeleteTodo 删除待办事项
+func (s *CachedStore) DeleteTodo(id int) error {
+	defer s.invalidate(id)
+	return s.inner.DeleteTodo(id)
+}
+
+// SaveTodo 保存完整的待办事项
+func (s *CachedStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	defer s.invalidate(todo.ID)
+	return s.inner.SaveTodo(todo)
+}
+
+// SearchTodos 搜索待办事项，不使用缓存
+func (s *CachedStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	return s.inner.SearchTodos(query, category, completed)
+}
+
+// BulkCreate 批量创建待办事项
+func (s *CachedStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	defer s.invalidate()
+	return s.inner.BulkCreate(reqs)
+}
+
+// BulkUpdate 批量更新待办事项
+func (s *CachedStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	ids := make([]int, len(updates))
+	for i, update := range updates {
+		ids[i] = update.ID
+	}
+	defer s.invalidate(ids...)
+	return s.inner.BulkUpdate(updates)
+}
+
+// BulkDelete 批量删除待办事项
+func (s *CachedStore) BulkDelete(ids []int) error {
+	defer s.invalidate(ids...)
+	return s.inner.BulkDelete(ids)
+}
+
+// GetStats 获取统计信息，不使用缓存
+func (s *CachedStore) GetStats() (map[string]interface{}, error) {
+	return s.inner.GetStats()
+}
+
+// Transaction 在被包装存储的事务中执行fn，事务中的读写都不经过缓存，结束后清空全部缓存
+// 提交之前开始的读取在清空之后不会再写入缓存，事务结束之后的读取总能读到提交的数据
+func (s *CachedStore) Transaction(fn func(tx TodoStore) error) error {
+	defer s.Purge()
+	return s.inner.Transaction(fn)
+}
+
+// Export 把全部数据以快照JSON写入w，不使用缓存
+func (s *CachedStore) Export(w io.Writer) error {
+	return s.inner.Export(w)
+}
+
+// Import 从r读取快照并替换全部数据，结束后清空全部缓存
+func (s *CachedStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// GetMeta 读取附属数据，不使用缓存
+func (s *CachedStore) GetMeta(namespace, key string) ([]byte, error) {
+	return s.inner.GetMeta(namespace, key)
+}
+
+// PutMeta 写入附属数据
+func (s *CachedStore) PutMeta(namespace, key string, value []byte) error {
+	return s.inner.PutMeta(namespace, key, value)
+}
+
+// DeleteMeta 删除附属数据
+func (s *CachedStore) DeleteMeta(namespace, key string) error {
+	return s.inner.DeleteMeta(namespace, key)
+}
+
+// ListMeta 列出命名空间下的所有附属数据，不使用缓存
+func (s *CachedStore) ListMeta(namespace string) (map[string][]byte, error) {
+	return s.inner.ListMeta(namespace)
+}
+
+// LoadTodos 按原样写入待办事项，被包装的存储不支持时返回 ErrLoadUnsupported
+func (s *CachedStore) LoadTodos(todos []*models.Todo) error {
+	loader, ok := s.inner.(Loader)
+	if !ok {
+		return ErrLoadUnsupported
+	}
+	defer s.Purge()
+	return loader.LoadTodos(todos)
+}
+
+// Ping 检查被包装的存储是否可用
+func (s *CachedStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Close 关闭被包装的存储
+func (s *CachedStore) Close() error {
+	if closer, ok := s.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// CalDAVResourcesNamespace CalDAV 资源对应关系在附属数据中的命名空间，键为资源名称
+const CalDAVResourcesNamespace = "caldav_resources"
+
+// GetCalDAVResource 根据资源名称获取对应关系
+// 资源名称没有对应关系时返回 ErrMetaNotFound
+func GetCalDAVResource(s MetaStore, name string) (*models.CalDAVResource, error) {
+	data, err := s.GetMeta(CalDAVResourcesNamespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource models.CalDAVResource
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// SaveCalDAVResource 保存对应关系（已存在则覆盖）
+func SaveCalDAVResource(s MetaStore, resource *models.CalDAVResource) error {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(CalDAVResourcesNamespace, resource.Name, data)
+}
+
+// DeleteCalDAVResource 删除对应关系
+func DeleteCalDAVResource(s MetaStore, name string) error {
+	return s.DeleteMeta(CalDAVResourcesNamespace, name)
+}
+
+// ListCalDAVResources 列出所有对应关系，key为待办事项ID
+func ListCalDAVResources(s MetaStore) (map[int]*models.CalDAVResource, error) {
+	items, err := s.ListMeta(CalDAVResourcesNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[int]*models.CalDAVResource, len(items))
+	for _, data := range items {
+		var resource models.CalDAVResource
+		if err := json.Unmarshal(data, &resource); err != nil {
+			return nil, err
+		}
+		results[resource.TodoID] = &resource
+	}
+	return results, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// CategoryDefaultsNamespace 分类默认设置在附属数据中的命名空间
+const CategoryDefaultsNamespace = "category_defaults"
+
+// GetCategoryDefaults 获取指定分类的默认设置
+// 分类没有配置默认设置时返回 ErrMetaNotFound
+func GetCategoryDefaults(s MetaStore, category string) (*models.CategoryDefaults, error) {
+	data, err := s.GetMeta(CategoryDefaultsNamespace, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaults models.CategoryDefaults
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, err
+	}
+	return &defaults, nil
+}
+
+// SaveCategoryDefaults 保存分类默认设置（已存在则覆盖）
+func SaveCategoryDefaults(s MetaStore, defaults *models.CategoryDefaults) error {
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(CategoryDefaultsNamespace, defaults.Category, data)
+}
+
+// DeleteCategoryDefaults 删除分类默认设置
+func DeleteCategoryDefaults(s MetaStore, category string) error {
+	return s.DeleteMeta(CategoryDefaultsNamespace, category)
+}
+
+// ListCategoryDefaults 列出所有分类的默认设置，按分类名称排序
+func ListCategoryDefaults(s MetaStore) ([]*models.CategoryDefaults, error) {
+	items, err := s.ListMeta(CategoryDefaultsNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.CategoryDefaults, 0, len(items))
+	for _, data := range items {
+		var defaults models.CategoryDefaults
+		if err := json.Unmarshal(data, &defaults); err != nil {
+			return nil, err
+		}
+		results = append(results, &defaults)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Category < results[j].Category
+	})
+	return results, nil
+}
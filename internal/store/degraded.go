@@ -0,0 +1,510 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// ErrStoreUnavailable 存储后端不可用、处于降级模式时写操作返回的错误，API 对应 503 Service Unavailable
+var ErrStoreUnavailable = errors.New("存储暂时不可用")
+
+// 降级模式的默认间隔
+const (
+	DefaultHealthInterval  = 5 * time.Second  // 检查后端是否可用的间隔
+	DefaultFallbackRefresh = 60 * time.Second // 后端可用时刷新只读副本的间隔
+)
+
+// pingTimeout 每次检查后端是否可用的超时时间
+const pingTimeout = 2 * time.Second
+
+// DegradedStatus 降级模式的状态
+type DegradedStatus struct {
+	Degraded      bool       `json:"degraded"`                 // 后端是否不可用，不可用时读操作返回只读副本中的数据，写操作被拒绝
+	Since         *time.Time `json:"since,omitempty"`          // 进入降级模式的时间
+	LastError     string     `json:"last_error,omitempty"`     // 最近一次检查失败的原因
+	RefreshedAt   *time.Time `json:"refreshed_at,omitempty"`   // 只读副本最近一次从后端全量刷新的时间
+	Outages       int64      `json:"outages"`                  // 进入降级模式的次数
+	LastRecovered *time.Time `json:"last_recovered,omitempty"` // 最近一次恢复的时间
+}
+
+// Recovery 后端从不可用恢复时的通知
+type Recovery struct {
+	DegradedAt  time.Time // 进入降级模式的时间
+	RecoveredAt time.Time // 恢复的时间
+	LastError   string    // 降级期间最后一次检查失败的原因
+}
+
+// DegradableStore 降级装饰器，用于 PostgreSQL、MySQL、Redis 等依赖外部服务的存储
+// 在内存中保留一份只读副本（待办事项和 MigrateNamespaces 中的附属数据），通过本存储的写操作同步更新，并定期从后端全量刷新。
+// 后端操作失败且 Ping 不通时进入降级模式：读操作返回只读副本中的数据（其它实例在此期间的修改读不到），
+// 写操作不访问后端，直接返回 ErrStoreUnavailable；之后按间隔检查后端，恢复后先刷新只读副本再退出降级模式，并调用 OnRecover
+type DegradableStore struct {
+	inner    TodoStore
+	pinger   Pinger
+	fallback *MemoryStore
+	interval time.Duration
+	refresh  time.Duration
+
+	mu            sync.RWMutex
+	degraded      bool
+	since         time.Time
+	lastErr       string
+	refreshedAt   time.Time
+	outages       int64
+	lastRecovered time.Time
+	onRecover     func(Recovery)
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDegradableStore 创建降级装饰器，从后端加载只读副本并开始定期检查
+// inner（或被它包装的存储）需要实现 Pinger；interval、refresh 不大于0时使用默认值
+func NewDegradableStore(inner TodoStore, interval, refresh time.Duration) (*DegradableStore, error) {
+	pinger, ok := inner.(Pinger)
+	if !ok {
+		return nil, errors.New("存储不支持可用性检查（Ping），不能启用降级模式")
+	}
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+	if refresh <= 0 {
+		refresh = DefaultFallbackRefresh
+	}
+
+	s := &DegradableStore{
+		inner:    inner,
+		pinger:   pinger,
+		fallback: newMemoryStore(),
+		interval: interval,
+		refresh:  refresh,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := s.reload(); err != nil {
+		return nil, fmt.Errorf("加载只读副本失败: %w", err)
+	}
+	go s.monitor()
+	return s, nil
+}
+
+// FindDegradable 逐层查找降级装饰器，没有启用降级模式时返回nil
+func FindDegradable(s TodoStore) *DegradableStore {
+	for {
+		if degradable, ok := s.(*DegradableStore); ok {
+			return degradable
+		}
+		wrapper, ok := s.(Wrapper)
+		if !ok {
+			return nil
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// Unwrap 返回被包装的存储
+func (s *DegradableStore) Unwrap() TodoStore {
+	return s.inner
+}
+
+// OnRecover 设置后端恢复时的回调，在检查协程中调用
+func (s *DegradableStore) OnRecover(fn func(Recovery)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRecover = fn
+}
+
+// Degraded 是否处于降级模式
+func (s *DegradableStore) Degraded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.degraded
+}
+
+// RetryAfter 建议客户端重试的等待时间，即检查后端的间隔
+func (s *DegradableStore) RetryAfter() time.Duration {
+	return s.interval
+}
+
+// Status 返回降级模式的状态
+func (s *DegradableStore) Status() *DegradedStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := &DegradedStatus{Degraded: s.degraded, LastError: s.lastErr, Outages: s.outages}
+	if s.degraded {
+		since := s.since
+		status.Since = &since
+	}
+	if !s.refreshedAt.IsZero() {
+		refreshedAt := s.refreshedAt
+		status.RefreshedAt = &refreshedAt
+	}
+	if !s.lastRecovered.IsZero() {
+		lastRecovered := s.lastRecovered
+		status.LastRecovered = &lastRecovered
+	}
+	return status
+}
+
+// reload 从后端全量刷新只读副本
+func (s *DegradableStore) reload() error {
+	snapshot, err := Dump(s.inner)
+	if err != nil {
+		return err
+	}
+	if err := s.fallback.Restore(snapshot); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.refreshedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// monitor 检查协程：按间隔 Ping 后端，不可用时进入降级模式，恢复后退出；后端可用时按刷新间隔全量刷新只读副本
+func (s *DegradableStore) monitor() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !s.check() {
+			continue
+		}
+		s.mu.RLock()
+		stale := time.Since(s.refreshedAt) >= s.refresh
+		s.mu.RUnlock()
+		if stale {
+			if err := s.reload(); err != nil {
+				log.Printf("⚠️ 刷新只读副本失败: %v", err)
+			}
+		}
+	}
+}
+
+// check Ping 后端并更新降级状态，返回后端是否可用
+func (s *DegradableStore) check() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := s.pinger.Ping(ctx); err != nil {
+		s.degrade(err)
+		return false
+	}
+
+	s.mu.RLock()
+	degraded := s.degraded
+	s.mu.RUnlock()
+	if degraded {
+		s.recover()
+	}
+	return true
+}
+
+// degrade 进入降级模式，已经在降级模式时只更新失败原因
+func (s *DegradableStore) degrade(cause error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastErr = cause.Error()
+	if s.degraded {
+		return
+	}
+	s.degraded, s.since = true, time.Now()
+	s.outages++
+	log.Printf("⚠️ 存储后端不可用，进入降级模式（只读，写操作返回503）: %v", cause)
+}
+
+// recover 后端恢复：先刷新只读副本，再退出降级模式并通知
+// 刷新失败时保持降级模式，下一次检查时重试
+func (s *DegradableStore) recover() {
+	if err := s.reload(); err != nil {
+		s.degrade(err)
+		return
+	}
+
+	s.mu.Lock()
+	recovery := Recovery{DegradedAt: s.since, RecoveredAt: time.Now(), LastError: s.lastErr}
+	s.degraded, s.since, s.lastErr = false, time.Time{}, ""
+	s.lastRecovered = recovery.RecoveredAt
+	onRecover := s.onRecover
+	s.mu.Unlock()
+
+	log.Printf("✅ 存储后端已恢复，退出降级模式（持续 %v）", recovery.RecoveredAt.Sub(recovery.DegradedAt).Round(time.Second))
+	if onRecover != nil {
+		onRecover(recovery)
+	}
+}
+
+// failed 后端操作返回错误时检查后端是否仍然可用，返回是否（已经）进入降级模式
+// 找不到数据、版本冲突等业务错误不检查
+func (s *DegradableStore) failed(err error) bool {
+	if err == nil || isBusinessError(err) {
+		return false
+	}
+	return !s.check()
+}
+
+// isBusinessError 是否是与后端可用性无关的业务错误
+func isBusinessError(err error) bool {
+	for _, target := range []error{
+		ErrTodoNotFound, ErrInvalidID, ErrMetaNotFound, ErrDuplicateID, ErrInvalidSortField,
+		ErrVersionConflict, ErrStoreFull, ErrLoadUnsupported, ErrStoreUnavailable,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// read 执行读操作：降级模式下直接读只读副本，否则读后端，后端失败并且确认不可用时改读只读副本
+func read[T any](s *DegradableStore, fromInner func(TodoStore) (T, error), fromFallback func(*MemoryStore) (T, error)) (T, error) {
+	if s.Degraded() {
+		return fromFallback(s.fallback)
+	}
+	result, err := fromInner(s.inner)
+	if s.failed(err) {
+		return fromFallback(s.fallback)
+	}
+	return result, err
+}
+
+// write 执行写操作：降级模式下返回 ErrStoreUnavailable；成功后由 apply 同步更新只读副本
+func (s *DegradableStore) write(fn func() error, apply func(fallback *MemoryStore)) error {
+	if s.Degraded() {
+		return ErrStoreUnavailable
+	}
+	if err := fn(); err != nil {
+		if s.failed(err) {
+			return fmt.Errorf("%w: %v", ErrStoreUnavailable, err)
+		}
+		return err
+	}
+	apply(s.fallback)
+	return nil
+}
+
+// putFallback 用写操作的结果替换只读副本中的事项
+func putFallback(fallback *MemoryStore, todos ...*models.Todo) {
+	fallback.Transaction(func(tx TodoStore) error {
+		for _, todo := range todos {
+			tx.DeleteTodo(todo.ID)
+		}
+		return tx.(*MemoryStore).LoadTodos(todos)
+	})
+}
+
+// deleteFallback 从只读副本中删除事项
+func deleteFallback(fallback *MemoryStore, ids ...int) {
+	for _, id := range ids {
+		fallback.DeleteTodo(id)
+	}
+}
+
+// GetAllTodos 获取所有待办事项
+func (s *DegradableStore) GetAllTodos() ([]*models.Todo, error) {
+	return read(s, TodoStore.GetAllTodos, (*MemoryStore).GetAllTodos)
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (s *DegradableStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	return read(s,
+		func(inner TodoStore) ([]*models.Todo, error) { return inner.ListTodos(opts) },
+		func(fallback *MemoryStore) ([]*models.Todo, error) { return fallback.ListTodos(opts) })
+}
+
+// GetTodoByID 根据ID获取单个待办事项
+func (s *DegradableStore) GetTodoByID(id int) (*models.Todo, error) {
+	return read(s,
+		func(inner TodoStore) (*models.Todo, error) { return inner.GetTodoByID(id) },
+		func(fallback *MemoryStore) (*models.Todo, error) { return fallback.GetTodoByID(id) })
+}
+
+// CreateTodo 创建待办事项
+func (s *DegradableStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.write(func() (err error) {
+		todo, err = s.inner.CreateTodo(req)
+		return err
+	}, func(fallback *MemoryStore) { putFallback(fallback, todo) })
+	return todo, err
+}
+
+// UpdateTodo 更新待办事项
+func (s *DegradableStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.write(func() (err error) {
+		todo, err = s.inner.UpdateTodo(id, req)
+		return err
+	}, func(fallback *MemoryStore) { putFallback(fallback, todo) })
+	return todo, err
+}
+
+// DeleteTodo 删除待办事项
+func (s *DegradableStore) DeleteTodo(id int) error {
+	return s.write(func() error {
+		return s.inner.DeleteTodo(id)
+	}, func(fallback *MemoryStore) { deleteFallback(fallback, id) })
+}
+
+// SaveTodo 保存完整的待办事项
+func (s *DegradableStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	var saved *models.Todo
+	err := s.write(func() (err error) {
+		saved, err = s.inner.SaveTodo(todo)
+		return err
+	}, func(fallback *MemoryStore) { putFallback(fallback, saved) })
+	return saved, err
+}
+
+// SearchTodos 搜索待办事项
+func (s *DegradableStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	return read(s,
+		func(inner TodoStore) ([]*models.Todo, error) { return inner.SearchTodos(query, category, completed) },
+		func(fallback *MemoryStore) ([]*models.Todo, error) {
+			return fallback.SearchTodos(query, category, completed)
+		})
+}
+
+// BulkCreate 批量创建待办事项
+func (s *DegradableStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	err := s.write(func() (err error) {
+		todos, err = s.inner.BulkCreate(reqs)
+		return err
+	}, func(fallback *MemoryStore) { putFallback(fallback, todos...) })
+	return todos, err
+}
+
+// BulkUpdate 批量更新待办事项
+func (s *DegradableStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	err := s.write(func() (err error) {
+		todos, err = s.inner.BulkUpdate(updates)
+		return err
+	}, func(fallback *MemoryStore) { putFallback(fallback, todos...) })
+	return todos, err
+}
+
+// BulkDelete 批量删除待办事项
+func (s *DegradableStore) BulkDelete(ids []int) error {
+	return s.write(func() error {
+		return s.inner.BulkDelete(ids)
+	}, func(fallback *MemoryStore) { deleteFallback(fallback, ids...) })
+}
+
+// GetStats 获取统计信息
+func (s *DegradableStore) GetStats() (map[string]interface{}, error) {
+	return read(s, TodoStore.GetStats, (*MemoryStore).GetStats)
+}
+
+// Transaction 在后端的事务中执行fn，提交后从后端全量刷新只读副本
+// 事务中的操作直接访问后端，不经过降级判断
+func (s *DegradableStore) Transaction(fn func(tx TodoStore) error) error {
+	return s.write(func() error {
+		return s.inner.Transaction(fn)
+	}, func(*MemoryStore) {
+		if err := s.reload(); err != nil {
+			log.Printf("⚠️ 刷新只读副本失败: %v", err)
+		}
+	})
+}
+
+// Export 把全部数据以快照JSON写入w，降级模式下导出只读副本中的数据
+func (s *DegradableStore) Export(w io.Writer) error {
+	if s.Degraded() {
+		return s.fallback.Export(w)
+	}
+	return s.inner.Export(w)
+}
+
+// Import 从r读取快照并替换全部数据，降级模式下返回 ErrStoreUnavailable
+func (s *DegradableStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// GetMeta 读取附属数据，降级模式下只能读取 MigrateNamespaces 中的附属数据
+func (s *DegradableStore) GetMeta(namespace, key string) ([]byte, error) {
+	return read(s,
+		func(inner TodoStore) ([]byte, error) { return inner.GetMeta(namespace, key) },
+		func(fallback *MemoryStore) ([]byte, error) {
+			if !slices.Contains(MigrateNamespaces, namespace) {
+				return nil, ErrStoreUnavailable
+			}
+			return fallback.GetMeta(namespace, key)
+		})
+}
+
+// PutMeta 写入附属数据
+func (s *DegradableStore) PutMeta(namespace, key string, value []byte) error {
+	return s.write(func() error {
+		return s.inner.PutMeta(namespace, key, value)
+	}, func(fallback *MemoryStore) {
+		if slices.Contains(MigrateNamespaces, namespace) {
+			fallback.PutMeta(namespace, key, value)
+		}
+	})
+}
+
+// DeleteMeta 删除附属数据
+func (s *DegradableStore) DeleteMeta(namespace, key string) error {
+	return s.write(func() error {
+		return s.inner.DeleteMeta(namespace, key)
+	}, func(fallback *MemoryStore) { fallback.DeleteMeta(namespace, key) })
+}
+
+// ListMeta 列出命名空间下的所有附属数据，降级模式下只能列出 MigrateNamespaces 中的附属数据
+func (s *DegradableStore) ListMeta(namespace string) (map[string][]byte, error) {
+	return read(s,
+		func(inner TodoStore) (map[string][]byte, error) { return inner.ListMeta(namespace) },
+		func(fallback *MemoryStore) (map[string][]byte, error) {
+			if !slices.Contains(MigrateNamespaces, namespace) {
+				return nil, ErrStoreUnavailable
+			}
+			return fallback.ListMeta(namespace)
+		})
+}
+
+// LoadTodos 按原样写入待办事项，被包装的存储不支持时返回 ErrLoadUnsupported
+func (s *DegradableStore) LoadTodos(todos []*models.Todo) error {
+	loader, ok := s.inner.(Loader)
+	if !ok {
+		return ErrLoadUnsupported
+	}
+	return s.write(func() error {
+		return loader.LoadTodos(todos)
+	}, func(fallback *MemoryStore) { putFallback(fallback, todos...) })
+}
+
+// Ping 检查后端是否可用
+func (s *DegradableStore) Ping(ctx context.Context) error {
+	return s.pinger.Ping(ctx)
+}
+
+// Close 停止检查协程并关闭被包装的存储
+func (s *DegradableStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+	if closer, ok := s.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
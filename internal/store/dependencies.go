@@ -0,0 +1,64 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// DependenciesNamespace 待办事项阻塞依赖在附属数据中的命名空间，键为被阻塞的待办事项ID
+const DependenciesNamespace = "dependencies"
+
+// GetDependencies 获取待办事项的阻塞依赖，没有依赖时返回 BlockedBy 为空的记录
+func GetDependencies(s MetaStore, todoID int) (*models.Dependencies, error) {
+	data, err := s.GetMeta(DependenciesNamespace, strconv.Itoa(todoID))
+	if errors.Is(err, ErrMetaNotFound) {
+		return &models.Dependencies{TodoID: todoID, BlockedBy: []int{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var deps models.Dependencies
+	if err := json.Unmarshal(data, &deps); err != nil {
+		return nil, err
+	}
+	return &deps, nil
+}
+
+// SaveDependencies 保存待办事项的阻塞依赖（已存在则覆盖），没有依赖时删除记录
+func SaveDependencies(s MetaStore, deps *models.Dependencies) error {
+	key := strconv.Itoa(deps.TodoID)
+	if len(deps.BlockedBy) == 0 {
+		if err := s.DeleteMeta(DependenciesNamespace, key); err != nil && !errors.Is(err, ErrMetaNotFound) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(deps)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(DependenciesNamespace, key, data)
+}
+
+// ListDependencies 列出所有待办事项的阻塞依赖，key为被阻塞的待办事项ID
+func ListDependencies(s MetaStore) (map[int]*models.Dependencies, error) {
+	items, err := s.ListMeta(DependenciesNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[int]*models.Dependencies, len(items))
+	for _, data := range items {
+		var deps models.Dependencies
+		if err := json.Unmarshal(data, &deps); err != nil {
+			return nil, err
+		}
+		results[deps.TodoID] = &deps
+	}
+	return results, nil
+}
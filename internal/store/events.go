@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// EventLog 事件发件箱接口
+// 事件按追加顺序分配单调递增的序号，集成方通过 ListEvents 从上次确认的序号之后继续读取
+type EventLog interface {
+	AppendEvent(event *models.Event) error                      // 追加事件，成功后 event.Seq 为分配的序号
+	ListEvents(after int64, limit int) ([]*models.Event, error) // 按序号升序列出序号大于 after 的事件，最多 limit 个
+	PurgeEvents(before time.Time) (int, error)                  // 删除早于指定时间的事件，返回删除的数量
+}
+
+// NewEventLog 返回存储对应的事件发件箱
+// SQL、Redis 存储自身实现了 EventLog，多个实例共用时序号依然唯一；
+// 内存、文件等单实例存储把事件保存在附属数据中（文件存储因此可以在重启后保留事件）。
+// 同一个存储只应创建一个 EventLog。s 是装饰器时使用被包装的存储的发件箱，启用了变更日志时使用 JournaledStore 的发件箱
+func NewEventLog(s TodoStore) EventLog {
+	if journaled := FindJournaled(s); journaled != nil {
+		// 变更日志模式下与存储共用同一个发件箱，保证序号连续
+		return journaled.log
+	}
+	if log, ok := Unwrap(s).(EventLog); ok {
+		return log
+	}
+	return &metaEventLog{meta: s}
+}
+
+// EventsNamespace 单实例存储的事件在附属数据中的命名空间，键为补零的序号，保证按字符串排序即按序号排序
+const EventsNamespace = "events"
+
+// OverdueEventsNamespace 已记录过期事件的事项在附属数据中的命名空间，键为事项ID，值为记录时的截止时间
+const OverdueEventsNamespace = "overdue_events"
+
+// FiredRemindersNamespace 已发送提醒的事项在附属数据中的命名空间，键为事项ID，值为发送时的提醒时间
+const FiredRemindersNamespace = "fired_reminders"
+
+// EventOffsetsNamespace 集成方确认位置在附属数据中的命名空间，键为集成方名称
+const EventOffsetsNamespace = "event_offsets"
+
+// GetEventOffset 获取集成方已确认的事件位置，没有确认过时返回 ErrMetaNotFound
+func GetEventOffset(s MetaStore, consumer string) (*models.EventOffset, error) {
+	data, err := s.GetMeta(EventOffsetsNamespace, consumer)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset models.EventOffset
+	if err := json.Unmarshal(data, &offset); err != nil {
+		return nil, err
+	}
+	return &offset, nil
+}
+
+// SaveEventOffset 保存集成方已确认的事件位置（已存在则覆盖）
+func SaveEventOffset(s MetaStore, offset *models.EventOffset) error {
+	data, err := json.Marshal(offset)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(EventOffsetsNamespace, offset.Consumer, data)
+}
+
+// metaEventLog 基于附属数据的事件发件箱，用于单实例存储
+type metaEventLog struct {
+	meta MetaStore
+
+	mu      sync.Mutex
+	lastSeq int64 // 最后分配的序号，首次追加时从已有事件中恢复
+	loaded  bool
+}
+
+// eventKey 事件在附属数据中的键
+func eventKey(seq int64) string {
+	return fmt.Sprintf("%020d", seq)
+}
+
+// AppendEvent 追加事件
+func (l *metaEventLog) AppendEvent(event *models.Event) error {
+	return l.appendTo(l.meta, event)
+}
+
+// appendTo 通过 meta 追加事件，meta 是事务中的存储时事件与事务中的修改一起提交
+// 事务回滚时已分配的序号不会重新使用，读取方应容忍序号间的空洞
+func (l *metaEventLog) appendTo(meta MetaStore, event *models.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded {
+		events, err := allEvents(meta)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			l.lastSeq = events[len(events)-1].Seq
+		}
+		l.loaded = true
+	}
+
+	event.Seq = l.lastSeq + 1
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := meta.PutMeta(EventsNamespace, eventKey(event.Seq), data); err != nil {
+		return err
+	}
+	l.lastSeq = event.Seq
+	return nil
+}
+
+// ListEvents 列出序号大于 after 的事件
+func (l *metaEventLog) ListEvents(after int64, limit int) ([]*models.Event, error) {
+	events, err := l.all()
+	if err != nil {
+		return nil, err
+	}
+
+	start := sort.Search(len(events), func(i int) bool { return events[i].Seq > after })
+	events = events[start:]
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// PurgeEvents 删除早于指定时间的事件
+// 最后一个事件即使过期也会保留，保证重启后序号不会倒退。
+// 不持有追加事件的锁：变更日志模式下事件在存储的事务中追加，持有锁等待存储会与事务互相等待；
+// 清理时新追加的事件序号更大，读取到的最后一个事件之后总有事件保留
+func (l *metaEventLog) PurgeEvents(before time.Time) (int, error) {
+	events, err := l.all()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i, event := range events {
+		if i == len(events)-1 || !event.CreatedAt.Before(before) {
+			break
+		}
+		if err := l.meta.DeleteMeta(EventsNamespace, eventKey(event.Seq)); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// all 按序号升序读取所有事件
+func (l *metaEventLog) all() ([]*models.Event, error) {
+	return allEvents(l.meta)
+}
+
+// allEvents 按序号升序读取附属数据中的所有事件
+func allEvents(meta MetaStore) ([]*models.Event, error) {
+	items, err := meta.ListMeta(EventsNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.Event, 0, len(items))
+	for _, data := range items {
+		var event models.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+	return events, nil
+}
+
+// AppendEvent 追加事件
+// 注意：序号在插入时分配，并发事务提交的先后可能与序号顺序不同，读取方应容忍序号间的短暂空洞
+func (s *sqlStore) AppendEvent(event *models.Event) error {
+	args := []interface{}{event.Type, event.TodoID, string(event.Data), s.dialect.timeValue(event.CreatedAt)}
+
+	if s.dialect.returningID {
+		return s.stmt("event_insert").QueryRow(args...).Scan(&event.Seq)
+	}
+
+	result, err := s.stmt("event_insert").Exec(args...)
+	if err != nil {
+		return err
+	}
+	event.Seq, err = result.LastInsertId()
+	return err
+}
+
+// ListEvents 列出序号大于 after 的事件
+func (s *sqlStore) ListEvents(after int64, limit int) ([]*models.Event, error) {
+	rows, err := s.stmt("event_list").Query(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+	for rows.Next() {
+		var event models.Event
+		var data string
+		var createdAt sqlTime
+		if err := rows.Scan(&event.Seq, &event.Type, &event.TodoID, &data, &createdAt); err != nil {
+			return nil, err
+		}
+		if data != "" {
+			event.Data = json.RawMessage(data)
+		}
+		event.CreatedAt = createdAt.Time
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// PurgeEvents 删除早于指定时间的事件，自增序号不会因删除而重新分配
+func (s *sqlStore) PurgeEvents(before time.Time) (int, error) {
+	result, err := s.stmt("event_purge").Exec(s.dialect.timeValue(before))
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// redisAppendEventScript 原子地分配序号并写入事件
+// 有序集合的分数为序号，成员为 "序号:事件JSON"，序号前缀保证成员唯一
+var redisAppendEventScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('ZADD', KEYS[2], seq, seq .. ':' .. ARGV[1])
+return seq
+`)
+
+// AppendEvent 追加事件，序号计数器保存在 events:seq 中，事件保存在有序集合 events 中
+func (s *RedisStore) AppendEvent(event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	seq, err := redisAppendEventScript.Run(context.Background(), s.client,
+		[]string{s.key("events", "seq"), s.key("events")}, string(data)).Int64()
+	if err != nil {
+		return err
+	}
+	event.Seq = seq
+	return nil
+}
+
+// ListEvents 列出序号大于 after 的事件
+func (s *RedisStore) ListEvents(after int64, limit int) ([]*models.Event, error) {
+	members, err := s.client.ZRangeByScore(context.Background(), s.key("events"), &redis.ZRangeBy{
+		Min:   "(" + strconv.FormatInt(after, 10),
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.Event, 0, len(members))
+	for _, member := range members {
+		event, err := decodeRedisEvent(member)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// PurgeEvents 删除早于指定时间的事件
+// 事件按序号追加，时间基本有序，从最早的事件开始删除，遇到不早于指定时间的事件即停止
+func (s *RedisStore) PurgeEvents(before time.Time) (int, error) {
+	ctx := context.Background()
+	const batch = 100
+
+	purged := 0
+	for {
+		members, err := s.client.ZRange(ctx, s.key("events"), 0, batch-1).Result()
+		if err != nil {
+			return purged, err
+		}
+
+		var expired []interface{}
+		for _, member := range members {
+			event, err := decodeRedisEvent(member)
+			if err != nil {
+				return purged, err
+			}
+			if !event.CreatedAt.Before(before) {
+				break
+			}
+			expired = append(expired, member)
+		}
+		if len(expired) == 0 {
+			return purged, nil
+		}
+
+		if err := s.client.ZRem(ctx, s.key("events"), expired...).Err(); err != nil {
+			return purged, err
+		}
+		purged += len(expired)
+		if len(expired) < len(members) {
+			return purged, nil
+		}
+	}
+}
+
+// decodeRedisEvent 解析 "序号:事件JSON" 格式的有序集合成员
+func decodeRedisEvent(member string) (*models.Event, error) {
+	seqText, data, ok := strings.Cut(member, ":")
+	if !ok {
+		return nil, fmt.Errorf("无效的事件数据: %s", member)
+	}
+	seq, err := strconv.ParseInt(seqText, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的事件序号: %s", seqText)
+	}
+
+	var event models.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, err
+	}
+	event.Seq = seq
+	return &event, nil
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+)
+
+// 文件型存储未配置路径时使用的默认路径
+const (
+	DefaultFilePath   = "data/todos.json"     // 文件存储的默认数据文件
+	DefaultSQLitePath = "data/xstreamtool.db" // SQLite 的默认数据库文件
+)
+
+// Factory 存储后端工厂函数，根据数据库配置创建存储
+type Factory func(cfg config.DatabaseConfig) (TodoStore, error)
+
+// 已注册的存储后端，key为数据库类型（config.DatabaseConfig.Type）
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register 注册存储后端，之后配置中的数据库类型为 name 时使用 factory 创建存储
+// 嵌入本服务器的程序可以在调用 NewStore 之前（通常在 init 中）注册自己的后端，无需修改本包。
+// 与 database/sql.Register 一样，name 为空、factory 为nil或重复注册同名后端时会 panic
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if name == "" {
+		panic("store: 存储后端名称不能为空")
+	}
+	if factory == nil {
+		panic("store: 存储后端 " + name + " 的工厂函数为nil")
+	}
+	if _, exists := factories[name]; exists {
+		panic("store: 重复注册存储后端 " + name)
+	}
+	factories[name] = factory
+}
+
+// Backends 返回已注册的存储后端名称（按字母排序）
+func Backends() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStore 根据数据库配置创建对应的存储后端
+// 类型为空时使用内存存储；类型未注册或连接失败时返回带有存储类型的错误。
+// 启用变更日志时后端先被包装为 JournaledStore，配置了副本时再包装为 ReplicatedStore，启用降级模式时再包装为 DegradableStore，启用监控时再包装为 InstrumentedStore，启用全文索引时再包装为 IndexedStore，
+// 配置了缓存有效期时最外层包装为 CachedStore（缓存命中不计入后端的统计）。
+// 返回的存储如果实现了 io.Closer，调用方应在退出时关闭
+func NewStore(cfg *config.DatabaseConfig) (TodoStore, error) {
+	name := cfg.Type
+	if name == "" {
+		name = "memory"
+	}
+
+	factoriesMu.RLock()
+	factory, exists := factories[name]
+	factoriesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("不支持的数据库类型: %q（可选 %s）", cfg.Type, strings.Join(Backends(), "、"))
+	}
+
+	s, err := factory(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Journal {
+		s = NewJournaledStore(s)
+	}
+	if len(cfg.Replicas) > 0 {
+		replicated, err := newReplicatedStore(s, cfg)
+		if err != nil {
+			if closer, ok := s.(io.Closer); ok {
+				closer.Close()
+			}
+			return nil, err
+		}
+		s = replicated
+	}
+	if cfg.DegradedMode {
+		degradable, err := NewDegradableStore(s, time.Duration(cfg.HealthInterval)*time.Second, time.Duration(cfg.FallbackRefresh)*time.Second)
+		if err != nil {
+			if closer, ok := s.(io.Closer); ok {
+				closer.Close()
+			}
+			return nil, fmt.Errorf("启用降级模式失败: %w", err)
+		}
+		s = degradable
+	}
+	if cfg.Instrument {
+		s = NewInstrumentedStore(s)
+	}
+	if cfg.SearchIndex {
+		indexed, err := NewIndexedStore(s, cfg.SearchFuzziness)
+		if err != nil {
+			if closer, ok := s.(io.Closer); ok {
+				closer.Close()
+			}
+			return nil, fmt.Errorf("初始化全文索引失败: %w", err)
+		}
+		s = indexed
+	}
+	if cfg.CacheTTL > 0 {
+		s = NewCachedStore(s, time.Duration(cfg.CacheTTL)*time.Millisecond, cfg.CacheMaxEntries)
+	}
+	return s, nil
+}
+
+// newReplicatedStore 打开配置中的全部副本，把主存储包装为 ReplicatedStore；任一副本打开失败时关闭已打开的副本
+func newReplicatedStore(primary TodoStore, cfg *config.DatabaseConfig) (*ReplicatedStore, error) {
+	secondaries := make([]TodoStore, 0, len(cfg.Replicas))
+	names := make([]string, 0, len(cfg.Replicas))
+	for i, replicaConfig := range cfg.Replicas {
+		replicaConfig.Replicas = nil
+		secondary, err := NewStore(&replicaConfig)
+		if err != nil {
+			for _, opened := range secondaries {
+				if closer, ok := opened.(io.Closer); ok {
+					closer.Close()
+				}
+			}
+			return nil, fmt.Errorf("打开第 %d 个副本失败: %w", i+1, err)
+		}
+		secondaries = append(secondaries, secondary)
+		names = append(names, replicaName(&replicaConfig))
+	}
+	return NewReplicatedStore(primary, secondaries, names, time.Duration(cfg.ReplicaMaxLag)*time.Second), nil
+}
+
+// replicaName 副本在复制状态中显示的名称：类型加数据文件路径或主机，不包含账号和密码
+func replicaName(cfg *config.DatabaseConfig) string {
+	name := cfg.Type
+	if name == "" {
+		name = "memory"
+	}
+	switch {
+	case cfg.Path != "":
+		return name + ":" + cfg.Path
+	case cfg.Host != "":
+		return fmt.Sprintf("%s:%s:%d/%s", name, cfg.Host, cfg.Port, cfg.Name)
+	}
+	return name
+}
+
+// init 注册内置的存储后端
+func init() {
+	Register("memory", func(cfg config.DatabaseConfig) (TodoStore, error) {
+		limits, err := memoryLimits(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化内存存储失败: %w", err)
+		}
+		seed, err := LoadSeed(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化内存存储失败: %w", err)
+		}
+		if cfg.WALDir == "" {
+			memoryStore := newMemoryStore() // 数据只保存在内存中，重启后丢失
+			if err := memoryStore.SeedWith(seed); err != nil {
+				return nil, fmt.Errorf("初始化内存存储失败: %w", err)
+			}
+			memoryStore.SetLimits(limits)
+			return memoryStore, nil
+		}
+		// 启用操作日志：每次修改追加到日志，定期写入检查点，重启后恢复数据
+		memoryStore, err := OpenMemoryStore(cfg.WALDir, time.Duration(cfg.SnapshotInterval)*time.Second, seed)
+		if err != nil {
+			return nil, fmt.Errorf("初始化内存存储失败（%s）: %w", cfg.WALDir, err)
+		}
+		memoryStore.SetLimits(limits)
+		return memoryStore, nil
+	})
+	Register("file", func(cfg config.DatabaseConfig) (TodoStore, error) {
+		path := cfg.Path
+		if path == "" {
+			path = DefaultFilePath
+		}
+		limits, err := memoryLimits(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化文件存储失败（%s）: %w", path, err)
+		}
+		seed, err := LoadSeed(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("初始化文件存储失败（%s）: %w", path, err)
+		}
+		fileStore, err := NewFileStore(path, seed) // 数据保存在内存中，每次修改后写入JSON文件
+		if err != nil {
+			return nil, fmt.Errorf("初始化文件存储失败（%s）: %w", path, err)
+		}
+		fileStore.SetLimits(limits)
+		return fileStore, nil
+	})
+	Register("sqlite", func(cfg config.DatabaseConfig) (TodoStore, error) {
+		path := cfg.Path
+		if path == "" {
+			path = DefaultSQLitePath
+		}
+		sqliteStore, err := NewSQLiteStore(path) // 数据保存在本地文件中
+		if err != nil {
+			return nil, fmt.Errorf("初始化SQLite存储失败（%s）: %w", path, err)
+		}
+		return sqliteStore, nil
+	})
+	Register("postgres", func(cfg config.DatabaseConfig) (TodoStore, error) {
+		postgresStore, err := NewPostgresStore(&cfg) // 使用配置中的主机、端口、库名和账号连接
+		if err != nil {
+			return nil, fmt.Errorf("初始化PostgreSQL存储失败（主机 %s，库 %s）: %w", cfg.Host, cfg.Name, err)
+		}
+		return postgresStore, nil
+	})
+	Register("mysql", func(cfg config.DatabaseConfig) (TodoStore, error) {
+		mysqlStore, err := NewMySQLStore(&cfg) // 使用配置中的主机、端口、库名和账号连接
+		if err != nil {
+			return nil, fmt.Errorf("初始化MySQL存储失败（主机 %s，库 %s）: %w", cfg.Host, cfg.Name, err)
+		}
+		return mysqlStore, nil
+	})
+	Register("redis", func(cfg config.DatabaseConfig) (TodoStore, error) {
+		redisStore, err := NewRedisStore(&cfg) // 使用配置中的主机、端口和密码连接，库名作为键前缀
+		if err != nil {
+			return nil, fmt.Errorf("初始化Redis存储失败（主机 %s）: %w", cfg.Host, err)
+		}
+		return redisStore, nil
+	})
+}
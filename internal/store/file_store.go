@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// FileStore 文件存储实现
+// 数据保存在内存中（读取性能与内存存储相同），每次修改后把完整快照写入JSON文件，启动时从文件加载。
+// 写文件时先写入同目录下的临时文件再重命名，即使写入过程中进程崩溃，数据文件也总是完整的
+type FileStore struct {
+	*MemoryStore
+
+	path string     // 数据文件路径
+	mu   sync.Mutex // 保证同一时间只有一个写文件操作，且后写入的快照总是包含之前的所有修改
+}
+
+// NewFileStore 创建文件存储
+// 数据文件存在时从文件加载数据；不存在时写入初始数据 seed（为nil时写入示例数据）并创建文件，所在目录不存在时会自动创建
+func NewFileStore(path string, seed *models.Snapshot) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建数据目录失败: %w", err)
+	}
+
+	s := &FileStore{
+		MemoryStore: newMemoryStore(),
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var snapshot models.Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("解析数据文件失败: %w", err)
+		}
+		if err := s.MemoryStore.Restore(&snapshot); err != nil {
+			return nil, fmt.Errorf("加载数据文件失败: %w", err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		// 首次运行：与内存存储一样写入初始数据
+		if err := s.MemoryStore.SeedWith(seed); err != nil {
+			return nil, fmt.Errorf("写入初始数据失败: %w", err)
+		}
+		if err := s.persist(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("读取数据文件失败: %w", err)
+	}
+
+	return s, nil
+}
+
+// CreateTodo 创建新的待办事项并写入文件
+func (s *FileStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := s.MemoryStore.CreateTodo(req)
+	if err != nil {
+		return nil, err
+	}
+	return todo, s.persist()
+}
+
+// UpdateTodo 更新待办事项并写入文件
+func (s *FileStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := s.MemoryStore.UpdateTodo(id, req)
+	if err != nil {
+		return nil, err
+	}
+	return todo, s.persist()
+}
+
+// SaveTodo 保存完整的待办事项并写入文件
+func (s *FileStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	saved, err := s.MemoryStore.SaveTodo(todo)
+	if err != nil {
+		return nil, err
+	}
+	return saved, s.persist()
+}
+
+// DeleteTodo 删除待办事项并写入文件
+func (s *FileStore) DeleteTodo(id int) error {
+	if err := s.MemoryStore.DeleteTodo(id); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// BulkCreate 批量创建待办事项，全部创建后只写入一次文件
+func (s *FileStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	todos, err := s.MemoryStore.BulkCreate(reqs)
+	if err != nil {
+		return nil, err
+	}
+	return todos, s.persist()
+}
+
+// BulkUpdate 批量更新待办事项，全部更新后只写入一次文件
+func (s *FileStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	todos, err := s.MemoryStore.BulkUpdate(updates)
+	if err != nil {
+		return nil, err
+	}
+	return todos, s.persist()
+}
+
+// BulkDelete 批量删除待办事项，全部删除后只写入一次文件
+func (s *FileStore) BulkDelete(ids []int) error {
+	if err := s.MemoryStore.BulkDelete(ids); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// LoadTodos 按原样写入待办事项，全部写入后只写入一次文件
+func (s *FileStore) LoadTodos(todos []*models.Todo) error {
+	if err := s.MemoryStore.LoadTodos(todos); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// Transaction 在一个事务中执行fn，提交后只写入一次文件
+func (s *FileStore) Transaction(fn func(tx TodoStore) error) error {
+	if err := s.MemoryStore.Transaction(fn); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// Import 从r读取快照，替换全部数据后写入一次文件
+func (s *FileStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// PutMeta 写入附属数据并写入文件
+func (s *FileStore) PutMeta(namespace, key string, value []byte) error {
+	if err := s.MemoryStore.PutMeta(namespace, key, value); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// DeleteMeta 删除附属数据并写入文件
+func (s *FileStore) DeleteMeta(namespace, key string) error {
+	if err := s.MemoryStore.DeleteMeta(namespace, key); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// Restore 用快照替换当前的全部数据并写入文件
+func (s *FileStore) Restore(snapshot *models.Snapshot) error {
+	if err := s.MemoryStore.Restore(snapshot); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// Ping 检查数据文件是否仍然存在（数据目录被删除或卸载时后续写入会失败）
+func (s *FileStore) Ping(ctx context.Context) error {
+	_, err := os.Stat(s.path)
+	return err
+}
+
+// persist 把当前数据的完整快照原子地写入数据文件
+// 快照在持有文件锁之后生成，保证并发修改时文件中保存的总是最新的数据
+func (s *FileStore) persist() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s.MemoryStore.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码数据失败: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("写入数据文件失败: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic 原子地写入文件：先写入同目录下的临时文件并同步到磁盘，再重命名为目标文件
+// 重命名在同一文件系统内是原子操作，读取方要么看到旧文件，要么看到完整的新文件
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	// 出错时清理临时文件
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	success = true
+
+	// 同步目录，确保重命名本身也已写入磁盘（部分平台不支持，忽略错误）
+	if dirFile, err := os.Open(dir); err == nil {
+		dirFile.Sync()
+		dirFile.Close()
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// TodoFilter 列出待办事项时的过滤条件，所有条件同时满足的事项才会返回，零值表示不过滤
+// 截止日期条件为半开区间 [DueAfter, DueBefore)，设置了任一截止日期条件时没有截止日期的事项不会返回
+type TodoFilter struct {
+	Category   string            // 分类，精确匹配
+	Completed  *bool             // 完成状态，为nil时不过滤
+	Priorities []models.Priority // 优先级，匹配其中任意一个，为空时不过滤
+	DueBefore  *time.Time        // 截止日期早于该时间
+	DueAfter   *time.Time        // 截止日期不早于该时间
+	Overdue    *bool             // true 只返回已过期（未完成且截止日期已过）的事项，false 只返回未过期的事项
+	Archived   *bool             // 归档状态，为nil时不过滤
+	Tags       []string          // 必须带有的标签（全部），为空时不过滤
+	LinkedTo   int               // 有关联链接指向该待办事项ID，为0时不过滤（用于查找反向链接）
+	Now        time.Time         // 判断是否过期的参照时间，为零值时使用当前时间
+}
+
+// IsZero 是否没有任何过滤条件
+func (f TodoFilter) IsZero() bool {
+	return f.Category == "" && f.Completed == nil && len(f.Priorities) == 0 &&
+		f.DueBefore == nil && f.DueAfter == nil && f.Overdue == nil && f.Archived == nil && len(f.Tags) == 0 &&
+		f.LinkedTo == 0
+}
+
+// now 返回判断是否过期的参照时间
+func (f TodoFilter) now() time.Time {
+	if f.Now.IsZero() {
+		return time.Now()
+	}
+	return f.Now
+}
+
+// overdue 事项在参照时间是否已过期，规则与统计中的 overdue 一致
+func overdue(todo *models.Todo, now time.Time) bool {
+	return !todo.Completed && todo.DueDate != nil && todo.DueDate.Before(now)
+}
+
+// Matches 判断事项是否满足全部过滤条件
+func (f TodoFilter) Matches(todo *models.Todo, now time.Time) bool {
+	switch {
+	case f.Category != "" && todo.Category != f.Category:
+		return false
+	case f.Completed != nil && todo.Completed != *f.Completed:
+		return false
+	case len(f.Priorities) > 0 && !slices.Contains(f.Priorities, todo.Priority):
+		return false
+	case (f.DueBefore != nil || f.DueAfter != nil) && todo.DueDate == nil:
+		return false
+	case f.DueBefore != nil && !todo.DueDate.Before(*f.DueBefore):
+		return false
+	case f.DueAfter != nil && todo.DueDate.Before(*f.DueAfter):
+		return false
+	case f.Overdue != nil && overdue(todo, now) != *f.Overdue:
+		return false
+	case f.Archived != nil && todo.Archived != *f.Archived:
+		return false
+	case !todo.HasTags(f.Tags):
+		return false
+	case f.LinkedTo != 0 && !slices.ContainsFunc(todo.Links, func(link models.TodoLink) bool { return link.TargetID == f.LinkedTo }):
+		return false
+	}
+	return true
+}
+
+// filterTodos 在内存中过滤事项，保持原有顺序；没有过滤条件时原样返回
+// Redis 等没有查询能力的存储使用
+func filterTodos(todos []*models.Todo, f TodoFilter) []*models.Todo {
+	if f.IsZero() {
+		return todos
+	}
+	now := f.now()
+	results := todos[:0]
+	for _, todo := range todos {
+		if f.Matches(todo, now) {
+			results = append(results, todo)
+		}
+	}
+	return results
+}
+
+// sqlConditions 把过滤条件转换为 WHERE 子句中的条件表达式和参数（使用 ? 占位符），没有过滤条件时返回空
+// 标签以JSON数组保存，按JSON编码后的字符串（带引号）匹配子串，标签中不含引号和反斜杠，不会误匹配；
+// 关联链接同样以JSON数组保存，target_id 是最后一个字段，按 "target_id":ID} 匹配不会误匹配到更长的ID
+func (f TodoFilter) sqlConditions(dialect *sqlDialect) ([]string, []interface{}) {
+	timeValue := dialect.timeValue
+	var conditions []string
+	var args []interface{}
+
+	if f.Category != "" {
+		conditions = append(conditions, `category = ?`)
+		args = append(args, f.Category)
+	}
+	if f.Completed != nil {
+		conditions = append(conditions, `completed = ?`)
+		args = append(args, *f.Completed)
+	}
+	if len(f.Priorities) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Priorities)), ", ")
+		conditions = append(conditions, `priority IN (`+placeholders+`)`)
+		for _, priority := range f.Priorities {
+			args = append(args, int(priority))
+		}
+	}
+	if f.DueBefore != nil {
+		conditions = append(conditions, `due_date IS NOT NULL AND due_date < ?`)
+		args = append(args, timeValue(*f.DueBefore))
+	}
+	if f.DueAfter != nil {
+		conditions = append(conditions, `due_date IS NOT NULL AND due_date >= ?`)
+		args = append(args, timeValue(*f.DueAfter))
+	}
+	if f.Overdue != nil {
+		condition := `(NOT completed AND due_date IS NOT NULL AND due_date < ?)`
+		if !*f.Overdue {
+			condition = `NOT ` + condition
+		}
+		conditions = append(conditions, condition)
+		args = append(args, timeValue(f.now()))
+	}
+	if f.Archived != nil {
+		conditions = append(conditions, `archived = ?`)
+		args = append(args, *f.Archived)
+	}
+	for _, tag := range f.Tags {
+		pattern := `"` + tag + `"`
+		if dialect.pattern != nil {
+			pattern = dialect.pattern(pattern)
+		}
+		conditions = append(conditions, dialect.contains("tags"))
+		args = append(args, pattern)
+	}
+	if f.LinkedTo != 0 {
+		pattern := `"target_id":` + strconv.Itoa(f.LinkedTo) + `}`
+		if dialect.pattern != nil {
+			pattern = dialect.pattern(pattern)
+		}
+		conditions = append(conditions, dialect.contains("links"))
+		args = append(args, pattern)
+	}
+	return conditions, args
+}
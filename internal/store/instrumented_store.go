@@ -0,0 +1,327 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// latencyBuckets 耗时分布的上限，超过最后一个上限的计入 "+Inf"
+var latencyBuckets = []struct {
+	name  string
+	limit time.Duration
+}{
+	{"1ms", time.Millisecond},
+	{"5ms", 5 * time.Millisecond},
+	{"10ms", 10 * time.Millisecond},
+	{"50ms", 50 * time.Millisecond},
+	{"100ms", 100 * time.Millisecond},
+	{"500ms", 500 * time.Millisecond},
+	{"1s", time.Second},
+}
+
+// MethodStats 存储方法的调用统计
+type MethodStats struct {
+	Calls     int64            `json:"calls"`      // 调用次数
+	Errors    int64            `json:"errors"`     // 返回错误的次数，不包括找不到数据、版本冲突等业务错误
+	ErrorRate float64          `json:"error_rate"` // 错误率（0-1）
+	AvgMs     float64          `json:"avg_ms"`     // 平均耗时（毫秒）
+	MaxMs     float64          `json:"max_ms"`     // 最长耗时（毫秒）
+	Buckets   map[string]int64 `json:"buckets"`    // 耗时分布，key为耗时上限，每次调用只计入第一个不小于其耗时的区间
+}
+
+// methodCounter 单个方法的累计数据
+type methodCounter struct {
+	calls   int64
+	errors  int64
+	total   time.Duration
+	max     time.Duration
+	buckets []int64 // 与 latencyBuckets 一一对应，最后一个为 +Inf
+}
+
+// instrumentStats 调用统计，InstrumentedStore 和它在事务中使用的包装共用
+type instrumentStats struct {
+	mu      sync.Mutex
+	methods map[string]*methodCounter
+}
+
+// InstrumentedStore 监控装饰器，记录被包装存储每个方法的调用次数、耗时和错误率
+// 用于判断存储后端是否是性能瓶颈；事务中的操作以 "tx." 为前缀单独统计，Transaction 本身的耗时包括事务中的全部操作
+type InstrumentedStore struct {
+	inner  TodoStore
+	prefix string
+	stats  *instrumentStats
+}
+
+// NewInstrumentedStore 创建监控装饰器
+func NewInstrumentedStore(inner TodoStore) *InstrumentedStore {
+	return &InstrumentedStore{
+		inner: inner,
+		stats: &instrumentStats{methods: make(map[string]*methodCounter)},
+	}
+}
+
+// Unwrap 返回被包装的存储
+func (s *InstrumentedStore) Unwrap() TodoStore {
+	return s.inner
+}
+
+// Stats 返回每个方法的调用统计，key为方法名
+func (s *InstrumentedStore) Stats() map[string]MethodStats {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	results := make(map[string]MethodStats, len(s.stats.methods))
+	for name, counter := range s.stats.methods {
+		stats := MethodStats{
+			Calls:   counter.calls,
+			Errors:  counter.errors,
+			MaxMs:   durationMs(counter.max),
+			Buckets: make(map[string]int64, len(latencyBuckets)+1),
+		}
+		if counter.calls > 0 {
+			stats.ErrorRate = float64(counter.errors) / float64(counter.calls)
+			stats.AvgMs = durationMs(counter.total / time.Duration(counter.calls))
+		}
+		for i, bucket := range latencyBuckets {
+			stats.Buckets[bucket.name] = counter.buckets[i]
+		}
+		stats.Buckets["+Inf"] = counter.buckets[len(latencyBuckets)]
+		results[name] = stats
+	}
+	return results
+}
+
+// durationMs 把耗时转换为毫秒，保留三位小数
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// observe 记录一次调用
+func (s *InstrumentedStore) observe(method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	name := s.prefix + method
+	counter, exists := s.stats.methods[name]
+	if !exists {
+		counter = &methodCounter{buckets: make([]int64, len(latencyBuckets)+1)}
+		s.stats.methods[name] = counter
+	}
+	counter.calls++
+	counter.total += elapsed
+	if elapsed > counter.max {
+		counter.max = elapsed
+	}
+	if err != nil && !isExpectedError(err) {
+		counter.errors++
+	}
+
+	bucket := len(latencyBuckets)
+	for i, b := range latencyBuckets {
+		if elapsed <= b.limit {
+			bucket = i
+			break
+		}
+	}
+	counter.buckets[bucket]++
+}
+
+// isExpectedError 判断错误是否是正常的业务结果（而不是存储后端的故障）
+func isExpectedError(err error) bool {
+	return errors.Is(err, ErrTodoNotFound) || errors.Is(err, ErrMetaNotFound) ||
+		errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrDuplicateID) || errors.Is(err, ErrInvalidID)
+}
+
+// GetAllTodos 获取所有待办事项
+func (s *InstrumentedStore) GetAllTodos() ([]*models.Todo, error) {
+	start := time.Now()
+	todos, err := s.inner.GetAllTodos()
+	s.observe("GetAllTodos", start, err)
+	return todos, err
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (s *InstrumentedStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	start := time.Now()
+	todos, err := s.inner.ListTodos(opts)
+	s.observe("ListTodos", start, err)
+	return todos, err
+}
+
+// GetTodoByID 根据ID获取单个待办事项
+func (s *InstrumentedStore) GetTodoByID(id int) (*models.Todo, error) {
+	start := time.Now()
+	todo, err := s.inner.GetTodoByID(id)
+	s.observe("GetTodoByID", start, err)
+	return todo, err
+}
+
+// CreateTodo 创建新的待办事项
+func (s *InstrumentedStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	start := time.Now()
+	todo, err := s.inner.CreateTodo(req)
+	s.observe("CreateTodo", start, err)
+	return todo, err
+}
+
+// UpdateTodo 更新待办事项
+func (s *InstrumentedStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	start := time.Now()
+	todo, err := s.inner.UpdateTodo(id, req)
+	s.observe("UpdateTodo", start, err)
+	return todo, err
+}
+
+// DeleteTodo 删除待办事项
+func (s *InstrumentedStore) DeleteTodo(id int) error {
+	start := time.Now()
+	err := s.inner.DeleteTodo(id)
+	s.observe("DeleteTodo", start, err)
+	return err
+}
+
+// SaveTodo 保存完整的待办事项
+func (s *InstrumentedStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	start := time.Now()
+	saved, err := s.inner.SaveTodo(todo)
+	s.observe("SaveTodo", start, err)
+	return saved, err
+}
+
+// SearchTodos 搜索待办事项
+func (s *InstrumentedStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	start := time.Now()
+	todos, err := s.inner.SearchTodos(query, category, completed)
+	s.observe("SearchTodos", start, err)
+	return todos, err
+}
+
+// BulkCreate 批量创建待办事项
+func (s *InstrumentedStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	start := time.Now()
+	todos, err := s.inner.BulkCreate(reqs)
+	s.observe("BulkCreate", start, err)
+	return todos, err
+}
+
+// BulkUpdate 批量更新待办事项
+func (s *InstrumentedStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	start := time.Now()
+	todos, err := s.inner.BulkUpdate(updates)
+	s.observe("BulkUpdate", start, err)
+	return todos, err
+}
+
+// BulkDelete 批量删除待办事项
+func (s *InstrumentedStore) BulkDelete(ids []int) error {
+	start := time.Now()
+	err := s.inner.BulkDelete(ids)
+	s.observe("BulkDelete", start, err)
+	return err
+}
+
+// GetStats 获取统计信息
+func (s *InstrumentedStore) GetStats() (map[string]interface{}, error) {
+	start := time.Now()
+	stats, err := s.inner.GetStats()
+	s.observe("GetStats", start, err)
+	return stats, err
+}
+
+// Transaction 在被包装存储的事务中执行fn，事务中的操作以 "tx." 为前缀统计
+// 已经在事务中时（嵌套事务），前缀保持不变
+func (s *InstrumentedStore) Transaction(fn func(tx TodoStore) error) error {
+	start := time.Now()
+	err := s.inner.Transaction(func(tx TodoStore) error {
+		return fn(&InstrumentedStore{inner: tx, prefix: "tx.", stats: s.stats})
+	})
+	s.observe("Transaction", start, err)
+	return err
+}
+
+// Export 导出快照
+func (s *InstrumentedStore) Export(w io.Writer) error {
+	start := time.Now()
+	err := s.inner.Export(w)
+	s.observe("Export", start, err)
+	return err
+}
+
+// Import 导入快照
+func (s *InstrumentedStore) Import(r io.Reader) error {
+	start := time.Now()
+	err := s.inner.Import(r)
+	s.observe("Import", start, err)
+	return err
+}
+
+// GetMeta 读取附属数据
+func (s *InstrumentedStore) GetMeta(namespace, key string) ([]byte, error) {
+	start := time.Now()
+	value, err := s.inner.GetMeta(namespace, key)
+	s.observe("GetMeta", start, err)
+	return value, err
+}
+
+// PutMeta 写入附属数据
+func (s *InstrumentedStore) PutMeta(namespace, key string, value []byte) error {
+	start := time.Now()
+	err := s.inner.PutMeta(namespace, key, value)
+	s.observe("PutMeta", start, err)
+	return err
+}
+
+// DeleteMeta 删除附属数据
+func (s *InstrumentedStore) DeleteMeta(namespace, key string) error {
+	start := time.Now()
+	err := s.inner.DeleteMeta(namespace, key)
+	s.observe("DeleteMeta", start, err)
+	return err
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *InstrumentedStore) ListMeta(namespace string) (map[string][]byte, error) {
+	start := time.Now()
+	items, err := s.inner.ListMeta(namespace)
+	s.observe("ListMeta", start, err)
+	return items, err
+}
+
+// LoadTodos 按原样写入待办事项，被包装的存储不支持时返回 ErrLoadUnsupported
+func (s *InstrumentedStore) LoadTodos(todos []*models.Todo) error {
+	loader, ok := s.inner.(Loader)
+	if !ok {
+		return ErrLoadUnsupported
+	}
+	start := time.Now()
+	err := loader.LoadTodos(todos)
+	s.observe("LoadTodos", start, err)
+	return err
+}
+
+// Ping 检查被包装的存储是否可用
+func (s *InstrumentedStore) Ping(ctx context.Context) error {
+	pinger, ok := s.inner.(Pinger)
+	if !ok {
+		return nil
+	}
+	start := time.Now()
+	err := pinger.Ping(ctx)
+	s.observe("Ping", start, err)
+	return err
+}
+
+// Close 关闭被包装的存储
+func (s *InstrumentedStore) Close() error {
+	if closer, ok := s.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
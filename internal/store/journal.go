@@ -0,0 +1,377 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// JournaledStore 变更日志装饰器，把待办事项的每一次修改记录为事件发件箱中的一个事件
+// 与 API 层在请求成功后写入事件不同，规则、归档、回收站、CalDAV 等所有经过存储的修改都会记录；
+// 每个写操作在被包装存储的一个事务中执行，SQL 存储和内存、文件存储的事件与修改一起提交，不会出现修改成功但事件丢失的情况
+// （Redis 存储的事务不能写入事件，事件在事务提交后追加）。事件序号单调递增，集成方、Webhook、SSE 和副本等
+// 读取方保存最后处理的序号，通过 EventLog.ListEvents 从该序号之后读取即可可靠地补上遗漏的修改
+type JournaledStore struct {
+	inner TodoStore
+	log   EventLog      // 发件箱，读取、清理事件和事务外追加事件时使用
+	meta  *metaEventLog // 基于附属数据的发件箱（内存、文件存储），事务中通过事务的存储写入；其它存储为nil
+
+	inTx     bool             // 是否在事务中
+	deferred *[]*models.Event // 事务中不能写入、等待提交后追加的事件，事务外为nil
+}
+
+// NewJournaledStore 创建变更日志装饰器，事件写入被包装存储的发件箱（见 NewEventLog）
+func NewJournaledStore(inner TodoStore) *JournaledStore {
+	s := &JournaledStore{inner: inner}
+	if log, ok := Unwrap(inner).(EventLog); ok {
+		s.log = log
+	} else {
+		s.meta = &metaEventLog{meta: inner}
+		s.log = s.meta
+	}
+	return s
+}
+
+// FindJournaled 逐层查找变更日志装饰器，没有启用变更日志时返回nil
+func FindJournaled(s TodoStore) *JournaledStore {
+	for {
+		if journaled, ok := s.(*JournaledStore); ok {
+			return journaled
+		}
+		wrapper, ok := s.(Wrapper)
+		if !ok {
+			return nil
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// Unwrap 返回被包装的存储
+func (s *JournaledStore) Unwrap() TodoStore {
+	return s.inner
+}
+
+// Events 返回记录变更的事件发件箱
+func (s *JournaledStore) Events() EventLog {
+	return s.log
+}
+
+// write 在事务中执行写操作fn，已经在事务中时直接执行；fn 通过 j 读写数据并记录事件
+func (s *JournaledStore) write(fn func(j *JournaledStore) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+	return s.Transaction(func(tx TodoStore) error {
+		return fn(tx.(*JournaledStore))
+	})
+}
+
+// record 记录事件：SQL 存储通过事务中的存储写入，内存、文件存储写入事务中的附属数据，都不支持时等待事务提交后追加
+func (s *JournaledStore) record(events ...*models.Event) error {
+	for _, event := range events {
+		switch {
+		case s.meta != nil:
+			if err := s.meta.appendTo(s.inner, event); err != nil {
+				return err
+			}
+		case s.deferred != nil:
+			if log, ok := s.inner.(EventLog); ok {
+				if err := log.AppendEvent(event); err != nil {
+					return err
+				}
+				continue
+			}
+			*s.deferred = append(*s.deferred, event)
+		default:
+			if err := s.log.AppendEvent(event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newChangeEvent 生成待办事项修改后的事件，todo 为nil表示已删除
+func newChangeEvent(eventType string, id int, todo *models.Todo, now time.Time) (*models.Event, error) {
+	event := &models.Event{Type: eventType, TodoID: id, CreatedAt: now}
+	if todo != nil {
+		data, err := json.Marshal(todo)
+		if err != nil {
+			return nil, err
+		}
+		event.Data = data
+	}
+	return event, nil
+}
+
+// changeType 根据修改前后的内容确定事件类型：由未完成变为完成时为 todo.completed，其它修改为 todo.updated
+func changeType(before, after *models.Todo) string {
+	if before != nil && !before.Completed && after.Completed {
+		return models.EventTodoCompleted
+	}
+	return models.EventTodoUpdated
+}
+
+// recordSaved 记录一批创建或修改的事项，befores 为修改前的内容（按ID），不存在的事项记为创建
+func (s *JournaledStore) recordSaved(saved []*models.Todo, befores map[int]*models.Todo, created bool) error {
+	now := time.Now()
+	for _, todo := range saved {
+		eventType := models.EventTodoCreated
+		if !created {
+			eventType = changeType(befores[todo.ID], todo)
+		}
+		event, err := newChangeEvent(eventType, todo.ID, todo, now)
+		if err != nil {
+			return err
+		}
+		if err := s.record(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordDeleted 记录一批删除的事项
+func (s *JournaledStore) recordDeleted(ids []int) error {
+	now := time.Now()
+	for _, id := range ids {
+		event, err := newChangeEvent(models.EventTodoDeleted, id, nil, now)
+		if err != nil {
+			return err
+		}
+		if err := s.record(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// befores 读取修改前的事项，不存在的事项不在结果中
+func (s *JournaledStore) befores(ids []int) (map[int]*models.Todo, error) {
+	befores := make(map[int]*models.Todo, len(ids))
+	for _, id := range ids {
+		todo, err := s.inner.GetTodoByID(id)
+		if errors.Is(err, ErrTodoNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		befores[id] = todo
+	}
+	return befores, nil
+}
+
+// GetAllTodos 获取所有待办事项
+func (s *JournaledStore) GetAllTodos() ([]*models.Todo, error) {
+	return s.inner.GetAllTodos()
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (s *JournaledStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	return s.inner.ListTodos(opts)
+}
+
+// GetTodoByID 根据ID获取单个待办事项
+func (s *JournaledStore) GetTodoByID(id int) (*models.Todo, error) {
+	return s.inner.GetTodoByID(id)
+}
+
+// CreateTodo 创建待办事项并记录 todo.created
+func (s *JournaledStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	var created *models.Todo
+	err := s.write(func(j *JournaledStore) error {
+		todo, err := j.inner.CreateTodo(req)
+		if err != nil {
+			return err
+		}
+		created = todo
+		return j.recordSaved([]*models.Todo{todo}, nil, true)
+	})
+	return created, err
+}
+
+// UpdateTodo 更新待办事项并记录 todo.updated 或 todo.completed
+func (s *JournaledStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	var updated *models.Todo
+	err := s.write(func(j *JournaledStore) error {
+		befores, err := j.befores([]int{id})
+		if err != nil {
+			return err
+		}
+		todo, err := j.inner.UpdateTodo(id, req)
+		if err != nil {
+			return err
+		}
+		updated = todo
+		return j.recordSaved([]*models.Todo{todo}, befores, false)
+	})
+	return updated, err
+}
+
+// DeleteTodo 删除待办事项并记录 todo.deleted
+func (s *JournaledStore) DeleteTodo(id int) error {
+	return s.write(func(j *JournaledStore) error {
+		if err := j.inner.DeleteTodo(id); err != nil {
+			return err
+		}
+		return j.recordDeleted([]int{id})
+	})
+}
+
+// SaveTodo 保存完整的待办事项并记录 todo.updated 或 todo.completed
+func (s *JournaledStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	var saved *models.Todo
+	err := s.write(func(j *JournaledStore) error {
+		befores, err := j.befores([]int{todo.ID})
+		if err != nil {
+			return err
+		}
+		result, err := j.inner.SaveTodo(todo)
+		if err != nil {
+			return err
+		}
+		saved = result
+		return j.recordSaved([]*models.Todo{result}, befores, false)
+	})
+	return saved, err
+}
+
+// SearchTodos 搜索待办事项
+func (s *JournaledStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	return s.inner.SearchTodos(query, category, completed)
+}
+
+// BulkCreate 批量创建待办事项，每个事项记录一个 todo.created
+func (s *JournaledStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	var created []*models.Todo
+	err := s.write(func(j *JournaledStore) error {
+		todos, err := j.inner.BulkCreate(reqs)
+		if err != nil {
+			return err
+		}
+		created = todos
+		return j.recordSaved(todos, nil, true)
+	})
+	return created, err
+}
+
+// BulkUpdate 批量更新待办事项，每个事项记录一个 todo.updated 或 todo.completed
+func (s *JournaledStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	var updated []*models.Todo
+	err := s.write(func(j *JournaledStore) error {
+		befores, err := j.befores(updateIDs(updates))
+		if err != nil {
+			return err
+		}
+		todos, err := j.inner.BulkUpdate(updates)
+		if err != nil {
+			return err
+		}
+		updated = todos
+		return j.recordSaved(todos, befores, false)
+	})
+	return updated, err
+}
+
+// BulkDelete 批量删除待办事项，每个事项记录一个 todo.deleted
+func (s *JournaledStore) BulkDelete(ids []int) error {
+	return s.write(func(j *JournaledStore) error {
+		if err := j.inner.BulkDelete(ids); err != nil {
+			return err
+		}
+		return j.recordDeleted(ids)
+	})
+}
+
+// GetStats 获取统计信息
+func (s *JournaledStore) GetStats() (map[string]interface{}, error) {
+	return s.inner.GetStats()
+}
+
+// Transaction 在被包装存储的事务中执行fn，事务中的修改和它们的事件一起提交或撤销
+// 嵌套事务直接在当前事务中执行；事务中不能写入的事件（Redis）在最外层事务提交后追加
+func (s *JournaledStore) Transaction(fn func(tx TodoStore) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	var deferred []*models.Event
+	err := s.inner.Transaction(func(tx TodoStore) error {
+		deferred = deferred[:0] // Redis 事务冲突重试时重新记录
+		return fn(&JournaledStore{inner: tx, log: s.log, meta: s.meta, inTx: true, deferred: &deferred})
+	})
+	if err != nil {
+		return err
+	}
+	for _, event := range deferred {
+		if err := s.log.AppendEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export 把全部数据以快照JSON写入w
+func (s *JournaledStore) Export(w io.Writer) error {
+	return s.inner.Export(w)
+}
+
+// Import 从r读取快照并替换全部数据，删除和写入的事项都记录事件
+func (s *JournaledStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// GetMeta 读取附属数据
+func (s *JournaledStore) GetMeta(namespace, key string) ([]byte, error) {
+	return s.inner.GetMeta(namespace, key)
+}
+
+// PutMeta 写入附属数据，附属数据的修改不记录事件
+func (s *JournaledStore) PutMeta(namespace, key string, value []byte) error {
+	return s.inner.PutMeta(namespace, key, value)
+}
+
+// DeleteMeta 删除附属数据
+func (s *JournaledStore) DeleteMeta(namespace, key string) error {
+	return s.inner.DeleteMeta(namespace, key)
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *JournaledStore) ListMeta(namespace string) (map[string][]byte, error) {
+	return s.inner.ListMeta(namespace)
+}
+
+// LoadTodos 按原样写入待办事项，每个事项记录一个 todo.created；被包装的存储不支持时返回 ErrLoadUnsupported
+func (s *JournaledStore) LoadTodos(todos []*models.Todo) error {
+	return s.write(func(j *JournaledStore) error {
+		loader, ok := j.inner.(Loader)
+		if !ok {
+			return ErrLoadUnsupported
+		}
+		if err := loader.LoadTodos(todos); err != nil {
+			return err
+		}
+		return j.recordSaved(todos, nil, true)
+	})
+}
+
+// Ping 检查被包装的存储是否可用
+func (s *JournaledStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Close 关闭被包装的存储
+func (s *JournaledStore) Close() error {
+	if closer, ok := s.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
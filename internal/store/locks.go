@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Locker 分布式锁接口
+// 多个服务实例共用同一个数据库时，用于保证定时任务等操作同一时间只在一个实例上执行。
+// 锁带有过期时间，持有者崩溃后锁会自动释放，不会永久阻塞其它实例
+type Locker interface {
+	// TryLock 尝试获取锁，不会阻塞
+	// 锁不存在、已过期或已被 owner 持有时获取成功（已持有时刷新过期时间），被其它实例持有时返回 false
+	TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Unlock 释放锁，锁不属于 owner 时不做任何操作
+	Unlock(ctx context.Context, name, owner string) error
+}
+
+// NewLocker 返回存储对应的分布式锁
+// SQL、Redis 等共享存储自身实现了 Locker；内存、文件等单实例存储使用进程内的锁。s 是装饰器时使用被包装的存储的锁
+func NewLocker(s TodoStore) Locker {
+	if locker, ok := Unwrap(s).(Locker); ok {
+		return locker
+	}
+	return NewLocalLocker()
+}
+
+// TryLock 尝试获取锁
+// 先更新已过期或自己持有的锁，没有更新到任何行时再尝试创建，两步都依赖数据库的原子性，不需要事务
+func (s *sqlStore) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	expiresAt := s.dialect.timeValue(now.Add(ttl))
+
+	result, err := s.stmts["lock_update"].ExecContext(ctx, owner, expiresAt, name, owner, s.dialect.timeValue(now))
+	if err != nil {
+		return false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if affected > 0 {
+		return true, nil
+	}
+
+	// 锁不存在时创建；并发创建时只有一个实例能插入成功
+	result, err = s.stmts["lock_insert"].ExecContext(ctx, name, owner, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Unlock 释放锁
+func (s *sqlStore) Unlock(ctx context.Context, name, owner string) error {
+	_, err := s.stmts["lock_delete"].ExecContext(ctx, name, owner)
+	return err
+}
+
+// redisTryLockScript 获取或刷新锁：不存在时创建，属于 owner 时刷新过期时间
+var redisTryLockScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current == false then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+if current == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// redisUnlockScript 只有锁属于 owner 时才删除
+var redisUnlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// TryLock 尝试获取锁，锁保存在 lock:{name} 键中，值为持有者，过期时间由Redis管理
+func (s *RedisStore) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := redisTryLockScript.Run(ctx, s.client, []string{s.key("lock", name)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+// Unlock 释放锁
+func (s *RedisStore) Unlock(ctx context.Context, name, owner string) error {
+	return redisUnlockScript.Run(ctx, s.client, []string{s.key("lock", name)}, owner).Err()
+}
+
+// LocalLocker 进程内的锁，用于不会被多个实例共享的存储
+type LocalLocker struct {
+	mu    sync.Mutex
+	locks map[string]localLock
+}
+
+// localLock 进程内锁的持有者和过期时间
+type localLock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// NewLocalLocker 创建进程内的锁
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{locks: make(map[string]localLock)}
+}
+
+// TryLock 尝试获取锁
+func (l *LocalLocker) TryLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if current, exists := l.locks[name]; exists && current.owner != owner && now.Before(current.expiresAt) {
+		return false, nil
+	}
+	l.locks[name] = localLock{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Unlock 释放锁
+func (l *LocalLocker) Unlock(ctx context.Context, name, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if current, exists := l.locks[name]; exists && current.owner == owner {
+		delete(l.locks, name)
+	}
+	return nil
+}
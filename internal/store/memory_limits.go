@@ -0,0 +1,251 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// ErrStoreFull 存储达到容量上限时创建待办事项返回的错误，API 对应 507 Insufficient Storage
+var ErrStoreFull = errors.New("存储空间已满")
+
+// errEvictionNeeded 只持有读锁时检查容量上限，按 evict_completed 策略需要淘汰事项时返回，调用方改为持有写锁后调用 reserve
+var errEvictionNeeded = errors.New("需要淘汰已完成的事项")
+
+// 达到容量上限后的处理策略
+const (
+	LimitPolicyReject         = "reject"          // 拒绝创建新的待办事项
+	LimitPolicyEvictCompleted = "evict_completed" // 按完成时间从早到晚淘汰已完成的事项，仍然不够时拒绝创建
+)
+
+// usageAlertPercent 用量达到上限的该百分比时记录告警日志
+const usageAlertPercent = 90
+
+// MemoryLimits 内存存储的容量上限
+// 占用空间按待办事项和附属数据JSON编码后的大小估算，与进程实际占用的内存成正比但不相等
+type MemoryLimits struct {
+	MaxItems int    // 最多保存的待办事项数，0表示不限制
+	MaxBytes int64  // 最大估算字节数，0表示不限制
+	Policy   string // 达到上限后的处理策略，为空时等同于 reject
+}
+
+// MemoryUsage 内存存储的用量统计，用于监控指标
+type MemoryUsage struct {
+	Items    int    `json:"items"`     // 当前待办事项数
+	Bytes    int64  `json:"bytes"`     // 当前估算字节数
+	MaxItems int    `json:"max_items"` // 待办事项数上限，0表示不限制
+	MaxBytes int64  `json:"max_bytes"` // 字节数上限，0表示不限制
+	Policy   string `json:"policy"`    // 达到上限后的处理策略
+	Rejected int64  `json:"rejected"`  // 因达到上限被拒绝的创建次数
+	Evicted  int64  `json:"evicted"`   // 因达到上限被淘汰的事项数
+}
+
+// UsageReporter 可选接口，由数据保存在进程内存中的存储实现，用于导出用量指标
+type UsageReporter interface {
+	Usage() MemoryUsage
+}
+
+// memoryLimits 从数据库配置中读取容量上限
+func memoryLimits(cfg config.DatabaseConfig) (MemoryLimits, error) {
+	limits := MemoryLimits{MaxItems: cfg.MaxItems, MaxBytes: cfg.MaxBytes, Policy: cfg.LimitPolicy}
+	switch limits.Policy {
+	case "", LimitPolicyReject, LimitPolicyEvictCompleted:
+	default:
+		return limits, fmt.Errorf("无效的容量上限策略: %q（可选 %s、%s）", limits.Policy, LimitPolicyReject, LimitPolicyEvictCompleted)
+	}
+	if limits.MaxItems < 0 || limits.MaxBytes < 0 {
+		return limits, errors.New("容量上限不能为负数")
+	}
+	return limits, nil
+}
+
+// SetLimits 设置容量上限，只影响之后创建的待办事项，已有数据即使超出上限也不会被删除
+func (s *MemoryStore) SetLimits(limits MemoryLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limits.Policy == "" {
+		limits.Policy = LimitPolicyReject
+	}
+	s.limits = limits
+	s.checkUsage()
+}
+
+// Usage 返回当前用量
+func (s *MemoryStore) Usage() MemoryUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	return MemoryUsage{
+		Items:    s.items,
+		Bytes:    s.bytes,
+		MaxItems: s.limits.MaxItems,
+		MaxBytes: s.limits.MaxBytes,
+		Policy:   s.limits.Policy,
+		Rejected: s.rejected,
+		Evicted:  s.evicted,
+	}
+}
+
+// todoSize 估算待办事项占用的字节数
+func todoSize(todo *models.Todo) int64 {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
+
+// metaSize 估算一条附属数据占用的字节数
+func metaSize(namespace, key string, value []byte) int64 {
+	return int64(len(namespace) + len(key) + len(value))
+}
+
+// recount 重新计算事项数和估算字节数，用于整体替换数据之后
+// 调用方需持有写锁
+func (s *MemoryStore) recount() {
+	s.items, s.bytes = 0, 0
+	s.each(func(todo *models.Todo) {
+		s.items++
+		s.bytes += todoSize(todo)
+	})
+	for namespace, items := range s.meta {
+		for key, value := range items {
+			s.bytes += metaSize(namespace, key, value)
+		}
+	}
+	s.checkUsage()
+}
+
+// adjustUsage 调整事项数和估算字节数
+// 调用方需持有读锁或写锁
+func (s *MemoryStore) adjustUsage(items int, bytes int64) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	s.items += items
+	s.bytes += bytes
+	s.checkUsage()
+}
+
+// tryReserve 只持有读锁时，在创建count个、总大小为size的待办事项前检查容量上限，容量足够时计入用量
+// 按 evict_completed 策略需要淘汰事项时返回 errEvictionNeeded，不计入用量
+// 调用方需持有读锁
+func (s *MemoryStore) tryReserve(count int, size int64) error {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	if s.fits(s.items, s.bytes, count, size) {
+		s.items += count
+		s.bytes += size
+		s.checkUsage()
+		return nil
+	}
+	if s.limits.Policy == LimitPolicyEvictCompleted {
+		return errEvictionNeeded
+	}
+	return s.reject(count)
+}
+
+// reserve 在创建count个、总大小为size的待办事项前检查容量上限，容量足够时计入用量
+// 按 evict_completed 策略时先淘汰已完成的事项腾出空间，返回淘汰产生的修改；淘汰所有已完成的事项仍然不够时不淘汰任何事项，直接拒绝。
+// 调用方需持有写锁
+func (s *MemoryStore) reserve(count int, size int64) ([]walRecord, error) {
+	items, bytes := s.items, s.bytes
+	if s.fits(items, bytes, count, size) {
+		s.items += count
+		s.bytes += size
+		s.checkUsage()
+		return nil, nil
+	}
+
+	if s.limits.Policy == LimitPolicyEvictCompleted {
+		var completed []*models.Todo
+		s.each(func(todo *models.Todo) {
+			if todo.Completed {
+				completed = append(completed, todo)
+			}
+		})
+		// 最早完成（更新时间最早）的先淘汰
+		sort.Slice(completed, func(i, j int) bool {
+			if !completed[i].UpdatedAt.Equal(completed[j].UpdatedAt) {
+				return completed[i].UpdatedAt.Before(completed[j].UpdatedAt)
+			}
+			return completed[i].ID < completed[j].ID
+		})
+
+		for n, todo := range completed {
+			items--
+			bytes -= todoSize(todo)
+			if !s.fits(items, bytes, count, size) {
+				continue
+			}
+			records := make([]walRecord, 0, n+1)
+			for _, evicted := range completed[:n+1] {
+				records = append(records, s.removeTodo(evicted.ID))
+			}
+			s.items, s.bytes = items+count, bytes+size
+			s.evicted += int64(n + 1)
+			s.checkUsage()
+			log.Printf("⚠️ 内存存储达到容量上限，淘汰了 %d 个已完成的待办事项", n+1)
+			return records, nil
+		}
+	}
+
+	return nil, s.reject(count)
+}
+
+// reject 记录一次因达到上限被拒绝的创建，返回对应的错误
+// 调用方需持有写锁，或持有读锁和 usageMu
+func (s *MemoryStore) reject(count int) error {
+	s.rejected++
+	log.Printf("⚠️ 内存存储达到容量上限，拒绝创建待办事项（%s）", s.usageText())
+	if s.limits.MaxItems > 0 && s.items+count > s.limits.MaxItems {
+		return fmt.Errorf("%w：待办事项数已达上限 %d", ErrStoreFull, s.limits.MaxItems)
+	}
+	return fmt.Errorf("%w：占用空间已达上限 %d 字节", ErrStoreFull, s.limits.MaxBytes)
+}
+
+// fits 判断在已有 items 个事项、bytes 字节时能否再保存count个、总大小为size的事项
+func (s *MemoryStore) fits(items int, bytes int64, count int, size int64) bool {
+	if s.limits.MaxItems > 0 && items+count > s.limits.MaxItems {
+		return false
+	}
+	if s.limits.MaxBytes > 0 && bytes+size > s.limits.MaxBytes {
+		return false
+	}
+	return true
+}
+
+// checkUsage 用量达到上限的 usageAlertPercent% 时记录一次告警日志，回落到该比例以下后重新计数
+// 调用方需持有写锁，或持有读锁和 usageMu
+func (s *MemoryStore) checkUsage() {
+	high := (s.limits.MaxItems > 0 && s.items*100 >= s.limits.MaxItems*usageAlertPercent) ||
+		(s.limits.MaxBytes > 0 && s.bytes*100 >= s.limits.MaxBytes*usageAlertPercent)
+
+	if high && !s.alerted {
+		log.Printf("⚠️ 内存存储用量已达上限的 %d%%（%s）", usageAlertPercent, s.usageText())
+	}
+	s.alerted = high
+}
+
+// usageText 用于日志的用量描述，只包含已配置的上限
+// 调用方需持有写锁，或持有读锁和 usageMu
+func (s *MemoryStore) usageText() string {
+	var parts []string
+	if s.limits.MaxItems > 0 {
+		parts = append(parts, fmt.Sprintf("事项 %d/%d", s.items, s.limits.MaxItems))
+	}
+	if s.limits.MaxBytes > 0 {
+		parts = append(parts, fmt.Sprintf("估算 %d/%d 字节", s.bytes, s.limits.MaxBytes))
+	}
+	return strings.Join(parts, "，")
+}
@@ -1,7 +1,11 @@
 package store
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"sync"
@@ -14,53 +18,223 @@ import (
 var (
 	ErrTodoNotFound = errors.New("待办事项不存在") // 当根据ID找不到待办事项时返回的错误
 	ErrInvalidID    = errors.New("无效的ID")   // 当ID格式无效时返回的错误
+	ErrMetaNotFound = errors.New("附属数据不存在") // 当根据命名空间和键找不到附属数据时返回的错误
+	ErrDuplicateID  = errors.New("重复的ID")   // 当恢复的数据或批量操作中存在重复ID时返回的错误
+
+	ErrInvalidSortField = errors.New("无效的排序字段") // 当列出待办事项的排序字段不受存储支持时返回的错误
+	ErrVersionConflict  = errors.New("版本冲突")    // 当更新请求中的版本号与待办事项的当前版本不一致时返回的错误
 )
 
+// MetaStore 附属数据存储接口
+// 用于保存与待办事项相关的附属数据（如分类默认设置），
+// 数据按命名空间和键组织，值为JSON编码后的字节，由调用方负责编解码
+type MetaStore interface {
+	GetMeta(namespace, key string) ([]byte, error)        // 读取附属数据
+	PutMeta(namespace, key string, value []byte) error    // 写入附属数据（已存在则覆盖）
+	DeleteMeta(namespace, key string) error               // 删除附属数据
+	ListMeta(namespace string) (map[string][]byte, error) // 列出命名空间下的所有附属数据
+}
+
 // TodoStore 待办事项存储接口
 // 定义了一组操作待办事项数据的接口方法
 // 通过接口可以实现不同的存储后端（如内存、数据库等）
 type TodoStore interface {
 	GetAllTodos() ([]*models.Todo, error)                                               // 获取所有待办事项
+	ListTodos(opts ListOptions) ([]*models.Todo, error)                                 // 按排序和过滤选项获取待办事项
 	GetTodoByID(id int) (*models.Todo, error)                                           // 根据ID获取单个待办事项
 	CreateTodo(req *models.TodoRequest) (*models.Todo, error)                           // 创建新的待办事项
-	UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error)                   // 更新待办事项
+	UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error)                   // 更新待办事项，请求中的版本号与当前版本不一致时返回 ErrVersionConflict
 	DeleteTodo(id int) error                                                            // 删除待办事项
+	SaveTodo(todo *models.Todo) (*models.Todo, error)                                   // 保存完整的待办事项（覆盖除ID和创建时间外的所有字段）
 	SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) // 搜索待办事项
+	BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error)                      // 批量创建待办事项，全部成功或全部失败
+	BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error)                            // 批量更新待办事项，任一事项不存在时全部不更新
+	BulkDelete(ids []int) error                                                         // 批量删除待办事项，任一事项不存在时全部不删除
 	GetStats() (map[string]interface{}, error)                                          // 获取待办事项统计信息
+	Transaction(fn func(tx TodoStore) error) error                                      // 在一个事务中执行fn，fn返回错误时撤销其中的所有修改
+	Export(w io.Writer) error                                                           // 把完整数据集（包括ID、时间戳和附属数据）以快照JSON写入w
+	Import(r io.Reader) error                                                           // 从r读取 Export 写出的快照，替换存储中的全部数据
+
+	MetaStore // 附属数据存储
+}
+
+// TodoUpdate 批量更新中的一项
+type TodoUpdate struct {
+	ID      int                 // 要更新的待办事项ID
+	Request *models.TodoRequest // 更新后的内容
+}
+
+// checkUniqueIDs 检查批量操作中的ID没有重复
+func checkUniqueIDs(ids []int) error {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("%w: %d", ErrDuplicateID, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// checkVersion 检查更新请求中的版本号与待办事项的当前版本是否一致，请求中的版本号为0时不检查
+func checkVersion(todo *models.Todo, req *models.TodoRequest) error {
+	if req.Version != 0 && req.Version != todo.Version {
+		return versionConflict(todo)
+	}
+	return nil
+}
+
+// versionConflict 返回包含待办事项当前版本的版本冲突错误
+func versionConflict(todo *models.Todo) error {
+	return fmt.Errorf("%w: 待办事项 %d 的当前版本为 %d", ErrVersionConflict, todo.ID, todo.Version)
+}
+
+// updateIDs 返回批量更新中的所有ID
+func updateIDs(updates []TodoUpdate) []int {
+	ids := make([]int, len(updates))
+	for i, update := range updates {
+		ids[i] = update.ID
+	}
+	return ids
+}
+
+// ListOptions 列出待办事项时的排序和过滤选项
+type ListOptions struct {
+	SortField string     // 排序字段：id、title、priority、due_date、created_at、updated_at、position，为空时按创建时间倒序
+	Desc      bool       // 是否降序
+	Filter    TodoFilter // 过滤条件，由存储完成过滤（SQL 存储转换为查询条件）
+
+	Sort []models.SortKey // 多字段排序，前一个字段相同时比较下一个字段；设置时忽略 SortField 和 Desc
+}
+
+// sortKeys 返回排序字段，没有指定排序时返回nil（按创建时间倒序）
+func (o ListOptions) sortKeys() []models.SortKey {
+	if len(o.Sort) > 0 {
+		return o.Sort
+	}
+	if o.SortField == "" {
+		return nil
+	}
+	return []models.SortKey{{Field: o.SortField, Desc: o.Desc}}
+}
+
+// validate 检查排序字段是否受存储支持
+// 按有效优先级排序依赖老化策略，只能在取出数据后排序，存储不支持
+func (o ListOptions) validate() error {
+	for _, key := range o.sortKeys() {
+		switch key.Field {
+		case models.SortByID, models.SortByTitle, models.SortByPriority,
+			models.SortByDueDate, models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByPosition:
+		default:
+			return ErrInvalidSortField
+		}
+	}
+	return nil
+}
+
+// Pinger 可选接口，由依赖外部服务或文件的存储实现，用于健康检查
+// 内存存储没有外部依赖，不实现此接口
+type Pinger interface {
+	Ping(ctx context.Context) error // 检查后端是否可用
+}
+
+// memoryShardCount 内存存储的分片数
+const memoryShardCount = 32
+
+// memoryShard 内存存储的一个分片，保存ID除以分片数的余数相同的待办事项
+type memoryShard struct {
+	mu    sync.RWMutex         // 分片锁，修改单个事项时与存储的读锁一起持有
+	todos map[int]*models.Todo // 分片中的待办事项，key为ID
+}
+
+// newMemoryShards 创建空的分片
+func newMemoryShards() [memoryShardCount]*memoryShard {
+	var shards [memoryShardCount]*memoryShard
+	for i := range shards {
+		shards[i] = &memoryShard{todos: make(map[int]*models.Todo)}
+	}
+	return shards
 }
 
 // MemoryStore 内存存储实现
-// 基于内存的待办事项存储实现，使用map存储数据
+// 基于内存的待办事项存储实现，待办事项按ID分片保存在多个map中
+// 所有方法返回的都是数据副本，调用方修改返回值不会影响存储中的数据
+//
+// 并发控制分两级：创建、修改、删除单个事项时持有 mu 的读锁和事项所在分片的写锁，不同分片上的修改可以并行；
+// 批量操作、事务、恢复快照等涉及多个事项的操作持有 mu 的写锁，此时其它操作都需要等待，不需要再获取分片锁
 type MemoryStore struct {
-	mu     sync.RWMutex         // 读写锁，用于保证并发安全
-	todos  map[int]*models.Todo // 存储待办事项的map，key为ID，value为待办事项对象
-	nextID int                  // 下一个可用的ID
+	mu     sync.RWMutex                   // 读写锁，用于保证并发安全
+	shards [memoryShardCount]*memoryShard // 按ID分片存储的待办事项
+
+	metaMu sync.RWMutex                 // 附属数据的锁，修改附属数据时与 mu 的读锁一起持有
+	meta   map[string]map[string][]byte // 附属数据，第一层key为命名空间，第二层key为数据键
+
+	usageMu  sync.Mutex   // 保护下一个可用ID和用量计数，只持有 mu 的读锁时需要获取
+	nextID   int          // 下一个可用的ID，只增不减，删除后ID也不会被重新分配
+	items    int          // 待办事项数
+	limits   MemoryLimits // 容量上限，默认不限制
+	bytes    int64        // 待办事项和附属数据的估算字节数
+	rejected int64        // 因达到上限被拒绝的创建次数
+	evicted  int64        // 因达到上限被淘汰的事项数
+	alerted  bool         // 是否已经记录过用量告警
+
+	wal       *writeAheadLog // 操作日志，未启用时为nil
+	recording bool           // 是否记录修改（启用了操作日志的存储及其事务副本）
+	pending   []walRecord    // 事务副本中的修改，事务提交时写入原存储的操作日志
+}
+
+// newMemoryStore 创建没有数据的内存存储
+func newMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		shards: newMemoryShards(),
+		nextID: 1, // 从ID 1开始
+		meta:   make(map[string]map[string][]byte),
+	}
 }
 
 // NewMemoryStore 创建新的内存存储
 func NewMemoryStore() *MemoryStore {
-	// 创建MemoryStore实例
-	store := &MemoryStore{
-		todos:  make(map[int]*models.Todo), // 初始化空的待办事项map
-		nextID: 1,                          // 从ID 1开始
-	}
+	store := newMemoryStore()
 
 	// 初始化示例数据
 	store.Seed()
 	return store
 }
 
+// shard 返回ID所在的分片
+func (s *MemoryStore) shard(id int) *memoryShard {
+	return s.shards[uint(id)%memoryShardCount]
+}
+
+// get 查找待办事项
+// 调用方需持有写锁，或持有读锁和事项所在分片的锁
+func (s *MemoryStore) get(id int) (*models.Todo, bool) {
+	todo, exists := s.shard(id).todos[id]
+	return todo, exists
+}
+
+// each 依次遍历所有待办事项，遍历每个分片时持有该分片的读锁
+// 调用方需持有读锁或写锁，fn 中不能修改存储
+func (s *MemoryStore) each(fn func(todo *models.Todo)) {
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+		for _, todo := range shard.todos {
+			fn(todo)
+		}
+		shard.mu.RUnlock()
+	}
+}
+
 // GetAllTodos 获取所有待办事项
 func (s *MemoryStore) GetAllTodos() ([]*models.Todo, error) {
 	s.mu.RLock()         // 获取读锁
 	defer s.mu.RUnlock() // 函数返回时释放读锁
 
-	// 将map中的所有待办事项转换为切片
-	// make： 创建一个切片，长度为当前待办事项数量
-	todos := make([]*models.Todo, 0, len(s.todos))
-	for _, todo := range s.todos {
-		todos = append(todos, todo)
-	}
+	// 将所有分片中的待办事项转换为切片
+	todos := make([]*models.Todo, 0)
+	s.each(func(todo *models.Todo) {
+		todos = append(todos, todo.Clone())
+	})
 
 	// 按创建时间倒序排序（最新的在前）
 	sort.Slice(todos, func(i, j int) bool {
@@ -70,77 +244,250 @@ func (s *MemoryStore) GetAllTodos() ([]*models.Todo, error) {
 	return todos, nil
 }
 
+// ListTodos 按排序和过滤选项获取待办事项
+// 排序规则与 models.SortTodos 一致：字段值相同时按ID升序，没有截止日期的事项排在最后
+func (s *MemoryStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	todos, err := s.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	todos = filterTodos(todos, opts.Filter)
+	if keys := opts.sortKeys(); len(keys) > 0 {
+		models.SortTodosBy(todos, keys, nil)
+	}
+	return todos, nil
+}
+
 // GetTodoByID 根据ID获取待办事项
 func (s *MemoryStore) GetTodoByID(id int) (*models.Todo, error) {
 	s.mu.RLock()         // 获取读锁
 	defer s.mu.RUnlock() // 函数返回时释放读锁
 
-	// 从map中查找指定ID的待办事项
-	todo, exists := s.todos[id]
+	shard := s.shard(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	// 从分片中查找指定ID的待办事项
+	todo, exists := shard.todos[id]
 	if !exists {
 		return nil, ErrTodoNotFound // 如果不存在，返回错误
 	}
 
-	return todo, nil
+	return todo.Clone(), nil
 }
 
 // CreateTodo 创建新的待办事项
+// 只持有读锁和新事项所在分片的写锁，并发的创建可以并行；
+// 需要按 evict_completed 策略淘汰已完成的事项时改为持有写锁。因达到上限被拒绝时，已分配的ID不再使用
 func (s *MemoryStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
-	s.mu.Lock()         // 获取写锁
-	defer s.mu.Unlock() // 函数返回时释放写锁
-
 	// 获取当前时间
 	now := time.Now()
 
-	// 创建新的待办事项对象
-	todo := &models.Todo{
-		ID:          s.nextID,        // 使用下一个可用的ID
-		Title:       req.Title,       // 标题
-		Description: req.Description, // 描述
-		Completed:   req.Completed,   // 完成状态
-		Priority:    req.Priority,    // 优先级
-		Category:    req.Category,    // 分类
-		DueDate:     req.DueDate,     // 截止日期
-		CreatedAt:   now,             // 创建时间
-		UpdatedAt:   now,             // 更新时间
-	}
-
-	// 将待办事项添加到map中
-	s.todos[todo.ID] = todo
-	s.nextID++ // ID自增，为下一个待办事项准备
+	// 创建新的待办事项对象，请求中已完成时记录完成时间，版本号从1开始
+	todo := &models.Todo{CreatedAt: now}
+	todo.FromRequest(req)
+	todo.UpdatedAt = now
+
+	s.mu.RLock() // 获取读锁
+
+	// 分配ID并检查容量上限，达到上限时按策略淘汰已完成的事项或拒绝创建
+	todo.ID = s.allocateID()
+	size := todoSize(todo)
+	var records []walRecord
+	err := s.tryReserve(1, size)
+	if errors.Is(err, errEvictionNeeded) {
+		// 淘汰需要修改多个分片，改为持有写锁后重新分配ID和检查
+		s.mu.RUnlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		todo.ID = s.nextID
+		s.nextID++
+		size = todoSize(todo)
+		records, err = s.reserve(1, size)
+	} else {
+		defer s.mu.RUnlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 将待办事项添加到所在分片
+	shard := s.shard(todo.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	records = append(records, s.putTodo(todo))
 
-	return todo, nil
+	return todo.Clone(), s.commitLog(records)
 }
 
 // UpdateTodo 更新待办事项
 func (s *MemoryStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
-	s.mu.Lock()         // 获取写锁
-	defer s.mu.Unlock() // 函数返回时释放写锁
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	// 查找要更新的待办事项
-	todo, exists := s.todos[id]
+	todo, exists := shard.todos[id]
 	if !exists {
 		return nil, ErrTodoNotFound // 如果不存在，返回错误
 	}
+	if err := checkVersion(todo, req); err != nil {
+		return nil, err
+	}
 
-	// 更新待办事项的字段
-	todo.FromRequest(req)
-	return todo, nil
+	// 更新副本后替换原对象（写时复制），事务中的数据副本不会影响原数据
+	updated := todo.Clone()
+	updated.FromRequest(req)
+	record := s.putTodo(updated)
+	s.adjustUsage(0, todoSize(updated)-todoSize(todo))
+	return updated.Clone(), s.commitLog([]walRecord{record})
+}
+
+// SaveTodo 保存完整的待办事项
+// 用于修改请求结构体之外的字段（如关联链接），ID和创建时间保持不变，更新时间设为当前时间
+func (s *MemoryStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+
+	shard := s.shard(todo.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	existing, exists := shard.todos[todo.ID]
+	if !exists {
+		return nil, ErrTodoNotFound // 如果不存在，返回错误
+	}
+
+	// 保存副本，避免调用方之后的修改影响存储中的数据
+	saved := todo.Clone()
+	saved.CreatedAt = existing.CreatedAt
+	saved.UpdatedAt = time.Now()
+	saved.Version = existing.Version + 1
+	record := s.putTodo(saved)
+	s.adjustUsage(0, todoSize(saved)-todoSize(existing))
+
+	return saved.Clone(), s.commitLog([]walRecord{record})
 }
 
 // DeleteTodo 删除待办事项
 func (s *MemoryStore) DeleteTodo(id int) error {
-	s.mu.Lock()         // 获取写锁
-	defer s.mu.Unlock() // 函数返回时释放写锁
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	// 检查待办事项是否存在
-	if _, exists := s.todos[id]; !exists {
+	todo, exists := shard.todos[id]
+	if !exists {
 		return ErrTodoNotFound // 如果不存在，返回错误
 	}
 
-	// 从map中删除待办事项
-	delete(s.todos, id)
-	return nil
+	// 从分片中删除待办事项
+	record := s.removeTodo(id)
+	s.adjustUsage(-1, -todoSize(todo))
+	return s.commitLog([]walRecord{record})
+}
+
+// BulkCreate 批量创建待办事项
+// 在同一个写锁内完成，容量不足时一个都不创建
+func (s *MemoryStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	todos := make([]*models.Todo, len(reqs))
+	var size int64
+	for i, req := range reqs {
+		todo := &models.Todo{ID: s.nextID + i, CreatedAt: now}
+		todo.FromRequest(req)
+		todo.UpdatedAt = now
+		todos[i] = todo
+		size += todoSize(todo)
+	}
+
+	records, err := s.reserve(len(todos), size)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.Todo, len(todos))
+	for i, todo := range todos {
+		records = append(records, s.putTodo(todo))
+		results[i] = todo.Clone()
+	}
+	s.nextID += len(todos)
+	return results, s.commitLog(records)
+}
+
+// BulkUpdate 批量更新待办事项
+// 先检查所有事项都存在且版本一致再修改，任一事项不存在时返回 ErrTodoNotFound、版本不一致时返回 ErrVersionConflict，不修改任何数据
+func (s *MemoryStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	if err := checkUniqueIDs(updateIDs(updates)); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, update := range updates {
+		todo, exists := s.get(update.ID)
+		if !exists {
+			return nil, fmt.Errorf("%w: %d", ErrTodoNotFound, update.ID)
+		}
+		if err := checkVersion(todo, update.Request); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*models.Todo, len(updates))
+	records := make([]walRecord, 0, len(updates))
+	for i, update := range updates {
+		todo, _ := s.get(update.ID)
+		updated := todo.Clone()
+		updated.FromRequest(update.Request)
+		records = append(records, s.putTodo(updated))
+		s.bytes += todoSize(updated) - todoSize(todo)
+		results[i] = updated.Clone()
+	}
+	s.checkUsage()
+	return results, s.commitLog(records)
+}
+
+// BulkDelete 批量删除待办事项
+// 先检查所有事项都存在再删除，任一事项不存在时返回 ErrTodoNotFound，不删除任何数据
+func (s *MemoryStore) BulkDelete(ids []int) error {
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if _, exists := s.get(id); !exists {
+			return fmt.Errorf("%w: %d", ErrTodoNotFound, id)
+		}
+	}
+
+	records := make([]walRecord, 0, len(ids))
+	for _, id := range ids {
+		todo, _ := s.get(id)
+		s.bytes -= todoSize(todo)
+		records = append(records, s.removeTodo(id))
+	}
+	s.items -= len(ids)
+	s.checkUsage()
+	return s.commitLog(records)
 }
 
 // SearchTodos 搜索待办事项
@@ -152,7 +499,7 @@ func (s *MemoryStore) SearchTodos(query string, category string, completed *bool
 	results := make([]*models.Todo, 0)
 
 	// 遍历所有待办事项，筛选符合条件的
-	for _, todo := range s.todos {
+	s.each(func(todo *models.Todo) {
 		// 匹配查询条件
 		matches := true
 
@@ -173,9 +520,9 @@ func (s *MemoryStore) SearchTodos(query string, category string, completed *bool
 
 		// 如果所有条件都匹配，添加到结果中
 		if matches {
-			results = append(results, todo)
+			results = append(results, todo.Clone())
 		}
-	}
+	})
 
 	// 按优先级（降序）和创建时间（倒序）排序
 	sort.Slice(results, func(i, j int) bool {
@@ -193,9 +540,19 @@ func (s *MemoryStore) GetStats() (map[string]interface{}, error) {
 	s.mu.RLock()         // 获取读锁
 	defer s.mu.RUnlock() // 函数返回时释放读锁
 
+	todos := make([]*models.Todo, 0)
+	s.each(func(todo *models.Todo) {
+		todos = append(todos, todo)
+	})
+	return computeStats(todos, time.Now()), nil
+}
+
+// computeStats 统计待办事项，返回各存储实现统一的统计结构
+// 在内存中完成统计的存储（内存、Redis等）共用此函数
+func computeStats(todos []*models.Todo, now time.Time) map[string]interface{} {
 	// 初始化统计信息map
 	stats := map[string]interface{}{
-		"total":       len(s.todos),         // 总数量
+		"total":       len(todos),           // 总数量
 		"completed":   0,                    // 已完成数量
 		"pending":     0,                    // 待完成数量
 		"overdue":     0,                    // 已过期数量
@@ -203,11 +560,8 @@ func (s *MemoryStore) GetStats() (map[string]interface{}, error) {
 		"by_category": make(map[string]int), // 按分类统计
 	}
 
-	// 获取当前时间
-	now := time.Now()
-
 	// 遍历所有待办事项，进行统计
-	for _, todo := range s.todos {
+	for _, todo := range todos {
 		if todo.Completed {
 			// 已完成的任务
 			stats["completed"] = stats["completed"].(int) + 1
@@ -216,13 +570,13 @@ func (s *MemoryStore) GetStats() (map[string]interface{}, error) {
 			stats["pending"] = stats["pending"].(int) + 1
 
 			// 检查是否过期
-			if !todo.DueDate.IsZero() && todo.DueDate.Before(now) {
+			if todo.HasDueDate() && todo.DueDate.Before(now) {
 				stats["overdue"] = stats["overdue"].(int) + 1
 			}
 		}
 
 		// 按优先级统计
-		stats["by_priority"].(map[int]int)[todo.Priority]++
+		stats["by_priority"].(map[int]int)[int(todo.Priority)]++
 
 		// 按分类统计
 		if todo.Category != "" {
@@ -230,7 +584,138 @@ func (s *MemoryStore) GetStats() (map[string]interface{}, error) {
 		}
 	}
 
-	return stats, nil
+	return stats
+}
+
+// Transaction 在一个事务中执行fn
+// 事务期间持有写锁，其它读写操作需要等待；fn 在数据的副本上执行，返回nil时副本替换当前数据，返回错误时丢弃副本。
+// 待办事项修改时总是替换为新对象（写时复制），副本只需复制各分片的map，不需要复制每个事项
+func (s *MemoryStore) Transaction(fn func(tx TodoStore) error) error {
+	s.mu.Lock()         // 获取写锁
+	defer s.mu.Unlock() // 函数返回时释放写锁
+
+	tx := s.fork()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.shards, s.meta, s.nextID, s.items = tx.shards, tx.meta, tx.nextID, tx.items
+	s.bytes, s.rejected, s.evicted, s.alerted = tx.bytes, tx.rejected, tx.evicted, tx.alerted
+	return s.commitLog(tx.pending)
+}
+
+// Export 把全部数据以快照JSON写入w
+func (s *MemoryStore) Export(w io.Writer) error {
+	return ExportSnapshot(s, w)
+}
+
+// Import 从r读取快照，在一个事务中替换全部数据
+func (s *MemoryStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// fork 复制当前数据，用于事务
+// 调用方需持有写锁
+func (s *MemoryStore) fork() *MemoryStore {
+	tx := &MemoryStore{
+		shards:   newMemoryShards(),
+		nextID:   s.nextID,
+		meta:     make(map[string]map[string][]byte, len(s.meta)),
+		items:    s.items,
+		limits:   s.limits,
+		bytes:    s.bytes,
+		rejected: s.rejected,
+		evicted:  s.evicted,
+		alerted:  s.alerted,
+
+		recording: s.recording,
+	}
+	for i, shard := range s.shards {
+		for id, todo := range shard.todos {
+			tx.shards[i].todos[id] = todo
+		}
+	}
+	// 附属数据的值在写入时总是复制，共享同一个字节切片是安全的
+	for namespace, items := range s.meta {
+		tx.meta[namespace] = make(map[string][]byte, len(items))
+		for key, value := range items {
+			tx.meta[namespace][key] = value
+		}
+	}
+	return tx
+}
+
+// GetMeta 读取附属数据
+func (s *MemoryStore) GetMeta(namespace, key string) ([]byte, error) {
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+	s.metaMu.RLock()
+	defer s.metaMu.RUnlock()
+
+	value, exists := s.meta[namespace][key]
+	if !exists {
+		return nil, ErrMetaNotFound
+	}
+
+	// 返回副本，避免调用方修改内部数据
+	return append([]byte(nil), value...), nil
+}
+
+// PutMeta 写入附属数据
+func (s *MemoryStore) PutMeta(namespace, key string, value []byte) error {
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+	s.metaMu.Lock()
+	defer s.metaMu.Unlock()
+
+	// 命名空间不存在时先创建
+	if s.meta[namespace] == nil {
+		s.meta[namespace] = make(map[string][]byte)
+	}
+	delta := metaSize(namespace, key, value)
+	if old, exists := s.meta[namespace][key]; exists {
+		delta -= metaSize(namespace, key, old)
+	}
+	s.meta[namespace][key] = append([]byte(nil), value...)
+	s.adjustUsage(0, delta)
+	return s.commitLog([]walRecord{{Op: walMetaPut, Namespace: namespace, Key: key, Value: s.meta[namespace][key]}})
+}
+
+// DeleteMeta 删除附属数据
+func (s *MemoryStore) DeleteMeta(namespace, key string) error {
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+	s.metaMu.Lock()
+	defer s.metaMu.Unlock()
+
+	value, exists := s.meta[namespace][key]
+	if !exists {
+		return ErrMetaNotFound
+	}
+
+	delete(s.meta[namespace], key)
+	s.adjustUsage(0, -metaSize(namespace, key, value))
+	return s.commitLog([]walRecord{{Op: walMetaDelete, Namespace: namespace, Key: key}})
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *MemoryStore) ListMeta(namespace string) (map[string][]byte, error) {
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+	s.metaMu.RLock()
+	defer s.metaMu.RUnlock()
+
+	results := make(map[string][]byte, len(s.meta[namespace]))
+	for key, value := range s.meta[namespace] {
+		results[key] = append([]byte(nil), value...)
+	}
+
+	return results, nil
+}
+
+// timePtr 返回指向时间的指针
+func timePtr(t time.Time) *time.Time {
+	return &t
 }
 
 // Seed 初始化示例数据
@@ -238,44 +723,214 @@ func (s *MemoryStore) Seed() {
 	now := time.Now()
 
 	// 创建第一个示例待办事项
-	s.todos[1] = &models.Todo{
+	s.putTodo(&models.Todo{
 		ID:          1,
 		Title:       "学习 Go 语言",
 		Description: "掌握 Go 语言的基础语法和并发编程",
 		Completed:   false,
 		Priority:    3,
 		Category:    "学习",
-		DueDate:     now.Add(7 * 24 * time.Hour),
+		DueDate:     timePtr(now.Add(7 * 24 * time.Hour)),
 		CreatedAt:   now.Add(-2 * 24 * time.Hour),
 		UpdatedAt:   now.Add(-2 * 24 * time.Hour),
-	}
+		Version:     1,
+	})
 
 	// 创建第二个示例待办事项
-	s.todos[2] = &models.Todo{
+	s.putTodo(&models.Todo{
 		ID:          2,
 		Title:       "编写 HTTP 服务器",
 		Description: "使用 Go 实现一个完整的 HTTP 服务器",
 		Completed:   true,
 		Priority:    4,
 		Category:    "项目",
-		DueDate:     now.Add(-1 * 24 * time.Hour),
+		DueDate:     timePtr(now.Add(-1 * 24 * time.Hour)),
 		CreatedAt:   now.Add(-3 * 24 * time.Hour),
 		UpdatedAt:   now.Add(-1 * 24 * time.Hour),
-	}
+		CompletedAt: timePtr(now.Add(-1 * 24 * time.Hour)),
+		Version:     1,
+	})
 
 	// 创建第三个示例待办事项
-	s.todos[3] = &models.Todo{
+	s.putTodo(&models.Todo{
 		ID:          3,
 		Title:       "部署到服务器",
 		Description: "将应用部署到生产环境",
 		Completed:   false,
 		Priority:    2,
 		Category:    "运维",
-		DueDate:     now.Add(3 * 24 * time.Hour),
+		DueDate:     timePtr(now.Add(3 * 24 * time.Hour)),
 		CreatedAt:   now.Add(-1 * 24 * time.Hour),
 		UpdatedAt:   now.Add(-1 * 24 * time.Hour),
+		Version:     1,
+	})
+
+	// 根据已有数据推导下一个可用的ID
+	s.advanceNextID()
+	s.recount()
+}
+
+// Snapshot 导出当前数据的完整快照
+// 快照中包含下一个可用ID，恢复时据此保证ID不会被重复分配
+func (s *MemoryStore) Snapshot() *models.Snapshot {
+	s.mu.RLock()         // 获取读锁
+	defer s.mu.RUnlock() // 函数返回时释放读锁
+
+	return s.snapshot()
+}
+
+// snapshot 导出当前数据的完整快照
+// 调用方需持有锁；只持有读锁时，快照中可能包含也可能不包含正在进行的单个事项的修改
+func (s *MemoryStore) snapshot() *models.Snapshot {
+	s.usageMu.Lock()
+	nextID := s.nextID
+	s.usageMu.Unlock()
+
+	snapshot := &models.Snapshot{
+		Version:    models.SnapshotVersion,
+		ExportedAt: time.Now(),
+		NextID:     nextID,
+		Todos:      make([]*models.Todo, 0),
+		Meta:       make(map[string]map[string]json.RawMessage),
 	}
 
-	// 设置下一个可用的ID为4
-	s.nextID = 4
+	// 复制待办事项，按ID升序排列，便于比对
+	s.each(func(todo *models.Todo) {
+		snapshot.Todos = append(snapshot.Todos, todo.Clone())
+	})
+	sort.Slice(snapshot.Todos, func(i, j int) bool {
+		return snapshot.Todos[i].ID < snapshot.Todos[j].ID
+	})
+
+	// 复制附属数据
+	s.metaMu.RLock()
+	defer s.metaMu.RUnlock()
+	for namespace, items := range s.meta {
+		snapshot.Meta[namespace] = make(map[string]json.RawMessage, len(items))
+		for key, value := range items {
+			snapshot.Meta[namespace][key] = append(json.RawMessage(nil), value...)
+		}
+	}
+
+	return snapshot
+}
+
+// Restore 用快照替换当前的全部数据
+// 恢复后的下一个可用ID取以下三者的最大值，保证已经分配过的ID永远不会被重新使用：
+// 1. 当前存储的下一个可用ID（恢复较旧的备份时不会回退计数器）
+// 2. 快照中记录的下一个可用ID（快照导出前删除的ID也不会被重新分配）
+// 3. 快照中最大ID+1（兼容没有记录next_id的手工数据）
+func (s *MemoryStore) Restore(snapshot *models.Snapshot) error {
+	// 先校验数据，校验失败时不修改当前数据
+	todos := make(map[int]*models.Todo, len(snapshot.Todos))
+	for _, todo := range snapshot.Todos {
+		if todo.ID <= 0 {
+			return ErrInvalidID
+		}
+		if _, exists := todos[todo.ID]; exists {
+			return ErrDuplicateID
+		}
+		todos[todo.ID] = todo.Clone()
+		// 没有版本号的旧数据从版本1开始
+		if todos[todo.ID].Version == 0 {
+			todos[todo.ID].Version = 1
+		}
+	}
+
+	meta := make(map[string]map[string][]byte, len(snapshot.Meta))
+	for namespace, items := range snapshot.Meta {
+		meta[namespace] = make(map[string][]byte, len(items))
+		for key, value := range items {
+			meta[namespace][key] = append([]byte(nil), value...)
+		}
+	}
+
+	s.mu.Lock()         // 获取写锁
+	defer s.mu.Unlock() // 函数返回时释放写锁
+
+	s.shards = newMemoryShards()
+	for _, todo := range todos {
+		s.putTodo(todo)
+	}
+	s.meta = meta
+	if snapshot.NextID > s.nextID {
+		s.nextID = snapshot.NextID
+	}
+	s.advanceNextID()
+	s.recount()
+
+	// 整体替换数据后写入检查点，之前的操作日志不再需要
+	if s.wal != nil {
+		s.wal.mu.Lock()
+		defer s.wal.mu.Unlock()
+		s.wal.pending = nil
+		return s.wal.checkpoint(s.snapshot())
+	}
+	return nil
+}
+
+// LoadTodos 按原样写入待办事项，保留ID、时间戳和版本号，用于从其它存储迁移数据
+// 任一ID已存在时不写入任何数据；写入的数据不受容量上限限制
+func (s *MemoryStore) LoadTodos(todos []*models.Todo) error {
+	s.mu.Lock()         // 获取写锁
+	defer s.mu.Unlock() // 函数返回时释放写锁
+
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		if todo.ID <= 0 {
+			return ErrInvalidID
+		}
+		if _, exists := s.get(todo.ID); exists {
+			return fmt.Errorf("%w: %d", ErrDuplicateID, todo.ID)
+		}
+		ids[i] = todo.ID
+	}
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+
+	records := make([]walRecord, 0, len(todos))
+	for _, todo := range todos {
+		loaded := todo.Clone()
+		records = append(records, s.putTodo(loaded))
+		s.bytes += todoSize(loaded)
+	}
+	s.items += len(todos)
+	s.advanceNextID()
+	s.checkUsage()
+	return s.commitLog(records)
+}
+
+// allocateID 分配一个新的ID
+// 调用方需持有读锁
+func (s *MemoryStore) allocateID() int {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	id := s.nextID
+	s.nextID++ // ID自增，为下一个待办事项准备
+	return id
+}
+
+// ReserveIDs 把下一个可用ID推进到不小于next，已经更大时保持不变
+func (s *MemoryStore) ReserveIDs(next int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	if next > s.nextID {
+		s.nextID = next
+	}
+	return nil
+}
+
+// advanceNextID 确保下一个可用ID大于当前所有数据的ID
+// 调用方需持有写锁
+func (s *MemoryStore) advanceNextID() {
+	s.each(func(todo *models.Todo) {
+		if todo.ID >= s.nextID {
+			s.nextID = todo.ID + 1
+		}
+	})
 }
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// restoreFuncs 两种恢复快照的方式：直接替换内存数据，以及通用的事务恢复
+var restoreFuncs = map[string]func(s *MemoryStore, snapshot *models.Snapshot) error{
+	"Restore": func(s *MemoryStore, snapshot *models.Snapshot) error {
+		return s.Restore(snapshot)
+	},
+	"RestoreSnapshot": func(s *MemoryStore, snapshot *models.Snapshot) error {
+		return RestoreSnapshot(s, snapshot)
+	},
+}
+
+// createTodos 新建n个待办事项，返回它们的ID
+func createTodos(t *testing.T, s *MemoryStore, n int) []int {
+	t.Helper()
+	ids := make([]int, n)
+	for i := range ids {
+		todo, err := s.CreateTodo(&models.TodoRequest{Title: "事项"})
+		if err != nil {
+			t.Fatalf("新建待办事项失败: %v", err)
+		}
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+// exportSnapshot 按备份文件的格式导出快照并重新解析，与从备份恢复时读取到的数据一致
+func exportSnapshot(t *testing.T, s *MemoryStore) *models.Snapshot {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.Export(&buf); err != nil {
+		t.Fatalf("导出快照失败: %v", err)
+	}
+	snapshot := &models.Snapshot{}
+	if err := json.Unmarshal(buf.Bytes(), snapshot); err != nil {
+		t.Fatalf("解析快照失败: %v", err)
+	}
+	return snapshot
+}
+
+func TestRestoreOlderSnapshotKeepsNextID(t *testing.T) {
+	for name, restore := range restoreFuncs {
+		t.Run(name, func(t *testing.T) {
+			s := newMemoryStore()
+			createTodos(t, s, 3)
+			old := exportSnapshot(t, s)
+			created := createTodos(t, s, 2)
+
+			if err := restore(s, old); err != nil {
+				t.Fatalf("恢复快照失败: %v", err)
+			}
+			next := createTodos(t, s, 1)[0]
+			if last := created[len(created)-1]; next <= last {
+				t.Fatalf("恢复旧快照后分配了ID %d，不应小于等于恢复前已分配的ID %d", next, last)
+			}
+		})
+	}
+}
+
+func TestRestoreAfterDeletingHighestIDDoesNotReuseIt(t *testing.T) {
+	for name, restore := range restoreFuncs {
+		t.Run(name, func(t *testing.T) {
+			s := newMemoryStore()
+			ids := createTodos(t, s, 3)
+			highest := ids[len(ids)-1]
+			if err := s.DeleteTodo(highest); err != nil {
+				t.Fatalf("删除待办事项失败: %v", err)
+			}
+			snapshot := exportSnapshot(t, s)
+			if snapshot.NextID != highest+1 {
+				t.Fatalf("快照中的 next_id 为 %d，期望 %d", snapshot.NextID, highest+1)
+			}
+
+			fresh := newMemoryStore()
+			if err := restore(fresh, snapshot); err != nil {
+				t.Fatalf("恢复快照失败: %v", err)
+			}
+			if next := createTodos(t, fresh, 1)[0]; next <= highest {
+				t.Fatalf("恢复后分配了ID %d，重新使用了已删除的ID %d", next, highest)
+			}
+		})
+	}
+}
+
+func TestRestoreWithoutNextIDUsesMaxPlusOne(t *testing.T) {
+	data := []byte(`{"version":1,"todos":[{"id":2,"title":"a"},{"id":7,"title":"b"}]}`)
+	for name, restore := range restoreFuncs {
+		t.Run(name, func(t *testing.T) {
+			snapshot := &models.Snapshot{}
+			if err := json.Unmarshal(data, snapshot); err != nil {
+				t.Fatalf("解析快照失败: %v", err)
+			}
+
+			s := newMemoryStore()
+			if err := restore(s, snapshot); err != nil {
+				t.Fatalf("恢复快照失败: %v", err)
+			}
+			if next := createTodos(t, s, 1)[0]; next != 8 {
+				t.Fatalf("没有 next_id 的快照恢复后分配了ID %d，期望最大ID+1即 8", next)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// 操作日志目录中的文件
+const (
+	walSnapshotFile = "snapshot.json" // 最近一次检查点的完整快照
+	walLogFile      = "wal.log"       // 检查点之后的操作日志，每行一条JSON记录
+)
+
+// DefaultSnapshotInterval 未配置时内存存储写入检查点的间隔
+const DefaultSnapshotInterval = 5 * time.Minute
+
+// 操作日志记录类型
+const (
+	walPut        = "put"         // 写入完整的待办事项
+	walDelete     = "delete"      // 删除待办事项
+	walMetaPut    = "meta_put"    // 写入附属数据
+	walMetaDelete = "meta_delete" // 删除附属数据
+)
+
+// walRecord 一次数据修改，记录修改后的结果而不是请求，重放时不依赖当前时间和容量上限
+type walRecord struct {
+	Op        string          `json:"op"`
+	Todo      *models.Todo    `json:"todo,omitempty"`      // put
+	ID        int             `json:"id,omitempty"`        // delete
+	Namespace string          `json:"namespace,omitempty"` // meta_put、meta_delete
+	Key       string          `json:"key,omitempty"`       // meta_put、meta_delete
+	Value     json.RawMessage `json:"value,omitempty"`     // meta_put
+}
+
+// walEntry 操作日志中的一行，对应一次存储操作（批量操作和事务也只有一行），重放时整行生效或整行丢弃
+type walEntry struct {
+	Seq     int64       `json:"seq"`     // 序号，单调递增
+	NextID  int         `json:"next_id"` // 操作之后的下一个可用ID，删除最大ID的事项后重启也不会重新分配
+	Records []walRecord `json:"records"` // 操作中的全部修改
+}
+
+// writeAheadLog 内存存储的操作日志
+// 每次修改在返回前追加到日志并同步到磁盘；定期写入检查点（完整快照）后清空日志，避免日志无限增长和重放过慢
+type writeAheadLog struct {
+	dir string
+
+	mu   sync.Mutex // 保证同一时间只有一个写日志或写检查点操作
+	file *os.File   // 以追加方式打开的日志文件
+	size int64      // 日志文件中完整记录的字节数
+	seq  int64      // 最后写入的序号
+
+	pending []walRecord // 写入失败的修改，与下一次操作的修改一起写入
+
+	stop chan struct{} // 关闭后停止定期写入检查点
+	done chan struct{} // 定期写入检查点的 goroutine 退出后关闭
+}
+
+// OpenMemoryStore 创建带操作日志的内存存储，进程崩溃或重启后可以从 dir 中恢复数据
+// 启动时先加载检查点快照，再按顺序重放之后的操作日志；目录中没有数据时写入初始数据 seed（为nil时写入示例数据）。
+// interval 为写入检查点的间隔，不大于0时使用 DefaultSnapshotInterval。使用完毕后应调用 Close
+func OpenMemoryStore(dir string, interval time.Duration, seed *models.Snapshot) (*MemoryStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建操作日志目录失败: %w", err)
+	}
+	if interval <= 0 {
+		interval = DefaultSnapshotInterval
+	}
+
+	s := newMemoryStore()
+	s.recording = true
+	wal := &writeAheadLog{dir: dir, stop: make(chan struct{}), done: make(chan struct{})}
+
+	data, err := os.ReadFile(filepath.Join(dir, walSnapshotFile))
+	hasSnapshot := err == nil
+	switch {
+	case err == nil:
+		var snapshot models.Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("解析检查点快照失败: %w", err)
+		}
+		if err := s.Restore(&snapshot); err != nil {
+			return nil, fmt.Errorf("加载检查点快照失败: %w", err)
+		}
+		wal.seq = snapshot.LogSeq
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("读取检查点快照失败: %w", err)
+	}
+
+	replayed, err := wal.replay(s)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSnapshot && replayed == 0 {
+		// 首次运行：与内存存储一样写入初始数据
+		if err := s.SeedWith(seed); err != nil {
+			return nil, fmt.Errorf("写入初始数据失败: %w", err)
+		}
+	}
+	s.advanceNextID()
+	s.recount()
+
+	wal.file, err = os.OpenFile(filepath.Join(dir, walLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开操作日志失败: %w", err)
+	}
+	s.wal = wal
+
+	// 启动时写入一次检查点，重放过的日志不需要在下次启动时再重放
+	if err := s.Checkpoint(); err != nil {
+		wal.file.Close()
+		return nil, err
+	}
+	if replayed > 0 {
+		log.Printf("💾 内存存储从操作日志恢复了 %d 次修改", replayed)
+	}
+
+	go wal.loop(s, interval)
+	return s, nil
+}
+
+// replay 按顺序重放序号大于检查点的日志，返回重放的条数
+// 最后一行不完整（写入过程中进程崩溃）时截断该行；其它行损坏时返回错误，避免在数据不完整的情况下启动
+func (w *writeAheadLog) replay(s *MemoryStore) (int, error) {
+	path := filepath.Join(w.dir, walLogFile)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("读取操作日志失败: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64 // 最后一条完整记录之后的位置
+	replayed := 0
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, fmt.Errorf("读取操作日志失败: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 && err == io.EOF {
+			return replayed, nil
+		}
+
+		var entry walEntry
+		if jsonErr := json.Unmarshal(data, &entry); jsonErr != nil || data[len(data)-1] != '\n' {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				log.Printf("⚠️ 操作日志最后一行不完整，已丢弃（%d 字节）", len(data))
+				return replayed, os.Truncate(path, offset)
+			}
+			return 0, fmt.Errorf("操作日志第 %d 行损坏: %v", line, jsonErr)
+		}
+		offset += int64(len(data))
+
+		if entry.Seq <= w.seq {
+			continue
+		}
+		s.apply(&entry)
+		w.seq = entry.Seq
+		replayed++
+	}
+}
+
+// append 追加一条日志并同步到磁盘
+// 写入失败时截断写入了一部分的记录，避免之后追加的记录跟在一条不完整的记录后面
+func (w *writeAheadLog) append(entry *walEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("编码操作日志失败: %w", err)
+	}
+	data = append(data, '\n')
+
+	_, err = w.file.Write(data)
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		w.file.Truncate(w.size)
+		return fmt.Errorf("写入操作日志失败: %w", err)
+	}
+	w.size += int64(len(data))
+	return nil
+}
+
+// checkpoint 写入完整快照后清空日志
+// 快照中记录已包含的最后序号，即使清空日志之前进程崩溃，重启时也会跳过这些日志
+func (w *writeAheadLog) checkpoint(snapshot *models.Snapshot) error {
+	snapshot.LogSeq = w.seq
+	data, err := json.Marshal(snapshot) // 不缩进，附属数据的值按原样保存
+	if err != nil {
+		return fmt.Errorf("编码检查点快照失败: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(w.dir, walSnapshotFile), data); err != nil {
+		return fmt.Errorf("写入检查点快照失败: %w", err)
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("清空操作日志失败: %w", err)
+	}
+	w.size = 0
+	return nil
+}
+
+// loop 定期写入检查点，直到 Close
+func (w *writeAheadLog) loop(s *MemoryStore, interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Checkpoint(); err != nil {
+				log.Printf("⚠️ 内存存储写入检查点失败: %v", err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Checkpoint 把当前数据写入检查点快照并清空操作日志，未启用操作日志时不做任何事
+// 写入期间持有写锁，等待进行中的修改写入日志后再生成快照，修改操作需要等待
+func (s *MemoryStore) Checkpoint() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+	s.wal.mu.Lock()
+	defer s.wal.mu.Unlock()
+	return s.wal.checkpoint(s.snapshot())
+}
+
+// Close 写入最后一次检查点并关闭操作日志，未启用操作日志时不做任何事
+func (s *MemoryStore) Close() error {
+	if s.wal == nil {
+		return nil
+	}
+	close(s.wal.stop)
+	<-s.wal.done
+
+	err := s.Checkpoint()
+	if closeErr := s.wal.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// commitLog 把一次操作中的修改作为一条日志写入
+// 写入失败时保留这些修改，与下一次操作的修改一起写入。事务副本没有日志，修改暂存在副本中，由事务提交时写入原存储的日志。
+// 调用方需持有写锁，或持有读锁和修改所在分片（附属数据）的锁，保证同一事项的日志按修改的顺序写入
+func (s *MemoryStore) commitLog(records []walRecord) error {
+	if !s.recording || len(records) == 0 {
+		return nil
+	}
+	if s.wal == nil {
+		s.pending = append(s.pending, records...)
+		return nil
+	}
+
+	s.wal.mu.Lock()
+	defer s.wal.mu.Unlock()
+
+	records = append(s.wal.pending, records...)
+	s.usageMu.Lock()
+	entry := &walEntry{Seq: s.wal.seq + 1, NextID: s.nextID, Records: records}
+	s.usageMu.Unlock()
+	if err := s.wal.append(entry); err != nil {
+		s.wal.pending = records
+		return err
+	}
+	s.wal.seq = entry.Seq
+	s.wal.pending = nil
+	return nil
+}
+
+// putTodo 保存待办事项，返回需要写入日志的修改
+// 调用方需持有写锁，或持有读锁和事项所在分片的写锁
+func (s *MemoryStore) putTodo(todo *models.Todo) walRecord {
+	s.shard(todo.ID).todos[todo.ID] = todo
+	return walRecord{Op: walPut, Todo: todo}
+}
+
+// removeTodo 删除待办事项，返回需要写入日志的修改
+// 调用方需持有写锁，或持有读锁和事项所在分片的写锁
+func (s *MemoryStore) removeTodo(id int) walRecord {
+	delete(s.shard(id).todos, id)
+	return walRecord{Op: walDelete, ID: id}
+}
+
+// apply 重放一条日志
+// 调用方需持有写锁（或在存储可被访问之前调用）
+func (s *MemoryStore) apply(entry *walEntry) {
+	for _, rec := range entry.Records {
+		switch rec.Op {
+		case walPut:
+			s.putTodo(rec.Todo)
+		case walDelete:
+			s.removeTodo(rec.ID)
+		case walMetaPut:
+			if s.meta[rec.Namespace] == nil {
+				s.meta[rec.Namespace] = make(map[string][]byte)
+			}
+			s.meta[rec.Namespace][rec.Key] = []byte(rec.Value)
+		case walMetaDelete:
+			delete(s.meta[rec.Namespace], rec.Key)
+		}
+	}
+	if entry.NextID > s.nextID {
+		s.nextID = entry.NextID
+	}
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// 迁移相关的错误
+var (
+	ErrLoadUnsupported = errors.New("目标存储不支持按原样写入待办事项") // 目标存储没有实现 Loader 时返回的错误
+	ErrTargetNotEmpty  = errors.New("目标存储中已有待办事项")      // 目标存储不为空时返回的错误，避免ID冲突和数据混合
+	ErrVerifyFailed    = errors.New("迁移校验失败")           // 迁移后源存储和目标存储的数量或校验和不一致时返回的错误
+)
+
+// defaultMigrateBatch 每批写入目标存储的待办事项数
+const defaultMigrateBatch = 500
+
+// MigrateNamespaces 迁移时复制的附属数据命名空间
+// 事件发件箱和集成方确认位置不迁移：目标存储中的事件序号从头开始分配，旧的确认位置没有意义，集成方需要重新同步；
+// webhook 投递记录与事件序号对应，同样不迁移（订阅本身迁移）；
+// 邮件发送计数只在当前小时内有效，也不迁移；审计日志只追加，恢复备份时不能被快照覆盖，因此也不在其中
+var MigrateNamespaces = []string{
+	CategoryDefaultsNamespace,
+	ShareLinksNamespace,
+	CalDAVResourcesNamespace,
+	RulesNamespace,
+	WatchersNamespace,
+	DependenciesNamespace,
+	NotificationTemplatesNamespace,
+	TrashNamespace,
+	WebhooksNamespace,
+}
+
+// Loader 可选接口，由支持按原样写入待办事项的存储实现，用于在存储后端之间迁移数据
+// 写入的事项保留ID、时间戳和版本号，之后新建的事项ID大于已写入的最大ID；任一ID已存在时返回 ErrDuplicateID
+type Loader interface {
+	LoadTodos(todos []*models.Todo) error
+}
+
+// IDReserver 可选接口，由能够调整ID计数器的存储实现，恢复快照时用来保留快照中的下一个可用ID
+// 之后新建的事项ID不小于 next；计数器已经更大时保持不变，已经分配过的ID不会被重新使用
+type IDReserver interface {
+	ReserveIDs(next int) error
+}
+
+// MigrateOptions 迁移选项
+type MigrateOptions struct {
+	BatchSize int                   // 每批写入的待办事项数，0表示使用默认值
+	Progress  func(done, total int) // 每写入一批后调用，可为nil
+}
+
+// Summary 存储中数据的数量和校验和，用于校验迁移结果
+type Summary struct {
+	Todos        int    // 待办事项数
+	TodoChecksum string // 待办事项的校验和
+	Meta         int    // MigrateNamespaces 中的附属数据条数
+	MetaChecksum string // 附属数据的校验和
+}
+
+// MigrateResult 迁移结果
+type MigrateResult struct {
+	Source Summary // 源存储的数量和校验和
+	Target Summary // 迁移后目标存储的数量和校验和
+}
+
+// Migrate 把源存储中的待办事项和附属数据复制到目标存储，完成后比较两边的数量和校验和
+// 目标存储必须实现 Loader 并且没有待办事项。源存储没有游标接口，待办事项一次读出后按批写入；
+// 写入中途失败时已写入的批次不会回滚，清空目标存储后可重新执行
+func Migrate(src, dst TodoStore, opts MigrateOptions) (*MigrateResult, error) {
+	loader, ok := dst.(Loader)
+	if !ok {
+		return nil, ErrLoadUnsupported
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultMigrateBatch
+	}
+
+	existing, err := dst.GetAllTodos()
+	if err != nil {
+		return nil, fmt.Errorf("读取目标存储失败: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w（%d 条）", ErrTargetNotEmpty, len(existing))
+	}
+
+	todos, err := src.ListTodos(ListOptions{SortField: models.SortByID})
+	if err != nil {
+		return nil, fmt.Errorf("读取源存储失败: %w", err)
+	}
+
+	for start := 0; start < len(todos); start += opts.BatchSize {
+		end := start + opts.BatchSize
+		if end > len(todos) {
+			end = len(todos)
+		}
+		if err := loader.LoadTodos(todos[start:end]); err != nil {
+			return nil, fmt.Errorf("写入第 %d~%d 条待办事项失败: %w", start+1, end, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(end, len(todos))
+		}
+	}
+
+	for _, namespace := range MigrateNamespaces {
+		items, err := src.ListMeta(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("读取源存储的附属数据 %s 失败: %w", namespace, err)
+		}
+		for key, value := range items {
+			if err := dst.PutMeta(namespace, key, value); err != nil {
+				return nil, fmt.Errorf("写入附属数据 %s/%s 失败: %w", namespace, key, err)
+			}
+		}
+	}
+
+	result := &MigrateResult{}
+	if result.Source, err = summarize(todos, src); err != nil {
+		return nil, err
+	}
+	target, err := Summarize(dst)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标存储失败: %w", err)
+	}
+	result.Target = *target
+
+	if result.Source != result.Target {
+		return result, fmt.Errorf("%w：源存储 %d 条事项、%d 条附属数据，目标存储 %d 条事项、%d 条附属数据",
+			ErrVerifyFailed, result.Source.Todos, result.Source.Meta, result.Target.Todos, result.Target.Meta)
+	}
+	return result, nil
+}
+
+// Summarize 计算存储中待办事项和 MigrateNamespaces 中附属数据的数量和校验和
+func Summarize(s TodoStore) (*Summary, error) {
+	todos, err := s.ListTodos(ListOptions{SortField: models.SortByID})
+	if err != nil {
+		return nil, err
+	}
+	summary, err := summarize(todos, s)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// summarize 计算按ID排好序的待办事项和存储中附属数据的数量和校验和
+func summarize(todos []*models.Todo, meta MetaStore) (Summary, error) {
+	summary := Summary{Todos: len(todos)}
+
+	hash := sha256.New()
+	for _, todo := range todos {
+		data, err := json.Marshal(canonicalTodo(todo))
+		if err != nil {
+			return summary, err
+		}
+		hash.Write(data)
+		hash.Write([]byte{'\n'})
+	}
+	summary.TodoChecksum = hex.EncodeToString(hash.Sum(nil))
+
+	hash.Reset()
+	for _, namespace := range MigrateNamespaces {
+		items, err := meta.ListMeta(namespace)
+		if err != nil {
+			return summary, fmt.Errorf("读取附属数据 %s 失败: %w", namespace, err)
+		}
+		keys := make([]string, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(hash, "%s\x00%s\x00%d\x00", namespace, key, len(items[key]))
+			hash.Write(items[key])
+		}
+		summary.Meta += len(items)
+	}
+	summary.MetaChecksum = hex.EncodeToString(hash.Sum(nil))
+
+	return summary, nil
+}
+
+// canonicalTodo 待办事项计算校验和时使用的形式
+// 时间统一为UTC并四舍五入到微秒（PostgreSQL 和 MySQL 只保存到微秒，写入时四舍五入），空的关联链接统一为nil
+func canonicalTodo(todo *models.Todo) *models.Todo {
+	canonical := todo.Clone()
+	for _, t := range []*time.Time{canonical.DueDate, canonical.RemindAt, canonical.CompletedAt, &canonical.CreatedAt, &canonical.UpdatedAt} {
+		if t != nil && !t.IsZero() {
+			*t = t.UTC().Round(time.Microsecond)
+		}
+	}
+	if len(canonical.Links) == 0 {
+		canonical.Links = nil
+	}
+	return canonical
+}
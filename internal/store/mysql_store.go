@@ -0,0 +1,183 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+
+	"github.com/go-sql-driver/mysql" // MySQL 驱动
+)
+
+// mysqlDialect MySQL 方言
+var mysqlDialect = &sqlDialect{
+	name: "MySQL",
+	migrations: [][]string{
+		// 版本1：待办事项表和附属数据表
+		// 文本字段使用 utf8mb4_bin 排序规则，搜索和分类比较区分大小写，与其它存储保持一致
+		// 注意：MySQL 中的DDL语句会隐式提交事务，迁移中途失败时需要手动清理
+		{
+			`CREATE TABLE todos (
+				id          BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+				title       VARCHAR(255) NOT NULL,
+				description TEXT         NOT NULL,
+				completed   BOOLEAN      NOT NULL DEFAULT FALSE,
+				priority    INT          NOT NULL DEFAULT 0,
+				category    VARCHAR(100) NOT NULL DEFAULT '',
+				due_date    DATETIME(6)  NULL,
+				created_at  DATETIME(6)  NOT NULL,
+				updated_at  DATETIME(6)  NOT NULL,
+				links       TEXT         NOT NULL,
+				INDEX idx_todos_category (category),
+				INDEX idx_todos_completed (completed)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin`,
+			"CREATE TABLE meta (" +
+				"namespace VARCHAR(100) NOT NULL, " +
+				"`key` VARCHAR(191) NOT NULL, " +
+				"value LONGBLOB NOT NULL, " +
+				"PRIMARY KEY (namespace, `key`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin",
+		},
+		// 版本2：待办事项地点，以JSON文本保存，没有地点时为空字符串
+		// TEXT 字段不能设置默认值，已有数据行会被填充为空字符串
+		{
+			`ALTER TABLE todos ADD COLUMN location TEXT NOT NULL`,
+		},
+		// 版本3：分布式锁，多个服务实例共用数据库时保证定时任务只在一个实例上执行
+		{
+			`CREATE TABLE locks (
+				name       VARCHAR(191) NOT NULL PRIMARY KEY,
+				owner      VARCHAR(191) NOT NULL,
+				expires_at DATETIME(6)  NOT NULL
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin`,
+		},
+		// 版本4：事件发件箱，序号自增且不会被重新分配
+		{
+			`CREATE TABLE events (
+				seq        BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+				type       VARCHAR(100) NOT NULL,
+				todo_id    BIGINT       NOT NULL,
+				data       LONGTEXT     NOT NULL,
+				created_at DATETIME(6)  NOT NULL,
+				INDEX idx_events_created_at (created_at)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin`,
+		},
+		// 版本5：乐观并发控制的版本号，已有数据从版本1开始
+		{
+			`ALTER TABLE todos ADD COLUMN version INT NOT NULL DEFAULT 1`,
+		},
+		// 版本6：完成时间，没有完成或在此之前就已完成时为NULL
+		{
+			`ALTER TABLE todos ADD COLUMN completed_at DATETIME(6) NULL`,
+		},
+		// 版本7：归档标记，已有数据均为未归档
+		{
+			`ALTER TABLE todos ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE`,
+		},
+		// 版本8：手动排序的位置，已有数据均为0（按ID排列）
+		{
+			`ALTER TABLE todos ADD COLUMN position INT NOT NULL DEFAULT 0`,
+		},
+		// 版本9：标签，以JSON数组文本保存，没有标签时为空字符串
+		{
+			`ALTER TABLE todos ADD COLUMN tags TEXT NOT NULL`,
+		},
+		// 版本10：子任务，以JSON数组文本保存，没有子任务时为空字符串
+		{
+			`ALTER TABLE todos ADD COLUMN subtasks TEXT NOT NULL`,
+		},
+		// 版本11：提醒时间，没有提醒时为NULL
+		{
+			`ALTER TABLE todos ADD COLUMN remind_at DATETIME(6) NULL`,
+		},
+	},
+	lockRows:   " FOR UPDATE",
+	keyColumn:  "`key`", // key 是 MySQL 的保留字
+	upsertMeta: "INSERT INTO meta (namespace, `key`, value) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE value = VALUES(value)",
+	insertLock: `INSERT IGNORE INTO locks (name, owner, expires_at) VALUES (?, ?, ?)`,
+	// 使用 LIKE 匹配子串，查询字符串中的通配符会被转义
+	contains: func(column string) string {
+		return column + " LIKE ? ESCAPE '!'"
+	},
+	pattern: func(query string) string {
+		return "%" + mysqlLikeEscaper.Replace(query) + "%"
+	},
+	timeValue: func(t time.Time) interface{} {
+		return t.UTC()
+	},
+	// ALTER TABLE ... AUTO_INCREMENT 会隐式提交事务，且可以把计数器调小到已删除的ID；
+	// 因此写入一条ID为 next-1 的占位事项再删除：InnoDB 的 AUTO_INCREMENT 计数器只增不减，回滚和删除都不会让它回退
+	reserveIDs: func(tx *sqlStore, next int) error {
+		if _, err := tx.getTodo(tx.stmt, next-1); err == nil {
+			return nil // 该ID的事项存在，计数器已经大于它
+		} else if !errors.Is(err, ErrTodoNotFound) {
+			return err
+		}
+		now := time.Now()
+		if err := tx.LoadTodos([]*models.Todo{{ID: next - 1, CreatedAt: now, UpdatedAt: now, Version: 1}}); err != nil {
+			return err
+		}
+		_, err := tx.stmt("delete").Exec(next - 1)
+		return err
+	},
+}
+
+// mysqlLikeEscaper 转义 LIKE 模式中的通配符和转义字符本身
+var mysqlLikeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// MySQLStore MySQL 存储实现
+type MySQLStore struct {
+	*sqlStore
+}
+
+// NewMySQLStore 创建MySQL存储
+// 使用配置中的 Host/Port/Name/Username/Password 连接数据库，首次运行时自动创建表结构
+func NewMySQLStore(cfg *config.DatabaseConfig) (*MySQLStore, error) {
+	db, err := sql.Open("mysql", mysqlDSN(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("打开MySQL连接失败: %w", err)
+	}
+
+	configurePool(db, cfg)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接MySQL失败: %w", err)
+	}
+
+	store, err := newSQLStore(db, mysqlDialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &MySQLStore{sqlStore: store}, nil
+}
+
+// mysqlDSN 根据配置生成MySQL连接字符串
+// 例如：user:pass@tcp(localhost:3306)/xstreamtool?clientFoundRows=true&loc=UTC&parseTime=true
+func mysqlDSN(cfg *config.DatabaseConfig) string {
+	port := cfg.Port
+	if port == 0 {
+		port = 3306 // MySQL 默认端口
+	}
+
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.Username
+	dsn.Passwd = cfg.Password
+	dsn.Net = "tcp"
+	dsn.Addr = cfg.Host + ":" + strconv.Itoa(port)
+	dsn.DBName = cfg.Name
+	dsn.ParseTime = true // DATETIME 字段读取为 time.Time
+	dsn.Loc = time.UTC
+	// 返回匹配的行数而不是实际修改的行数，
+	// 否则更新内容未变化时影响行数为0，会被误判为"不存在"
+	dsn.ClientFoundRows = true
+
+	return dsn.FormatDSN()
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// NextSkipsNamespace 下一步推荐中暂时跳过的事项在附属数据中的命名空间，键为待办事项ID
+// 跳过只在短时间内有效，迁移和备份时不复制
+const NextSkipsNamespace = "next_skips"
+
+// SaveNextSkip 保存跳过记录（已存在则覆盖）
+func SaveNextSkip(s MetaStore, skip *models.NextSkip) error {
+	data, err := json.Marshal(skip)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(NextSkipsNamespace, strconv.Itoa(skip.TodoID), data)
+}
+
+// DeleteNextSkip 删除跳过记录
+func DeleteNextSkip(s MetaStore, todoID int) error {
+	return s.DeleteMeta(NextSkipsNamespace, strconv.Itoa(todoID))
+}
+
+// ListNextSkips 列出所有跳过记录（包括已过期的），key为待办事项ID
+func ListNextSkips(s MetaStore) (map[int]*models.NextSkip, error) {
+	items, err := s.ListMeta(NextSkipsNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[int]*models.NextSkip, len(items))
+	for _, data := range items {
+		var skip models.NextSkip
+		if err := json.Unmarshal(data, &skip); err != nil {
+			return nil, err
+		}
+		results[skip.TodoID] = &skip
+	}
+	return results, nil
+}
@@ -0,0 +1,823 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// redisMaxRetries 乐观锁冲突时的最大重试次数
+const redisMaxRetries = 20
+
+// RedisStore Redis 存储实现
+// 适合不需要关系型数据库、但多个服务实例需要共享数据的部署方式。数据结构如下（均带有 前缀: ）：
+//
+//	todo:next_id        下一个ID计数器（INCR）
+//	todo:{id}           每个待办事项一个哈希
+//	todos               所有待办事项ID的集合
+//	category:{name}     分类索引集合
+//	completed:{0|1}     完成状态索引集合
+//	meta:{namespace}    附属数据哈希，字段为数据键
+//
+// 修改待办事项时使用 WATCH/MULTI 保证哈希和索引集合的一致性
+type RedisStore struct {
+	client *redis.Client
+	prefix string // 键前缀，多个应用共用一个Redis时互不干扰
+}
+
+// NewRedisStore 创建Redis存储
+// 使用配置中的 Host/Port/Username/Password 连接Redis，Name 作为键前缀
+func NewRedisStore(cfg *config.DatabaseConfig) (*RedisStore, error) {
+	port := cfg.Port
+	if port == 0 {
+		port = 6379 // Redis 默认端口
+	}
+
+	prefix := cfg.Name
+	if prefix == "" {
+		prefix = "xstreamtool"
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Host + ":" + strconv.Itoa(port),
+		Username: cfg.Username,
+		Password: cfg.Password,
+		PoolSize: cfg.MaxOpenConns,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接Redis失败: %w", err)
+	}
+
+	return &RedisStore{client: client, prefix: prefix}, nil
+}
+
+// Close 关闭Redis连接
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// Ping 检查Redis连接是否可用
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// key 生成带前缀的键
+func (s *RedisStore) key(parts ...string) string {
+	return s.prefix + ":" + strings.Join(parts, ":")
+}
+
+// todoKey 待办事项哈希的键
+func (s *RedisStore) todoKey(id int) string {
+	return s.key("todo", strconv.Itoa(id))
+}
+
+// categoryKey 分类索引集合的键
+func (s *RedisStore) categoryKey(category string) string {
+	return s.key("category", category)
+}
+
+// completedKey 完成状态索引集合的键
+func (s *RedisStore) completedKey(completed bool) string {
+	if completed {
+		return s.key("completed", "1")
+	}
+	return s.key("completed", "0")
+}
+
+// GetAllTodos 获取所有待办事项，按创建时间倒序排列
+func (s *RedisStore) GetAllTodos() ([]*models.Todo, error) {
+	ctx := context.Background()
+	ids, err := s.client.SMembers(ctx, s.key("todos")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := s.loadTodos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	sortNewestFirst(todos)
+	return todos, nil
+}
+
+// sortNewestFirst 按创建时间倒序排列，创建时间相同时ID大的在前
+func sortNewestFirst(todos []*models.Todo) {
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].CreatedAt.Equal(todos[j].CreatedAt) {
+			return todos[i].CreatedAt.After(todos[j].CreatedAt)
+		}
+		return todos[i].ID > todos[j].ID
+	})
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+// Redis 中没有按字段排序和过滤的索引，取出所有事项后在内存中过滤、排序，规则与内存存储一致
+func (s *RedisStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	todos, err := s.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	todos = filterTodos(todos, opts.Filter)
+	if keys := opts.sortKeys(); len(keys) > 0 {
+		models.SortTodosBy(todos, keys, nil)
+	}
+	return todos, nil
+}
+
+// GetTodoByID 根据ID获取待办事项
+func (s *RedisStore) GetTodoByID(id int) (*models.Todo, error) {
+	fields, err := s.client.HGetAll(context.Background(), s.todoKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrTodoNotFound
+	}
+	return decodeRedisTodo(fields)
+}
+
+// CreateTodo 创建新的待办事项
+func (s *RedisStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	ctx := context.Background()
+	id, err := s.client.Incr(ctx, s.key("todo", "next_id")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	todo := &models.Todo{ID: int(id), CreatedAt: now}
+	todo.FromRequest(req)
+	todo.UpdatedAt = now
+
+	fields, err := encodeRedisTodo(todo)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.todoKey(todo.ID), fields)
+		s.addIndexes(ctx, pipe, todo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// UpdateTodo 更新待办事项
+func (s *RedisStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	return s.modify(id, func(todo *models.Todo) error {
+		if err := checkVersion(todo, req); err != nil {
+			return err
+		}
+		todo.FromRequest(req)
+		return nil
+	})
+}
+
+// SaveTodo 保存完整的待办事项，ID和创建时间保持不变，更新时间设为当前时间
+func (s *RedisStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	return s.modify(todo.ID, func(current *models.Todo) error {
+		createdAt, version := current.CreatedAt, current.Version
+		*current = *todo.Clone()
+		current.CreatedAt = createdAt
+		current.UpdatedAt = time.Now()
+		current.Version = version + 1
+		return nil
+	})
+}
+
+// DeleteTodo 删除待办事项
+func (s *RedisStore) DeleteTodo(id int) error {
+	ctx := context.Background()
+	key := s.todoKey(id)
+
+	return s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			todo, err := s.readTodo(ctx, tx, key)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, key)
+				s.removeIndexes(ctx, pipe, todo)
+				return nil
+			})
+			return err
+		}, key)
+	})
+}
+
+// BulkCreate 批量创建待办事项
+// 一次 INCRBY 分配所有ID，哈希和索引在一个 MULTI 事务中写入
+func (s *RedisStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	if len(reqs) == 0 {
+		return []*models.Todo{}, nil
+	}
+
+	ctx := context.Background()
+	lastID, err := s.client.IncrBy(ctx, s.key("todo", "next_id"), int64(len(reqs))).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	firstID := int(lastID) - len(reqs) + 1
+	todos := make([]*models.Todo, len(reqs))
+	encoded := make([]map[string]interface{}, len(reqs))
+	for i, req := range reqs {
+		todo := &models.Todo{ID: firstID + i, CreatedAt: now}
+		todo.FromRequest(req)
+		todo.UpdatedAt = now
+		if encoded[i], err = encodeRedisTodo(todo); err != nil {
+			return nil, err
+		}
+		todos[i] = todo
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, todo := range todos {
+			pipe.HSet(ctx, s.todoKey(todo.ID), encoded[i])
+			s.addIndexes(ctx, pipe, todo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// BulkUpdate 批量更新待办事项
+// WATCH 所有事项后读取并在一个 MULTI 事务中写入，任一事项不存在或版本不一致时不修改任何数据
+func (s *RedisStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	if err := checkUniqueIDs(updateIDs(updates)); err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return []*models.Todo{}, nil
+	}
+
+	ctx := context.Background()
+	keys := make([]string, len(updates))
+	for i, update := range updates {
+		keys[i] = s.todoKey(update.ID)
+	}
+
+	var results []*models.Todo
+	err := s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			olds, err := s.readTodos(ctx, tx, keys, updateIDs(updates))
+			if err != nil {
+				return err
+			}
+
+			todos := make([]*models.Todo, len(updates))
+			encoded := make([]map[string]interface{}, len(updates))
+			for i, update := range updates {
+				if err := checkVersion(olds[i], update.Request); err != nil {
+					return err
+				}
+				todo := olds[i].Clone()
+				todo.FromRequest(update.Request)
+				if encoded[i], err = encodeRedisTodo(todo); err != nil {
+					return err
+				}
+				todos[i] = todo
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, todo := range todos {
+					pipe.HSet(ctx, keys[i], encoded[i])
+					s.removeIndexes(ctx, pipe, olds[i])
+					s.addIndexes(ctx, pipe, todo)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			results = todos
+			return nil
+		}, keys...)
+	})
+	return results, err
+}
+
+// BulkDelete 批量删除待办事项
+// WATCH 所有事项后读取并在一个 MULTI 事务中删除，任一事项不存在时不删除任何数据
+func (s *RedisStore) BulkDelete(ids []int) error {
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.todoKey(id)
+	}
+
+	return s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			todos, err := s.readTodos(ctx, tx, keys, ids)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, keys...)
+				for _, todo := range todos {
+					s.removeIndexes(ctx, pipe, todo)
+				}
+				return nil
+			})
+			return err
+		}, keys...)
+	})
+}
+
+// SearchTodos 搜索待办事项
+// 分类和完成状态通过索引集合求交集筛选，查询字符串在读取后匹配；
+// 结果按优先级降序、创建时间倒序排列，与内存存储保持一致
+func (s *RedisStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	ctx := context.Background()
+
+	keys := []string{s.key("todos")}
+	if category != "" {
+		keys = append(keys, s.categoryKey(category))
+	}
+	if completed != nil {
+		keys = append(keys, s.completedKey(*completed))
+	}
+
+	ids, err := s.client.SInter(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := s.loadTodos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if query == "" || strings.Contains(todo.Title, query) || strings.Contains(todo.Description, query) {
+			results = append(results, todo)
+		}
+	}
+
+	sortSearchResults(results)
+	return results, nil
+}
+
+// sortSearchResults 按优先级降序、创建时间倒序排列搜索结果，都相同时ID大的在前
+func sortSearchResults(results []*models.Todo) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Priority != results[j].Priority {
+			return results[i].Priority > results[j].Priority
+		}
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.After(results[j].CreatedAt)
+		}
+		return results[i].ID > results[j].ID
+	})
+}
+
+// GetStats 获取统计信息
+func (s *RedisStore) GetStats() (map[string]interface{}, error) {
+	todos, err := s.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	return computeStats(todos, time.Now()), nil
+}
+
+// GetMeta 读取附属数据
+func (s *RedisStore) GetMeta(namespace, key string) ([]byte, error) {
+	value, err := s.client.HGet(context.Background(), s.key("meta", namespace), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMetaNotFound
+	}
+	return value, err
+}
+
+// PutMeta 写入附属数据（已存在则覆盖）
+func (s *RedisStore) PutMeta(namespace, key string, value []byte) error {
+	return s.client.HSet(context.Background(), s.key("meta", namespace), key, value).Err()
+}
+
+// DeleteMeta 删除附属数据
+func (s *RedisStore) DeleteMeta(namespace, key string) error {
+	deleted, err := s.client.HDel(context.Background(), s.key("meta", namespace), key).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrMetaNotFound
+	}
+	return nil
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *RedisStore) ListMeta(namespace string) (map[string][]byte, error) {
+	values, err := s.client.HGetAll(context.Background(), s.key("meta", namespace)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]byte, len(values))
+	for key, value := range values {
+		results[key] = []byte(value)
+	}
+	return results, nil
+}
+
+// LoadTodos 按原样写入待办事项，保留ID、时间戳和版本号
+// WATCH 所有事项后确认都不存在再在一个 MULTI 事务中写入，之后把ID计数器推进到最大ID
+func (s *RedisStore) LoadTodos(todos []*models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	ids := make([]int, len(todos))
+	keys := make([]string, len(todos))
+	encoded := make([]map[string]interface{}, len(todos))
+	maxID := 0
+	for i, todo := range todos {
+		if todo.ID <= 0 {
+			return ErrInvalidID
+		}
+		fields, err := encodeRedisTodo(todo)
+		if err != nil {
+			return err
+		}
+		ids[i], keys[i], encoded[i] = todo.ID, s.todoKey(todo.ID), fields
+		if todo.ID > maxID {
+			maxID = todo.ID
+		}
+	}
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+
+	err := s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			existing, err := tx.Exists(ctx, keys...).Result()
+			if err != nil {
+				return err
+			}
+			if existing > 0 {
+				return fmt.Errorf("%w：%d 个事项已存在", ErrDuplicateID, existing)
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, todo := range todos {
+					pipe.HSet(ctx, keys[i], encoded[i])
+					s.addIndexes(ctx, pipe, todo)
+				}
+				return nil
+			})
+			return err
+		}, keys...)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.advanceNextID(ctx, maxID)
+}
+
+// advanceNextID 确保ID计数器不小于id，之后 INCR 分配的ID大于id
+func (s *RedisStore) advanceNextID(ctx context.Context, id int) error {
+	key := s.key("todo", "next_id")
+	return s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := tx.Get(ctx, key).Int()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			if current >= id {
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, id, 0)
+				return nil
+			})
+			return err
+		}, key)
+	})
+}
+
+// modify 读取待办事项，调用update修改后写回，并同步更新索引集合；update返回错误时不写回
+// 使用 WATCH 监视待办事项的键，期间被其它实例修改时自动重试
+func (s *RedisStore) modify(id int, update func(todo *models.Todo) error) (*models.Todo, error) {
+	ctx := context.Background()
+	key := s.todoKey(id)
+
+	var result *models.Todo
+	err := s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			todo, err := s.readTodo(ctx, tx, key)
+			if err != nil {
+				return err
+			}
+
+			old := todo.Clone()
+			if err := update(todo); err != nil {
+				return err
+			}
+			todo.ID = id
+
+			fields, err := encodeRedisTodo(todo)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, key, fields)
+				s.removeIndexes(ctx, pipe, old)
+				s.addIndexes(ctx, pipe, todo)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			result = todo
+			return nil
+		}, key)
+	})
+	return result, err
+}
+
+// retry 执行乐观锁事务，事务因冲突失败时随机等待片刻后重试，避免多个实例同时重试再次冲突
+func (s *RedisStore) retry(fn func() error) error {
+	for i := 0; i < redisMaxRetries; i++ {
+		err := fn()
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+		time.Sleep(time.Duration(rand.Intn(5*(i+1))+1) * time.Millisecond)
+	}
+	return errors.New("Redis事务冲突次数过多，请稍后重试")
+}
+
+// readTodo 在事务中读取待办事项
+func (s *RedisStore) readTodo(ctx context.Context, tx *redis.Tx, key string) (*models.Todo, error) {
+	fields, err := tx.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrTodoNotFound
+	}
+	return decodeRedisTodo(fields)
+}
+
+// readTodos 在事务中批量读取待办事项，任一事项不存在时返回带有该ID的 ErrTodoNotFound
+func (s *RedisStore) readTodos(ctx context.Context, tx *redis.Tx, keys []string, ids []int) ([]*models.Todo, error) {
+	pipe := tx.Pipeline()
+	commands := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		commands[i] = pipe.HGetAll(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	todos := make([]*models.Todo, len(keys))
+	for i, command := range commands {
+		fields := command.Val()
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: %d", ErrTodoNotFound, ids[i])
+		}
+		todo, err := decodeRedisTodo(fields)
+		if err != nil {
+			return nil, err
+		}
+		todos[i] = todo
+	}
+	return todos, nil
+}
+
+// loadTodos 批量读取待办事项，读取期间已被删除的事项会被忽略
+func (s *RedisStore) loadTodos(ctx context.Context, ids []string) ([]*models.Todo, error) {
+	if len(ids) == 0 {
+		return []*models.Todo{}, nil
+	}
+
+	pipe := s.client.Pipeline()
+	commands := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		commands[i] = pipe.HGetAll(ctx, s.key("todo", id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	todos := make([]*models.Todo, 0, len(ids))
+	for _, command := range commands {
+		fields := command.Val()
+		if len(fields) == 0 {
+			continue
+		}
+		todo, err := decodeRedisTodo(fields)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// addIndexes 将待办事项加入ID集合和索引集合
+func (s *RedisStore) addIndexes(ctx context.Context, pipe redis.Pipeliner, todo *models.Todo) {
+	id := strconv.Itoa(todo.ID)
+	pipe.SAdd(ctx, s.key("todos"), id)
+	pipe.SAdd(ctx, s.completedKey(todo.Completed), id)
+	if todo.Category != "" {
+		pipe.SAdd(ctx, s.categoryKey(todo.Category), id)
+	}
+}
+
+// removeIndexes 将待办事项从ID集合和索引集合中移除
+func (s *RedisStore) removeIndexes(ctx context.Context, pipe redis.Pipeliner, todo *models.Todo) {
+	id := strconv.Itoa(todo.ID)
+	pipe.SRem(ctx, s.key("todos"), id)
+	pipe.SRem(ctx, s.completedKey(todo.Completed), id)
+	if todo.Category != "" {
+		pipe.SRem(ctx, s.categoryKey(todo.Category), id)
+	}
+}
+
+// encodeRedisTodo 将待办事项编码为哈希字段
+// 时间以 RFC3339 文本保存，没有截止日期时为空字符串；关联链接、地点、标签和子任务以JSON文本保存
+func encodeRedisTodo(todo *models.Todo) (map[string]interface{}, error) {
+	links, err := encodeLinks(todo.Links)
+	if err != nil {
+		return nil, err
+	}
+	location, err := encodeLocation(todo.Location)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := encodeTags(todo.Tags)
+	if err != nil {
+		return nil, err
+	}
+	subtasks, err := encodeSubtasks(todo.Subtasks)
+	if err != nil {
+		return nil, err
+	}
+
+	dueDate := ""
+	if todo.HasDueDate() {
+		dueDate = todo.DueDate.Format(time.RFC3339Nano)
+	}
+	completedAt := ""
+	if todo.CompletedAt != nil {
+		completedAt = todo.CompletedAt.Format(time.RFC3339Nano)
+	}
+	remindAt := ""
+	if todo.RemindAt != nil {
+		remindAt = todo.RemindAt.Format(time.RFC3339Nano)
+	}
+
+	return map[string]interface{}{
+		"id":           todo.ID,
+		"title":        todo.Title,
+		"description":  todo.Description,
+		"completed":    strconv.FormatBool(todo.Completed),
+		"priority":     int(todo.Priority),
+		"category":     todo.Category,
+		"due_date":     dueDate,
+		"created_at":   todo.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at":   todo.UpdatedAt.Format(time.RFC3339Nano),
+		"links":        links,
+		"location":     location,
+		"version":      todo.Version,
+		"completed_at": completedAt,
+		"archived":     strconv.FormatBool(todo.Archived),
+		"position":     todo.Position,
+		"tags":         tags,
+		"subtasks":     subtasks,
+		"remind_at":    remindAt,
+	}, nil
+}
+
+// decodeRedisTodo 从哈希字段解码待办事项
+func decodeRedisTodo(fields map[string]string) (*models.Todo, error) {
+	var todo models.Todo
+	var err error
+
+	if todo.ID, err = strconv.Atoi(fields["id"]); err != nil {
+		return nil, fmt.Errorf("解析待办事项ID失败: %w", err)
+	}
+	todo.Title = fields["title"]
+	todo.Description = fields["description"]
+	todo.Category = fields["category"]
+	todo.Completed = fields["completed"] == "true"
+	todo.Archived = fields["archived"] == "true"
+	if value := fields["priority"]; value != "" {
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的优先级失败: %w", todo.ID, err)
+		}
+		todo.Priority = models.Priority(priority)
+	}
+
+	if value := fields["position"]; value != "" {
+		if todo.Position, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的位置失败: %w", todo.ID, err)
+		}
+	}
+
+	// 没有版本号的旧数据从版本1开始
+	todo.Version = 1
+	if value := fields["version"]; value != "" {
+		if todo.Version, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的版本号失败: %w", todo.ID, err)
+		}
+	}
+
+	if value := fields["due_date"]; value != "" {
+		due, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的 due_date 失败: %w", todo.ID, err)
+		}
+		todo.DueDate = &due
+	}
+	if value := fields["completed_at"]; value != "" {
+		completedAt, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的 completed_at 失败: %w", todo.ID, err)
+		}
+		todo.CompletedAt = &completedAt
+	}
+	if value := fields["remind_at"]; value != "" {
+		remindAt, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的 remind_at 失败: %w", todo.ID, err)
+		}
+		todo.RemindAt = &remindAt
+	}
+	for name, target := range map[string]*time.Time{
+		"created_at": &todo.CreatedAt,
+		"updated_at": &todo.UpdatedAt,
+	} {
+		if value := fields[name]; value != "" {
+			if *target, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				return nil, fmt.Errorf("解析待办事项 %d 的 %s 失败: %w", todo.ID, name, err)
+			}
+		}
+	}
+
+	if value := fields["links"]; value != "" {
+		if err := json.Unmarshal([]byte(value), &todo.Links); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的关联链接失败: %w", todo.ID, err)
+		}
+	}
+	if value := fields["location"]; value != "" {
+		if err := json.Unmarshal([]byte(value), &todo.Location); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的地点失败: %w", todo.ID, err)
+		}
+	}
+	if value := fields["tags"]; value != "" {
+		if err := json.Unmarshal([]byte(value), &todo.Tags); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的标签失败: %w", todo.ID, err)
+		}
+	}
+	if value := fields["subtasks"]; value != "" {
+		if err := json.Unmarshal([]byte(value), &todo.Subtasks); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的子任务失败: %w", todo.ID, err)
+		}
+	}
+
+	return &todo, nil
+}
@@ -0,0 +1,514 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// Transaction 在一个乐观锁事务中执行fn
+// 事务中读取的待办事项和附属数据的键都会被 WATCH，写入先缓存在内存中，fn 返回nil后在一个 MULTI 事务中提交；
+// 提交前被 WATCH 的键被其它客户端修改时，丢弃缓存的写入并重新执行fn，因此fn不应有事务之外的副作用。
+// 事务中新建事项的ID在创建时分配，事务回滚后这些ID不会被重新使用
+func (s *RedisStore) Transaction(fn func(tx TodoStore) error) error {
+	ctx := context.Background()
+	loaded := 0 // 事务中按原样写入的最大ID
+	err := s.retry(func() error {
+		return s.client.Watch(ctx, func(tx *redis.Tx) error {
+			t := &redisTx{
+				s:     s,
+				ctx:   ctx,
+				tx:    tx,
+				todos: make(map[int]*models.Todo),
+				orig:  make(map[int]*models.Todo),
+				dirty: make(map[int]bool),
+				meta:  make(map[string]map[string][]byte),
+			}
+			if err := fn(t); err != nil {
+				return err
+			}
+			if err := t.commit(); err != nil {
+				return err
+			}
+			loaded = t.loaded
+			return nil
+		})
+	})
+	if err != nil || loaded == 0 {
+		return err
+	}
+	return s.advanceNextID(ctx, loaded)
+}
+
+// Export 把全部数据以快照JSON写入w
+func (s *RedisStore) Export(w io.Writer) error {
+	return ExportSnapshot(s, w)
+}
+
+// Import 从r读取快照，在一个事务中替换全部数据
+func (s *RedisStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// redisTx Redis 存储的事务，实现 TodoStore 接口
+type redisTx struct {
+	s   *RedisStore
+	ctx context.Context
+	tx  *redis.Tx
+
+	todos map[int]*models.Todo         // 事务中读取或写入过的事项，值为nil表示不存在或已删除
+	orig  map[int]*models.Todo         // 事项在事务开始时的内容，提交时据此更新索引集合，值为nil表示原本不存在
+	dirty map[int]bool                 // 事务中修改过的事项
+	meta  map[string]map[string][]byte // 事务中写入的附属数据，值为nil表示已删除
+
+	loaded int // LoadTodos 写入的最大ID或 ReserveIDs 保留的ID，提交后把ID计数器推进到该值
+}
+
+// get 读取待办事项，优先使用事务中的数据；首次读取时 WATCH 事项的键
+func (t *redisTx) get(id int) (*models.Todo, error) {
+	if todo, exists := t.todos[id]; exists {
+		if todo == nil {
+			return nil, ErrTodoNotFound
+		}
+		return todo, nil
+	}
+
+	key := t.s.todoKey(id)
+	if err := t.tx.Watch(t.ctx, key).Err(); err != nil {
+		return nil, err
+	}
+	todo, err := t.s.readTodo(t.ctx, t.tx, key)
+	if errors.Is(err, ErrTodoNotFound) {
+		t.todos[id], t.orig[id] = nil, nil
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.todos[id], t.orig[id] = todo, todo.Clone()
+	return todo, nil
+}
+
+// put 在事务中写入待办事项，todo为nil表示删除
+func (t *redisTx) put(id int, todo *models.Todo) {
+	t.todos[id] = todo
+	t.dirty[id] = true
+}
+
+// all 读取所有待办事项（包括事务中新建的），WATCH ID集合和每个事项的键，其它客户端新建、修改或删除任一事项时事务重试
+func (t *redisTx) all() ([]*models.Todo, error) {
+	setKey := t.s.key("todos")
+	if err := t.tx.Watch(t.ctx, setKey).Err(); err != nil {
+		return nil, err
+	}
+	members, err := t.tx.SMembers(t.ctx, setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// 尚未读取过的事项一次 WATCH 后用管道批量读取
+	var keys []string
+	var ids []int
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		if _, exists := t.todos[id]; !exists {
+			keys = append(keys, t.s.todoKey(id))
+			ids = append(ids, id)
+		}
+	}
+	if len(keys) > 0 {
+		if err := t.tx.Watch(t.ctx, keys...).Err(); err != nil {
+			return nil, err
+		}
+		pipe := t.tx.Pipeline()
+		commands := make([]*redis.MapStringStringCmd, len(keys))
+		for i, key := range keys {
+			commands[i] = pipe.HGetAll(t.ctx, key)
+		}
+		if _, err := pipe.Exec(t.ctx); err != nil {
+			return nil, err
+		}
+		for i, command := range commands {
+			fields := command.Val()
+			if len(fields) == 0 {
+				t.todos[ids[i]], t.orig[ids[i]] = nil, nil
+				continue
+			}
+			todo, err := decodeRedisTodo(fields)
+			if err != nil {
+				return nil, err
+			}
+			t.todos[ids[i]], t.orig[ids[i]] = todo, todo.Clone()
+		}
+	}
+
+	todos := make([]*models.Todo, 0, len(t.todos))
+	for _, todo := range t.todos {
+		if todo != nil {
+			todos = append(todos, todo.Clone())
+		}
+	}
+	return todos, nil
+}
+
+// commit 在一个 MULTI 事务中写入修改过的事项和附属数据，并同步更新索引集合
+func (t *redisTx) commit() error {
+	encoded := make(map[int]map[string]interface{}, len(t.dirty))
+	for id := range t.dirty {
+		if todo := t.todos[id]; todo != nil {
+			fields, err := encodeRedisTodo(todo)
+			if err != nil {
+				return err
+			}
+			encoded[id] = fields
+		}
+	}
+	if len(t.dirty) == 0 && len(t.meta) == 0 {
+		return nil
+	}
+
+	_, err := t.tx.TxPipelined(t.ctx, func(pipe redis.Pipeliner) error {
+		for id := range t.dirty {
+			key := t.s.todoKey(id)
+			if old := t.orig[id]; old != nil {
+				t.s.removeIndexes(t.ctx, pipe, old)
+			}
+			todo := t.todos[id]
+			if todo == nil {
+				pipe.Del(t.ctx, key)
+				continue
+			}
+			pipe.HSet(t.ctx, key, encoded[id])
+			t.s.addIndexes(t.ctx, pipe, todo)
+		}
+		for namespace, items := range t.meta {
+			key := t.s.key("meta", namespace)
+			for field, value := range items {
+				if value == nil {
+					pipe.HDel(t.ctx, key, field)
+				} else {
+					pipe.HSet(t.ctx, key, field, value)
+				}
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// GetAllTodos 获取所有待办事项，按创建时间倒序排列
+func (t *redisTx) GetAllTodos() ([]*models.Todo, error) {
+	todos, err := t.all()
+	if err != nil {
+		return nil, err
+	}
+	sortNewestFirst(todos)
+	return todos, nil
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (t *redisTx) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	todos, err := t.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	todos = filterTodos(todos, opts.Filter)
+	if keys := opts.sortKeys(); len(keys) > 0 {
+		models.SortTodosBy(todos, keys, nil)
+	}
+	return todos, nil
+}
+
+// GetTodoByID 根据ID获取待办事项
+func (t *redisTx) GetTodoByID(id int) (*models.Todo, error) {
+	todo, err := t.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return todo.Clone(), nil
+}
+
+// CreateTodo 创建新的待办事项
+func (t *redisTx) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	id, err := t.s.client.Incr(t.ctx, t.s.key("todo", "next_id")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	todo := &models.Todo{ID: int(id), CreatedAt: now}
+	todo.FromRequest(req)
+	todo.UpdatedAt = now
+
+	t.orig[todo.ID] = nil
+	t.put(todo.ID, todo)
+	return todo.Clone(), nil
+}
+
+// UpdateTodo 更新待办事项
+func (t *redisTx) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := t.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(todo, req); err != nil {
+		return nil, err
+	}
+
+	updated := todo.Clone()
+	updated.FromRequest(req)
+	t.put(id, updated)
+	return updated.Clone(), nil
+}
+
+// SaveTodo 保存完整的待办事项，ID和创建时间保持不变，更新时间设为当前时间
+func (t *redisTx) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	current, err := t.get(todo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := todo.Clone()
+	saved.CreatedAt = current.CreatedAt
+	saved.UpdatedAt = time.Now()
+	saved.Version = current.Version + 1
+	t.put(saved.ID, saved)
+	return saved.Clone(), nil
+}
+
+// DeleteTodo 删除待办事项
+func (t *redisTx) DeleteTodo(id int) error {
+	if _, err := t.get(id); err != nil {
+		return err
+	}
+	t.put(id, nil)
+	return nil
+}
+
+// SearchTodos 搜索待办事项，结果的排序与 RedisStore.SearchTodos 一致
+func (t *redisTx) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	todos, err := t.all()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if category != "" && todo.Category != category {
+			continue
+		}
+		if completed != nil && todo.Completed != *completed {
+			continue
+		}
+		if query == "" || strings.Contains(todo.Title, query) || strings.Contains(todo.Description, query) {
+			results = append(results, todo)
+		}
+	}
+
+	sortSearchResults(results)
+	return results, nil
+}
+
+// BulkCreate 批量创建待办事项
+func (t *redisTx) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0, len(reqs))
+	for _, req := range reqs {
+		todo, err := t.CreateTodo(req)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// BulkUpdate 批量更新待办事项，先检查所有事项都存在且版本一致再修改
+func (t *redisTx) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	if err := checkUniqueIDs(updateIDs(updates)); err != nil {
+		return nil, err
+	}
+	for _, update := range updates {
+		todo, err := t.get(update.ID)
+		if errors.Is(err, ErrTodoNotFound) {
+			return nil, fmt.Errorf("%w: %d", ErrTodoNotFound, update.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkVersion(todo, update.Request); err != nil {
+			return nil, err
+		}
+	}
+
+	todos := make([]*models.Todo, len(updates))
+	for i, update := range updates {
+		todo, err := t.UpdateTodo(update.ID, update.Request)
+		if err != nil {
+			return nil, err
+		}
+		todos[i] = todo
+	}
+	return todos, nil
+}
+
+// BulkDelete 批量删除待办事项，先检查所有事项都存在再删除
+func (t *redisTx) BulkDelete(ids []int) error {
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := t.get(id); err != nil {
+			if errors.Is(err, ErrTodoNotFound) {
+				return fmt.Errorf("%w: %d", ErrTodoNotFound, id)
+			}
+			return err
+		}
+	}
+	for _, id := range ids {
+		t.put(id, nil)
+	}
+	return nil
+}
+
+// LoadTodos 在事务中按原样写入待办事项，保留ID、时间戳和版本号，任一ID已存在时返回 ErrDuplicateID
+func (t *redisTx) LoadTodos(todos []*models.Todo) error {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		if todo.ID <= 0 {
+			return ErrInvalidID
+		}
+		ids[i] = todo.ID
+	}
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := t.get(id); err == nil {
+			return fmt.Errorf("%w: %d", ErrDuplicateID, id)
+		} else if !errors.Is(err, ErrTodoNotFound) {
+			return err
+		}
+	}
+
+	for _, todo := range todos {
+		t.put(todo.ID, todo.Clone())
+		if todo.ID > t.loaded {
+			t.loaded = todo.ID
+		}
+	}
+	return nil
+}
+
+// ReserveIDs 提交后把ID计数器推进到至少 next-1，之后分配的ID不小于next
+func (t *redisTx) ReserveIDs(next int) error {
+	if next-1 > t.loaded {
+		t.loaded = next - 1
+	}
+	return nil
+}
+
+// GetStats 获取统计信息
+func (t *redisTx) GetStats() (map[string]interface{}, error) {
+	todos, err := t.all()
+	if err != nil {
+		return nil, err
+	}
+	return computeStats(todos, time.Now()), nil
+}
+
+// Transaction 已经在事务中，嵌套的事务合并到当前事务
+func (t *redisTx) Transaction(fn func(tx TodoStore) error) error {
+	return fn(t)
+}
+
+// Export 把事务中看到的全部数据以快照JSON写入w
+func (t *redisTx) Export(w io.Writer) error {
+	return ExportSnapshot(t, w)
+}
+
+// Import 从r读取快照，替换全部数据，随当前事务一起提交
+func (t *redisTx) Import(r io.Reader) error {
+	return ImportSnapshot(t, r)
+}
+
+// GetMeta 读取附属数据，优先使用事务中写入的值；从Redis读取时 WATCH 命名空间的哈希
+func (t *redisTx) GetMeta(namespace, key string) ([]byte, error) {
+	if value, exists := t.meta[namespace][key]; exists {
+		if value == nil {
+			return nil, ErrMetaNotFound
+		}
+		return append([]byte(nil), value...), nil
+	}
+
+	hashKey := t.s.key("meta", namespace)
+	if err := t.tx.Watch(t.ctx, hashKey).Err(); err != nil {
+		return nil, err
+	}
+	value, err := t.tx.HGet(t.ctx, hashKey, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMetaNotFound
+	}
+	return value, err
+}
+
+// PutMeta 在事务中写入附属数据
+func (t *redisTx) PutMeta(namespace, key string, value []byte) error {
+	if t.meta[namespace] == nil {
+		t.meta[namespace] = make(map[string][]byte)
+	}
+	t.meta[namespace][key] = append([]byte{}, value...)
+	return nil
+}
+
+// DeleteMeta 在事务中删除附属数据
+func (t *redisTx) DeleteMeta(namespace, key string) error {
+	if _, err := t.GetMeta(namespace, key); err != nil {
+		return err
+	}
+	if t.meta[namespace] == nil {
+		t.meta[namespace] = make(map[string][]byte)
+	}
+	t.meta[namespace][key] = nil
+	return nil
+}
+
+// ListMeta 列出命名空间下的所有附属数据，包括事务中的修改
+func (t *redisTx) ListMeta(namespace string) (map[string][]byte, error) {
+	hashKey := t.s.key("meta", namespace)
+	if err := t.tx.Watch(t.ctx, hashKey).Err(); err != nil {
+		return nil, err
+	}
+	values, err := t.tx.HGetAll(t.ctx, hashKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]byte, len(values))
+	for key, value := range values {
+		results[key] = []byte(value)
+	}
+	for key, value := range t.meta[namespace] {
+		if value == nil {
+			delete(results, key)
+		} else {
+			results[key] = append([]byte(nil), value...)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,568 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// DefaultReplicaMaxLag 未配置时副本允许落后的最长时间，超过后复制状态报告异常
+const DefaultReplicaMaxLag = 30 * time.Second
+
+// 同步副本失败后重试的间隔，每次失败加倍，直到上限
+const (
+	replicaRetryMin = time.Second
+	replicaRetryMax = 30 * time.Second
+)
+
+// ReplicaStatus 单个副本的同步状态
+type ReplicaStatus struct {
+	Name       string     `json:"name"`                   // 副本名称（存储类型和数据文件路径或主机）
+	Pending    int        `json:"pending"`                // 等待同步的待办事项和附属数据数
+	Resync     bool       `json:"resync"`                 // 是否等待全量同步
+	LagSeconds float64    `json:"lag_seconds"`            // 最早一条未同步的修改距今的秒数，已追上主存储时为0
+	Synced     int64      `json:"synced"`                 // 已同步的修改数（全量同步计为一次）
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"` // 最近一次同步成功的时间
+	Failures   int64      `json:"failures"`               // 同步失败的次数
+	LastError  string     `json:"last_error,omitempty"`   // 最近一次同步失败的原因，之后同步成功时清空
+	Healthy    bool       `json:"healthy"`                // 落后时间不超过上限且最近一次同步没有失败
+}
+
+// ReplicationStatus 全部副本的同步状态
+type ReplicationStatus struct {
+	Healthy       bool            `json:"healthy"`         // 全部副本是否健康
+	MaxLagSeconds float64         `json:"max_lag_seconds"` // 副本允许落后的最长时间（秒）
+	Replicas      []ReplicaStatus `json:"replicas"`        // 各副本的状态，按配置顺序排列
+}
+
+// metaRef 附属数据的命名空间和键
+type metaRef struct {
+	namespace string
+	key       string
+}
+
+// replicaChanges 等待同步到副本的修改，只记录修改过的事项ID和附属数据的键，同步时从主存储读取最新的内容
+type replicaChanges struct {
+	full  bool             // 是否需要全量同步，全量同步包含全部修改
+	todos map[int]bool     // 修改过的事项ID
+	meta  map[metaRef]bool // 修改过的附属数据
+}
+
+// newReplicaChanges 创建空的修改集合
+func newReplicaChanges() *replicaChanges {
+	return &replicaChanges{todos: make(map[int]bool), meta: make(map[metaRef]bool)}
+}
+
+// size 修改的数量，全量同步计为一次
+func (c *replicaChanges) size() int {
+	if c.full {
+		return 1
+	}
+	return len(c.todos) + len(c.meta)
+}
+
+// merge 合并另一组修改
+func (c *replicaChanges) merge(other *replicaChanges) {
+	c.full = c.full || other.full
+	for id := range other.todos {
+		c.todos[id] = true
+	}
+	for ref := range other.meta {
+		c.meta[ref] = true
+	}
+}
+
+// replica 一个副本和它等待同步的修改
+type replica struct {
+	name  string
+	store TodoStore
+	wake  chan struct{} // 有新的修改时发送信号，容量为1
+
+	mu       sync.Mutex
+	pending  *replicaChanges
+	since    time.Time // 最早一条未同步的修改的时间，没有待同步修改时为零值
+	applying time.Time // 正在写入副本的修改中最早的时间，没有正在写入的修改时为零值
+	synced   int64
+	lastSync time.Time
+	failures int64
+	lastErr  string
+}
+
+// ReplicatedStore 复制装饰器，写操作同步写入主存储，再异步复制到一个或多个副本（例如内存主存储加文件备份）
+// 读操作只访问主存储。复制的内容与备份相同：全部待办事项和 MigrateNamespaces 中的附属数据；
+// 每个副本由单独的协程同步，只记录修改过的事项ID和附属数据的键，同步时从主存储读取最新内容，
+// 同一个事项短时间内的多次修改只同步一次。创建时和 Resync 后先把主存储的全部数据同步到副本。
+// 同步失败时保留未同步的修改并按退避间隔重试，不影响主存储的读写
+type ReplicatedStore struct {
+	primary  TodoStore
+	replicas []*replica
+	maxLag   time.Duration
+
+	changes *replicaChanges // 事务中记录的修改，提交后统一加入各副本的队列；不在事务中时为nil
+
+	stop      chan struct{}
+	done      sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewReplicatedStore 创建复制装饰器，names 为各副本在状态中显示的名称，与 secondaries 一一对应
+// maxLag 为副本允许落后的最长时间，不大于0时使用 DefaultReplicaMaxLag。
+// 创建后每个副本立即开始全量同步，调用方应在退出时 Close，关闭前会尽量同步剩余的修改
+func NewReplicatedStore(primary TodoStore, secondaries []TodoStore, names []string, maxLag time.Duration) *ReplicatedStore {
+	if maxLag <= 0 {
+		maxLag = DefaultReplicaMaxLag
+	}
+	s := &ReplicatedStore{primary: primary, maxLag: maxLag, stop: make(chan struct{})}
+	for i, secondary := range secondaries {
+		name := fmt.Sprintf("replica-%d", i+1)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		r := &replica{name: name, store: secondary, wake: make(chan struct{}, 1), pending: newReplicaChanges()}
+		s.replicas = append(s.replicas, r)
+	}
+
+	full := newReplicaChanges()
+	full.full = true
+	s.enqueue(full)
+	for _, r := range s.replicas {
+		s.done.Add(1)
+		go s.run(r)
+	}
+	return s
+}
+
+// FindReplicated 逐层查找复制装饰器，没有配置副本时返回nil
+func FindReplicated(s TodoStore) *ReplicatedStore {
+	for {
+		if replicated, ok := s.(*ReplicatedStore); ok {
+			return replicated
+		}
+		wrapper, ok := s.(Wrapper)
+		if !ok {
+			return nil
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// Unwrap 返回主存储
+func (s *ReplicatedStore) Unwrap() TodoStore {
+	return s.primary
+}
+
+// Status 返回各副本的同步状态
+func (s *ReplicatedStore) Status() *ReplicationStatus {
+	now := time.Now()
+	status := &ReplicationStatus{
+		Healthy:       true,
+		MaxLagSeconds: s.maxLag.Seconds(),
+		Replicas:      make([]ReplicaStatus, len(s.replicas)),
+	}
+	for i, r := range s.replicas {
+		r.mu.Lock()
+		item := ReplicaStatus{
+			Name:      r.name,
+			Pending:   len(r.pending.todos) + len(r.pending.meta),
+			Resync:    r.pending.full,
+			Synced:    r.synced,
+			Failures:  r.failures,
+			LastError: r.lastErr,
+		}
+		oldest := r.since
+		if !r.applying.IsZero() && (oldest.IsZero() || r.applying.Before(oldest)) {
+			oldest = r.applying
+		}
+		lag := time.Duration(0)
+		if !oldest.IsZero() {
+			lag = now.Sub(oldest)
+		}
+		if !r.lastSync.IsZero() {
+			lastSync := r.lastSync
+			item.LastSyncAt = &lastSync
+		}
+		r.mu.Unlock()
+
+		item.LagSeconds = lag.Round(time.Millisecond).Seconds()
+		item.Healthy = lag <= s.maxLag && item.LastError == ""
+		status.Healthy = status.Healthy && item.Healthy
+		status.Replicas[i] = item
+	}
+	return status
+}
+
+// Resync 把主存储的全部数据重新同步到每个副本，用于副本被外部修改或怀疑不一致时
+func (s *ReplicatedStore) Resync() {
+	full := newReplicaChanges()
+	full.full = true
+	s.enqueue(full)
+}
+
+// enqueue 把修改加入每个副本的队列并唤醒同步协程
+func (s *ReplicatedStore) enqueue(changes *replicaChanges) {
+	if changes.size() == 0 {
+		return
+	}
+	now := time.Now()
+	for _, r := range s.replicas {
+		r.mu.Lock()
+		r.pending.merge(changes)
+		if r.since.IsZero() {
+			r.since = now
+		}
+		r.mu.Unlock()
+
+		select {
+		case r.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// record 记录写操作修改过的事项和附属数据，事务中先记录下来，提交后再加入队列
+func (s *ReplicatedStore) record(ids []int, meta ...metaRef) {
+	changes := s.changes
+	if changes == nil {
+		changes = newReplicaChanges()
+	}
+	for _, id := range ids {
+		changes.todos[id] = true
+	}
+	for _, ref := range meta {
+		if slices.Contains(MigrateNamespaces, ref.namespace) {
+			changes.meta[ref] = true
+		}
+	}
+	if s.changes == nil {
+		s.enqueue(changes)
+	}
+}
+
+// todoIDs 返回待办事项的ID
+func todoIDs(todos []*models.Todo) []int {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+// run 副本的同步协程：有新的修改时同步，失败后按退避间隔重试；关闭时最后同步一次后退出
+func (s *ReplicatedStore) run(r *replica) {
+	defer s.done.Done()
+
+	backoff := replicaRetryMin
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-s.stop:
+			if err := s.sync(r); err != nil {
+				log.Printf("⚠️ 关闭前同步副本 %s 失败，未同步的修改已丢失: %v", r.name, err)
+			}
+			return
+		case <-r.wake:
+		case <-retry:
+		}
+
+		if err := s.sync(r); err != nil {
+			log.Printf("⚠️ 同步副本 %s 失败，%v 后重试: %v", r.name, backoff, err)
+			retry = time.After(backoff)
+			backoff = min(backoff*2, replicaRetryMax)
+			continue
+		}
+		retry, backoff = nil, replicaRetryMin
+	}
+}
+
+// sync 取出副本等待同步的全部修改并写入副本，失败时把这些修改放回队列
+func (s *ReplicatedStore) sync(r *replica) error {
+	r.mu.Lock()
+	changes, since := r.pending, r.since
+	r.pending, r.since = newReplicaChanges(), time.Time{}
+	if changes.size() > 0 {
+		r.applying = since
+	}
+	r.mu.Unlock()
+	if changes.size() == 0 {
+		return nil
+	}
+
+	err := s.apply(r.store, changes)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applying = time.Time{}
+	if err != nil {
+		r.pending.merge(changes)
+		if r.since.IsZero() || since.Before(r.since) {
+			r.since = since
+		}
+		r.failures++
+		r.lastErr = err.Error()
+		return err
+	}
+	r.synced += int64(changes.size())
+	r.lastSync = time.Now()
+	r.lastErr = ""
+	return nil
+}
+
+// apply 把一组修改写入副本：全量同步时用主存储的快照替换副本的全部数据，
+// 否则在副本的一个事务中按主存储的当前内容写入或删除修改过的事项和附属数据
+func (s *ReplicatedStore) apply(secondary TodoStore, changes *replicaChanges) error {
+	if changes.full {
+		snapshot, err := Dump(s.primary)
+		if err != nil {
+			return fmt.Errorf("导出主存储失败: %w", err)
+		}
+		return RestoreSnapshot(secondary, snapshot)
+	}
+
+	var loads []*models.Todo
+	var deletes []int
+	for id := range changes.todos {
+		todo, err := s.primary.GetTodoByID(id)
+		switch {
+		case errors.Is(err, ErrTodoNotFound):
+			deletes = append(deletes, id)
+		case err != nil:
+			return fmt.Errorf("读取主存储的待办事项 %d 失败: %w", id, err)
+		default:
+			loads = append(loads, todo)
+		}
+	}
+	values := make(map[metaRef][]byte, len(changes.meta))
+	for ref := range changes.meta {
+		value, err := s.primary.GetMeta(ref.namespace, ref.key)
+		if err != nil && !errors.Is(err, ErrMetaNotFound) {
+			return fmt.Errorf("读取主存储的附属数据 %s/%s 失败: %w", ref.namespace, ref.key, err)
+		}
+		values[ref] = value
+	}
+
+	return secondary.Transaction(func(tx TodoStore) error {
+		// 已有的事项先删除，再按主存储的内容原样写入，保留ID、时间戳和版本号
+		for _, todo := range loads {
+			deletes = append(deletes, todo.ID)
+		}
+		for _, id := range deletes {
+			if err := tx.DeleteTodo(id); err != nil && !errors.Is(err, ErrTodoNotFound) {
+				return err
+			}
+		}
+		if len(loads) > 0 {
+			loader, ok := tx.(Loader)
+			if !ok {
+				return ErrLoadUnsupported
+			}
+			if err := loader.LoadTodos(loads); err != nil {
+				return err
+			}
+		}
+		for ref, value := range values {
+			var err error
+			if value == nil {
+				err = tx.DeleteMeta(ref.namespace, ref.key)
+			} else {
+				err = tx.PutMeta(ref.namespace, ref.key, value)
+			}
+			if err != nil && !errors.Is(err, ErrMetaNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAllTodos 获取所有待办事项
+func (s *ReplicatedStore) GetAllTodos() ([]*models.Todo, error) {
+	return s.primary.GetAllTodos()
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (s *ReplicatedStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	return s.primary.ListTodos(opts)
+}
+
+// GetTodoByID 根据ID获取单个待办事项
+func (s *ReplicatedStore) GetTodoByID(id int) (*models.Todo, error) {
+	return s.primary.GetTodoByID(id)
+}
+
+// CreateTodo 创建待办事项
+func (s *ReplicatedStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := s.primary.CreateTodo(req)
+	if err == nil {
+		s.record([]int{todo.ID})
+	}
+	return todo, err
+}
+
+// UpdateTodo 更新待办事项
+func (s *ReplicatedStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := s.primary.UpdateTodo(id, req)
+	if err == nil {
+		s.record([]int{id})
+	}
+	return todo, err
+}
+
+// DeleteTodo 删除待办事项
+func (s *ReplicatedStore) DeleteTodo(id int) error {
+	err := s.primary.DeleteTodo(id)
+	if err == nil {
+		s.record([]int{id})
+	}
+	return err
+}
+
+// SaveTodo 保存完整的待办事项
+func (s *ReplicatedStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	saved, err := s.primary.SaveTodo(todo)
+	if err == nil {
+		s.record([]int{saved.ID})
+	}
+	return saved, err
+}
+
+// SearchTodos 搜索待办事项
+func (s *ReplicatedStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	return s.primary.SearchTodos(query, category, completed)
+}
+
+// BulkCreate 批量创建待办事项
+func (s *ReplicatedStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	todos, err := s.primary.BulkCreate(reqs)
+	if err == nil {
+		s.record(todoIDs(todos))
+	}
+	return todos, err
+}
+
+// BulkUpdate 批量更新待办事项
+func (s *ReplicatedStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	todos, err := s.primary.BulkUpdate(updates)
+	if err == nil {
+		s.record(updateIDs(updates))
+	}
+	return todos, err
+}
+
+// BulkDelete 批量删除待办事项
+func (s *ReplicatedStore) BulkDelete(ids []int) error {
+	err := s.primary.BulkDelete(ids)
+	if err == nil {
+		s.record(ids)
+	}
+	return err
+}
+
+// GetStats 获取统计信息
+func (s *ReplicatedStore) GetStats() (map[string]interface{}, error) {
+	return s.primary.GetStats()
+}
+
+// Transaction 在主存储的事务中执行fn，事务提交后把其中修改过的事项和附属数据加入复制队列
+// 嵌套事务中的修改由最外层的事务统一处理
+func (s *ReplicatedStore) Transaction(fn func(tx TodoStore) error) error {
+	if s.changes != nil {
+		return s.primary.Transaction(func(tx TodoStore) error {
+			return fn(&ReplicatedStore{primary: tx, replicas: s.replicas, maxLag: s.maxLag, changes: s.changes})
+		})
+	}
+
+	changes := newReplicaChanges()
+	err := s.primary.Transaction(func(tx TodoStore) error {
+		return fn(&ReplicatedStore{primary: tx, replicas: s.replicas, maxLag: s.maxLag, changes: changes})
+	})
+	if err == nil {
+		s.enqueue(changes)
+	}
+	return err
+}
+
+// Export 把主存储的全部数据以快照JSON写入w
+func (s *ReplicatedStore) Export(w io.Writer) error {
+	return s.primary.Export(w)
+}
+
+// Import 从r读取快照并替换主存储的全部数据，提交后同步到副本
+func (s *ReplicatedStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// GetMeta 读取附属数据
+func (s *ReplicatedStore) GetMeta(namespace, key string) ([]byte, error) {
+	return s.primary.GetMeta(namespace, key)
+}
+
+// PutMeta 写入附属数据
+func (s *ReplicatedStore) PutMeta(namespace, key string, value []byte) error {
+	err := s.primary.PutMeta(namespace, key, value)
+	if err == nil {
+		s.record(nil, metaRef{namespace, key})
+	}
+	return err
+}
+
+// DeleteMeta 删除附属数据
+func (s *ReplicatedStore) DeleteMeta(namespace, key string) error {
+	err := s.primary.DeleteMeta(namespace, key)
+	if err == nil {
+		s.record(nil, metaRef{namespace, key})
+	}
+	return err
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *ReplicatedStore) ListMeta(namespace string) (map[string][]byte, error) {
+	return s.primary.ListMeta(namespace)
+}
+
+// LoadTodos 按原样写入待办事项，主存储不支持时返回 ErrLoadUnsupported
+func (s *ReplicatedStore) LoadTodos(todos []*models.Todo) error {
+	loader, ok := s.primary.(Loader)
+	if !ok {
+		return ErrLoadUnsupported
+	}
+	if err := loader.LoadTodos(todos); err != nil {
+		return err
+	}
+	s.record(todoIDs(todos))
+	return nil
+}
+
+// Ping 检查主存储是否可用，副本的状态通过 Status 查看
+func (s *ReplicatedStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.primary.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Close 尽量同步剩余的修改后停止同步协程，再关闭副本和主存储
+func (s *ReplicatedStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.done.Wait()
+		for _, r := range s.replicas {
+			if closer, ok := r.store.(io.Closer); ok {
+				if closeErr := closer.Close(); closeErr != nil {
+					log.Printf("⚠️ 关闭副本 %s 失败: %v", r.name, closeErr)
+				}
+			}
+		}
+		if closer, ok := s.primary.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// RulesNamespace 自动分类规则在附属数据中的命名空间，键为规则ID
+const RulesNamespace = "rules"
+
+// GetRule 根据ID获取自动分类规则，不存在时返回 ErrMetaNotFound
+func GetRule(s MetaStore, id string) (*models.Rule, error) {
+	data, err := s.GetMeta(RulesNamespace, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var rule models.Rule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SaveRule 保存自动分类规则（已存在则覆盖）
+func SaveRule(s MetaStore, rule *models.Rule) error {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(RulesNamespace, rule.ID, data)
+}
+
+// DeleteRule 删除自动分类规则
+func DeleteRule(s MetaStore, id string) error {
+	return s.DeleteMeta(RulesNamespace, id)
+}
+
+// ListRules 列出所有自动分类规则，按匹配顺序排列（顺序相同时按创建时间）
+func ListRules(s MetaStore) ([]*models.Rule, error) {
+	items, err := s.ListMeta(RulesNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.Rule, 0, len(items))
+	for _, data := range items {
+		var rule models.Rule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, err
+		}
+		results = append(results, &rule)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Order != results[j].Order {
+			return results[i].Order < results[j].Order
+		}
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
@@ -0,0 +1,451 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// MaxSearchFuzziness 全文搜索允许的最大编辑距离
+const MaxSearchFuzziness = 2
+
+// searchFields 参与全文搜索的字段及其权重，标题中的匹配比描述中的更相关
+var searchFields = []struct {
+	name  string
+	boost float64
+}{
+	{"title", 3},
+	{"category", 2},
+	{"description", 1},
+}
+
+// SearchHit 全文搜索的一条结果
+type SearchHit struct {
+	ID    int     `json:"id"`    // 待办事项ID
+	Score float64 `json:"score"` // 相关度得分，越大越相关
+}
+
+// Searcher 可选接口，由维护全文索引的存储装饰器实现
+type Searcher interface {
+	Search(query string) ([]SearchHit, error) // 返回匹配的事项，按相关度从高到低排列
+}
+
+// FindSearcher 逐层查找实现了 Searcher 的存储，没有启用全文索引时返回nil
+func FindSearcher(s TodoStore) Searcher {
+	for {
+		if searcher, ok := s.(Searcher); ok {
+			return searcher
+		}
+		wrapper, ok := s.(Wrapper)
+		if !ok {
+			return nil
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// indexDocument 写入全文索引的文档
+type indexDocument struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+}
+
+// IndexedStore 全文索引装饰器，在内存中为标题、描述和分类维护 bleve 索引
+// 创建时从被包装的存储建立索引，之后通过本存储执行的写操作同步更新索引；事务提交后重新索引事务中修改过的事项。
+// 多个实例共用数据库时，其它实例的修改要等本实例重启后才能搜索到。
+// 文本使用 CJK 分析器分词（中文按相邻两字切分，英文按单词切分并转为小写），支持按编辑距离的模糊匹配
+type IndexedStore struct {
+	inner     TodoStore
+	index     bleve.Index
+	fuzziness int
+
+	touched map[int]bool // 事务中修改过的事项ID，不在事务中时为nil
+}
+
+// NewIndexedStore 创建全文索引装饰器，并为被包装存储中的全部事项建立索引
+// fuzziness 为模糊匹配允许的编辑距离（0-2），0表示只做精确匹配
+func NewIndexedStore(inner TodoStore, fuzziness int) (*IndexedStore, error) {
+	if fuzziness < 0 || fuzziness > MaxSearchFuzziness {
+		return nil, fmt.Errorf("模糊匹配的编辑距离必须在0-%d之间", MaxSearchFuzziness)
+	}
+
+	index, err := bleve.NewMemOnly(newSearchMapping())
+	if err != nil {
+		return nil, fmt.Errorf("创建全文索引失败: %w", err)
+	}
+	s := &IndexedStore{inner: inner, index: index, fuzziness: fuzziness}
+
+	todos, err := inner.GetAllTodos()
+	if err != nil {
+		index.Close()
+		return nil, fmt.Errorf("读取待办事项失败: %w", err)
+	}
+	if err := s.indexTodos(todos); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("建立全文索引失败: %w", err)
+	}
+	return s, nil
+}
+
+// newSearchMapping 创建索引映射，所有字段使用 CJK 分析器，只建索引不保存原文
+func newSearchMapping() mapping.IndexMapping {
+	field := bleve.NewTextFieldMapping()
+	field.Analyzer = cjk.AnalyzerName
+	field.Store = false
+	field.IncludeInAll = false
+
+	document := bleve.NewDocumentMapping()
+	for _, f := range searchFields {
+		document.AddFieldMappingsAt(f.name, field)
+	}
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = document
+	indexMapping.DefaultAnalyzer = cjk.AnalyzerName
+	return indexMapping
+}
+
+// Unwrap 返回被包装的存储
+func (s *IndexedStore) Unwrap() TodoStore {
+	return s.inner
+}
+
+// Search 全文搜索标题、描述和分类，返回按相关度从高到低排列的事项ID
+// 搜索词经过分词后任意一个词匹配即可，匹配的词越多、出现在权重越高的字段中越相关；
+// 完整短语匹配和精确匹配的得分高于模糊匹配
+func (s *IndexedStore) Search(text string) ([]SearchHit, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []SearchHit{}, nil
+	}
+	count, err := s.index.DocCount()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return []SearchHit{}, nil
+	}
+
+	queries := make([]query.Query, 0, len(searchFields)*3)
+	for _, field := range searchFields {
+		phrase := bleve.NewMatchPhraseQuery(text)
+		phrase.SetField(field.name)
+		phrase.SetBoost(field.boost * 4)
+		queries = append(queries, phrase)
+
+		exact := bleve.NewMatchQuery(text)
+		exact.SetField(field.name)
+		exact.SetBoost(field.boost * 2)
+		queries = append(queries, exact)
+
+		if s.fuzziness > 0 {
+			fuzzy := bleve.NewMatchQuery(text)
+			fuzzy.SetField(field.name)
+			fuzzy.SetFuzziness(s.fuzziness)
+			fuzzy.SetPrefix(1) // 首字符必须相同，避免短词匹配到大量无关的词
+			fuzzy.SetBoost(field.boost * 0.5)
+			queries = append(queries, fuzzy)
+		}
+	}
+
+	result, err := s.index.Search(bleve.NewSearchRequestOptions(bleve.NewDisjunctionQuery(queries...), int(count), 0, false))
+	if err != nil {
+		return nil, fmt.Errorf("全文搜索失败: %w", err)
+	}
+
+	hits := make([]SearchHit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		id, err := strconv.Atoi(match.ID)
+		if err != nil {
+			continue
+		}
+		hits = append(hits, SearchHit{ID: id, Score: match.Score})
+	}
+	// 得分相同时按ID升序，保证结果稳定
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits, nil
+}
+
+// indexTodos 把待办事项写入索引，已存在的文档被替换
+func (s *IndexedStore) indexTodos(todos []*models.Todo) error {
+	batch := s.index.NewBatch()
+	for _, todo := range todos {
+		doc := indexDocument{Title: todo.Title, Description: todo.Description, Category: todo.Category}
+		if err := batch.Index(strconv.Itoa(todo.ID), doc); err != nil {
+			return err
+		}
+	}
+	return s.index.Batch(batch)
+}
+
+// unindexTodos 从索引中删除待办事项
+func (s *IndexedStore) unindexTodos(ids []int) error {
+	batch := s.index.NewBatch()
+	for _, id := range ids {
+		batch.Delete(strconv.Itoa(id))
+	}
+	return s.index.Batch(batch)
+}
+
+// afterWrite 写操作成功后更新索引，事务中只记录修改过的事项，提交后再统一更新
+// 索引更新失败不影响已经成功的写操作，只记录日志
+func (s *IndexedStore) afterWrite(saved []*models.Todo, deleted []int) {
+	if s.touched != nil {
+		for _, todo := range saved {
+			s.touched[todo.ID] = true
+		}
+		for _, id := range deleted {
+			s.touched[id] = true
+		}
+		return
+	}
+
+	if len(saved) > 0 {
+		if err := s.indexTodos(saved); err != nil {
+			log.Printf("⚠️ 更新全文索引失败: %v", err)
+		}
+	}
+	if len(deleted) > 0 {
+		if err := s.unindexTodos(deleted); err != nil {
+			log.Printf("⚠️ 更新全文索引失败: %v", err)
+		}
+	}
+}
+
+// GetAllTodos 获取所有待办事项
+func (s *IndexedStore) GetAllTodos() ([]*models.Todo, error) {
+	return s.inner.GetAllTodos()
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+func (s *IndexedStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	return s.inner.ListTodos(opts)
+}
+
+// GetTodoByID 根据ID获取单个待办事项
+func (s *IndexedStore) GetTodoByID(id int) (*models.Todo, error) {
+	return s.inner.GetTodoByID(id)
+}
+
+// CreateTodo 创建新的待办事项
+func (s *IndexedStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := s.inner.CreateTodo(req)
+	if err != nil {
+		return nil, err
+	}
+	s.afterWrite([]*models.Todo{todo}, nil)
+	return todo, nil
+}
+
+// UpdateTodo 更新待办事项
+func (s *IndexedStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	todo, err := s.inner.UpdateTodo(id, req)
+	if err != nil {
+		return nil, err
+	}
+	s.afterWrite([]*models.Todo{todo}, nil)
+	return todo, nil
+}
+
+// DeleteTodo 删除待办事项
+func (s *IndexedStore) DeleteTodo(id int) error {
+	if err := s.inner.DeleteTodo(id); err != nil {
+		return err
+	}
+	s.afterWrite(nil, []int{id})
+	return nil
+}
+
+// SaveTodo 保存完整的待办事项
+func (s *IndexedStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	saved, err := s.inner.SaveTodo(todo)
+	if err != nil {
+		return nil, err
+	}
+	s.afterWrite([]*models.Todo{saved}, nil)
+	return saved, nil
+}
+
+// SearchTodos 使用全文索引搜索待办事项，结果按相关度从高到低排列
+// 搜索词为空时由被包装的存储处理；索引中已经不存在于存储的事项被忽略
+func (s *IndexedStore) SearchTodos(text string, category string, completed *bool) ([]*models.Todo, error) {
+	if strings.TrimSpace(text) == "" {
+		return s.inner.SearchTodos(text, category, completed)
+	}
+
+	hits, err := s.Search(text)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := s.inner.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	results := make([]*models.Todo, 0, len(hits))
+	for _, hit := range hits {
+		todo, exists := byID[hit.ID]
+		if !exists {
+			continue
+		}
+		if category != "" && todo.Category != category {
+			continue
+		}
+		if completed != nil && todo.Completed != *completed {
+			continue
+		}
+		results = append(results, todo)
+	}
+	return results, nil
+}
+
+// BulkCreate 批量创建待办事项
+func (s *IndexedStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	todos, err := s.inner.BulkCreate(reqs)
+	if err != nil {
+		return nil, err
+	}
+	s.afterWrite(todos, nil)
+	return todos, nil
+}
+
+// BulkUpdate 批量更新待办事项
+func (s *IndexedStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	todos, err := s.inner.BulkUpdate(updates)
+	if err != nil {
+		return nil, err
+	}
+	s.afterWrite(todos, nil)
+	return todos, nil
+}
+
+// BulkDelete 批量删除待办事项
+func (s *IndexedStore) BulkDelete(ids []int) error {
+	if err := s.inner.BulkDelete(ids); err != nil {
+		return err
+	}
+	s.afterWrite(nil, ids)
+	return nil
+}
+
+// GetStats 获取统计信息
+func (s *IndexedStore) GetStats() (map[string]interface{}, error) {
+	return s.inner.GetStats()
+}
+
+// Transaction 在被包装存储的事务中执行fn，事务提交后重新索引其中修改过的事项
+// 嵌套事务中修改的事项由最外层的事务统一处理
+func (s *IndexedStore) Transaction(fn func(tx TodoStore) error) error {
+	if s.touched != nil {
+		return s.inner.Transaction(func(tx TodoStore) error {
+			return fn(&IndexedStore{inner: tx, index: s.index, fuzziness: s.fuzziness, touched: s.touched})
+		})
+	}
+
+	touched := make(map[int]bool)
+	err := s.inner.Transaction(func(tx TodoStore) error {
+		return fn(&IndexedStore{inner: tx, index: s.index, fuzziness: s.fuzziness, touched: touched})
+	})
+	if err != nil || len(touched) == 0 {
+		return err
+	}
+
+	// 按提交后的数据更新索引，事务中先修改后删除的事项从索引中删除
+	var saved []*models.Todo
+	var deleted []int
+	for id := range touched {
+		todo, err := s.inner.GetTodoByID(id)
+		switch {
+		case err == nil:
+			saved = append(saved, todo)
+		case errors.Is(err, ErrTodoNotFound):
+			deleted = append(deleted, id)
+		default:
+			log.Printf("⚠️ 更新全文索引失败（事项 %d）: %v", id, err)
+		}
+	}
+	s.afterWrite(saved, deleted)
+	return nil
+}
+
+// Export 把全部数据以快照JSON写入w
+func (s *IndexedStore) Export(w io.Writer) error {
+	return s.inner.Export(w)
+}
+
+// Import 从r读取快照并替换全部数据，事务提交后按导入的数据更新索引
+func (s *IndexedStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// GetMeta 读取附属数据
+func (s *IndexedStore) GetMeta(namespace, key string) ([]byte, error) {
+	return s.inner.GetMeta(namespace, key)
+}
+
+// PutMeta 写入附属数据
+func (s *IndexedStore) PutMeta(namespace, key string, value []byte) error {
+	return s.inner.PutMeta(namespace, key, value)
+}
+
+// DeleteMeta 删除附属数据
+func (s *IndexedStore) DeleteMeta(namespace, key string) error {
+	return s.inner.DeleteMeta(namespace, key)
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *IndexedStore) ListMeta(namespace string) (map[string][]byte, error) {
+	return s.inner.ListMeta(namespace)
+}
+
+// LoadTodos 按原样写入待办事项，被包装的存储不支持时返回 ErrLoadUnsupported
+func (s *IndexedStore) LoadTodos(todos []*models.Todo) error {
+	loader, ok := s.inner.(Loader)
+	if !ok {
+		return ErrLoadUnsupported
+	}
+	if err := loader.LoadTodos(todos); err != nil {
+		return err
+	}
+	s.afterWrite(todos, nil)
+	return nil
+}
+
+// Ping 检查被包装的存储是否可用
+func (s *IndexedStore) Ping(ctx context.Context) error {
+	if pinger, ok := s.inner.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Close 关闭全文索引和被包装的存储
+func (s *IndexedStore) Close() error {
+	s.index.Close()
+	if closer, ok := s.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// LoadSeed 按配置返回 memory、file 存储首次运行时写入的初始数据
+// 配置了 no_seed 时返回空快照（不写入任何数据），配置了 seed_file 时从文件读取，否则返回nil，表示写入内置的示例数据
+func LoadSeed(cfg config.DatabaseConfig) (*models.Snapshot, error) {
+	switch {
+	case cfg.NoSeed:
+		return &models.Snapshot{Version: models.SnapshotVersion, NextID: 1}, nil
+	case cfg.SeedFile == "":
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cfg.SeedFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取初始数据文件失败: %w", err)
+	}
+	snapshot, err := ParseSeed(data, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("初始数据文件 %s: %w", cfg.SeedFile, err)
+	}
+	return snapshot, nil
+}
+
+// ParseSeed 解析初始数据：与备份文件相同的快照格式，或者只包含待办事项的数组
+// 没有ID的事项按顺序分配大于已有最大ID的ID，没有创建时间的事项使用 now，没有更新时间的使用创建时间
+func ParseSeed(data []byte, now time.Time) (*models.Snapshot, error) {
+	snapshot := &models.Snapshot{Version: models.SnapshotVersion}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &snapshot.Todos); err != nil {
+			return nil, fmt.Errorf("解析待办事项失败: %w", err)
+		}
+	} else if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("解析快照失败: %w", err)
+	}
+
+	maxID := snapshot.MaxTodoID()
+	for i, todo := range snapshot.Todos {
+		if todo == nil || strings.TrimSpace(todo.Title) == "" {
+			return nil, fmt.Errorf("第 %d 个待办事项缺少标题", i+1)
+		}
+		if todo.ID < 0 {
+			return nil, ErrInvalidID
+		}
+		if todo.ID == 0 {
+			maxID++
+			todo.ID = maxID
+		}
+		if todo.CreatedAt.IsZero() {
+			todo.CreatedAt = now
+		}
+		if todo.UpdatedAt.IsZero() {
+			todo.UpdatedAt = todo.CreatedAt
+		}
+	}
+	if err := checkUniqueIDs(snapshotIDs(snapshot)); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// snapshotIDs 返回快照中全部待办事项的ID
+func snapshotIDs(snapshot *models.Snapshot) []int {
+	ids := make([]int, len(snapshot.Todos))
+	for i, todo := range snapshot.Todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+// SeedWith 写入初始数据，snapshot 为nil时写入内置的示例数据
+func (s *MemoryStore) SeedWith(snapshot *models.Snapshot) error {
+	if snapshot == nil {
+		s.Seed()
+		return nil
+	}
+	return s.Restore(snapshot)
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// ShareLinksNamespace 公开分享链接在附属数据中的命名空间，键为访问令牌
+const ShareLinksNamespace = "share_links"
+
+// GetShareLink 根据令牌获取分享链接
+// 令牌不存在（或已被撤销）时返回 ErrMetaNotFound
+func GetShareLink(s MetaStore, token string) (*models.ShareLink, error) {
+	data, err := s.GetMeta(ShareLinksNamespace, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var link models.ShareLink
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// SaveShareLink 保存分享链接
+func SaveShareLink(s MetaStore, link *models.ShareLink) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(ShareLinksNamespace, link.Token, data)
+}
+
+// DeleteShareLink 删除（撤销）分享链接
+func DeleteShareLink(s MetaStore, token string) error {
+	return s.DeleteMeta(ShareLinksNamespace, token)
+}
+
+// ListShareLinks 列出所有分享链接，按创建时间排序
+func ListShareLinks(s MetaStore) ([]*models.ShareLink, error) {
+	items, err := s.ListMeta(ShareLinksNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.ShareLink, 0, len(items))
+	for _, data := range items {
+		var link models.ShareLink
+		if err := json.Unmarshal(data, &link); err != nil {
+			return nil, err
+		}
+		results = append(results, &link)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+		return results[i].Token < results[j].Token
+	})
+	return results, nil
+}
@@ -0,0 +1,922 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// sqlDialect SQL方言
+// 不同数据库在字段类型、占位符、时间表示等方面存在差异，
+// sqlStore 中的通用逻辑通过方言处理这些差异，各数据库只需提供自己的方言定义
+type sqlDialect struct {
+	name        string                             // 方言名称，用于错误信息
+	migrations  [][]string                         // 数据库结构迁移，每个版本包含若干条语句，按顺序执行
+	numbered    bool                               // 占位符是否使用编号形式（$1, $2...），否则使用 ?
+	returningID bool                               // 插入时是否支持 RETURNING id，否则使用 LastInsertId
+	keyColumn   string                             // 附属数据表中键字段的写法（MySQL中key是保留字，需要转义）
+	upsertMeta  string                             // 写入附属数据的语句（已存在则覆盖）
+	insertLock  string                             // 创建锁的语句（锁已存在时不做任何修改）
+	contains    func(column string) string         // 生成"字段包含参数字符串"的条件表达式
+	pattern     func(query string) string          // 将查询字符串转换为 contains 使用的参数，为nil时直接使用查询字符串
+	collate     string                             // 按标题排序时追加的排序规则，使文本按字节比较，为空时使用数据库默认规则
+	resetSeq    string                             // 写入指定ID的数据后把ID序列推进到最大ID的语句，为空表示数据库会自动推进
+	lockRows    string                             // 事务中读取单个事项时追加的行锁子句，为空表示数据库本身保证事务串行执行
+	timeValue   func(t time.Time) interface{}      // 将时间转换为写入数据库的值
+	reserveIDs  func(tx *sqlStore, next int) error // 在事务tx中把ID序列推进到之后分配的ID不小于next，已经更大时不变
+}
+
+// todoColumns 查询待办事项时使用的字段列表，与 models.Todo 的 db 标签对应，顺序与 scanTodo 保持一致
+const todoColumns = "id, title, description, completed, priority, category, due_date, created_at, updated_at, links, location, version, completed_at, archived, position, tags, subtasks, remind_at"
+
+// sqlStore 基于 database/sql 的通用存储实现
+// 实现了完整的 TodoStore 接口，SQLite、PostgreSQL、MySQL 等关系型数据库存储都基于它构建
+type sqlStore struct {
+	db      *sql.DB
+	dialect *sqlDialect
+	stmts   map[string]*sql.Stmt // 预编译语句，key为语句名称
+
+	conn  sqlConn              // 执行非预编译查询的连接，事务中为事务本身
+	tx    *sql.Tx              // 当前事务，不在事务中时为nil
+	bound map[string]*sql.Stmt // 已绑定到当前事务的预编译语句
+}
+
+// sqlConn 执行查询的连接，*sql.DB 和 *sql.Tx 都实现了该接口
+type sqlConn interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// stmtFunc 按名称获取预编译语句，在事务中执行时返回绑定到事务的语句
+type stmtFunc func(name string) *sql.Stmt
+
+// sqlStatements 需要预编译的语句，占位符统一使用 ?，预编译前按方言转换
+// update 中 completed_at 需放在 completed 之前赋值：MySQL 按从左到右的顺序赋值，之后的表达式读取到的是新值
+var sqlStatements = map[string]string{
+	"all":    `SELECT ` + todoColumns + ` FROM todos ORDER BY created_at DESC, id DESC`,
+	"get":    `SELECT ` + todoColumns + ` FROM todos WHERE id = ?`,
+	"insert": `INSERT INTO todos (title, description, completed, priority, category, due_date, created_at, updated_at, links, location, completed_at, archived, position, tags, subtasks, remind_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	"update": `UPDATE todos SET completed_at = CASE WHEN ? THEN (CASE WHEN completed THEN completed_at ELSE ? END) ELSE NULL END, title = ?, description = ?, completed = ?, priority = ?, category = ?, due_date = ?, remind_at = ?, updated_at = ?, location = ?, tags = ?, version = version + 1 WHERE id = ? AND (? = 0 OR version = ?)`,
+	"save":   `UPDATE todos SET title = ?, description = ?, completed = ?, priority = ?, category = ?, due_date = ?, updated_at = ?, links = ?, location = ?, completed_at = ?, archived = ?, position = ?, tags = ?, subtasks = ?, remind_at = ?, version = version + 1 WHERE id = ?`,
+	"delete": `DELETE FROM todos WHERE id = ?`,
+	"load":   `INSERT INTO todos (id, title, description, completed, priority, category, due_date, created_at, updated_at, links, location, version, completed_at, archived, position, tags, subtasks, remind_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+}
+
+// newSQLStore 创建通用SQL存储：执行数据库结构迁移并预编译常用语句
+func newSQLStore(db *sql.DB, dialect *sqlDialect) (*sqlStore, error) {
+	s := &sqlStore{db: db, dialect: dialect, stmts: make(map[string]*sql.Stmt), conn: db}
+
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("初始化%s数据库结构失败: %w", dialect.name, err)
+	}
+
+	statements := make(map[string]string, len(sqlStatements)+10)
+	for name, query := range sqlStatements {
+		statements[name] = query
+	}
+	if dialect.returningID {
+		statements["insert"] += ` RETURNING id`
+	}
+	key := dialect.keyColumn
+	statements["meta_get"] = `SELECT value FROM meta WHERE namespace = ? AND ` + key + ` = ?`
+	statements["meta_put"] = dialect.upsertMeta
+	statements["meta_delete"] = `DELETE FROM meta WHERE namespace = ? AND ` + key + ` = ?`
+	statements["meta_list"] = `SELECT ` + key + `, value FROM meta WHERE namespace = ?`
+	statements["lock_insert"] = dialect.insertLock
+	statements["lock_update"] = `UPDATE locks SET owner = ?, expires_at = ? WHERE name = ? AND (owner = ? OR expires_at < ?)`
+	statements["lock_delete"] = `DELETE FROM locks WHERE name = ? AND owner = ?`
+	statements["event_insert"] = `INSERT INTO events (type, todo_id, data, created_at) VALUES (?, ?, ?, ?)`
+	if dialect.returningID {
+		statements["event_insert"] += ` RETURNING seq`
+	}
+	statements["event_list"] = `SELECT seq, type, todo_id, data, created_at FROM events WHERE seq > ? ORDER BY seq LIMIT ?`
+	statements["event_purge"] = `DELETE FROM events WHERE created_at < ?`
+	if dialect.resetSeq != "" {
+		statements["reset_seq"] = dialect.resetSeq
+	}
+	if dialect.lockRows != "" {
+		statements["get_locked"] = sqlStatements["get"] + dialect.lockRows
+	}
+
+	for name, query := range statements {
+		stmt, err := db.Prepare(s.rebind(query))
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("预编译语句 %s 失败: %w", name, err)
+		}
+		s.stmts[name] = stmt
+	}
+
+	return s, nil
+}
+
+// Close 关闭预编译语句和数据库连接
+func (s *sqlStore) Close() error {
+	for _, stmt := range s.stmts {
+		stmt.Close()
+	}
+	return s.db.Close()
+}
+
+// Ping 检查数据库连接是否可用
+func (s *sqlStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// configurePool 按配置设置连接池参数（PostgreSQL、MySQL 等网络数据库使用）
+func configurePool(db *sql.DB, cfg *config.DatabaseConfig) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+	}
+}
+
+// stmt 按名称获取预编译语句，在事务中时返回绑定到事务的语句，同一语句在事务中只绑定一次
+// 事务中读取单个事项时加行锁，保证先读后写的操作不会被其它事务插入修改
+func (s *sqlStore) stmt(name string) *sql.Stmt {
+	if s.tx == nil {
+		return s.stmts[name]
+	}
+	if name == "get" && s.dialect.lockRows != "" {
+		name = "get_locked"
+	}
+	if txStmt, exists := s.bound[name]; exists {
+		return txStmt
+	}
+	txStmt := s.tx.Stmt(s.stmts[name])
+	s.bound[name] = txStmt
+	return txStmt
+}
+
+// begin 开始事务，在绑定到事务的存储上执行fn，fn返回错误时回滚，否则提交
+// 已经在事务中时直接执行fn，嵌套的事务合并到外层事务中
+func (s *sqlStore) begin(fn func(tx *sqlStore) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	txStore := &sqlStore{
+		db:      s.db,
+		dialect: s.dialect,
+		stmts:   s.stmts,
+		conn:    tx,
+		tx:      tx,
+		bound:   make(map[string]*sql.Stmt),
+	}
+
+	if err := fn(txStore); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// inTx 在一个事务中执行fn，fn返回错误时回滚，否则提交
+// fn 通过传入的 stmtFunc 使用绑定到事务的预编译语句
+func (s *sqlStore) inTx(fn func(stmt stmtFunc) error) error {
+	return s.begin(func(tx *sqlStore) error {
+		return fn(tx.stmt)
+	})
+}
+
+// Transaction 在一个数据库事务中执行fn，fn返回错误时回滚
+// fn 中通过 tx 执行的所有操作都在同一个事务中，读取单个事项时加行锁（SQLite 只有一个连接，事务本身就是串行的）
+func (s *sqlStore) Transaction(fn func(tx TodoStore) error) error {
+	return s.begin(func(tx *sqlStore) error {
+		return fn(tx)
+	})
+}
+
+// Export 把全部数据以快照JSON写入w
+func (s *sqlStore) Export(w io.Writer) error {
+	return ExportSnapshot(s, w)
+}
+
+// Import 从r读取快照，在一个数据库事务中替换全部数据
+func (s *sqlStore) Import(r io.Reader) error {
+	return ImportSnapshot(s, r)
+}
+
+// rebind 将语句中的 ? 占位符转换为方言使用的形式
+func (s *sqlStore) rebind(query string) string {
+	if !s.dialect.numbered {
+		return query
+	}
+
+	var builder strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// SchemaChecker 有数据库结构版本的存储，就绪检查据此确认迁移已经全部执行
+type SchemaChecker interface {
+	CheckSchema(ctx context.Context) error // 数据库结构版本低于程序需要的版本时返回错误
+}
+
+// CheckSchema 检查 schema_migrations 中记录的版本是否已达到当前程序的迁移版本
+// 其它实例执行了更新的迁移时版本会更高，不影响当前实例
+func (s *sqlStore) CheckSchema(ctx context.Context) error {
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	if expected := len(s.dialect.migrations); current < expected {
+		return fmt.Errorf("数据库结构版本为 %d，需要 %d", current, expected)
+	}
+	return nil
+}
+
+// migrate 执行尚未执行过的数据库结构迁移
+// 已执行的版本记录在 schema_migrations 表中，每个版本在一个事务中执行
+func (s *sqlStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for i := current; i < len(s.dialect.migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		for _, statement := range s.dialect.migrations[i] {
+			if _, err := tx.Exec(statement); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("迁移版本 %d 失败: %w", i+1, err)
+			}
+		}
+		if _, err := tx.Exec(s.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAllTodos 获取所有待办事项，按创建时间倒序排列
+func (s *sqlStore) GetAllTodos() ([]*models.Todo, error) {
+	rows, err := s.stmt("all").Query()
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// ListTodos 按排序和过滤选项获取待办事项
+// 过滤和排序都在SQL中完成，规则与内存存储一致：字段值相同时按ID升序，没有截止日期的事项无论升序降序都排在最后
+func (s *sqlStore) ListTodos(opts ListOptions) ([]*models.Todo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	keys := opts.sortKeys()
+	if len(keys) == 0 && opts.Filter.IsZero() {
+		return s.GetAllTodos()
+	}
+
+	sqlQuery := `SELECT ` + todoColumns + ` FROM todos`
+	conditions, args := opts.Filter.sqlConditions(s.dialect)
+	if len(conditions) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += ` ORDER BY ` + s.orderBy(keys)
+
+	rows, err := s.conn.Query(s.rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// orderBy 生成排序字段对应的 ORDER BY 子句，最后按ID升序保证结果稳定；没有排序字段时按创建时间倒序
+// 排序字段已经过 validate 检查，可以直接拼接到语句中
+func (s *sqlStore) orderBy(keys []models.SortKey) string {
+	if len(keys) == 0 {
+		return "created_at DESC, id DESC"
+	}
+
+	clauses := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		direction := " ASC"
+		if key.Desc {
+			direction = " DESC"
+		}
+		switch key.Field {
+		case models.SortByID:
+			// ID唯一，之后的字段不会影响顺序
+			return strings.Join(append(clauses, "id"+direction), ", ")
+		case models.SortByTitle:
+			clauses = append(clauses, "title"+s.dialect.collate+direction)
+		case models.SortByDueDate:
+			clauses = append(clauses, "due_date IS NULL", "due_date"+direction)
+		default:
+			clauses = append(clauses, key.Field+direction)
+		}
+	}
+	return strings.Join(append(clauses, "id ASC"), ", ")
+}
+
+// GetTodoByID 根据ID获取待办事项
+func (s *sqlStore) GetTodoByID(id int) (*models.Todo, error) {
+	return s.getTodo(s.stmt, id)
+}
+
+// getTodo 使用指定的语句根据ID获取待办事项
+func (s *sqlStore) getTodo(stmt stmtFunc, id int) (*models.Todo, error) {
+	todo, err := scanTodo(stmt("get").QueryRow(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTodoNotFound
+	}
+	return todo, err
+}
+
+// CreateTodo 创建新的待办事项
+func (s *sqlStore) CreateTodo(req *models.TodoRequest) (*models.Todo, error) {
+	return s.createTodo(s.stmt, req)
+}
+
+// createTodo 使用指定的语句创建待办事项
+func (s *sqlStore) createTodo(stmt stmtFunc, req *models.TodoRequest) (*models.Todo, error) {
+	now := time.Now()
+	todo := &models.Todo{CreatedAt: now}
+	todo.FromRequest(req)
+	todo.UpdatedAt = now
+
+	links, err := encodeLinks(todo.Links)
+	if err != nil {
+		return nil, err
+	}
+	location, err := encodeLocation(todo.Location)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := encodeTags(todo.Tags)
+	if err != nil {
+		return nil, err
+	}
+	subtasks, err := encodeSubtasks(todo.Subtasks)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{
+		todo.Title, todo.Description, todo.Completed, todo.Priority, todo.Category,
+		s.nullableTime(todo.DueDate), s.dialect.timeValue(todo.CreatedAt), s.dialect.timeValue(todo.UpdatedAt), links, location,
+		s.nullableTime(todo.CompletedAt), todo.Archived, todo.Position, tags, subtasks,
+		s.nullableTime(todo.RemindAt),
+	}
+
+	// 支持 RETURNING 的数据库直接返回新ID，否则通过 LastInsertId 获取
+	if s.dialect.returningID {
+		if err := stmt("insert").QueryRow(args...).Scan(&todo.ID); err != nil {
+			return nil, err
+		}
+		return todo, nil
+	}
+
+	result, err := stmt("insert").Exec(args...)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	todo.ID = int(id)
+	return todo, nil
+}
+
+// UpdateTodo 更新待办事项
+func (s *sqlStore) UpdateTodo(id int, req *models.TodoRequest) (*models.Todo, error) {
+	return s.updateTodo(s.stmt, id, req)
+}
+
+// updateTodo 使用指定的语句更新待办事项
+func (s *sqlStore) updateTodo(stmt stmtFunc, id int, req *models.TodoRequest) (*models.Todo, error) {
+	location, err := encodeLocation(req.Location)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := encodeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求中带有版本号时只更新版本一致的行，没有更新任何行时再区分事项不存在和版本冲突；
+	// 由未完成变为完成时记录完成时间，与 models.Todo.SetCompleted 一致
+	now := s.dialect.timeValue(time.Now())
+	result, err := stmt("update").Exec(
+		req.Completed, now,
+		req.Title, req.Description, req.Completed, req.Priority, req.Category,
+		s.nullableTime(req.DueDate), s.nullableTime(req.RemindAt), now, location, tags, id, req.Version, req.Version,
+	)
+	if err := checkAffected(result, err, ErrTodoNotFound); err != nil {
+		if !errors.Is(err, ErrTodoNotFound) {
+			return nil, err
+		}
+		todo, getErr := s.getTodo(stmt, id)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return nil, versionConflict(todo)
+	}
+
+	return s.getTodo(stmt, id)
+}
+
+// SaveTodo 保存完整的待办事项，ID和创建时间保持不变，更新时间设为当前时间
+func (s *sqlStore) SaveTodo(todo *models.Todo) (*models.Todo, error) {
+	links, err := encodeLinks(todo.Links)
+	if err != nil {
+		return nil, err
+	}
+	location, err := encodeLocation(todo.Location)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := encodeTags(todo.Tags)
+	if err != nil {
+		return nil, err
+	}
+	subtasks, err := encodeSubtasks(todo.Subtasks)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.stmt("save").Exec(
+		todo.Title, todo.Description, todo.Completed, todo.Priority, todo.Category,
+		s.nullableTime(todo.DueDate), s.dialect.timeValue(time.Now()), links, location, s.nullableTime(todo.CompletedAt), todo.Archived, todo.Position, tags, subtasks, s.nullableTime(todo.RemindAt), todo.ID,
+	)
+	if err := checkAffected(result, err, ErrTodoNotFound); err != nil {
+		return nil, err
+	}
+
+	return s.GetTodoByID(todo.ID)
+}
+
+// DeleteTodo 删除待办事项
+func (s *sqlStore) DeleteTodo(id int) error {
+	result, err := s.stmt("delete").Exec(id)
+	return checkAffected(result, err, ErrTodoNotFound)
+}
+
+// BulkCreate 批量创建待办事项，在一个事务中完成
+func (s *sqlStore) BulkCreate(reqs []*models.TodoRequest) ([]*models.Todo, error) {
+	todos := make([]*models.Todo, 0, len(reqs))
+	err := s.inTx(func(stmt stmtFunc) error {
+		for _, req := range reqs {
+			todo, err := s.createTodo(stmt, req)
+			if err != nil {
+				return err
+			}
+			todos = append(todos, todo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// BulkUpdate 批量更新待办事项，在一个事务中完成，任一事项不存在或版本不一致时回滚
+func (s *sqlStore) BulkUpdate(updates []TodoUpdate) ([]*models.Todo, error) {
+	if err := checkUniqueIDs(updateIDs(updates)); err != nil {
+		return nil, err
+	}
+
+	todos := make([]*models.Todo, 0, len(updates))
+	err := s.inTx(func(stmt stmtFunc) error {
+		for _, update := range updates {
+			todo, err := s.updateTodo(stmt, update.ID, update.Request)
+			if errors.Is(err, ErrTodoNotFound) {
+				return fmt.Errorf("%w: %d", ErrTodoNotFound, update.ID)
+			}
+			if err != nil {
+				return err
+			}
+			todos = append(todos, todo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// BulkDelete 批量删除待办事项，在一个事务中完成，任一事项不存在时回滚
+func (s *sqlStore) BulkDelete(ids []int) error {
+	if err := checkUniqueIDs(ids); err != nil {
+		return err
+	}
+
+	return s.inTx(func(stmt stmtFunc) error {
+		for _, id := range ids {
+			result, err := stmt("delete").Exec(id)
+			if err := checkAffected(result, err, ErrTodoNotFound); err != nil {
+				if errors.Is(err, ErrTodoNotFound) {
+					return fmt.Errorf("%w: %d", ErrTodoNotFound, id)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadTodos 按原样写入待办事项，保留ID、时间戳和版本号，在一个事务中完成，任一ID已存在时回滚
+func (s *sqlStore) LoadTodos(todos []*models.Todo) error {
+	return s.inTx(func(stmt stmtFunc) error {
+		for _, todo := range todos {
+			if todo.ID <= 0 {
+				return ErrInvalidID
+			}
+			if _, err := s.getTodo(stmt, todo.ID); err == nil {
+				return fmt.Errorf("%w: %d", ErrDuplicateID, todo.ID)
+			} else if !errors.Is(err, ErrTodoNotFound) {
+				return err
+			}
+
+			links, err := encodeLinks(todo.Links)
+			if err != nil {
+				return err
+			}
+			location, err := encodeLocation(todo.Location)
+			if err != nil {
+				return err
+			}
+			tags, err := encodeTags(todo.Tags)
+			if err != nil {
+				return err
+			}
+			subtasks, err := encodeSubtasks(todo.Subtasks)
+			if err != nil {
+				return err
+			}
+			_, err = stmt("load").Exec(
+				todo.ID, todo.Title, todo.Description, todo.Completed, todo.Priority, todo.Category,
+				s.nullableTime(todo.DueDate), s.dialect.timeValue(todo.CreatedAt), s.dialect.timeValue(todo.UpdatedAt),
+				links, location, todo.Version, s.nullableTime(todo.CompletedAt), todo.Archived, todo.Position, tags, subtasks,
+				s.nullableTime(todo.RemindAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if s.dialect.resetSeq == "" {
+			return nil
+		}
+		_, err := stmt("reset_seq").Exec()
+		return err
+	})
+}
+
+// ReserveIDs 把ID序列推进到之后新建的事项ID不小于next，已经更大时保持不变
+func (s *sqlStore) ReserveIDs(next int) error {
+	if next <= 1 {
+		return nil
+	}
+	return s.begin(func(tx *sqlStore) error {
+		return s.dialect.reserveIDs(tx, next)
+	})
+}
+
+// SearchTodos 搜索待办事项
+// 与内存存储保持一致：标题或描述包含查询字符串，结果按优先级降序、创建时间倒序排列
+func (s *sqlStore) SearchTodos(query string, category string, completed *bool) ([]*models.Todo, error) {
+	var conditions []string
+	var args []interface{}
+
+	if query != "" {
+		pattern := query
+		if s.dialect.pattern != nil {
+			pattern = s.dialect.pattern(query)
+		}
+		conditions = append(conditions, `(`+s.dialect.contains("title")+` OR `+s.dialect.contains("description")+`)`)
+		args = append(args, pattern, pattern)
+	}
+	if category != "" {
+		conditions = append(conditions, `category = ?`)
+		args = append(args, category)
+	}
+	if completed != nil {
+		conditions = append(conditions, `completed = ?`)
+		args = append(args, *completed)
+	}
+
+	sqlQuery := `SELECT ` + todoColumns + ` FROM todos`
+	if len(conditions) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += ` ORDER BY priority DESC, created_at DESC, id DESC`
+
+	rows, err := s.conn.Query(s.rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// GetStats 获取统计信息，统计在SQL中完成，返回结构与内存存储一致
+func (s *sqlStore) GetStats() (map[string]interface{}, error) {
+	var total, completed, pending, overdue int
+	err := s.conn.QueryRow(s.rebind(
+		`SELECT COUNT(*),
+		        COALESCE(SUM(CASE WHEN completed THEN 1 ELSE 0 END), 0),
+		        COALESCE(SUM(CASE WHEN NOT completed THEN 1 ELSE 0 END), 0),
+		        COALESCE(SUM(CASE WHEN NOT completed AND due_date IS NOT NULL AND due_date < ? THEN 1 ELSE 0 END), 0)
+		 FROM todos`),
+		s.dialect.timeValue(time.Now()),
+	).Scan(&total, &completed, &pending, &overdue)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按优先级统计
+	byPriority := make(map[int]int)
+	rows, err := s.conn.Query(`SELECT priority, COUNT(*) FROM todos GROUP BY priority`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var priority, count int
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, err
+		}
+		byPriority[priority] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 按分类统计（忽略空分类）
+	byCategory := make(map[string]int)
+	rows, err = s.conn.Query(`SELECT category, COUNT(*) FROM todos WHERE category <> '' GROUP BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, err
+		}
+		byCategory[category] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total":       total,
+		"completed":   completed,
+		"pending":     pending,
+		"overdue":     overdue,
+		"by_priority": byPriority,
+		"by_category": byCategory,
+	}, nil
+}
+
+// GetMeta 读取附属数据
+func (s *sqlStore) GetMeta(namespace, key string) ([]byte, error) {
+	var value []byte
+	err := s.stmt("meta_get").QueryRow(namespace, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMetaNotFound
+	}
+	return value, err
+}
+
+// PutMeta 写入附属数据（已存在则覆盖）
+func (s *sqlStore) PutMeta(namespace, key string, value []byte) error {
+	_, err := s.stmt("meta_put").Exec(namespace, key, value)
+	return err
+}
+
+// DeleteMeta 删除附属数据
+func (s *sqlStore) DeleteMeta(namespace, key string) error {
+	result, err := s.stmt("meta_delete").Exec(namespace, key)
+	return checkAffected(result, err, ErrMetaNotFound)
+}
+
+// ListMeta 列出命名空间下的所有附属数据
+func (s *sqlStore) ListMeta(namespace string) (map[string][]byte, error) {
+	rows, err := s.stmt("meta_list").Query(namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(map[string][]byte)
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		results[key] = value
+	}
+	return results, rows.Err()
+}
+
+// nullableTime 将时间转换为写入数据库的值，零值时间转换为NULL
+func (s *sqlStore) nullableTime(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return s.dialect.timeValue(*t)
+}
+
+// rowScanner 可以读取一行数据的对象（*sql.Row 和 *sql.Rows 都满足）
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTodos 读取查询结果中的所有待办事项，读取完成后关闭结果集
+func scanTodos(rows *sql.Rows) ([]*models.Todo, error) {
+	defer rows.Close()
+
+	todos := make([]*models.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// scanTodo 读取一行待办事项数据，字段顺序与 todoColumns 一致
+func scanTodo(row rowScanner) (*models.Todo, error) {
+	var todo models.Todo
+	var dueDate, createdAt, updatedAt, completedAt, remindAt sqlTime
+	var links, location, tags, subtasks string
+
+	err := row.Scan(
+		&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.Priority, &todo.Category,
+		&dueDate, &createdAt, &updatedAt, &links, &location, &todo.Version, &completedAt, &todo.Archived, &todo.Position, &tags, &subtasks, &remindAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dueDate.Time.IsZero() {
+		todo.DueDate = &dueDate.Time
+	}
+	if !completedAt.Time.IsZero() {
+		todo.CompletedAt = &completedAt.Time
+	}
+	if !remindAt.Time.IsZero() {
+		todo.RemindAt = &remindAt.Time
+	}
+	todo.CreatedAt = createdAt.Time
+	todo.UpdatedAt = updatedAt.Time
+
+	if links != "" {
+		if err := json.Unmarshal([]byte(links), &todo.Links); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的关联链接失败: %w", todo.ID, err)
+		}
+	}
+	if location != "" {
+		if err := json.Unmarshal([]byte(location), &todo.Location); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的地点失败: %w", todo.ID, err)
+		}
+	}
+	if tags != "" {
+		if err := json.Unmarshal([]byte(tags), &todo.Tags); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的标签失败: %w", todo.ID, err)
+		}
+	}
+	if subtasks != "" {
+		if err := json.Unmarshal([]byte(subtasks), &todo.Subtasks); err != nil {
+			return nil, fmt.Errorf("解析待办事项 %d 的子任务失败: %w", todo.ID, err)
+		}
+	}
+
+	return &todo, nil
+}
+
+// sqlTime 可以从不同数据库的时间表示中读取时间
+// 支持Unix纳秒整数（SQLite）、驱动解析好的 time.Time（PostgreSQL、MySQL）以及文本格式，NULL读取为零值时间
+type sqlTime struct {
+	Time time.Time
+}
+
+// Scan 实现 sql.Scanner 接口
+func (t *sqlTime) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case int64:
+		t.Time = time.Unix(0, value)
+	case time.Time:
+		t.Time = value
+	case []byte:
+		return t.parse(string(value))
+	case string:
+		return t.parse(value)
+	default:
+		return fmt.Errorf("无法将 %T 转换为时间", src)
+	}
+	return nil
+}
+
+// parse 解析文本格式的时间
+func (t *sqlTime) parse(value string) error {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("无法解析时间: %s", value)
+}
+
+// encodeLinks 将关联链接编码为JSON文本，没有关联时为空字符串
+func encodeLinks(links []models.TodoLink) (string, error) {
+	if len(links) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(links)
+	return string(data), err
+}
+
+// encodeTags 将标签编码为JSON数组文本，没有标签时为空字符串
+func encodeTags(tags []string) (string, error) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(tags)
+	return string(data), err
+}
+
+// encodeSubtasks 将子任务编码为JSON数组文本，没有子任务时为空字符串
+func encodeSubtasks(subtasks []models.Subtask) (string, error) {
+	if len(subtasks) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(subtasks)
+	return string(data), err
+}
+
+// encodeLocation 将地点编码为JSON文本，没有地点时为空字符串
+func encodeLocation(location *models.Location) (string, error) {
+	if location == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(location)
+	return string(data), err
+}
+
+// checkAffected 检查写操作是否影响了数据，没有影响任何行时返回 notFound
+func checkAffected(result sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
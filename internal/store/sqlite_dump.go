@@ -0,0 +1,148 @@
+package store
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// WriteSQLiteDump 把快照写成 SQLite 方言的SQL脚本
+// 脚本包含完整的表结构（与 SQLite 存储的数据库结构迁移一致）和数据，可以用 sqlite3 命令行工具
+// 直接导入为 SQLite 存储使用的数据库文件，也可以用 ReadSQLiteDump 读回快照
+func WriteSQLiteDump(w io.Writer, snapshot *models.Snapshot) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "-- xStreamTool 数据备份，导出时间 %s\n", snapshot.ExportedAt.UTC().Format(time.RFC3339))
+	out.WriteString("BEGIN TRANSACTION;\n")
+
+	out.WriteString("CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY);\n")
+	for i, statements := range sqliteDialect.migrations {
+		for _, statement := range statements {
+			out.WriteString(statement + ";\n")
+		}
+		fmt.Fprintf(out, "INSERT INTO schema_migrations (version) VALUES (%d);\n", i+1)
+	}
+
+	for _, todo := range snapshot.Todos {
+		links, err := encodeLinks(todo.Links)
+		if err != nil {
+			return err
+		}
+		location, err := encodeLocation(todo.Location)
+		if err != nil {
+			return err
+		}
+		tags, err := encodeTags(todo.Tags)
+		if err != nil {
+			return err
+		}
+		subtasks, err := encodeSubtasks(todo.Subtasks)
+		if err != nil {
+			return err
+		}
+		dueDate := "NULL"
+		if todo.HasDueDate() {
+			dueDate = strconv.FormatInt(todo.DueDate.UnixNano(), 10)
+		}
+		completed := 0
+		if todo.Completed {
+			completed = 1
+		}
+		archived := 0
+		if todo.Archived {
+			archived = 1
+		}
+		completedAt := "NULL"
+		if todo.CompletedAt != nil {
+			completedAt = strconv.FormatInt(todo.CompletedAt.UnixNano(), 10)
+		}
+		remindAt := "NULL"
+		if todo.RemindAt != nil {
+			remindAt = strconv.FormatInt(todo.RemindAt.UnixNano(), 10)
+		}
+		version := todo.Version
+		if version == 0 {
+			version = 1
+		}
+		fmt.Fprintf(out, "INSERT INTO todos (%s) VALUES (%d, %s, %s, %d, %d, %s, %s, %d, %d, %s, %s, %d, %s, %d, %d, %s, %s, %s);\n",
+			todoColumns, todo.ID, sqliteString(todo.Title), sqliteString(todo.Description), completed, todo.Priority,
+			sqliteString(todo.Category), dueDate, todo.CreatedAt.UnixNano(), todo.UpdatedAt.UnixNano(),
+			sqliteString(links), sqliteString(location), version, completedAt, archived, todo.Position, sqliteString(tags), sqliteString(subtasks), remindAt)
+	}
+
+	// 删除过的最大ID之后也不会被重新分配
+	if nextID := snapshot.NextID; nextID > 1 {
+		fmt.Fprintf(out, "DELETE FROM sqlite_sequence WHERE name = 'todos';\n")
+		fmt.Fprintf(out, "INSERT INTO sqlite_sequence (name, seq) VALUES ('todos', %d);\n", nextID-1)
+	}
+
+	namespaces := make([]string, 0, len(snapshot.Meta))
+	for namespace := range snapshot.Meta {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		items := snapshot.Meta[namespace]
+		keys := make([]string, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// 附属数据的值按原样以BLOB保存
+			fmt.Fprintf(out, "INSERT INTO meta (namespace, key, value) VALUES (%s, %s, X'%s');\n",
+				sqliteString(namespace), sqliteString(key), hex.EncodeToString(items[key]))
+		}
+	}
+
+	out.WriteString("COMMIT;\n")
+	return out.Flush()
+}
+
+// sqliteString 生成SQL字符串字面量，单引号转义为两个单引号
+func sqliteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// ReadSQLiteDump 读取 WriteSQLiteDump 生成的SQL脚本，返回其中的数据
+// 脚本在临时目录中的 SQLite 数据库里执行，读取完成后删除临时数据库
+func ReadSQLiteDump(r io.Reader) (*models.Snapshot, error) {
+	script, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取SQL脚本失败: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "xstream-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "restore.db")
+
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		return nil, fmt.Errorf("打开临时数据库失败: %w", err)
+	}
+	_, err = db.Exec(string(script))
+	db.Close()
+	if err != nil {
+		return nil, fmt.Errorf("执行SQL脚本失败: %w", err)
+	}
+
+	// 脚本中已经记录了数据库结构版本，打开时不会重复执行迁移
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return Dump(s)
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // 纯Go实现的SQLite驱动，无需CGO
+)
+
+// sqliteDialect SQLite 方言
+var sqliteDialect = &sqlDialect{
+	name: "SQLite",
+	migrations: [][]string{
+		// 版本1：待办事项表和附属数据表
+		// AUTOINCREMENT 保证删除后的ID不会被重新分配
+		// 时间字段以Unix纳秒保存，便于直接在SQL中比较大小；没有截止日期时为NULL
+		{
+			`CREATE TABLE todos (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				title       TEXT    NOT NULL,
+				description TEXT    NOT NULL DEFAULT '',
+				completed   INTEGER NOT NULL DEFAULT 0,
+				priority    INTEGER NOT NULL DEFAULT 0,
+				category    TEXT    NOT NULL DEFAULT '',
+				due_date    INTEGER,
+				created_at  INTEGER NOT NULL,
+				updated_at  INTEGER NOT NULL,
+				links       TEXT    NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX idx_todos_category ON todos(category)`,
+			`CREATE INDEX idx_todos_completed ON todos(completed)`,
+			`CREATE TABLE meta (
+				namespace TEXT NOT NULL,
+				key       TEXT NOT NULL,
+				value     BLOB NOT NULL,
+				PRIMARY KEY (namespace, key)
+			)`,
+		},
+		// 版本2：待办事项地点，以JSON文本保存，没有地点时为空字符串
+		{
+			`ALTER TABLE todos ADD COLUMN location TEXT NOT NULL DEFAULT ''`,
+		},
+		// 版本3：分布式锁，多个服务实例共用数据库时保证定时任务只在一个实例上执行
+		{
+			`CREATE TABLE locks (
+				name       TEXT    PRIMARY KEY,
+				owner      TEXT    NOT NULL,
+				expires_at INTEGER NOT NULL
+			)`,
+		},
+		// 版本4：事件发件箱，序号自增且不会被重新分配
+		{
+			`CREATE TABLE events (
+				seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+				type       TEXT    NOT NULL,
+				todo_id    INTEGER NOT NULL,
+				data       TEXT    NOT NULL DEFAULT '',
+				created_at INTEGER NOT NULL
+			)`,
+			`CREATE INDEX idx_events_created_at ON events(created_at)`,
+		},
+		// 版本5：乐观并发控制的版本号，已有数据从版本1开始
+		{
+			`ALTER TABLE todos ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+		// 版本6：完成时间，没有完成或在此之前就已完成时为NULL
+		{
+			`ALTER TABLE todos ADD COLUMN completed_at INTEGER`,
+		},
+		// 版本7：归档标记，已有数据均为未归档
+		{
+			`ALTER TABLE todos ADD COLUMN archived INTEGER NOT NULL DEFAULT 0`,
+		},
+		// 版本8：手动排序的位置，已有数据均为0（按ID排列）
+		{
+			`ALTER TABLE todos ADD COLUMN position INTEGER NOT NULL DEFAULT 0`,
+		},
+		// 版本9：标签，以JSON数组文本保存，没有标签时为空字符串
+		{
+			`ALTER TABLE todos ADD COLUMN tags TEXT NOT NULL DEFAULT ''`,
+		},
+		// 版本10：子任务，以JSON数组文本保存，没有子任务时为空字符串
+		{
+			`ALTER TABLE todos ADD COLUMN subtasks TEXT NOT NULL DEFAULT ''`,
+		},
+		// 版本11：提醒时间，没有提醒时为NULL
+		{
+			`ALTER TABLE todos ADD COLUMN remind_at INTEGER`,
+		},
+	},
+	returningID: true,
+	keyColumn:   "key",
+	upsertMeta: `INSERT INTO meta (namespace, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (namespace, key) DO UPDATE SET value = excluded.value`,
+	insertLock: `INSERT INTO locks (name, owner, expires_at) VALUES (?, ?, ?) ON CONFLICT (name) DO NOTHING`,
+	// instr 按字节匹配子串，与 strings.Contains 的行为一致（区分大小写）
+	contains: func(column string) string {
+		return "instr(" + column + ", ?) > 0"
+	},
+	timeValue: func(t time.Time) interface{} {
+		return t.UnixNano()
+	},
+	// AUTOINCREMENT 表的计数器保存在 sqlite_sequence 中，表中从未写入过数据时还没有对应的行
+	reserveIDs: func(tx *sqlStore, next int) error {
+		result, err := tx.tx.Exec(`UPDATE sqlite_sequence SET seq = MAX(seq, ?) WHERE name = 'todos'`, next-1)
+		if err != nil {
+			return err
+		}
+		if updated, err := result.RowsAffected(); err != nil || updated > 0 {
+			return err
+		}
+		_, err = tx.tx.Exec(`INSERT INTO sqlite_sequence (name, seq) VALUES ('todos', ?)`, next-1)
+		return err
+	},
+}
+
+// SQLiteStore SQLite 存储实现
+// 数据保存在本地的SQLite数据库文件中，无需运行外部数据库服务，重启后数据不会丢失
+type SQLiteStore struct {
+	*sqlStore
+}
+
+// NewSQLiteStore 创建SQLite存储
+// path为数据库文件路径，所在目录不存在时会自动创建；首次运行时自动创建表结构
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+		}
+	}
+
+	// busy_timeout：数据库被锁定时等待而不是立即报错
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开SQLite数据库失败: %w", err)
+	}
+
+	// SQLite 同一时间只允许一个写入者，使用单个连接避免 "database is locked" 错误
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接SQLite数据库失败: %w", err)
+	}
+
+	store, err := newSQLStore(db, sqliteDialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{sqlStore: store}, nil
+}
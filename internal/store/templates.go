@@ -0,0 +1,60 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// NotificationTemplatesNamespace 自定义通知模板在附属数据中的命名空间，键为通知类型
+const NotificationTemplatesNamespace = "notification_templates"
+
+// GetNotificationTemplate 获取通知类型的自定义模板，没有自定义时返回 ErrMetaNotFound
+func GetNotificationTemplate(s MetaStore, name string) (*models.NotificationTemplate, error) {
+	data, err := s.GetMeta(NotificationTemplatesNamespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmpl models.NotificationTemplate
+	if err := json.Unmarshal(data, &tmpl); err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// SaveNotificationTemplate 保存自定义通知模板（已存在则覆盖）
+func SaveNotificationTemplate(s MetaStore, tmpl *models.NotificationTemplate) error {
+	data, err := json.Marshal(tmpl)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(NotificationTemplatesNamespace, tmpl.Name, data)
+}
+
+// DeleteNotificationTemplate 删除自定义通知模板，之后使用默认模板
+func DeleteNotificationTemplate(s MetaStore, name string) error {
+	return s.DeleteMeta(NotificationTemplatesNamespace, name)
+}
+
+// ListNotificationTemplates 列出所有自定义通知模板，按通知类型排序
+func ListNotificationTemplates(s MetaStore) ([]*models.NotificationTemplate, error) {
+	items, err := s.ListMeta(NotificationTemplatesNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.NotificationTemplate, 0, len(items))
+	for _, data := range items {
+		var tmpl models.NotificationTemplate
+		if err := json.Unmarshal(data, &tmpl); err != nil {
+			return nil, err
+		}
+		results = append(results, &tmpl)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// TrashNamespace 回收站在附属数据中的命名空间，键为待办事项ID
+const TrashNamespace = "trash"
+
+// GetTrashedTodo 获取回收站中的待办事项
+// 回收站中没有该事项时返回 ErrMetaNotFound
+func GetTrashedTodo(s MetaStore, id int) (*models.TrashedTodo, error) {
+	data, err := s.GetMeta(TrashNamespace, strconv.Itoa(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var trashed models.TrashedTodo
+	if err := json.Unmarshal(data, &trashed); err != nil {
+		return nil, err
+	}
+	return &trashed, nil
+}
+
+// SaveTrashedTodo 保存回收站中的待办事项（已存在则覆盖）
+func SaveTrashedTodo(s MetaStore, trashed *models.TrashedTodo) error {
+	data, err := json.Marshal(trashed)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(TrashNamespace, strconv.Itoa(trashed.Todo.ID), data)
+}
+
+// DeleteTrashedTodo 从回收站中删除待办事项
+func DeleteTrashedTodo(s MetaStore, id int) error {
+	return s.DeleteMeta(TrashNamespace, strconv.Itoa(id))
+}
+
+// ListTrashedTodos 列出回收站中的待办事项，最先被永久删除的在前
+func ListTrashedTodos(s MetaStore) ([]*models.TrashedTodo, error) {
+	items, err := s.ListMeta(TrashNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.TrashedTodo, 0, len(items))
+	for _, data := range items {
+		var trashed models.TrashedTodo
+		if err := json.Unmarshal(data, &trashed); err != nil {
+			return nil, err
+		}
+		results = append(results, &trashed)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].PurgeAt.Equal(results[j].PurgeAt) {
+			return results[i].PurgeAt.Before(results[j].PurgeAt)
+		}
+		return results[i].Todo.ID < results[j].Todo.ID
+	})
+	return results, nil
+}
+
+// MoveToTrash 在一个事务中把待办事项移入回收站并从存储中删除，保留 retention 后被永久删除
+// 任一事项不存在时返回 ErrTodoNotFound，不做任何修改
+func MoveToTrash(s TodoStore, ids []int, now time.Time, retention time.Duration) error {
+	return s.Transaction(func(tx TodoStore) error {
+		for _, id := range ids {
+			todo, err := tx.GetTodoByID(id)
+			if err != nil {
+				return err
+			}
+			trashed := &models.TrashedTodo{Todo: todo, DeletedAt: now, PurgeAt: now.Add(retention)}
+			if err := SaveTrashedTodo(tx, trashed); err != nil {
+				return err
+			}
+		}
+		if len(ids) == 1 {
+			return tx.DeleteTodo(ids[0])
+		}
+		return tx.BulkDelete(ids)
+	})
+}
+
+// RestoreFromTrash 在一个事务中把回收站中的待办事项按原ID恢复到存储
+// 回收站中没有该事项时返回 ErrMetaNotFound，存储中已有该ID的事项时返回 ErrDuplicateID，
+// 存储不支持按原样写入时返回 ErrLoadUnsupported
+func RestoreFromTrash(s TodoStore, id int) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.Transaction(func(tx TodoStore) error {
+		loader, ok := tx.(Loader)
+		if !ok {
+			return ErrLoadUnsupported
+		}
+		trashed, err := GetTrashedTodo(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.GetTodoByID(id); err == nil {
+			return ErrDuplicateID
+		} else if !errors.Is(err, ErrTodoNotFound) {
+			return err
+		}
+		if err := loader.LoadTodos([]*models.Todo{trashed.Todo}); err != nil {
+			return err
+		}
+		todo = trashed.Todo
+		return DeleteTrashedTodo(tx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// PurgeTrash 永久删除回收站中已超过保留期的待办事项，all 为 true 时清空回收站，返回被删除的事项ID
+func PurgeTrash(s TodoStore, now time.Time, all bool) ([]int, error) {
+	trashed, err := ListTrashedTodos(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []int
+	for _, item := range trashed {
+		if !all && !item.Expired(now) {
+			continue
+		}
+		if err := DeleteTrashedTodo(s, item.Todo.ID); err != nil && !errors.Is(err, ErrMetaNotFound) {
+			return purged, err
+		}
+		purged = append(purged, item.Todo.ID)
+	}
+	return purged, nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// WatchersNamespace 待办事项关注者在附属数据中的命名空间，键为退订令牌
+const WatchersNamespace = "watchers"
+
+// NotificationQuotasNamespace 邮件发送计数在附属数据中的命名空间，键为邮箱地址
+const NotificationQuotasNamespace = "notification_quotas"
+
+// GetWatcher 根据退订令牌获取关注者，不存在（或已退订）时返回 ErrMetaNotFound
+func GetWatcher(s MetaStore, token string) (*models.Watcher, error) {
+	data, err := s.GetMeta(WatchersNamespace, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var watcher models.Watcher
+	if err := json.Unmarshal(data, &watcher); err != nil {
+		return nil, err
+	}
+	return &watcher, nil
+}
+
+// SaveWatcher 保存关注者（已存在则覆盖）
+func SaveWatcher(s MetaStore, watcher *models.Watcher) error {
+	data, err := json.Marshal(watcher)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(WatchersNamespace, watcher.Token, data)
+}
+
+// DeleteWatcher 删除关注者
+func DeleteWatcher(s MetaStore, token string) error {
+	return s.DeleteMeta(WatchersNamespace, token)
+}
+
+// ListWatchers 列出所有关注者，按添加时间排序
+func ListWatchers(s MetaStore) ([]*models.Watcher, error) {
+	items, err := s.ListMeta(WatchersNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.Watcher, 0, len(items))
+	for _, data := range items {
+		var watcher models.Watcher
+		if err := json.Unmarshal(data, &watcher); err != nil {
+			return nil, err
+		}
+		results = append(results, &watcher)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+		return results[i].Token < results[j].Token
+	})
+	return results, nil
+}
+
+// GetNotificationQuota 获取邮箱的发送计数，没有发送过时返回 ErrMetaNotFound
+func GetNotificationQuota(s MetaStore, email string) (*models.NotificationQuota, error) {
+	data, err := s.GetMeta(NotificationQuotasNamespace, email)
+	if err != nil {
+		return nil, err
+	}
+
+	var quota models.NotificationQuota
+	if err := json.Unmarshal(data, &quota); err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// SaveNotificationQuota 保存邮箱的发送计数
+func SaveNotificationQuota(s MetaStore, quota *models.NotificationQuota) error {
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(NotificationQuotasNamespace, quota.Email, data)
+}
+
+// DeleteNotificationQuota 删除邮箱的发送计数
+func DeleteNotificationQuota(s MetaStore, email string) error {
+	return s.DeleteMeta(NotificationQuotasNamespace, email)
+}
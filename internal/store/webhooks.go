@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// WebhooksNamespace webhook 订阅在附属数据中的命名空间，键为订阅ID
+const WebhooksNamespace = "webhooks"
+
+// WebhookDeliveriesNamespace webhook 投递记录在附属数据中的命名空间，键为投递ID
+const WebhookDeliveriesNamespace = "webhook_deliveries"
+
+// GetWebhook 根据ID获取 webhook 订阅，不存在时返回 ErrMetaNotFound
+func GetWebhook(s MetaStore, id string) (*models.Webhook, error) {
+	data, err := s.GetMeta(WebhooksNamespace, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook models.Webhook
+	if err := json.Unmarshal(data, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// SaveWebhook 保存 webhook 订阅（已存在则覆盖）
+func SaveWebhook(s MetaStore, webhook *models.Webhook) error {
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(WebhooksNamespace, webhook.ID, data)
+}
+
+// DeleteWebhook 删除 webhook 订阅
+func DeleteWebhook(s MetaStore, id string) error {
+	return s.DeleteMeta(WebhooksNamespace, id)
+}
+
+// ListWebhooks 列出所有 webhook 订阅，按创建时间排序
+func ListWebhooks(s MetaStore) ([]*models.Webhook, error) {
+	items, err := s.ListMeta(WebhooksNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.Webhook, 0, len(items))
+	for _, data := range items {
+		var webhook models.Webhook
+		if err := json.Unmarshal(data, &webhook); err != nil {
+			return nil, err
+		}
+		results = append(results, &webhook)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// GetWebhookDelivery 根据ID获取投递记录，不存在时返回 ErrMetaNotFound
+func GetWebhookDelivery(s MetaStore, id string) (*models.WebhookDelivery, error) {
+	data, err := s.GetMeta(WebhookDeliveriesNamespace, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery models.WebhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// SaveWebhookDelivery 保存投递记录（已存在则覆盖）
+func SaveWebhookDelivery(s MetaStore, delivery *models.WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return s.PutMeta(WebhookDeliveriesNamespace, delivery.ID, data)
+}
+
+// DeleteWebhookDelivery 删除投递记录
+func DeleteWebhookDelivery(s MetaStore, id string) error {
+	return s.DeleteMeta(WebhookDeliveriesNamespace, id)
+}
+
+// ListWebhookDeliveries 列出投递记录，按事件序号和加入队列的时间排序
+// webhookID 不为空时只列出该订阅的投递记录
+func ListWebhookDeliveries(s MetaStore, webhookID string) ([]*models.WebhookDelivery, error) {
+	items, err := s.ListMeta(WebhookDeliveriesNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.WebhookDelivery, 0, len(items))
+	for _, data := range items {
+		var delivery models.WebhookDelivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return nil, err
+		}
+		if webhookID != "" && delivery.WebhookID != webhookID {
+			continue
+		}
+		results = append(results, &delivery)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].EventSeq != results[j].EventSeq {
+			return results[i].EventSeq < results[j].EventSeq
+		}
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"time"
+
+	"github.com/MGter/xStreamTool_go/internal/models"
+)
+
+// dueSoonWindow 截止日期在此时间内的未完成事项视为即将到期
+const dueSoonWindow = 24 * time.Hour
+
+// dateLayouts 各语言区域的日期和日期时间格式
+var dateLayouts = map[string][2]string{
+	"zh-CN": {"2006-01-02", "2006-01-02 15:04"},
+	"en-US": {"Jan 2, 2006", "Jan 2, 2006 3:04 PM"},
+	"en-GB": {"02/01/2006", "02/01/2006 15:04"},
+	"de-DE": {"02.01.2006", "02.01.2006 15:04"},
+	"ja-JP": {"2006/01/02", "2006/01/02 15:04"},
+}
+
+// priorityLabels 优先级徽章的文字
+var priorityLabels = map[int]string{
+	5: "紧急",
+	4: "高",
+	3: "中",
+	2: "低",
+	1: "最低",
+}
+
+// FuncMap 返回页面模板使用的函数
+// locale 决定日期格式，不支持的语言区域使用 zh-CN：
+//   - formatDate / formatDateTime：按语言区域格式化日期，零值返回空字符串
+//   - priorityBadge：生成带颜色的优先级徽章
+//   - dueClass：根据截止日期返回 overdue（已过期）、due-soon（即将到期）或空字符串，用于高亮事项
+func FuncMap(locale string) template.FuncMap {
+	layouts, exists := dateLayouts[locale]
+	if !exists {
+		if locale != "" {
+			log.Printf("⚠️ 不支持的语言区域 %q，使用 zh-CN", locale)
+		}
+		locale, layouts = "zh-CN", dateLayouts["zh-CN"]
+	}
+
+	return template.FuncMap{
+		"locale": func() string { return locale },
+		"formatDate": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(layouts[0])
+		},
+		"formatDateTime": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(layouts[1])
+		},
+		"priorityBadge": priorityBadge,
+		"dueClass": func(due *time.Time, completed bool) string {
+			return dueClass(due, completed, time.Now())
+		},
+	}
+}
+
+// priorityBadge 生成优先级徽章，样式由 priority-N 类决定
+func priorityBadge(priority models.Priority) template.HTML {
+	label, exists := priorityLabels[int(priority)]
+	if !exists {
+		label = fmt.Sprintf("P%d", priority)
+	}
+	return template.HTML(fmt.Sprintf(`<span class="badge priority-%d" title="优先级 %d">%s</span>`,
+		priority, priority, template.HTMLEscapeString(label)))
+}
+
+// dueClass 根据截止日期返回高亮样式类，due 为nil表示没有截止日期
+func dueClass(due *time.Time, completed bool, now time.Time) string {
+	switch {
+	case completed || due == nil || due.IsZero():
+		return ""
+	case due.Before(now):
+		return "overdue"
+	case due.Sub(now) <= dueSoonWindow:
+		return "due-soon"
+	default:
+		return ""
+	}
+}
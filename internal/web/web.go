@@ -0,0 +1,165 @@
+// Package web 负责渲染网页界面
+// 页面模板和样式表嵌入在程序中，主题、日期格式和品牌由配置文件的 ui 部分决定
+package web
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/MGter/xStreamTool_go/internal/config"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// pages 可渲染的页面，每个页面对应 templates 目录下的同名模板文件
+var pages = []string{"home", "todos", "docs", "shared", "unsubscribe"}
+
+// colorPattern 允许的主色调格式（十六进制颜色）
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Renderer 页面渲染器
+type Renderer struct {
+	pages map[string]*template.Template
+}
+
+// NewRenderer 根据界面配置创建页面渲染器
+// 配置中的主题、语言区域或主色调无效，或自定义样式表读取失败时，记录警告并使用默认值，保证页面始终可用
+func NewRenderer(cfg config.UIConfig) *Renderer {
+	theme, exists := themes[cfg.Theme]
+	if !exists {
+		if cfg.Theme != "" {
+			log.Printf("⚠️ 未知的界面主题 %q，使用 light", cfg.Theme)
+		}
+		cfg.Theme, theme = "light", themes["light"]
+	}
+	if cfg.AccentColor != "" {
+		if colorPattern.MatchString(cfg.AccentColor) {
+			theme.Accent = cfg.AccentColor
+		} else {
+			log.Printf("⚠️ 无效的主色调 %q，使用主题默认值", cfg.AccentColor)
+		}
+	}
+	if cfg.BrandName == "" {
+		cfg.BrandName = "xStreamTool Go"
+	}
+
+	var customCSS string
+	if cfg.CustomCSS != "" {
+		data, err := os.ReadFile(cfg.CustomCSS)
+		if err != nil {
+			log.Printf("⚠️ 读取自定义样式表失败: %v", err)
+		} else {
+			customCSS = string(data)
+		}
+	}
+
+	funcs := FuncMap(cfg.Locale)
+	funcs["brand"] = func() string { return cfg.BrandName }
+	funcs["themeName"] = func() string { return cfg.Theme }
+	funcs["themeVars"] = func() template.CSS { return theme.vars() }
+	// 自定义样式表由部署者提供，视为可信内容
+	funcs["customCSS"] = func() template.CSS { return template.CSS(customCSS) }
+
+	base := template.Must(template.New("").Funcs(funcs).ParseFS(templateFS, "templates/layout.tmpl", "templates/styles.tmpl"))
+
+	r := &Renderer{pages: make(map[string]*template.Template, len(pages))}
+	for _, page := range pages {
+		tmpl := template.Must(base.Clone())
+		r.pages[page] = template.Must(tmpl.ParseFS(templateFS, "templates/"+page+".tmpl"))
+	}
+	return r
+}
+
+// Render 渲染页面并写入响应
+// 先渲染到缓冲区，模板执行失败时不会向客户端输出半个页面
+func (r *Renderer) Render(w http.ResponseWriter, page string, data interface{}) error {
+	tmpl, exists := r.pages[page]
+	if !exists {
+		return fmt.Errorf("页面不存在: %s", page)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Theme 主题配色，以 CSS 变量的形式提供给样式表
+type Theme struct {
+	Background  string // 页面背景
+	Text        string // 正文文字
+	Muted       string // 次要文字
+	Card        string // 卡片背景
+	CardDone    string // 已完成事项的卡片背景
+	Code        string // 代码和输入框背景
+	Border      string // 边框
+	Accent      string // 主色调
+	Success     string // 成功
+	Danger      string // 危险、过期
+	Warning     string // 警告、即将到期
+	Secondary   string // 次要按钮、低优先级
+	ButtonText  string // 按钮文字
+	BadgeNormal string // 普通优先级徽章背景
+}
+
+// themes 内置主题
+var themes = map[string]Theme{
+	"light": {
+		Background:  "#ffffff",
+		Text:        "#333333",
+		Muted:       "#6c757d",
+		Card:        "#f5f5f5",
+		CardDone:    "#e8f5e8",
+		Code:        "#e9ecef",
+		Border:      "#dee2e6",
+		Accent:      "#007bff",
+		Success:     "#28a745",
+		Danger:      "#dc3545",
+		Warning:     "#fd7e14",
+		Secondary:   "#6c757d",
+		ButtonText:  "#ffffff",
+		BadgeNormal: "#17a2b8",
+	},
+	"dark": {
+		Background:  "#1e1f22",
+		Text:        "#e3e3e3",
+		Muted:       "#9aa0a6",
+		Card:        "#2b2d31",
+		CardDone:    "#23382a",
+		Code:        "#383a40",
+		Border:      "#44474d",
+		Accent:      "#4c9aff",
+		Success:     "#3fb950",
+		Danger:      "#f85149",
+		Warning:     "#f0883e",
+		Secondary:   "#6e7681",
+		ButtonText:  "#ffffff",
+		BadgeNormal: "#2f9bb0",
+	},
+}
+
+// vars 生成 :root 中的 CSS 变量声明
+func (t Theme) vars() template.CSS {
+	var b strings.Builder
+	for _, v := range [][2]string{
+		{"bg", t.Background}, {"text", t.Text}, {"muted", t.Muted},
+		{"card", t.Card}, {"card-done", t.CardDone}, {"code", t.Code}, {"border", t.Border},
+		{"accent", t.Accent}, {"success", t.Success}, {"danger", t.Danger}, {"warning", t.Warning},
+		{"secondary", t.Secondary}, {"button-text", t.ButtonText}, {"badge-normal", t.BadgeNormal},
+	} {
+		fmt.Fprintf(&b, "--%s: %s; ", v[0], v[1])
+	}
+	return template.CSS(b.String())
+}